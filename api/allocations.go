@@ -36,6 +36,17 @@ func (a *Allocations) Info(allocID string, q *QueryOptions) (*Allocation, *Query
 	return &resp, qm, nil
 }
 
+// Stats returns the latest resource usage of an allocation and of each of its
+// tasks, as measured by the client running it.
+func (a *Allocations) Stats(allocID string, q *QueryOptions) (*AllocResourceUsage, *QueryMeta, error) {
+	var resp AllocResourceUsage
+	qm, err := a.client.query("/v1/client/allocation/"+allocID+"/stats", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
 // Allocation is used for serialization of allocations.
 type Allocation struct {
 	ID                 string
@@ -52,10 +63,26 @@ type Allocation struct {
 	DesiredDescription string
 	ClientStatus       string
 	ClientDescription  string
+	TaskStates         map[string]*TaskState
+	DeploymentID       string
+	DeploymentStatus   *AllocDeploymentStatus
+	DesiredTransition  DesiredTransition
 	CreateIndex        uint64
 	ModifyIndex        uint64
 }
 
+// DesiredTransition is the transition the servers request of an allocation
+type DesiredTransition struct {
+	Migrate bool
+}
+
+// AllocDeploymentStatus captures the status of the allocation as part of the
+// deployment.
+type AllocDeploymentStatus struct {
+	Canary  bool
+	Healthy *bool
+}
+
 // AllocationMetric is used to deserialize allocation metrics.
 type AllocationMetric struct {
 	NodesEvaluated     int
@@ -83,10 +110,47 @@ type AllocationListStub struct {
 	DesiredDescription string
 	ClientStatus       string
 	ClientDescription  string
+	TaskStates         map[string]*TaskState
 	CreateIndex        uint64
 	ModifyIndex        uint64
 }
 
+// TaskState tracks the current state of a task and the events that caused
+// its state transitions.
+type TaskState struct {
+	State  string
+	Failed bool
+	Events []*TaskEvent
+}
+
+const (
+	TaskDriverFailure          = "Driver Failure"
+	TaskStarted                = "Started"
+	TaskTerminated             = "Terminated"
+	TaskKilled                 = "Killed"
+	TaskRestarting             = "Restarting"
+	TaskNotRestarting          = "Not Restarting"
+	TaskDownloadingArtifacts   = "Downloading Artifacts"
+	TaskArtifactDownloadFailed = "Failed Artifact Download"
+	TaskSetupFailure           = "Setup Failure"
+)
+
+// TaskEvent is an event that effects the state of a task and contains meta-data
+// appropriate to the events type.
+type TaskEvent struct {
+	Type          string
+	Time          int64
+	FailsTask     bool
+	DriverError   string
+	ExitCode      int
+	Signal        int
+	Message       string
+	KillError     string
+	StartDelay    int64
+	DownloadError string
+	SetupError    string
+}
+
 // AllocIndexSort reverse sorts allocs by CreateIndex.
 type AllocIndexSort []*AllocationListStub
 
@@ -101,3 +165,42 @@ func (a AllocIndexSort) Less(i, j int) bool {
 func (a AllocIndexSort) Swap(i, j int) {
 	a[i], a[j] = a[j], a[i]
 }
+
+// MemoryStats holds the memory usage of a task in bytes
+type MemoryStats struct {
+	RSS      uint64
+	Cache    uint64
+	Swap     uint64
+	MaxUsage uint64
+}
+
+// CpuStats holds the CPU usage of a task. The percentages are relative to a
+// single core and TotalTicks is the usage in MHz.
+type CpuStats struct {
+	SystemMode       float64
+	UserMode         float64
+	Percent          float64
+	TotalTicks       float64
+	ThrottledPeriods uint64
+	ThrottledTime    uint64
+}
+
+// ResourceUsage holds the resource usage of a task or allocation
+type ResourceUsage struct {
+	MemoryStats *MemoryStats
+	CpuStats    *CpuStats
+}
+
+// TaskResourceUsage holds a sample of the resource usage of a task
+type TaskResourceUsage struct {
+	ResourceUsage *ResourceUsage
+	Timestamp     int64
+}
+
+// AllocResourceUsage holds the resource usage of an allocation and of each of
+// its running tasks
+type AllocResourceUsage struct {
+	ResourceUsage *ResourceUsage
+	Tasks         map[string]*TaskResourceUsage
+	Timestamp     int64
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -52,6 +53,18 @@ func TestAllocations_List(t *testing.T) {
 	}
 }
 
+func TestAllocations_Stats_UnknownAlloc(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	a := c.Allocations()
+
+	// Querying the stats of an allocation that doesn't exist fails
+	_, _, err := a.Stats("8ba85cef-26cc-40d5-b8fb-a17a1f5db3e6", nil)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestAllocations_CreateIndexSort(t *testing.T) {
 	allocs := []*AllocationListStub{
 		&AllocationListStub{CreateIndex: 2},
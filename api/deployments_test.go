@@ -0,0 +1,85 @@
+package api
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDeployments_List(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	d := c.Deployments()
+
+	// Listing when nothing exists returns empty
+	result, qm, err := d.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if qm.LastIndex != 0 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	if n := len(result); n != 0 {
+		t.Fatalf("expected 0 deployments, got: %d", n)
+	}
+}
+
+func TestDeployments_Info(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	d := c.Deployments()
+
+	// Querying a non-existent deployment returns error
+	_, _, err := d.Info("8E231CF4-CA48-43FF-B694-5801E69E22FA", nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %s", err)
+	}
+}
+
+func TestDeployments_Fail(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	d := c.Deployments()
+
+	// Failing a non-existent deployment returns error
+	_, _, err := d.Fail("8E231CF4-CA48-43FF-B694-5801E69E22FA", nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %s", err)
+	}
+}
+
+func TestDeployments_PromoteGroups(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	d := c.Deployments()
+
+	// Promoting requires at least one group
+	if _, _, err := d.PromoteGroups("8E231CF4-CA48-43FF-B694-5801E69E22FA", nil, nil); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Promoting a non-existent deployment returns error
+	_, _, err := d.PromoteGroups("8E231CF4-CA48-43FF-B694-5801E69E22FA", []string{"web"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %s", err)
+	}
+}
+
+func TestDeployments_Sort(t *testing.T) {
+	deploys := []*Deployment{
+		&Deployment{CreateIndex: 1},
+		&Deployment{CreateIndex: 3},
+		&Deployment{CreateIndex: 2},
+	}
+	sort.Sort(DeploymentIndexSort(deploys))
+
+	expect := []*Deployment{
+		&Deployment{CreateIndex: 3},
+		&Deployment{CreateIndex: 2},
+		&Deployment{CreateIndex: 1},
+	}
+	if !reflect.DeepEqual(deploys, expect) {
+		t.Fatalf("\n\n%#v\n\n%#v", deploys, expect)
+	}
+}
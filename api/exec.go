@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	// execUpgradeProtocol is the protocol the connection of an exec request is
+	// upgraded to in order to stream the command's input and output
+	execUpgradeProtocol = "nomad-exec"
+)
+
+// TerminalSize is the size of a terminal in characters
+type TerminalSize struct {
+	Height uint16
+	Width  uint16
+}
+
+// ExecOptions describes a command to run in the environment of a task and the
+// streams it is attached to.
+type ExecOptions struct {
+	// Task is the name of the task
+	Task string
+
+	// Command is the command to run followed by its arguments
+	Command []string
+
+	// Tty attaches the command to a terminal. Its output is written to
+	// Stdout.
+	Tty bool
+
+	// Stdin is sent to the command until it returns io.EOF. If nil the
+	// command reads from the null device.
+	Stdin io.Reader
+
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ResizeCh sends the size of the terminal when it changes
+	ResizeCh <-chan TerminalSize
+}
+
+// execFrame is a frame of the stream of an exec request
+type execFrame struct {
+	Stdin      []byte        `json:",omitempty"`
+	StdinClose bool          `json:",omitempty"`
+	Resize     *TerminalSize `json:",omitempty"`
+
+	Stdout   []byte `json:",omitempty"`
+	Stderr   []byte `json:",omitempty"`
+	Exited   bool   `json:",omitempty"`
+	ExitCode int    `json:",omitempty"`
+	Error    string `json:",omitempty"`
+}
+
+// Exec runs a command in the environment of a task of an allocation on the
+// client running it. The input and output of the command are streamed until it
+// exits and its exit code is returned.
+func (a *Allocations) Exec(allocID string, opts *ExecOptions, q *QueryOptions) (int, error) {
+	command, err := json.Marshal(opts.Command)
+	if err != nil {
+		return 0, err
+	}
+
+	r := a.client.newRequest("GET", "/v1/client/allocation/"+allocID+"/exec")
+	r.setQueryOptions(q)
+	r.params.Set("task", opts.Task)
+	r.params.Set("command", string(command))
+	r.params.Set("tty", strconv.FormatBool(opts.Tty))
+	req, err := r.toHTTP()
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", execUpgradeProtocol)
+
+	// The connection is dialed directly as it is taken over by the stream
+	// once upgraded
+	host := req.URL.Host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "80")
+	}
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if err := req.Write(conn); err != nil {
+		return 0, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		var buf bytes.Buffer
+		io.Copy(&buf, resp.Body)
+		resp.Body.Close()
+		return 0, fmt.Errorf("Unexpected response code: %d (%s)", resp.StatusCode, buf.Bytes())
+	}
+
+	return execStream(conn, br, opts)
+}
+
+// execStream sends the input and terminal size of the command over the
+// upgraded connection and writes its output until it exits
+func execStream(conn net.Conn, r io.Reader, opts *ExecOptions) (int, error) {
+	enc := json.NewEncoder(conn)
+	var encLock sync.Mutex
+	send := func(frame *execFrame) error {
+		encLock.Lock()
+		defer encLock.Unlock()
+		return enc.Encode(frame)
+	}
+
+	if opts.Stdin != nil {
+		go func() {
+			buf := make([]byte, 4096)
+			for {
+				n, err := opts.Stdin.Read(buf)
+				if n > 0 {
+					if err := send(&execFrame{Stdin: buf[:n]}); err != nil {
+						return
+					}
+				}
+				if err == io.EOF {
+					send(&execFrame{StdinClose: true})
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	if opts.ResizeCh != nil {
+		go func() {
+			for size := range opts.ResizeCh {
+				size := size
+				if err := send(&execFrame{Resize: &size}); err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = ioutil.Discard
+	}
+	if stderr == nil {
+		stderr = ioutil.Discard
+	}
+
+	dec := json.NewDecoder(r)
+	for {
+		var frame execFrame
+		if err := dec.Decode(&frame); err != nil {
+			return 0, fmt.Errorf("exec stream ended before the command exited: %v", err)
+		}
+		if len(frame.Stdout) > 0 {
+			if _, err := stdout.Write(frame.Stdout); err != nil {
+				return 0, err
+			}
+		}
+		if len(frame.Stderr) > 0 {
+			if _, err := stderr.Write(frame.Stderr); err != nil {
+				return 0, err
+			}
+		}
+		if frame.Exited {
+			if frame.Error != "" {
+				return frame.ExitCode, errors.New(frame.Error)
+			}
+			return frame.ExitCode, nil
+		}
+	}
+}
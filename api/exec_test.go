@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestAllocations_Exec_UnknownAlloc(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	a := c.Allocations()
+
+	// Running a command in an allocation that doesn't exist fails
+	opts := &ExecOptions{
+		Task:    "web",
+		Command: []string{"/bin/sh"},
+	}
+	_, err := a.Exec("8ba85cef-26cc-40d5-b8fb-a17a1f5db3e6", opts, nil)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestAllocations_ExecStream(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	// Echo stdin to stdout and exit once stdin is closed
+	go func() {
+		dec := json.NewDecoder(server)
+		enc := json.NewEncoder(server)
+		for {
+			var frame execFrame
+			if err := dec.Decode(&frame); err != nil {
+				return
+			}
+			if len(frame.Stdin) > 0 {
+				enc.Encode(&execFrame{Stdout: frame.Stdin})
+			}
+			if frame.StdinClose {
+				enc.Encode(&execFrame{Stderr: []byte("bye")})
+				enc.Encode(&execFrame{Exited: true, ExitCode: 3})
+				return
+			}
+		}
+	}()
+
+	var stdout, stderr bytes.Buffer
+	opts := &ExecOptions{
+		Stdin:  strings.NewReader("hello"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	code, err := execStream(client, client, opts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if code != 3 {
+		t.Fatalf("bad exit code: %d", code)
+	}
+	if stdout.String() != "hello" || stderr.String() != "bye" {
+		t.Fatalf("bad output: %q %q", stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// LogStdout and LogStderr are the types of logs of a task
+	LogStdout = "stdout"
+	LogStderr = "stderr"
+
+	// OriginStart and OriginEnd are the origins an offset into the logs of a
+	// task is relative to
+	OriginStart = "start"
+	OriginEnd   = "end"
+)
+
+// AllocFS is used to access the files of allocations on the clients
+// running them.
+type AllocFS struct {
+	client *Client
+}
+
+// AllocFS returns a handle on the allocation file system endpoints.
+func (c *Client) AllocFS() *AllocFS {
+	return &AllocFS{client: c}
+}
+
+// LogsOptions selects the logs of a task to read.
+type LogsOptions struct {
+	// Task is the name of the task
+	Task string
+
+	// Type is the type of logs, LogStdout by default
+	Type string
+
+	// Follow keeps streaming new log lines until the reader is closed
+	Follow bool
+
+	// Origin and Offset select the byte the logs are read from
+	Origin string
+	Offset int64
+
+	// Lines, if set, reads the last lines of the logs instead
+	Lines int
+}
+
+// Logs streams the logs of a task of an allocation. The returned reader must
+// be closed by the caller.
+func (a *AllocFS) Logs(allocID string, opts *LogsOptions, q *QueryOptions) (io.ReadCloser, error) {
+	r := a.client.newRequest("GET", "/v1/client/fs/logs/"+allocID)
+	r.setQueryOptions(q)
+	r.params.Set("task", opts.Task)
+	if opts.Type != "" {
+		r.params.Set("type", opts.Type)
+	}
+	if opts.Follow {
+		r.params.Set("follow", "true")
+	}
+	if opts.Origin != "" {
+		r.params.Set("origin", opts.Origin)
+	}
+	if opts.Offset != 0 {
+		r.params.Set("offset", strconv.FormatInt(opts.Offset, 10))
+	}
+	if opts.Lines != 0 {
+		r.params.Set("lines", strconv.Itoa(opts.Lines))
+	}
+
+	_, resp, err := requireOK(a.client.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// AllocFileInfo holds information about a file or directory of an allocation
+type AllocFileInfo struct {
+	Name     string
+	IsDir    bool
+	Size     int64
+	FileMode string
+	ModTime  time.Time
+}
+
+// List lists the files of a directory of an allocation. The path is relative
+// to the allocation directory.
+func (a *AllocFS) List(allocID, path string, q *QueryOptions) ([]*AllocFileInfo, *QueryMeta, error) {
+	var resp []*AllocFileInfo
+	qm, err := a.client.query(a.fsPath("ls", allocID, path), &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// Stat returns information about a file of an allocation.
+func (a *AllocFS) Stat(allocID, path string, q *QueryOptions) (*AllocFileInfo, *QueryMeta, error) {
+	var resp AllocFileInfo
+	qm, err := a.client.query(a.fsPath("stat", allocID, path), &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Cat reads the contents of a file of an allocation. The returned reader must
+// be closed by the caller.
+func (a *AllocFS) Cat(allocID, path string, q *QueryOptions) (io.ReadCloser, error) {
+	return a.read("cat", allocID, path, nil, q)
+}
+
+// ReadAt reads the contents of a file of an allocation starting at the
+// offset. If limit is positive at most limit bytes are read. The returned
+// reader must be closed by the caller.
+func (a *AllocFS) ReadAt(allocID, path string, offset, limit int64, q *QueryOptions) (io.ReadCloser, error) {
+	params := map[string]string{
+		"offset": strconv.FormatInt(offset, 10),
+		"limit":  strconv.FormatInt(limit, 10),
+	}
+	return a.read("readat", allocID, path, params, q)
+}
+
+// Stream streams the contents of a file of an allocation starting at the
+// offset, following the data appended to the file until the reader is
+// closed.
+func (a *AllocFS) Stream(allocID, path string, offset int64, q *QueryOptions) (io.ReadCloser, error) {
+	params := map[string]string{
+		"offset": strconv.FormatInt(offset, 10),
+	}
+	return a.read("stream", allocID, path, params, q)
+}
+
+// fsPath returns the endpoint of a file system operation on a path
+func (a *AllocFS) fsPath(op, allocID, path string) string {
+	return "/v1/client/fs/" + op + "/" + allocID + "?path=" + url.QueryEscape(path)
+}
+
+// read issues a file system request returning the raw response body
+func (a *AllocFS) read(op, allocID, path string, params map[string]string, q *QueryOptions) (io.ReadCloser, error) {
+	r := a.client.newRequest("GET", "/v1/client/fs/"+op+"/"+allocID)
+	r.setQueryOptions(q)
+	r.params.Set("path", path)
+	for k, v := range params {
+		r.params.Set(k, v)
+	}
+
+	_, resp, err := requireOK(a.client.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
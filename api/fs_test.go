@@ -0,0 +1,39 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllocFS_Logs_UnknownAlloc(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	fs := c.AllocFS()
+
+	// Reading the logs of an allocation that doesn't exist fails
+	_, err := fs.Logs("8ba85cef-26cc-40d5-b8fb-a17a1f5db3e6", &LogsOptions{Task: "web"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestAllocFS_UnknownAlloc(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	fs := c.AllocFS()
+	allocID := "8ba85cef-26cc-40d5-b8fb-a17a1f5db3e6"
+
+	// Accessing the files of an allocation that doesn't exist fails
+	if _, _, err := fs.List(allocID, "/", nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := fs.Stat(allocID, "alloc", nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := fs.Cat(allocID, "alloc/logs/web.stdout.0", nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := fs.ReadAt(allocID, "alloc/logs/web.stdout.0", 1, 2, nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err: %v", err)
+	}
+}
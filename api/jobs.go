@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"sort"
 	"time"
 )
@@ -26,9 +27,27 @@ func (c *Client) Jobs() *Jobs {
 // Register is used to register a new job. It returns the ID
 // of the evaluation, along with any errors encountered.
 func (j *Jobs) Register(job *Job, q *WriteOptions) (string, *WriteMeta, error) {
+	return j.register(job, 0, false, q)
+}
+
+// EnforceRegister is used to register a job enforcing its job modify index.
+// The job is only registered if the current job's modify index matches the
+// passed index. A modify index of zero requires that the job does not exist.
+func (j *Jobs) EnforceRegister(job *Job, modifyIndex uint64, q *WriteOptions) (string, *WriteMeta, error) {
+	return j.register(job, modifyIndex, true, q)
+}
+
+// register is used to register a job, optionally enforcing the job modify
+// index.
+func (j *Jobs) register(job *Job, modifyIndex uint64, enforce bool,
+	q *WriteOptions) (string, *WriteMeta, error) {
 	var resp registerJobResponse
 
-	req := &registerJobRequest{job}
+	req := &registerJobRequest{
+		Job:            job,
+		EnforceIndex:   enforce,
+		JobModifyIndex: modifyIndex,
+	}
 	wm, err := j.client.write("/v1/jobs", req, &resp, q)
 	if err != nil {
 		return "", nil, err
@@ -101,27 +120,127 @@ func (j *Jobs) ForceEvaluate(jobID string, q *WriteOptions) (string, *WriteMeta,
 	return resp.EvalID, wm, nil
 }
 
+// PeriodicForce spawns a new instance of the periodic job and returns the eval ID
+func (j *Jobs) PeriodicForce(jobID string, q *WriteOptions) (string, *WriteMeta, error) {
+	var resp periodicForceResponse
+	wm, err := j.client.write("/v1/job/"+jobID+"/periodic/force", nil, &resp, q)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.EvalID, wm, nil
+}
+
+// Dispatch is used to dispatch a new instance of a parameterized job with
+// the given meta data and payload.
+func (j *Jobs) Dispatch(jobID string, meta map[string]string,
+	payload []byte, q *WriteOptions) (*JobDispatchResponse, *WriteMeta, error) {
+	var resp JobDispatchResponse
+	req := &JobDispatchRequest{
+		JobID:   jobID,
+		Meta:    meta,
+		Payload: payload,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/dispatch", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Versions is used to retrieve all the tracked versions of a job, sorted
+// from newest to oldest.
+func (j *Jobs) Versions(jobID string, q *QueryOptions) ([]*Job, *QueryMeta, error) {
+	var resp []*Job
+	qm, err := j.client.query("/v1/job/"+jobID+"/versions", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// Revert is used to revert the given job to the passed version and returns
+// the ID of the evaluation created.
+func (j *Jobs) Revert(jobID string, version uint64, q *WriteOptions) (string, *WriteMeta, error) {
+	var resp registerJobResponse
+	req := &jobRevertRequest{
+		JobID:      jobID,
+		JobVersion: version,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/revert", req, &resp, q)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.EvalID, wm, nil
+}
+
+// Plan is used to invoke a dry-run of a job registration. If diff is set,
+// the response contains a diff of the submitted job against the existing one.
+func (j *Jobs) Plan(job *Job, diff bool, q *WriteOptions) (*JobPlanResponse, *WriteMeta, error) {
+	if job == nil {
+		return nil, nil, fmt.Errorf("must pass non-nil job")
+	}
+
+	var resp JobPlanResponse
+	req := &JobPlanRequest{
+		Job:  job,
+		Diff: diff,
+	}
+	wm, err := j.client.write("/v1/job/"+job.ID+"/plan", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 //UpdateStrategy is for serializing update strategy for a job.
 type UpdateStrategy struct {
-	Stagger     time.Duration
-	MaxParallel int
+	Stagger         time.Duration
+	MaxParallel     int
+	Canary          int
+	MinHealthyTime  time.Duration
+	HealthyDeadline time.Duration
+	AutoRevert      bool
+}
+
+// PeriodicConfig is for serializing periodic config for a job.
+type PeriodicConfig struct {
+	Enabled         bool
+	Spec            string
+	SpecType        string
+	ProhibitOverlap bool
+	TimeZone        string
+}
+
+// ParameterizedJobConfig is used to configure a parameterized job.
+type ParameterizedJobConfig struct {
+	Payload      string
+	MetaRequired []string
+	MetaOptional []string
 }
 
 // Job is used to serialize a job.
 type Job struct {
 	Region            string
 	ID                string
+	ParentID          string
 	Name              string
 	Type              string
 	Priority          int
 	AllAtOnce         bool
 	Datacenters       []string
 	Constraints       []*Constraint
+	Spreads           []*Spread
 	TaskGroups        []*TaskGroup
 	Update            *UpdateStrategy
+	Periodic          *PeriodicConfig
+	ParameterizedJob  *ParameterizedJobConfig
+	Payload           []byte
 	Meta              map[string]string
 	Status            string
 	StatusDescription string
+	Version           uint64
+	Stable            bool
+	SubmitTime        int64
 	CreateIndex       uint64
 	ModifyIndex       uint64
 }
@@ -130,6 +249,7 @@ type Job struct {
 // jobs during list operations.
 type JobListStub struct {
 	ID                string
+	ParentID          string
 	Name              string
 	Type              string
 	Priority          int
@@ -200,6 +320,12 @@ func (j *Job) Constrain(c *Constraint) *Job {
 	return j
 }
 
+// AddSpread is used to add a spread to a job.
+func (j *Job) AddSpread(s *Spread) *Job {
+	j.Spreads = append(j.Spreads, s)
+	return j
+}
+
 // AddTaskGroup adds a task group to an existing job.
 func (j *Job) AddTaskGroup(grp *TaskGroup) *Job {
 	j.TaskGroups = append(j.TaskGroups, grp)
@@ -208,7 +334,9 @@ func (j *Job) AddTaskGroup(grp *TaskGroup) *Job {
 
 // registerJobRequest is used to serialize a job registration
 type registerJobRequest struct {
-	Job *Job
+	Job            *Job
+	EnforceIndex   bool
+	JobModifyIndex uint64
 }
 
 // registerJobResponse is used to deserialize a job response
@@ -216,7 +344,110 @@ type registerJobResponse struct {
 	EvalID string
 }
 
+// jobRevertRequest is used to revert a job to a prior version
+type jobRevertRequest struct {
+	JobID      string
+	JobVersion uint64
+}
+
+// periodicForceResponse is used to decode a periodic force response
+type periodicForceResponse struct {
+	EvalID string
+}
+
+// JobDispatchRequest is used to dispatch a parameterized job.
+type JobDispatchRequest struct {
+	JobID   string
+	Payload []byte
+	Meta    map[string]string
+}
+
+// JobDispatchResponse is used to decode a dispatch response.
+type JobDispatchResponse struct {
+	DispatchedJobID string
+	EvalID          string
+	EvalCreateIndex uint64
+	JobCreateIndex  uint64
+}
+
 // deregisterJobResponse is used to decode a deregister response
 type deregisterJobResponse struct {
 	EvalID string
 }
+
+// JobPlanRequest is used to plan a job registration.
+type JobPlanRequest struct {
+	Job  *Job
+	Diff bool
+}
+
+// JobPlanResponse is used to decode the results of a job plan.
+type JobPlanResponse struct {
+	JobModifyIndex     uint64
+	CreatedEvals       []*Evaluation
+	Diff               *JobDiff
+	Annotations        *PlanAnnotations
+	FailedAllocs       []*Allocation
+	NextPeriodicLaunch time.Time
+}
+
+// JobDiff is the diff of two versions of a job.
+type JobDiff struct {
+	Type       string
+	ID         string
+	Fields     []*FieldDiff
+	Objects    []*ObjectDiff
+	TaskGroups []*TaskGroupDiff
+}
+
+// TaskGroupDiff is the diff of two versions of a task group.
+type TaskGroupDiff struct {
+	Type    string
+	Name    string
+	Fields  []*FieldDiff
+	Objects []*ObjectDiff
+	Tasks   []*TaskDiff
+	Updates map[string]uint64
+}
+
+// TaskDiff is the diff of two versions of a task.
+type TaskDiff struct {
+	Type        string
+	Name        string
+	Fields      []*FieldDiff
+	Objects     []*ObjectDiff
+	Annotations []string
+}
+
+// FieldDiff is the diff of a single field.
+type FieldDiff struct {
+	Type        string
+	Name        string
+	Old, New    string
+	Annotations []string
+}
+
+// ObjectDiff is the diff of an object nested in a job.
+type ObjectDiff struct {
+	Type    string
+	Name    string
+	Fields  []*FieldDiff
+	Objects []*ObjectDiff
+}
+
+// PlanAnnotations holds the annotations made by the scheduler.
+type PlanAnnotations struct {
+	DesiredTGUpdates map[string]*DesiredUpdates
+}
+
+// DesiredUpdates is the number of changes of each type the scheduler would
+// make to a task group.
+type DesiredUpdates struct {
+	Ignore            uint64
+	Place             uint64
+	Migrate           uint64
+	Stop              uint64
+	InPlaceUpdate     uint64
+	DestructiveUpdate uint64
+	Canary            uint64
+}
@@ -48,6 +48,73 @@ func TestJobs_Register(t *testing.T) {
 	}
 }
 
+func TestJobs_EnforceRegister(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Listing jobs before registering returns nothing
+	resp, qm, err := jobs.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if qm.LastIndex != 0 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	if n := len(resp); n != 0 {
+		t.Fatalf("expected 0 jobs, got: %d", n)
+	}
+
+	// Create a job and attempt to register it with an incorrect index.
+	job := testJob()
+	eval, wm, err := jobs.EnforceRegister(job, 10, nil)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected enforcement error: %v", err)
+	}
+
+	// Register
+	eval, wm, err = jobs.EnforceRegister(job, 0, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if eval == "" {
+		t.Fatalf("missing eval id")
+	}
+	assertWriteMeta(t, wm)
+
+	// Query the jobs back out again
+	resp, qm, err = jobs.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+
+	// Check that we got the expected response
+	if len(resp) != 1 {
+		t.Fatalf("bad length: %d", len(resp))
+	}
+	if resp[0].ID != job.ID {
+		t.Fatalf("bad: %#v", resp[0])
+	}
+	curIndex := resp[0].ModifyIndex
+
+	// Fail at incorrect index
+	eval, wm, err = jobs.EnforceRegister(job, 123456, nil)
+	if err == nil || !strings.Contains(err.Error(), "conflicting job modify index") {
+		t.Fatalf("expected enforcement error: %v", err)
+	}
+
+	// Works at correct index
+	eval, wm, err = jobs.EnforceRegister(job, curIndex, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if eval == "" {
+		t.Fatalf("missing eval id")
+	}
+	assertWriteMeta(t, wm)
+}
+
 func TestJobs_Info(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -221,6 +288,55 @@ func TestJobs_ForceEvaluate(t *testing.T) {
 	t.Fatalf("evaluation %q missing", evalID)
 }
 
+func TestJobs_PeriodicForce(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Force-eval on a non-existent job fails
+	_, _, err := jobs.PeriodicForce("job1", nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %#v", err)
+	}
+
+	// Create a new periodic job
+	job := testJob()
+	job.Periodic = &PeriodicConfig{
+		Enabled:  true,
+		Spec:     "*/30 * * * *",
+		SpecType: "cron",
+	}
+	_, wm, err := jobs.Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Try force again
+	evalID, wm, err := jobs.PeriodicForce("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	if evalID == "" {
+		t.Fatalf("empty eval ID")
+	}
+
+	// The eval belongs to a job derived from the periodic job
+	result, qm, err := jobs.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	for _, stub := range result {
+		if stub.ParentID == "job1" {
+			return
+		}
+	}
+	t.Fatalf("derived job missing: %#v", result)
+}
+
 func TestJobs_NewBatchJob(t *testing.T) {
 	job := NewBatchJob("job1", "myjob", "region1", 5)
 	expect := &Job{
@@ -321,3 +437,175 @@ func TestJobs_Sort(t *testing.T) {
 		t.Fatalf("\n\n%#v\n\n%#v", jobs, expect)
 	}
 }
+
+func TestJobs_Dispatch(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Dispatching a non-existent job fails
+	_, _, err := jobs.Dispatch("job1", nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %#v", err)
+	}
+
+	// Create a new parameterized job
+	job := testJob()
+	job.ParameterizedJob = &ParameterizedJobConfig{
+		Payload:      "required",
+		MetaRequired: []string{"foo"},
+	}
+	_, wm, err := jobs.Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Dispatch it
+	meta := map[string]string{"foo": "bar"}
+	resp, wm, err := jobs.Dispatch("job1", meta, []byte("hello"), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if resp.EvalID == "" || resp.DispatchedJobID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The dispatched job carries the payload and meta data
+	out, qm, err := jobs.Info(resp.DispatchedJobID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if out.ParentID != "job1" || string(out.Payload) != "hello" || out.Meta["foo"] != "bar" {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestJobs_Versions(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Trying to retrieve the versions of a job before it exists errors
+	_, _, err := jobs.Versions("job1", nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %#v", err)
+	}
+
+	// Register the job
+	job := testJob()
+	_, wm, err := jobs.Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Query the versions of the job
+	result, qm, err := jobs.Versions("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+
+	// Check that we got the first version
+	if len(result) != 1 || result[0].Version != 0 || result[0].ID != "job1" {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
+func TestJobs_Revert(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Register twice to create two versions
+	job := testJob()
+	original := job.Priority
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	job.Priority = 99
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Fail reverting to the current version
+	_, _, err := jobs.Revert("job1", 1, nil)
+	if err == nil || !strings.Contains(err.Error(), "current version") {
+		t.Fatalf("expected current version error, got: %#v", err)
+	}
+
+	// Revert to the first version
+	evalID, wm, err := jobs.Revert("job1", 0, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if evalID == "" {
+		t.Fatalf("missing eval ID")
+	}
+
+	// The job is now at a new version with the original priority
+	out, _, err := jobs.Info("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.Version != 2 || out.Priority != original {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestJobs_Plan(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Create a job and attempt to register it
+	job := testJob()
+	eval, wm, err := jobs.Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if eval == "" {
+		t.Fatalf("missing eval id")
+	}
+	assertWriteMeta(t, wm)
+
+	// Check that passing a nil job fails
+	if _, _, err := jobs.Plan(nil, true, nil); err == nil {
+		t.Fatalf("expect an error when job isn't provided")
+	}
+
+	// Make a plan request
+	planResp, wm, err := jobs.Plan(job, true, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if planResp == nil {
+		t.Fatalf("nil response")
+	}
+	assertWriteMeta(t, wm)
+
+	if planResp.JobModifyIndex == 0 {
+		t.Fatalf("bad JobModifyIndex value: %#v", planResp)
+	}
+	if planResp.Diff == nil {
+		t.Fatalf("got nil diff: %#v", planResp)
+	}
+	if planResp.Annotations == nil {
+		t.Fatalf("got nil annotations: %#v", planResp)
+	}
+
+	// Make a plan request w/o the diff
+	planResp, wm, err = jobs.Plan(job, false, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	if planResp.Diff != nil {
+		t.Fatalf("got non-nil diff: %#v", planResp)
+	}
+}
@@ -1,8 +1,17 @@
 package api
 
 import (
+	"net/url"
 	"sort"
 	"strconv"
+	"time"
+)
+
+const (
+	// NodeSchedulingEligible and NodeSchedulingIneligible mark whether new
+	// allocations may be placed on a node
+	NodeSchedulingEligible   = "eligible"
+	NodeSchedulingIneligible = "ineligible"
 )
 
 // Nodes is used to query node-related API endpoints
@@ -46,6 +55,22 @@ func (n *Nodes) ToggleDrain(nodeID string, drain bool, q *WriteOptions) (*WriteM
 	return wm, nil
 }
 
+// UpdateDrain is used to drain a given node according to the spec. A nil
+// spec disables the drain.
+func (n *Nodes) UpdateDrain(nodeID string, spec *DrainSpec, q *WriteOptions) (*WriteMeta, error) {
+	v := url.Values{}
+	v.Set("enable", strconv.FormatBool(spec != nil))
+	if spec != nil {
+		v.Set("deadline", spec.Deadline.String())
+		v.Set("ignore_system_jobs", strconv.FormatBool(spec.IgnoreSystemJobs))
+	}
+	wm, err := n.client.write("/v1/node/"+nodeID+"/drain?"+v.Encode(), nil, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
 // Allocations is used to return the allocations associated with a node.
 func (n *Nodes) Allocations(nodeID string, q *QueryOptions) ([]*Allocation, *QueryMeta, error) {
 	var resp []*Allocation
@@ -57,6 +82,17 @@ func (n *Nodes) Allocations(nodeID string, q *QueryOptions) ([]*Allocation, *Que
 	return resp, qm, nil
 }
 
+// Stats returns the latest resource usage of the host of a node, as measured
+// by its client.
+func (n *Nodes) Stats(nodeID string, q *QueryOptions) (*HostStats, *QueryMeta, error) {
+	var resp HostStats
+	qm, err := n.client.query("/v1/client/stats?node_id="+nodeID, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
 // ForceEvaluate is used to force-evaluate an existing node.
 func (n *Nodes) ForceEvaluate(nodeID string, q *WriteOptions) (string, *WriteMeta, error) {
 	var resp nodeEvalResponse
@@ -67,35 +103,78 @@ func (n *Nodes) ForceEvaluate(nodeID string, q *WriteOptions) (string, *WriteMet
 	return resp.EvalID, wm, nil
 }
 
+// ToggleEligibility is used to toggle whether new allocations may be placed
+// on a given node. The existing allocations of the node keep running.
+func (n *Nodes) ToggleEligibility(nodeID string, eligible bool, q *WriteOptions) (*WriteMeta, error) {
+	eligibleArg := strconv.FormatBool(eligible)
+	wm, err := n.client.write("/v1/node/"+nodeID+"/eligibility?enable="+eligibleArg, nil, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
 // Node is used to deserialize a node entry.
 type Node struct {
-	ID                string
-	Datacenter        string
-	Name              string
-	Attributes        map[string]string
-	Resources         *Resources
-	Reserved          *Resources
-	Links             map[string]string
-	NodeClass         string
-	Drain             bool
-	Status            string
-	StatusDescription string
-	CreateIndex       uint64
-	ModifyIndex       uint64
+	ID                    string
+	Datacenter            string
+	Name                  string
+	HTTPAddr              string
+	Attributes            map[string]string
+	Resources             *Resources
+	Reserved              *Resources
+	Links                 map[string]string
+	NodeClass             string
+	HostVolumes           map[string]*HostVolumeInfo
+	Drain                 bool
+	DrainStrategy         *DrainStrategy
+	SchedulingEligibility string
+	Status                string
+	StatusDescription     string
+	CreateIndex           uint64
+	ModifyIndex           uint64
+}
+
+// DrainSpec is the drain requested of a node
+type DrainSpec struct {
+	// Deadline is the time after which the remaining allocations are
+	// stopped. A zero deadline waits for the allocations to migrate and a
+	// negative deadline stops them immediately.
+	Deadline time.Duration
+
+	// IgnoreSystemJobs leaves the allocations of system jobs running
+	IgnoreSystemJobs bool
+}
+
+// DrainStrategy is the drain of a node that is in progress
+type DrainStrategy struct {
+	DrainSpec
+
+	// ForceDeadline is the time the remaining allocations are stopped. It is
+	// zero if the drain has no deadline.
+	ForceDeadline time.Time
+}
+
+// HostVolumeInfo is a host path a node offers to be mounted into tasks
+type HostVolumeInfo struct {
+	Name     string
+	Path     string
+	ReadOnly bool
 }
 
 // NodeListStub is a subset of information returned during
 // node list operations.
 type NodeListStub struct {
-	ID                string
-	Datacenter        string
-	Name              string
-	NodeClass         string
-	Drain             bool
-	Status            string
-	StatusDescription string
-	CreateIndex       uint64
-	ModifyIndex       uint64
+	ID                    string
+	Datacenter            string
+	Name                  string
+	NodeClass             string
+	Drain                 bool
+	SchedulingEligibility string
+	Status                string
+	StatusDescription     string
+	CreateIndex           uint64
+	ModifyIndex           uint64
 }
 
 // NodeIndexSort reverse sorts nodes by CreateIndex
@@ -132,3 +211,40 @@ func (a AllocationSort) Less(i, j int) bool {
 func (a AllocationSort) Swap(i, j int) {
 	a[i], a[j] = a[j], a[i]
 }
+
+// HostStats holds a sample of the resource usage of the host of a node
+type HostStats struct {
+	Memory    *HostMemoryStats
+	CPU       []*HostCPUStats
+	DiskStats []*HostDiskStats
+	Uptime    uint64
+	Timestamp int64
+}
+
+// HostMemoryStats holds the memory usage of a host in bytes
+type HostMemoryStats struct {
+	Total     uint64
+	Available uint64
+	Used      uint64
+	Free      uint64
+}
+
+// HostCPUStats holds the usage of a core as percentages of its time
+type HostCPUStats struct {
+	CPU    string
+	User   float64
+	System float64
+	Idle   float64
+	Total  float64
+}
+
+// HostDiskStats holds the usage of a mount of the alloc dir of a client
+type HostDiskStats struct {
+	Device            string
+	Mountpoint        string
+	Size              uint64
+	Used              uint64
+	Available         uint64
+	UsedPercent       float64
+	InodesUsedPercent float64
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/testutil"
 )
@@ -148,6 +149,129 @@ func TestNodes_ToggleDrain(t *testing.T) {
 	}
 }
 
+func TestNodes_ToggleEligibility(t *testing.T) {
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.DevMode = true
+	})
+	defer s.Stop()
+	nodes := c.Nodes()
+
+	// Wait for node registration and get the ID
+	var nodeID string
+	testutil.WaitForResult(func() (bool, error) {
+		out, _, err := nodes.List(nil)
+		if err != nil {
+			return false, err
+		}
+		if n := len(out); n != 1 {
+			return false, fmt.Errorf("expected 1 node, got: %d", n)
+		}
+		nodeID = out[0].ID
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	// Check for eligibility
+	out, _, err := nodes.Info(nodeID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.SchedulingEligibility != NodeSchedulingEligible {
+		t.Fatalf("node should be eligible")
+	}
+
+	// Toggle it off
+	wm, err := nodes.ToggleEligibility(nodeID, false, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Check again
+	out, _, err = nodes.Info(nodeID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.SchedulingEligibility != NodeSchedulingIneligible {
+		t.Fatalf("node should be ineligible")
+	}
+
+	// Toggle on again
+	wm, err = nodes.ToggleEligibility(nodeID, true, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Check again
+	out, _, err = nodes.Info(nodeID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.SchedulingEligibility != NodeSchedulingEligible {
+		t.Fatalf("node should be eligible")
+	}
+}
+
+func TestNodes_UpdateDrain(t *testing.T) {
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.DevMode = true
+	})
+	defer s.Stop()
+	nodes := c.Nodes()
+
+	// Wait for node registration and get the ID
+	var nodeID string
+	testutil.WaitForResult(func() (bool, error) {
+		out, _, err := nodes.List(nil)
+		if err != nil {
+			return false, err
+		}
+		if n := len(out); n != 1 {
+			return false, fmt.Errorf("expected 1 node, got: %d", n)
+		}
+		nodeID = out[0].ID
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	// Drain the node with a deadline
+	spec := &DrainSpec{Deadline: time.Hour, IgnoreSystemJobs: true}
+	wm, err := nodes.UpdateDrain(nodeID, spec, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// The drain of the node without allocations completes
+	testutil.WaitForResult(func() (bool, error) {
+		out, _, err := nodes.Info(nodeID, nil)
+		if err != nil {
+			return false, err
+		}
+		if !out.Drain || out.DrainStrategy != nil {
+			return false, fmt.Errorf("drain not complete: %#v", out)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	// Disable the drain
+	if _, err := nodes.UpdateDrain(nodeID, nil, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out, _, err := nodes.Info(nodeID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.Drain {
+		t.Fatalf("drain mode should be off")
+	}
+}
+
 func TestNodes_Allocations(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -166,6 +290,18 @@ func TestNodes_Allocations(t *testing.T) {
 	}
 }
 
+func TestNodes_Stats_UnknownNode(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	nodes := c.Nodes()
+
+	// Querying the stats of a node that doesn't exist fails
+	_, _, err := nodes.Stats("8ba85cef-26cc-40d5-b8fb-a17a1f5db3e6", nil)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestNodes_ForceEvaluate(t *testing.T) {
 	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
 		c.DevMode = true
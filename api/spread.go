@@ -0,0 +1,32 @@
+package api
+
+// Spread is used to serialize a job placement spread.
+type Spread struct {
+	Attribute string
+	Weight    int
+	Targets   []*SpreadTarget
+}
+
+// SpreadTarget is used to serialize the desired percentage of a spread
+// attribute value.
+type SpreadTarget struct {
+	Value   string
+	Percent int
+}
+
+// NewSpread generates a new job placement spread.
+func NewSpread(attribute string, weight int, targets []*SpreadTarget) *Spread {
+	return &Spread{
+		Attribute: attribute,
+		Weight:    weight,
+		Targets:   targets,
+	}
+}
+
+// NewSpreadTarget generates a new spread target.
+func NewSpreadTarget(value string, percent int) *SpreadTarget {
+	return &SpreadTarget{
+		Value:   value,
+		Percent: percent,
+	}
+}
@@ -0,0 +1,19 @@
+package api
+
+// System is used to query the system-related endpoints.
+type System struct {
+	client *Client
+}
+
+// System returns a handle on the system endpoints.
+func (c *Client) System() *System {
+	return &System{client: c}
+}
+
+// GarbageCollect is used to force the servers to immediately garbage collect
+// all eligible jobs, evaluations, allocations and nodes.
+func (s *System) GarbageCollect() error {
+	var req struct{}
+	_, err := s.client.write("/v1/system/gc", &req, nil, nil)
+	return err
+}
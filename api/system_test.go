@@ -0,0 +1,16 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestSystem_GarbageCollect(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	system := c.System()
+
+	// Forcing a garbage collection should succeed
+	if err := system.GarbageCollect(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
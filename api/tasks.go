@@ -20,13 +20,50 @@ func NewRestartPolicy() *RestartPolicy {
 	}
 }
 
+// EphemeralDisk is the disk shared by the tasks of an allocation in the
+// alloc/data directory
+type EphemeralDisk struct {
+	SizeMB  int
+	Sticky  bool
+	Migrate bool
+}
+
+// MigrateStrategy is how the allocations of a task group are migrated off a
+// draining node
+type MigrateStrategy struct {
+	MaxParallel     int
+	HealthCheck     string
+	MinHealthyTime  time.Duration
+	HealthyDeadline time.Duration
+}
+
+// VolumeRequest is a volume requested by a task group, which its tasks may
+// mount
+type VolumeRequest struct {
+	Name     string
+	Type     string
+	Source   string
+	ReadOnly bool
+}
+
+// VolumeMount mounts a volume of the task group into a task
+type VolumeMount struct {
+	Volume      string
+	Destination string
+	ReadOnly    bool
+}
+
 // TaskGroup is the unit of scheduling.
 type TaskGroup struct {
 	Name          string
 	Count         int
 	Constraints   []*Constraint
+	Spreads       []*Spread
 	Tasks         []*Task
 	RestartPolicy *RestartPolicy
+	EphemeralDisk *EphemeralDisk
+	Migrate       *MigrateStrategy
+	Volumes       map[string]*VolumeRequest
 	Meta          map[string]string
 }
 
@@ -46,6 +83,12 @@ func (g *TaskGroup) Constrain(c *Constraint) *TaskGroup {
 	return g
 }
 
+// AddSpread is used to add a spread to a task group.
+func (g *TaskGroup) AddSpread(s *Spread) *TaskGroup {
+	g.Spreads = append(g.Spreads, s)
+	return g
+}
+
 // AddMeta is used to add a meta k/v pair to a task group
 func (g *TaskGroup) SetMeta(key, val string) *TaskGroup {
 	if g.Meta == nil {
@@ -55,6 +98,27 @@ func (g *TaskGroup) SetMeta(key, val string) *TaskGroup {
 	return g
 }
 
+// RequireDisk sets the ephemeral disk of the task group
+func (g *TaskGroup) RequireDisk(disk *EphemeralDisk) *TaskGroup {
+	g.EphemeralDisk = disk
+	return g
+}
+
+// MigrateWith sets the migrate strategy of the task group
+func (g *TaskGroup) MigrateWith(m *MigrateStrategy) *TaskGroup {
+	g.Migrate = m
+	return g
+}
+
+// AddVolume is used to add a volume to a task group.
+func (g *TaskGroup) AddVolume(v *VolumeRequest) *TaskGroup {
+	if g.Volumes == nil {
+		g.Volumes = make(map[string]*VolumeRequest)
+	}
+	g.Volumes[v.Name] = v
+	return g
+}
+
 // AddTask is used to add a new task to a task group.
 func (g *TaskGroup) AddTask(t *Task) *TaskGroup {
 	g.Tasks = append(g.Tasks, t)
@@ -63,13 +127,77 @@ func (g *TaskGroup) AddTask(t *Task) *TaskGroup {
 
 // Task is a single process in a task group.
 type Task struct {
-	Name        string
-	Driver      string
-	Config      map[string]string
-	Constraints []*Constraint
-	Env         map[string]string
-	Resources   *Resources
-	Meta        map[string]string
+	Name            string
+	Driver          string
+	Config          map[string]string
+	Constraints     []*Constraint
+	Env             map[string]string
+	Resources       *Resources
+	Meta            map[string]string
+	DispatchPayload *DispatchPayloadConfig
+	Services        []*Service
+	Artifacts       []*TaskArtifact
+	Templates       []*Template
+	Lifecycle       *TaskLifecycle
+	KillSignal      string
+	KillTimeout     time.Duration
+	LogConfig       *LogConfig
+	VolumeMounts    []*VolumeMount
+}
+
+// LogConfig configures the rotation of the stdout and stderr log files of a
+// task
+type LogConfig struct {
+	MaxFiles      int
+	MaxFileSizeMB int
+}
+
+// TaskLifecycle configures when a task runs relative to the main tasks of its
+// task group
+type TaskLifecycle struct {
+	Hook    string
+	Sidecar bool
+}
+
+// Template is a template rendered into the task directory
+type Template struct {
+	SourcePath   string
+	DestPath     string
+	EmbeddedTmpl string
+	ChangeMode   string
+	ChangeSignal string
+}
+
+// TaskArtifact is an artifact to download and extract before running the task
+type TaskArtifact struct {
+	GetterSource  string
+	GetterOptions map[string]string
+	RelativeDest  string
+}
+
+// ServiceCheck is a health check registered with Consul for a service
+type ServiceCheck struct {
+	Name     string
+	Type     string
+	Script   string
+	Path     string
+	Protocol string
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// Service is a service the task registers with Consul
+type Service struct {
+	Name      string
+	Tags      []string
+	PortLabel string
+	Checks    []*ServiceCheck
+}
+
+// DispatchPayloadConfig configures how a task gets its input from a job
+// dispatch.
+type DispatchPayloadConfig struct {
+	File string
 }
 
 // NewTask creates and initializes a new Task.
@@ -105,6 +233,12 @@ func (t *Task) Require(r *Resources) *Task {
 	return t
 }
 
+// Mount is used to mount a volume of the task group into the task.
+func (t *Task) Mount(m *VolumeMount) *Task {
+	t.VolumeMounts = append(t.VolumeMounts, m)
+	return t
+}
+
 // Constraint adds a new constraints to a single task.
 func (t *Task) Constrain(c *Constraint) *Task {
 	t.Constraints = append(t.Constraints, c)
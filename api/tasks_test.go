@@ -50,6 +50,40 @@ func TestTaskGroup_Constrain(t *testing.T) {
 	}
 }
 
+func TestTaskGroup_AddSpread(t *testing.T) {
+	grp := NewTaskGroup("grp1", 1)
+
+	// Add a spread to the group
+	out := grp.AddSpread(NewSpread("$node.datacenter", 50, nil))
+	if n := len(grp.Spreads); n != 1 {
+		t.Fatalf("expected 1 spread, got: %d", n)
+	}
+
+	// Check that the group was returned
+	if out != grp {
+		t.Fatalf("expected: %#v, got: %#v", grp, out)
+	}
+
+	// Add a second spread
+	grp.AddSpread(NewSpread("$meta.rack", 100, []*SpreadTarget{NewSpreadTarget("r1", 100)}))
+	expect := []*Spread{
+		&Spread{
+			Attribute: "$node.datacenter",
+			Weight:    50,
+		},
+		&Spread{
+			Attribute: "$meta.rack",
+			Weight:    100,
+			Targets: []*SpreadTarget{
+				&SpreadTarget{Value: "r1", Percent: 100},
+			},
+		},
+	}
+	if !reflect.DeepEqual(grp.Spreads, expect) {
+		t.Fatalf("expect: %#v, got: %#v", expect, grp.Spreads)
+	}
+}
+
 func TestTaskGroup_SetMeta(t *testing.T) {
 	grp := NewTaskGroup("grp1", 1)
 
@@ -72,6 +106,46 @@ func TestTaskGroup_SetMeta(t *testing.T) {
 	}
 }
 
+func TestTaskGroup_RequireDisk(t *testing.T) {
+	grp := NewTaskGroup("grp1", 1)
+
+	// Set the ephemeral disk of the group
+	disk := &EphemeralDisk{
+		SizeMB: 500,
+		Sticky: true,
+	}
+	out := grp.RequireDisk(disk)
+	if !reflect.DeepEqual(grp.EphemeralDisk, disk) {
+		t.Fatalf("expect: %#v, got: %#v", disk, grp.EphemeralDisk)
+	}
+
+	// Check that the group was returned
+	if out != grp {
+		t.Fatalf("expect: %#v, got: %#v", grp, out)
+	}
+}
+
+func TestTaskGroup_AddVolume(t *testing.T) {
+	grp := NewTaskGroup("grp1", 1)
+
+	// Add a volume to the group
+	volume := &VolumeRequest{
+		Name:   "data",
+		Type:   "host",
+		Source: "mysql",
+	}
+	out := grp.AddVolume(volume)
+	expect := map[string]*VolumeRequest{"data": volume}
+	if !reflect.DeepEqual(grp.Volumes, expect) {
+		t.Fatalf("expect: %#v, got: %#v", expect, grp.Volumes)
+	}
+
+	// Check that the group was returned
+	if out != grp {
+		t.Fatalf("expect: %#v, got: %#v", grp, out)
+	}
+}
+
 func TestTaskGroup_AddTask(t *testing.T) {
 	grp := NewTaskGroup("grp1", 1)
 
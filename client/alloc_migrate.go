@@ -0,0 +1,188 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// prevAllocQueryTime is the maximum time a query for the status of a
+	// previous allocation blocks before the node running it is checked again
+	prevAllocQueryTime = 30 * time.Second
+)
+
+// prevAllocMigrator moves the ephemeral disk data of the allocation an
+// allocation replaces into the replacement's alloc dir once the previous
+// allocation stopped. The data is moved if the previous allocation ran on this
+// node and streamed from the node running it otherwise.
+type prevAllocMigrator struct {
+	prevAllocID string
+
+	// prevRunner is the runner of the previous allocation if it runs on this
+	// node
+	prevRunner *AllocRunner
+
+	client *Client
+	logger *log.Logger
+}
+
+// newPrevAllocMigrator returns the migrator of the data of the allocation
+// replaced by the passed allocation or nil if no data is to be moved. Data is
+// only streamed from another node if the ephemeral disk is to be migrated. The
+// allocLock must be held by the caller.
+func (c *Client) newPrevAllocMigrator(alloc *structs.Allocation) *prevAllocMigrator {
+	if alloc.PreviousAllocation == "" {
+		return nil
+	}
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	if tg == nil || tg.EphemeralDisk == nil {
+		return nil
+	}
+	disk := tg.EphemeralDisk
+	if !disk.Sticky && !disk.Migrate {
+		return nil
+	}
+
+	prevRunner := c.allocs[alloc.PreviousAllocation]
+	if prevRunner == nil && !disk.Migrate {
+		return nil
+	}
+	return &prevAllocMigrator{
+		prevAllocID: alloc.PreviousAllocation,
+		prevRunner:  prevRunner,
+		client:      c,
+		logger:      c.logger,
+	}
+}
+
+// Migrate waits for the previous allocation to stop and then moves its data
+// into the data directory of the passed alloc dir. Closing the stop channel
+// aborts the migration without moving any data.
+func (m *prevAllocMigrator) Migrate(dest *allocdir.AllocDir, stopCh chan struct{}) error {
+	if m.prevRunner != nil {
+		select {
+		case <-m.prevRunner.WaitCh():
+		case <-stopCh:
+			return nil
+		}
+		m.logger.Printf("[DEBUG] client: moving data of previous alloc '%s'", m.prevAllocID)
+		prev := allocdir.NewAllocDir(filepath.Join(m.client.config.AllocDir, m.prevAllocID))
+		return dest.MoveData(prev)
+	}
+
+	node, err := m.waitRemote(stopCh)
+	if err != nil || node == nil {
+		return err
+	}
+	return m.streamData(node, dest, stopCh)
+}
+
+// waitRemote blocks until the previous allocation stopped on the node running
+// it and returns that node. No node is returned if there is no data to stream
+// or the stop channel is closed.
+func (m *prevAllocMigrator) waitRemote(stopCh chan struct{}) (*structs.Node, error) {
+	req := structs.AllocSpecificRequest{
+		AllocID: m.prevAllocID,
+		QueryOptions: structs.QueryOptions{
+			Region:       m.client.config.Region,
+			AllowStale:   true,
+			MaxQueryTime: prevAllocQueryTime,
+		},
+	}
+
+	for {
+		var resp structs.SingleAllocResponse
+		if err := m.client.RPC("Alloc.GetAlloc", &req, &resp); err != nil {
+			m.logger.Printf("[ERR] client: failed to query previous alloc '%s': %v", m.prevAllocID, err)
+			select {
+			case <-time.After(m.client.retryIntv(getAllocRetryIntv)):
+				continue
+			case <-stopCh:
+				return nil, nil
+			}
+		}
+
+		// The previous allocation was garbage collected along with its data
+		prev := resp.Alloc
+		if prev == nil || prev.NodeID == m.client.Node().ID {
+			return nil, nil
+		}
+
+		node, err := m.node(prev.NodeID)
+		if err != nil {
+			return nil, err
+		}
+		if node.Status == structs.NodeStatusDown {
+			return nil, fmt.Errorf("node '%s' of previous alloc '%s' is down", node.ID, prev.ID)
+		}
+
+		// An allocation stopped before it was started has no data but still
+		// holds its alloc dir
+		if prev.ClientTerminalStatus() ||
+			(prev.TerminalStatus() && prev.ClientStatus == structs.AllocClientStatusPending) {
+			return node, nil
+		}
+
+		select {
+		case <-stopCh:
+			return nil, nil
+		default:
+		}
+		if resp.Index > req.MinQueryIndex {
+			req.MinQueryIndex = resp.Index
+		}
+	}
+}
+
+// node returns the node with the passed ID
+func (m *prevAllocMigrator) node(nodeID string) (*structs.Node, error) {
+	req := structs.NodeSpecificRequest{
+		NodeID: nodeID,
+		QueryOptions: structs.QueryOptions{
+			Region:     m.client.config.Region,
+			AllowStale: true,
+		},
+	}
+	var resp structs.SingleNodeResponse
+	if err := m.client.RPC("Node.GetNode", &req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to query node '%s': %v", nodeID, err)
+	}
+	if resp.Node == nil {
+		return nil, fmt.Errorf("node '%s' of previous alloc '%s' not found", nodeID, m.prevAllocID)
+	}
+	return resp.Node, nil
+}
+
+// streamData requests a snapshot of the data of the previous allocation from
+// the client of the node running it and restores it into the passed alloc dir
+func (m *prevAllocMigrator) streamData(node *structs.Node, dest *allocdir.AllocDir, stopCh chan struct{}) error {
+	if node.HTTPAddr == "" {
+		return fmt.Errorf("node '%s' of previous alloc '%s' has no HTTP address", node.ID, m.prevAllocID)
+	}
+	url := fmt.Sprintf("http://%s/v1/client/allocation/%s/snapshot", node.HTTPAddr, m.prevAllocID)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Cancel = stopCh
+
+	m.logger.Printf("[DEBUG] client: streaming data of previous alloc '%s' from node '%s'", m.prevAllocID, node.ID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request snapshot of previous alloc '%s': %v", m.prevAllocID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("failed to request snapshot of previous alloc '%s': %d (%s)",
+			m.prevAllocID, resp.StatusCode, body)
+	}
+	return dest.RestoreData(resp.Body)
+}
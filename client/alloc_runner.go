@@ -1,7 +1,6 @@
 package client
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -13,6 +12,7 @@ import (
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -22,20 +22,15 @@ const (
 	allocSyncRetryIntv = 15 * time.Second
 )
 
-// taskStatus is used to track the status of a task
-type taskStatus struct {
-	Status      string
-	Description string
-}
-
 // AllocStateUpdater is used to update the status of an allocation
 type AllocStateUpdater func(alloc *structs.Allocation) error
 
 // AllocRunner is used to wrap an allocation and provide the execution context.
 type AllocRunner struct {
-	config  *config.Config
-	updater AllocStateUpdater
-	logger  *log.Logger
+	config        *config.Config
+	updater       AllocStateUpdater
+	logger        *log.Logger
+	consulService *ConsulService
 
 	alloc *structs.Allocation
 
@@ -46,38 +41,66 @@ type AllocRunner struct {
 	RestartPolicy *structs.RestartPolicy
 	taskLock      sync.RWMutex
 
-	taskStatus     map[string]taskStatus
+	// updatedTasks are the tasks of in-place updates to the allocation that
+	// haven't started yet. They are protected by the taskLock.
+	updatedTasks map[string]*structs.Task
+
+	taskStates     map[string]*structs.TaskState
+	taskRestarted  map[string]bool
 	taskStatusLock sync.RWMutex
 
+	// tasksPending is set while tasks of the task group remain to be started
+	// and prestartFailed once a prestart task that isn't a sidecar failed.
+	// Both are protected by the taskStatusLock.
+	tasksPending   bool
+	prestartFailed bool
+
+	// healthCh is used to notify the health watcher of task status changes
+	healthCh chan struct{}
+
 	updateCh chan *structs.Allocation
 
+	// prevAlloc moves the data of the allocation this one replaces into its
+	// alloc dir before the tasks are started
+	prevAlloc *prevAllocMigrator
+
 	destroy     bool
 	destroyCh   chan struct{}
 	destroyLock sync.Mutex
 	waitCh      chan struct{}
+
+	// exited is set once Run returned, after which Destroy destroys the
+	// context and state itself
+	exited bool
 }
 
 // allocRunnerState is used to snapshot the state of the alloc runner
 type allocRunnerState struct {
-	Alloc         *structs.Allocation
-	RestartPolicy *structs.RestartPolicy
-	TaskStatus    map[string]taskStatus
-	Context       *driver.ExecContext
+	Alloc          *structs.Allocation
+	RestartPolicy  *structs.RestartPolicy
+	TaskStates     map[string]*structs.TaskState
+	Context        *driver.ExecContext
+	PrestartFailed bool
 }
 
 // NewAllocRunner is used to create a new allocation context
-func NewAllocRunner(logger *log.Logger, config *config.Config, updater AllocStateUpdater, alloc *structs.Allocation) *AllocRunner {
+func NewAllocRunner(logger *log.Logger, config *config.Config, updater AllocStateUpdater,
+	alloc *structs.Allocation, consulService *ConsulService) *AllocRunner {
 	ar := &AllocRunner{
-		config:     config,
-		updater:    updater,
-		logger:     logger,
-		alloc:      alloc,
-		dirtyCh:    make(chan struct{}, 1),
-		tasks:      make(map[string]*TaskRunner),
-		taskStatus: make(map[string]taskStatus),
-		updateCh:   make(chan *structs.Allocation, 8),
-		destroyCh:  make(chan struct{}),
-		waitCh:     make(chan struct{}),
+		config:        config,
+		updater:       updater,
+		logger:        logger,
+		consulService: consulService,
+		alloc:         alloc,
+		dirtyCh:       make(chan struct{}, 1),
+		tasks:         make(map[string]*TaskRunner),
+		updatedTasks:  make(map[string]*structs.Task),
+		taskStates:    make(map[string]*structs.TaskState),
+		taskRestarted: make(map[string]bool),
+		healthCh:      make(chan struct{}, 1),
+		updateCh:      make(chan *structs.Allocation, 8),
+		destroyCh:     make(chan struct{}),
+		waitCh:        make(chan struct{}),
 	}
 	return ar
 }
@@ -98,20 +121,22 @@ func (r *AllocRunner) RestoreState() error {
 	// Restore fields
 	r.alloc = snap.Alloc
 	r.RestartPolicy = snap.RestartPolicy
-	r.taskStatus = snap.TaskStatus
+	r.taskStates = snap.TaskStates
 	r.ctx = snap.Context
+	r.prestartFailed = snap.PrestartFailed
 
 	// Restore the task runners
 	var mErr multierror.Error
-	for name, status := range r.taskStatus {
+	for name, state := range r.taskStates {
 		task := &structs.Task{Name: name}
 		restartTracker := newRestartTracker(r.alloc.Job.Type, r.RestartPolicy)
-		tr := NewTaskRunner(r.logger, r.config, r.setTaskStatus, r.ctx, r.alloc.ID, task, restartTracker)
+		tr := NewTaskRunner(r.logger, r.config, r.setTaskState, r.ctx, r.alloc, task, restartTracker,
+			r.consulService)
 		r.tasks[name] = tr
 
 		// Skip tasks in terminal states.
-		if status.Status == structs.AllocClientStatusDead ||
-			status.Status == structs.AllocClientStatusFailed {
+		if state.State == structs.TaskStateDead {
+			tr.restoreTerminal()
 			continue
 		}
 
@@ -150,10 +175,11 @@ func (r *AllocRunner) saveAllocRunnerState() error {
 	r.taskStatusLock.RLock()
 	defer r.taskStatusLock.RUnlock()
 	snap := allocRunnerState{
-		Alloc:         r.alloc,
-		RestartPolicy: r.RestartPolicy,
-		TaskStatus:    r.taskStatus,
-		Context:       r.ctx,
+		Alloc:          r.alloc,
+		RestartPolicy:  r.RestartPolicy,
+		TaskStates:     r.taskStates,
+		Context:        r.ctx,
+		PrestartFailed: r.prestartFailed,
 	}
 	return persistState(r.stateFilePath(), &snap)
 }
@@ -174,20 +200,83 @@ func (r *AllocRunner) DestroyState() error {
 
 // DestroyContext is used to destroy the context
 func (r *AllocRunner) DestroyContext() error {
+	// The runner may have exited before building the alloc dir
+	if r.ctx == nil {
+		return nil
+	}
 	return r.ctx.AllocDir.Destroy()
 }
 
+// destroyContextAndState destroys the alloc dir and the persisted state
+func (r *AllocRunner) destroyContextAndState() {
+	if err := r.DestroyContext(); err != nil {
+		r.logger.Printf("[ERR] client: failed to destroy context for alloc '%s': %v",
+			r.alloc.ID, err)
+	}
+	if err := r.DestroyState(); err != nil {
+		r.logger.Printf("[ERR] client: failed to destroy state for alloc '%s': %v",
+			r.alloc.ID, err)
+	}
+}
+
+// exit marks the runner as exited and destroys the context and state if the
+// runner was destroyed
+func (r *AllocRunner) exit() {
+	r.destroyLock.Lock()
+	defer r.destroyLock.Unlock()
+	r.exited = true
+	if r.destroy {
+		r.destroyContextAndState()
+	}
+}
+
 // Alloc returns the associated allocation
 func (r *AllocRunner) Alloc() *structs.Allocation {
 	return r.alloc
 }
 
+// LatestAllocStats returns the latest resource usage of the running tasks of
+// the allocation and their sum
+func (r *AllocRunner) LatestAllocStats() *cstructs.AllocResourceUsage {
+	r.taskLock.RLock()
+	defer r.taskLock.RUnlock()
+
+	usage := &cstructs.AllocResourceUsage{
+		ResourceUsage: &cstructs.ResourceUsage{},
+		Tasks:         make(map[string]*cstructs.TaskResourceUsage),
+	}
+	for name, tr := range r.tasks {
+		taskUsage := tr.LatestResourceUsage()
+		if taskUsage == nil {
+			continue
+		}
+		usage.Tasks[name] = taskUsage
+		usage.ResourceUsage.Add(taskUsage.ResourceUsage)
+		if taskUsage.Timestamp > usage.Timestamp {
+			usage.Timestamp = taskUsage.Timestamp
+		}
+	}
+	return usage
+}
+
+// TaskRunner returns the runner of a task of the allocation or nil if the task
+// isn't known
+func (r *AllocRunner) TaskRunner(task string) *TaskRunner {
+	r.taskLock.RLock()
+	defer r.taskLock.RUnlock()
+	return r.tasks[task]
+}
+
 // setAlloc is used to update the allocation of the runner
-// we preserve the existing client status and description
+// we preserve the existing client status, description and task states
 func (r *AllocRunner) setAlloc(alloc *structs.Allocation) {
 	if r.alloc != nil {
 		alloc.ClientStatus = r.alloc.ClientStatus
 		alloc.ClientDescription = r.alloc.ClientDescription
+		alloc.TaskStates = r.alloc.TaskStates
+		if r.alloc.DeploymentStatus.HasHealth() {
+			alloc.DeploymentStatus = r.alloc.DeploymentStatus
+		}
 	}
 	r.alloc = alloc
 }
@@ -223,24 +312,28 @@ func (r *AllocRunner) retrySyncState(stopCh chan struct{}) {
 
 // syncStatus is used to run and sync the status when it changes
 func (r *AllocRunner) syncStatus() error {
-	// Scan the task status to termine the status of the alloc
+	// Scan the task states to termine the status of the alloc
 	var pending, running, dead, failed bool
 	r.taskStatusLock.RLock()
-	pending = len(r.taskStatus) < len(r.tasks)
-	for _, status := range r.taskStatus {
-		switch status.Status {
-		case structs.AllocClientStatusRunning:
+	pending = r.tasksPending
+	failed = r.prestartFailed
+	taskStates := make(map[string]*structs.TaskState, len(r.taskStates))
+	for name, state := range r.taskStates {
+		taskStates[name] = state.Copy()
+		switch state.State {
+		case structs.TaskStatePending:
+			pending = true
+		case structs.TaskStateRunning:
 			running = true
-		case structs.AllocClientStatusDead:
-			dead = true
-		case structs.AllocClientStatusFailed:
-			failed = true
+		case structs.TaskStateDead:
+			if state.Failed {
+				failed = true
+			} else {
+				dead = true
+			}
 		}
 	}
-	if len(r.taskStatus) > 0 {
-		taskDesc, _ := json.Marshal(r.taskStatus)
-		r.alloc.ClientDescription = string(taskDesc)
-	}
+	r.alloc.TaskStates = taskStates
 	r.taskStatusLock.RUnlock()
 
 	// Determine the alloc status
@@ -271,23 +364,130 @@ func (r *AllocRunner) setStatus(status, desc string) {
 	}
 }
 
-// setTaskStatus is used to set the status of a task
-func (r *AllocRunner) setTaskStatus(taskName, status, desc string) {
+// setTaskState is used to set the state of a task and append the event that
+// caused the transition to its history
+func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEvent) {
 	r.taskStatusLock.Lock()
-	r.taskStatus[taskName] = taskStatus{
-		Status:      status,
-		Description: desc,
+	taskState, ok := r.taskStates[taskName]
+	if !ok {
+		taskState = &structs.TaskState{}
+		r.taskStates[taskName] = taskState
+	}
+	if taskState.State == structs.TaskStateRunning && state != structs.TaskStateRunning {
+		r.taskRestarted[taskName] = true
+	}
+	taskState.State = state
+	if event != nil {
+		if event.FailsTask {
+			taskState.Failed = true
+		}
+		taskState.AppendEvent(event)
 	}
 	r.taskStatusLock.Unlock()
 	select {
 	case r.dirtyCh <- struct{}{}:
 	default:
 	}
+	select {
+	case r.healthCh <- struct{}{}:
+	default:
+	}
+}
+
+// setHealth is used to set the deployment health of the allocation
+func (r *AllocRunner) setHealth(healthy bool) {
+	status := r.alloc.DeploymentStatus.Copy()
+	if status == nil {
+		status = &structs.AllocDeploymentStatus{}
+	}
+	status.Healthy = &healthy
+	r.alloc.DeploymentStatus = status
+	r.logger.Printf("[DEBUG] client: alloc '%s' deployment health set to %v", r.alloc.ID, healthy)
+	select {
+	case r.dirtyCh <- struct{}{}:
+	default:
+	}
+}
+
+// taskHealth returns whether all the given tasks are running and whether any
+// of them have failed or restarted. Tasks that haven't started yet aren't
+// running.
+func (r *AllocRunner) taskHealth(tasks []string) (running, unhealthy bool) {
+	r.taskStatusLock.RLock()
+	defer r.taskStatusLock.RUnlock()
+
+	if r.prestartFailed {
+		return false, true
+	}
+	count := 0
+	for _, name := range tasks {
+		if r.taskRestarted[name] {
+			return false, true
+		}
+		state, ok := r.taskStates[name]
+		if !ok {
+			continue
+		}
+		switch state.State {
+		case structs.TaskStateRunning:
+			count++
+		case structs.TaskStateDead:
+			return false, true
+		}
+	}
+	return count == len(tasks), false
+}
+
+// watchHealth is used to determine the health of an allocation that is part
+// of a deployment or replaces a migrated allocation. The allocation is healthy
+// once all of its tasks have been running for the minimum healthy time without
+// restarting. Only the main tasks and the sidecars are expected to keep
+// running. It is unhealthy if any of them fails or restarts, or if it isn't
+// healthy by the healthy deadline. Watching stops once the stopCh is closed.
+func (r *AllocRunner) watchHealth(minHealthyTime, healthyDeadline time.Duration, tasks []string, stopCh chan struct{}) {
+	var deadline <-chan time.Time
+	if healthyDeadline > 0 {
+		deadline = time.After(healthyDeadline)
+	}
+
+	var healthyTimer <-chan time.Time
+	for {
+		select {
+		case <-r.healthCh:
+			// Tasks being stopped don't make the allocation unhealthy
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+
+			running, unhealthy := r.taskHealth(tasks)
+			if unhealthy {
+				r.setHealth(false)
+				return
+			}
+			if !running {
+				healthyTimer = nil
+			} else if healthyTimer == nil {
+				healthyTimer = time.After(minHealthyTime)
+			}
+		case <-healthyTimer:
+			r.setHealth(true)
+			return
+		case <-deadline:
+			r.logger.Printf("[DEBUG] client: alloc '%s' not healthy by deadline", r.alloc.ID)
+			r.setHealth(false)
+			return
+		case <-stopCh:
+			return
+		}
+	}
 }
 
 // Run is a long running goroutine used to manage an allocation
 func (r *AllocRunner) Run() {
 	defer close(r.waitCh)
+	defer r.exit()
 	go r.dirtySyncState()
 
 	// Check if the allocation is in a terminal status
@@ -318,24 +518,62 @@ func (r *AllocRunner) Run() {
 			return
 		}
 		r.ctx = driver.NewExecContext(allocDir, r.alloc.ID)
+		r.ctx.Volumes = tg.Volumes
 	}
 
-	// Start the task runners
-	r.taskLock.Lock()
-	for _, task := range tg.Tasks {
-		// Skip tasks that were restored
-		if _, ok := r.taskStatus[task.Name]; ok {
-			continue
+	// Start the task runners in the order of their lifecycle
+	tasksStopCh := make(chan struct{})
+	tasksDoneCh := make(chan struct{})
+	r.taskStatusLock.Lock()
+	r.tasksPending = !r.prestartFailed
+	r.taskStatusLock.Unlock()
+	go func() {
+		defer close(tasksDoneCh)
+		if !r.prestartFailed {
+			r.migratePrevAlloc(tasksStopCh)
+			r.runTasks(tg, tasksStopCh)
 		}
-
-		// Merge in the task resources
-		task.Resources = alloc.TaskResources[task.Name]
-		restartTracker := newRestartTracker(r.alloc.Job.Type, r.RestartPolicy)
-		tr := NewTaskRunner(r.logger, r.config, r.setTaskStatus, r.ctx, r.alloc.ID, task, restartTracker)
-		r.tasks[task.Name] = tr
-		go tr.Run()
+		r.taskStatusLock.Lock()
+		r.tasksPending = false
+		r.taskStatusLock.Unlock()
+		select {
+		case r.dirtyCh <- struct{}{}:
+		default:
+		}
+	}()
+
+	// Report the health of the allocation to its deployment or, if it
+	// replaces a migrated allocation, to the drainer of the servers
+	var watchHealth bool
+	minHealthyTime, healthyDeadline := alloc.Job.Update.MinHealthyTime, alloc.Job.Update.HealthyDeadline
+	switch {
+	case alloc.DeploymentStatus.HasHealth():
+	case alloc.DeploymentID != "":
+		watchHealth = true
+	case alloc.PreviousAllocation != "":
+		migrate := tg.Migrate
+		if migrate == nil {
+			migrate = structs.DefaultMigrateStrategy()
+		}
+		if migrate.HealthCheck == structs.MigrateHealthCheckTaskStates {
+			watchHealth = true
+			minHealthyTime, healthyDeadline = migrate.MinHealthyTime, migrate.HealthyDeadline
+		}
+	}
+	healthStopCh := make(chan struct{})
+	if watchHealth {
+		var healthTasks []string
+		for _, task := range tg.Tasks {
+			if task.IsMain() || task.IsSidecar() {
+				healthTasks = append(healthTasks, task.Name)
+			}
+		}
+		select {
+		case r.healthCh <- struct{}{}:
+		default:
+		}
+		go r.watchHealth(minHealthyTime, healthyDeadline, healthTasks, healthStopCh)
 	}
-	r.taskLock.Unlock()
 
 OUTER:
 	// Wait for updates
@@ -348,22 +586,39 @@ OUTER:
 				break OUTER
 			}
 
-			// Update the task groups
-			r.taskLock.RLock()
-			for _, task := range tg.Tasks {
-				tr := r.tasks[task.Name]
-
+			// Update the tasks from the updated job so that the task runners
+			// see its changes, such as the task meta
+			updateTG := update.Job.LookupTaskGroup(update.TaskGroup)
+			if updateTG == nil {
+				r.logger.Printf("[ERR] client: update to alloc '%s' for missing task group '%s'",
+					update.ID, update.TaskGroup)
+				continue
+			}
+			r.taskLock.Lock()
+			for _, task := range updateTG.Tasks {
 				// Merge in the task resources
 				task.Resources = update.TaskResources[task.Name]
-				tr.Update(task)
+
+				// Tasks that haven't started yet start with the update
+				if tr, ok := r.tasks[task.Name]; ok {
+					tr.Update(task)
+				} else {
+					r.updatedTasks[task.Name] = task
+				}
 			}
-			r.taskLock.RUnlock()
+			r.taskLock.Unlock()
 
 		case <-r.destroyCh:
 			break OUTER
 		}
 	}
 
+	// Stop watching the health before the tasks are stopped and don't start
+	// any more tasks
+	close(healthStopCh)
+	close(tasksStopCh)
+	<-tasksDoneCh
+
 	// Destroy each sub-task
 	r.taskLock.RLock()
 	defer r.taskLock.RUnlock()
@@ -378,19 +633,157 @@ OUTER:
 
 	// Final state sync
 	r.retrySyncState(nil)
+	r.logger.Printf("[DEBUG] client: terminating runner for alloc '%s'", r.alloc.ID)
+}
 
-	// Check if we should destroy our state
-	if r.destroy {
-		if err := r.DestroyContext(); err != nil {
-			r.logger.Printf("[ERR] client: failed to destroy context for alloc '%s': %v",
-				r.alloc.ID, err)
+// migratePrevAlloc moves the data of the previous allocation into the alloc
+// dir. The tasks are started with an empty data directory if it fails.
+func (r *AllocRunner) migratePrevAlloc(stopCh chan struct{}) {
+	if r.prevAlloc == nil {
+		return
+	}
+	if err := r.prevAlloc.Migrate(r.ctx.AllocDir, stopCh); err != nil {
+		r.logger.Printf("[ERR] client: failed to migrate data of previous alloc '%s' to alloc '%s': %v",
+			r.prevAlloc.prevAllocID, r.alloc.ID, err)
+	}
+	r.prevAlloc = nil
+}
+
+// runTasks starts the tasks of the task group in the order of their
+// lifecycle. The prestart tasks are started first and the allocation fails if
+// a prestart task that isn't a sidecar fails. Once the other prestart tasks
+// completed the main tasks are started, followed by the poststart tasks once
+// the main tasks are running. When the main tasks exit the sidecars are
+// stopped and the poststop tasks are started. No more tasks are started once
+// the stopCh is closed.
+func (r *AllocRunner) runTasks(tg *structs.TaskGroup, stopCh chan struct{}) {
+	var prestart, main, poststart, poststop []*structs.Task
+	for _, task := range tg.Tasks {
+		switch {
+		case task.IsMain():
+			main = append(main, task)
+		case task.Lifecycle.Hook == structs.TaskLifecycleHookPrestart:
+			prestart = append(prestart, task)
+		case task.Lifecycle.Hook == structs.TaskLifecycleHookPoststart:
+			poststart = append(poststart, task)
+		case task.Lifecycle.Hook == structs.TaskLifecycleHookPoststop:
+			poststop = append(poststop, task)
 		}
-		if err := r.DestroyState(); err != nil {
-			r.logger.Printf("[ERR] client: failed to destroy state for alloc '%s': %v",
-				r.alloc.ID, err)
+	}
+
+	// Wait for the prestart tasks that aren't sidecars to complete
+	runners, ok := r.startTasks(prestart, stopCh)
+	if !ok {
+		return
+	}
+	for i, tr := range runners {
+		if prestart[i].IsSidecar() {
+			continue
+		}
+		select {
+		case <-tr.WaitCh():
+		case <-stopCh:
+			return
+		}
+		if r.taskFailed(prestart[i].Name) {
+			r.logger.Printf("[ERR] client: prestart task '%s' of alloc '%s' failed, not starting the main tasks",
+				prestart[i].Name, r.alloc.ID)
+			r.taskStatusLock.Lock()
+			r.prestartFailed = true
+			r.taskStatusLock.Unlock()
+			r.stopSidecars(tg)
+			return
+		}
+	}
+
+	// Start the main tasks and the poststart tasks once they are running
+	runners, ok = r.startTasks(main, stopCh)
+	if !ok {
+		return
+	}
+	for _, tr := range runners {
+		select {
+		case <-tr.StartedCh():
+		case <-tr.WaitCh():
+		case <-stopCh:
+			return
+		}
+	}
+	if _, ok := r.startTasks(poststart, stopCh); !ok {
+		return
+	}
+
+	// Stop the sidecars and run the poststop tasks once the main tasks exit
+	for _, tr := range runners {
+		select {
+		case <-tr.WaitCh():
+		case <-stopCh:
+			return
+		}
+	}
+	r.stopSidecars(tg)
+	r.startTasks(poststop, stopCh)
+}
+
+// startTasks starts the task runners of the given tasks and returns them.
+// Task runners that were restored are reused. It returns false without
+// starting the tasks if the stopCh is closed.
+func (r *AllocRunner) startTasks(tasks []*structs.Task, stopCh chan struct{}) ([]*TaskRunner, bool) {
+	r.taskLock.Lock()
+	defer r.taskLock.Unlock()
+
+	select {
+	case <-stopCh:
+		return nil, false
+	default:
+	}
+
+	runners := make([]*TaskRunner, len(tasks))
+	for i, task := range tasks {
+		// Reuse the task runners that were restored
+		if tr, ok := r.tasks[task.Name]; ok {
+			runners[i] = tr
+			continue
+		}
+
+		// Start the task of the latest in-place update to the allocation
+		if updated, ok := r.updatedTasks[task.Name]; ok {
+			task = updated
+		} else {
+			// Merge in the task resources
+			task.Resources = r.alloc.TaskResources[task.Name]
+		}
+		restartTracker := newRestartTracker(r.alloc.Job.Type, r.RestartPolicy)
+		tr := NewTaskRunner(r.logger, r.config, r.setTaskState, r.ctx, r.alloc, task, restartTracker,
+			r.consulService)
+		r.tasks[task.Name] = tr
+		runners[i] = tr
+		r.setTaskState(task.Name, structs.TaskStatePending, nil)
+		go tr.Run()
+	}
+	return runners, true
+}
+
+// taskFailed returns whether the task with the given name failed
+func (r *AllocRunner) taskFailed(taskName string) bool {
+	r.taskStatusLock.RLock()
+	defer r.taskStatusLock.RUnlock()
+	state, ok := r.taskStates[taskName]
+	return ok && state.Failed
+}
+
+// stopSidecars destroys the task runners of the sidecars of the task group
+func (r *AllocRunner) stopSidecars(tg *structs.TaskGroup) {
+	r.taskLock.RLock()
+	defer r.taskLock.RUnlock()
+	for _, task := range tg.Tasks {
+		if !task.IsSidecar() {
+			continue
+		}
+		if tr, ok := r.tasks[task.Name]; ok {
+			tr.Destroy()
 		}
 	}
-	r.logger.Printf("[DEBUG] client: terminating runner for alloc '%s'", r.alloc.ID)
 }
 
 // Update is used to update the allocation of the context
@@ -402,7 +795,9 @@ func (r *AllocRunner) Update(update *structs.Allocation) {
 	}
 }
 
-// Destroy is used to indicate that the allocation context should be destroyed
+// Destroy is used to indicate that the allocation context should be destroyed.
+// The context is destroyed once the runner exits, or right away if it already
+// did.
 func (r *AllocRunner) Destroy() {
 	r.destroyLock.Lock()
 	defer r.destroyLock.Unlock()
@@ -412,6 +807,9 @@ func (r *AllocRunner) Destroy() {
 	}
 	r.destroy = true
 	close(r.destroyCh)
+	if r.exited {
+		r.destroyContextAndState()
+	}
 }
 
 // WaitCh returns a channel to wait for termination
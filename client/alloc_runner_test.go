@@ -1,10 +1,14 @@
 package client
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
@@ -31,7 +35,7 @@ func testAllocRunner() (*MockAllocStateUpdater, *AllocRunner) {
 	conf.AllocDir = os.TempDir()
 	upd := &MockAllocStateUpdater{}
 	alloc := mock.Alloc()
-	ar := NewAllocRunner(logger, conf, upd.Update, alloc)
+	ar := NewAllocRunner(logger, conf, upd.Update, alloc, nil)
 	return upd, ar
 }
 
@@ -76,7 +80,7 @@ func TestAllocRunner_Destroy(t *testing.T) {
 		last := upd.Allocs[upd.Count-1]
 		return last.ClientStatus == structs.AllocClientStatusDead, nil
 	}, func(err error) {
-		t.Fatalf("err: %v %#v %#v", err, upd.Allocs[0], ar.taskStatus)
+		t.Fatalf("err: %v %#v %#v", err, upd.Allocs[0], ar.taskStates)
 	})
 
 	if time.Since(start) > time.Second {
@@ -109,7 +113,7 @@ func TestAllocRunner_Update(t *testing.T) {
 		last := upd.Allocs[upd.Count-1]
 		return last.ClientStatus == structs.AllocClientStatusDead, nil
 	}, func(err error) {
-		t.Fatalf("err: %v %#v %#v", err, upd.Allocs[0], ar.taskStatus)
+		t.Fatalf("err: %v %#v %#v", err, upd.Allocs[0], ar.taskStates)
 	})
 
 	if time.Since(start) > time.Second {
@@ -117,6 +121,48 @@ func TestAllocRunner_Update(t *testing.T) {
 	}
 }
 
+func TestAllocRunner_Update_Meta(t *testing.T) {
+	ctestutil.ExecCompatible(t)
+	_, ar := testAllocRunner()
+
+	// Render the meta of a long running task into a template
+	task := ar.alloc.Job.TaskGroups[0].Tasks[0]
+	task.Config["command"] = "/bin/sleep"
+	task.Config["args"] = "10"
+	task.Meta = map[string]string{"foo": "bar"}
+	task.Templates = []*structs.Template{
+		&structs.Template{
+			EmbeddedTmpl: `{{ env "NOMAD_META_FOO" }}`,
+			DestPath:     "local/meta",
+			ChangeMode:   structs.TemplateChangeModeNoop,
+		},
+	}
+	go ar.Run()
+	defer ar.Destroy()
+
+	// Update the meta of the task in place
+	newAlloc := new(structs.Allocation)
+	*newAlloc = *ar.alloc
+	newAlloc.Job = ar.alloc.Job.Copy()
+	newAlloc.Job.TaskGroups[0].Tasks[0].Meta["foo"] = "baz"
+	ar.Update(newAlloc)
+
+	// The template is re-rendered with the new meta
+	path := filepath.Join(ar.config.AllocDir, ar.alloc.ID, task.Name, allocdir.TaskLocal, "meta")
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := ioutil.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		if string(out) != "baz" {
+			return false, fmt.Errorf("bad: %q", out)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
 func TestAllocRunner_SaveRestoreState(t *testing.T) {
 	ctestutil.ExecCompatible(t)
 	upd, ar := testAllocRunner()
@@ -137,7 +183,7 @@ func TestAllocRunner_SaveRestoreState(t *testing.T) {
 
 	// Create a new alloc runner
 	ar2 := NewAllocRunner(ar.logger, ar.config, upd.Update,
-		&structs.Allocation{ID: ar.alloc.ID})
+		&structs.Allocation{ID: ar.alloc.ID}, nil)
 	err = ar2.RestoreState()
 	if err != nil {
 		t.Fatalf("err: %v", err)
@@ -156,10 +202,234 @@ func TestAllocRunner_SaveRestoreState(t *testing.T) {
 		last := upd.Allocs[upd.Count-1]
 		return last.ClientStatus == structs.AllocClientStatusDead, nil
 	}, func(err error) {
-		t.Fatalf("err: %v %#v %#v", err, upd.Allocs[0], ar.taskStatus)
+		t.Fatalf("err: %v %#v %#v", err, upd.Allocs[0], ar.taskStates)
 	})
 
 	if time.Since(start) > 15*time.Second {
 		t.Fatalf("took too long to terminate")
 	}
 }
+
+func TestAllocRunner_MigratePrevAlloc(t *testing.T) {
+	ctestutil.ExecCompatible(t)
+	_, prev := testAllocRunner()
+
+	// Ensure the previous alloc runs until it's stopped
+	task := prev.alloc.Job.TaskGroups[0].Tasks[0]
+	task.Config["command"] = "/bin/sleep"
+	task.Config["args"] = "10"
+	go prev.Run()
+	defer prev.Destroy()
+
+	time.Sleep(200 * time.Millisecond)
+	data := filepath.Join(prev.config.AllocDir, prev.alloc.ID, "alloc", "data", "foo")
+	if err := ioutil.WriteFile(data, []byte("bar"), 0666); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The replacement waits for the previous alloc to stop
+	upd, ar := testAllocRunner()
+	ar.alloc.PreviousAllocation = prev.alloc.ID
+	ar.prevAlloc = &prevAllocMigrator{
+		prevAllocID: prev.alloc.ID,
+		prevRunner:  prev,
+		client:      &Client{config: ar.config},
+		logger:      ar.logger,
+	}
+	go ar.Run()
+	defer ar.Destroy()
+
+	time.Sleep(200 * time.Millisecond)
+	if upd.Count != 0 && upd.Allocs[upd.Count-1].ClientStatus != structs.AllocClientStatusPending {
+		t.Fatalf("tasks started before previous alloc stopped: %#v", upd.Allocs[upd.Count-1])
+	}
+
+	// Stop the previous alloc without destroying its alloc dir
+	stopped := *prev.alloc
+	stopped.DesiredStatus = structs.AllocDesiredStatusStop
+	prev.Update(&stopped)
+
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, nil
+		}
+		last := upd.Allocs[upd.Count-1]
+		return last.ClientStatus == structs.AllocClientStatusDead, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	out, err := ioutil.ReadFile(filepath.Join(ar.ctx.AllocDir.DataDir(), "foo"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != "bar" {
+		t.Fatalf("bad: %q", out)
+	}
+}
+
+func TestAllocRunner_DeploymentHealth_Healthy(t *testing.T) {
+	ctestutil.ExecCompatible(t)
+	upd, ar := testAllocRunner()
+	ar.alloc.DeploymentID = structs.GenerateUUID()
+	ar.alloc.Job.Update.MinHealthyTime = 100 * time.Millisecond
+
+	// Ensure task takes some time
+	task := ar.alloc.Job.TaskGroups[0].Tasks[0]
+	task.Config["command"] = "/bin/sleep"
+	task.Config["args"] = "10"
+	go ar.Run()
+	defer ar.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, nil
+		}
+		last := upd.Allocs[upd.Count-1]
+		return last.DeploymentStatus.IsHealthy(), nil
+	}, func(err error) {
+		t.Fatalf("err: %v %#v", err, ar.taskStates)
+	})
+}
+
+func TestAllocRunner_DeploymentHealth_Unhealthy(t *testing.T) {
+	ctestutil.ExecCompatible(t)
+	upd, ar := testAllocRunner()
+	ar.alloc.DeploymentID = structs.GenerateUUID()
+	ar.alloc.Job.Update.MinHealthyTime = 10 * time.Second
+
+	// The task exits before the minimum healthy time
+	task := ar.alloc.Job.TaskGroups[0].Tasks[0]
+	task.Config["command"] = "/bin/sleep"
+	task.Config["args"] = "1"
+	go ar.Run()
+	defer ar.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, nil
+		}
+		last := upd.Allocs[upd.Count-1]
+		return last.DeploymentStatus.IsUnhealthy(), nil
+	}, func(err error) {
+		t.Fatalf("err: %v %#v", err, ar.taskStates)
+	})
+}
+
+func TestAllocRunner_MigrateHealth_Healthy(t *testing.T) {
+	ctestutil.ExecCompatible(t)
+	upd, ar := testAllocRunner()
+	ar.alloc.PreviousAllocation = structs.GenerateUUID()
+	ar.alloc.Job.TaskGroups[0].Migrate = &structs.MigrateStrategy{
+		MaxParallel:    1,
+		HealthCheck:    structs.MigrateHealthCheckTaskStates,
+		MinHealthyTime: 100 * time.Millisecond,
+	}
+
+	// Ensure task takes some time
+	task := ar.alloc.Job.TaskGroups[0].Tasks[0]
+	task.Config["command"] = "/bin/sleep"
+	task.Config["args"] = "10"
+	go ar.Run()
+	defer ar.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, nil
+		}
+		last := upd.Allocs[upd.Count-1]
+		return last.DeploymentStatus.IsHealthy(), nil
+	}, func(err error) {
+		t.Fatalf("err: %v %#v", err, ar.taskStates)
+	})
+}
+
+// testLifecycleAllocRunner returns an alloc runner whose task group runs a
+// prestart task with the given command before its main task
+func testLifecycleAllocRunner(command string) (*MockAllocStateUpdater, *AllocRunner) {
+	upd, ar := testAllocRunner()
+	tg := ar.alloc.Job.TaskGroups[0]
+	tg.RestartPolicy = &structs.RestartPolicy{Interval: 10 * time.Minute}
+
+	web := tg.Tasks[0]
+	web.Config["command"] = "/bin/sleep"
+	web.Config["args"] = "10"
+
+	prestart := web.Copy()
+	prestart.Name = "init"
+	prestart.Config["command"] = command
+	prestart.Config["args"] = ""
+	prestart.Lifecycle = &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPrestart}
+	tg.Tasks = append([]*structs.Task{prestart}, tg.Tasks...)
+	ar.alloc.TaskResources[prestart.Name] = ar.alloc.TaskResources[web.Name]
+	return upd, ar
+}
+
+func TestAllocRunner_Lifecycle_Prestart(t *testing.T) {
+	ctestutil.ExecCompatible(t)
+	upd, ar := testLifecycleAllocRunner("/bin/true")
+	go ar.Run()
+	defer ar.Destroy()
+
+	// The main task starts once the prestart task completed
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, nil
+		}
+		last := upd.Allocs[upd.Count-1]
+		if last.ClientStatus != structs.AllocClientStatusRunning {
+			return false, fmt.Errorf("bad status: %v", last.ClientStatus)
+		}
+
+		ar.taskStatusLock.RLock()
+		defer ar.taskStatusLock.RUnlock()
+		if ar.taskStates["init"].State != structs.TaskStateDead {
+			return false, fmt.Errorf("bad init state: %#v", ar.taskStates)
+		}
+		if ar.taskStates["web"].State != structs.TaskStateRunning {
+			return false, fmt.Errorf("bad main state: %#v", ar.taskStates)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestAllocRunner_Lifecycle_PrestartFailed(t *testing.T) {
+	ctestutil.ExecCompatible(t)
+	upd, ar := testLifecycleAllocRunner("/bin/false")
+	go ar.Run()
+	defer ar.Destroy()
+
+	// The allocation fails without starting the main task
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, nil
+		}
+		last := upd.Allocs[upd.Count-1]
+		return last.ClientStatus == structs.AllocClientStatusFailed, nil
+	}, func(err error) {
+		t.Fatalf("err: %v %#v", err, ar.taskStates)
+	})
+
+	ar.taskLock.RLock()
+	defer ar.taskLock.RUnlock()
+	if _, ok := ar.tasks["web"]; ok {
+		t.Fatalf("main task started")
+	}
+	// The prestart task's state records why it failed
+	last := upd.Allocs[upd.Count-1]
+	state, ok := last.TaskStates["init"]
+	if !ok || state.State != structs.TaskStateDead || !state.Failed {
+		t.Fatalf("bad: %#v", last.TaskStates)
+	}
+	var terminated *structs.TaskEvent
+	for _, event := range state.Events {
+		if event.Type == structs.TaskTerminated {
+			terminated = event
+		}
+	}
+	if terminated == nil || terminated.ExitCode != 1 {
+		t.Fatalf("bad: %#v", state.Events)
+	}
+}
@@ -1,11 +1,16 @@
 package allocdir
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -14,14 +19,36 @@ var (
 	// The name of the directory that is shared across tasks in a task group.
 	SharedAllocName = "alloc"
 
+	// The name of the directory inside the shared alloc directory that the
+	// logs of the tasks are written to.
+	LogDirName = "logs"
+
+	// The name of the directory inside the shared alloc directory that holds
+	// the ephemeral disk data, which may be migrated to a replacement.
+	DataDirName = "data"
+
 	// The set of directories that exist inside eache shared alloc directory.
-	SharedAllocDirs = []string{"logs", "tmp", "data"}
+	SharedAllocDirs = []string{LogDirName, "tmp", DataDirName}
 
 	// The name of the directory that exists inside each task directory
 	// regardless of driver.
 	TaskLocal = "local"
 )
 
+// ErrInvalidPath is returned when a path resolves outside of the allocation
+// directory.
+var ErrInvalidPath = errors.New("path escapes the allocation directory")
+
+// AllocFileInfo holds information about a file inside the allocation
+// directory.
+type AllocFileInfo struct {
+	Name     string
+	IsDir    bool
+	Size     int64
+	FileMode string
+	ModTime  time.Time
+}
+
 type AllocDir struct {
 	// AllocDir is the directory used for storing any state
 	// of this allocation. It will be purged on alloc destroy.
@@ -34,6 +61,11 @@ type AllocDir struct {
 	// TaskDirs is a mapping of task names to their non-shared directory.
 	TaskDirs map[string]string
 
+	// VolumeMounts is the list of locations host volumes have been mounted
+	// to. They are unmounted before the directory is destroyed so the data of
+	// the host is kept.
+	VolumeMounts []string
+
 	// A list of locations the shared alloc has been mounted to.
 	mounted []string
 }
@@ -44,8 +76,187 @@ func NewAllocDir(allocDir string) *AllocDir {
 	return d
 }
 
+// LogDir returns the directory the logs of the tasks are written to.
+func (d *AllocDir) LogDir() string {
+	return filepath.Join(d.SharedDir, LogDirName)
+}
+
+// DataDir returns the directory holding the ephemeral disk data.
+func (d *AllocDir) DataDir() string {
+	return filepath.Join(d.SharedDir, DataDirName)
+}
+
+// MoveData replaces the data directory with the one of a previous allocation
+// on the same node. Nothing is moved if the previous allocation has no data.
+func (d *AllocDir) MoveData(prev *AllocDir) error {
+	src := prev.DataDir()
+	if _, err := os.Stat(src); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	dst := d.DataDir()
+	if err := os.RemoveAll(dst); err != nil {
+		return fmt.Errorf("Couldn't remove data directory %v: %v", dst, err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("Couldn't move data directory %v to %v: %v", src, dst, err)
+	}
+	return nil
+}
+
+// SnapshotData writes the data directory to w as a gzipped tar archive.
+func (d *AllocDir) SnapshotData(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	root := d.DataDir()
+	walk := func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		var link string
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	}
+	if err := filepath.Walk(root, walk); err != nil {
+		return fmt.Errorf("Couldn't archive data directory %v: %v", root, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// RestoreData extracts an archive written by SnapshotData into the data
+// directory. Entries that would be written outside of the data directory,
+// either directly or through a symlink, are rejected with ErrInvalidPath.
+func (d *AllocDir) RestoreData(r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	root, err := filepath.EvalSymlinks(d.DataDir())
+	if err != nil {
+		return err
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(root, filepath.FromSlash(hdr.Name))
+		if path == root || !withinDir(root, path) {
+			return ErrInvalidPath
+		}
+
+		// Don't follow a restored symlink out of the data directory
+		parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil && !withinDir(root, parent) {
+			return ErrInvalidPath
+		}
+
+		// Replace rather than write through an existing symlink
+		if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, mode); err != nil {
+				return err
+			}
+			if err := os.Chmod(path, mode); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := restoreFile(path, mode, tr); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, path); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+
+		// Keep the owner of the files, which fails unless running as root
+		os.Lchown(path, hdr.Uid, hdr.Gid)
+	}
+}
+
+// restoreFile writes the contents of a file extracted from an archive.
+func restoreFile(path string, perm os.FileMode, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // Tears down previously build directory structure.
 func (d *AllocDir) Destroy() error {
+	// Unmount all host volumes.
+	for _, m := range d.VolumeMounts {
+		if err := d.unmountVolume(m); err != nil {
+			return fmt.Errorf("Failed to unmount host volume: %v", err)
+		}
+	}
+
 	// Unmount all mounted shared alloc dirs.
 	for _, m := range d.mounted {
 		if err := d.unmountSharedDir(m); err != nil {
@@ -201,6 +412,174 @@ func (d *AllocDir) MountSharedDir(task string) error {
 	return nil
 }
 
+// MountVolume mounts the host path into the specified task's directory at the
+// destination, which is relative to the task directory. A volume that was
+// mounted by a previous run of the task is kept. Mount is documented at an OS
+// level in their respective implementation files.
+func (d *AllocDir) MountVolume(task, source, dest string, readOnly bool) error {
+	taskDir, ok := d.TaskDirs[task]
+	if !ok {
+		return fmt.Errorf("No task directory exists for %v", task)
+	}
+
+	target := filepath.Join(taskDir, dest)
+	if target == taskDir || !withinDir(taskDir, target) {
+		return ErrInvalidPath
+	}
+
+	// The destination must not lead out of the task directory through a
+	// symlink, which is checked before creating any missing directories
+	root, err := filepath.EvalSymlinks(taskDir)
+	if err != nil {
+		return err
+	}
+	existing := target
+	for existing != taskDir {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+	if !withinDir(root, resolved) {
+		return ErrInvalidPath
+	}
+
+	if err := os.MkdirAll(target, 0777); err != nil {
+		return fmt.Errorf("Couldn't create volume destination %v: %v", target, err)
+	}
+	if target, err = filepath.EvalSymlinks(target); err != nil {
+		return err
+	}
+	if target == root || !withinDir(root, target) {
+		return ErrInvalidPath
+	}
+
+	for _, m := range d.VolumeMounts {
+		if m == target {
+			return nil
+		}
+	}
+	if err := d.mountVolume(source, target, readOnly); err != nil {
+		return fmt.Errorf("Failed to mount host volume %v for task %v: %v", source, task, err)
+	}
+
+	d.VolumeMounts = append(d.VolumeMounts, target)
+	return nil
+}
+
+// List returns the files of a directory of the allocation. The path is
+// relative to the allocation directory.
+func (d *AllocDir) List(path string) ([]*AllocFileInfo, error) {
+	p, err := d.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*AllocFileInfo, 0, len(files))
+	for _, fi := range files {
+		infos = append(infos, newAllocFileInfo(fi))
+	}
+	return infos, nil
+}
+
+// Stat returns information about a file of the allocation. The path is
+// relative to the allocation directory.
+func (d *AllocDir) Stat(path string) (*AllocFileInfo, error) {
+	p, err := d.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	return newAllocFileInfo(fi), nil
+}
+
+// ReadAt returns a reader of a file of the allocation starting at the offset.
+// If limit is positive, at most limit bytes are read. The path is relative to
+// the allocation directory.
+func (d *AllocDir) ReadAt(path string, offset, limit int64) (io.ReadCloser, error) {
+	p, err := d.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Couldn't seek to offset %d: %v", offset, err)
+	}
+	if limit <= 0 {
+		return f, nil
+	}
+	return &limitReadCloser{Reader: io.LimitReader(f, limit), Closer: f}, nil
+}
+
+// limitReadCloser closes the file a limited reader reads from.
+type limitReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// resolve returns the absolute path of a path relative to the allocation
+// directory. ErrInvalidPath is returned if the path, after following any
+// symlinks, is outside of the allocation directory.
+func (d *AllocDir) resolve(path string) (string, error) {
+	p := filepath.Join(d.AllocDir, path)
+	if !withinDir(d.AllocDir, p) {
+		return "", ErrInvalidPath
+	}
+
+	// A symlink must not point outside of the allocation directory either
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return "", err
+	}
+	root, err := filepath.EvalSymlinks(d.AllocDir)
+	if err != nil {
+		return "", err
+	}
+	if !withinDir(root, resolved) {
+		return "", ErrInvalidPath
+	}
+	return resolved, nil
+}
+
+// withinDir returns whether the path is the directory or inside of it.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func newAllocFileInfo(fi os.FileInfo) *AllocFileInfo {
+	return &AllocFileInfo{
+		Name:     fi.Name(),
+		IsDir:    fi.IsDir(),
+		Size:     fi.Size(),
+		FileMode: fi.Mode().String(),
+		ModTime:  fi.ModTime(),
+	}
+}
+
 func fileCopy(src, dst string, perm os.FileMode) error {
 	// Do a simple copy.
 	srcFile, err := os.Open(src)
@@ -1,6 +1,7 @@
 package allocdir
 
 import (
+	"errors"
 	"syscall"
 )
 
@@ -13,3 +14,13 @@ func (d *AllocDir) mountSharedDir(dir string) error {
 func (d *AllocDir) unmountSharedDir(dir string) error {
 	return syscall.Unlink(dir)
 }
+
+// Host volumes can't be mounted on Darwin.
+func (d *AllocDir) mountVolume(source, target string, readOnly bool) error {
+	return errors.New("Mounting host volumes on Darwin not supported.")
+}
+
+// Host volumes can't be mounted on Darwin.
+func (d *AllocDir) unmountVolume(target string) error {
+	return nil
+}
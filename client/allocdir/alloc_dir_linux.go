@@ -18,3 +18,30 @@ func (d *AllocDir) mountSharedDir(taskDir string) error {
 func (d *AllocDir) unmountSharedDir(dir string) error {
 	return syscall.Unmount(dir, 0)
 }
+
+// Bind mounts a host volume, remounting it read-only if requested as the
+// flag is ignored when creating a bind mount. Must be root to run.
+func (d *AllocDir) mountVolume(source, target string, readOnly bool) error {
+	if err := syscall.Mount(source, target, "", syscall.MS_BIND, ""); err != nil {
+		return err
+	}
+	if !readOnly {
+		return nil
+	}
+
+	flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY)
+	if err := syscall.Mount("", target, "", flags, ""); err != nil {
+		syscall.Unmount(target, 0)
+		return err
+	}
+	return nil
+}
+
+// Unmounts a host volume. A volume that is no longer mounted, for example
+// after a reboot, is ignored.
+func (d *AllocDir) unmountVolume(target string) error {
+	if err := syscall.Unmount(target, 0); err != nil && err != syscall.EINVAL && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
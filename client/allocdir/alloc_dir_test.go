@@ -1,6 +1,7 @@
 package allocdir
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -192,3 +193,215 @@ func TestAllocDir_MountSharedAlloc(t *testing.T) {
 		}
 	}
 }
+
+func TestAllocDir_ListStatReadAt(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	d := NewAllocDir(tmp)
+	tasks := []*structs.Task{t1}
+	if err := d.Build(tasks); err != nil {
+		t.Fatalf("Build(%v) failed: %v", tasks, err)
+	}
+
+	file := filepath.Join(t1.Name, TaskLocal, "foo.txt")
+	if err := ioutil.WriteFile(filepath.Join(tmp, file), []byte("hello world"), 0666); err != nil {
+		t.Fatalf("Couldn't write file: %v", err)
+	}
+
+	files, err := d.List(filepath.Join(t1.Name, TaskLocal))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "foo.txt" || files[0].IsDir || files[0].Size != 11 {
+		t.Fatalf("bad: %#v", files)
+	}
+
+	info, err := d.Stat(t1.Name)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if !info.IsDir || info.Name != t1.Name {
+		t.Fatalf("bad: %#v", info)
+	}
+	if _, err := d.Stat("missing"); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got: %v", err)
+	}
+
+	r, err := d.ReadAt(file, 6, 3)
+	if err != nil {
+		t.Fatalf("ReadAt failed: %v", err)
+	}
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(data) != "wor" {
+		t.Fatalf("bad: %q", data)
+	}
+}
+
+func TestAllocDir_RejectsEscapes(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	d := NewAllocDir(tmp)
+	tasks := []*structs.Task{t1}
+	if err := d.Build(tasks); err != nil {
+		t.Fatalf("Build(%v) failed: %v", tasks, err)
+	}
+
+	// A symlink pointing outside of the allocation directory
+	link := filepath.Join(t1.Name, TaskLocal, "etc")
+	if err := os.Symlink("/etc", filepath.Join(tmp, link)); err != nil {
+		t.Fatalf("Couldn't create symlink: %v", err)
+	}
+
+	for _, path := range []string{"..", "../foo", "/../../etc/passwd", link, filepath.Join(link, "passwd")} {
+		if _, err := d.List(path); err != ErrInvalidPath {
+			t.Fatalf("List(%q): expected invalid path error, got: %v", path, err)
+		}
+		if _, err := d.Stat(path); err != ErrInvalidPath {
+			t.Fatalf("Stat(%q): expected invalid path error, got: %v", path, err)
+		}
+		if _, err := d.ReadAt(path, 0, 0); err != ErrInvalidPath {
+			t.Fatalf("ReadAt(%q): expected invalid path error, got: %v", path, err)
+		}
+	}
+}
+
+func TestAllocDir_MountVolume_RejectsEscapes(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	outside, err := ioutil.TempDir("", "AllocDirOutside")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(outside)
+
+	d := NewAllocDir(tmp)
+	tasks := []*structs.Task{t1}
+	if err := d.Build(tasks); err != nil {
+		t.Fatalf("Build(%v) failed: %v", tasks, err)
+	}
+
+	// A symlink pointing outside of the task directory
+	link := filepath.Join(TaskLocal, "outside")
+	if err := os.Symlink(outside, filepath.Join(d.TaskDirs[t1.Name], link)); err != nil {
+		t.Fatalf("Couldn't create symlink: %v", err)
+	}
+
+	for _, dest := range []string{"/", "..", "../foo", link, filepath.Join(link, "foo")} {
+		if err := d.MountVolume(t1.Name, "/srv", dest, false); err != ErrInvalidPath {
+			t.Fatalf("MountVolume(%q): expected invalid path error, got: %v", dest, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "foo")); !os.IsNotExist(err) {
+		t.Fatalf("expected no directory outside of the task directory, got: %v", err)
+	}
+	if len(d.VolumeMounts) != 0 {
+		t.Fatalf("bad: %v", d.VolumeMounts)
+	}
+}
+
+func TestAllocDir_MoveData(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	prev := NewAllocDir(filepath.Join(tmp, "prev"))
+	d := NewAllocDir(filepath.Join(tmp, "next"))
+	tasks := []*structs.Task{t1}
+	for _, dir := range []*AllocDir{prev, d} {
+		if err := dir.Build(tasks); err != nil {
+			t.Fatalf("Build(%v) failed: %v", tasks, err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(prev.DataDir(), "foo"), []byte("bar"), 0666); err != nil {
+		t.Fatalf("Couldn't write file: %v", err)
+	}
+	if err := d.MoveData(prev); err != nil {
+		t.Fatalf("MoveData failed: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(d.DataDir(), "foo"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(data) != "bar" {
+		t.Fatalf("bad: %q", data)
+	}
+	if _, err := os.Stat(prev.DataDir()); !os.IsNotExist(err) {
+		t.Fatalf("expected previous data dir to be moved, got: %v", err)
+	}
+
+	// Moving again is a no-op since the previous data is gone
+	if err := d.MoveData(prev); err != nil {
+		t.Fatalf("MoveData failed: %v", err)
+	}
+}
+
+func TestAllocDir_SnapshotRestoreData(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	prev := NewAllocDir(filepath.Join(tmp, "prev"))
+	d := NewAllocDir(filepath.Join(tmp, "next"))
+	tasks := []*structs.Task{t1}
+	for _, dir := range []*AllocDir{prev, d} {
+		if err := dir.Build(tasks); err != nil {
+			t.Fatalf("Build(%v) failed: %v", tasks, err)
+		}
+	}
+
+	sub := filepath.Join(prev.DataDir(), "sub")
+	if err := os.Mkdir(sub, 0750); err != nil {
+		t.Fatalf("Couldn't create dir: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(sub, "foo"), []byte("bar"), 0640); err != nil {
+		t.Fatalf("Couldn't write file: %v", err)
+	}
+	if err := os.Symlink("sub/foo", filepath.Join(prev.DataDir(), "link")); err != nil {
+		t.Fatalf("Couldn't create symlink: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := prev.SnapshotData(&buf); err != nil {
+		t.Fatalf("SnapshotData failed: %v", err)
+	}
+	if err := d.RestoreData(&buf); err != nil {
+		t.Fatalf("RestoreData failed: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(d.DataDir(), "link"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(data) != "bar" {
+		t.Fatalf("bad: %q", data)
+	}
+	fi, err := os.Stat(filepath.Join(d.DataDir(), "sub", "foo"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fi.Mode().Perm() != 0640 {
+		t.Fatalf("bad mode: %v", fi.Mode())
+	}
+}
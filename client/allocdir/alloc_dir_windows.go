@@ -23,3 +23,13 @@ func (d *AllocDir) dropDirPermissions(path string) error {
 func (d *AllocDir) unmountSharedDir(dir string) error {
 	return nil
 }
+
+// The windows version does nothing currently.
+func (d *AllocDir) mountVolume(source, target string, readOnly bool) error {
+	return errors.New("Mounting host volumes on Windows not supported.")
+}
+
+// The windows version does nothing currently.
+func (d *AllocDir) unmountVolume(target string) error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -11,10 +12,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/client/driver/logging"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/client/fingerprint"
+	"github.com/hashicorp/nomad/client/stats"
 	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -51,13 +57,40 @@ const (
 	// starting and the intial heartbeat. After the intial heartbeat,
 	// we switch to using the TTL specified by the servers.
 	initialHeartbeatStagger = 10 * time.Second
+
+	// defaultStatsCollectionInterval is the default interval at which the
+	// resource usage of the host and of the running tasks is sampled
+	defaultStatsCollectionInterval = 1 * time.Second
+
+	// defaultGCInterval is the default interval at which the usage of the
+	// filesystem of the alloc dir is checked by the garbage collector
+	defaultGCInterval = 1 * time.Minute
+
+	// defaultGCDiskUsageThreshold and defaultGCInodeUsageThreshold are the
+	// default disk and inode usage percentages above which terminal
+	// allocations are garbage collected
+	defaultGCDiskUsageThreshold  = 80
+	defaultGCInodeUsageThreshold = 70
+
+	// defaultGCMaxAllocs is the default maximum number of terminal
+	// allocations retained
+	defaultGCMaxAllocs = 50
 )
 
+// ErrUnknownAllocation is returned when an allocation isn't running on the
+// client
+var ErrUnknownAllocation = errors.New("unknown allocation")
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *config.Config {
 	return &config.Config{
-		LogOutput: os.Stderr,
-		Region:    "global",
+		LogOutput:               os.Stderr,
+		Region:                  "global",
+		StatsCollectionInterval: defaultStatsCollectionInterval,
+		GCInterval:              defaultGCInterval,
+		GCDiskUsageThreshold:    defaultGCDiskUsageThreshold,
+		GCInodeUsageThreshold:   defaultGCInodeUsageThreshold,
+		GCMaxAllocs:             defaultGCMaxAllocs,
 	}
 }
 
@@ -86,6 +119,19 @@ type Client struct {
 	allocs    map[string]*AllocRunner
 	allocLock sync.RWMutex
 
+	// consulService registers the services of the running tasks with Consul
+	consulService *ConsulService
+
+	// hostStatsCollector samples the resource usage of the host and
+	// hostStats is its latest sample
+	hostStatsCollector *stats.HostStatsCollector
+	hostStats          *stats.HostStats
+	hostStatsLock      sync.RWMutex
+
+	// garbageCollector retains the runners of allocations removed by the
+	// servers and destroys them to free up disk space
+	garbageCollector *AllocGarbageCollector
+
 	shutdown     bool
 	shutdownCh   chan struct{}
 	shutdownLock sync.Mutex
@@ -126,6 +172,11 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		return nil, fmt.Errorf("driver setup failed: %v", err)
 	}
 
+	// Setup the Consul service syncer
+	if err := c.setupConsulService(); err != nil {
+		return nil, fmt.Errorf("failed to create the consul service: %v", err)
+	}
+
 	// Set up the known servers list
 	c.SetServers(c.config.Servers)
 
@@ -136,6 +187,8 @@ func NewClient(cfg *config.Config) (*Client, error) {
 
 	// Start the client!
 	go c.run()
+	go c.collectHostStats()
+	go c.garbageCollector.Run(c.shutdownCh)
 	return c, nil
 }
 
@@ -173,6 +226,36 @@ func (c *Client) init() error {
 	}
 
 	c.logger.Printf("[INFO] client: using alloc directory %v", c.config.AllocDir)
+
+	if c.config.StatsCollectionInterval == 0 {
+		c.config.StatsCollectionInterval = defaultStatsCollectionInterval
+	}
+	c.hostStatsCollector = stats.NewHostStatsCollector(c.config.AllocDir)
+
+	if c.config.GCInterval == 0 {
+		c.config.GCInterval = defaultGCInterval
+	}
+	if c.config.GCDiskUsageThreshold == 0 {
+		c.config.GCDiskUsageThreshold = defaultGCDiskUsageThreshold
+	}
+	if c.config.GCInodeUsageThreshold == 0 {
+		c.config.GCInodeUsageThreshold = defaultGCInodeUsageThreshold
+	}
+	if c.config.GCMaxAllocs == 0 {
+		c.config.GCMaxAllocs = defaultGCMaxAllocs
+	}
+	c.garbageCollector = NewAllocGarbageCollector(c.logger, c.config)
+	return nil
+}
+
+// setupConsulService creates the syncer of the tasks' services with Consul
+func (c *Client) setupConsulService() error {
+	consulService, err := NewConsulService(c.logger, c.config)
+	if err != nil {
+		return err
+	}
+	c.consulService = consulService
+	go consulService.SyncWithConsul()
 	return nil
 }
 
@@ -192,16 +275,18 @@ func (c *Client) Shutdown() error {
 		return nil
 	}
 
-	// Destroy all the running allocations.
+	// Destroy all the running and retained allocations.
 	if c.config.DevMode {
 		for _, ar := range c.allocs {
 			ar.Destroy()
 			<-ar.WaitCh()
 		}
+		c.garbageCollector.CollectAll()
 	}
 
 	c.shutdown = true
 	close(c.shutdownCh)
+	c.consulService.Shutdown()
 	c.connPool.Shutdown()
 	return c.saveState()
 }
@@ -316,6 +401,156 @@ func (c *Client) Node() *structs.Node {
 	return c.config.Node
 }
 
+// LatestHostStats returns the latest sample of the resource usage of the
+// host or nil if none was collected yet
+func (c *Client) LatestHostStats() *stats.HostStats {
+	c.hostStatsLock.RLock()
+	defer c.hostStatsLock.RUnlock()
+	return c.hostStats
+}
+
+// collectHostStats periodically samples the resource usage of the host until
+// the client shuts down
+func (c *Client) collectHostStats() {
+	ticker := time.NewTicker(c.config.StatsCollectionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			hs, err := c.hostStatsCollector.Collect()
+			if err != nil {
+				c.logger.Printf("[WARN] client: failed to collect host stats: %v", err)
+				continue
+			}
+			c.hostStatsLock.Lock()
+			c.hostStats = hs
+			c.hostStatsLock.Unlock()
+
+			if c.config.PublishNodeMetrics {
+				c.emitHostStats(hs)
+			}
+		case <-c.shutdownCh:
+			return
+		}
+	}
+}
+
+// emitHostStats emits the resource usage of the host as gauges
+func (c *Client) emitHostStats(hs *stats.HostStats) {
+	nodeID := c.Node().ID
+	prefix := []string{"client", "host"}
+	gauge := func(key []string, val float32) {
+		metrics.SetGauge(append(append([]string{}, prefix...), key...), val)
+	}
+
+	gauge([]string{"memory", nodeID, "total"}, float32(hs.Memory.Total))
+	gauge([]string{"memory", nodeID, "available"}, float32(hs.Memory.Available))
+	gauge([]string{"memory", nodeID, "used"}, float32(hs.Memory.Used))
+	gauge([]string{"memory", nodeID, "free"}, float32(hs.Memory.Free))
+
+	for _, cpu := range hs.CPU {
+		gauge([]string{"cpu", nodeID, cpu.CPU, "total"}, float32(cpu.Total))
+		gauge([]string{"cpu", nodeID, cpu.CPU, "user"}, float32(cpu.User))
+		gauge([]string{"cpu", nodeID, cpu.CPU, "system"}, float32(cpu.System))
+		gauge([]string{"cpu", nodeID, cpu.CPU, "idle"}, float32(cpu.Idle))
+	}
+
+	for _, disk := range hs.DiskStats {
+		gauge([]string{"disk", nodeID, disk.Device, "size"}, float32(disk.Size))
+		gauge([]string{"disk", nodeID, disk.Device, "used"}, float32(disk.Used))
+		gauge([]string{"disk", nodeID, disk.Device, "available"}, float32(disk.Available))
+		gauge([]string{"disk", nodeID, disk.Device, "used_percent"}, float32(disk.UsedPercent))
+		gauge([]string{"disk", nodeID, disk.Device, "inodes_percent"}, float32(disk.InodesUsedPercent))
+	}
+
+	gauge([]string{"uptime", nodeID}, float32(hs.Uptime))
+}
+
+// GetAllocDir returns the directory of an allocation. ErrUnknownAllocation is
+// returned if the allocation isn't running on this client.
+func (c *Client) GetAllocDir(allocID string) (*allocdir.AllocDir, error) {
+	c.allocLock.RLock()
+	_, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return nil, ErrUnknownAllocation
+	}
+	return allocdir.NewAllocDir(filepath.Join(c.config.AllocDir, allocID)), nil
+}
+
+// AllocStats returns the latest resource usage of an allocation.
+// ErrUnknownAllocation is returned if the allocation isn't running on this
+// client.
+func (c *Client) AllocStats(allocID string) (*cstructs.AllocResourceUsage, error) {
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return nil, ErrUnknownAllocation
+	}
+	return ar.LatestAllocStats(), nil
+}
+
+// ExecTask runs a command in the environment of a running task of an
+// allocation and returns its exit code once it exits. ErrUnknownAllocation is
+// returned if the allocation isn't running on this client.
+func (c *Client) ExecTask(allocID, task string, opts *cstructs.ExecOptions) (int, error) {
+	tr, err := c.taskRunner(allocID, task)
+	if err != nil {
+		return 0, err
+	}
+	return tr.Exec(opts)
+}
+
+// ValidateExecTask returns an error if a command can't be run for the task of
+// the allocation. ErrUnknownAllocation is returned if the allocation isn't
+// running on this client.
+func (c *Client) ValidateExecTask(allocID, task string) error {
+	_, err := c.taskRunner(allocID, task)
+	return err
+}
+
+// taskRunner returns the runner of a task of an allocation running on this
+// client
+func (c *Client) taskRunner(allocID, task string) (*TaskRunner, error) {
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return nil, ErrUnknownAllocation
+	}
+
+	tr := ar.TaskRunner(task)
+	if tr == nil {
+		return nil, fmt.Errorf("unknown task %q in allocation %q", task, allocID)
+	}
+	return tr, nil
+}
+
+// TaskLogPath returns the base path of the rotated logs of the given type of a
+// task. ErrUnknownAllocation is returned if the allocation isn't running on
+// this client.
+func (c *Client) TaskLogPath(allocID, task, logType string) (string, error) {
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return "", ErrUnknownAllocation
+	}
+
+	alloc := ar.Alloc()
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	if tg == nil || tg.LookupTask(task) == nil {
+		return "", fmt.Errorf("unknown task %q in allocation %q", task, allocID)
+	}
+	if logType != logging.Stdout && logType != logging.Stderr {
+		return "", fmt.Errorf("invalid log type %q", logType)
+	}
+
+	allocDir := allocdir.NewAllocDir(filepath.Join(c.config.AllocDir, allocID))
+	return logging.LogPath(allocDir.LogDir(), task, logType), nil
+}
+
 // restoreState is used to restore our state from the data dir
 func (c *Client) restoreState() error {
 	if c.config.DevMode {
@@ -335,7 +570,7 @@ func (c *Client) restoreState() error {
 	for _, entry := range list {
 		id := entry.Name()
 		alloc := &structs.Allocation{ID: id}
-		ar := NewAllocRunner(c.logger, c.config, c.updateAllocStatus, alloc)
+		ar := NewAllocRunner(c.logger, c.config, c.updateAllocStatus, alloc, c.consulService)
 		c.allocs[id] = ar
 		if err := ar.RestoreState(); err != nil {
 			c.logger.Printf("[ERR] client: failed to restore state for alloc %s: %v", id, err)
@@ -718,20 +953,35 @@ func (c *Client) runAllocs(updated []*structs.Allocation) {
 	}
 }
 
-// removeAlloc is invoked when we should remove an allocation
+// removeAlloc is invoked when we should remove an allocation. The allocation
+// is retained by the garbage collector, which destroys it later on.
 func (c *Client) removeAlloc(alloc *structs.Allocation) error {
 	c.allocLock.Lock()
-	defer c.allocLock.Unlock()
 	ar, ok := c.allocs[alloc.ID]
 	if !ok {
+		c.allocLock.Unlock()
 		c.logger.Printf("[WARN] client: missing context for alloc '%s'", alloc.ID)
 		return nil
 	}
-	ar.Destroy()
 	delete(c.allocs, alloc.ID)
+	c.allocLock.Unlock()
+
+	// Stop the tasks of an allocation that was removed while running
+	if exist := ar.Alloc(); !exist.TerminalStatus() {
+		stopped := *exist
+		stopped.DesiredStatus = structs.AllocDesiredStatusStop
+		ar.Update(&stopped)
+	}
+	c.garbageCollector.MarkForCollection(ar)
 	return nil
 }
 
+// CollectAllAllocs destroys all the allocations retained after the servers
+// removed them
+func (c *Client) CollectAllAllocs() {
+	c.garbageCollector.CollectAll()
+}
+
 // updateAlloc is invoked when we should update an allocation
 func (c *Client) updateAlloc(exist, update *structs.Allocation) error {
 	c.allocLock.RLock()
@@ -749,7 +999,8 @@ func (c *Client) updateAlloc(exist, update *structs.Allocation) error {
 func (c *Client) addAlloc(alloc *structs.Allocation) error {
 	c.allocLock.Lock()
 	defer c.allocLock.Unlock()
-	ar := NewAllocRunner(c.logger, c.config, c.updateAllocStatus, alloc)
+	ar := NewAllocRunner(c.logger, c.config, c.updateAllocStatus, alloc, c.consulService)
+	ar.prevAlloc = c.newPrevAllocMigrator(alloc)
 	c.allocs[alloc.ID] = ar
 	go ar.Run()
 	return nil
@@ -2,6 +2,7 @@ package config
 
 import (
 	"io"
+	"time"
 
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -48,6 +49,30 @@ type Config struct {
 	// Node provides the base node
 	Node *structs.Node
 
+	// StatsCollectionInterval is the interval at which the resource usage of
+	// the host and of the running tasks is sampled
+	StatsCollectionInterval time.Duration
+
+	// PublishNodeMetrics emits the resource usage of the host through the
+	// metrics sinks
+	PublishNodeMetrics bool
+
+	// GCInterval is the interval at which the usage of the filesystem of the
+	// alloc dir is checked by the garbage collector of terminal allocations
+	GCInterval time.Duration
+
+	// GCDiskUsageThreshold is the disk usage percentage of the filesystem of
+	// the alloc dir above which terminal allocations are garbage collected
+	GCDiskUsageThreshold float64
+
+	// GCInodeUsageThreshold is the inode usage percentage of the filesystem
+	// of the alloc dir above which terminal allocations are garbage collected
+	GCInodeUsageThreshold float64
+
+	// GCMaxAllocs is the maximum number of terminal allocations whose alloc
+	// dirs are retained
+	GCMaxAllocs int
+
 	// Options provides arbitrary key-value configuration for nomad internals,
 	// like fingerprinters and drivers. The format is:
 	//
@@ -0,0 +1,313 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// consulSyncIntv is the interval on which the services registered by
+	// Nomad are reconciled with the local Consul agent
+	consulSyncIntv = 15 * time.Second
+
+	// nomadServicePrefix is the prefix of the IDs of the services and checks
+	// registered by Nomad. It allows us to find the services that are no
+	// longer tracked.
+	nomadServicePrefix = "nomad"
+)
+
+// trackedService is a service of a task registered with Consul
+type trackedService struct {
+	allocID  string
+	taskName string
+	service  *consul.AgentServiceRegistration
+	checks   []*consul.AgentCheckRegistration
+}
+
+// ConsulService is used to register the services of the running tasks and
+// their health checks with the local Consul agent.
+type ConsulService struct {
+	client *consul.Client
+	logger *log.Logger
+
+	// tracked is the set of services registered by Nomad, keyed by service ID
+	tracked     map[string]*trackedService
+	trackedLock sync.Mutex
+
+	shutdown     bool
+	shutdownCh   chan struct{}
+	shutdownLock sync.Mutex
+}
+
+// NewConsulService returns a ConsulService talking to the Consul agent at the
+// address of the client's consul.address option.
+func NewConsulService(logger *log.Logger, config *config.Config) (*ConsulService, error) {
+	consulConfig := consul.DefaultConfig()
+	consulConfig.Address = config.ReadDefault("consul.address", "127.0.0.1:8500")
+
+	client, err := consul.NewClient(consulConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize consul client: %v", err)
+	}
+
+	c := &ConsulService{
+		client:     client,
+		logger:     logger,
+		tracked:    make(map[string]*trackedService),
+		shutdownCh: make(chan struct{}),
+	}
+	return c, nil
+}
+
+// Register registers the services of a task and their checks with Consul.
+// Services the task previously registered but no longer defines are
+// deregistered. The services remain tracked if Consul can't be reached so
+// that they are registered on the next sync.
+func (c *ConsulService) Register(task *structs.Task, allocID string) error {
+	var mErr multierror.Error
+	current := make(map[string]struct{}, len(task.Services))
+	for _, service := range task.Services {
+		ts, err := c.makeService(task, allocID, service)
+		if err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+			continue
+		}
+		current[ts.service.ID] = struct{}{}
+
+		c.trackedLock.Lock()
+		c.tracked[ts.service.ID] = ts
+		c.trackedLock.Unlock()
+
+		if err := c.registerService(ts); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	// Remove the services the task no longer defines
+	for _, id := range c.taskServices(task.Name, allocID) {
+		if _, ok := current[id]; ok {
+			continue
+		}
+		if err := c.deregisterService(id); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Deregister removes the services of a task and their checks from Consul
+func (c *ConsulService) Deregister(task *structs.Task, allocID string) error {
+	var mErr multierror.Error
+	for _, id := range c.taskServices(task.Name, allocID) {
+		if err := c.deregisterService(id); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// SyncWithConsul periodically registers the tracked services that are missing
+// from the Consul agent, for example after the agent restarted, and removes
+// the services Nomad registered but no longer tracks. It blocks until the
+// ConsulService is shutdown.
+func (c *ConsulService) SyncWithConsul() {
+	ticker := time.NewTicker(consulSyncIntv)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.performSync(); err != nil {
+				c.logger.Printf("[DEBUG] client: failed to sync services with consul: %v", err)
+			}
+		case <-c.shutdownCh:
+			return
+		}
+	}
+}
+
+// Shutdown stops syncing the services with Consul
+func (c *ConsulService) Shutdown() {
+	c.shutdownLock.Lock()
+	defer c.shutdownLock.Unlock()
+
+	if c.shutdown {
+		return
+	}
+	c.shutdown = true
+	close(c.shutdownCh)
+}
+
+// performSync reconciles the services registered with the Consul agent with
+// the tracked services.
+func (c *ConsulService) performSync() error {
+	services, err := c.client.Agent().Services()
+	if err != nil {
+		return err
+	}
+
+	c.trackedLock.Lock()
+	var missing []*trackedService
+	for id, ts := range c.tracked {
+		if _, ok := services[id]; !ok {
+			missing = append(missing, ts)
+		}
+	}
+	var orphaned []string
+	for id := range services {
+		if _, ok := c.tracked[id]; !ok && strings.HasPrefix(id, nomadServicePrefix+"-") {
+			orphaned = append(orphaned, id)
+		}
+	}
+	c.trackedLock.Unlock()
+
+	var mErr multierror.Error
+	for _, ts := range missing {
+		if err := c.registerService(ts); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+	for _, id := range orphaned {
+		if err := c.client.Agent().ServiceDeregister(id); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// registerService registers a tracked service and its checks with Consul
+func (c *ConsulService) registerService(ts *trackedService) error {
+	if err := c.client.Agent().ServiceRegister(ts.service); err != nil {
+		return fmt.Errorf("failed to register service %q: %v", ts.service.Name, err)
+	}
+	for _, check := range ts.checks {
+		if err := c.client.Agent().CheckRegister(check); err != nil {
+			return fmt.Errorf("failed to register check %q of service %q: %v",
+				check.Name, ts.service.Name, err)
+		}
+	}
+	return nil
+}
+
+// deregisterService stops tracking a service and removes it along with its
+// checks from Consul.
+func (c *ConsulService) deregisterService(id string) error {
+	c.trackedLock.Lock()
+	ts, ok := c.tracked[id]
+	delete(c.tracked, id)
+	c.trackedLock.Unlock()
+	if !ok {
+		return nil
+	}
+
+	for _, check := range ts.checks {
+		if err := c.client.Agent().CheckDeregister(check.ID); err != nil {
+			return fmt.Errorf("failed to deregister check %q of service %q: %v",
+				check.Name, ts.service.Name, err)
+		}
+	}
+	if err := c.client.Agent().ServiceDeregister(id); err != nil {
+		return fmt.Errorf("failed to deregister service %q: %v", ts.service.Name, err)
+	}
+	return nil
+}
+
+// taskServices returns the IDs of the tracked services of a task
+func (c *ConsulService) taskServices(taskName, allocID string) []string {
+	c.trackedLock.Lock()
+	defer c.trackedLock.Unlock()
+
+	var ids []string
+	for id, ts := range c.tracked {
+		if ts.allocID == allocID && ts.taskName == taskName {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// makeService builds the Consul registrations of a service of a task
+func (c *ConsulService) makeService(task *structs.Task, allocID string, service *structs.Service) (*trackedService, error) {
+	ts := &trackedService{
+		allocID:  allocID,
+		taskName: task.Name,
+		service: &consul.AgentServiceRegistration{
+			ID:   serviceID(allocID, task.Name, service.Name),
+			Name: service.Name,
+			Tags: service.Tags,
+		},
+	}
+
+	var ip string
+	var port int
+	if service.PortLabel != "" {
+		var err error
+		ip, port, err = resolvePort(task, service.PortLabel)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %v", service.Name, err)
+		}
+		ts.service.Address = ip
+		ts.service.Port = port
+	}
+
+	for idx, check := range service.Checks {
+		reg := &consul.AgentCheckRegistration{
+			ID:        fmt.Sprintf("%s-%d", ts.service.ID, idx),
+			Name:      check.Name,
+			ServiceID: ts.service.ID,
+		}
+		reg.Interval = check.Interval.String()
+		if check.Timeout > 0 {
+			reg.Timeout = check.Timeout.String()
+		}
+
+		switch check.Type {
+		case structs.ServiceCheckHTTP:
+			protocol := check.Protocol
+			if protocol == "" {
+				protocol = "http"
+			}
+			reg.HTTP = fmt.Sprintf("%s://%s:%d%s", protocol, ip, port, check.Path)
+		case structs.ServiceCheckTCP:
+			reg.TCP = fmt.Sprintf("%s:%d", ip, port)
+		case structs.ServiceCheckScript:
+			reg.Script = check.Script
+		}
+		ts.checks = append(ts.checks, reg)
+	}
+	return ts, nil
+}
+
+// serviceID returns the ID of the service of a task registered with Consul
+func serviceID(allocID, taskName, serviceName string) string {
+	return fmt.Sprintf("%s-%s-%s-%s", nomadServicePrefix, allocID, taskName, serviceName)
+}
+
+// resolvePort returns the IP and port a port label of the task maps to. The
+// label is either the label of a dynamic port or a reserved port number.
+func resolvePort(task *structs.Task, label string) (string, int, error) {
+	if task.Resources == nil || len(task.Resources.Networks) == 0 {
+		return "", 0, fmt.Errorf("task has no network to resolve port %q", label)
+	}
+	network := task.Resources.Networks[0]
+
+	if port, ok := network.MapDynamicPorts()[label]; ok {
+		return network.IP, port, nil
+	}
+	if port, err := strconv.Atoi(label); err == nil {
+		for _, reserved := range network.ListStaticPorts() {
+			if reserved == port {
+				return network.IP, port, nil
+			}
+		}
+	}
+	return "", 0, fmt.Errorf("port %q not found", label)
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// fakeConsulAgent implements the parts of the Consul agent's HTTP API used to
+// register services and checks.
+type fakeConsulAgent struct {
+	services map[string]*consul.AgentServiceRegistration
+	checks   map[string]*consul.AgentCheckRegistration
+	lock     sync.Mutex
+}
+
+func (f *fakeConsulAgent) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	path := req.URL.Path
+	switch {
+	case path == "/v1/agent/services":
+		out := make(map[string]*consul.AgentService)
+		for id, s := range f.services {
+			out[id] = &consul.AgentService{ID: s.ID, Service: s.Name, Tags: s.Tags, Port: s.Port}
+		}
+		json.NewEncoder(w).Encode(out)
+	case path == "/v1/agent/service/register":
+		var s consul.AgentServiceRegistration
+		if err := json.NewDecoder(req.Body).Decode(&s); err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		f.services[s.ID] = &s
+	case strings.HasPrefix(path, "/v1/agent/service/deregister/"):
+		delete(f.services, strings.TrimPrefix(path, "/v1/agent/service/deregister/"))
+	case path == "/v1/agent/check/register":
+		var c consul.AgentCheckRegistration
+		if err := json.NewDecoder(req.Body).Decode(&c); err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		f.checks[c.ID] = &c
+	case strings.HasPrefix(path, "/v1/agent/check/deregister/"):
+		delete(f.checks, strings.TrimPrefix(path, "/v1/agent/check/deregister/"))
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func testConsulService(t *testing.T) (*fakeConsulAgent, *ConsulService, func()) {
+	agent := &fakeConsulAgent{
+		services: make(map[string]*consul.AgentServiceRegistration),
+		checks:   make(map[string]*consul.AgentCheckRegistration),
+	}
+	srv := httptest.NewServer(agent)
+
+	conf := DefaultConfig()
+	conf.Options = map[string]string{
+		"consul.address": strings.TrimPrefix(srv.URL, "http://"),
+	}
+	c, err := NewConsulService(testLogger(), conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return agent, c, srv.Close
+}
+
+func testServiceTask() (*structs.Allocation, *structs.Task) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Resources.Networks[0].IP = "10.0.0.1"
+	task.Resources.Networks[0].ReservedPorts = []int{8080}
+	task.Services = []*structs.Service{
+		&structs.Service{
+			Name:      "web-frontend",
+			Tags:      []string{"foo"},
+			PortLabel: "http",
+			Checks: []*structs.ServiceCheck{
+				&structs.ServiceCheck{
+					Name:     "alive",
+					Type:     structs.ServiceCheckHTTP,
+					Path:     "/health",
+					Interval: 10 * time.Second,
+					Timeout:  2 * time.Second,
+				},
+				&structs.ServiceCheck{
+					Name:     "tcp",
+					Type:     structs.ServiceCheckTCP,
+					Interval: 5 * time.Second,
+				},
+			},
+		},
+	}
+	return alloc, task
+}
+
+func TestConsulService_Register(t *testing.T) {
+	agent, c, cleanup := testConsulService(t)
+	defer cleanup()
+	alloc, task := testServiceTask()
+
+	if err := c.Register(task, alloc.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	id := serviceID(alloc.ID, task.Name, "web-frontend")
+	service, ok := agent.services[id]
+	if !ok {
+		t.Fatalf("service not registered: %#v", agent.services)
+	}
+	if service.Name != "web-frontend" || service.Address != "10.0.0.1" || service.Port != 8080 {
+		t.Fatalf("bad: %#v", service)
+	}
+
+	if len(agent.checks) != 2 {
+		t.Fatalf("bad: %#v", agent.checks)
+	}
+	httpCheck := agent.checks[id+"-0"]
+	if httpCheck == nil || httpCheck.ServiceID != id || httpCheck.HTTP != "http://10.0.0.1:8080/health" ||
+		httpCheck.Interval != "10s" || httpCheck.Timeout != "2s" {
+		t.Fatalf("bad: %#v", httpCheck)
+	}
+	tcp := agent.checks[id+"-1"]
+	if tcp == nil || tcp.TCP != "10.0.0.1:8080" || tcp.Interval != "5s" {
+		t.Fatalf("bad: %#v", tcp)
+	}
+
+	// Renaming the service replaces the registration
+	task.Services[0].Name = "web"
+	task.Services[0].Checks = nil
+	if err := c.Register(task, alloc.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(agent.services) != 1 || len(agent.checks) != 0 {
+		t.Fatalf("bad: %#v %#v", agent.services, agent.checks)
+	}
+	if _, ok := agent.services[serviceID(alloc.ID, task.Name, "web")]; !ok {
+		t.Fatalf("service not registered: %#v", agent.services)
+	}
+}
+
+func TestConsulService_Register_BadPort(t *testing.T) {
+	agent, c, cleanup := testConsulService(t)
+	defer cleanup()
+	alloc, task := testServiceTask()
+	task.Services[0].PortLabel = "admin"
+
+	if err := c.Register(task, alloc.ID); err == nil {
+		t.Fatalf("expected error")
+	}
+	if len(agent.services) != 0 {
+		t.Fatalf("bad: %#v", agent.services)
+	}
+}
+
+func TestConsulService_Deregister(t *testing.T) {
+	agent, c, cleanup := testConsulService(t)
+	defer cleanup()
+	alloc, task := testServiceTask()
+
+	if err := c.Register(task, alloc.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.Deregister(task, alloc.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(agent.services) != 0 || len(agent.checks) != 0 {
+		t.Fatalf("bad: %#v %#v", agent.services, agent.checks)
+	}
+}
+
+func TestConsulService_PerformSync(t *testing.T) {
+	agent, c, cleanup := testConsulService(t)
+	defer cleanup()
+	alloc, task := testServiceTask()
+
+	if err := c.Register(task, alloc.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Simulate the agent losing the registration and an orphaned service
+	id := serviceID(alloc.ID, task.Name, "web-frontend")
+	delete(agent.services, id)
+	orphan := serviceID(structs.GenerateUUID(), "web", "old")
+	agent.services[orphan] = &consul.AgentServiceRegistration{ID: orphan, Name: "old"}
+	agent.services["consul"] = &consul.AgentServiceRegistration{ID: "consul", Name: "consul"}
+
+	if err := c.performSync(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := agent.services[id]; !ok {
+		t.Fatalf("service not re-registered: %#v", agent.services)
+	}
+	if _, ok := agent.services[orphan]; ok {
+		t.Fatalf("orphaned service not removed: %#v", agent.services)
+	}
+	if _, ok := agent.services["consul"]; !ok {
+		t.Fatalf("service not registered by nomad removed: %#v", agent.services)
+	}
+}
@@ -5,15 +5,21 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 
 	docker "github.com/fsouza/go-dockerclient"
 
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/args"
+	"github.com/hashicorp/nomad/client/driver/logging"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/client/fingerprint"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -26,6 +32,7 @@ type DockerDriver struct {
 type dockerPID struct {
 	ImageID     string
 	ContainerID string
+	LogConfig   *structs.LogConfig
 }
 
 type dockerHandle struct {
@@ -35,8 +42,15 @@ type dockerHandle struct {
 	cleanupImage     bool
 	imageID          string
 	containerID      string
-	waitCh           chan error
+	logDir           string
+	taskName         string
+	logConfig        *structs.LogConfig
+	waitCh           chan *cstructs.WaitResult
 	doneCh           chan struct{}
+
+	// resourceUsage is the latest resource usage of the container
+	resourceUsage     *cstructs.TaskResourceUsage
+	resourceUsageLock sync.RWMutex
 }
 
 func NewDockerDriver(ctx *DriverContext) Driver {
@@ -100,19 +114,34 @@ func (d *DockerDriver) Fingerprint(cfg *config.Config, node *structs.Node) (bool
 	return true, nil
 }
 
-func (d *DockerDriver) containerBinds(alloc *allocdir.AllocDir, task *structs.Task) ([]string, error) {
+func (d *DockerDriver) containerBinds(ctx *ExecContext, task *structs.Task) ([]string, error) {
+	alloc := ctx.AllocDir
 	shared := alloc.SharedDir
 	local, ok := alloc.TaskDirs[task.Name]
 	if !ok {
 		return nil, fmt.Errorf("Failed to find task local directory: %v", task.Name)
 	}
 
-	return []string{
+	binds := []string{
 		// "z" and "Z" option is to allocate directory with SELinux label.
 		fmt.Sprintf("%s:/%s:rw,z", shared, allocdir.SharedAllocName),
 		// capital "Z" will label with Multi-Category Security (MCS) labels
 		fmt.Sprintf("%s:/%s:rw,Z", local, allocdir.TaskLocal),
-	}, nil
+	}
+
+	// Host volumes are shared with the host so they aren't relabeled
+	mounts, err := hostVolumeMounts(ctx, d.node, task)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mounts {
+		mode := "rw"
+		if m.ReadOnly {
+			mode = "ro"
+		}
+		binds = append(binds, fmt.Sprintf("%s:%s:%s", m.Source, path.Join("/", m.Destination), mode))
+	}
+	return binds, nil
 }
 
 // createContainer initializes a struct needed to call docker.client.CreateContainer()
@@ -123,7 +152,7 @@ func (d *DockerDriver) createContainer(ctx *ExecContext, task *structs.Task) (do
 		return c, fmt.Errorf("task.Resources is nil and we can't constrain resource usage. We shouldn't have been able to schedule this in the first place.")
 	}
 
-	binds, err := d.containerBinds(ctx.AllocDir, task)
+	binds, err := d.containerBinds(ctx, task)
 	if err != nil {
 		return c, err
 	}
@@ -405,6 +434,11 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle
 	}
 	d.logger.Printf("[INFO] driver.docker: started container %s", container.ID)
 
+	logConfig := task.LogConfig
+	if logConfig == nil {
+		logConfig = structs.DefaultLogConfig()
+	}
+
 	// Return a driver handle
 	h := &dockerHandle{
 		client:           client,
@@ -413,9 +447,14 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle
 		logger:           d.logger,
 		imageID:          dockerImage.ID,
 		containerID:      container.ID,
+		logDir:           ctx.AllocDir.LogDir(),
+		taskName:         d.taskName,
+		logConfig:        logConfig,
 		doneCh:           make(chan struct{}),
-		waitCh:           make(chan error, 1),
+		waitCh:           make(chan *cstructs.WaitResult, 1),
 	}
+	go h.collectLogs("all")
+	go h.collectStats()
 	go h.run()
 	return h, nil
 }
@@ -465,6 +504,11 @@ func (d *DockerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, er
 		return nil, fmt.Errorf("Failed to find container %s: %v", pid.ContainerID, err)
 	}
 
+	logConfig := pid.LogConfig
+	if logConfig == nil {
+		logConfig = structs.DefaultLogConfig()
+	}
+
 	// Return a driver handle
 	h := &dockerHandle{
 		client:           client,
@@ -473,9 +517,17 @@ func (d *DockerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, er
 		logger:           d.logger,
 		imageID:          pid.ImageID,
 		containerID:      pid.ContainerID,
+		logDir:           ctx.AllocDir.LogDir(),
+		taskName:         d.taskName,
+		logConfig:        logConfig,
 		doneCh:           make(chan struct{}),
-		waitCh:           make(chan error, 1),
+		waitCh:           make(chan *cstructs.WaitResult, 1),
 	}
+
+	// Only collect the logs written from now on as the earlier ones were
+	// collected before the re-attach.
+	go h.collectLogs("0")
+	go h.collectStats()
 	go h.run()
 	return h, nil
 }
@@ -485,6 +537,7 @@ func (h *dockerHandle) ID() string {
 	pid := dockerPID{
 		ImageID:     h.imageID,
 		ContainerID: h.containerID,
+		LogConfig:   h.logConfig,
 	}
 	data, err := json.Marshal(pid)
 	if err != nil {
@@ -493,7 +546,7 @@ func (h *dockerHandle) ID() string {
 	return fmt.Sprintf("DOCKER:%s", string(data))
 }
 
-func (h *dockerHandle) WaitCh() chan error {
+func (h *dockerHandle) WaitCh() chan *cstructs.WaitResult {
 	return h.waitCh
 }
 
@@ -502,6 +555,71 @@ func (h *dockerHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+// Signal sends a signal to the main process of the container
+func (h *dockerHandle) Signal(s os.Signal) error {
+	sysSig, ok := s.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("Failed to determine the value of signal %v", s)
+	}
+	return h.client.KillContainer(docker.KillContainerOptions{
+		ID:     h.containerID,
+		Signal: docker.Signal(sysSig),
+	})
+}
+
+// Stats returns the latest resource usage of the container
+func (h *dockerHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	h.resourceUsageLock.RLock()
+	defer h.resourceUsageLock.RUnlock()
+	if h.resourceUsage == nil {
+		return nil, fmt.Errorf("stats of container %s not collected yet", h.containerID)
+	}
+	return h.resourceUsage, nil
+}
+
+// Exec runs a command in the container with docker exec. The command inherits
+// the environment of the container.
+func (h *dockerHandle) Exec(opts *cstructs.ExecOptions) (int, error) {
+	exec, err := h.client.CreateExec(docker.CreateExecOptions{
+		Container:    h.containerID,
+		Cmd:          opts.Command,
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: !opts.Tty,
+		Tty:          opts.Tty,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create exec in container %s: %v", h.containerID, err)
+	}
+
+	if opts.Tty && opts.ResizeCh != nil {
+		go func() {
+			for size := range opts.ResizeCh {
+				if err := h.client.ResizeExecTTY(exec.ID, int(size.Height), int(size.Width)); err != nil {
+					h.logger.Printf("[DEBUG] driver.docker: failed to resize terminal of exec %s: %v", exec.ID, err)
+				}
+			}
+		}()
+	}
+
+	err = h.client.StartExec(exec.ID, docker.StartExecOptions{
+		InputStream:  opts.Stdin,
+		OutputStream: opts.Stdout,
+		ErrorStream:  opts.Stderr,
+		Tty:          opts.Tty,
+		RawTerminal:  opts.Tty,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Failed to start exec in container %s: %v", h.containerID, err)
+	}
+
+	inspect, err := h.client.InspectExec(exec.ID)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to inspect exec in container %s: %v", h.containerID, err)
+	}
+	return inspect.ExitCode, nil
+}
+
 // Kill is used to terminate the task. This uses docker stop -t 5
 func (h *dockerHandle) Kill() error {
 	// Stop the container
@@ -553,6 +671,98 @@ func (h *dockerHandle) Kill() error {
 	return nil
 }
 
+// collectLogs copies the output of the container into the rotated logs of the
+// task until the container exits. tail is the number of lines of the existing
+// output to copy or "all".
+func (h *dockerHandle) collectLogs(tail string) {
+	fileSize := logging.FileSizeBytes(h.logConfig.MaxFileSizeMB)
+	stdo, err := logging.NewFileRotator(logging.LogPath(h.logDir, h.taskName, logging.Stdout), h.logConfig.MaxFiles, fileSize)
+	if err != nil {
+		h.logger.Printf("[ERR] driver.docker: failed to open stdout log of container %s: %v", h.containerID, err)
+		return
+	}
+	defer stdo.Close()
+
+	stde, err := logging.NewFileRotator(logging.LogPath(h.logDir, h.taskName, logging.Stderr), h.logConfig.MaxFiles, fileSize)
+	if err != nil {
+		h.logger.Printf("[ERR] driver.docker: failed to open stderr log of container %s: %v", h.containerID, err)
+		return
+	}
+	defer stde.Close()
+
+	err = h.client.Logs(docker.LogsOptions{
+		Container:    h.containerID,
+		OutputStream: stdo,
+		ErrorStream:  stde,
+		Follow:       true,
+		Stdout:       true,
+		Stderr:       true,
+		Tail:         tail,
+	})
+	if err != nil {
+		h.logger.Printf("[ERR] driver.docker: failed to collect logs of container %s: %v", h.containerID, err)
+	}
+}
+
+// collectStats keeps the latest resource usage of the container from the
+// stats streamed by docker until the container exits.
+func (h *dockerHandle) collectStats() {
+	statsCh := make(chan *docker.Stats)
+	doneCh := make(chan bool)
+	go func() {
+		<-h.doneCh
+		close(doneCh)
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		// Stats closes the stats channel when it returns
+		errCh <- h.client.Stats(docker.StatsOptions{
+			ID:     h.containerID,
+			Stats:  statsCh,
+			Stream: true,
+			Done:   doneCh,
+		})
+	}()
+
+	var cpuTracker cstructs.CpuPercentTracker
+	for s := range statsCh {
+		ms := &cstructs.MemoryStats{
+			RSS:      s.MemoryStats.Stats.Rss,
+			Cache:    s.MemoryStats.Stats.Cache,
+			Swap:     s.MemoryStats.Stats.Swap,
+			MaxUsage: s.MemoryStats.MaxUsage,
+		}
+
+		cpu := s.CPUStats
+		cs := &cstructs.CpuStats{
+			ThrottledPeriods: cpu.ThrottlingData.ThrottledPeriods,
+			ThrottledTime:    cpu.ThrottlingData.ThrottledTime,
+		}
+		cs.Percent, cs.UserMode, cs.SystemMode = cpuTracker.Sample(s.Read,
+			cpu.CPUUsage.TotalUsage, cpu.CPUUsage.UsageInUsermode, cpu.CPUUsage.UsageInKernelmode)
+
+		h.resourceUsageLock.Lock()
+		h.resourceUsage = &cstructs.TaskResourceUsage{
+			ResourceUsage: &cstructs.ResourceUsage{
+				MemoryStats: ms,
+				CpuStats:    cs,
+			},
+			Timestamp: s.Read.UTC().UnixNano(),
+		}
+		h.resourceUsageLock.Unlock()
+	}
+
+	if err := <-errCh; err != nil {
+		select {
+		case <-h.doneCh:
+			// Streaming the stats of an exited container is expected to fail
+		default:
+			h.logger.Printf("[ERR] driver.docker: failed to collect stats of container %s: %v", h.containerID, err)
+		}
+	}
+}
+
 func (h *dockerHandle) run() {
 	// Wait for it...
 	exitCode, err := h.client.WaitContainer(h.containerID)
@@ -560,13 +770,7 @@ func (h *dockerHandle) run() {
 		h.logger.Printf("[ERR] driver.docker: unable to wait for %s; container already terminated", h.containerID)
 	}
 
-	if exitCode != 0 {
-		err = fmt.Errorf("Docker container exited with non-zero exit code: %d", exitCode)
-	}
-
 	close(h.doneCh)
-	if err != nil {
-		h.waitCh <- err
-	}
+	h.waitCh <- cstructs.NewWaitResult(exitCode, 0, err)
 	close(h.waitCh)
 }
@@ -3,12 +3,14 @@ package driver
 import (
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 	"sync"
 
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/environment"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/client/fingerprint"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -84,14 +86,29 @@ type DriverHandle interface {
 	// Returns an opaque handle that can be used to re-open the handle
 	ID() string
 
-	// WaitCh is used to return a channel used wait for task completion
-	WaitCh() chan error
+	// WaitCh is used to return a channel used wait for task completion. The
+	// result holds the exit code and signal of the task.
+	WaitCh() chan *cstructs.WaitResult
 
 	// Update is used to update the task if possible
 	Update(task *structs.Task) error
 
+	// Signal is used to send a signal to the task, for example to ask it to
+	// exit gracefully
+	Signal(s os.Signal) error
+
 	// Kill is used to stop the task
 	Kill() error
+
+	// Stats returns a sample of the resource usage of the task.
+	// cstructs.ErrStatsNotSupported is returned if the driver can't measure
+	// it.
+	Stats() (*cstructs.TaskResourceUsage, error)
+
+	// Exec runs a command in the environment of the task and returns its
+	// exit code once it exits. cstructs.ErrExecNotSupported is returned if
+	// the driver can't run commands in it.
+	Exec(opts *cstructs.ExecOptions) (int, error)
 }
 
 // ExecContext is shared between drivers within an allocation
@@ -103,6 +120,10 @@ type ExecContext struct {
 
 	// Alloc ID
 	AllocID string
+
+	// Volumes are the volumes requested by the task group, which the tasks
+	// mount by name
+	Volumes map[string]*structs.VolumeRequest
 }
 
 // NewExecContext is used to create a new execution context
@@ -110,6 +131,36 @@ func NewExecContext(alloc *allocdir.AllocDir, allocID string) *ExecContext {
 	return &ExecContext{AllocDir: alloc, AllocID: allocID}
 }
 
+// hostVolumeMount is a host path to be mounted into a task
+type hostVolumeMount struct {
+	Source      string
+	Destination string
+	ReadOnly    bool
+}
+
+// hostVolumeMounts resolves the volume mounts of a task to the paths of the
+// node's host volumes. A mount is read-only if the mount, the volume request
+// or the host volume is.
+func hostVolumeMounts(ctx *ExecContext, node *structs.Node, task *structs.Task) ([]*hostVolumeMount, error) {
+	mounts := make([]*hostVolumeMount, 0, len(task.VolumeMounts))
+	for _, m := range task.VolumeMounts {
+		req, ok := ctx.Volumes[m.Volume]
+		if !ok {
+			return nil, fmt.Errorf("task %q mounts unknown volume %q", task.Name, m.Volume)
+		}
+		volume, ok := node.HostVolumes[req.Source]
+		if !ok {
+			return nil, fmt.Errorf("host volume %q of volume %q not found", req.Source, m.Volume)
+		}
+		mounts = append(mounts, &hostVolumeMount{
+			Source:      volume.Path,
+			Destination: m.Destination,
+			ReadOnly:    m.ReadOnly || req.ReadOnly || volume.ReadOnly,
+		})
+	}
+	return mounts, nil
+}
+
 // TaskEnvironmentVariables converts exec context and task configuration into a
 // TaskEnvironment.
 func TaskEnvironmentVariables(ctx *ExecContext, task *structs.Task) environment.TaskEnvironment {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/getter"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -47,6 +48,16 @@ func testDriverExecContext(task *structs.Task, driverCtx *DriverContext) *ExecCo
 	return ctx
 }
 
+// testDownloadArtifacts downloads the task's artifacts into its task directory
+// as the task runner does before starting the task.
+func testDownloadArtifacts(t *testing.T, task *structs.Task, ctx *ExecContext) {
+	for _, artifact := range task.Artifacts {
+		if err := getter.GetArtifact(artifact, ctx.AllocDir.TaskDirs[task.Name], testLogger()); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+}
+
 func TestDriver_TaskEnvironmentVariables(t *testing.T) {
 	ctx := &ExecContext{}
 	task := &structs.Task{
@@ -89,3 +100,43 @@ func TestDriver_TaskEnvironmentVariables(t *testing.T) {
 		t.Fatalf("TaskEnvironmentVariables(%#v, %#v) returned %#v; want %#v", ctx, task, act, exp)
 	}
 }
+
+func TestDriver_HostVolumeMounts(t *testing.T) {
+	ctx := &ExecContext{
+		Volumes: map[string]*structs.VolumeRequest{
+			"data":  &structs.VolumeRequest{Name: "data", Type: structs.VolumeTypeHost, Source: "mysql"},
+			"certs": &structs.VolumeRequest{Name: "certs", Type: structs.VolumeTypeHost, Source: "ssl", ReadOnly: true},
+		},
+	}
+	node := &structs.Node{
+		HostVolumes: map[string]*structs.ClientHostVolumeConfig{
+			"mysql": &structs.ClientHostVolumeConfig{Name: "mysql", Path: "/srv/mysql"},
+			"ssl":   &structs.ClientHostVolumeConfig{Name: "ssl", Path: "/etc/ssl"},
+		},
+	}
+	task := &structs.Task{
+		Name: "web",
+		VolumeMounts: []*structs.VolumeMount{
+			&structs.VolumeMount{Volume: "data", Destination: "/var/lib/mysql"},
+			&structs.VolumeMount{Volume: "certs", Destination: "/etc/ssl/certs"},
+		},
+	}
+
+	mounts, err := hostVolumeMounts(ctx, node, task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := []*hostVolumeMount{
+		&hostVolumeMount{Source: "/srv/mysql", Destination: "/var/lib/mysql"},
+		&hostVolumeMount{Source: "/etc/ssl", Destination: "/etc/ssl/certs", ReadOnly: true},
+	}
+	if !reflect.DeepEqual(mounts, exp) {
+		t.Fatalf("bad: %#v", mounts)
+	}
+
+	// The node must offer the host volume
+	delete(node.HostVolumes, "ssl")
+	if _, err := hostVolumeMounts(ctx, node, task); err == nil {
+		t.Fatalf("expected error for missing host volume")
+	}
+}
@@ -2,16 +2,15 @@ package driver
 
 import (
 	"fmt"
-	"path/filepath"
+	"os"
 	"runtime"
 	"syscall"
 	"time"
 
-	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/executor"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/client/fingerprint"
-	"github.com/hashicorp/nomad/client/getter"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -25,7 +24,7 @@ type ExecDriver struct {
 // execHandle is returned from Start/Open as a handle to the PID
 type execHandle struct {
 	cmd    executor.Executor
-	waitCh chan error
+	waitCh chan *cstructs.WaitResult
 	doneCh chan struct{}
 }
 
@@ -55,27 +54,6 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		return nil, fmt.Errorf("missing command for exec driver")
 	}
 
-	// Create a location to download the artifact.
-	taskDir, ok := ctx.AllocDir.TaskDirs[d.DriverContext.taskName]
-	if !ok {
-		return nil, fmt.Errorf("Could not find task directory for task: %v", d.DriverContext.taskName)
-	}
-
-	// Check if an artificat is specified and attempt to download it
-	source, ok := task.Config["artifact_source"]
-	if ok && source != "" {
-		// Proceed to download an artifact to be executed.
-		_, err := getter.GetArtifact(
-			filepath.Join(taskDir, allocdir.TaskLocal),
-			task.Config["artifact_source"],
-			task.Config["checksum"],
-			d.logger,
-		)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	// Get the environment variables.
 	envVars := TaskEnvironmentVariables(ctx, task)
 
@@ -98,6 +76,21 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		return nil, fmt.Errorf("failed to configure task directory: %v", err)
 	}
 
+	// Mount the host volumes into the chroot
+	mounts, err := hostVolumeMounts(ctx, d.node, task)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mounts {
+		if err := ctx.AllocDir.MountVolume(d.taskName, m.Source, m.Destination, m.ReadOnly); err != nil {
+			return nil, fmt.Errorf("failed to mount host volume: %v", err)
+		}
+	}
+
+	if err := cmd.ConfigureLogs(task.LogConfig); err != nil {
+		return nil, fmt.Errorf("failed to configure logs: %v", err)
+	}
+
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start command: %v", err)
 	}
@@ -106,7 +99,7 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 	h := &execHandle{
 		cmd:    cmd,
 		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+		waitCh: make(chan *cstructs.WaitResult, 1),
 	}
 	go h.run()
 	return h, nil
@@ -123,7 +116,7 @@ func (d *ExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, erro
 	h := &execHandle{
 		cmd:    cmd,
 		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+		waitCh: make(chan *cstructs.WaitResult, 1),
 	}
 	go h.run()
 	return h, nil
@@ -134,7 +127,7 @@ func (h *execHandle) ID() string {
 	return id
 }
 
-func (h *execHandle) WaitCh() chan error {
+func (h *execHandle) WaitCh() chan *cstructs.WaitResult {
 	return h.waitCh
 }
 
@@ -143,6 +136,18 @@ func (h *execHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+func (h *execHandle) Signal(s os.Signal) error {
+	return h.cmd.Signal(s)
+}
+
+func (h *execHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	return h.cmd.Stats()
+}
+
+func (h *execHandle) Exec(opts *cstructs.ExecOptions) (int, error) {
+	return h.cmd.Exec(opts)
+}
+
 func (h *execHandle) Kill() error {
 	h.cmd.Shutdown()
 	select {
@@ -154,10 +159,8 @@ func (h *execHandle) Kill() error {
 }
 
 func (h *execHandle) run() {
-	err := h.cmd.Wait()
+	res := h.cmd.Wait()
 	close(h.doneCh)
-	if err != nil {
-		h.waitCh <- err
-	}
+	h.waitCh <- res
 	close(h.waitCh)
 }
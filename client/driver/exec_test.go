@@ -3,11 +3,13 @@ package driver
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/environment"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -99,9 +101,9 @@ func TestExecDriver_Start_Wait(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err != nil {
-			t.Fatalf("err: %v", err)
+	case res := <-handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("err: %v", res)
 		}
 	case <-time.After(4 * time.Second):
 		t.Fatalf("timeout")
@@ -116,8 +118,15 @@ func TestExecDriver_Start_Artifact_basic(t *testing.T) {
 	task := &structs.Task{
 		Name: "sleep",
 		Config: map[string]string{
-			"artifact_source": fmt.Sprintf("https://dl.dropboxusercontent.com/u/47675/jar_thing/%s?checksum=%s", file, checksum),
-			"command":         filepath.Join("$NOMAD_TASK_DIR", file),
+			"command": filepath.Join("$NOMAD_TASK_DIR", file),
+		},
+		Artifacts: []*structs.TaskArtifact{
+			&structs.TaskArtifact{
+				GetterSource: fmt.Sprintf("https://dl.dropboxusercontent.com/u/47675/jar_thing/%s", file),
+				GetterOptions: map[string]string{
+					"checksum": checksum,
+				},
+			},
 		},
 		Resources: basicResources,
 	}
@@ -125,6 +134,7 @@ func TestExecDriver_Start_Artifact_basic(t *testing.T) {
 	driverCtx := testDriverContext(task.Name)
 	ctx := testDriverExecContext(task, driverCtx)
 	defer ctx.AllocDir.Destroy()
+	testDownloadArtifacts(t, task, ctx)
 	d := NewExecDriver(driverCtx)
 
 	handle, err := d.Start(ctx, task)
@@ -143,9 +153,9 @@ func TestExecDriver_Start_Artifact_basic(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err != nil {
-			t.Fatalf("err: %v", err)
+	case res := <-handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("err: %v", res)
 		}
 	case <-time.After(5 * time.Second):
 		t.Fatalf("timeout")
@@ -159,9 +169,13 @@ func TestExecDriver_Start_Artifact_expanded(t *testing.T) {
 	task := &structs.Task{
 		Name: "sleep",
 		Config: map[string]string{
-			"artifact_source": fmt.Sprintf("https://dl.dropboxusercontent.com/u/47675/jar_thing/%s", file),
-			"command":         "/bin/bash",
-			"args":            fmt.Sprintf("-c '/bin/sleep 1 && %s'", filepath.Join("$NOMAD_TASK_DIR", file)),
+			"command": "/bin/bash",
+			"args":    fmt.Sprintf("-c '/bin/sleep 1 && %s'", filepath.Join("$NOMAD_TASK_DIR", file)),
+		},
+		Artifacts: []*structs.TaskArtifact{
+			&structs.TaskArtifact{
+				GetterSource: fmt.Sprintf("https://dl.dropboxusercontent.com/u/47675/jar_thing/%s", file),
+			},
 		},
 		Resources: basicResources,
 	}
@@ -169,6 +183,13 @@ func TestExecDriver_Start_Artifact_expanded(t *testing.T) {
 	driverCtx := testDriverContext(task.Name)
 	ctx := testDriverExecContext(task, driverCtx)
 	defer ctx.AllocDir.Destroy()
+	testDownloadArtifacts(t, task, ctx)
+
+	// The artifact is run by bash rather than as the command so it has to be
+	// made executable
+	if err := os.Chmod(filepath.Join(ctx.AllocDir.TaskDirs[task.Name], allocdir.TaskLocal, file), 0755); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 	d := NewExecDriver(driverCtx)
 
 	handle, err := d.Start(ctx, task)
@@ -187,9 +208,9 @@ func TestExecDriver_Start_Artifact_expanded(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err != nil {
-			t.Fatalf("err: %v", err)
+	case res := <-handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("err: %v", res)
 		}
 	case <-time.After(5 * time.Second):
 		t.Fatalf("timeout")
@@ -224,9 +245,9 @@ func TestExecDriver_Start_Wait_AllocDir(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err != nil {
-			t.Fatalf("err: %v", err)
+	case res := <-handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("err: %v", res)
 		}
 	case <-time.After(2 * time.Second):
 		t.Fatalf("timeout")
@@ -278,8 +299,8 @@ func TestExecDriver_Start_Kill_Wait(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err == nil {
+	case res := <-handle.WaitCh():
+		if res.Successful() {
 			t.Fatal("should err")
 		}
 	case <-time.After(8 * time.Second):
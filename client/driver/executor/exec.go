@@ -22,10 +22,17 @@ package executor
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/driver/logging"
+	"github.com/hashicorp/nomad/client/driver/spawn"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -44,6 +51,11 @@ type Executor interface {
 	// directory is properly configured.
 	ConfigureTaskDir(taskName string, alloc *allocdir.AllocDir) error
 
+	// ConfigureLogs must be called before Start and sets the rotation of the
+	// stdout and stderr logs of the task. If not called, the default log
+	// config is used.
+	ConfigureLogs(*structs.LogConfig) error
+
 	// Start the process. This may wrap the actual process in another command,
 	// depending on the capabilities in this environment. Errors that arise from
 	// Limits or Runas may bubble through Start()
@@ -53,8 +65,9 @@ type Executor interface {
 	// nomad is restarted.
 	Open(string) error
 
-	// Wait waits till the user's command is completed.
-	Wait() error
+	// Wait waits till the user's command is completed and returns its exit
+	// code and signal.
+	Wait() *cstructs.WaitResult
 
 	// Returns a handle that is executor specific for use in reopening.
 	ID() (string, error)
@@ -68,6 +81,18 @@ type Executor interface {
 	// implementations must provide this.
 	ForceStop() error
 
+	// Signal sends a signal to the user process
+	Signal(s os.Signal) error
+
+	// Stats returns a sample of the resource usage of the user process.
+	// cstructs.ErrStatsNotSupported is returned if the executor can't
+	// measure it.
+	Stats() (*cstructs.TaskResourceUsage, error)
+
+	// Exec runs a command with the isolation of the user process and returns
+	// its exit code once it exits.
+	Exec(opts *cstructs.ExecOptions) (int, error)
+
 	// Command provides access the underlying Cmd struct in case the Executor
 	// interface doesn't expose the functionality you need.
 	Command() *exec.Cmd
@@ -94,6 +119,108 @@ func SetCommand(e Executor, name string, args []string) {
 	}
 }
 
+// makeExecutable ensures that a command placed in the task directory, for
+// example by downloading it as an artifact, is executable. Commands outside of
+// the task directory are left untouched.
+func makeExecutable(path, taskDir string) error {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(taskDir, path)
+	}
+	rel, err := filepath.Rel(taskDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to stat command %q: %v", path, err)
+	}
+
+	perm := fi.Mode().Perm()
+	if perm&0111 == 0111 {
+		return nil
+	}
+	if err := os.Chmod(path, perm|0111); err != nil {
+		return fmt.Errorf("failed to make command %q executable: %v", path, err)
+	}
+	return nil
+}
+
+// spawnLogs returns the rotated logs of the task in the alloc log directory
+func spawnLogs(logDir, taskName string, config *structs.LogConfig) *spawn.Logs {
+	if config == nil {
+		config = structs.DefaultLogConfig()
+	}
+	return &spawn.Logs{
+		Stdout:      logging.LogPath(logDir, taskName, logging.Stdout),
+		Stderr:      logging.LogPath(logDir, taskName, logging.Stderr),
+		Stdin:       os.DevNull,
+		MaxFiles:    config.MaxFiles,
+		MaxFileSize: logging.FileSizeBytes(config.MaxFileSizeMB),
+	}
+}
+
+// runExec runs the command attached to the streams of the exec options and
+// returns its exit code once it exits. started, if set, is called with the pid
+// of the command once it is started and the command is killed if it fails.
+func runExec(cmd *exec.Cmd, opts *cstructs.ExecOptions, started func(pid int) error) (int, error) {
+	if len(cmd.Args) == 0 {
+		return 0, fmt.Errorf("No command given")
+	}
+	if opts.Tty {
+		return runExecTty(cmd, opts, started)
+	}
+
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+
+	// Stdin is copied separately as Wait would otherwise block on reading it
+	// after the command exits.
+	var stdin io.WriteCloser
+	if opts.Stdin != nil {
+		var err error
+		if stdin, err = cmd.StdinPipe(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("Failed to start command %q: %v", cmd.Path, err)
+	}
+	if stdin != nil {
+		go func() {
+			io.Copy(stdin, opts.Stdin)
+			stdin.Close()
+		}()
+	}
+
+	if started != nil {
+		if err := started(cmd.Process.Pid); err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return 0, err
+		}
+	}
+	return waitExec(cmd)
+}
+
+// waitExec waits for the command to exit and returns its exit code
+func waitExec(cmd *exec.Cmd) (int, error) {
+	err := cmd.Wait()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus(), nil
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Failed to wait for command %q: %v", cmd.Path, err)
+	}
+	return 0, nil
+}
+
 // OpenId is similar to executor.Command but will attempt to reopen with the
 // passed ID.
 func OpenId(id string) (Executor, error) {
@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/nomad/client/driver/args"
 	"github.com/hashicorp/nomad/client/driver/environment"
 	"github.com/hashicorp/nomad/client/driver/spawn"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -25,6 +26,10 @@ type BasicExecutor struct {
 	taskName string
 	taskDir  string
 	allocDir string
+
+	// Log rotation configuration.
+	logDir    string
+	logConfig *structs.LogConfig
 }
 
 // TODO: Have raw_exec use this as well.
@@ -49,6 +54,17 @@ func (e *BasicExecutor) ConfigureTaskDir(taskName string, alloc *allocdir.AllocD
 	e.taskDir = taskDir
 	e.taskName = taskName
 	e.allocDir = alloc.AllocDir
+	e.logDir = alloc.LogDir()
+	return nil
+}
+
+func (e *BasicExecutor) ConfigureLogs(config *structs.LogConfig) error {
+	if config != nil {
+		if err := config.Validate(); err != nil {
+			return err
+		}
+	}
+	e.logConfig = config
 	return nil
 }
 
@@ -61,6 +77,9 @@ func (e *BasicExecutor) Start() error {
 	}
 
 	e.cmd.Path = args.ReplaceEnv(e.cmd.Path, envVars.Map())
+	if err := makeExecutable(e.cmd.Path, e.taskDir); err != nil {
+		return err
+	}
 	combined := strings.Join(e.cmd.Args, " ")
 	parsed, err := args.ParseAndReplace(combined, envVars.Map())
 	if err != nil {
@@ -71,11 +90,7 @@ func (e *BasicExecutor) Start() error {
 	spawnState := filepath.Join(e.allocDir, fmt.Sprintf("%s_%s", e.taskName, "exit_status"))
 	e.spawn = spawn.NewSpawner(spawnState)
 	e.spawn.SetCommand(&e.cmd)
-	e.spawn.SetLogs(&spawn.Logs{
-		Stdout: filepath.Join(e.taskDir, allocdir.TaskLocal, fmt.Sprintf("%v.stdout", e.taskName)),
-		Stderr: filepath.Join(e.taskDir, allocdir.TaskLocal, fmt.Sprintf("%v.stderr", e.taskName)),
-		Stdin:  os.DevNull,
-	})
+	e.spawn.SetLogs(spawnLogs(e.logDir, e.taskName, e.logConfig))
 
 	return e.spawn.Spawn(nil)
 }
@@ -92,17 +107,8 @@ func (e *BasicExecutor) Open(id string) error {
 	return e.spawn.Valid()
 }
 
-func (e *BasicExecutor) Wait() error {
-	code, err := e.spawn.Wait()
-	if err != nil {
-		return err
-	}
-
-	if code != 0 {
-		return fmt.Errorf("Task exited with code: %d", code)
-	}
-
-	return nil
+func (e *BasicExecutor) Wait() *cstructs.WaitResult {
+	return e.spawn.Wait()
 }
 
 func (e *BasicExecutor) ID() (string, error) {
@@ -132,6 +138,53 @@ func (e *BasicExecutor) Shutdown() error {
 	return proc.Signal(os.Interrupt)
 }
 
+func (e *BasicExecutor) Signal(s os.Signal) error {
+	proc, err := os.FindProcess(e.spawn.UserPid)
+	if err != nil {
+		return fmt.Errorf("Failed to find user processes %v: %v", e.spawn.UserPid, err)
+	}
+
+	return proc.Signal(s)
+}
+
+// Stats isn't supported as the BasicExecutor doesn't isolate the process
+func (e *BasicExecutor) Stats() (*cstructs.TaskResourceUsage, error) {
+	return nil, cstructs.ErrStatsNotSupported
+}
+
+// Exec runs a command in the task directory. Like the user process, it
+// isn't isolated from the host.
+func (e *BasicExecutor) Exec(opts *cstructs.ExecOptions) (int, error) {
+	if len(opts.Command) == 0 {
+		return 0, fmt.Errorf("No command given")
+	}
+
+	// Paths containing a slash are relative to the task directory
+	path := opts.Command[0]
+	if !strings.Contains(path, "/") {
+		lp, err := exec.LookPath(path)
+		if err != nil {
+			return 0, err
+		}
+		path = lp
+	}
+
+	// The task directory isn't part of the ID so it is recovered from the
+	// command of a re-opened executor.
+	dir := e.taskDir
+	if dir == "" && e.spawn != nil && e.spawn.UserCmd != nil {
+		dir = e.spawn.UserCmd.Dir
+	}
+
+	cmd := &exec.Cmd{
+		Path: path,
+		Args: opts.Command,
+		Env:  opts.Env,
+		Dir:  dir,
+	}
+	return runExec(cmd, opts, nil)
+}
+
 func (e *BasicExecutor) ForceStop() error {
 	proc, err := os.FindProcess(e.spawn.UserPid)
 	if err != nil {
@@ -11,13 +11,16 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/driver/args"
 	"github.com/hashicorp/nomad/client/driver/environment"
 	"github.com/hashicorp/nomad/client/driver/spawn"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
@@ -40,6 +43,10 @@ var (
 	}
 )
 
+// defaultExecPath is searched for commands run in the chroot when the task
+// doesn't set PATH.
+const defaultExecPath = "/usr/local/bin:/usr/bin:/bin"
+
 func NewExecutor() Executor {
 	return NewLinuxExecutor()
 }
@@ -59,8 +66,16 @@ type LinuxExecutor struct {
 	taskDir  string
 	allocDir string
 
+	// Log rotation configuration.
+	logDir    string
+	logConfig *structs.LogConfig
+
 	// Spawn process.
 	spawn *spawn.Spawner
+
+	// Resource usage measurement.
+	cpuTracker cstructs.CpuPercentTracker
+	statsLock  sync.Mutex
 }
 
 func (e *LinuxExecutor) Command() *exec.Cmd {
@@ -122,31 +137,47 @@ func (e *LinuxExecutor) ID() (string, error) {
 // runAs takes a user id as a string and looks up the user, and sets the command
 // to execute as that user.
 func (e *LinuxExecutor) runAs(userid string) error {
+	cred, err := userCredential(userid)
+	if err != nil {
+		return err
+	}
+
+	// Set the command to run as that user and group.
+	if e.cmd.SysProcAttr == nil {
+		e.cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	e.cmd.SysProcAttr.Credential = cred
+	return nil
+}
+
+// userCredential looks up the user id and returns the credential of the user
+// and its group.
+func userCredential(userid string) (*syscall.Credential, error) {
 	u, err := user.Lookup(userid)
 	if err != nil {
-		return fmt.Errorf("Failed to identify user %v: %v", userid, err)
+		return nil, fmt.Errorf("Failed to identify user %v: %v", userid, err)
 	}
 
 	// Convert the uid and gid
 	uid, err := strconv.ParseUint(u.Uid, 10, 32)
 	if err != nil {
-		return fmt.Errorf("Unable to convert userid to uint32: %s", err)
+		return nil, fmt.Errorf("Unable to convert userid to uint32: %s", err)
 	}
 	gid, err := strconv.ParseUint(u.Gid, 10, 32)
 	if err != nil {
-		return fmt.Errorf("Unable to convert groupid to uint32: %s", err)
+		return nil, fmt.Errorf("Unable to convert groupid to uint32: %s", err)
 	}
 
-	// Set the command to run as that user and group.
-	if e.cmd.SysProcAttr == nil {
-		e.cmd.SysProcAttr = &syscall.SysProcAttr{}
-	}
-	if e.cmd.SysProcAttr.Credential == nil {
-		e.cmd.SysProcAttr.Credential = &syscall.Credential{}
-	}
-	e.cmd.SysProcAttr.Credential.Uid = uint32(uid)
-	e.cmd.SysProcAttr.Credential.Gid = uint32(gid)
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+}
 
+func (e *LinuxExecutor) ConfigureLogs(config *structs.LogConfig) error {
+	if config != nil {
+		if err := config.Validate(); err != nil {
+			return err
+		}
+	}
+	e.logConfig = config
 	return nil
 }
 
@@ -165,6 +196,9 @@ func (e *LinuxExecutor) Start() error {
 	}
 
 	e.cmd.Path = args.ReplaceEnv(e.cmd.Path, envVars.Map())
+	if err := makeExecutable(e.cmd.Path, e.taskDir); err != nil {
+		return err
+	}
 	combined := strings.Join(e.cmd.Args, " ")
 	parsed, err := args.ParseAndReplace(combined, envVars.Map())
 	if err != nil {
@@ -176,11 +210,7 @@ func (e *LinuxExecutor) Start() error {
 	e.spawn = spawn.NewSpawner(spawnState)
 	e.spawn.SetCommand(&e.cmd)
 	e.spawn.SetChroot(e.taskDir)
-	e.spawn.SetLogs(&spawn.Logs{
-		Stdout: filepath.Join(e.taskDir, allocdir.TaskLocal, fmt.Sprintf("%v.stdout", e.taskName)),
-		Stderr: filepath.Join(e.taskDir, allocdir.TaskLocal, fmt.Sprintf("%v.stderr", e.taskName)),
-		Stdin:  os.DevNull,
-	})
+	e.spawn.SetLogs(spawnLogs(e.logDir, e.taskName, e.logConfig))
 
 	enterCgroup := func(pid int) error {
 		// Join the spawn-daemon to the cgroup.
@@ -197,17 +227,14 @@ func (e *LinuxExecutor) Start() error {
 	return e.spawn.Spawn(enterCgroup)
 }
 
-// Wait waits til the user process exits and returns an error on non-zero exit
-// codes. Wait also cleans up the task directory and created cgroups.
-func (e *LinuxExecutor) Wait() error {
+// Wait waits til the user process exits and returns its exit code and signal.
+// Wait also cleans up the task directory and created cgroups, returning any
+// errors in the result.
+func (e *LinuxExecutor) Wait() *cstructs.WaitResult {
 	errs := new(multierror.Error)
-	code, err := e.spawn.Wait()
-	if err != nil {
-		errs = multierror.Append(errs, err)
-	}
-
-	if code != 0 {
-		errs = multierror.Append(errs, fmt.Errorf("Task exited with code: %d", code))
+	res := e.spawn.Wait()
+	if res.Err != nil {
+		errs = multierror.Append(errs, res.Err)
 	}
 
 	if err := e.destroyCgroup(); err != nil {
@@ -218,13 +245,139 @@ func (e *LinuxExecutor) Wait() error {
 		errs = multierror.Append(errs, err)
 	}
 
-	return errs.ErrorOrNil()
+	res.Err = errs.ErrorOrNil()
+	return res
 }
 
 func (e *LinuxExecutor) Shutdown() error {
 	return e.ForceStop()
 }
 
+// Signal sends a signal to the user process running in the task directory
+func (e *LinuxExecutor) Signal(s os.Signal) error {
+	proc, err := os.FindProcess(e.spawn.UserPid)
+	if err != nil {
+		return fmt.Errorf("Failed to find user processes %v: %v", e.spawn.UserPid, err)
+	}
+
+	return proc.Signal(s)
+}
+
+// Stats returns the resource usage of the processes in the task's cgroup
+func (e *LinuxExecutor) Stats() (*cstructs.TaskResourceUsage, error) {
+	if e.groups == nil {
+		return nil, errors.New("Can't collect stats: cgroup configuration empty")
+	}
+
+	manager := e.getCgroupManager(e.groups)
+	stats, err := manager.GetStats()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get stats of the cgroup %v: %v", e.groups.Name, err)
+	}
+	now := time.Now()
+
+	mem := stats.MemoryStats
+	ms := &cstructs.MemoryStats{
+		RSS:      mem.Stats["rss"],
+		Cache:    mem.Cache,
+		Swap:     mem.Stats["swap"],
+		MaxUsage: mem.Usage.MaxUsage,
+	}
+
+	cpu := stats.CpuStats
+	cs := &cstructs.CpuStats{
+		ThrottledPeriods: cpu.ThrottlingData.ThrottledPeriods,
+		ThrottledTime:    cpu.ThrottlingData.ThrottledTime,
+	}
+	e.statsLock.Lock()
+	cs.Percent, cs.UserMode, cs.SystemMode = e.cpuTracker.Sample(now,
+		cpu.CpuUsage.TotalUsage, cpu.CpuUsage.UsageInUsermode, cpu.CpuUsage.UsageInKernelmode)
+	e.statsLock.Unlock()
+
+	return &cstructs.TaskResourceUsage{
+		ResourceUsage: &cstructs.ResourceUsage{
+			MemoryStats: ms,
+			CpuStats:    cs,
+		},
+		Timestamp: now.UTC().UnixNano(),
+	}, nil
+}
+
+// Exec runs a command in the chroot and cgroup of the task as the same
+// unprivileged user as the user process.
+func (e *LinuxExecutor) Exec(opts *cstructs.ExecOptions) (int, error) {
+	if e.groups == nil || e.taskDir == "" {
+		return 0, fmt.Errorf("LinuxExecutor not properly initialized.")
+	}
+	if len(opts.Command) == 0 {
+		return 0, fmt.Errorf("No command given")
+	}
+
+	cred, err := userCredential("nobody")
+	if err != nil {
+		return 0, err
+	}
+
+	env, err := environment.ParseFromList(opts.Env)
+	if err != nil {
+		return 0, err
+	}
+	env.SetAllocDir(filepath.Join("/", allocdir.SharedAllocName))
+	env.SetTaskLocalDir(filepath.Join("/", allocdir.TaskLocal))
+
+	path, err := lookPathIn(e.taskDir, opts.Command[0], env.Map()["PATH"])
+	if err != nil {
+		return 0, err
+	}
+
+	cmd := &exec.Cmd{
+		Path: path,
+		Args: opts.Command,
+		Env:  env.List(),
+		Dir:  "/",
+		SysProcAttr: &syscall.SysProcAttr{
+			Chroot:     e.taskDir,
+			Credential: cred,
+		},
+	}
+
+	enterCgroup := func(pid int) error {
+		manager := e.getCgroupManager(e.groups)
+		if err := manager.Apply(pid); err != nil {
+			return fmt.Errorf("Failed to join command to the cgroup (%+v): %v", e.groups, err)
+		}
+		return nil
+	}
+	return runExec(cmd, opts, enterCgroup)
+}
+
+// lookPathIn searches the directories of the path list for the executable
+// name, as seen from within the root directory. Names containing a slash are
+// returned as is.
+func lookPathIn(root, name, pathList string) (string, error) {
+	if strings.Contains(name, "/") {
+		return name, nil
+	}
+	if pathList == "" {
+		pathList = defaultExecPath
+	}
+
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		fi, err := os.Stat(filepath.Join(root, path))
+		if err != nil {
+			continue
+		}
+		if mode := fi.Mode(); mode.IsRegular() && mode.Perm()&0111 != 0 {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("Executable %q not found in the task directory", name)
+}
+
 // ForceStop immediately exits the user process and cleans up both the task
 // directory and the cgroups.
 func (e *LinuxExecutor) ForceStop() error {
@@ -247,6 +400,7 @@ func (e *LinuxExecutor) ForceStop() error {
 func (e *LinuxExecutor) ConfigureTaskDir(taskName string, alloc *allocdir.AllocDir) error {
 	e.taskName = taskName
 	e.allocDir = alloc.AllocDir
+	e.logDir = alloc.LogDir()
 
 	taskDir, ok := alloc.TaskDirs[taskName]
 	if !ok {
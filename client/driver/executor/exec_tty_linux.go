@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
+)
+
+// runExecTty runs the command attached to a new terminal whose input is read
+// from Stdin and whose output is written to Stdout. The command becomes the
+// leader of a new session controlled by the terminal.
+func runExecTty(cmd *exec.Cmd, opts *cstructs.ExecOptions, started func(pid int) error) (int, error) {
+	ptm, pts, err := openPty()
+	if err != nil {
+		return 0, err
+	}
+	defer ptm.Close()
+
+	cmd.Stdin = pts
+	cmd.Stdout = pts
+	cmd.Stderr = pts
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+
+	err = cmd.Start()
+	pts.Close()
+	if err != nil {
+		return 0, fmt.Errorf("Failed to start command %q: %v", cmd.Path, err)
+	}
+
+	if opts.ResizeCh != nil {
+		go func() {
+			for size := range opts.ResizeCh {
+				setPtySize(ptm, size)
+			}
+		}()
+	}
+	if opts.Stdin != nil {
+		go io.Copy(ptm, opts.Stdin)
+	}
+
+	// Reading the terminal fails once the command and its children have
+	// closed it.
+	outputDone := make(chan struct{})
+	go func() {
+		io.Copy(opts.Stdout, ptm)
+		close(outputDone)
+	}()
+
+	if started != nil {
+		if err := started(cmd.Process.Pid); err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return 0, err
+		}
+	}
+
+	code, err := waitExec(cmd)
+	<-outputDone
+	return code, err
+}
+
+// openPty opens a new pseudo terminal and returns its master and slave
+func openPty() (*os.File, *os.File, error) {
+	ptm, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to open terminal: %v", err)
+	}
+
+	var unlock int32
+	if err := ioctl(ptm.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		ptm.Close()
+		return nil, nil, fmt.Errorf("Failed to unlock terminal: %v", err)
+	}
+	var n uint32
+	if err := ioctl(ptm.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); err != nil {
+		ptm.Close()
+		return nil, nil, fmt.Errorf("Failed to get terminal number: %v", err)
+	}
+
+	pts, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		ptm.Close()
+		return nil, nil, fmt.Errorf("Failed to open terminal: %v", err)
+	}
+	return ptm, pts, nil
+}
+
+// setPtySize sets the size of the terminal
+func setPtySize(ptm *os.File, size cstructs.TerminalSize) error {
+	ws := struct {
+		Row, Col, X, Y uint16
+	}{
+		Row: size.Height,
+		Col: size.Width,
+	}
+	return ioctl(ptm.Fd(), syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&ws)))
+}
+
+func ioctl(fd, cmd, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, cmd, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+// +build !linux
+
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
+)
+
+// runExecTty fails as terminals are only supported on Linux
+func runExecTty(cmd *exec.Cmd, opts *cstructs.ExecOptions, started func(pid int) error) (int, error) {
+	return 0, fmt.Errorf("Terminals are not supported on this platform")
+}
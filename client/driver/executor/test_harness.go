@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/nomad/client/allocdir"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -54,6 +56,7 @@ func testExecutor(t *testing.T, buildExecutor func() Executor, compatible func(*
 	Executor_Start_Wait_Failure_Code(t, command)
 	Executor_Start_Wait(t, command)
 	Executor_Start_Kill(t, command)
+	Executor_Exec(t, command)
 	Executor_Open(t, command, buildExecutor)
 }
 
@@ -95,7 +98,7 @@ func Executor_Start_Wait_Failure_Code(t *testing.T, command buildExecCommand) {
 		log.Panicf("Start() failed: %v", err)
 	}
 
-	if err := e.Wait(); err == nil {
+	if res := e.Wait(); res.Successful() {
 		log.Panicf("Wait() should have failed")
 	}
 }
@@ -127,8 +130,8 @@ func Executor_Start_Wait(t *testing.T, command buildExecCommand) {
 		log.Panicf("Start() failed: %v", err)
 	}
 
-	if err := e.Wait(); err != nil {
-		log.Panicf("Wait() failed: %v", err)
+	if res := e.Wait(); !res.Successful() {
+		log.Panicf("Wait() failed: %v", res)
 	}
 
 	output, err := ioutil.ReadFile(absFilePath)
@@ -142,6 +145,44 @@ func Executor_Start_Wait(t *testing.T, command buildExecCommand) {
 	}
 }
 
+func Executor_Exec(t *testing.T, command buildExecCommand) {
+	task, alloc := mockAllocDir(t)
+	defer alloc.Destroy()
+
+	e := command("/bin/sleep", "10")
+
+	if err := e.Limit(constraint); err != nil {
+		log.Panicf("Limit() failed: %v", err)
+	}
+
+	if err := e.ConfigureTaskDir(task, alloc); err != nil {
+		log.Panicf("ConfigureTaskDir(%v, %v) failed: %v", task, alloc, err)
+	}
+
+	if err := e.Start(); err != nil {
+		log.Panicf("Start() failed: %v", err)
+	}
+	defer e.ForceStop()
+
+	var stdout, stderr bytes.Buffer
+	opts := &cstructs.ExecOptions{
+		Command: []string{"/bin/sh", "-c", "cat; echo -n failure >&2; exit 3"},
+		Stdin:   bytes.NewBufferString("hello world"),
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	}
+	code, err := e.Exec(opts)
+	if err != nil {
+		log.Panicf("Exec() failed: %v", err)
+	}
+	if code != 3 {
+		log.Panicf("Exec() returned exit code %d; want 3", code)
+	}
+	if stdout.String() != "hello world" || stderr.String() != "failure" {
+		log.Panicf("Exec() output incorrectly: got %q and %q", stdout.String(), stderr.String())
+	}
+}
+
 func Executor_Start_Kill(t *testing.T, command buildExecCommand) {
 	task, alloc := mockAllocDir(t)
 	defer alloc.Destroy()
@@ -215,8 +256,8 @@ func Executor_Open(t *testing.T, command buildExecCommand, newExecutor func() Ex
 		log.Panicf("Open(%v) failed: %v", id, err)
 	}
 
-	if err := e2.Wait(); err != nil {
-		log.Panicf("Wait() failed: %v", err)
+	if res := e2.Wait(); !res.Successful() {
+		log.Panicf("Wait() failed: %v", res)
 	}
 
 	output, err := ioutil.ReadFile(absFilePath)
@@ -3,18 +3,17 @@ package driver
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/executor"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/client/fingerprint"
-	"github.com/hashicorp/nomad/client/getter"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -28,7 +27,7 @@ type JavaDriver struct {
 // javaHandle is returned from Start/Open as a handle to the PID
 type javaHandle struct {
 	cmd    executor.Executor
-	waitCh chan error
+	waitCh chan *cstructs.WaitResult
 	doneCh chan struct{}
 }
 
@@ -90,24 +89,13 @@ func (d *JavaDriver) Fingerprint(cfg *config.Config, node *structs.Node) (bool,
 }
 
 func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle, error) {
-	taskDir, ok := ctx.AllocDir.TaskDirs[d.DriverContext.taskName]
-	if !ok {
-		return nil, fmt.Errorf("Could not find task directory for task: %v", d.DriverContext.taskName)
-	}
-
-	// Proceed to download an artifact to be executed.
-	path, err := getter.GetArtifact(
-		filepath.Join(taskDir, allocdir.TaskLocal),
-		task.Config["artifact_source"],
-		task.Config["checksum"],
-		d.logger,
-	)
-	if err != nil {
-		return nil, err
+	// Get the path of the jar, relative to the task directory. The jar is
+	// usually downloaded as an artifact of the task.
+	jarPath, ok := task.Config["jar_path"]
+	if !ok || jarPath == "" {
+		return nil, fmt.Errorf("missing jar_path for Java driver")
 	}
 
-	jarName := filepath.Base(path)
-
 	// Get the environment variables.
 	envVars := TaskEnvironmentVariables(ctx, task)
 
@@ -120,7 +108,7 @@ func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 	}
 
 	// Build the argument list.
-	args = append(args, "-jar", filepath.Join(allocdir.TaskLocal, jarName))
+	args = append(args, "-jar", jarPath)
 	if argRaw, ok := task.Config["args"]; ok {
 		args = append(args, argRaw)
 	}
@@ -140,6 +128,10 @@ func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		return nil, fmt.Errorf("failed to configure task directory: %v", err)
 	}
 
+	if err := cmd.ConfigureLogs(task.LogConfig); err != nil {
+		return nil, fmt.Errorf("failed to configure logs: %v", err)
+	}
+
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start source: %v", err)
 	}
@@ -148,7 +140,7 @@ func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 	h := &javaHandle{
 		cmd:    cmd,
 		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+		waitCh: make(chan *cstructs.WaitResult, 1),
 	}
 
 	go h.run()
@@ -166,7 +158,7 @@ func (d *JavaDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, erro
 	h := &javaHandle{
 		cmd:    cmd,
 		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+		waitCh: make(chan *cstructs.WaitResult, 1),
 	}
 
 	go h.run()
@@ -178,7 +170,7 @@ func (h *javaHandle) ID() string {
 	return id
 }
 
-func (h *javaHandle) WaitCh() chan error {
+func (h *javaHandle) WaitCh() chan *cstructs.WaitResult {
 	return h.waitCh
 }
 
@@ -187,6 +179,18 @@ func (h *javaHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+func (h *javaHandle) Signal(s os.Signal) error {
+	return h.cmd.Signal(s)
+}
+
+func (h *javaHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	return h.cmd.Stats()
+}
+
+func (h *javaHandle) Exec(opts *cstructs.ExecOptions) (int, error) {
+	return h.cmd.Exec(opts)
+}
+
 func (h *javaHandle) Kill() error {
 	h.cmd.Shutdown()
 	select {
@@ -198,10 +202,8 @@ func (h *javaHandle) Kill() error {
 }
 
 func (h *javaHandle) run() {
-	err := h.cmd.Wait()
+	res := h.cmd.Wait()
 	close(h.doneCh)
-	if err != nil {
-		h.waitCh <- err
-	}
+	h.waitCh <- res
 	close(h.waitCh)
 }
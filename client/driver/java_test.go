@@ -50,9 +50,16 @@ func TestJavaDriver_StartOpen_Wait(t *testing.T) {
 	task := &structs.Task{
 		Name: "demo-app",
 		Config: map[string]string{
-			"artifact_source": "https://dl.dropboxusercontent.com/u/47675/jar_thing/demoapp.jar",
-			"jvm_options":     "-Xmx2048m -Xms256m",
-			"checksum":        "sha256:58d6e8130308d32e197c5108edd4f56ddf1417408f743097c2e662df0f0b17c8",
+			"jar_path":    "local/demoapp.jar",
+			"jvm_options": "-Xmx2048m -Xms256m",
+		},
+		Artifacts: []*structs.TaskArtifact{
+			&structs.TaskArtifact{
+				GetterSource: "https://dl.dropboxusercontent.com/u/47675/jar_thing/demoapp.jar",
+				GetterOptions: map[string]string{
+					"checksum": "sha256:58d6e8130308d32e197c5108edd4f56ddf1417408f743097c2e662df0f0b17c8",
+				},
+			},
 		},
 		Resources: basicResources,
 	}
@@ -60,6 +67,7 @@ func TestJavaDriver_StartOpen_Wait(t *testing.T) {
 	driverCtx := testDriverContext(task.Name)
 	ctx := testDriverExecContext(task, driverCtx)
 	defer ctx.AllocDir.Destroy()
+	testDownloadArtifacts(t, task, ctx)
 	d := NewJavaDriver(driverCtx)
 
 	handle, err := d.Start(ctx, task)
@@ -96,9 +104,16 @@ func TestJavaDriver_Start_Wait(t *testing.T) {
 	task := &structs.Task{
 		Name: "demo-app",
 		Config: map[string]string{
-			"artifact_source": "https://dl.dropboxusercontent.com/u/47675/jar_thing/demoapp.jar",
-			"jvm_options":     "-Xmx2048m -Xms256m",
-			"checksum":        "sha256:58d6e8130308d32e197c5108edd4f56ddf1417408f743097c2e662df0f0b17c8",
+			"jar_path":    "local/demoapp.jar",
+			"jvm_options": "-Xmx2048m -Xms256m",
+		},
+		Artifacts: []*structs.TaskArtifact{
+			&structs.TaskArtifact{
+				GetterSource: "https://dl.dropboxusercontent.com/u/47675/jar_thing/demoapp.jar",
+				GetterOptions: map[string]string{
+					"checksum": "sha256:58d6e8130308d32e197c5108edd4f56ddf1417408f743097c2e662df0f0b17c8",
+				},
+			},
 		},
 		Resources: basicResources,
 	}
@@ -106,6 +121,7 @@ func TestJavaDriver_Start_Wait(t *testing.T) {
 	driverCtx := testDriverContext(task.Name)
 	ctx := testDriverExecContext(task, driverCtx)
 	defer ctx.AllocDir.Destroy()
+	testDownloadArtifacts(t, task, ctx)
 	d := NewJavaDriver(driverCtx)
 
 	handle, err := d.Start(ctx, task)
@@ -118,9 +134,9 @@ func TestJavaDriver_Start_Wait(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err != nil {
-			t.Fatalf("err: %v", err)
+	case res := <-handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("err: %v", res)
 		}
 	case <-time.After(2 * time.Second):
 		// expect the timeout b/c it's a long lived process
@@ -143,7 +159,12 @@ func TestJavaDriver_Start_Kill_Wait(t *testing.T) {
 	task := &structs.Task{
 		Name: "demo-app",
 		Config: map[string]string{
-			"artifact_source": "https://dl.dropboxusercontent.com/u/47675/jar_thing/demoapp.jar",
+			"jar_path": "local/demoapp.jar",
+		},
+		Artifacts: []*structs.TaskArtifact{
+			&structs.TaskArtifact{
+				GetterSource: "https://dl.dropboxusercontent.com/u/47675/jar_thing/demoapp.jar",
+			},
 		},
 		Resources: basicResources,
 	}
@@ -151,6 +172,7 @@ func TestJavaDriver_Start_Kill_Wait(t *testing.T) {
 	driverCtx := testDriverContext(task.Name)
 	ctx := testDriverExecContext(task, driverCtx)
 	defer ctx.AllocDir.Destroy()
+	testDownloadArtifacts(t, task, ctx)
 	d := NewJavaDriver(driverCtx)
 
 	handle, err := d.Start(ctx, task)
@@ -171,8 +193,8 @@ func TestJavaDriver_Start_Kill_Wait(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err == nil {
+	case res := <-handle.WaitCh():
+		if res.Successful() {
 			t.Fatal("should err")
 		}
 	case <-time.After(8 * time.Second):
@@ -0,0 +1,274 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// OriginStart and OriginEnd are the origins an offset into the logs is
+	// relative to
+	OriginStart = "start"
+	OriginEnd   = "end"
+
+	// pollInterval is how often a following reader checks for new log data
+	pollInterval = 250 * time.Millisecond
+)
+
+// LogReader reads the log files of a base path in order. A following reader
+// blocks waiting for new log lines once it has read all of the existing ones
+// until it is closed.
+type LogReader struct {
+	path   string
+	follow bool
+
+	index    int
+	offset   int64
+	file     *os.File
+	lock     sync.Mutex
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewLogReader returns a reader of the log files of the base path. The
+// reader starts offset bytes from the origin, which is either the start of
+// the oldest log file or the end of the newest one.
+func NewLogReader(path, origin string, offset int64, follow bool) (*LogReader, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset: %d", offset)
+	}
+
+	r := &LogReader{
+		path:   path,
+		follow: follow,
+		stopCh: make(chan struct{}),
+	}
+
+	indexes, sizes, err := logSizes(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(indexes) == 0 {
+		return r, nil
+	}
+
+	switch origin {
+	case OriginStart, "":
+		r.index, r.offset = indexes[len(indexes)-1], sizes[len(sizes)-1]
+		for i, size := range sizes {
+			if offset < size {
+				r.index, r.offset = indexes[i], offset
+				break
+			}
+			offset -= size
+		}
+	case OriginEnd:
+		r.index, r.offset = indexes[0], 0
+		for i := len(sizes) - 1; i >= 0; i-- {
+			if offset <= sizes[i] {
+				r.index, r.offset = indexes[i], sizes[i]-offset
+				break
+			}
+			offset -= sizes[i]
+		}
+	default:
+		return nil, fmt.Errorf("invalid origin %q", origin)
+	}
+	return r, nil
+}
+
+// Read reads the logs into p. Once all of the logs are read, a following
+// reader polls for new log lines and others return io.EOF.
+func (r *LogReader) Read(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for {
+		select {
+		case <-r.stopCh:
+			return 0, io.EOF
+		default:
+		}
+
+		if r.file == nil {
+			if err := r.openFile(); err != nil {
+				return 0, err
+			}
+		}
+
+		if r.file != nil {
+			n, err := r.file.Read(p)
+			if n > 0 {
+				return n, nil
+			}
+			if err != nil && err != io.EOF {
+				return 0, err
+			}
+
+			// Move on to the next log file once the current one is rotated
+			next, err := r.nextIndex()
+			if err != nil {
+				return 0, err
+			}
+			if next != -1 {
+				// Read any data written before the rotation
+				if n, _ := r.file.Read(p); n > 0 {
+					return n, nil
+				}
+				r.file.Close()
+				r.file = nil
+				r.index, r.offset = next, 0
+				continue
+			}
+		}
+
+		if !r.follow {
+			return 0, io.EOF
+		}
+
+		// Allow the reader to be closed while waiting for new log lines
+		r.lock.Unlock()
+		select {
+		case <-r.stopCh:
+		case <-time.After(pollInterval):
+		}
+		r.lock.Lock()
+	}
+}
+
+// Close stops the reader, unblocking a pending Read of a following reader.
+func (r *LogReader) Close() error {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.file != nil {
+		err := r.file.Close()
+		r.file = nil
+		return err
+	}
+	return nil
+}
+
+// openFile opens the log file of the current index at the current offset. If
+// the file was removed, the reader skips ahead to the oldest existing log file
+// and if no log file exists yet, the file is left unopened.
+func (r *LogReader) openFile() error {
+	f, err := os.Open(logFile(r.path, r.index))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to open log file: %v", err)
+		}
+
+		next, err := r.nextIndex()
+		if err != nil || next == -1 {
+			return err
+		}
+		r.index, r.offset = next, 0
+		return r.openFile()
+	}
+
+	if _, err := f.Seek(r.offset, 0); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to seek log file: %v", err)
+	}
+	r.file = f
+	return nil
+}
+
+// nextIndex returns the index of the oldest log file newer than the current
+// one or -1 if there is none.
+func (r *LogReader) nextIndex() (int, error) {
+	indexes, err := logIndexes(r.path)
+	if err != nil {
+		return -1, err
+	}
+	for _, idx := range indexes {
+		if idx > r.index {
+			return idx, nil
+		}
+	}
+	return -1, nil
+}
+
+// TailOffset returns the offset from the end of the logs of the base path at
+// which the last n lines start.
+func TailOffset(path string, lines int) (int64, error) {
+	if lines < 1 {
+		return 0, nil
+	}
+
+	indexes, sizes, err := logSizes(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var offset int64
+	found := 0
+	buf := make([]byte, 32*1024)
+	for i := len(indexes) - 1; i >= 0; i-- {
+		f, err := os.Open(logFile(path, indexes[i]))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, fmt.Errorf("failed to open log file: %v", err)
+		}
+
+		// Scan the file backwards for newlines
+		end := sizes[i]
+		for end > 0 {
+			start := end - int64(len(buf))
+			if start < 0 {
+				start = 0
+			}
+			chunk := buf[:end-start]
+			if _, err := f.ReadAt(chunk, start); err != nil && err != io.EOF {
+				f.Close()
+				return 0, fmt.Errorf("failed to read log file: %v", err)
+			}
+
+			for j := len(chunk) - 1; j >= 0; j-- {
+				// The newline ending the last line doesn't start a line
+				if chunk[j] == '\n' && offset != 0 {
+					found++
+					if found == lines {
+						f.Close()
+						return offset, nil
+					}
+				}
+				offset++
+			}
+			end = start
+		}
+		f.Close()
+	}
+	return offset, nil
+}
+
+// logSizes returns the sorted indexes of the log files of the base path and
+// their sizes.
+func logSizes(path string) ([]int, []int64, error) {
+	indexes, err := logIndexes(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var existing []int
+	var sizes []int64
+	for _, idx := range indexes {
+		fi, err := os.Stat(logFile(path, idx))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("failed to stat log file: %v", err)
+		}
+		existing = append(existing, idx)
+		sizes = append(sizes, fi.Size())
+	}
+	return existing, sizes, nil
+}
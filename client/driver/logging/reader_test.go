@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// testLogs writes the log lines to rotated log files of five bytes
+func testLogs(t *testing.T, path, logs string) *FileRotator {
+	r, err := NewFileRotator(path, 10, 5)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := r.Write([]byte(logs)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return r
+}
+
+func TestLogReader_Offsets(t *testing.T) {
+	path, cleanup := testLogPath(t)
+	defer cleanup()
+	testLogs(t, path, "foo\nbar\nbaz\n").Close()
+
+	cases := []struct {
+		origin string
+		offset int64
+		exp    string
+	}{
+		{OriginStart, 0, "foo\nbar\nbaz\n"},
+		{OriginStart, 6, "r\nbaz\n"},
+		{OriginStart, 20, ""},
+		{OriginEnd, 0, ""},
+		{OriginEnd, 4, "baz\n"},
+		{OriginEnd, 20, "foo\nbar\nbaz\n"},
+	}
+	for _, c := range cases {
+		r, err := NewLogReader(path, c.origin, c.offset, false)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if string(out) != c.exp {
+			t.Fatalf("%s %d: got %q; want %q", c.origin, c.offset, out, c.exp)
+		}
+	}
+
+	if _, err := NewLogReader(path, "middle", 0, false); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestLogReader_Follow(t *testing.T) {
+	path, cleanup := testLogPath(t)
+	defer cleanup()
+	w := testLogs(t, path, "foo\n")
+	defer w.Close()
+
+	r, err := NewLogReader(path, OriginStart, 0, true)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	outCh := make(chan []byte)
+	go func() {
+		out, _ := ioutil.ReadAll(r)
+		outCh <- out
+	}()
+
+	// Lines written across a rotation are followed
+	time.Sleep(2 * pollInterval)
+	w.Write([]byte("bar\nbaz\n"))
+	time.Sleep(2 * pollInterval)
+	r.Close()
+
+	select {
+	case out := <-outCh:
+		if string(out) != "foo\nbar\nbaz\n" {
+			t.Fatalf("bad: %q", out)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("reader not stopped")
+	}
+
+	// A closed reader returns EOF
+	if _, err := r.Read(make([]byte, 10)); err != io.EOF {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestTailOffset(t *testing.T) {
+	path, cleanup := testLogPath(t)
+	defer cleanup()
+	testLogs(t, path, "foo\nbar\nbaz\n").Close()
+
+	cases := map[int]int64{
+		0:  0,
+		1:  4,
+		2:  8,
+		3:  12,
+		10: 12,
+	}
+	for lines, exp := range cases {
+		offset, err := TailOffset(path, lines)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if offset != exp {
+			t.Fatalf("%d lines: got %d; want %d", lines, offset, exp)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Package logging implements the rotation of the stdout and stderr logs of
+// tasks and the reading of the rotated logs. The logs of a task are written to
+// a sequence of files named <path>.<index> in the shared alloc log directory,
+// where a higher index holds newer log lines.
+package logging
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// Stdout and Stderr are the types of logs written for a task
+	Stdout = "stdout"
+	Stderr = "stderr"
+
+	// bytesPerMB is used to convert the MB of a log config into bytes
+	bytesPerMB = 1024 * 1024
+)
+
+// LogPath returns the base path of the rotated logs of the given type of a
+// task in the passed log directory.
+func LogPath(logDir, taskName, logType string) string {
+	return filepath.Join(logDir, fmt.Sprintf("%s.%s", taskName, logType))
+}
+
+// FileSizeBytes converts a log file size in MB to bytes.
+func FileSizeBytes(sizeMB int) int64 {
+	return int64(sizeMB) * bytesPerMB
+}
+
+// FileRotator is a writer that writes to the log files of a base path,
+// starting a new file once the current one reaches the maximum file size and
+// removing the oldest files so that at most MaxFiles are kept.
+type FileRotator struct {
+	MaxFiles int
+	FileSize int64
+
+	path        string
+	index       int
+	currentFile *os.File
+	currentSize int64
+	lock        sync.Mutex
+}
+
+// NewFileRotator returns a FileRotator writing to the log files of the base
+// path. Writing resumes in the newest existing log file.
+func NewFileRotator(path string, maxFiles int, fileSize int64) (*FileRotator, error) {
+	if maxFiles < 1 {
+		return nil, fmt.Errorf("invalid number of log files: %d", maxFiles)
+	}
+	if fileSize < 1 {
+		return nil, fmt.Errorf("invalid log file size: %d", fileSize)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %v", err)
+	}
+
+	r := &FileRotator{
+		MaxFiles: maxFiles,
+		FileSize: fileSize,
+		path:     path,
+	}
+
+	indexes, err := logIndexes(path)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(indexes); n != 0 {
+		r.index = indexes[n-1]
+	}
+	if err := r.openFile(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes p to the log files, rotating them when the current file is
+// full.
+func (r *FileRotator) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.currentFile == nil {
+		return 0, fmt.Errorf("file rotator is closed")
+	}
+
+	written := 0
+	for written < len(p) {
+		if r.currentSize >= r.FileSize {
+			if err := r.rotate(); err != nil {
+				return written, err
+			}
+		}
+
+		chunk := p[written:]
+		if remaining := r.FileSize - r.currentSize; int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+		n, err := r.currentFile.Write(chunk)
+		written += n
+		r.currentSize += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Close closes the current log file.
+func (r *FileRotator) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.currentFile == nil {
+		return nil
+	}
+	err := r.currentFile.Close()
+	r.currentFile = nil
+	return err
+}
+
+// rotate closes the current log file, opens the next one and removes the
+// files that exceed the maximum number of files.
+func (r *FileRotator) rotate() error {
+	if err := r.currentFile.Close(); err != nil {
+		return err
+	}
+	r.index++
+	if err := r.openFile(); err != nil {
+		return err
+	}
+	return r.purge()
+}
+
+// openFile opens the log file of the current index for appending.
+func (r *FileRotator) openFile() error {
+	f, err := os.OpenFile(logFile(r.path, r.index), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %v", err)
+	}
+	r.currentFile = f
+	r.currentSize = fi.Size()
+	return nil
+}
+
+// purge removes the oldest log files so that at most MaxFiles are kept.
+func (r *FileRotator) purge() error {
+	indexes, err := logIndexes(r.path)
+	if err != nil {
+		return err
+	}
+	for _, idx := range indexes {
+		if idx > r.index-r.MaxFiles {
+			break
+		}
+		if err := os.Remove(logFile(r.path, idx)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove log file: %v", err)
+		}
+	}
+	return nil
+}
+
+// logFile returns the path of the log file with the given index.
+func logFile(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
+
+// logIndexes returns the sorted indexes of the existing log files of the base
+// path.
+func logIndexes(path string) ([]int, error) {
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list log files: %v", err)
+	}
+
+	prefix := filepath.Base(path) + "."
+	var indexes []int
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+		if err != nil || idx < 0 {
+			continue
+		}
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}
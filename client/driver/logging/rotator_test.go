@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testLogPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return LogPath(filepath.Join(dir, "logs"), "web", Stdout), func() { os.RemoveAll(dir) }
+}
+
+func TestFileRotator_Rotate(t *testing.T) {
+	path, cleanup := testLogPath(t)
+	defer cleanup()
+
+	r, err := NewFileRotator(path, 2, 5)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n, err := r.Write([]byte("abcdefghijkl")); err != nil || n != 12 {
+		t.Fatalf("bad: %d %v", n, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The oldest file is removed once more than two files exist
+	indexes, err := logIndexes(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(indexes, []int{1, 2}) {
+		t.Fatalf("bad: %v", indexes)
+	}
+	for idx, exp := range map[int]string{1: "fghij", 2: "kl"} {
+		out, err := ioutil.ReadFile(logFile(path, idx))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if string(out) != exp {
+			t.Fatalf("file %d: got %q; want %q", idx, out, exp)
+		}
+	}
+}
+
+func TestFileRotator_Resume(t *testing.T) {
+	path, cleanup := testLogPath(t)
+	defer cleanup()
+
+	r, err := NewFileRotator(path, 3, 5)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r.Write([]byte("abcdefg"))
+	r.Close()
+
+	// A new rotator continues writing the newest file
+	r, err = NewFileRotator(path, 3, 5)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r.Write([]byte("hijk"))
+	r.Close()
+
+	out, err := ioutil.ReadFile(logFile(path, 1))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != "fghij" {
+		t.Fatalf("bad: %q", out)
+	}
+	out, err = ioutil.ReadFile(logFile(path, 2))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != "k" {
+		t.Fatalf("bad: %q", out)
+	}
+}
+
+func TestNewFileRotator_Invalid(t *testing.T) {
+	path, cleanup := testLogPath(t)
+	defer cleanup()
+
+	if _, err := NewFileRotator(path, 0, 5); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err := NewFileRotator(path, 1, 0); err == nil {
+		t.Fatalf("expected error")
+	}
+}
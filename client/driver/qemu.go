@@ -1,20 +1,23 @@
 package driver
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/executor"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/client/fingerprint"
-	"github.com/hashicorp/nomad/client/getter"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -22,6 +25,16 @@ var (
 	reQemuVersion = regexp.MustCompile(`version (\d[\.\d+]+)`)
 )
 
+const (
+	// qemuMonitorSocket is the name of the socket in the task directory the
+	// Qemu monitor listens on
+	qemuMonitorSocket = "qemu-monitor.sock"
+
+	// qemuGracefulShutdownCmd is the monitor command that sends an ACPI
+	// shutdown request to the VM
+	qemuGracefulShutdownCmd = "system_powerdown\n"
+)
+
 // QemuDriver is a driver for running images via Qemu
 // We attempt to chose sane defaults for now, with more configuration available
 // planned in the future
@@ -32,9 +45,21 @@ type QemuDriver struct {
 
 // qemuHandle is returned from Start/Open as a handle to the PID
 type qemuHandle struct {
-	cmd    executor.Executor
-	waitCh chan error
-	doneCh chan struct{}
+	cmd         executor.Executor
+	monitorPath string
+	waitCh      chan *cstructs.WaitResult
+	doneCh      chan struct{}
+
+	// killSignal is the name of the task's kill signal, which is sent to the
+	// VM as an ACPI shutdown request
+	killSignal     string
+	killSignalLock sync.Mutex
+}
+
+// qemuID is the ID of a qemuHandle from which the handle is re-opened
+type qemuID struct {
+	ExecutorID string
+	KillSignal string
 }
 
 // NewQemuDriver is used to create a new exec driver
@@ -69,10 +94,11 @@ func (d *QemuDriver) Fingerprint(cfg *config.Config, node *structs.Node) (bool,
 // Run an existing Qemu image. Start() will pull down an existing, valid Qemu
 // image and save it to the Drivers Allocation Dir
 func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle, error) {
-	// Get the image source
-	source, ok := task.Config["artifact_source"]
-	if !ok || source == "" {
-		return nil, fmt.Errorf("Missing source image Qemu driver")
+	// Get the path of the image, relative to the task directory. The image is
+	// usually downloaded as an artifact of the task.
+	imagePath, ok := task.Config["image_path"]
+	if !ok || imagePath == "" {
+		return nil, fmt.Errorf("Missing image_path for Qemu driver")
 	}
 
 	// Qemu defaults to 128M of RAM for a given VM. Instead, we force users to
@@ -87,18 +113,9 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		return nil, fmt.Errorf("Could not find task directory for task: %v", d.DriverContext.taskName)
 	}
 
-	// Proceed to download an artifact to be executed.
-	vmPath, err := getter.GetArtifact(
-		filepath.Join(taskDir, allocdir.TaskLocal),
-		task.Config["artifact_source"],
-		task.Config["checksum"],
-		d.logger,
-	)
-	if err != nil {
-		return nil, err
-	}
-
+	vmPath := filepath.Join(taskDir, imagePath)
 	vmID := filepath.Base(vmPath)
+	monitorPath := filepath.Join(taskDir, qemuMonitorSocket)
 
 	// Parse configuration arguments
 	// Create the base arguments
@@ -118,6 +135,7 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		"-nodefconfig",
 		"-nodefaults",
 		"-nographic",
+		"-monitor", fmt.Sprintf("unix:%s,server,nowait", monitorPath),
 	}
 
 	// Check the Resources required Networks to add port mappings. If no resources
@@ -183,6 +201,10 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		return nil, fmt.Errorf("failed to configure task directory: %v", err)
 	}
 
+	if err := cmd.ConfigureLogs(task.LogConfig); err != nil {
+		return nil, fmt.Errorf("failed to configure logs: %v", err)
+	}
+
 	d.logger.Printf("[DEBUG] Starting QemuVM command: %q", strings.Join(args, " "))
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start command: %v", err)
@@ -191,9 +213,11 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 
 	// Create and Return Handle
 	h := &qemuHandle{
-		cmd:    cmd,
-		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+		cmd:         cmd,
+		monitorPath: monitorPath,
+		doneCh:      make(chan struct{}),
+		waitCh:      make(chan *cstructs.WaitResult, 1),
+		killSignal:  qemuKillSignal(task),
 	}
 
 	go h.run()
@@ -201,36 +225,88 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 }
 
 func (d *QemuDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
+	id := &qemuID{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(handleID, "QEMU:")), id); err != nil {
+		return nil, fmt.Errorf("failed to parse handle '%s': %v", handleID, err)
+	}
+
 	// Find the process
-	cmd, err := executor.OpenId(handleID)
+	cmd, err := executor.OpenId(id.ExecutorID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open ID %v: %v", handleID, err)
+		return nil, fmt.Errorf("failed to open ID %v: %v", id.ExecutorID, err)
 	}
 
 	// Return a driver handle
-	h := &execHandle{
-		cmd:    cmd,
-		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+	h := &qemuHandle{
+		cmd:        cmd,
+		doneCh:     make(chan struct{}),
+		waitCh:     make(chan *cstructs.WaitResult, 1),
+		killSignal: id.KillSignal,
+	}
+	if taskDir, ok := ctx.AllocDir.TaskDirs[d.DriverContext.taskName]; ok {
+		h.monitorPath = filepath.Join(taskDir, qemuMonitorSocket)
 	}
 	go h.run()
 	return h, nil
 }
 
 func (h *qemuHandle) ID() string {
-	id, _ := h.cmd.ID()
-	return id
+	executorID, _ := h.cmd.ID()
+	h.killSignalLock.Lock()
+	id := qemuID{ExecutorID: executorID, KillSignal: h.killSignal}
+	h.killSignalLock.Unlock()
+	data, _ := json.Marshal(id)
+	return fmt.Sprintf("QEMU:%s", string(data))
 }
 
-func (h *qemuHandle) WaitCh() chan error {
+func (h *qemuHandle) WaitCh() chan *cstructs.WaitResult {
 	return h.waitCh
 }
 
 func (h *qemuHandle) Update(task *structs.Task) error {
-	// Update is not possible
+	// Only the kill signal can be updated
+	h.killSignalLock.Lock()
+	h.killSignal = qemuKillSignal(task)
+	h.killSignalLock.Unlock()
 	return nil
 }
 
+// Signal asks the VM to shut down by sending an ACPI shutdown request through
+// the Qemu monitor when sent the task's kill signal. The guest OS decides how
+// to handle the request. Other signals can't be forwarded to the VM so an
+// error is returned for them.
+func (h *qemuHandle) Signal(s os.Signal) error {
+	h.killSignalLock.Lock()
+	killSignal := h.killSignal
+	h.killSignalLock.Unlock()
+	if sig, err := ParseSignal(killSignal); err != nil || sig != s {
+		return fmt.Errorf("the Qemu driver can only send the kill signal to the VM, not %v", s)
+	}
+
+	if h.monitorPath == "" {
+		return fmt.Errorf("the Qemu monitor of the VM is unknown")
+	}
+	conn, err := net.Dial("unix", h.monitorPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the Qemu monitor: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(qemuGracefulShutdownCmd)); err != nil {
+		return fmt.Errorf("failed to send the shutdown request to the Qemu monitor: %v", err)
+	}
+	return nil
+}
+
+func (h *qemuHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	return h.cmd.Stats()
+}
+
+// Exec isn't supported as commands would run next to the VM rather than in it
+func (h *qemuHandle) Exec(opts *cstructs.ExecOptions) (int, error) {
+	return 0, cstructs.ErrExecNotSupported
+}
+
 // TODO: allow a 'shutdown_command' that can be executed over a ssh connection
 // to the VM
 func (h *qemuHandle) Kill() error {
@@ -243,11 +319,17 @@ func (h *qemuHandle) Kill() error {
 	}
 }
 
+// qemuKillSignal returns the name of the kill signal of the task
+func qemuKillSignal(task *structs.Task) string {
+	if task.KillSignal == "" {
+		return structs.DefaultKillSignal
+	}
+	return task.KillSignal
+}
+
 func (h *qemuHandle) run() {
-	err := h.cmd.Wait()
+	res := h.cmd.Wait()
 	close(h.doneCh)
-	if err != nil {
-		h.waitCh <- err
-	}
+	h.waitCh <- res
 	close(h.waitCh)
 }
@@ -2,6 +2,11 @@ package driver
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
 
 	"github.com/hashicorp/nomad/client/config"
@@ -38,10 +43,17 @@ func TestQemuDriver_StartOpen_Wait(t *testing.T) {
 	task := &structs.Task{
 		Name: "linux",
 		Config: map[string]string{
-			"artifact_source": "https://dl.dropboxusercontent.com/u/47675/jar_thing/linux-0.2.img",
-			"checksum":        "sha256:a5e836985934c3392cbbd9b26db55a7d35a8d7ae1deb7ca559dd9c0159572544",
-			"accelerator":     "tcg",
-			"guest_ports":     "22,8080",
+			"image_path":  "local/linux-0.2.img",
+			"accelerator": "tcg",
+			"guest_ports": "22,8080",
+		},
+		Artifacts: []*structs.TaskArtifact{
+			&structs.TaskArtifact{
+				GetterSource: "https://dl.dropboxusercontent.com/u/47675/jar_thing/linux-0.2.img",
+				GetterOptions: map[string]string{
+					"checksum": "sha256:a5e836985934c3392cbbd9b26db55a7d35a8d7ae1deb7ca559dd9c0159572544",
+				},
+			},
 		},
 		Resources: &structs.Resources{
 			CPU:      500,
@@ -57,6 +69,7 @@ func TestQemuDriver_StartOpen_Wait(t *testing.T) {
 	driverCtx := testDriverContext(task.Name)
 	ctx := testDriverExecContext(task, driverCtx)
 	defer ctx.AllocDir.Destroy()
+	testDownloadArtifacts(t, task, ctx)
 	d := NewQemuDriver(driverCtx)
 
 	handle, err := d.Start(ctx, task)
@@ -88,11 +101,10 @@ func TestQemuDriver_RequiresMemory(t *testing.T) {
 	task := &structs.Task{
 		Name: "linux",
 		Config: map[string]string{
-			"artifact_source": "https://dl.dropboxusercontent.com/u/47675/jar_thing/linux-0.2.img",
-			"accelerator":     "tcg",
-			"host_port":       "8080",
-			"guest_port":      "8081",
-			"checksum":        "sha256:a5e836985934c3392cbbd9b26db55a7d35a8d7ae1deb7ca559dd9c0159572544",
+			"image_path":  "local/linux-0.2.img",
+			"accelerator": "tcg",
+			"host_port":   "8080",
+			"guest_port":  "8081",
 			// ssh u/p would be here
 		},
 	}
@@ -107,3 +119,42 @@ func TestQemuDriver_RequiresMemory(t *testing.T) {
 		t.Fatalf("Expected error when not specifying memory")
 	}
 }
+
+func TestQemuHandle_Signal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Listen on the monitor socket of the VM
+	monitorPath := filepath.Join(dir, qemuMonitorSocket)
+	l, err := net.Listen("unix", monitorPath)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+	h := &qemuHandle{monitorPath: monitorPath, killSignal: "SIGTERM"}
+
+	// Signals other than the kill signal can't be sent to the VM
+	if err := h.Signal(syscall.SIGHUP); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// The kill signal is sent as an ACPI shutdown request
+	if err := h.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer conn.Close()
+	out, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != qemuGracefulShutdownCmd {
+		t.Fatalf("bad: %q", out)
+	}
+}
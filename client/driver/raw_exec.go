@@ -2,15 +2,14 @@ package driver
 
 import (
 	"fmt"
-	"path/filepath"
+	"os"
 	"strconv"
 	"time"
 
-	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/executor"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/client/fingerprint"
-	"github.com/hashicorp/nomad/client/getter"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -30,7 +29,7 @@ type RawExecDriver struct {
 // rawExecHandle is returned from Start/Open as a handle to the PID
 type rawExecHandle struct {
 	cmd    executor.Executor
-	waitCh chan error
+	waitCh chan *cstructs.WaitResult
 	doneCh chan struct{}
 }
 
@@ -56,34 +55,12 @@ func (d *RawExecDriver) Fingerprint(cfg *config.Config, node *structs.Node) (boo
 }
 
 func (d *RawExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle, error) {
-	// Get the tasks local directory.
-	taskName := d.DriverContext.taskName
-	taskDir, ok := ctx.AllocDir.TaskDirs[taskName]
-	if !ok {
-		return nil, fmt.Errorf("Could not find task directory for task: %v", d.DriverContext.taskName)
-	}
-
 	// Get the command to be ran
 	command, ok := task.Config["command"]
 	if !ok || command == "" {
 		return nil, fmt.Errorf("missing command for Raw Exec driver")
 	}
 
-	// Check if an artificat is specified and attempt to download it
-	source, ok := task.Config["artifact_source"]
-	if ok && source != "" {
-		// Proceed to download an artifact to be executed.
-		_, err := getter.GetArtifact(
-			filepath.Join(taskDir, allocdir.TaskLocal),
-			task.Config["artifact_source"],
-			task.Config["checksum"],
-			d.logger,
-		)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	// Get the environment variables.
 	envVars := TaskEnvironmentVariables(ctx, task)
 
@@ -107,6 +84,10 @@ func (d *RawExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandl
 		return nil, fmt.Errorf("failed to configure task directory: %v", err)
 	}
 
+	if err := cmd.ConfigureLogs(task.LogConfig); err != nil {
+		return nil, fmt.Errorf("failed to configure logs: %v", err)
+	}
+
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start command: %v", err)
 	}
@@ -115,7 +96,7 @@ func (d *RawExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandl
 	h := &execHandle{
 		cmd:    cmd,
 		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+		waitCh: make(chan *cstructs.WaitResult, 1),
 	}
 	go h.run()
 	return h, nil
@@ -132,7 +113,7 @@ func (d *RawExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, e
 	h := &execHandle{
 		cmd:    cmd,
 		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+		waitCh: make(chan *cstructs.WaitResult, 1),
 	}
 	go h.run()
 	return h, nil
@@ -143,7 +124,7 @@ func (h *rawExecHandle) ID() string {
 	return id
 }
 
-func (h *rawExecHandle) WaitCh() chan error {
+func (h *rawExecHandle) WaitCh() chan *cstructs.WaitResult {
 	return h.waitCh
 }
 
@@ -152,6 +133,18 @@ func (h *rawExecHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+func (h *rawExecHandle) Signal(s os.Signal) error {
+	return h.cmd.Signal(s)
+}
+
+func (h *rawExecHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	return h.cmd.Stats()
+}
+
+func (h *rawExecHandle) Exec(opts *cstructs.ExecOptions) (int, error) {
+	return h.cmd.Exec(opts)
+}
+
 func (h *rawExecHandle) Kill() error {
 	h.cmd.Shutdown()
 	select {
@@ -163,10 +156,8 @@ func (h *rawExecHandle) Kill() error {
 }
 
 func (h *rawExecHandle) run() {
-	err := h.cmd.Wait()
+	res := h.cmd.Wait()
 	close(h.doneCh)
-	if err != nil {
-		h.waitCh <- err
-	}
+	h.waitCh <- res
 	close(h.waitCh)
 }
@@ -3,12 +3,15 @@ package driver
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/environment"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -101,15 +104,22 @@ func TestRawExecDriver_Start_Artifact_basic(t *testing.T) {
 	task := &structs.Task{
 		Name: "sleep",
 		Config: map[string]string{
-			"artifact_source": fmt.Sprintf("https://dl.dropboxusercontent.com/u/47675/jar_thing/%s", file),
-			"command":         filepath.Join("$NOMAD_TASK_DIR", file),
-			"checksum":        checksum,
+			"command": filepath.Join("$NOMAD_TASK_DIR", file),
+		},
+		Artifacts: []*structs.TaskArtifact{
+			&structs.TaskArtifact{
+				GetterSource: fmt.Sprintf("https://dl.dropboxusercontent.com/u/47675/jar_thing/%s", file),
+				GetterOptions: map[string]string{
+					"checksum": checksum,
+				},
+			},
 		},
 		Resources: basicResources,
 	}
 	driverCtx := testDriverContext(task.Name)
 	ctx := testDriverExecContext(task, driverCtx)
 	defer ctx.AllocDir.Destroy()
+	testDownloadArtifacts(t, task, ctx)
 
 	d := NewRawExecDriver(driverCtx)
 	handle, err := d.Start(ctx, task)
@@ -149,15 +159,26 @@ func TestRawExecDriver_Start_Artifact_expanded(t *testing.T) {
 	task := &structs.Task{
 		Name: "sleep",
 		Config: map[string]string{
-			"artifact_source": fmt.Sprintf("https://dl.dropboxusercontent.com/u/47675/jar_thing/%s", file),
-			"command":         "/bin/bash",
-			"args":            fmt.Sprintf("-c '/bin/sleep 1 && %s'", filepath.Join("$NOMAD_TASK_DIR", file)),
+			"command": "/bin/bash",
+			"args":    fmt.Sprintf("-c '/bin/sleep 1 && %s'", filepath.Join("$NOMAD_TASK_DIR", file)),
+		},
+		Artifacts: []*structs.TaskArtifact{
+			&structs.TaskArtifact{
+				GetterSource: fmt.Sprintf("https://dl.dropboxusercontent.com/u/47675/jar_thing/%s", file),
+			},
 		},
 		Resources: basicResources,
 	}
 	driverCtx := testDriverContext(task.Name)
 	ctx := testDriverExecContext(task, driverCtx)
 	defer ctx.AllocDir.Destroy()
+	testDownloadArtifacts(t, task, ctx)
+
+	// The artifact is run by bash rather than as the command so it has to be
+	// made executable
+	if err := os.Chmod(filepath.Join(ctx.AllocDir.TaskDirs[task.Name], allocdir.TaskLocal, file), 0755); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
 	d := NewRawExecDriver(driverCtx)
 	handle, err := d.Start(ctx, task)
@@ -216,9 +237,9 @@ func TestRawExecDriver_Start_Wait(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err != nil {
-			t.Fatalf("err: %v", err)
+	case res := <-handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("err: %v", res)
 		}
 	case <-time.After(2 * time.Second):
 		t.Fatalf("timeout")
@@ -252,9 +273,9 @@ func TestRawExecDriver_Start_Wait_AllocDir(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err != nil {
-			t.Fatalf("err: %v", err)
+	case res := <-handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("err: %v", res)
 		}
 	case <-time.After(2 * time.Second):
 		t.Fatalf("timeout")
@@ -305,11 +326,50 @@ func TestRawExecDriver_Start_Kill_Wait(t *testing.T) {
 
 	// Task should terminate quickly
 	select {
-	case err := <-handle.WaitCh():
-		if err == nil {
+	case res := <-handle.WaitCh():
+		if res.Successful() {
 			t.Fatal("should err")
 		}
 	case <-time.After(2 * time.Second):
 		t.Fatalf("timeout")
 	}
 }
+
+func TestRawExecDriver_Signal(t *testing.T) {
+	task := &structs.Task{
+		Name: "sleep",
+		Config: map[string]string{
+			"command": "/bin/sleep",
+			"args":    "10",
+		},
+		Resources: basicResources,
+	}
+
+	driverCtx := testDriverContext(task.Name)
+	ctx := testDriverExecContext(task, driverCtx)
+	defer ctx.AllocDir.Destroy()
+
+	d := NewRawExecDriver(driverCtx)
+	handle, err := d.Start(ctx, task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if handle == nil {
+		t.Fatalf("missing handle")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if err := handle.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Task should exit because of the signal
+	select {
+	case res := <-handle.WaitCh():
+		if res.Signal != int(syscall.SIGTERM) {
+			t.Fatalf("bad: %v", res)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout")
+	}
+}
@@ -4,19 +4,20 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/args"
+	"github.com/hashicorp/nomad/client/driver/logging"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/client/fingerprint"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -37,9 +38,11 @@ type RktDriver struct {
 // rktHandle is returned from Start/Open as a handle to the PID
 type rktHandle struct {
 	proc   *os.Process
+	cmd    *exec.Cmd
+	logs   []io.Closer
 	image  string
 	logger *log.Logger
-	waitCh chan error
+	waitCh chan *cstructs.WaitResult
 	doneCh chan struct{}
 }
 
@@ -89,13 +92,11 @@ func (d *RktDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle, e
 		return nil, fmt.Errorf("Missing ACI image for rkt")
 	}
 
-	// Get the tasks local directory.
+	// Check that the task has a directory.
 	taskName := d.DriverContext.taskName
-	taskDir, ok := ctx.AllocDir.TaskDirs[taskName]
-	if !ok {
+	if _, ok := ctx.AllocDir.TaskDirs[taskName]; !ok {
 		return nil, fmt.Errorf("Could not find task directory for task: %v", d.DriverContext.taskName)
 	}
-	taskLocal := filepath.Join(taskDir, allocdir.TaskLocal)
 
 	// Add the given trust prefix
 	trust_prefix, trust_cmd := task.Config["trust_prefix"]
@@ -155,17 +156,21 @@ func (d *RktDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle, e
 		}
 	}
 
-	// Create files to capture stdin and out.
-	stdoutFilename := filepath.Join(taskLocal, fmt.Sprintf("%s.stdout", taskName))
-	stderrFilename := filepath.Join(taskLocal, fmt.Sprintf("%s.stderr", taskName))
-
-	stdo, err := os.OpenFile(stdoutFilename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	// Create rotated logs to capture stdout and stderr.
+	logConfig := task.LogConfig
+	if logConfig == nil {
+		logConfig = structs.DefaultLogConfig()
+	}
+	logDir := ctx.AllocDir.LogDir()
+	fileSize := logging.FileSizeBytes(logConfig.MaxFileSizeMB)
+	stdo, err := logging.NewFileRotator(logging.LogPath(logDir, taskName, logging.Stdout), logConfig.MaxFiles, fileSize)
 	if err != nil {
 		return nil, fmt.Errorf("Error opening file to redirect stdout: %v", err)
 	}
 
-	stde, err := os.OpenFile(stderrFilename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	stde, err := logging.NewFileRotator(logging.LogPath(logDir, taskName, logging.Stderr), logConfig.MaxFiles, fileSize)
 	if err != nil {
+		stdo.Close()
 		return nil, fmt.Errorf("Error opening file to redirect stderr: %v", err)
 	}
 
@@ -174,16 +179,20 @@ func (d *RktDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle, e
 	cmd.Stderr = stde
 
 	if err := cmd.Start(); err != nil {
+		stdo.Close()
+		stde.Close()
 		return nil, fmt.Errorf("Error running rkt: %v", err)
 	}
 
 	d.logger.Printf("[DEBUG] driver.rkt: started ACI %q with: %v", img, cmd.Args)
 	h := &rktHandle{
 		proc:   cmd.Process,
+		cmd:    cmd,
+		logs:   []io.Closer{stdo, stde},
 		image:  img,
 		logger: d.logger,
 		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+		waitCh: make(chan *cstructs.WaitResult, 1),
 	}
 	go h.run()
 	return h, nil
@@ -209,7 +218,7 @@ func (d *RktDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error
 		image:  qpid.Image,
 		logger: d.logger,
 		doneCh: make(chan struct{}),
-		waitCh: make(chan error, 1),
+		waitCh: make(chan *cstructs.WaitResult, 1),
 	}
 
 	go h.run()
@@ -229,7 +238,7 @@ func (h *rktHandle) ID() string {
 	return fmt.Sprintf("Rkt:%s", string(data))
 }
 
-func (h *rktHandle) WaitCh() chan error {
+func (h *rktHandle) WaitCh() chan *cstructs.WaitResult {
 	return h.waitCh
 }
 
@@ -238,6 +247,21 @@ func (h *rktHandle) Update(task *structs.Task) error {
 	return nil
 }
 
+// Signal sends a signal to the rkt process, which forwards it to the pod
+func (h *rktHandle) Signal(s os.Signal) error {
+	return h.proc.Signal(s)
+}
+
+// Stats isn't supported as the resources of the pod aren't tracked
+func (h *rktHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	return nil, cstructs.ErrStatsNotSupported
+}
+
+// Exec isn't supported as rkt can't enter a running pod
+func (h *rktHandle) Exec(opts *cstructs.ExecOptions) (int, error) {
+	return 0, cstructs.ErrExecNotSupported
+}
+
 // Kill is used to terminate the task. We send an Interrupt
 // and then provide a 5 second grace period before doing a Kill.
 func (h *rktHandle) Kill() error {
@@ -251,12 +275,34 @@ func (h *rktHandle) Kill() error {
 }
 
 func (h *rktHandle) run() {
-	ps, err := h.proc.Wait()
+	// Waiting on the command of a started task also waits for its output to
+	// be copied into the logs. A reopened task can only be waited on by PID.
+	var ps *os.ProcessState
+	var err error
+	if h.cmd != nil {
+		err = h.cmd.Wait()
+		ps = h.cmd.ProcessState
+		if _, ok := err.(*exec.ExitError); ok {
+			err = nil
+		}
+	} else {
+		ps, err = h.proc.Wait()
+	}
+	for _, l := range h.logs {
+		l.Close()
+	}
 	close(h.doneCh)
+	code, signal := 0, 0
 	if err != nil {
-		h.waitCh <- err
+		code = -1
+	} else if status, ok := ps.Sys().(syscall.WaitStatus); ok {
+		code = status.ExitStatus()
+		if status.Signaled() {
+			signal = int(status.Signal())
+		}
 	} else if !ps.Success() {
-		h.waitCh <- fmt.Errorf("task exited with error")
+		code = 1
 	}
+	h.waitCh <- cstructs.NewWaitResult(code, signal, err)
 	close(h.waitCh)
 }
@@ -0,0 +1,34 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// signals maps the names of the signals that can be sent to tasks to their
+// values. Signals only available on some platforms are added by the platform
+// specific files.
+var signals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGALRM": syscall.SIGALRM,
+}
+
+// ParseSignal returns the signal of the given name, such as "SIGTERM". The
+// SIG prefix is optional and the name is case insensitive.
+func ParseSignal(name string) (os.Signal, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig, ok := signals[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown signal %q", name)
+	}
+	return sig, nil
+}
@@ -0,0 +1,11 @@
+// +build !windows
+
+package driver
+
+import "syscall"
+
+func init() {
+	signals["SIGUSR1"] = syscall.SIGUSR1
+	signals["SIGUSR2"] = syscall.SIGUSR2
+	signals["SIGWINCH"] = syscall.SIGWINCH
+}
@@ -0,0 +1,27 @@
+package driver
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	cases := map[string]syscall.Signal{
+		"SIGTERM": syscall.SIGTERM,
+		"sigint":  syscall.SIGINT,
+		"HUP":     syscall.SIGHUP,
+	}
+	for name, expected := range cases {
+		sig, err := ParseSignal(name)
+		if err != nil {
+			t.Fatalf("%s: err: %v", name, err)
+		}
+		if sig != expected {
+			t.Fatalf("%s: got %v; want %v", name, sig, expected)
+		}
+	}
+
+	if _, err := ParseSignal("SIGFOO"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
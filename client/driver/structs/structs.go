@@ -0,0 +1,187 @@
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrStatsNotSupported is returned by drivers that can't report the
+	// resource usage of their tasks
+	ErrStatsNotSupported = errors.New("stats are not supported by the driver")
+
+	// ErrExecNotSupported is returned by drivers that can't run commands in
+	// the environment of their tasks
+	ErrExecNotSupported = errors.New("exec is not supported by the driver")
+)
+
+// WaitResult stores the result of waiting on a task to exit
+type WaitResult struct {
+	// ExitCode is the exit code of the task
+	ExitCode int
+
+	// Signal is the signal that terminated the task, if any
+	Signal int
+
+	// Err is set if waiting on the task failed
+	Err error
+}
+
+// NewWaitResult returns a WaitResult with the given exit code, signal and
+// error
+func NewWaitResult(code, signal int, err error) *WaitResult {
+	return &WaitResult{
+		ExitCode: code,
+		Signal:   signal,
+		Err:      err,
+	}
+}
+
+// Successful returns whether the task exited successfully
+func (r *WaitResult) Successful() bool {
+	return r.ExitCode == 0 && r.Signal == 0 && r.Err == nil
+}
+
+func (r *WaitResult) String() string {
+	return fmt.Sprintf("Wait returned exit code %v, signal %v, and error %v",
+		r.ExitCode, r.Signal, r.Err)
+}
+
+// MemoryStats holds the memory usage of a task in bytes
+type MemoryStats struct {
+	RSS      uint64
+	Cache    uint64
+	Swap     uint64
+	MaxUsage uint64
+}
+
+// CpuStats holds the CPU usage of a task. The percentages are relative to a
+// single core and TotalTicks is the usage expressed in MHz, comparable to the
+// CPU resources reserved for the task.
+type CpuStats struct {
+	SystemMode       float64
+	UserMode         float64
+	Percent          float64
+	TotalTicks       float64
+	ThrottledPeriods uint64
+	ThrottledTime    uint64
+}
+
+// ResourceUsage holds the resource usage of a task or allocation
+type ResourceUsage struct {
+	MemoryStats *MemoryStats
+	CpuStats    *CpuStats
+}
+
+// Add adds the resource usage of another task to the usage
+func (r *ResourceUsage) Add(other *ResourceUsage) {
+	if other.MemoryStats != nil {
+		if r.MemoryStats == nil {
+			r.MemoryStats = &MemoryStats{}
+		}
+		r.MemoryStats.RSS += other.MemoryStats.RSS
+		r.MemoryStats.Cache += other.MemoryStats.Cache
+		r.MemoryStats.Swap += other.MemoryStats.Swap
+		r.MemoryStats.MaxUsage += other.MemoryStats.MaxUsage
+	}
+	if other.CpuStats != nil {
+		if r.CpuStats == nil {
+			r.CpuStats = &CpuStats{}
+		}
+		r.CpuStats.SystemMode += other.CpuStats.SystemMode
+		r.CpuStats.UserMode += other.CpuStats.UserMode
+		r.CpuStats.Percent += other.CpuStats.Percent
+		r.CpuStats.TotalTicks += other.CpuStats.TotalTicks
+		r.CpuStats.ThrottledPeriods += other.CpuStats.ThrottledPeriods
+		r.CpuStats.ThrottledTime += other.CpuStats.ThrottledTime
+	}
+}
+
+// TaskResourceUsage holds a sample of the resource usage of a task
+type TaskResourceUsage struct {
+	ResourceUsage *ResourceUsage
+
+	// Timestamp is the time the sample was taken in nanoseconds since the
+	// epoch
+	Timestamp int64
+}
+
+// AllocResourceUsage holds the resource usage of an allocation and of each
+// of its tasks
+type AllocResourceUsage struct {
+	// ResourceUsage is the sum of the usage of the tasks
+	ResourceUsage *ResourceUsage
+
+	// Tasks maps the name of the running tasks to their usage
+	Tasks map[string]*TaskResourceUsage
+
+	// Timestamp is the time of the most recent task sample
+	Timestamp int64
+}
+
+// CpuPercentTracker computes the CPU usage percentages of a task from
+// successive samples of the cumulative CPU time it used.
+type CpuPercentTracker struct {
+	prevTime   time.Time
+	prevTotal  uint64
+	prevUser   uint64
+	prevSystem uint64
+}
+
+// Sample takes the cumulative total, user and system CPU times in nanoseconds
+// and returns the percentages of a core used since the previous sample. The
+// first sample returns zero percentages.
+func (c *CpuPercentTracker) Sample(now time.Time, total, user, system uint64) (totalPct, userPct, systemPct float64) {
+	if !c.prevTime.IsZero() && now.After(c.prevTime) {
+		elapsed := float64(now.Sub(c.prevTime).Nanoseconds())
+		totalPct = percent(c.prevTotal, total, elapsed)
+		userPct = percent(c.prevUser, user, elapsed)
+		systemPct = percent(c.prevSystem, system, elapsed)
+	}
+	c.prevTime = now
+	c.prevTotal = total
+	c.prevUser = user
+	c.prevSystem = system
+	return
+}
+
+// percent returns the percentage of the elapsed time a counter increased by
+func percent(prev, cur uint64, elapsed float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / elapsed * 100
+}
+
+// TerminalSize is the size of a terminal in characters
+type TerminalSize struct {
+	Height uint16
+	Width  uint16
+}
+
+// ExecOptions describes a command to run in the environment of a task and the
+// streams it is attached to.
+type ExecOptions struct {
+	// Command is the command to run followed by its arguments
+	Command []string
+
+	// Env is the environment of the command
+	Env []string
+
+	// Tty sets whether the command is attached to a terminal. Stderr is
+	// unused as the output of the terminal is written to Stdout.
+	Tty bool
+
+	// Stdin is read until it is closed or the command exits. If nil the
+	// command reads from the null device.
+	Stdin io.Reader
+
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ResizeCh receives the size of the terminal when it changes. It must be
+	// closed by the caller once the command exits.
+	ResizeCh <-chan TerminalSize
+}
@@ -0,0 +1,40 @@
+package structs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCpuPercentTracker(t *testing.T) {
+	var c CpuPercentTracker
+	now := time.Now()
+
+	// The first sample has nothing to compare against
+	total, user, system := c.Sample(now, 1000, 600, 400)
+	if total != 0 || user != 0 || system != 0 {
+		t.Fatalf("bad: %v %v %v", total, user, system)
+	}
+
+	// Half a core used over a second
+	now = now.Add(time.Second)
+	total, user, system = c.Sample(now, 1000+5e8, 600+3e8, 400+2e8)
+	if total != 50 || user != 30 || system != 20 {
+		t.Fatalf("bad: %v %v %v", total, user, system)
+	}
+}
+
+func TestResourceUsage_Add(t *testing.T) {
+	usage := &ResourceUsage{}
+	task := &ResourceUsage{
+		MemoryStats: &MemoryStats{RSS: 10, Cache: 2},
+		CpuStats:    &CpuStats{Percent: 12.5, TotalTicks: 100},
+	}
+	usage.Add(task)
+	usage.Add(task)
+	if usage.MemoryStats.RSS != 20 || usage.MemoryStats.Cache != 4 {
+		t.Fatalf("bad: %#v", usage.MemoryStats)
+	}
+	if usage.CpuStats.Percent != 25 || usage.CpuStats.TotalTicks != 200 {
+		t.Fatalf("bad: %#v", usage.CpuStats)
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/shirou/gopsutil/disk"
+)
+
+// AllocGarbageCollector retains the runners of terminal allocations along with
+// their alloc dirs, so their logs and data can be inspected after the servers
+// garbage collected the allocations. The oldest allocations are destroyed first
+// once too many are retained or the filesystem of the alloc dir runs low on
+// disk space or inodes.
+type AllocGarbageCollector struct {
+	// allocs are the retained runners, oldest first
+	allocs []*AllocRunner
+	lock   sync.Mutex
+
+	// usage returns the usage of the filesystem holding the path
+	usage func(path string) (*disk.DiskUsageStat, error)
+
+	config *config.Config
+	logger *log.Logger
+}
+
+// NewAllocGarbageCollector returns a garbage collector of terminal allocations
+func NewAllocGarbageCollector(logger *log.Logger, config *config.Config) *AllocGarbageCollector {
+	return &AllocGarbageCollector{
+		usage:  disk.DiskUsage,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Run periodically destroys the oldest allocations while the usage of the
+// filesystem of the alloc dir is above the thresholds, until the stop channel
+// is closed
+func (g *AllocGarbageCollector) Run(stopCh chan struct{}) {
+	ticker := time.NewTicker(g.config.GCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := g.keepUsageBelowThreshold(); err != nil {
+				g.logger.Printf("[ERR] client: garbage collection of allocations failed: %v", err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// MarkForCollection retains the runner of an allocation until it is garbage
+// collected. The oldest allocations are destroyed if too many are retained.
+func (g *AllocGarbageCollector) MarkForCollection(ar *AllocRunner) {
+	g.lock.Lock()
+	g.allocs = append(g.allocs, ar)
+	g.lock.Unlock()
+
+	for g.NumAllocs() > g.config.GCMaxAllocs {
+		if !g.destroyOldest("maximum number of retained allocations reached") {
+			return
+		}
+	}
+}
+
+// NumAllocs returns the number of retained allocations
+func (g *AllocGarbageCollector) NumAllocs() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return len(g.allocs)
+}
+
+// CollectAll destroys all retained allocations
+func (g *AllocGarbageCollector) CollectAll() {
+	for g.destroyOldest("forced collection") {
+	}
+}
+
+// keepUsageBelowThreshold destroys the oldest allocations until the disk and
+// inode usage of the filesystem of the alloc dir are below the thresholds or no
+// allocations are left
+func (g *AllocGarbageCollector) keepUsageBelowThreshold() error {
+	for {
+		usage, err := g.usage(g.config.AllocDir)
+		if err != nil {
+			return err
+		}
+
+		var reason string
+		switch {
+		case usage.UsedPercent > g.config.GCDiskUsageThreshold:
+			reason = "disk usage of the alloc dir above threshold"
+		case usage.InodesUsedPercent > g.config.GCInodeUsageThreshold:
+			reason = "inode usage of the alloc dir above threshold"
+		default:
+			return nil
+		}
+
+		if !g.destroyOldest(reason) {
+			g.logger.Printf("[WARN] client: %s but no allocations left to garbage collect", reason)
+			return nil
+		}
+	}
+}
+
+// destroyOldest destroys the oldest retained allocation and returns whether
+// there was one
+func (g *AllocGarbageCollector) destroyOldest(reason string) bool {
+	g.lock.Lock()
+	if len(g.allocs) == 0 {
+		g.lock.Unlock()
+		return false
+	}
+	ar := g.allocs[0]
+	g.allocs[0] = nil
+	g.allocs = g.allocs[1:]
+	g.lock.Unlock()
+
+	g.logger.Printf("[INFO] client: garbage collecting alloc '%s': %s", ar.Alloc().ID, reason)
+	ar.Destroy()
+	<-ar.WaitCh()
+	return true
+}
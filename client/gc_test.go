@@ -0,0 +1,91 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/shirou/gopsutil/disk"
+)
+
+// gcAllocRunner returns the runner of a terminal allocation whose Run exited
+func gcAllocRunner() *AllocRunner {
+	_, ar := testAllocRunner()
+	ar.alloc.DesiredStatus = structs.AllocDesiredStatusStop
+	go ar.Run()
+	<-ar.WaitCh()
+	return ar
+}
+
+func TestAllocGarbageCollector_MaxAllocs(t *testing.T) {
+	conf := DefaultConfig()
+	conf.GCMaxAllocs = 1
+	gc := NewAllocGarbageCollector(testLogger(), conf)
+
+	ar1, ar2 := gcAllocRunner(), gcAllocRunner()
+	gc.MarkForCollection(ar1)
+	gc.MarkForCollection(ar2)
+
+	// The oldest allocation is destroyed
+	if n := gc.NumAllocs(); n != 1 {
+		t.Fatalf("expected 1 retained alloc, got: %d", n)
+	}
+	if !ar1.destroy || ar2.destroy {
+		t.Fatalf("bad: %v %v", ar1.destroy, ar2.destroy)
+	}
+}
+
+func TestAllocGarbageCollector_UsageAboveThreshold(t *testing.T) {
+	conf := DefaultConfig()
+	gc := NewAllocGarbageCollector(testLogger(), conf)
+
+	// The disk usage drops below the threshold after the first collection
+	var calls int
+	gc.usage = func(path string) (*disk.DiskUsageStat, error) {
+		calls++
+		if calls == 1 {
+			return &disk.DiskUsageStat{UsedPercent: conf.GCDiskUsageThreshold + 1}, nil
+		}
+		return &disk.DiskUsageStat{}, nil
+	}
+
+	ar1, ar2 := gcAllocRunner(), gcAllocRunner()
+	gc.MarkForCollection(ar1)
+	gc.MarkForCollection(ar2)
+
+	if err := gc.keepUsageBelowThreshold(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := gc.NumAllocs(); n != 1 {
+		t.Fatalf("expected 1 retained alloc, got: %d", n)
+	}
+	if !ar1.destroy || ar2.destroy {
+		t.Fatalf("bad: %v %v", ar1.destroy, ar2.destroy)
+	}
+
+	// Inode usage above the threshold collects the remaining allocation
+	gc.usage = func(path string) (*disk.DiskUsageStat, error) {
+		return &disk.DiskUsageStat{InodesUsedPercent: conf.GCInodeUsageThreshold + 1}, nil
+	}
+	if err := gc.keepUsageBelowThreshold(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := gc.NumAllocs(); n != 0 {
+		t.Fatalf("expected no retained allocs, got: %d", n)
+	}
+}
+
+func TestAllocGarbageCollector_CollectAll(t *testing.T) {
+	gc := NewAllocGarbageCollector(testLogger(), DefaultConfig())
+
+	ar1, ar2 := gcAllocRunner(), gcAllocRunner()
+	gc.MarkForCollection(ar1)
+	gc.MarkForCollection(ar2)
+
+	gc.CollectAll()
+	if n := gc.NumAllocs(); n != 0 {
+		t.Fatalf("expected no retained allocs, got: %d", n)
+	}
+	if !ar1.destroy || !ar2.destroy {
+		t.Fatalf("bad: %v %v", ar1.destroy, ar2.destroy)
+	}
+}
@@ -4,40 +4,74 @@ import (
 	"fmt"
 	"log"
 	"net/url"
-	"path"
 	"path/filepath"
-	"runtime"
 	"strings"
-	"syscall"
 
 	gg "github.com/hashicorp/go-getter"
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/nomad/structs"
 )
 
-func GetArtifact(destDir, source, checksum string, logger *log.Logger) (string, error) {
-	if source == "" {
-		return "", fmt.Errorf("Source url is empty in Artifact Getter")
+// GetArtifact downloads an artifact into its destination within the task
+// directory. Archives are unpacked into the destination and the options of
+// the artifact, such as its checksum, are passed to go-getter.
+func GetArtifact(artifact *structs.TaskArtifact, taskDir string, logger *log.Logger) error {
+	source, err := getterSource(artifact)
+	if err != nil {
+		return err
 	}
-	u, err := url.Parse(source)
+
+	dest, err := artifactDest(artifact, taskDir)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	logger.Printf("[DEBUG] client.getter: downloading artifact %q to %q", source, dest)
+	client := &gg.Client{
+		Src:  source,
+		Dst:  dest,
+		Mode: gg.ClientModeAny,
+	}
+	if err := client.Get(); err != nil {
+		return fmt.Errorf("error downloading artifact %q: %v", artifact.GetterSource, err)
+	}
+	return nil
+}
+
+// getterSource returns the go-getter source of the artifact with its options
+// applied as query parameters.
+func getterSource(artifact *structs.TaskArtifact) (string, error) {
+	if artifact.GetterSource == "" {
+		return "", fmt.Errorf("artifact source is empty")
+	}
+	if len(artifact.GetterOptions) == 0 {
+		return artifact.GetterSource, nil
 	}
 
-	// if checksum is seperate, apply to source
-	if checksum != "" {
-		source = strings.Join([]string{source, fmt.Sprintf("checksum=%s", checksum)}, "?")
-		logger.Printf("[DEBUG] client.getter: Applying checksum to Artifact Source URL, new url: %s", source)
+	u, err := url.Parse(artifact.GetterSource)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse artifact source %q: %v", artifact.GetterSource, err)
+	}
+	q := u.Query()
+	for k, v := range artifact.GetterOptions {
+		q.Set(k, v)
 	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
 
-	artifactFile := filepath.Join(destDir, path.Base(u.Path))
-	if err := gg.GetFile(artifactFile, source); err != nil {
-		return "", fmt.Errorf("Error downloading artifact: %s", err)
+// artifactDest returns the directory the artifact is downloaded into,
+// ensuring it is within the task directory.
+func artifactDest(artifact *structs.TaskArtifact, taskDir string) (string, error) {
+	relative := artifact.RelativeDest
+	if relative == "" {
+		relative = allocdir.TaskLocal
 	}
 
-	// Add execution permissions to the newly downloaded artifact
-	if runtime.GOOS != "windows" {
-		if err := syscall.Chmod(artifactFile, 0755); err != nil {
-			logger.Printf("[ERR] driver.raw_exec: Error making artifact executable: %s", err)
-		}
+	dest := filepath.Join(taskDir, relative)
+	rel, err := filepath.Rel(taskDir, dest)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("artifact destination %q escapes the task directory", artifact.RelativeDest)
 	}
-	return artifactFile, nil
+	return dest, nil
 }
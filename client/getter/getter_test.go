@@ -1,111 +1,114 @@
 package getter
 
 import (
-	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
-	"strings"
+	"path/filepath"
 	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
 )
 
-func TestGetArtifact_basic(t *testing.T) {
+func testLogger() *log.Logger {
+	return log.New(os.Stderr, "", log.LstdFlags)
+}
 
-	logger := log.New(os.Stderr, "", log.LstdFlags)
+// testServer serves the test fixtures over HTTP
+func testServer(t *testing.T) *httptest.Server {
+	dir, err := filepath.Abs("./test-fixtures")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return httptest.NewServer(http.FileServer(http.Dir(dir)))
+}
 
-	// TODO: Use http.TestServer to serve these files from fixtures dir
-	passing := []struct {
-		Source, Checksum string
-	}{
-		{
-			"https://dl.dropboxusercontent.com/u/47675/jar_thing/hi_darwin_amd64",
-			"sha256:66aa0f05fc0cfcf1e5ed8cc5307b5df51e33871d6b295a60e0f9f6dd573da977",
-		},
-		{
-			"https://dl.dropboxusercontent.com/u/47675/jar_thing/hi_linux_amd64",
-			"sha256:6f99b4c5184726e601ecb062500aeb9537862434dfe1898dbe5c68d9f50c179c",
-		},
-		{
-			"https://dl.dropboxusercontent.com/u/47675/jar_thing/hi_linux_amd64",
-			"md5:a9b14903a8942748e4f8474e11f795d3",
-		},
-		{
-			"https://dl.dropboxusercontent.com/u/47675/jar_thing/hi_linux_amd64?checksum=sha256:6f99b4c5184726e601ecb062500aeb9537862434dfe1898dbe5c68d9f50c179c",
-			"",
-		},
-		{
-			"https://dl.dropboxusercontent.com/u/47675/jar_thing/hi_linux_amd64",
-			"",
-		},
+func TestGetArtifact_File(t *testing.T) {
+	ts := testServer(t)
+	defer ts.Close()
+
+	taskDir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
+	defer os.RemoveAll(taskDir)
 
-	for i, p := range passing {
-		destDir, err := ioutil.TempDir("", fmt.Sprintf("nomad-test-%d", i))
-		if err != nil {
-			t.Fatalf("Error in TestGetArtifact_basic makeing TempDir: %s", err)
-		}
+	// The artifact is downloaded into the local directory by default
+	artifact := &structs.TaskArtifact{
+		GetterSource: ts.URL + "/test.sh",
+		GetterOptions: map[string]string{
+			"checksum": "sha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		},
+	}
+	if err := GetArtifact(artifact, taskDir, testLogger()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(taskDir, "local", "test.sh")); err != nil {
+		t.Fatalf("artifact not downloaded: %v", err)
+	}
+}
 
-		path, err := GetArtifact(destDir, p.Source, p.Checksum, logger)
-		if err != nil {
-			t.Fatalf("TestGetArtifact_basic unexpected failure here: %s", err)
-		}
+func TestGetArtifact_Archive(t *testing.T) {
+	ts := testServer(t)
+	defer ts.Close()
 
-		if p.Checksum != "" {
-			if ok := strings.Contains(path, p.Checksum); ok {
-				t.Fatalf("path result should not contain the checksum, got: %s", path)
-			}
-		}
+	taskDir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(taskDir)
 
-		// verify artifact exists
-		if _, err := os.Stat(path); err != nil {
-			t.Fatalf("source path error: %s", err)
-		}
+	// The archive is unpacked into the destination
+	artifact := &structs.TaskArtifact{
+		GetterSource: ts.URL + "/archive.tar.gz",
+		RelativeDest: "local/archive",
+	}
+	if err := GetArtifact(artifact, taskDir, testLogger()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(taskDir, "local", "archive", "exist.txt")); err != nil {
+		t.Fatalf("archive not unpacked: %v", err)
 	}
 }
 
-func TestGetArtifact_fails(t *testing.T) {
+func TestGetArtifact_Fails(t *testing.T) {
+	ts := testServer(t)
+	defer ts.Close()
 
-	logger := log.New(os.Stderr, "", log.LstdFlags)
+	taskDir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(taskDir)
 
-	failing := []struct {
-		Source, Checksum string
-	}{
-		{
-			"",
-			"sha256:66aa0f05fc0cfcf1e5ed8cc5307b5d",
-		},
-		{
-			"/u/47675/jar_thing/hi_darwin_amd64",
-			"sha256:66aa0f05fc0cfcf1e5ed8cc5307b5d",
-		},
-		{
-			"https://dl.dropboxusercontent.com/u/47675/jar_thing/hi_darwin_amd64",
-			"sha256:66aa0f05fc0cfcf1e5ed8cc5307b5d",
-		},
-		{
-			"https://dl.dropboxusercontent.com/u/47675/jar_thing/hi_linux_amd64",
-			"sha257:6f99b4c5184726e601ecb062500aeb9537862434dfe1898dbe5c68d9f50c179c",
-		},
-		// malformed checksum
-		{
-			"https://dl.dropboxusercontent.com/u/47675/jar_thing/hi_linux_amd64",
-			"6f99b4c5184726e601ecb062500aeb9537862434dfe1898dbe5c68d9f50c179c",
+	failing := []*structs.TaskArtifact{
+		// Missing source
+		&structs.TaskArtifact{},
+
+		// Bad checksum
+		&structs.TaskArtifact{
+			GetterSource: ts.URL + "/test.sh",
+			GetterOptions: map[string]string{
+				"checksum": "sha256:6f99b4c5184726e601ecb062500aeb9537862434dfe1898dbe5c68d9f50c179c",
+			},
 		},
+
 		// 404
-		{
-			"https://dl.dropboxusercontent.com/u/47675/jar_thing/hi_linux_amd86",
-			"",
+		&structs.TaskArtifact{
+			GetterSource: ts.URL + "/missing.sh",
 		},
-	}
-	for i, p := range failing {
-		destDir, err := ioutil.TempDir("", fmt.Sprintf("nomad-test-%d", i))
-		if err != nil {
-			t.Fatalf("Error in TestGetArtifact_basic makeing TempDir: %s", err)
-		}
 
-		_, err = GetArtifact(destDir, p.Source, p.Checksum, logger)
-		if err == nil {
-			t.Fatalf("TestGetArtifact_basic expected failure, but got none")
+		// Destination escapes the task directory
+		&structs.TaskArtifact{
+			GetterSource: ts.URL + "/test.sh",
+			RelativeDest: "../../foo",
+		},
+	}
+	for i, artifact := range failing {
+		if err := GetArtifact(artifact, taskDir, testLogger()); err == nil {
+			t.Fatalf("case %d: expected error", i)
 		}
 	}
 }
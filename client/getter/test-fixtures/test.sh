@@ -0,0 +1 @@
+hello
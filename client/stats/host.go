@@ -0,0 +1,187 @@
+package stats
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/host"
+	"github.com/shirou/gopsutil/mem"
+)
+
+// HostStats holds a sample of the resource usage of the host
+type HostStats struct {
+	Memory    *MemoryStats
+	CPU       []*CPUStats
+	DiskStats []*DiskStats
+
+	// Uptime is the number of seconds since the host booted
+	Uptime uint64
+
+	// Timestamp is the time the sample was taken in nanoseconds since the
+	// epoch
+	Timestamp int64
+}
+
+// MemoryStats holds the memory usage of the host in bytes
+type MemoryStats struct {
+	Total     uint64
+	Available uint64
+	Used      uint64
+	Free      uint64
+}
+
+// CPUStats holds the usage of a core of the host as percentages of its time
+// since the previous sample
+type CPUStats struct {
+	CPU    string
+	User   float64
+	System float64
+	Idle   float64
+	Total  float64
+}
+
+// DiskStats holds the usage of a mount of the alloc dir
+type DiskStats struct {
+	Device            string
+	Mountpoint        string
+	Size              uint64
+	Used              uint64
+	Available         uint64
+	UsedPercent       float64
+	InodesUsedPercent float64
+}
+
+// HostStatsCollector samples the resource usage of the host
+type HostStatsCollector struct {
+	allocDir string
+
+	// prevCPU are the cumulative times of each core at the previous sample
+	prevCPU map[string]cpu.CPUTimesStat
+}
+
+// NewHostStatsCollector returns a collector of the resource usage of the host
+// whose disk usage is measured for the mounts of the alloc dir
+func NewHostStatsCollector(allocDir string) *HostStatsCollector {
+	return &HostStatsCollector{
+		allocDir: allocDir,
+		prevCPU:  make(map[string]cpu.CPUTimesStat),
+	}
+}
+
+// Collect samples the resource usage of the host. The CPU usage is measured
+// since the previous sample, so the first sample reports idle cores.
+func (h *HostStatsCollector) Collect() (*HostStats, error) {
+	hs := &HostStats{Timestamp: time.Now().UTC().UnixNano()}
+
+	memStats, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect memory stats: %v", err)
+	}
+	hs.Memory = &MemoryStats{
+		Total:     memStats.Total,
+		Available: memStats.Available,
+		Used:      memStats.Used,
+		Free:      memStats.Free,
+	}
+
+	times, err := cpu.CPUTimes(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect cpu stats: %v", err)
+	}
+	for _, t := range times {
+		hs.CPU = append(hs.CPU, h.cpuStats(t))
+	}
+
+	if hs.DiskStats, err = h.diskStats(); err != nil {
+		return nil, fmt.Errorf("failed to collect disk stats: %v", err)
+	}
+
+	info, err := host.HostInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect uptime: %v", err)
+	}
+	hs.Uptime = info.Uptime
+	return hs, nil
+}
+
+// cpuStats returns the usage of a core since the previous sample
+func (h *HostStatsCollector) cpuStats(t cpu.CPUTimesStat) *CPUStats {
+	cs := &CPUStats{CPU: t.CPU, Idle: 100}
+	prev, ok := h.prevCPU[t.CPU]
+	h.prevCPU[t.CPU] = t
+	if !ok {
+		return cs
+	}
+
+	elapsed := cpuTotal(t) - cpuTotal(prev)
+	if elapsed <= 0 {
+		return cs
+	}
+	cs.User = (t.User - prev.User) / elapsed * 100
+	cs.System = (t.System - prev.System) / elapsed * 100
+	cs.Idle = (t.Idle - prev.Idle) / elapsed * 100
+	cs.Total = 100 - cs.Idle
+	return cs
+}
+
+// cpuTotal returns the total time accounted to a core
+func cpuTotal(t cpu.CPUTimesStat) float64 {
+	return t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq +
+		t.Softirq + t.Steal + t.Guest + t.GuestNice + t.Stolen
+}
+
+// diskStats returns the usage of the mount holding the alloc dir and of the
+// mounts below it
+func (h *HostStatsCollector) diskStats() ([]*DiskStats, error) {
+	partitions, err := disk.DiskPartitions(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var holding *disk.DiskPartitionStat
+	var mounts []disk.DiskPartitionStat
+	for i, p := range partitions {
+		switch {
+		case withinDir(h.allocDir, p.Mountpoint):
+			mounts = append(mounts, p)
+		case withinDir(p.Mountpoint, h.allocDir):
+			if holding == nil || len(p.Mountpoint) > len(holding.Mountpoint) {
+				holding = &partitions[i]
+			}
+		}
+	}
+	if holding != nil {
+		mounts = append([]disk.DiskPartitionStat{*holding}, mounts...)
+	}
+
+	stats := make([]*DiskStats, 0, len(mounts))
+	for _, p := range mounts {
+		usage, err := disk.DiskUsage(p.Mountpoint)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, &DiskStats{
+			Device:            p.Device,
+			Mountpoint:        p.Mountpoint,
+			Size:              usage.Total,
+			Used:              usage.Used,
+			Available:         usage.Free,
+			UsedPercent:       usage.UsedPercent,
+			InodesUsedPercent: usage.InodesUsedPercent,
+		})
+	}
+	return stats, nil
+}
+
+// withinDir returns whether the path is the directory or below it
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"os"
+	"testing"
+)
+
+func TestHostStatsCollector_Collect(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	h := NewHostStatsCollector(dir)
+
+	// Collect twice so the CPU usage is measured
+	if _, err := h.Collect(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	hs, err := h.Collect()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if hs.Memory == nil || hs.Memory.Total == 0 {
+		t.Fatalf("bad memory stats: %#v", hs.Memory)
+	}
+	if len(hs.CPU) == 0 {
+		t.Fatalf("expected cpu stats")
+	}
+	for _, cpu := range hs.CPU {
+		if cpu.Total < 0 || cpu.Total > 100 {
+			t.Fatalf("bad cpu stats: %#v", cpu)
+		}
+	}
+	if hs.Uptime == 0 || hs.Timestamp == 0 {
+		t.Fatalf("bad: %#v", hs)
+	}
+}
+
+func TestWithinDir(t *testing.T) {
+	cases := []struct {
+		dir, path string
+		exp       bool
+	}{
+		{"/", "/var/lib/nomad", true},
+		{"/var/lib/nomad", "/var/lib/nomad", true},
+		{"/var/lib/nomad", "/var/lib/nomad/alloc", true},
+		{"/var/lib/nomad", "/var/lib", false},
+		{"/var/lib/nomad", "/var/lib/nomad2", false},
+	}
+	for _, c := range cases {
+		if act := withinDir(c.dir, c.path); act != c.exp {
+			t.Fatalf("withinDir(%q, %q): got %v; want %v", c.dir, c.path, act, c.exp)
+		}
+	}
+}
@@ -4,14 +4,19 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sync"
 	"time"
 
+	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
+	"github.com/hashicorp/nomad/client/getter"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -21,44 +26,68 @@ type TaskRunner struct {
 	updater        TaskStateUpdater
 	logger         *log.Logger
 	ctx            *driver.ExecContext
+	alloc          *structs.Allocation
 	allocID        string
 	restartTracker restartTracker
+	consulService  *ConsulService
 
 	task     *structs.Task
 	updateCh chan *structs.Task
-	handle   driver.DriverHandle
+
+	// handle is only replaced by the Run routine, handleLock guards reading
+	// it from other routines
+	handle     driver.DriverHandle
+	handleLock sync.RWMutex
+
+	// artifactsDownloaded tracks whether the task's artifacts have been
+	// downloaded so that they are only fetched once
+	artifactsDownloaded bool
+
+	// startedCh is closed once the task has been started
+	startedCh   chan struct{}
+	startedOnce sync.Once
 
 	destroy     bool
 	destroyCh   chan struct{}
 	destroyLock sync.Mutex
 	waitCh      chan struct{}
 
+	// resourceUsage is the latest resource usage sample of the running task
+	resourceUsage     *cstructs.TaskResourceUsage
+	resourceUsageLock sync.RWMutex
+
 	snapshotLock sync.Mutex
 }
 
 // taskRunnerState is used to snapshot the state of the task runner
 type taskRunnerState struct {
-	Task     *structs.Task
-	HandleID string
+	Task                *structs.Task
+	HandleID            string
+	ArtifactsDownloaded bool
 }
 
-// TaskStateUpdater is used to update the status of a task
-type TaskStateUpdater func(taskName, status, desc string)
+// TaskStateUpdater is used to update the state of a task and append the event
+// that caused the transition
+type TaskStateUpdater func(taskName, state string, event *structs.TaskEvent)
 
 // NewTaskRunner is used to create a new task context
 func NewTaskRunner(logger *log.Logger, config *config.Config,
 	updater TaskStateUpdater, ctx *driver.ExecContext,
-	allocID string, task *structs.Task, restartTracker restartTracker) *TaskRunner {
+	alloc *structs.Allocation, task *structs.Task, restartTracker restartTracker,
+	consulService *ConsulService) *TaskRunner {
 
 	tc := &TaskRunner{
 		config:         config,
 		updater:        updater,
 		logger:         logger,
 		restartTracker: restartTracker,
+		consulService:  consulService,
 		ctx:            ctx,
-		allocID:        allocID,
+		alloc:          alloc,
+		allocID:        alloc.ID,
 		task:           task,
 		updateCh:       make(chan *structs.Task, 8),
+		startedCh:      make(chan struct{}),
 		destroyCh:      make(chan struct{}),
 		waitCh:         make(chan struct{}),
 	}
@@ -70,6 +99,84 @@ func (r *TaskRunner) WaitCh() <-chan struct{} {
 	return r.waitCh
 }
 
+// StartedCh returns a channel that is closed once the task has been started
+func (r *TaskRunner) StartedCh() <-chan struct{} {
+	return r.startedCh
+}
+
+// markStarted notifies the waiters on the StartedCh that the task started
+func (r *TaskRunner) markStarted() {
+	r.startedOnce.Do(func() {
+		close(r.startedCh)
+	})
+}
+
+// LatestResourceUsage returns the latest resource usage sample of the task or
+// nil if the task isn't running or its driver can't measure it
+func (r *TaskRunner) LatestResourceUsage() *cstructs.TaskResourceUsage {
+	r.resourceUsageLock.RLock()
+	defer r.resourceUsageLock.RUnlock()
+	return r.resourceUsage
+}
+
+// setResourceUsage stores the latest resource usage sample of the task
+func (r *TaskRunner) setResourceUsage(usage *cstructs.TaskResourceUsage) {
+	r.resourceUsageLock.Lock()
+	defer r.resourceUsageLock.Unlock()
+	r.resourceUsage = usage
+}
+
+// collectResourceUsage samples the resource usage of the running task. It
+// returns false if the driver can't measure it.
+func (r *TaskRunner) collectResourceUsage() bool {
+	usage, err := r.handle.Stats()
+	if err == cstructs.ErrStatsNotSupported {
+		return false
+	}
+	if err != nil {
+		r.logger.Printf("[DEBUG] client: failed to collect stats of task '%s' for alloc '%s': %v",
+			r.task.Name, r.allocID, err)
+		return true
+	}
+
+	// Express the CPU usage in MHz so it compares to the reserved CPU. The
+	// sample is copied as drivers may return the same sample repeatedly.
+	if cpu := usage.ResourceUsage.CpuStats; cpu != nil && r.config.Node != nil && r.config.Node.Resources != nil {
+		mhzPerCore := float64(r.config.Node.Resources.CPU) / float64(runtime.NumCPU())
+		cpuCopy := *cpu
+		cpuCopy.TotalTicks = cpu.Percent / 100 * mhzPerCore
+		usage = &cstructs.TaskResourceUsage{
+			ResourceUsage: &cstructs.ResourceUsage{
+				MemoryStats: usage.ResourceUsage.MemoryStats,
+				CpuStats:    &cpuCopy,
+			},
+			Timestamp: usage.Timestamp,
+		}
+	}
+	r.setResourceUsage(usage)
+	return true
+}
+
+// Exec runs a command in the environment of the running task and returns its
+// exit code once it exits
+func (r *TaskRunner) Exec(opts *cstructs.ExecOptions) (int, error) {
+	r.handleLock.RLock()
+	handle := r.handle
+	r.handleLock.RUnlock()
+	if handle == nil {
+		return 0, fmt.Errorf("task %q is not running", r.task.Name)
+	}
+
+	opts.Env = driver.TaskEnvironmentVariables(r.ctx, r.task).List()
+	return handle.Exec(opts)
+}
+
+// restoreTerminal marks the task runner of a task that already completed as
+// terminated without running it
+func (r *TaskRunner) restoreTerminal() {
+	close(r.waitCh)
+}
+
 // stateFilePath returns the path to our state file
 func (r *TaskRunner) stateFilePath() string {
 	// Get the MD5 of the task name
@@ -93,6 +200,7 @@ func (r *TaskRunner) RestoreState() error {
 
 	// Restore fields
 	r.task = snap.Task
+	r.artifactsDownloaded = snap.ArtifactsDownloaded
 
 	// Restore the driver
 	if snap.HandleID != "" {
@@ -119,7 +227,8 @@ func (r *TaskRunner) SaveState() error {
 	r.snapshotLock.Lock()
 	defer r.snapshotLock.Unlock()
 	snap := taskRunnerState{
-		Task: r.task,
+		Task:                r.task,
+		ArtifactsDownloaded: r.artifactsDownloaded,
 	}
 	if r.handle != nil {
 		snap.HandleID = r.handle.ID()
@@ -132,12 +241,12 @@ func (r *TaskRunner) DestroyState() error {
 	return os.RemoveAll(r.stateFilePath())
 }
 
-// setStatus is used to update the status of the task runner
-func (r *TaskRunner) setStatus(status, desc string) {
+// setState is used to update the state of the task runner
+func (r *TaskRunner) setState(state string, event *structs.TaskEvent) {
 	if err := r.SaveState(); err != nil {
 		r.logger.Printf("[ERR] client: failed to save state of Task Runner: %v", r.task.Name)
 	}
-	r.updater(r.task.Name, status, desc)
+	r.updater(r.task.Name, state, event)
 }
 
 // createDriver makes a driver for the task
@@ -152,12 +261,133 @@ func (r *TaskRunner) createDriver() (driver.Driver, error) {
 	return driver, err
 }
 
+// writePayload writes the payload of a dispatched job into the task's local
+// directory if the task requested it.
+func (r *TaskRunner) writePayload() error {
+	if r.task.DispatchPayload == nil || r.task.DispatchPayload.File == "" {
+		return nil
+	}
+
+	taskDir, ok := r.ctx.AllocDir.TaskDirs[r.task.Name]
+	if !ok {
+		return fmt.Errorf("task directory doesn't exist for task %v", r.task.Name)
+	}
+
+	var payload []byte
+	if r.alloc.Job != nil {
+		payload = r.alloc.Job.Payload
+	}
+
+	dst := filepath.Join(taskDir, allocdir.TaskLocal, r.task.DispatchPayload.File)
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, payload, 0666)
+}
+
+// updateTemplates renders the task's templates into the task directory and
+// returns the templates whose content changed.
+func (r *TaskRunner) updateTemplates() ([]*structs.Template, error) {
+	if len(r.task.Templates) == 0 {
+		return nil, nil
+	}
+
+	taskDir, ok := r.ctx.AllocDir.TaskDirs[r.task.Name]
+	if !ok {
+		return nil, fmt.Errorf("task directory doesn't exist for task %v", r.task.Name)
+	}
+	env := driver.TaskEnvironmentVariables(r.ctx, r.task).Map()
+	return renderTemplates(r.task.Templates, taskDir, env)
+}
+
+// downloadArtifacts downloads the task's artifacts into the task directory.
+// Failed downloads are retried according to the restart policy. An error is
+// returned if the artifacts can't be downloaded or the task is destroyed.
+func (r *TaskRunner) downloadArtifacts() error {
+	if r.artifactsDownloaded || len(r.task.Artifacts) == 0 {
+		return nil
+	}
+
+	taskDir, ok := r.ctx.AllocDir.TaskDirs[r.task.Name]
+	if !ok {
+		err := fmt.Errorf("task directory doesn't exist for task %v", r.task.Name)
+		r.setState(structs.TaskStateDead,
+			structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask())
+		return err
+	}
+
+	for {
+		r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskDownloadingArtifacts))
+		var err error
+		for _, artifact := range r.task.Artifacts {
+			if err = getter.GetArtifact(artifact, taskDir, r.logger); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			r.artifactsDownloaded = true
+			return nil
+		}
+
+		r.logger.Printf("[ERR] client: failed to download artifacts of task '%s' for alloc '%s': %v",
+			r.task.Name, r.allocID, err)
+		r.setState(structs.TaskStatePending,
+			structs.NewTaskEvent(structs.TaskArtifactDownloadFailed).SetDownloadError(err))
+		if !r.waitRestart() {
+			return err
+		}
+	}
+}
+
+// waitRestart consults the restart policy after the task failed and blocks
+// until the task should be restarted. It returns false if the task shouldn't
+// be restarted because it exhausted its restarts or was destroyed.
+func (r *TaskRunner) waitRestart() bool {
+	shouldRestart, when := r.restartTracker.nextRestart()
+	if !shouldRestart {
+		r.logger.Printf("[INFO] client: Not restarting task: %v for alloc: %v ", r.task.Name, r.allocID)
+		r.setState(structs.TaskStateDead, structs.NewTaskEvent(structs.TaskNotRestarting).SetFailsTask())
+		return false
+	}
+
+	r.logger.Printf("[INFO] client: Restarting Task: %v", r.task.Name)
+	r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskRestarting).SetRestartDelay(when))
+	r.logger.Printf("[DEBUG] client: Sleeping for %v before restarting Task %v", when, r.task.Name)
+	select {
+	case <-time.After(when):
+		return true
+	case <-r.destroyCh:
+		r.logger.Printf("[DEBUG] client: Not restarting task: %v because it's destroyed by user", r.task.Name)
+		r.setState(structs.TaskStateDead, structs.NewTaskEvent(structs.TaskKilled))
+		return false
+	}
+}
+
 // startTask is used to start the task if there is no handle
 func (r *TaskRunner) startTask() error {
 	// Create a driver
 	driver, err := r.createDriver()
 	if err != nil {
-		r.setStatus(structs.AllocClientStatusFailed, err.Error())
+		r.setState(structs.TaskStatePending,
+			structs.NewTaskEvent(structs.TaskDriverFailure).SetDriverError(err))
+		return err
+	}
+
+	// Write the dispatch payload before the task can read it
+	if err := r.writePayload(); err != nil {
+		r.logger.Printf("[ERR] client: failed to write dispatch payload of task '%s' for alloc '%s': %v",
+			r.task.Name, r.allocID, err)
+		r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskSetupFailure).
+			SetSetupError(fmt.Errorf("failed to write dispatch payload: %v", err)))
+		return err
+	}
+
+	// Render the templates before the task can read them
+	if _, err := r.updateTemplates(); err != nil {
+		r.logger.Printf("[ERR] client: failed to render templates of task '%s' for alloc '%s': %v",
+			r.task.Name, r.allocID, err)
+		r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskSetupFailure).
+			SetSetupError(fmt.Errorf("failed to render templates: %v", err)))
 		return err
 	}
 
@@ -166,77 +396,141 @@ func (r *TaskRunner) startTask() error {
 	if err != nil {
 		r.logger.Printf("[ERR] client: failed to start task '%s' for alloc '%s': %v",
 			r.task.Name, r.allocID, err)
-		r.setStatus(structs.AllocClientStatusFailed,
-			fmt.Sprintf("failed to start: %v", err))
+		r.setState(structs.TaskStatePending,
+			structs.NewTaskEvent(structs.TaskDriverFailure).SetDriverError(err))
 		return err
 	}
+	r.handleLock.Lock()
 	r.handle = handle
-	r.setStatus(structs.AllocClientStatusRunning, "task started")
+	r.handleLock.Unlock()
+	r.setState(structs.TaskStateRunning, structs.NewTaskEvent(structs.TaskStarted))
+	r.markStarted()
 	return nil
 }
 
 // Run is a long running routine used to manage the task
 func (r *TaskRunner) Run() {
-	var err error
 	defer close(r.waitCh)
 	r.logger.Printf("[DEBUG] client: starting task context for '%s' (alloc '%s')",
 		r.task.Name, r.allocID)
 
 	// Start the task if not yet started
 	if r.handle == nil {
+		if err := r.downloadArtifacts(); err != nil {
+			return
+		}
 		if err := r.startTask(); err != nil {
+			r.setState(structs.TaskStateDead, structs.NewTaskEvent(structs.TaskNotRestarting).SetFailsTask())
 			return
 		}
 	}
 
 	// Monitoring the Driver
+	r.markStarted()
 	defer r.DestroyState()
-	err = r.monitorDriver(r.handle.WaitCh(), r.updateCh, r.destroyCh)
-	for err != nil {
-		r.logger.Printf("[ERR] client: failed to complete task '%s' for alloc '%s': %v",
-			r.task.Name, r.allocID, err)
-		shouldRestart, when := r.restartTracker.nextRestart()
-		if !shouldRestart {
-			r.logger.Printf("[INFO] client: Not restarting task: %v for alloc: %v ", r.task.Name, r.allocID)
-			r.setStatus(structs.AllocClientStatusDead, fmt.Sprintf("task failed with: %v", err))
+	for {
+		r.registerServices()
+		res, restart, killErr := r.monitorDriver(r.handle.WaitCh(), r.updateCh, r.destroyCh)
+		r.deregisterServices()
+
+		r.destroyLock.Lock()
+		destroyed := r.destroy
+		r.destroyLock.Unlock()
+		if destroyed {
+			r.logger.Printf("[INFO] client: killed task '%s' for alloc '%s'", r.task.Name, r.allocID)
+			r.setState(structs.TaskStateDead, structs.NewTaskEvent(structs.TaskKilled).SetKillError(killErr))
 			return
 		}
 
-		r.logger.Printf("[INFO] client: Restarting Task: %v", r.task.Name)
-		r.setStatus(structs.AllocClientStatusPending, "Task Restarting")
-		r.logger.Printf("[DEBUG] client: Sleeping for %v before restarting Task %v", when, r.task.Name)
-		select {
-		case <-time.After(when):
-		case <-r.destroyCh:
-		}
-		r.destroyLock.Lock()
-		if r.destroy {
-			r.logger.Printf("[DEBUG] client: Not restarting task: %v because it's destroyed by user", r.task.Name)
-			break
+		if restart {
+			// Restarts caused by template changes don't count against the
+			// restart policy
+			r.logger.Printf("[INFO] client: Restarting Task: %v after its templates changed", r.task.Name)
+			r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskRestarting))
+		} else {
+			event := structs.NewTaskEvent(structs.TaskTerminated).SetExitCode(res.ExitCode).
+				SetSignal(res.Signal).SetExitMessage(res.Err)
+			if res.Successful() {
+				r.logger.Printf("[INFO] client: completed task '%s' for alloc '%s'", r.task.Name, r.allocID)
+				r.setState(structs.TaskStateDead, event)
+				return
+			}
+
+			r.logger.Printf("[ERR] client: failed to complete task '%s' for alloc '%s': %v",
+				r.task.Name, r.allocID, res)
+			r.setState(structs.TaskStatePending, event)
+			if !r.waitRestart() {
+				return
+			}
 		}
-		if err = r.startTask(); err != nil {
+
+		// Start the task again, retrying according to the restart policy
+		for {
+			r.destroyLock.Lock()
+			if r.destroy {
+				r.destroyLock.Unlock()
+				r.logger.Printf("[DEBUG] client: Not restarting task: %v because it's destroyed by user", r.task.Name)
+				r.setState(structs.TaskStateDead, structs.NewTaskEvent(structs.TaskKilled))
+				return
+			}
+			err := r.startTask()
 			r.destroyLock.Unlock()
-			continue
+			if err == nil {
+				break
+			}
+			if !r.waitRestart() {
+				return
+			}
 		}
-		r.destroyLock.Unlock()
-		err = r.monitorDriver(r.handle.WaitCh(), r.updateCh, r.destroyCh)
 	}
+}
 
-	// Cleanup after ourselves
-	r.logger.Printf("[INFO] client: completed task '%s' for alloc '%s'", r.task.Name, r.allocID)
-	r.setStatus(structs.AllocClientStatusDead, "task completed")
+// registerServices registers the services of the running task with Consul
+func (r *TaskRunner) registerServices() {
+	if r.consulService == nil {
+		return
+	}
+	if err := r.consulService.Register(r.task, r.allocID); err != nil {
+		r.logger.Printf("[ERR] client: failed to register services of task '%s' for alloc '%s': %v",
+			r.task.Name, r.allocID, err)
+	}
+}
+
+// deregisterServices removes the services of the task from Consul
+func (r *TaskRunner) deregisterServices() {
+	if r.consulService == nil {
+		return
+	}
+	if err := r.consulService.Deregister(r.task, r.allocID); err != nil {
+		r.logger.Printf("[ERR] client: failed to deregister services of task '%s' for alloc '%s': %v",
+			r.task.Name, r.allocID, err)
+	}
 }
 
 // This functions listens to messages from the driver and blocks until the
-// driver exits
-func (r *TaskRunner) monitorDriver(waitCh chan error, updateCh chan *structs.Task, destroyCh chan struct{}) error {
-	var err error
+// driver exits. It returns the result of the task, whether it was killed to
+// restart it with its re-rendered templates and the error killing it, if any.
+func (r *TaskRunner) monitorDriver(waitCh chan *cstructs.WaitResult, updateCh chan *structs.Task,
+	destroyCh chan struct{}) (res *cstructs.WaitResult, restart bool, killErr error) {
+	var destroyed bool
+	var killTimeout <-chan time.Time
+	destroyNotify := destroyCh
+
+	// Sample the resource usage of the task while it runs
+	statsTicker := time.NewTicker(r.config.StatsCollectionInterval)
+	defer statsTicker.Stop()
+	defer r.setResourceUsage(nil)
+	statsCh := statsTicker.C
 OUTER:
 	// Wait for updates
 	for {
 		select {
-		case err = <-waitCh:
+		case res = <-waitCh:
 			break OUTER
+		case <-statsCh:
+			if !r.collectResourceUsage() {
+				statsCh = nil
+			}
 		case update := <-updateCh:
 			// Update
 			r.task = update
@@ -244,16 +538,110 @@ OUTER:
 				r.logger.Printf("[ERR] client: failed to update task '%s' for alloc '%s': %v",
 					r.task.Name, r.allocID, err)
 			}
+			r.registerServices()
 
-		case <-destroyCh:
-			// Send the kill signal, and use the WaitCh to block until complete
-			if err := r.handle.Kill(); err != nil {
+			// Re-render the templates and notify the task of their changes
+			if restart || destroyed {
+				continue
+			}
+			if r.handleTemplateChanges() {
+				restart = true
+				killTimeout, killErr = r.shutdownTask()
+			}
+
+		case <-destroyNotify:
+			// Ask the task to exit and use the WaitCh to block until complete
+			destroyed = true
+			destroyNotify = nil
+			if killTimeout == nil {
+				killTimeout, killErr = r.shutdownTask()
+			}
+
+		case <-killTimeout:
+			// The task didn't exit in time so kill it
+			killTimeout = nil
+			r.logger.Printf("[INFO] client: task '%s' for alloc '%s' didn't exit within its kill timeout, killing it",
+				r.task.Name, r.allocID)
+			if killErr = r.handle.Kill(); killErr != nil {
 				r.logger.Printf("[ERR] client: failed to kill task '%s' for alloc '%s': %v",
-					r.task.Name, r.allocID, err)
+					r.task.Name, r.allocID, killErr)
 			}
 		}
 	}
-	return err
+	return res, restart, killErr
+}
+
+// shutdownTask sends the task its kill signal and returns a channel that
+// fires once the task's kill timeout elapsed. The task is killed right away if
+// the signal can't be sent and the error killing it is returned.
+func (r *TaskRunner) shutdownTask() (<-chan time.Time, error) {
+	name := r.task.KillSignal
+	if name == "" {
+		name = structs.DefaultKillSignal
+	}
+	timeout := r.task.KillTimeout
+	if timeout == 0 {
+		timeout = structs.DefaultKillTimeout
+	}
+
+	sig, err := driver.ParseSignal(name)
+	if err == nil {
+		err = r.handle.Signal(sig)
+	}
+	if err != nil {
+		r.logger.Printf("[WARN] client: failed to send kill signal to task '%s' for alloc '%s', killing it: %v",
+			r.task.Name, r.allocID, err)
+		if err := r.handle.Kill(); err != nil {
+			r.logger.Printf("[ERR] client: failed to kill task '%s' for alloc '%s': %v",
+				r.task.Name, r.allocID, err)
+			return nil, err
+		}
+		return nil, nil
+	}
+	return time.After(timeout), nil
+}
+
+// handleTemplateChanges re-renders the task's templates, signals the task for
+// the changed templates with the signal change mode and returns whether a
+// changed template requires the task to be restarted. The task is restarted
+// if it can't be signaled.
+func (r *TaskRunner) handleTemplateChanges() bool {
+	changed, err := r.updateTemplates()
+	if err != nil {
+		r.logger.Printf("[ERR] client: failed to render templates of task '%s' for alloc '%s': %v",
+			r.task.Name, r.allocID, err)
+		return false
+	}
+
+	var signals []string
+	for _, tmpl := range changed {
+		switch tmpl.ChangeMode {
+		case structs.TemplateChangeModeRestart:
+			return true
+		case structs.TemplateChangeModeSignal:
+			signals = append(signals, tmpl.ChangeSignal)
+		}
+	}
+
+	// Send each signal once
+	sent := make(map[string]struct{}, len(signals))
+	for _, name := range signals {
+		if _, ok := sent[name]; ok {
+			continue
+		}
+		sent[name] = struct{}{}
+
+		sig, err := driver.ParseSignal(name)
+		if err == nil {
+			err = r.handle.Signal(sig)
+		}
+		if err != nil {
+			r.logger.Printf("[ERR] client: failed to signal task '%s' for alloc '%s' after its templates changed, restarting it: %v",
+				r.task.Name, r.allocID, err)
+			return true
+		}
+	}
+	return false
 }
 
 // Update is used to update the task of the context
@@ -1,10 +1,11 @@
 package client
 
 import (
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
+	"reflect"
 	"testing"
 	"time"
 
@@ -22,17 +23,17 @@ func testLogger() *log.Logger {
 }
 
 type MockTaskStateUpdater struct {
-	Count       int
-	Name        []string
-	Status      []string
-	Description []string
+	Count  int
+	Name   []string
+	State  []string
+	Events []*structs.TaskEvent
 }
 
-func (m *MockTaskStateUpdater) Update(name, status, desc string) {
+func (m *MockTaskStateUpdater) Update(name, state string, event *structs.TaskEvent) {
 	m.Count += 1
 	m.Name = append(m.Name, name)
-	m.Status = append(m.Status, status)
-	m.Description = append(m.Description, desc)
+	m.State = append(m.State, state)
+	m.Events = append(m.Events, event)
 }
 
 func testTaskRunner() (*MockTaskStateUpdater, *TaskRunner) {
@@ -54,7 +55,7 @@ func testTaskRunner() (*MockTaskStateUpdater, *TaskRunner) {
 	ctx := driver.NewExecContext(allocDir, alloc.ID)
 	rp := structs.NewRestartPolicy(structs.JobTypeService)
 	restartTracker := newRestartTracker(structs.JobTypeService, rp)
-	tr := NewTaskRunner(logger, conf, upd.Update, ctx, alloc.ID, task, restartTracker)
+	tr := NewTaskRunner(logger, conf, upd.Update, ctx, alloc, task, restartTracker, nil)
 	return upd, tr
 }
 
@@ -77,21 +78,21 @@ func TestTaskRunner_SimpleRun(t *testing.T) {
 	if upd.Name[0] != tr.task.Name {
 		t.Fatalf("bad: %#v", upd.Name)
 	}
-	if upd.Status[0] != structs.AllocClientStatusRunning {
-		t.Fatalf("bad: %#v", upd.Status)
+	if upd.State[0] != structs.TaskStateRunning {
+		t.Fatalf("bad: %#v", upd.State)
 	}
-	if upd.Description[0] != "task started" {
-		t.Fatalf("bad: %#v", upd.Description)
+	if upd.Events[0].Type != structs.TaskStarted {
+		t.Fatalf("bad: %#v", upd.Events[0])
 	}
 
 	if upd.Name[1] != tr.task.Name {
 		t.Fatalf("bad: %#v", upd.Name)
 	}
-	if upd.Status[1] != structs.AllocClientStatusDead {
-		t.Fatalf("bad: %#v", upd.Status)
+	if upd.State[1] != structs.TaskStateDead {
+		t.Fatalf("bad: %#v", upd.State)
 	}
-	if upd.Description[1] != "task completed" {
-		t.Fatalf("bad: %#v", upd.Description)
+	if upd.Events[1].Type != structs.TaskTerminated || upd.Events[1].ExitCode != 0 {
+		t.Fatalf("bad: %#v", upd.Events[1])
 	}
 }
 
@@ -120,14 +121,14 @@ func TestTaskRunner_Destroy(t *testing.T) {
 	if upd.Count != 2 {
 		t.Fatalf("should have 2 updates: %#v", upd)
 	}
-	if upd.Status[0] != structs.AllocClientStatusRunning {
-		t.Fatalf("bad: %#v", upd.Status)
+	if upd.State[0] != structs.TaskStateRunning {
+		t.Fatalf("bad: %#v", upd.State)
 	}
-	if upd.Status[1] != structs.AllocClientStatusDead {
-		t.Fatalf("bad: %#v", upd.Status)
+	if upd.State[1] != structs.TaskStateDead {
+		t.Fatalf("bad: %#v", upd.State)
 	}
-	if !strings.Contains(upd.Description[1], "task failed") {
-		t.Fatalf("bad: %#v", upd.Description)
+	if upd.Events[1].Type != structs.TaskKilled || upd.Events[1].FailsTask {
+		t.Fatalf("bad: %#v", upd.Events[1])
 	}
 }
 
@@ -174,7 +175,7 @@ func TestTaskRunner_SaveRestoreState(t *testing.T) {
 
 	// Create a new task runner
 	tr2 := NewTaskRunner(tr.logger, tr.config, upd.Update,
-		tr.ctx, tr.allocID, &structs.Task{Name: tr.task.Name}, tr.restartTracker)
+		tr.ctx, tr.alloc, &structs.Task{Name: tr.task.Name}, tr.restartTracker, nil)
 	if err := tr2.RestoreState(); err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -187,3 +188,29 @@ func TestTaskRunner_SaveRestoreState(t *testing.T) {
 		t.Fatalf("RestoreState() didn't open handle")
 	}
 }
+
+func TestTaskRunner_WritePayload(t *testing.T) {
+	_, tr := testTaskRunner()
+	defer tr.ctx.AllocDir.Destroy()
+
+	// Dispatch a payload into a nested file of the local directory
+	expected := []byte("hello world")
+	tr.alloc.Job.Payload = expected
+	tr.task.DispatchPayload = &structs.DispatchPayloadConfig{
+		File: "sub/payload",
+	}
+
+	if err := tr.writePayload(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	taskDir := tr.ctx.AllocDir.TaskDirs[tr.task.Name]
+	path := filepath.Join(taskDir, allocdir.TaskLocal, "sub", "payload")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Fatalf("bad: got %q; want %q", data, expected)
+	}
+}
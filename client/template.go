@@ -0,0 +1,69 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// renderTemplates renders the templates of a task into the task directory.
+// The templates can read the task's environment, such as NOMAD_IP,
+// NOMAD_PORT_<label> and NOMAD_META_<key>, using the env function. A template
+// is only written if its rendered content differs from the file at its
+// destination and the templates that were written are returned.
+func renderTemplates(templates []*structs.Template, taskDir string, env map[string]string) ([]*structs.Template, error) {
+	var changed []*structs.Template
+	for _, tmpl := range templates {
+		rendered, err := renderTemplate(tmpl, taskDir, env)
+		if err != nil {
+			return nil, fmt.Errorf("template %q: %v", tmpl.DestPath, err)
+		}
+
+		dest := filepath.Join(taskDir, tmpl.DestPath)
+		if existing, err := ioutil.ReadFile(dest); err == nil && bytes.Equal(existing, rendered) {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dest), 0777); err != nil {
+			return nil, fmt.Errorf("template %q: %v", tmpl.DestPath, err)
+		}
+		if err := ioutil.WriteFile(dest, rendered, 0644); err != nil {
+			return nil, fmt.Errorf("template %q: %v", tmpl.DestPath, err)
+		}
+		changed = append(changed, tmpl)
+	}
+	return changed, nil
+}
+
+// renderTemplate returns the rendered content of a template
+func renderTemplate(tmpl *structs.Template, taskDir string, env map[string]string) ([]byte, error) {
+	contents := tmpl.EmbeddedTmpl
+	if tmpl.SourcePath != "" {
+		raw, err := ioutil.ReadFile(filepath.Join(taskDir, tmpl.SourcePath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source: %v", err)
+		}
+		contents = string(raw)
+	}
+
+	funcs := template.FuncMap{
+		"env": func(key string) string {
+			return env[key]
+		},
+	}
+	t, err := template.New(tmpl.DestPath).Funcs(funcs).Option("missingkey=zero").Parse(contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, env); err != nil {
+		return nil, fmt.Errorf("failed to render: %v", err)
+	}
+	return buf.Bytes(), nil
+}
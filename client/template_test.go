@@ -0,0 +1,105 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	taskDir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(taskDir)
+
+	// Write the source of the file template
+	src := filepath.Join(taskDir, "local", "app.tmpl")
+	if err := os.MkdirAll(filepath.Dir(src), 0777); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := ioutil.WriteFile(src, []byte(`port = {{ env "NOMAD_PORT_http" }}`), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	templates := []*structs.Template{
+		&structs.Template{
+			EmbeddedTmpl: `{{ env "NOMAD_IP" }} {{ env "NOMAD_META_foo" }}`,
+			DestPath:     "local/embedded.conf",
+			ChangeMode:   structs.TemplateChangeModeNoop,
+		},
+		&structs.Template{
+			SourcePath: "local/app.tmpl",
+			DestPath:   "local/conf/app.conf",
+			ChangeMode: structs.TemplateChangeModeRestart,
+		},
+	}
+	env := map[string]string{
+		"NOMAD_IP":        "10.0.0.1",
+		"NOMAD_PORT_http": "8080",
+		"NOMAD_META_foo":  "bar",
+	}
+
+	changed, err := renderTemplates(templates, taskDir, env)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(changed) != 2 {
+		t.Fatalf("bad: %#v", changed)
+	}
+
+	expected := map[string]string{
+		"local/embedded.conf": "10.0.0.1 bar",
+		"local/conf/app.conf": "port = 8080",
+	}
+	for dest, exp := range expected {
+		out, err := ioutil.ReadFile(filepath.Join(taskDir, dest))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if string(out) != exp {
+			t.Fatalf("%s: got %q; want %q", dest, out, exp)
+		}
+	}
+
+	// Rendering again without changes doesn't rewrite the templates
+	changed, err = renderTemplates(templates, taskDir, env)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(changed) != 0 {
+		t.Fatalf("bad: %#v", changed)
+	}
+
+	// Only the templates whose content changed are returned
+	env["NOMAD_PORT_http"] = "9090"
+	changed, err = renderTemplates(templates, taskDir, env)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != templates[1] {
+		t.Fatalf("bad: %#v", changed)
+	}
+}
+
+func TestRenderTemplates_MissingSource(t *testing.T) {
+	taskDir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(taskDir)
+
+	templates := []*structs.Template{
+		&structs.Template{
+			SourcePath: "local/missing.tmpl",
+			DestPath:   "local/out",
+			ChangeMode: structs.TemplateChangeModeRestart,
+		},
+	}
+	if _, err := renderTemplates(templates, taskDir, nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
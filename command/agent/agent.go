@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
@@ -145,6 +146,13 @@ func (a *Agent) serverConfig() (*nomad.Config, error) {
 		}
 		conf.NodeGCThreshold = dur
 	}
+	if gcThreshold := a.config.Server.JobGCThreshold; gcThreshold != "" {
+		dur, err := time.ParseDuration(gcThreshold)
+		if err != nil {
+			return nil, err
+		}
+		conf.JobGCThreshold = dur
+	}
 
 	return conf, nil
 }
@@ -208,6 +216,22 @@ func (a *Agent) setupClient() error {
 	if a.config.Client.NetworkSpeed != 0 {
 		conf.NetworkSpeed = a.config.Client.NetworkSpeed
 	}
+	if gcInterval := a.config.Client.GCInterval; gcInterval != "" {
+		dur, err := time.ParseDuration(gcInterval)
+		if err != nil {
+			return fmt.Errorf("failed to parse gc interval: %v", err)
+		}
+		conf.GCInterval = dur
+	}
+	if a.config.Client.GCDiskUsageThreshold != 0 {
+		conf.GCDiskUsageThreshold = float64(a.config.Client.GCDiskUsageThreshold)
+	}
+	if a.config.Client.GCInodeUsageThreshold != 0 {
+		conf.GCInodeUsageThreshold = float64(a.config.Client.GCInodeUsageThreshold)
+	}
+	if a.config.Client.GCMaxAllocs != 0 {
+		conf.GCMaxAllocs = a.config.Client.GCMaxAllocs
+	}
 
 	// Setup the node
 	conf.Node = new(structs.Node)
@@ -216,6 +240,32 @@ func (a *Agent) setupClient() error {
 	conf.Node.ID = a.config.Client.NodeID
 	conf.Node.Meta = a.config.Client.Meta
 	conf.Node.NodeClass = a.config.Client.NodeClass
+	conf.Node.HTTPAddr = a.clientHTTPAddr()
+	if len(a.config.Client.HostVolumes) != 0 {
+		conf.Node.HostVolumes = make(map[string]*structs.ClientHostVolumeConfig)
+	}
+	for _, v := range a.config.Client.HostVolumes {
+		if v.Name == "" || !filepath.IsAbs(v.Path) {
+			return fmt.Errorf("host volume %q must have an absolute path: %q", v.Name, v.Path)
+		}
+		conf.Node.HostVolumes[v.Name] = &structs.ClientHostVolumeConfig{
+			Name:     v.Name,
+			Path:     v.Path,
+			ReadOnly: v.ReadOnly,
+		}
+	}
+
+	// Setup the telemetry of the client
+	if telemetry := a.config.Telemetry; telemetry != nil {
+		if telemetry.CollectionInterval != "" {
+			dur, err := time.ParseDuration(telemetry.CollectionInterval)
+			if err != nil {
+				return fmt.Errorf("failed to parse telemetry collection interval: %v", err)
+			}
+			conf.StatsCollectionInterval = dur
+		}
+		conf.PublishNodeMetrics = telemetry.PublishNodeMetrics
+	}
 
 	// Create the client
 	client, err := client.NewClient(conf)
@@ -226,6 +276,23 @@ func (a *Agent) setupClient() error {
 	return nil
 }
 
+// clientHTTPAddr returns the address the client advertises for its HTTP API
+func (a *Agent) clientHTTPAddr() string {
+	port := strconv.Itoa(a.config.Ports.HTTP)
+	if addr := a.config.AdvertiseAddrs.HTTP; addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err == nil {
+			return addr
+		}
+		return net.JoinHostPort(addr, port)
+	}
+
+	addr := a.config.BindAddr
+	if a.config.Addresses.HTTP != "" {
+		addr = a.config.Addresses.HTTP
+	}
+	return net.JoinHostPort(addr, port)
+}
+
 // Leave is used gracefully exit. Clients will inform servers
 // of their departure so that allocations can be rescheduled.
 func (a *Agent) Leave() error {
@@ -0,0 +1,258 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/nomad/client"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
+)
+
+const (
+	// execUpgradeProtocol is the protocol the connection of an exec request is
+	// upgraded to in order to stream the command's input and output
+	execUpgradeProtocol = "nomad-exec"
+)
+
+func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/v1/client/allocation/")
+	tokens := strings.Split(path, "/")
+	if len(tokens) != 2 || tokens[0] == "" {
+		return nil, CodedError(404, "Invalid path")
+	}
+	allocID := tokens[0]
+	switch tokens[1] {
+	case "stats":
+		return s.allocStats(resp, req, allocID)
+	case "exec":
+		return s.allocExec(resp, req, allocID)
+	case "snapshot":
+		return s.allocSnapshot(resp, req, allocID)
+	default:
+		return nil, CodedError(404, "Invalid path")
+	}
+}
+
+// allocStats returns the resource usage of an allocation. Requests for
+// allocations that don't run on this agent's client are forwarded to the
+// client running the allocation.
+func (s *HTTPServer) allocStats(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	if c := s.agent.Client(); c != nil {
+		stats, err := c.AllocStats(allocID)
+		if err == nil {
+			return stats, nil
+		}
+		if err != client.ErrUnknownAllocation {
+			return nil, err
+		}
+	}
+	return nil, s.forwardClientRequest(resp, req, allocID)
+}
+
+// allocSnapshot writes the data directory of an allocation as a gzipped tar
+// archive. It is requested by the client running the replacement of a sticky
+// allocation to migrate its data. Requests for allocations that don't run on
+// this agent's client are forwarded to the client running the allocation.
+func (s *HTTPServer) allocSnapshot(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	c := s.agent.Client()
+	if c == nil {
+		return nil, s.forwardClientRequest(resp, req, allocID)
+	}
+	allocDir, err := c.GetAllocDir(allocID)
+	if err != nil {
+		if err == client.ErrUnknownAllocation {
+			return nil, s.forwardClientRequest(resp, req, allocID)
+		}
+		return nil, err
+	}
+
+	if _, err := os.Stat(allocDir.DataDir()); err != nil {
+		if os.IsNotExist(err) {
+			return nil, CodedError(404, fmt.Sprintf("alloc %q has no data directory", allocID))
+		}
+		return nil, err
+	}
+
+	resp.Header().Set("Content-Type", "application/x-gzip")
+	if err := allocDir.SnapshotData(resp); err != nil {
+		s.logger.Printf("[ERR] http: failed to snapshot data of alloc '%s': %v", allocID, err)
+	}
+	return nil, nil
+}
+
+// execFrame is a frame of the stream of an exec request. Frames sent by the
+// requester carry the input and terminal size of the command, frames sent
+// back carry its output and finally its exit code.
+type execFrame struct {
+	Stdin      []byte                 `json:",omitempty"`
+	StdinClose bool                   `json:",omitempty"`
+	Resize     *cstructs.TerminalSize `json:",omitempty"`
+
+	Stdout   []byte `json:",omitempty"`
+	Stderr   []byte `json:",omitempty"`
+	Exited   bool   `json:",omitempty"`
+	ExitCode int    `json:",omitempty"`
+	Error    string `json:",omitempty"`
+}
+
+// allocExec runs a command in the environment of a task of an allocation. The
+// connection is upgraded and the input and output of the command are streamed
+// over it as JSON encoded frames until the command exits. Requests for
+// allocations that don't run on this agent's client are forwarded to the
+// client running the allocation.
+func (s *HTTPServer) allocExec(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	query := req.URL.Query()
+	task := query.Get("task")
+	if task == "" {
+		return nil, CodedError(400, "missing task name")
+	}
+	var command []string
+	if err := json.Unmarshal([]byte(query.Get("command")), &command); err != nil || len(command) == 0 {
+		return nil, CodedError(400, "command must be a non-empty JSON array of strings")
+	}
+	var tty bool
+	if raw := query.Get("tty"); raw != "" {
+		var err error
+		if tty, err = strconv.ParseBool(raw); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("invalid tty value %q", raw))
+		}
+	}
+	if !strings.EqualFold(req.Header.Get("Upgrade"), execUpgradeProtocol) {
+		return nil, CodedError(400, fmt.Sprintf("exec requires upgrading the connection to %q", execUpgradeProtocol))
+	}
+
+	c := s.agent.Client()
+	if c == nil {
+		return nil, s.forwardClientRequest(resp, req, allocID)
+	}
+	if err := c.ValidateExecTask(allocID, task); err != nil {
+		if err == client.ErrUnknownAllocation {
+			return nil, s.forwardClientRequest(resp, req, allocID)
+		}
+		return nil, CodedError(400, err.Error())
+	}
+
+	hijacker, ok := resp.(http.Hijacker)
+	if !ok {
+		return nil, CodedError(500, "connection can't be upgraded")
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, CodedError(500, fmt.Sprintf("failed to upgrade connection: %v", err))
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(buf, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: %s\r\n\r\n", execUpgradeProtocol)
+	if err := buf.Flush(); err != nil {
+		return nil, nil
+	}
+
+	enc := &execEncoder{enc: json.NewEncoder(conn)}
+	stdinR, stdinW := io.Pipe()
+	resizeCh := make(chan cstructs.TerminalSize, 1)
+	go execReadFrames(json.NewDecoder(buf), stdinW, resizeCh)
+
+	opts := &cstructs.ExecOptions{
+		Command:  command,
+		Tty:      tty,
+		Stdin:    stdinR,
+		Stdout:   &execWriter{enc: enc, stderr: false},
+		Stderr:   &execWriter{enc: enc, stderr: true},
+		ResizeCh: resizeCh,
+	}
+	code, err := c.ExecTask(allocID, task, opts)
+
+	// Unblock a pending write of stdin so the frame reader returns once the
+	// connection is closed
+	stdinR.Close()
+
+	exit := &execFrame{Exited: true, ExitCode: code}
+	if err != nil {
+		exit.Error = err.Error()
+	}
+	if err := enc.Encode(exit); err != nil {
+		s.logger.Printf("[DEBUG] http: failed to send exit of exec in alloc %q: %v", allocID, err)
+	}
+
+	// The response was written to the hijacked connection
+	return nil, nil
+}
+
+// execReadFrames reads the frames sent by the requester of an exec, writing the
+// input to stdin and sending terminal resizes, until the connection is
+// closed.
+func execReadFrames(dec *json.Decoder, stdin *io.PipeWriter, resizeCh chan cstructs.TerminalSize) {
+	defer close(resizeCh)
+	defer stdin.Close()
+
+	for {
+		var frame execFrame
+		if err := dec.Decode(&frame); err != nil {
+			return
+		}
+
+		if len(frame.Stdin) > 0 {
+			if _, err := stdin.Write(frame.Stdin); err != nil {
+				return
+			}
+		}
+		if frame.StdinClose {
+			stdin.Close()
+		}
+		if frame.Resize != nil {
+			// Resizes are dropped if the command hasn't handled the previous one
+			select {
+			case resizeCh <- *frame.Resize:
+			default:
+			}
+		}
+	}
+}
+
+// execEncoder serializes the frames written to the connection of an exec
+type execEncoder struct {
+	enc  *json.Encoder
+	lock sync.Mutex
+}
+
+func (e *execEncoder) Encode(frame *execFrame) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.enc.Encode(frame)
+}
+
+// execWriter writes the stdout or stderr of an exec as frames
+type execWriter struct {
+	enc    *execEncoder
+	stderr bool
+}
+
+func (w *execWriter) Write(p []byte) (int, error) {
+	// The frame is encoded before returning so p may be reused by the caller
+	frame := &execFrame{Stdout: p}
+	if w.stderr {
+		frame = &execFrame{Stderr: p}
+	}
+	if err := w.enc.Encode(frame); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTP_ClientAllocStats_InvalidPath(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		paths := []string{
+			"/v1/client/allocation/",
+			"/v1/client/allocation/foo",
+			"/v1/client/allocation/foo/bar",
+			"/v1/client/allocation//stats",
+		}
+		for _, path := range paths {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			respW := httptest.NewRecorder()
+
+			_, err = s.Server.ClientAllocRequest(respW, req)
+			coded, ok := err.(HTTPCodedError)
+			if !ok || coded.Code() != 404 {
+				t.Fatalf("%s: err: %v", path, err)
+			}
+		}
+	})
+}
+
+func TestHTTP_ClientAllocStats_UnknownAlloc(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/client/allocation/foo/stats", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		coded, ok := err.(HTTPCodedError)
+		if !ok || coded.Code() != 404 {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_ClientAllocExec_BadRequest(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		paths := []string{
+			// Missing task
+			`/v1/client/allocation/foo/exec?command=["/bin/sh"]`,
+			// Missing command
+			"/v1/client/allocation/foo/exec?task=web",
+			// Command isn't a JSON array
+			"/v1/client/allocation/foo/exec?task=web&command=/bin/sh",
+			// Invalid tty flag
+			`/v1/client/allocation/foo/exec?task=web&command=["/bin/sh"]&tty=maybe`,
+		}
+		for _, path := range paths {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			req.Header.Set("Upgrade", execUpgradeProtocol)
+			respW := httptest.NewRecorder()
+
+			_, err = s.Server.ClientAllocRequest(respW, req)
+			coded, ok := err.(HTTPCodedError)
+			if !ok || coded.Code() != 400 {
+				t.Fatalf("%s: err: %v", path, err)
+			}
+		}
+
+		// The connection must be upgraded
+		req, err := http.NewRequest("GET", `/v1/client/allocation/foo/exec?task=web&command=["/bin/sh"]`, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		coded, ok := err.(HTTPCodedError)
+		if !ok || coded.Code() != 400 {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_ClientAllocExec_UnknownAlloc(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", `/v1/client/allocation/foo/exec?task=web&command=["/bin/sh"]`, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		req.Header.Set("Upgrade", execUpgradeProtocol)
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		coded, ok := err.(HTTPCodedError)
+		if !ok || coded.Code() != 404 {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_ClientAllocSnapshot_UnknownAlloc(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/client/allocation/foo/snapshot", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		coded, ok := err.(HTTPCodedError)
+		if !ok || coded.Code() != 404 {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"net/http"
+)
+
+// ClientGCRequest forces the garbage collection of all the allocations
+// retained by a client. Requests for another node, given by the node_id
+// parameter, are forwarded to its client.
+func (s *HTTPServer) ClientGCRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	c := s.agent.Client()
+	if nodeID := req.URL.Query().Get("node_id"); nodeID != "" {
+		if c == nil || c.Node().ID != nodeID {
+			return nil, s.forwardToNode(resp, req, nodeID)
+		}
+	}
+	if c == nil {
+		return nil, CodedError(400, "Nomad agent is not running a client")
+	}
+
+	c.CollectAllAllocs()
+	return nil, nil
+}
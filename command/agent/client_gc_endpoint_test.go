@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTP_ClientGC(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("PUT", "/v1/client/gc", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		if _, err := s.Server.ClientGCRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_ClientGC_BadMethod(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/client/gc", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.ClientGCRequest(respW, req)
+		coded, ok := err.(HTTPCodedError)
+		if !ok || coded.Code() != 405 {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"net/http"
+)
+
+// ClientStatsRequest returns the latest resource usage of the host of a
+// client. Requests for another node, given by the node_id parameter, are
+// forwarded to its client.
+func (s *HTTPServer) ClientStatsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	c := s.agent.Client()
+	if nodeID := req.URL.Query().Get("node_id"); nodeID != "" {
+		if c == nil || c.Node().ID != nodeID {
+			return nil, s.forwardToNode(resp, req, nodeID)
+		}
+	}
+	if c == nil {
+		return nil, CodedError(400, "Nomad agent is not running a client")
+	}
+
+	hostStats := c.LatestHostStats()
+	if hostStats == nil {
+		return nil, CodedError(503, "Host stats have not been collected yet")
+	}
+	return hostStats, nil
+}
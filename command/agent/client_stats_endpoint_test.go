@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/stats"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestHTTP_ClientStats(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Wait for the first sample of the host stats
+		var hostStats *stats.HostStats
+		testutil.WaitForResult(func() (bool, error) {
+			req, err := http.NewRequest("GET", "/v1/client/stats", nil)
+			if err != nil {
+				return false, err
+			}
+			respW := httptest.NewRecorder()
+
+			obj, err := s.Server.ClientStatsRequest(respW, req)
+			if err != nil {
+				return false, err
+			}
+			hostStats = obj.(*stats.HostStats)
+			return true, nil
+		}, func(err error) {
+			t.Fatalf("err: %v", err)
+		})
+
+		if hostStats.Memory == nil || len(hostStats.CPU) == 0 {
+			t.Fatalf("bad: %#v", hostStats)
+		}
+	})
+}
+
+func TestHTTP_ClientStats_UnknownNode(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/client/stats?node_id=foo", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.ClientStatsRequest(respW, req)
+		coded, ok := err.(HTTPCodedError)
+		if !ok || coded.Code() != 404 {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_ClientStats_BadMethod(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("PUT", "/v1/client/stats", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.ClientStatsRequest(respW, req)
+		coded, ok := err.(HTTPCodedError)
+		if !ok || coded.Code() != 405 {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
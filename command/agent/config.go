@@ -151,6 +151,35 @@ type ClientConfig struct {
 
 	// The network link speed to use if it can not be determined dynamically.
 	NetworkSpeed int `hcl:"network_speed"`
+
+	// HostVolumes are the host paths offered to be mounted into tasks
+	HostVolumes []*HostVolumeConfig `hcl:"host_volume"`
+
+	// GCInterval is the interval at which the garbage collector of terminal
+	// allocations checks the usage of the alloc dir
+	GCInterval string `hcl:"gc_interval"`
+
+	// GCDiskUsageThreshold and GCInodeUsageThreshold are the disk and inode
+	// usage percentages of the alloc dir above which terminal allocations
+	// are garbage collected
+	GCDiskUsageThreshold  int `hcl:"gc_disk_usage_threshold"`
+	GCInodeUsageThreshold int `hcl:"gc_inode_usage_threshold"`
+
+	// GCMaxAllocs is the maximum number of terminal allocations retained
+	GCMaxAllocs int `hcl:"gc_max_allocs"`
+}
+
+// HostVolumeConfig is a host path the client offers to be mounted into tasks
+// that request a host volume with its name
+type HostVolumeConfig struct {
+	// Name is the name tasks request the volume by
+	Name string `hcl:",key"`
+
+	// Path is the absolute path of the volume on the host
+	Path string `hcl:"path"`
+
+	// ReadOnly only allows the volume to be mounted read-only
+	ReadOnly bool `hcl:"read_only"`
 }
 
 // ServerConfig is configuration specific to the server mode
@@ -181,6 +210,9 @@ type ServerConfig struct {
 
 	// NodeGCThreshold contros how "old" a node must be to be collected by GC.
 	NodeGCThreshold string `hcl:"node_gc_threshold"`
+
+	// JobGCThreshold controls how "old" a job must be to be collected by GC.
+	JobGCThreshold string `hcl:"job_gc_threshold"`
 }
 
 // Telemetry is the telemetry configuration for the server
@@ -188,6 +220,14 @@ type Telemetry struct {
 	StatsiteAddr    string `hcl:"statsite_address"`
 	StatsdAddr      string `hcl:"statsd_address"`
 	DisableHostname bool   `hcl:"disable_hostname"`
+
+	// CollectionInterval is the interval at which clients sample the
+	// resource usage of the host and of the running tasks
+	CollectionInterval string `hcl:"collection_interval"`
+
+	// PublishNodeMetrics emits the resource usage of the host of clients
+	// through the metrics sinks
+	PublishNodeMetrics bool `hcl:"publish_node_metrics"`
 }
 
 // Ports is used to encapsulate the various ports we bind to for network
@@ -210,6 +250,7 @@ type Addresses struct {
 // different network services. Not all network services support an
 // advertise address. All are optional and default to BindAddr.
 type AdvertiseAddrs struct {
+	HTTP string `hcl:"http"`
 	RPC  string `hcl:"rpc"`
 	Serf string `hcl:"serf"`
 }
@@ -383,6 +424,9 @@ func (a *ServerConfig) Merge(b *ServerConfig) *ServerConfig {
 	if b.NodeGCThreshold != "" {
 		result.NodeGCThreshold = b.NodeGCThreshold
 	}
+	if b.JobGCThreshold != "" {
+		result.JobGCThreshold = b.JobGCThreshold
+	}
 
 	// Add the schedulers
 	result.EnabledSchedulers = append(result.EnabledSchedulers, b.EnabledSchedulers...)
@@ -415,10 +459,25 @@ func (a *ClientConfig) Merge(b *ClientConfig) *ClientConfig {
 	if b.NetworkSpeed != 0 {
 		result.NetworkSpeed = b.NetworkSpeed
 	}
+	if b.GCInterval != "" {
+		result.GCInterval = b.GCInterval
+	}
+	if b.GCDiskUsageThreshold != 0 {
+		result.GCDiskUsageThreshold = b.GCDiskUsageThreshold
+	}
+	if b.GCInodeUsageThreshold != 0 {
+		result.GCInodeUsageThreshold = b.GCInodeUsageThreshold
+	}
+	if b.GCMaxAllocs != 0 {
+		result.GCMaxAllocs = b.GCMaxAllocs
+	}
 
 	// Add the servers
 	result.Servers = append(result.Servers, b.Servers...)
 
+	// Add the host volumes
+	result.HostVolumes = append(result.HostVolumes, b.HostVolumes...)
+
 	// Add the options map values
 	if result.Options == nil {
 		result.Options = make(map[string]string)
@@ -451,6 +510,12 @@ func (a *Telemetry) Merge(b *Telemetry) *Telemetry {
 	if b.DisableHostname {
 		result.DisableHostname = true
 	}
+	if b.CollectionInterval != "" {
+		result.CollectionInterval = b.CollectionInterval
+	}
+	if b.PublishNodeMetrics {
+		result.PublishNodeMetrics = true
+	}
 	return &result
 }
 
@@ -490,6 +555,9 @@ func (a *Addresses) Merge(b *Addresses) *Addresses {
 func (a *AdvertiseAddrs) Merge(b *AdvertiseAddrs) *AdvertiseAddrs {
 	var result AdvertiseAddrs = *a
 
+	if b.HTTP != "" {
+		result.HTTP = b.HTTP
+	}
 	if b.RPC != "" {
 		result.RPC = b.RPC
 	}
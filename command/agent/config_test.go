@@ -26,9 +26,10 @@ func TestConfig_Merge(t *testing.T) {
 		DisableAnonymousSignature: false,
 		BindAddr:                  "127.0.0.1",
 		Telemetry: &Telemetry{
-			StatsiteAddr:    "127.0.0.1:8125",
-			StatsdAddr:      "127.0.0.1:8125",
-			DisableHostname: false,
+			StatsiteAddr:       "127.0.0.1:8125",
+			StatsdAddr:         "127.0.0.1:8125",
+			DisableHostname:    false,
+			CollectionInterval: "1s",
 		},
 		Client: &ClientConfig{
 			Enabled:   false,
@@ -48,6 +49,7 @@ func TestConfig_Merge(t *testing.T) {
 			ProtocolVersion: 1,
 			NumSchedulers:   1,
 			NodeGCThreshold: "1h",
+			JobGCThreshold:  "1h",
 		},
 		Ports: &Ports{
 			HTTP: 4646,
@@ -60,6 +62,7 @@ func TestConfig_Merge(t *testing.T) {
 			Serf: "127.0.0.1",
 		},
 		AdvertiseAddrs: &AdvertiseAddrs{
+			HTTP: "127.0.0.1",
 			RPC:  "127.0.0.1",
 			Serf: "127.0.0.1",
 		},
@@ -80,9 +83,11 @@ func TestConfig_Merge(t *testing.T) {
 		DisableAnonymousSignature: true,
 		BindAddr:                  "127.0.0.2",
 		Telemetry: &Telemetry{
-			StatsiteAddr:    "127.0.0.2:8125",
-			StatsdAddr:      "127.0.0.2:8125",
-			DisableHostname: true,
+			StatsiteAddr:       "127.0.0.2:8125",
+			StatsdAddr:         "127.0.0.2:8125",
+			DisableHostname:    true,
+			CollectionInterval: "5s",
+			PublishNodeMetrics: true,
 		},
 		Client: &ClientConfig{
 			Enabled:   true,
@@ -98,7 +103,11 @@ func TestConfig_Merge(t *testing.T) {
 				"foo": "bar",
 				"baz": "zip",
 			},
-			NetworkSpeed: 100,
+			NetworkSpeed:          100,
+			GCInterval:            "6s",
+			GCDiskUsageThreshold:  82,
+			GCInodeUsageThreshold: 91,
+			GCMaxAllocs:           200,
 		},
 		Server: &ServerConfig{
 			Enabled:           true,
@@ -108,6 +117,7 @@ func TestConfig_Merge(t *testing.T) {
 			NumSchedulers:     2,
 			EnabledSchedulers: []string{structs.JobTypeBatch},
 			NodeGCThreshold:   "12h",
+			JobGCThreshold:    "12h",
 		},
 		Ports: &Ports{
 			HTTP: 20000,
@@ -120,6 +130,7 @@ func TestConfig_Merge(t *testing.T) {
 			Serf: "127.0.0.2",
 		},
 		AdvertiseAddrs: &AdvertiseAddrs{
+			HTTP: "127.0.0.2",
 			RPC:  "127.0.0.2",
 			Serf: "127.0.0.2",
 		},
@@ -344,6 +355,7 @@ func TestConfig_LoadConfigString(t *testing.T) {
 			Serf: "127.0.0.3",
 		},
 		AdvertiseAddrs: &AdvertiseAddrs{
+			HTTP: "127.0.0.2:1234",
 			RPC:  "127.0.0.3",
 			Serf: "127.0.0.4",
 		},
@@ -363,6 +375,17 @@ func TestConfig_LoadConfigString(t *testing.T) {
 				"baz": "zip",
 			},
 			NetworkSpeed: 100,
+			HostVolumes: []*HostVolumeConfig{
+				&HostVolumeConfig{
+					Name:     "mysql",
+					Path:     "/srv/mysql",
+					ReadOnly: true,
+				},
+			},
+			GCInterval:            "6s",
+			GCDiskUsageThreshold:  82,
+			GCInodeUsageThreshold: 91,
+			GCMaxAllocs:           200,
 		},
 		Server: &ServerConfig{
 			Enabled:           true,
@@ -372,11 +395,14 @@ func TestConfig_LoadConfigString(t *testing.T) {
 			NumSchedulers:     2,
 			EnabledSchedulers: []string{"test"},
 			NodeGCThreshold:   "12h",
+			JobGCThreshold:    "12h",
 		},
 		Telemetry: &Telemetry{
-			StatsiteAddr:    "127.0.0.1:1234",
-			StatsdAddr:      "127.0.0.1:2345",
-			DisableHostname: true,
+			StatsiteAddr:       "127.0.0.1:1234",
+			StatsdAddr:         "127.0.0.1:2345",
+			DisableHostname:    true,
+			CollectionInterval: "3s",
+			PublishNodeMetrics: true,
 		},
 		LeaveOnInt:                true,
 		LeaveOnTerm:               true,
@@ -417,6 +443,7 @@ addresses {
 	serf = "127.0.0.3"
 }
 advertise {
+	http = "127.0.0.2:1234"
 	rpc = "127.0.0.3"
 	serf = "127.0.0.4"
 }
@@ -436,6 +463,14 @@ client {
 		baz = "zip"
 	}
 	network_speed = 100
+	host_volume "mysql" {
+		path = "/srv/mysql"
+		read_only = true
+	}
+	gc_interval = "6s"
+	gc_disk_usage_threshold = 82
+	gc_inode_usage_threshold = 91
+	gc_max_allocs = 200
 }
 server {
 	enabled = true
@@ -445,11 +480,14 @@ server {
 	num_schedulers = 2
 	enabled_schedulers = ["test"]
 	node_gc_threshold = "12h"
+	job_gc_threshold = "12h"
 }
 telemetry {
 	statsite_address = "127.0.0.1:1234"
 	statsd_address = "127.0.0.1:2345"
 	disable_hostname = true
+	collection_interval = "3s"
+	publish_node_metrics = true
 }
 leave_on_interrupt = true
 leave_on_terminate = true
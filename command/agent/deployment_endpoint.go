@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func (s *HTTPServer) DeploymentsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.DeploymentListRequest{}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.DeploymentListResponse
+	if err := s.agent.RPC("Deployment.List", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Deployments == nil {
+		out.Deployments = make([]*structs.Deployment, 0)
+	}
+	return out.Deployments, nil
+}
+
+func (s *HTTPServer) DeploymentSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/v1/deployment/")
+	switch {
+	case strings.HasPrefix(path, "promote/"):
+		deploymentID := strings.TrimPrefix(path, "promote/")
+		return s.deploymentPromote(resp, req, deploymentID)
+	case strings.HasPrefix(path, "fail/"):
+		deploymentID := strings.TrimPrefix(path, "fail/")
+		return s.deploymentFail(resp, req, deploymentID)
+	default:
+		return s.deploymentQuery(resp, req, path)
+	}
+}
+
+func (s *HTTPServer) deploymentPromote(resp http.ResponseWriter, req *http.Request,
+	deploymentID string) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	args := structs.DeploymentPromoteRequest{}
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if args.DeploymentID != "" && args.DeploymentID != deploymentID {
+		return nil, CodedError(400, "Deployment ID does not match")
+	}
+	args.DeploymentID = deploymentID
+	s.parseRegion(req, &args.Region)
+
+	var out structs.DeploymentUpdateResponse
+	if err := s.agent.RPC("Deployment.Promote", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
+func (s *HTTPServer) deploymentFail(resp http.ResponseWriter, req *http.Request,
+	deploymentID string) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	args := structs.DeploymentFailRequest{
+		DeploymentID: deploymentID,
+	}
+	s.parseRegion(req, &args.Region)
+
+	var out structs.DeploymentUpdateResponse
+	if err := s.agent.RPC("Deployment.Fail", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
+func (s *HTTPServer) deploymentQuery(resp http.ResponseWriter, req *http.Request,
+	deploymentID string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.DeploymentSpecificRequest{
+		DeploymentID: deploymentID,
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.SingleDeploymentResponse
+	if err := s.agent.RPC("Deployment.GetDeployment", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Deployment == nil {
+		return nil, CodedError(404, "deployment not found")
+	}
+	return out.Deployment, nil
+}
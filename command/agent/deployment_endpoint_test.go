@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_DeploymentList(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d1 := mock.Deployment()
+		d2 := mock.Deployment()
+		if err := state.UpsertDeployment(999, d1); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertDeployment(1000, d2); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployments", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		if respW.HeaderMap.Get("X-Nomad-LastContact") == "" {
+			t.Fatalf("missing last contact")
+		}
+
+		// Check the deployments
+		deploys := obj.([]*structs.Deployment)
+		if len(deploys) != 2 {
+			t.Fatalf("bad: %#v", deploys)
+		}
+	})
+}
+
+func TestHTTP_DeploymentQuery(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d := mock.Deployment()
+		if err := state.UpsertDeployment(1000, d); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployment/"+d.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the deployment
+		out := obj.(*structs.Deployment)
+		if out.ID != d.ID {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_DeploymentPromote(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		job := mock.Job()
+		if err := state.UpsertJob(999, job); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		d := structs.NewDeployment(job)
+		d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 10}
+		if err := state.UpsertDeployment(1000, d); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		args := structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		}
+		buf := encodeReq(args)
+		req, err := http.NewRequest("PUT", "/v1/deployment/promote/"+d.ID, buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the response
+		resp := obj.(structs.DeploymentUpdateResponse)
+		if resp.EvalID == "" {
+			t.Fatalf("bad: %#v", resp)
+		}
+	})
+}
+
+func TestHTTP_DeploymentFail(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		job := mock.Job()
+		if err := state.UpsertJob(999, job); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		d := structs.NewDeployment(job)
+		if err := state.UpsertDeployment(1000, d); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/deployment/fail/"+d.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the deployment failed
+		resp := obj.(structs.DeploymentUpdateResponse)
+		if resp.EvalID == "" {
+			t.Fatalf("bad: %#v", resp)
+		}
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.Status != structs.DeploymentStatusFailed {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
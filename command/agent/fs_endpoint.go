@@ -0,0 +1,423 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/client"
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/driver/logging"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// fsForwardFlushInterval is how often the response of a request that is
+	// forwarded to the client running the allocation is flushed so that
+	// followed logs are streamed
+	fsForwardFlushInterval = 100 * time.Millisecond
+
+	// fsStreamPollInterval is how often a streamed file is checked for new
+	// data
+	fsStreamPollInterval = 250 * time.Millisecond
+)
+
+func (s *HTTPServer) FsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/v1/client/fs/")
+	switch {
+	case strings.HasPrefix(path, "logs/"):
+		return s.Logs(resp, req, strings.TrimPrefix(path, "logs/"))
+	case strings.HasPrefix(path, "ls/"):
+		return s.DirectoryListRequest(resp, req, strings.TrimPrefix(path, "ls/"))
+	case strings.HasPrefix(path, "stat/"):
+		return s.FileStatRequest(resp, req, strings.TrimPrefix(path, "stat/"))
+	case strings.HasPrefix(path, "cat/"):
+		return s.FileCatRequest(resp, req, strings.TrimPrefix(path, "cat/"))
+	case strings.HasPrefix(path, "readat/"):
+		return s.FileReadAtRequest(resp, req, strings.TrimPrefix(path, "readat/"))
+	case strings.HasPrefix(path, "stream/"):
+		return s.FileStreamRequest(resp, req, strings.TrimPrefix(path, "stream/"))
+	default:
+		return nil, CodedError(404, "Invalid path")
+	}
+}
+
+// DirectoryListRequest lists the files of a directory of an allocation
+func (s *HTTPServer) DirectoryListRequest(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	path := req.URL.Query().Get("path")
+	if path == "" {
+		path = "/"
+	}
+	ad, err := s.fsAllocDir(req, allocID, path)
+	if err != nil || ad == nil {
+		return nil, s.fsForward(resp, req, allocID, err)
+	}
+
+	files, err := ad.List(path)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	return files, nil
+}
+
+// FileStatRequest returns information about a file of an allocation
+func (s *HTTPServer) FileStatRequest(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	path := req.URL.Query().Get("path")
+	ad, err := s.fsAllocDir(req, allocID, path)
+	if err != nil || ad == nil {
+		return nil, s.fsForward(resp, req, allocID, err)
+	}
+
+	info, err := ad.Stat(path)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	return info, nil
+}
+
+// FileCatRequest returns the contents of a file of an allocation
+func (s *HTTPServer) FileCatRequest(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	path := req.URL.Query().Get("path")
+	ad, err := s.fsAllocDir(req, allocID, path)
+	if err != nil || ad == nil {
+		return nil, s.fsForward(resp, req, allocID, err)
+	}
+	return nil, s.readFile(resp, ad, path, 0, 0)
+}
+
+// FileReadAtRequest returns the contents of a file of an allocation starting
+// at an offset, optionally limited to a number of bytes
+func (s *HTTPServer) FileReadAtRequest(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	query := req.URL.Query()
+	path := query.Get("path")
+	ad, err := s.fsAllocDir(req, allocID, path)
+	if err != nil || ad == nil {
+		return nil, s.fsForward(resp, req, allocID, err)
+	}
+
+	offset, err := parseInt64Param(query, "offset")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := parseInt64Param(query, "limit")
+	if err != nil {
+		return nil, err
+	}
+	return nil, s.readFile(resp, ad, path, offset, limit)
+}
+
+// FileStreamRequest streams the contents of a file of an allocation starting
+// at an offset, following the data appended to the file until the client
+// goes away
+func (s *HTTPServer) FileStreamRequest(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	query := req.URL.Query()
+	path := query.Get("path")
+	ad, err := s.fsAllocDir(req, allocID, path)
+	if err != nil || ad == nil {
+		return nil, s.fsForward(resp, req, allocID, err)
+	}
+
+	offset, err := parseInt64Param(query, "offset")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRegularFile(ad, path); err != nil {
+		return nil, err
+	}
+	r, err := ad.ReadAt(path, offset, 0)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	defer r.Close()
+
+	// Stop streaming once the client goes away
+	var closeCh <-chan bool
+	if notifier, ok := resp.(http.CloseNotifier); ok {
+		closeCh = notifier.CloseNotify()
+	}
+
+	flusher, _ := resp.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, err := resp.Write(buf[:n]); err != nil {
+				return nil, nil
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			s.logger.Printf("[ERR] http: failed to stream file %q: %v", path, err)
+			return nil, nil
+		}
+
+		select {
+		case <-closeCh:
+			return nil, nil
+		case <-time.After(fsStreamPollInterval):
+		}
+	}
+}
+
+// fsAllocDir returns the directory of an allocation running on this agent's
+// client. nil is returned if the allocation doesn't run on this agent.
+func (s *HTTPServer) fsAllocDir(req *http.Request, allocID, path string) (*allocdir.AllocDir, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	if allocID == "" {
+		return nil, CodedError(400, "Must provide an allocation ID")
+	}
+	if path == "" {
+		return nil, CodedError(400, "Must provide a file path")
+	}
+
+	c := s.agent.Client()
+	if c == nil {
+		return nil, nil
+	}
+	ad, err := c.GetAllocDir(allocID)
+	if err == client.ErrUnknownAllocation {
+		return nil, nil
+	}
+	return ad, err
+}
+
+// fsForward returns the error of a request or, if there is none, forwards it
+// to the client running the allocation.
+func (s *HTTPServer) fsForward(resp http.ResponseWriter, req *http.Request, allocID string, err error) error {
+	if err != nil {
+		return err
+	}
+	return s.forwardClientRequest(resp, req, allocID)
+}
+
+// readFile writes the contents of a file of an allocation to the response
+func (s *HTTPServer) readFile(resp http.ResponseWriter, ad *allocdir.AllocDir, path string, offset, limit int64) error {
+	if err := checkRegularFile(ad, path); err != nil {
+		return err
+	}
+	r, err := ad.ReadAt(path, offset, limit)
+	if err != nil {
+		return fsError(err)
+	}
+	defer r.Close()
+
+	if _, err := io.Copy(resp, r); err != nil {
+		s.logger.Printf("[ERR] http: failed to read file %q: %v", path, err)
+	}
+	return nil
+}
+
+// checkRegularFile returns an error if the path is a directory
+func checkRegularFile(ad *allocdir.AllocDir, path string) error {
+	info, err := ad.Stat(path)
+	if err != nil {
+		return fsError(err)
+	}
+	if info.IsDir {
+		return CodedError(400, fmt.Sprintf("%q is a directory", path))
+	}
+	return nil
+}
+
+// fsError converts the error of a file operation to the HTTP error
+func fsError(err error) error {
+	switch {
+	case err == allocdir.ErrInvalidPath:
+		return CodedError(400, err.Error())
+	case os.IsNotExist(err):
+		return CodedError(404, "file not found")
+	default:
+		return err
+	}
+}
+
+// parseInt64Param parses an optional non-negative integer query parameter
+func parseInt64Param(query url.Values, name string) (int64, error) {
+	v := query.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || i < 0 {
+		return 0, CodedError(400, fmt.Sprintf("Invalid %s %q", name, v))
+	}
+	return i, nil
+}
+
+// Logs streams the stdout or stderr logs of a task. Requests for allocations
+// that don't run on this agent's client are forwarded to the client running
+// the allocation.
+func (s *HTTPServer) Logs(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	if allocID == "" {
+		return nil, CodedError(400, "Must provide an allocation ID")
+	}
+
+	query := req.URL.Query()
+	task := query.Get("task")
+	if task == "" {
+		return nil, CodedError(400, "Must provide a task name")
+	}
+	logType := query.Get("type")
+	if logType == "" {
+		logType = logging.Stdout
+	}
+	origin := query.Get("origin")
+	if origin == "" {
+		origin = logging.OriginStart
+	}
+	if origin != logging.OriginStart && origin != logging.OriginEnd {
+		return nil, CodedError(400, fmt.Sprintf("Invalid origin %q", origin))
+	}
+
+	var follow bool
+	if f := query.Get("follow"); f != "" {
+		var err error
+		if follow, err = strconv.ParseBool(f); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Invalid follow value %q", f))
+		}
+	}
+	offset, err := parseInt64Param(query, "offset")
+	if err != nil {
+		return nil, err
+	}
+	var lines int
+	if l := query.Get("lines"); l != "" {
+		var err error
+		if lines, err = strconv.Atoi(l); err != nil || lines < 0 {
+			return nil, CodedError(400, fmt.Sprintf("Invalid number of lines %q", l))
+		}
+	}
+
+	if c := s.agent.Client(); c != nil {
+		path, err := c.TaskLogPath(allocID, task, logType)
+		if err == nil {
+			return nil, s.streamLogs(resp, req, path, origin, offset, lines, follow)
+		}
+		if err != client.ErrUnknownAllocation {
+			return nil, CodedError(400, err.Error())
+		}
+	}
+	return nil, s.forwardClientRequest(resp, req, allocID)
+}
+
+// streamLogs writes the logs at the path to the response, flushing as it
+// goes. If lines is set, the logs start at the last lines rather than the
+// offset.
+func (s *HTTPServer) streamLogs(resp http.ResponseWriter, req *http.Request, path, origin string,
+	offset int64, lines int, follow bool) error {
+	if lines > 0 {
+		var err error
+		if offset, err = logging.TailOffset(path, lines); err != nil {
+			return err
+		}
+		origin = logging.OriginEnd
+	}
+
+	r, err := logging.NewLogReader(path, origin, offset, follow)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// Stop following the logs once the client goes away
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	if notifier, ok := resp.(http.CloseNotifier); ok {
+		closeCh := notifier.CloseNotify()
+		go func() {
+			select {
+			case <-closeCh:
+				r.Close()
+			case <-doneCh:
+			}
+		}()
+	}
+
+	resp.Header().Set("Content-Type", "text/plain")
+	flusher, _ := resp.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, err := resp.Write(buf[:n]); err != nil {
+				return nil
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// The response is already being written so the error can only
+			// be logged
+			s.logger.Printf("[ERR] http: failed to read logs %q: %v", path, err)
+			return nil
+		}
+	}
+}
+
+// forwardClientRequest proxies the request to the HTTP API of the client running
+// the allocation.
+func (s *HTTPServer) forwardClientRequest(resp http.ResponseWriter, req *http.Request, allocID string) error {
+	allocArgs := structs.AllocSpecificRequest{
+		AllocID: allocID,
+	}
+	s.parseRegion(req, &allocArgs.Region)
+	var allocOut structs.SingleAllocResponse
+	if err := s.agent.RPC("Alloc.GetAlloc", &allocArgs, &allocOut); err != nil {
+		return err
+	}
+	if allocOut.Alloc == nil {
+		return CodedError(404, "alloc not found")
+	}
+
+	// The client running the allocation doesn't forward the request again
+	nodeID := allocOut.Alloc.NodeID
+	if c := s.agent.Client(); c != nil && c.Node().ID == nodeID {
+		return CodedError(404, fmt.Sprintf("alloc %q is not running on node %q", allocID, nodeID))
+	}
+	return s.forwardToNode(resp, req, nodeID)
+}
+
+// forwardToNode proxies the request to the HTTP API of the client of a node.
+func (s *HTTPServer) forwardToNode(resp http.ResponseWriter, req *http.Request, nodeID string) error {
+	nodeArgs := structs.NodeSpecificRequest{
+		NodeID: nodeID,
+	}
+	s.parseRegion(req, &nodeArgs.Region)
+	var nodeOut structs.SingleNodeResponse
+	if err := s.agent.RPC("Node.GetNode", &nodeArgs, &nodeOut); err != nil {
+		return err
+	}
+	if nodeOut.Node == nil {
+		return CodedError(404, fmt.Sprintf("node %q not found", nodeID))
+	}
+	if nodeOut.Node.HTTPAddr == "" {
+		return CodedError(500, fmt.Sprintf("node %q has no HTTP address", nodeID))
+	}
+
+	s.logger.Printf("[DEBUG] http: forwarding %v to node %q at %s", req.URL.Path, nodeID, nodeOut.Node.HTTPAddr)
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{
+		Scheme: "http",
+		Host:   nodeOut.Node.HTTPAddr,
+	})
+	proxy.FlushInterval = fsForwardFlushInterval
+	proxy.ServeHTTP(resp, req)
+	return nil
+}
@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_FsLogs_BadRequest(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		paths := []string{
+			"/v1/client/fs/logs/",
+			"/v1/client/fs/logs/foo",
+			"/v1/client/fs/logs/foo?task=web&origin=middle",
+			"/v1/client/fs/logs/foo?task=web&offset=-1",
+			"/v1/client/fs/logs/foo?task=web&follow=maybe",
+		}
+		for _, path := range paths {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			respW := httptest.NewRecorder()
+
+			_, err = s.Server.FsRequest(respW, req)
+			coded, ok := err.(HTTPCodedError)
+			if !ok || coded.Code() != 400 {
+				t.Fatalf("%s: err: %v", path, err)
+			}
+		}
+	})
+}
+
+func TestHTTP_FsLogs_UnknownAlloc(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/client/fs/logs/foo?task=web", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		_, err = s.Server.FsRequest(respW, req)
+		coded, ok := err.(HTTPCodedError)
+		if !ok || coded.Code() != 404 {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_FsLogs_Forward(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Fake the HTTP API of the client running the allocation
+		var forwarded string
+		client := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			forwarded = req.URL.String()
+			w.Write([]byte("hello world\n"))
+		}))
+		defer client.Close()
+
+		state := s.Agent.server.State()
+		node := mock.Node()
+		node.HTTPAddr = strings.TrimPrefix(client.URL, "http://")
+		if err := state.UpsertNode(1000, node); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		alloc := mock.Alloc()
+		alloc.NodeID = node.ID
+		if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		path := "/v1/client/fs/logs/" + alloc.ID + "?task=web&type=stderr"
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		if _, err := s.Server.FsRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if forwarded != path {
+			t.Fatalf("bad: %q", forwarded)
+		}
+		if respW.Body.String() != "hello world\n" {
+			t.Fatalf("bad: %q", respW.Body.String())
+		}
+	})
+}
+
+func TestHTTP_Fs_BadRequest(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		paths := []string{
+			"/v1/client/fs/ls/",
+			"/v1/client/fs/stat/foo",
+			"/v1/client/fs/cat/foo",
+			"/v1/client/fs/readat/",
+			"/v1/client/fs/stream/foo",
+		}
+		for _, path := range paths {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			respW := httptest.NewRecorder()
+
+			_, err = s.Server.FsRequest(respW, req)
+			coded, ok := err.(HTTPCodedError)
+			if !ok || coded.Code() != 400 {
+				t.Fatalf("%s: err: %v", path, err)
+			}
+		}
+	})
+}
+
+func TestHTTP_Fs_UnknownAlloc(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		paths := []string{
+			"/v1/client/fs/ls/foo",
+			"/v1/client/fs/stat/foo?path=alloc",
+			"/v1/client/fs/cat/foo?path=alloc/logs/web.stdout.0",
+			"/v1/client/fs/readat/foo?path=alloc/logs/web.stdout.0&offset=1&limit=2",
+			"/v1/client/fs/stream/foo?path=alloc/logs/web.stdout.0",
+		}
+		for _, path := range paths {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			respW := httptest.NewRecorder()
+
+			_, err = s.Server.FsRequest(respW, req)
+			coded, ok := err.(HTTPCodedError)
+			if !ok || coded.Code() != 404 {
+				t.Fatalf("%s: err: %v", path, err)
+			}
+		}
+	})
+}
@@ -292,6 +292,45 @@ func TestHTTP_JobForceEvaluate(t *testing.T) {
 	})
 }
 
+func TestHTTP_PeriodicForce(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create and register a periodic job.
+		job := mock.PeriodicJob()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("POST", "/v1/job/"+job.ID+"/periodic/force", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the response
+		r := obj.(structs.PeriodicForceResponse)
+		if r.EvalID == "" {
+			t.Fatalf("bad: %#v", r)
+		}
+	})
+}
+
 func TestHTTP_JobEvaluations(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Create the job
@@ -390,3 +429,198 @@ func TestHTTP_JobAllocations(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_JobDispatch(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the parameterized job
+		job := mock.ParameterizedJob()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the request
+		args2 := structs.JobDispatchRequest{
+			Payload:      []byte("hello world"),
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args2)
+
+		// Make the HTTP request
+		req2, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/dispatch", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req2)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		dispatch := obj.(structs.JobDispatchResponse)
+		if dispatch.EvalID == "" {
+			t.Fatalf("bad: %v", dispatch)
+		}
+
+		if dispatch.DispatchedJobID == "" {
+			t.Fatalf("bad: %v", dispatch)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+	})
+}
+
+func TestHTTP_JobVersions(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job
+		job := mock.Job()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Update the job to create a second version
+		job2 := job.Copy()
+		job2.Priority = 100
+		args2 := structs.JobRegisterRequest{
+			Job:          job2,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		if err := s.Agent.RPC("Job.Register", &args2, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/job/"+job.ID+"/versions", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		versions := obj.([]*structs.Job)
+		if len(versions) != 2 {
+			t.Fatalf("got %d versions; want 2", len(versions))
+		}
+		if v := versions[0]; v.Version != 1 || v.Priority != 100 {
+			t.Fatalf("bad %v", v)
+		}
+		if v := versions[1]; v.Version != 0 {
+			t.Fatalf("bad %v", v)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+	})
+}
+
+func TestHTTP_JobRevert(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job and register it twice
+		job := mock.Job()
+		regReq := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var regResp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &regReq, &regResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		job2 := job.Copy()
+		job2.Priority = 100
+		regReq.Job = job2
+		if err := s.Agent.RPC("Job.Register", &regReq, &regResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		args := structs.JobRevertRequest{
+			JobID:        job.ID,
+			JobVersion:   0,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args)
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/revert", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		revertResp := obj.(structs.JobRegisterResponse)
+		if revertResp.EvalID == "" {
+			t.Fatalf("bad: %v", revertResp)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+	})
+}
+
+func TestHTTP_JobPlan(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job
+		job := mock.Job()
+		args := structs.JobPlanRequest{
+			Job:          job,
+			Diff:         true,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args)
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/plan", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		plan := obj.(structs.JobPlanResponse)
+		if plan.Annotations == nil {
+			t.Fatalf("bad: %v", plan)
+		}
+
+		if plan.Diff == nil {
+			t.Fatalf("bad: %v", plan)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -42,6 +43,9 @@ func (s *HTTPServer) NodeSpecificRequest(resp http.ResponseWriter, req *http.Req
 	case strings.HasSuffix(path, "/drain"):
 		nodeName := strings.TrimSuffix(path, "/drain")
 		return s.nodeToggleDrain(resp, req, nodeName)
+	case strings.HasSuffix(path, "/eligibility"):
+		nodeName := strings.TrimSuffix(path, "/eligibility")
+		return s.nodeToggleEligibility(resp, req, nodeName)
 	default:
 		return s.nodeQuery(resp, req, path)
 	}
@@ -105,10 +109,26 @@ func (s *HTTPServer) nodeToggleDrain(resp http.ResponseWriter, req *http.Request
 		return nil, CodedError(400, "invalid enable value")
 	}
 
+	// Get the optional deadline and whether to ignore system jobs
+	var spec structs.DrainSpec
+	if deadlineRaw := req.URL.Query().Get("deadline"); deadlineRaw != "" {
+		if spec.Deadline, err = time.ParseDuration(deadlineRaw); err != nil {
+			return nil, CodedError(400, "invalid deadline value")
+		}
+	}
+	if ignoreRaw := req.URL.Query().Get("ignore_system_jobs"); ignoreRaw != "" {
+		if spec.IgnoreSystemJobs, err = strconv.ParseBool(ignoreRaw); err != nil {
+			return nil, CodedError(400, "invalid ignore_system_jobs value")
+		}
+	}
+
 	args := structs.NodeUpdateDrainRequest{
 		NodeID: nodeID,
 		Drain:  enable,
 	}
+	if enable {
+		args.DrainStrategy = &structs.DrainStrategy{DrainSpec: spec}
+	}
 	s.parseRegion(req, &args.Region)
 
 	var out structs.NodeDrainUpdateResponse
@@ -119,6 +139,39 @@ func (s *HTTPServer) nodeToggleDrain(resp http.ResponseWriter, req *http.Request
 	return out, nil
 }
 
+func (s *HTTPServer) nodeToggleEligibility(resp http.ResponseWriter, req *http.Request,
+	nodeID string) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	// Get the enable value
+	enableRaw := req.URL.Query().Get("enable")
+	if enableRaw == "" {
+		return nil, CodedError(400, "missing enable value")
+	}
+	enable, err := strconv.ParseBool(enableRaw)
+	if err != nil {
+		return nil, CodedError(400, "invalid enable value")
+	}
+
+	args := structs.NodeUpdateEligibilityRequest{
+		NodeID:      nodeID,
+		Eligibility: structs.NodeSchedulingIneligible,
+	}
+	if enable {
+		args.Eligibility = structs.NodeSchedulingEligible
+	}
+	s.parseRegion(req, &args.Region)
+
+	var out structs.NodeEligibilityUpdateResponse
+	if err := s.agent.RPC("Node.UpdateEligibility", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
 func (s *HTTPServer) nodeQuery(resp http.ResponseWriter, req *http.Request,
 	nodeID string) (interface{}, error) {
 	if req.Method != "GET" {
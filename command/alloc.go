@@ -0,0 +1,34 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type AllocCommand struct {
+	Meta
+}
+
+func (c *AllocCommand) Help() string {
+	helpText := `
+Usage: nomad alloc <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with allocations.
+
+  Run a command in the environment of a task of an allocation:
+
+      $ nomad alloc exec <allocation> <command>
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocCommand) Synopsis() string {
+	return "Interact with allocations"
+}
+
+func (c *AllocCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
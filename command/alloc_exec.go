@@ -0,0 +1,144 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type AllocExecCommand struct {
+	Meta
+}
+
+func (c *AllocExecCommand) Help() string {
+	helpText := `
+Usage: nomad alloc exec [options] <allocation> <command> [<args>...]
+
+  Run a command in the environment of a task of an allocation, for example to
+  start an interactive shell for debugging. The command is isolated like the
+  task, sharing its chroot, cgroup and environment variables, depending on the
+  task's driver. Input is forwarded to the command until it exits and the exit
+  code of the command is returned.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Exec Options:
+
+  -task <task>
+    Sets the task to run the command for. The task may be omitted if the task
+    group of the allocation has a single task.
+
+  -t
+    Attaches the command to a terminal. Enabled by default if the input is a
+    terminal.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocExecCommand) Synopsis() string {
+	return "Run a command in the environment of a task"
+}
+
+func (c *AllocExecCommand) Run(args []string) int {
+	var task string
+	var tty bool
+
+	flags := c.Meta.FlagSet("alloc exec", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&task, "task", "", "")
+	flags.BoolVar(&tty, "t", isTerminal(os.Stdin.Fd()), "")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got the allocation ID and a command
+	args = flags.Args()
+	if len(args) < 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	allocID := args[0]
+	command := args[1:]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Query the allocation info
+	alloc, _, err := client.Allocations().Info(allocID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying allocation: %s", err))
+		return 1
+	}
+
+	if task == "" {
+		if task, err = allocTask(alloc); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	opts := &api.ExecOptions{
+		Task:    task,
+		Command: command,
+		Tty:     tty,
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	}
+
+	// Pass the keys typed in the local terminal through to the remote one and
+	// keep its size in sync
+	restore := func() {}
+	if tty && isTerminal(os.Stdin.Fd()) {
+		if restore, err = makeRawTerminal(os.Stdin.Fd()); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error configuring terminal: %s", err))
+			return 1
+		}
+
+		resizeCh := make(chan api.TerminalSize, 1)
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		go watchTerminalSize(os.Stdin.Fd(), resizeCh, stopCh)
+		opts.ResizeCh = resizeCh
+	}
+
+	code, err := client.Allocations().Exec(alloc.ID, opts, nil)
+	restore()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error running command: %s", err))
+		return 1
+	}
+	return code
+}
+
+// watchTerminalSize sends the size of the terminal and then sends it again
+// each time it changes until stopped
+func watchTerminalSize(fd uintptr, resizeCh chan<- api.TerminalSize, stopCh <-chan struct{}) {
+	signalCh := make(chan os.Signal, 1)
+	notifyTerminalResize(signalCh)
+	defer stopTerminalResize(signalCh)
+
+	for {
+		if size, err := terminalSize(fd); err == nil {
+			select {
+			case resizeCh <- size:
+			case <-stopCh:
+				return
+			}
+		}
+
+		select {
+		case <-signalCh:
+		case <-stopCh:
+			return
+		}
+	}
+}
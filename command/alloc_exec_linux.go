@@ -0,0 +1,70 @@
+package command
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// isTerminal returns whether the file descriptor is a terminal
+func isTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	return termiosIoctl(fd, syscall.TCGETS, &termios) == nil
+}
+
+// makeRawTerminal puts the terminal in raw mode so that input is passed through
+// unprocessed, returning a function restoring its previous mode
+func makeRawTerminal(fd uintptr) (func(), error) {
+	var old syscall.Termios
+	if err := termiosIoctl(fd, syscall.TCGETS, &old); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := termiosIoctl(fd, syscall.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		termiosIoctl(fd, syscall.TCSETS, &old)
+	}, nil
+}
+
+// terminalSize returns the size of the terminal
+func terminalSize(fd uintptr) (api.TerminalSize, error) {
+	ws := struct {
+		Row, Col, X, Y uint16
+	}{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); errno != 0 {
+		return api.TerminalSize{}, errno
+	}
+	return api.TerminalSize{Height: ws.Row, Width: ws.Col}, nil
+}
+
+// notifyTerminalResize relays the signal sent when the terminal is resized
+func notifyTerminalResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
+
+// stopTerminalResize stops relaying the resize signal
+func stopTerminalResize(ch chan<- os.Signal) {
+	signal.Stop(ch)
+}
+
+func termiosIoctl(fd, req uintptr, termios *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+// +build !linux
+
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// isTerminal returns false as terminals are only detected on Linux
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+// makeRawTerminal fails as terminals are only supported on Linux
+func makeRawTerminal(fd uintptr) (func(), error) {
+	return nil, fmt.Errorf("terminals are not supported on this platform")
+}
+
+// terminalSize fails as terminals are only supported on Linux
+func terminalSize(fd uintptr) (api.TerminalSize, error) {
+	return api.TerminalSize{}, fmt.Errorf("terminals are not supported on this platform")
+}
+
+func notifyTerminalResize(ch chan<- os.Signal) {}
+
+func stopTerminalResize(ch chan<- os.Signal) {}
@@ -2,7 +2,11 @@ package command
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
 )
 
 type AllocStatusCommand struct {
@@ -15,12 +19,20 @@ Usage: nomad alloc-status [options] <allocation>
 
   Display information about existing allocations. This command can
   be used to inspect the current status of all allocation,
-  including its running status, metadata, and verbose failure
-  messages reported by internal subsystems.
+  including its running status, the state and recent events of
+  its tasks, metadata, and verbose failure messages reported by
+  internal subsystems.
 
 General Options:
 
-  ` + generalOptionsUsage()
+  ` + generalOptionsUsage() + `
+
+Alloc Status Options:
+
+  -stats
+    Display the latest resource usage of the tasks of the allocation, as
+    measured by the client running it.
+`
 	return strings.TrimSpace(helpText)
 }
 
@@ -29,8 +41,11 @@ func (c *AllocStatusCommand) Synopsis() string {
 }
 
 func (c *AllocStatusCommand) Run(args []string) int {
+	var stats bool
+
 	flags := c.Meta.FlagSet("alloc-status", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&stats, "stats", false, "")
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
@@ -74,9 +89,139 @@ func (c *AllocStatusCommand) Run(args []string) int {
 	}
 	c.Ui.Output(formatKV(basic))
 
+	// Format the task states
+	if len(alloc.TaskStates) > 0 {
+		c.Ui.Output("\n==> Task States")
+		c.taskStates(alloc)
+	}
+
+	// Format the resource usage
+	if stats {
+		usage, _, err := client.Allocations().Stats(alloc.ID, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying allocation stats: %s", err))
+			return 1
+		}
+		c.Ui.Output("\n==> Resource Usage")
+		c.Ui.Output(formatResourceUsage(alloc, usage))
+	}
+
 	// Format the detailed status
 	c.Ui.Output("\n==> Status")
 	dumpAllocStatus(c.Ui, alloc)
 
 	return 0
 }
+
+// taskStates prints the state of each task of the allocation followed by its
+// most recent events.
+func (c *AllocStatusCommand) taskStates(alloc *api.Allocation) {
+	names := make([]string, 0, len(alloc.TaskStates))
+	for name := range alloc.TaskStates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	states := make([]string, 0, len(names)+1)
+	states = append(states, "Name|State|Failed|Last Event")
+	for _, name := range names {
+		state := alloc.TaskStates[name]
+		lastEvent := ""
+		if len(state.Events) > 0 {
+			lastEvent = state.Events[len(state.Events)-1].Type
+		}
+		states = append(states, fmt.Sprintf("%s|%s|%v|%s", name, state.State, state.Failed, lastEvent))
+	}
+	c.Ui.Output(formatList(states))
+
+	for _, name := range names {
+		state := alloc.TaskStates[name]
+		if len(state.Events) == 0 {
+			continue
+		}
+
+		// Print the most recent events first
+		events := make([]string, 0, len(state.Events)+1)
+		events = append(events, "Time|Type|Description")
+		for i := len(state.Events) - 1; i >= 0; i-- {
+			event := state.Events[i]
+			formatted := time.Unix(0, event.Time).Format("01/02/06 15:04:05 MST")
+			events = append(events, fmt.Sprintf("%s|%s|%s", formatted, event.Type, taskEventDesc(event)))
+		}
+		c.Ui.Output(fmt.Sprintf("\n==> Recent Events of Task %q", name))
+		c.Ui.Output(formatList(events))
+	}
+}
+
+// formatResourceUsage formats the resource usage of each running task of the
+// allocation next to the resources reserved for it
+func formatResourceUsage(alloc *api.Allocation, usage *api.AllocResourceUsage) string {
+	names := make([]string, 0, len(usage.Tasks))
+	for name := range usage.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([]string, 0, len(names)+1)
+	rows = append(rows, "Task|CPU|Memory|Cache|Swap|Throttled Periods|Throttled Time")
+	for _, name := range names {
+		res := usage.Tasks[name].ResourceUsage
+		if res == nil || res.CpuStats == nil || res.MemoryStats == nil {
+			continue
+		}
+		cpu, mem := res.CpuStats, res.MemoryStats
+
+		cpuUsage := fmt.Sprintf("%.2f%% (%.0f MHz)", cpu.Percent, cpu.TotalTicks)
+		memUsage := formatBytes(mem.RSS)
+		if reserved, ok := alloc.TaskResources[name]; ok && reserved != nil {
+			cpuUsage = fmt.Sprintf("%.0f/%d MHz (%.2f%%)", cpu.TotalTicks, reserved.CPU, cpu.Percent)
+			memUsage = fmt.Sprintf("%s/%s", formatBytes(mem.RSS),
+				formatBytes(uint64(reserved.MemoryMB)*1024*1024))
+		}
+		rows = append(rows, fmt.Sprintf("%s|%s|%s|%s|%s|%d|%v", name, cpuUsage, memUsage,
+			formatBytes(mem.Cache), formatBytes(mem.Swap), cpu.ThrottledPeriods,
+			time.Duration(cpu.ThrottledTime)))
+	}
+	return formatList(rows)
+}
+
+// taskEventDesc returns a human readable description of a task event
+func taskEventDesc(event *api.TaskEvent) string {
+	switch event.Type {
+	case api.TaskDriverFailure:
+		if event.DriverError != "" {
+			return event.DriverError
+		}
+		return "Failed to start task"
+	case api.TaskStarted:
+		return "Task started by client"
+	case api.TaskTerminated:
+		parts := []string{fmt.Sprintf("Exit Code: %d", event.ExitCode)}
+		if event.Signal != 0 {
+			parts = append(parts, fmt.Sprintf("Signal: %d", event.Signal))
+		}
+		if event.Message != "" {
+			parts = append(parts, fmt.Sprintf("Exit Message: %q", event.Message))
+		}
+		return strings.Join(parts, ", ")
+	case api.TaskKilled:
+		if event.KillError != "" {
+			return event.KillError
+		}
+		return "Task successfully killed"
+	case api.TaskRestarting:
+		if event.StartDelay > 0 {
+			return fmt.Sprintf("Task restarting in %v", time.Duration(event.StartDelay))
+		}
+		return "Task restarting"
+	case api.TaskNotRestarting:
+		return "Task failed and is not being restarted"
+	case api.TaskDownloadingArtifacts:
+		return "Client is downloading artifacts"
+	case api.TaskArtifactDownloadFailed:
+		return event.DownloadError
+	case api.TaskSetupFailure:
+		return event.SetupError
+	}
+	return ""
+}
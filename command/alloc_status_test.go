@@ -3,7 +3,9 @@ package command
 import (
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -43,3 +45,63 @@ func TestAllocStatusCommand_Fails(t *testing.T) {
 		t.Fatalf("expected not found error, got: %s", out)
 	}
 }
+
+func TestAllocStatusCommand_TaskEventDesc(t *testing.T) {
+	cases := []struct {
+		event    *api.TaskEvent
+		expected string
+	}{
+		{
+			&api.TaskEvent{Type: api.TaskTerminated, ExitCode: 1},
+			"Exit Code: 1",
+		},
+		{
+			&api.TaskEvent{Type: api.TaskTerminated, ExitCode: -1, Signal: 9, Message: "killed"},
+			`Exit Code: -1, Signal: 9, Exit Message: "killed"`,
+		},
+		{
+			&api.TaskEvent{Type: api.TaskRestarting, StartDelay: int64(15 * time.Second)},
+			"Task restarting in 15s",
+		},
+		{
+			&api.TaskEvent{Type: api.TaskDriverFailure, DriverError: "missing driver"},
+			"missing driver",
+		},
+		{
+			&api.TaskEvent{Type: api.TaskKilled},
+			"Task successfully killed",
+		},
+	}
+
+	for i, c := range cases {
+		if desc := taskEventDesc(c.event); desc != c.expected {
+			t.Fatalf("case %d: got %q; want %q", i, desc, c.expected)
+		}
+	}
+}
+
+func TestAllocStatusCommand_FormatResourceUsage(t *testing.T) {
+	alloc := &api.Allocation{
+		TaskResources: map[string]*api.Resources{
+			"web": &api.Resources{CPU: 500, MemoryMB: 256},
+		},
+	}
+	usage := &api.AllocResourceUsage{
+		Tasks: map[string]*api.TaskResourceUsage{
+			"web": &api.TaskResourceUsage{
+				ResourceUsage: &api.ResourceUsage{
+					CpuStats:    &api.CpuStats{Percent: 12.5, TotalTicks: 300},
+					MemoryStats: &api.MemoryStats{RSS: 64 * 1024 * 1024},
+				},
+			},
+		},
+	}
+
+	out := formatResourceUsage(alloc, usage)
+	if !strings.Contains(out, "300/500 MHz (12.50%)") {
+		t.Fatalf("expected cpu usage, got: %s", out)
+	}
+	if !strings.Contains(out, "64.0 MiB/256.0 MiB") {
+		t.Fatalf("expected memory usage, got: %s", out)
+	}
+}
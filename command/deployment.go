@@ -0,0 +1,48 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type DeploymentCommand struct {
+	Meta
+}
+
+func (c *DeploymentCommand) Help() string {
+	helpText := `
+Usage: nomad deployment <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with deployments. Deployments
+  are created when a job using canaries is updated and track the rollout of
+  the new version of the job.
+
+  List the deployments:
+
+      $ nomad deployment list
+
+  Display the status of a deployment:
+
+      $ nomad deployment status <deployment>
+
+  Promote the canaries of a deployment:
+
+      $ nomad deployment promote <deployment>
+
+  Mark a deployment as failed:
+
+      $ nomad deployment fail <deployment>
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentCommand) Synopsis() string {
+	return "Interact with deployments"
+}
+
+func (c *DeploymentCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
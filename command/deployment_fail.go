@@ -0,0 +1,81 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type DeploymentFailCommand struct {
+	Meta
+}
+
+func (c *DeploymentFailCommand) Help() string {
+	helpText := `
+Usage: nomad deployment fail [options] <deployment>
+
+  Fail is used to mark a deployment as failed. Failing a deployment stops its
+  canaries that have not been promoted and halts the rollout of the new version
+  of the job.
+
+  Upon successfully failing the deployment, an evaluation will be created and
+  monitored. This can be disabled by supplying the detach flag.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Fail Options:
+
+  -detach
+    Return immediately instead of entering monitor mode. After the deployment
+    is failed, the evaluation ID will be printed to the screen, which can be
+    used to examine the evaluation using the eval-monitor command.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentFailCommand) Synopsis() string {
+	return "Manually fail a deployment"
+}
+
+func (c *DeploymentFailCommand) Run(args []string) int {
+	var detach bool
+
+	flags := c.Meta.FlagSet("deployment fail", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one deployment
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	deployID := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fail the deployment
+	resp, _, err := client.Deployments().Fail(deployID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error failing deployment: %s", err))
+		return 1
+	}
+
+	if detach {
+		c.Ui.Output("Evaluation ID: " + resp.EvalID)
+		return 0
+	}
+
+	mon := newMonitor(c.Ui, client)
+	return mon.monitor(resp.EvalID)
+}
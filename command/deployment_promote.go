@@ -0,0 +1,96 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper/flag-slice"
+)
+
+type DeploymentPromoteCommand struct {
+	Meta
+}
+
+func (c *DeploymentPromoteCommand) Help() string {
+	helpText := `
+Usage: nomad deployment promote [options] <deployment>
+
+  Promote is used to promote the canaries of a deployment. Once promoted, the
+  remaining allocations of the task group are replaced by the new version of
+  the job, following the job's update strategy. By default all task groups are
+  promoted.
+
+  Upon successful promotion, an evaluation will be created and monitored. This
+  can be disabled by supplying the detach flag.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Promote Options:
+
+  -group
+    Group may be specified many times and is used to promote only the canaries
+    of the given task groups.
+
+  -detach
+    Return immediately instead of entering monitor mode. After promotion,
+    the evaluation ID will be printed to the screen, which can be used to
+    examine the evaluation using the eval-monitor command.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentPromoteCommand) Synopsis() string {
+	return "Promote the canaries of a deployment"
+}
+
+func (c *DeploymentPromoteCommand) Run(args []string) int {
+	var detach bool
+	var groups []string
+
+	flags := c.Meta.FlagSet("deployment promote", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.Var((*sliceflag.StringFlag)(&groups), "group", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one deployment
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	deployID := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Promote the deployment
+	var resp *api.DeploymentUpdateResponse
+	if len(groups) == 0 {
+		resp, _, err = client.Deployments().PromoteAll(deployID, nil)
+	} else {
+		resp, _, err = client.Deployments().PromoteGroups(deployID, groups, nil)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error promoting deployment: %s", err))
+		return 1
+	}
+
+	if detach {
+		c.Ui.Output("Evaluation ID: " + resp.EvalID)
+		return 0
+	}
+
+	mon := newMonitor(c.Ui, client)
+	return mon.monitor(resp.EvalID)
+}
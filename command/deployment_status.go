@@ -0,0 +1,106 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type DeploymentStatusCommand struct {
+	Meta
+}
+
+func (c *DeploymentStatusCommand) Help() string {
+	helpText := `
+Usage: nomad deployment status [options] <deployment>
+
+  Status is used to display the status of a deployment. The status includes
+  the number of canaries and allocations placed for each task group and
+  whether the task group has been promoted.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentStatusCommand) Synopsis() string {
+	return "Display the status of a deployment"
+}
+
+func (c *DeploymentStatusCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("deployment status", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one deployment
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	deployID := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	deploy, _, err := client.Deployments().Info(deployID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving deployment: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(formatDeployment(deploy))
+	return 0
+}
+
+// formatDeployment returns the status of the deployment and a table of its
+// task groups.
+func formatDeployment(d *api.Deployment) string {
+	basic := []string{
+		fmt.Sprintf("ID|%s", d.ID),
+		fmt.Sprintf("Job ID|%s", d.JobID),
+		fmt.Sprintf("Job Version|%d", d.JobVersion),
+		fmt.Sprintf("Status|%s", d.Status),
+		fmt.Sprintf("Description|%s", d.StatusDescription),
+	}
+	out := formatKV(basic)
+
+	if len(d.TaskGroups) == 0 {
+		return out
+	}
+
+	// Sort the task groups so the output is stable
+	names := make([]string, 0, len(d.TaskGroups))
+	for name := range d.TaskGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([]string, len(names)+1)
+	rows[0] = "Task Group|Auto Revert|Promoted|Desired Canaries|Placed Canaries|Desired|Placed|Healthy|Unhealthy"
+	for i, name := range names {
+		state := d.TaskGroups[name]
+		rows[i+1] = fmt.Sprintf("%s|%v|%v|%d|%d|%d|%d|%d|%d",
+			name,
+			state.AutoRevert,
+			state.Promoted,
+			state.DesiredCanaries,
+			len(state.PlacedCanaries),
+			state.DesiredTotal,
+			state.PlacedAllocs,
+			state.HealthyAllocs,
+			state.UnhealthyAllocs)
+	}
+
+	return fmt.Sprintf("%s\n\n==> Deployed\n%s", out, formatList(rows))
+}
@@ -0,0 +1,166 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type FSCommand struct {
+	Meta
+}
+
+func (c *FSCommand) Help() string {
+	helpText := `
+Usage: nomad fs [options] <allocation> [<path>]
+
+  Inspect the files of an allocation on the client running it. Directories
+  are listed and the contents of files are displayed. The path is relative to
+  the allocation directory and defaults to its root.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+FS Options:
+
+  -stat
+    Display information about the file at the path instead of its contents.
+
+  -f
+    Follow the file, displaying new data as it is appended until interrupted.
+
+  -offset <bytes>
+    Start reading the file at the byte offset.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *FSCommand) Synopsis() string {
+	return "Inspect the files of an allocation"
+}
+
+func (c *FSCommand) Run(args []string) int {
+	var stat, follow bool
+	var offset int64
+
+	flags := c.Meta.FlagSet("fs", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&stat, "stat", false, "")
+	flags.BoolVar(&follow, "f", false, "")
+	flags.Int64Var(&offset, "offset", 0, "")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got the allocation ID and optionally a path
+	args = flags.Args()
+	if len(args) < 1 || len(args) > 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	allocID := args[0]
+	path := "/"
+	if len(args) == 2 {
+		path = args[1]
+	}
+
+	if offset < 0 {
+		c.Ui.Error("The -offset flag must not be negative")
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Query the allocation info
+	alloc, _, err := client.Allocations().Info(allocID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying allocation: %s", err))
+		return 1
+	}
+
+	fs := client.AllocFS()
+	info, _, err := fs.Stat(alloc.ID, path, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading file info: %s", err))
+		return 1
+	}
+
+	if stat {
+		c.Ui.Output(formatFileInfos([]*api.AllocFileInfo{info}))
+		return 0
+	}
+
+	if info.IsDir {
+		files, _, err := fs.List(alloc.ID, path, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error listing directory: %s", err))
+			return 1
+		}
+		c.Ui.Output(formatFileInfos(files))
+		return 0
+	}
+
+	var r io.ReadCloser
+	if follow {
+		r, err = fs.Stream(alloc.ID, path, offset, nil)
+	} else {
+		r, err = fs.ReadAt(alloc.ID, path, offset, 0, nil)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading file: %s", err))
+		return 1
+	}
+	defer r.Close()
+
+	// Stop following the file when interrupted
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+	interruptCh := make(chan struct{})
+	go func() {
+		<-signalCh
+		close(interruptCh)
+		r.Close()
+	}()
+
+	if _, err := io.Copy(os.Stdout, r); err != nil {
+		// Closing the file on interrupt ends the copy with an error
+		select {
+		case <-interruptCh:
+			return 0
+		default:
+		}
+		c.Ui.Error(fmt.Sprintf("Error reading file: %s", err))
+		return 1
+	}
+	return 0
+}
+
+// formatFileInfos formats file information as a list
+func formatFileInfos(files []*api.AllocFileInfo) string {
+	out := make([]string, len(files)+1)
+	out[0] = "Mode|Size|Modified Time|Name"
+	for i, file := range files {
+		name := file.Name
+		if file.IsDir {
+			name += "/"
+		}
+		out[i+1] = fmt.Sprintf("%s|%d|%s|%s",
+			file.FileMode,
+			file.Size,
+			file.ModTime.Format("01/02/06 15:04:05 MST"),
+			name)
+	}
+	return formatList(out)
+}
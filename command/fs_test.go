@@ -0,0 +1,82 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestFSCommand_Implements(t *testing.T) {
+	var _ cli.Command = &FSCommand{}
+}
+
+func TestFSCommand_Fails(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &FSCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on a negative offset
+	if code := cmd.Run([]string{"-offset=-1", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "must not be negative") {
+		t.Fatalf("expected flag error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying allocation") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on missing alloc
+	if code := cmd.Run([]string{"-address=" + url, "26470238-5CF2-438F-8772-DC67CFB0705C"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "not found") {
+		t.Fatalf("expected not found error, got: %s", out)
+	}
+}
+
+func TestFSCommand_FormatFileInfos(t *testing.T) {
+	files := []*api.AllocFileInfo{
+		&api.AllocFileInfo{
+			Name:     "alloc",
+			IsDir:    true,
+			Size:     4096,
+			FileMode: "drwxrwxrwx",
+			ModTime:  time.Now(),
+		},
+		&api.AllocFileInfo{
+			Name:     "web.stdout.0",
+			Size:     12,
+			FileMode: "-rw-r--r--",
+			ModTime:  time.Now(),
+		},
+	}
+	out := formatFileInfos(files)
+	if !strings.Contains(out, "alloc/") || !strings.Contains(out, "web.stdout.0") {
+		t.Fatalf("bad: %s", out)
+	}
+	if lines := strings.Split(out, "\n"); len(lines) != 3 {
+		t.Fatalf("bad: %s", out)
+	}
+}
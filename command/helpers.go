@@ -1,6 +1,8 @@
 package command
 
 import (
+	"fmt"
+
 	"github.com/ryanuber/columnize"
 )
 
@@ -21,3 +23,19 @@ func formatList(in []string) string {
 	columnConf.Empty = "<none>"
 	return columnize.Format(in, columnConf)
 }
+
+// formatBytes formats a number of bytes using the largest binary unit that
+// keeps the value at least one.
+func formatBytes(b uint64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(b)
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d %s", b, units[0])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
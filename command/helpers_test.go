@@ -27,3 +27,19 @@ func TestHelpers_FormatList(t *testing.T) {
 		t.Fatalf("expect: %s, got: %s", expect, out)
 	}
 }
+
+func TestHelpers_FormatBytes(t *testing.T) {
+	cases := map[uint64]string{
+		0:                      "0 B",
+		1023:                   "1023 B",
+		1024:                   "1.0 KiB",
+		1536:                   "1.5 KiB",
+		256 * 1024 * 1024:      "256.0 MiB",
+		3 * 1024 * 1024 * 1024: "3.0 GiB",
+	}
+	for in, expect := range cases {
+		if out := formatBytes(in); out != expect {
+			t.Fatalf("%d: expect: %s, got: %s", in, expect, out)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type JobCommand struct {
+	Meta
+}
+
+func (c *JobCommand) Help() string {
+	helpText := `
+Usage: nomad job <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with jobs.
+
+  Dispatch an instance of a parameterized job:
+
+      $ nomad job dispatch <parameterized job> [input source]
+
+  Display the version history of a job:
+
+      $ nomad job history <job>
+
+  Revert a job to a prior version:
+
+      $ nomad job revert <job> <version>
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobCommand) Synopsis() string {
+	return "Interact with jobs"
+}
+
+func (c *JobCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,137 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/helper/flag-slice"
+)
+
+type JobDispatchCommand struct {
+	Meta
+}
+
+func (c *JobDispatchCommand) Help() string {
+	helpText := `
+Usage: nomad job dispatch [options] <parameterized job> [input source]
+
+  Dispatch creates an instance of a parameterized job. A data payload to the
+  dispatched instance can be provided via stdin by using "-" or by specifying
+  a path to a file. Metadata can be supplied by using the meta flag one or more
+  times.
+
+  Upon successful creation, the dispatched job ID will be printed and the
+  triggered evaluation will be monitored. This can be disabled by supplying the
+  detach flag.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Dispatch Options:
+
+  -meta <key>=<value>
+    Meta takes a key/value pair separated by "=". The metadata key will be
+    merged into the job's metadata. The job may define a default value for the
+    key which is overridden when dispatching. The flag can be provided more
+    than once to inject multiple metadata key/value pairs. Arbitrary keys are
+    not allowed. The parameterized job must allow the key to be merged.
+
+  -detach
+    Return immediately instead of entering monitor mode. After job dispatch,
+    the evaluation ID will be printed to the screen, which can be used to
+    examine the evaluation using the eval-monitor command.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobDispatchCommand) Synopsis() string {
+	return "Dispatch an instance of a parameterized job"
+}
+
+func (c *JobDispatchCommand) Run(args []string) int {
+	var detach bool
+	var meta []string
+
+	flags := c.Meta.FlagSet("job dispatch", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.Var((*sliceflag.StringFlag)(&meta), "meta", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one job and optionally an input source
+	args = flags.Args()
+	if l := len(args); l < 1 || l > 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	job := args[0]
+	var payload []byte
+	var readErr error
+
+	// Read the input
+	if len(args) == 2 {
+		switch args[1] {
+		case "-":
+			payload, readErr = ioutil.ReadAll(os.Stdin)
+		default:
+			f, err := os.Open(args[1])
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error opening input source %q: %v", args[1], err))
+				return 1
+			}
+			payload, readErr = ioutil.ReadAll(f)
+			f.Close()
+		}
+		if readErr != nil {
+			c.Ui.Error(fmt.Sprintf("Error reading input data: %v", readErr))
+			return 1
+		}
+	}
+
+	// Build the meta
+	metaMap := make(map[string]string, len(meta))
+	for _, m := range meta {
+		split := strings.SplitN(m, "=", 2)
+		if len(split) != 2 {
+			c.Ui.Error(fmt.Sprintf("Error parsing meta value: %v", m))
+			return 1
+		}
+
+		metaMap[split[0]] = split[1]
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Dispatch the job
+	resp, _, err := client.Jobs().Dispatch(job, metaMap, payload, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to dispatch job: %s", err))
+		return 1
+	}
+
+	basic := []string{
+		fmt.Sprintf("Dispatched Job ID|%s", resp.DispatchedJobID),
+		fmt.Sprintf("Evaluation ID|%s", resp.EvalID),
+	}
+	c.Ui.Output(formatKV(basic))
+
+	if detach {
+		return 0
+	}
+
+	c.Ui.Output("")
+	mon := newMonitor(c.Ui, client)
+	return mon.monitor(resp.EvalID)
+}
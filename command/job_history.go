@@ -0,0 +1,123 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type JobHistoryCommand struct {
+	Meta
+}
+
+func (c *JobHistoryCommand) Help() string {
+	helpText := `
+Usage: nomad job history [options] <job>
+
+  History is used to display the known versions of a particular job. The
+  versions are listed from newest to oldest. Only the most recent versions
+  of a job are retained.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+History Options:
+
+  -version <job version>
+    Display only the details of the specified job version.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobHistoryCommand) Synopsis() string {
+	return "Display all tracked versions of a job"
+}
+
+func (c *JobHistoryCommand) Run(args []string) int {
+	var versionStr string
+
+	flags := c.Meta.FlagSet("job history", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&versionStr, "version", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one job
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	jobID := args[0]
+
+	// Parse the version if given
+	var version uint64
+	if versionStr != "" {
+		v, err := strconv.ParseUint(versionStr, 10, 64)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error parsing version value %q: %v", versionStr, err))
+			return 1
+		}
+		version = v
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Query the job versions
+	versions, _, err := client.Jobs().Versions(jobID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving job versions: %s", err))
+		return 1
+	}
+
+	// Display the single version if one was requested
+	if versionStr != "" {
+		for _, job := range versions {
+			if job.Version == version {
+				c.Ui.Output(formatKV(formatJobVersion(job)))
+				return 0
+			}
+		}
+
+		c.Ui.Error(fmt.Sprintf("Job %q does not have version %d", jobID, version))
+		return 1
+	}
+
+	// Format the versions, separating each with a blank line
+	for i, job := range versions {
+		if i != 0 {
+			c.Ui.Output("")
+		}
+		c.Ui.Output(formatKV(formatJobVersion(job)))
+	}
+	return 0
+}
+
+// formatJobVersion returns the key/value pairs describing a version of a job.
+func formatJobVersion(job *api.Job) []string {
+	submitted := "<none>"
+	if job.SubmitTime != 0 {
+		submitted = time.Unix(0, job.SubmitTime).Format(time.RFC1123)
+	}
+
+	return []string{
+		fmt.Sprintf("Version|%d", job.Version),
+		fmt.Sprintf("Stable|%v", job.Stable),
+		fmt.Sprintf("Submit Date|%s", submitted),
+		fmt.Sprintf("Type|%s", job.Type),
+		fmt.Sprintf("Priority|%d", job.Priority),
+		fmt.Sprintf("Datacenters|%s", strings.Join(job.Datacenters, ",")),
+		fmt.Sprintf("Task Groups|%d", len(job.TaskGroups)),
+	}
+}
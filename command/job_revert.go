@@ -0,0 +1,94 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type JobRevertCommand struct {
+	Meta
+}
+
+func (c *JobRevertCommand) Help() string {
+	helpText := `
+Usage: nomad job revert [options] <job> <version>
+
+  Revert is used to revert a job to a prior version of the job. The available
+  versions to revert to can be found using the "nomad job history" command.
+
+  Upon successful revert, an evaluation will be created and monitored. This
+  can be disabled by supplying the detach flag.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Revert Options:
+
+  -detach
+    Return immediately instead of entering monitor mode. After job revert,
+    the evaluation ID will be printed to the screen, which can be used to
+    examine the evaluation using the eval-monitor command.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobRevertCommand) Synopsis() string {
+	return "Revert to a prior version of the job"
+}
+
+func (c *JobRevertCommand) Run(args []string) int {
+	var detach bool
+
+	flags := c.Meta.FlagSet("job revert", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly a job and a version
+	args = flags.Args()
+	if len(args) != 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	jobID, versionStr := args[0], args[1]
+	version, err := strconv.ParseUint(versionStr, 10, 64)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing version value %q: %v", versionStr, err))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Revert the job
+	evalID, _, err := client.Jobs().Revert(jobID, version, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reverting job: %s", err))
+		return 1
+	}
+
+	// Nothing to monitor if no evaluation was created, such as when
+	// reverting a periodic or parameterized job
+	if evalID == "" {
+		c.Ui.Output(fmt.Sprintf("Job %q reverted to version %d", jobID, version))
+		return 0
+	}
+
+	if detach {
+		c.Ui.Output("Evaluation ID: " + evalID)
+		return 0
+	}
+
+	mon := newMonitor(c.Ui, client)
+	return mon.monitor(evalID)
+}
@@ -0,0 +1,190 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+const (
+	// defaultTailLines is the number of lines displayed by -tail
+	defaultTailLines = 10
+)
+
+type LogsCommand struct {
+	Meta
+}
+
+func (c *LogsCommand) Help() string {
+	helpText := `
+Usage: nomad logs [options] <allocation> [<task>]
+
+  Display the stdout or stderr logs of a task of an allocation. The task may
+  be omitted if the task group of the allocation has a single task.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Logs Options:
+
+  -stderr
+    Display the stderr logs of the task instead of its stdout logs.
+
+  -f
+    Follow the logs, displaying new log lines as they are written until
+    interrupted.
+
+  -tail
+    Display only the end of the logs. By default the last 10 lines are
+    displayed.
+
+  -n <lines>
+    Sets the number of lines displayed with -tail.
+
+  -c <bytes>
+    Sets the number of bytes displayed with -tail.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LogsCommand) Synopsis() string {
+	return "Stream the logs of a task"
+}
+
+func (c *LogsCommand) Run(args []string) int {
+	var stderr, follow, tail bool
+	var lines int
+	var bytes int64
+
+	flags := c.Meta.FlagSet("logs", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&stderr, "stderr", false, "")
+	flags.BoolVar(&follow, "f", false, "")
+	flags.BoolVar(&tail, "tail", false, "")
+	flags.IntVar(&lines, "n", -1, "")
+	flags.Int64Var(&bytes, "c", -1, "")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got the allocation ID and optionally a task
+	args = flags.Args()
+	if len(args) < 1 || len(args) > 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	allocID := args[0]
+
+	if (lines >= 0 || bytes >= 0) && !tail {
+		c.Ui.Error("The -n and -c flags require -tail")
+		return 1
+	}
+	if lines >= 0 && bytes >= 0 {
+		c.Ui.Error("Only one of -n and -c may be set")
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Query the allocation info
+	alloc, _, err := client.Allocations().Info(allocID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying allocation: %s", err))
+		return 1
+	}
+
+	var task string
+	if len(args) == 2 {
+		task = args[1]
+	} else if task, err = allocTask(alloc); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	opts := &api.LogsOptions{
+		Task:   task,
+		Type:   api.LogStdout,
+		Follow: follow,
+	}
+	if stderr {
+		opts.Type = api.LogStderr
+	}
+	if tail {
+		switch {
+		case bytes >= 0:
+			opts.Origin = api.OriginEnd
+			opts.Offset = bytes
+		case lines == 0:
+			// Tailing no lines only displays new ones
+			opts.Origin = api.OriginEnd
+		case lines > 0:
+			opts.Lines = lines
+		default:
+			opts.Lines = defaultTailLines
+		}
+	}
+
+	logs, err := client.AllocFS().Logs(alloc.ID, opts, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading logs: %s", err))
+		return 1
+	}
+	defer logs.Close()
+
+	// Stop following the logs when interrupted
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+	interruptCh := make(chan struct{})
+	go func() {
+		<-signalCh
+		close(interruptCh)
+		logs.Close()
+	}()
+
+	if _, err := io.Copy(os.Stdout, logs); err != nil {
+		// Closing the logs on interrupt ends the copy with an error
+		select {
+		case <-interruptCh:
+			return 0
+		default:
+		}
+		c.Ui.Error(fmt.Sprintf("Error reading logs: %s", err))
+		return 1
+	}
+	return 0
+}
+
+// allocTask returns the task of the allocation if its task group has only one
+// task.
+func allocTask(alloc *api.Allocation) (string, error) {
+	if alloc.Job != nil {
+		for _, tg := range alloc.Job.TaskGroups {
+			if tg.Name != alloc.TaskGroup {
+				continue
+			}
+			if len(tg.Tasks) == 1 {
+				return tg.Tasks[0].Name, nil
+			}
+
+			names := make([]string, 0, len(tg.Tasks))
+			for _, t := range tg.Tasks {
+				names = append(names, t.Name)
+			}
+			return "", fmt.Errorf("Allocation %q has multiple tasks, one must be given: %s",
+				alloc.ID, strings.Join(names, ", "))
+		}
+	}
+	return "", fmt.Errorf("Failed to determine the tasks of allocation %q", alloc.ID)
+}
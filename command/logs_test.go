@@ -0,0 +1,88 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestLogsCommand_Implements(t *testing.T) {
+	var _ cli.Command = &LogsCommand{}
+}
+
+func TestLogsCommand_Fails(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &LogsCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on -n without -tail
+	if code := cmd.Run([]string{"-n=5", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "require -tail") {
+		t.Fatalf("expected flag error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying allocation") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on missing alloc
+	if code := cmd.Run([]string{"-address=" + url, "26470238-5CF2-438F-8772-DC67CFB0705C"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "not found") {
+		t.Fatalf("expected not found error, got: %s", out)
+	}
+}
+
+func TestLogsCommand_AllocTask(t *testing.T) {
+	alloc := &api.Allocation{
+		ID:        "foo",
+		TaskGroup: "web",
+		Job: &api.Job{
+			TaskGroups: []*api.TaskGroup{
+				&api.TaskGroup{
+					Name:  "cache",
+					Tasks: []*api.Task{&api.Task{Name: "redis"}, &api.Task{Name: "sidecar"}},
+				},
+				&api.TaskGroup{
+					Name:  "web",
+					Tasks: []*api.Task{&api.Task{Name: "frontend"}},
+				},
+			},
+		},
+	}
+	task, err := allocTask(alloc)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if task != "frontend" {
+		t.Fatalf("bad: %q", task)
+	}
+
+	// A task must be given for task groups with multiple tasks
+	alloc.TaskGroup = "cache"
+	if _, err := allocTask(alloc); err == nil || !strings.Contains(err.Error(), "redis, sidecar") {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type NodeCommand struct {
+	Meta
+}
+
+func (c *NodeCommand) Help() string {
+	helpText := `
+Usage: nomad node <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with the client nodes of the
+  cluster.
+
+  Stop new allocations from being placed on a node:
+
+      $ nomad node eligibility -disable <node-id>
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NodeCommand) Synopsis() string {
+	return "Interact with nodes"
+}
+
+func (c *NodeCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
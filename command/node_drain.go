@@ -3,6 +3,10 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/nomad/structs"
 )
 
 type NodeDrainCommand struct {
@@ -15,6 +19,14 @@ Usage: nomad node-drain [options] <node>
 
   Toggles node draining on a specified node. It is required
   that either -enable or -disable is specified, but not both.
+  A node may be monitored without either flag to follow a drain
+  that is already in progress.
+
+  Draining migrates the allocations of service jobs off the node
+  at the pace of the migrate stanza of their task groups. The
+  allocations of batch jobs are left to complete and those of
+  system jobs are stopped last. Once the deadline is reached the
+  remaining allocations are stopped.
 
 General Options:
 
@@ -27,6 +39,20 @@ Node Drain Options:
 
   -enable
     Enable draining for the specified node.
+
+  -deadline <duration>
+    Set the deadline by which all allocations must be moved off
+    the node. Remaining allocations are stopped at the deadline.
+    By default the drain waits for the allocations to migrate.
+
+  -force
+    Stop all allocations of the node immediately.
+
+  -ignore-system
+    Leave the allocations of system jobs running on the node.
+
+  -monitor
+    Monitor the progress of the drain until it completes.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -36,19 +62,25 @@ func (c *NodeDrainCommand) Synopsis() string {
 }
 
 func (c *NodeDrainCommand) Run(args []string) int {
-	var enable, disable bool
+	var enable, disable, force, ignoreSystem, monitor bool
+	var deadlineRaw string
 
 	flags := c.Meta.FlagSet("node-drain", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&enable, "enable", false, "Enable drain mode")
 	flags.BoolVar(&disable, "disable", false, "Disable drain mode")
+	flags.StringVar(&deadlineRaw, "deadline", "", "")
+	flags.BoolVar(&force, "force", false, "")
+	flags.BoolVar(&ignoreSystem, "ignore-system", false, "")
+	flags.BoolVar(&monitor, "monitor", false, "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
 
-	// Check that we got either enable or disable, but not both.
-	if (enable && disable) || (!enable && !disable) {
+	// Check that we got either enable or disable, but not both. Monitoring
+	// a drain in progress requires neither.
+	if (enable && disable) || (!enable && !disable && !monitor) {
 		c.Ui.Error(c.Help())
 		return 1
 	}
@@ -61,6 +93,34 @@ func (c *NodeDrainCommand) Run(args []string) int {
 	}
 	nodeID := args[0]
 
+	// Build the drain spec
+	var spec *api.DrainSpec
+	if !enable && (deadlineRaw != "" || force || ignoreSystem) {
+		c.Ui.Error("The -deadline, -force and -ignore-system flags require -enable")
+		return 1
+	}
+	if disable && monitor {
+		c.Ui.Error("The -monitor flag can not be combined with -disable")
+		return 1
+	}
+	if enable {
+		spec = &api.DrainSpec{IgnoreSystemJobs: ignoreSystem}
+		switch {
+		case force && deadlineRaw != "":
+			c.Ui.Error("The -deadline and -force flags are mutually exclusive")
+			return 1
+		case force:
+			spec.Deadline = -1
+		case deadlineRaw != "":
+			deadline, err := time.ParseDuration(deadlineRaw)
+			if err != nil || deadline <= 0 {
+				c.Ui.Error(fmt.Sprintf("Invalid deadline %q: must be a positive duration", deadlineRaw))
+				return 1
+			}
+			spec.Deadline = deadline
+		}
+	}
+
 	// Get the HTTP client
 	client, err := c.Meta.Client()
 	if err != nil {
@@ -69,9 +129,76 @@ func (c *NodeDrainCommand) Run(args []string) int {
 	}
 
 	// Toggle node draining
-	if _, err := client.Nodes().ToggleDrain(nodeID, enable, nil); err != nil {
-		c.Ui.Error(fmt.Sprintf("Error toggling drain mode: %s", err))
-		return 1
+	if enable || disable {
+		if _, err := client.Nodes().UpdateDrain(nodeID, spec, nil); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error toggling drain mode: %s", err))
+			return 1
+		}
+	}
+
+	if monitor {
+		return c.monitorDrain(client, nodeID)
 	}
 	return 0
 }
+
+// monitorDrain polls the node and its allocations and outputs the progress of
+// the drain until it completes
+func (c *NodeDrainCommand) monitorDrain(client *api.Client, nodeID string) int {
+	c.Ui.Info(fmt.Sprintf("Monitoring drain of node %q", nodeID))
+	var deadline time.Time
+	remaining, migrating := -1, -1
+	for {
+		node, _, err := client.Nodes().Info(nodeID, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying node: %s", err))
+			return 1
+		}
+		if !node.Drain {
+			c.Ui.Error(fmt.Sprintf("Node %q is not draining", nodeID))
+			return 1
+		}
+		if node.DrainStrategy == nil {
+			c.Ui.Info(fmt.Sprintf("Drain of node %q complete", nodeID))
+			return 0
+		}
+		if force := node.DrainStrategy.ForceDeadline; !force.IsZero() && !force.Equal(deadline) {
+			deadline = force
+			c.Ui.Info(fmt.Sprintf("Remaining allocations are stopped at %s",
+				deadline.Local().Format("01/02/06 15:04:05 MST")))
+		}
+
+		allocs, _, err := client.Nodes().Allocations(nodeID, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying node allocations: %s", err))
+			return 1
+		}
+		r, m := drainProgress(allocs)
+		if r != remaining || m != migrating {
+			remaining, migrating = r, m
+			c.Ui.Info(fmt.Sprintf("%d allocations remaining, %d migrating", remaining, migrating))
+		}
+
+		time.Sleep(updateWait)
+	}
+}
+
+// drainProgress returns the number of allocations that still run on a
+// draining node and how many of them are being migrated
+func drainProgress(allocs []*api.Allocation) (remaining, migrating int) {
+	for _, alloc := range allocs {
+		switch alloc.DesiredStatus {
+		case structs.AllocDesiredStatusStop, structs.AllocDesiredStatusEvict, structs.AllocDesiredStatusFailed:
+			continue
+		}
+		switch alloc.ClientStatus {
+		case structs.AllocClientStatusDead, structs.AllocClientStatusFailed:
+			continue
+		}
+		remaining++
+		if alloc.DesiredTransition.Migrate {
+			migrating++
+		}
+	}
+	return remaining, migrating
+}
@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/mitchellh/cli"
 )
 
@@ -61,4 +63,58 @@ func TestNodeDrainCommand_Fails(t *testing.T) {
 	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
 		t.Fatalf("expected help output, got: %s", out)
 	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on a drain spec without enable
+	if code := cmd.Run([]string{"-disable", "-deadline=1h", "nope"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "require -enable") {
+		t.Fatalf("expected flag error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an invalid deadline
+	if code := cmd.Run([]string{"-enable", "-deadline=-1h", "nope"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Invalid deadline") {
+		t.Fatalf("expected deadline error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on a deadline along with force
+	if code := cmd.Run([]string{"-enable", "-deadline=1h", "-force", "nope"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "mutually exclusive") {
+		t.Fatalf("expected flag error, got: %s", out)
+	}
+}
+
+func TestNodeDrainCommand_DrainProgress(t *testing.T) {
+	allocs := []*api.Allocation{
+		&api.Allocation{
+			DesiredStatus: structs.AllocDesiredStatusRun,
+			ClientStatus:  structs.AllocClientStatusRunning,
+		},
+		&api.Allocation{
+			DesiredStatus:     structs.AllocDesiredStatusRun,
+			ClientStatus:      structs.AllocClientStatusRunning,
+			DesiredTransition: api.DesiredTransition{Migrate: true},
+		},
+		&api.Allocation{
+			DesiredStatus:     structs.AllocDesiredStatusStop,
+			ClientStatus:      structs.AllocClientStatusRunning,
+			DesiredTransition: api.DesiredTransition{Migrate: true},
+		},
+		&api.Allocation{
+			DesiredStatus: structs.AllocDesiredStatusRun,
+			ClientStatus:  structs.AllocClientStatusDead,
+		},
+	}
+	remaining, migrating := drainProgress(allocs)
+	if remaining != 2 || migrating != 1 {
+		t.Fatalf("bad: %d %d", remaining, migrating)
+	}
 }
@@ -0,0 +1,81 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type NodeEligibilityCommand struct {
+	Meta
+}
+
+func (c *NodeEligibilityCommand) Help() string {
+	helpText := `
+Usage: nomad node eligibility [options] <node>
+
+  Toggles the scheduling eligibility of a specified node. It is
+  required that either -enable or -disable is specified, but not
+  both. No new allocations are placed on an ineligible node, but
+  the allocations already running on it are left untouched.
+  Enabling a drain marks the node ineligible and disabling the
+  drain marks it eligible again.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Node Eligibility Options:
+
+  -disable
+    Mark the specified node as ineligible for new allocations.
+
+  -enable
+    Mark the specified node as eligible for new allocations.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NodeEligibilityCommand) Synopsis() string {
+	return "Toggle scheduling eligibility of a given node"
+}
+
+func (c *NodeEligibilityCommand) Run(args []string) int {
+	var enable, disable bool
+
+	flags := c.Meta.FlagSet("node eligibility", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&enable, "enable", false, "Mark the node eligible")
+	flags.BoolVar(&disable, "disable", false, "Mark the node ineligible")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got either enable or disable, but not both.
+	if (enable && disable) || (!enable && !disable) {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Check that we got a node ID
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	nodeID := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Toggle the scheduling eligibility
+	if _, err := client.Nodes().ToggleEligibility(nodeID, enable, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error toggling scheduling eligibility: %s", err))
+		return 1
+	}
+	return 0
+}
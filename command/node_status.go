@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
 )
 
 type NodeStatusCommand struct {
@@ -31,6 +34,11 @@ Node Status Options:
   -short
     Display short output. Used only when a single node is being
     queried, and drops verbose output about node allocations.
+
+  -stats
+    Display the latest resource usage of the host of the node, as
+    measured by its client. Used only when a single node is being
+    queried.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -40,11 +48,12 @@ func (c *NodeStatusCommand) Synopsis() string {
 }
 
 func (c *NodeStatusCommand) Run(args []string) int {
-	var short bool
+	var short, stats bool
 
 	flags := c.Meta.FlagSet("node-status", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&short, "short", false, "")
+	flags.BoolVar(&stats, "stats", false, "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -80,14 +89,15 @@ func (c *NodeStatusCommand) Run(args []string) int {
 
 		// Format the nodes list
 		out := make([]string, len(nodes)+1)
-		out[0] = "ID|DC|Name|Class|Drain|Status"
+		out[0] = "ID|DC|Name|Class|Drain|Eligibility|Status"
 		for i, node := range nodes {
-			out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%v|%s",
+			out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%v|%s|%s",
 				node.ID,
 				node.Datacenter,
 				node.Name,
 				node.NodeClass,
 				node.Drain,
+				node.SchedulingEligibility,
 				node.Status)
 		}
 
@@ -125,6 +135,7 @@ func (c *NodeStatusCommand) Run(args []string) int {
 		fmt.Sprintf("Class|%s", node.NodeClass),
 		fmt.Sprintf("Datacenter|%s", node.Datacenter),
 		fmt.Sprintf("Drain|%v", node.Drain),
+		fmt.Sprintf("Eligibility|%s", node.SchedulingEligibility),
 		fmt.Sprintf("Status|%s", node.Status),
 		fmt.Sprintf("Attributes|%s", strings.Join(attributes, ", ")),
 	}
@@ -152,11 +163,69 @@ func (c *NodeStatusCommand) Run(args []string) int {
 		}
 	}
 
+	var hostStats *api.HostStats
+	if stats {
+		hostStats, _, err = client.Nodes().Stats(node.ID, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying node stats: %s", err))
+			return 1
+		}
+	}
+
 	// Dump the output
 	c.Ui.Output(formatKV(basic))
+	if stats {
+		c.Ui.Output("\n### Host Resource Usage")
+		c.Ui.Output(formatHostStats(hostStats))
+	}
 	if !short {
 		c.Ui.Output("\n### Allocations")
 		c.Ui.Output(formatList(allocs))
 	}
 	return 0
 }
+
+// formatHostStats formats the resource usage of the host of a node: a summary
+// followed by the usage of each core and of each mount of the alloc dir
+func formatHostStats(hs *api.HostStats) string {
+	var cpuTotal float64
+	for _, cpu := range hs.CPU {
+		cpuTotal += cpu.Total
+	}
+	if len(hs.CPU) > 0 {
+		cpuTotal /= float64(len(hs.CPU))
+	}
+
+	summary := []string{
+		fmt.Sprintf("CPU|%.2f%%", cpuTotal),
+		fmt.Sprintf("Uptime|%v", time.Duration(hs.Uptime)*time.Second),
+	}
+	if mem := hs.Memory; mem != nil {
+		summary = append(summary,
+			fmt.Sprintf("Memory|%s/%s", formatBytes(mem.Used), formatBytes(mem.Total)),
+			fmt.Sprintf("Memory Available|%s", formatBytes(mem.Available)))
+	}
+	out := formatKV(summary)
+
+	if len(hs.CPU) > 0 {
+		cpus := make([]string, len(hs.CPU)+1)
+		cpus[0] = "CPU|Total|User|System|Idle"
+		for i, cpu := range hs.CPU {
+			cpus[i+1] = fmt.Sprintf("%s|%.2f%%|%.2f%%|%.2f%%|%.2f%%",
+				cpu.CPU, cpu.Total, cpu.User, cpu.System, cpu.Idle)
+		}
+		out += "\n\n" + formatList(cpus)
+	}
+
+	if len(hs.DiskStats) > 0 {
+		disks := make([]string, len(hs.DiskStats)+1)
+		disks[0] = "Device|Mountpoint|Size|Used|Available|Used%|Inodes Used%"
+		for i, disk := range hs.DiskStats {
+			disks[i+1] = fmt.Sprintf("%s|%s|%s|%s|%s|%.2f%%|%.2f%%",
+				disk.Device, disk.Mountpoint, formatBytes(disk.Size), formatBytes(disk.Used),
+				formatBytes(disk.Available), disk.UsedPercent, disk.InodesUsedPercent)
+		}
+		out += "\n\n" + formatList(disks)
+	}
+	return out
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/mitchellh/cli"
 )
@@ -108,3 +109,28 @@ func TestNodeStatusCommand_Fails(t *testing.T) {
 		t.Fatalf("expected not found error, got: %s", out)
 	}
 }
+
+func TestNodeStatusCommand_FormatHostStats(t *testing.T) {
+	hs := &api.HostStats{
+		Memory: &api.HostMemoryStats{
+			Total:     2 * 1024 * 1024 * 1024,
+			Used:      1024 * 1024 * 1024,
+			Available: 1024 * 1024 * 1024,
+		},
+		CPU: []*api.HostCPUStats{
+			&api.HostCPUStats{CPU: "cpu0", Total: 20, User: 15, System: 5, Idle: 80},
+			&api.HostCPUStats{CPU: "cpu1", Total: 40, User: 30, System: 10, Idle: 60},
+		},
+		DiskStats: []*api.HostDiskStats{
+			&api.HostDiskStats{Device: "/dev/sda1", Mountpoint: "/", UsedPercent: 12.5},
+		},
+		Uptime: 3600,
+	}
+
+	out := formatHostStats(hs)
+	for _, expect := range []string{"30.00%", "1.0 GiB/2.0 GiB", "1h0m0s", "cpu1", "/dev/sda1", "12.50%"} {
+		if !strings.Contains(out, expect) {
+			t.Fatalf("expected %q, got: %s", expect, out)
+		}
+	}
+}
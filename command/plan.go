@@ -0,0 +1,341 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/jobspec"
+)
+
+const (
+	jobModifyIndexHelp = `To submit the job with version verification run:
+
+nomad run -check-index %d %s
+
+When running the job with the check-index flag, the job will only be run if the
+server side version matches the job modify index returned. If the index has
+changed, another user has modified the job and the plan's results are
+potentially invalid.`
+)
+
+type PlanCommand struct {
+	Meta
+}
+
+func (c *PlanCommand) Help() string {
+	helpText := `
+Usage: nomad plan [options] <file>
+
+  Plan invokes a dry-run of the scheduler to determine the effects of submitting
+  either a new or updated version of a job. The plan will not result in any
+  changes to the cluster but gives insight into whether the job could be run
+  successfully and how it would affect existing allocations.
+
+  A job modify index is returned with the plan. This value can be used when
+  submitting the job using "nomad run -check-index", which will check that the
+  job was not modified between the plan and run command before invoking the
+  scheduler. This ensures the job has not been modified since the plan.
+
+  A structured diff between the local and remote job is displayed to
+  give insight into what the scheduler will attempt to do and why.
+
+  Plan will return one of the following exit codes:
+    * 0: No allocations failed placement.
+    * 1: An error occurred with the plan.
+    * 2: Allocations would fail placement.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Plan Options:
+
+  -diff
+    Determines whether the diff between the remote job and planned job is shown.
+    Defaults to true.
+
+  -verbose
+    Increase diff verbosity.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PlanCommand) Synopsis() string {
+	return "Dry-run a job update to determine its effects"
+}
+
+func (c *PlanCommand) Run(args []string) int {
+	var diff, verbose bool
+
+	flags := c.Meta.FlagSet("plan", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&diff, "diff", true, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one job
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	file := args[0]
+
+	// Parse the job file
+	job, err := jobspec.ParseFile(file)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing job file %s: %s", file, err))
+		return 1
+	}
+
+	// Check that the job is valid
+	if err := job.Validate(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error validating job: %s", err))
+		return 1
+	}
+
+	// Convert it to something we can use
+	apiJob, err := convertJob(job)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error converting job: %s", err))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Submit the job
+	resp, _, err := client.Jobs().Plan(apiJob, diff, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error during plan: %s", err))
+		return 1
+	}
+
+	// Print the diff if not disabled
+	if diff {
+		c.Ui.Output(fmt.Sprintf("%s\n", formatJobDiff(resp.Diff, verbose)))
+	}
+
+	// Print the scheduler dry-run output
+	c.Ui.Output("Scheduler dry-run:")
+	c.Ui.Output(formatDryRun(resp))
+	c.Ui.Output("")
+
+	// Print the job index info
+	c.Ui.Output(formatJobModifyIndex(resp.JobModifyIndex, file))
+
+	// Print when a periodic job would next be launched
+	if next := resp.NextPeriodicLaunch; !next.IsZero() {
+		c.Ui.Output(fmt.Sprintf("\nNext periodic launch: %v (%s from now)",
+			next, next.Sub(time.Now().UTC())))
+	}
+
+	if len(resp.FailedAllocs) != 0 {
+		return 2
+	}
+	return 0
+}
+
+// formatJobModifyIndex produces a help string that displays the job modify
+// index and how to submit a job with it.
+func formatJobModifyIndex(jobModifyIndex uint64, jobName string) string {
+	help := fmt.Sprintf(jobModifyIndexHelp, jobModifyIndex, jobName)
+	out := fmt.Sprintf("Job Modify Index: %d\n%s", jobModifyIndex, help)
+	return out
+}
+
+// formatDryRun produces a string explaining the results of the dry run.
+func formatDryRun(resp *api.JobPlanResponse) string {
+	var out string
+	if len(resp.FailedAllocs) == 0 {
+		out = "- All tasks successfully allocated.\n"
+	} else {
+		out = "- WARNING: Failed to place all allocations.\n"
+		for _, alloc := range resp.FailedAllocs {
+			out += fmt.Sprintf("  Task Group %q: %d allocation(s) failed to place\n",
+				alloc.TaskGroup, alloc.Metrics.CoalescedFailures+1)
+		}
+	}
+
+	if len(resp.CreatedEvals) != 0 {
+		out += "- Evaluations would be created to complete a rolling update.\n"
+	}
+
+	return strings.TrimSuffix(out, "\n")
+}
+
+// formatJobDiff produces an annotated diff of the job. If verbose mode is
+// set, unchanged fields are also displayed.
+func formatJobDiff(job *api.JobDiff, verbose bool) string {
+	if job == nil {
+		return ""
+	}
+
+	out := fmt.Sprintf("%s Job: %q\n", getDiffString(job.Type), job.ID)
+
+	// Only show the job level fields and objects if the job is changing
+	if job.Type != "None" || verbose {
+		for _, field := range job.Fields {
+			if s := formatFieldDiff(field, "", verbose); s != "" {
+				out += s + "\n"
+			}
+		}
+		for _, object := range job.Objects {
+			if s := formatObjectDiff(object, "", verbose); s != "" {
+				out += s + "\n"
+			}
+		}
+	}
+
+	for _, tg := range job.TaskGroups {
+		if tg.Type != "None" || len(tg.Updates) != 0 || verbose {
+			out += formatTaskGroupDiff(tg, verbose) + "\n"
+		}
+	}
+
+	return strings.TrimSuffix(out, "\n")
+}
+
+// formatTaskGroupDiff produces an annotated diff of a task group including
+// the number of each type of update the scheduler would make.
+func formatTaskGroupDiff(tg *api.TaskGroupDiff, verbose bool) string {
+	out := fmt.Sprintf("%s Task Group: %q", getDiffString(tg.Type), tg.Name)
+
+	// Append the updates
+	if l := len(tg.Updates); l > 0 {
+		order := make([]string, 0, l)
+		for updateType := range tg.Updates {
+			order = append(order, updateType)
+		}
+		sort.Strings(order)
+
+		updates := make([]string, 0, l)
+		for _, updateType := range order {
+			updates = append(updates, fmt.Sprintf("%d %s", tg.Updates[updateType], updateType))
+		}
+		out += fmt.Sprintf(" (%s)", strings.Join(updates, ", "))
+	}
+	out += "\n"
+
+	prefix := "  "
+	for _, field := range tg.Fields {
+		if s := formatFieldDiff(field, prefix, verbose); s != "" {
+			out += s + "\n"
+		}
+	}
+	for _, object := range tg.Objects {
+		if s := formatObjectDiff(object, prefix, verbose); s != "" {
+			out += s + "\n"
+		}
+	}
+
+	for _, task := range tg.Tasks {
+		if task.Type != "None" || verbose {
+			out += formatTaskDiff(task, prefix, verbose) + "\n"
+		}
+	}
+
+	return strings.TrimSuffix(out, "\n")
+}
+
+// formatTaskDiff produces an annotated diff of a task.
+func formatTaskDiff(task *api.TaskDiff, prefix string, verbose bool) string {
+	out := fmt.Sprintf("%s%s Task: %q", prefix, getDiffString(task.Type), task.Name)
+	if len(task.Annotations) != 0 {
+		out += fmt.Sprintf(" (%s)", strings.Join(task.Annotations, ", "))
+	}
+	out += "\n"
+
+	// Nothing more to show for tasks being added or removed unless verbose
+	if !verbose && (task.Type == "Added" || task.Type == "Deleted") {
+		return strings.TrimSuffix(out, "\n")
+	}
+
+	subPrefix := prefix + "  "
+	for _, field := range task.Fields {
+		if s := formatFieldDiff(field, subPrefix, verbose); s != "" {
+			out += s + "\n"
+		}
+	}
+	for _, object := range task.Objects {
+		if s := formatObjectDiff(object, subPrefix, verbose); s != "" {
+			out += s + "\n"
+		}
+	}
+
+	return strings.TrimSuffix(out, "\n")
+}
+
+// formatObjectDiff produces an annotated diff of an object and the objects
+// nested within it.
+func formatObjectDiff(diff *api.ObjectDiff, prefix string, verbose bool) string {
+	if diff.Type == "None" && !verbose {
+		return ""
+	}
+
+	out := fmt.Sprintf("%s%s %s {\n", prefix, getDiffString(diff.Type), diff.Name)
+
+	subPrefix := prefix + "  "
+	for _, field := range diff.Fields {
+		if s := formatFieldDiff(field, subPrefix, true); s != "" {
+			out += s + "\n"
+		}
+	}
+	for _, object := range diff.Objects {
+		if s := formatObjectDiff(object, subPrefix, verbose); s != "" {
+			out += s + "\n"
+		}
+	}
+
+	return fmt.Sprintf("%s%s  }", out, prefix)
+}
+
+// formatFieldDiff produces an annotated diff of a single field. Unchanged
+// fields are only shown if verbose is set.
+func formatFieldDiff(diff *api.FieldDiff, prefix string, verbose bool) string {
+	var out string
+	switch diff.Type {
+	case "Added":
+		out = fmt.Sprintf("%s%s %s: %q", prefix, getDiffString(diff.Type), diff.Name, diff.New)
+	case "Deleted":
+		out = fmt.Sprintf("%s%s %s: %q", prefix, getDiffString(diff.Type), diff.Name, diff.Old)
+	case "Edited":
+		out = fmt.Sprintf("%s%s %s: %q => %q", prefix, getDiffString(diff.Type), diff.Name, diff.Old, diff.New)
+	default:
+		if !verbose {
+			return ""
+		}
+		out = fmt.Sprintf("%s%s %s: %q", prefix, getDiffString(diff.Type), diff.Name, diff.New)
+	}
+
+	// Append the annotations
+	if len(diff.Annotations) != 0 {
+		out += fmt.Sprintf(" (%s)", strings.Join(diff.Annotations, ", "))
+	}
+
+	return out
+}
+
+// getDiffString returns the prefix marking the type of a diff.
+func getDiffString(diffType string) string {
+	switch diffType {
+	case "Added":
+		return "+"
+	case "Deleted":
+		return "-"
+	case "Edited":
+		return "+/-"
+	default:
+		return " "
+	}
+}
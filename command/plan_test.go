@@ -0,0 +1,123 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestPlanCommand_Implements(t *testing.T) {
+	var _ cli.Command = &PlanCommand{}
+}
+
+func TestPlanCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &PlanCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails when specified file does not exist
+	if code := cmd.Run([]string{"/unicorns/leprechauns"}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error parsing") {
+		t.Fatalf("expect parsing error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on invalid job spec
+	fh1, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh1.Name())
+	if _, err := fh1.WriteString(`job "job1" {}`); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if code := cmd.Run([]string{fh1.Name()}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error validating") {
+		t.Fatalf("expect validation error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure (requires a valid job)
+	fh2, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh2.Name())
+	_, err = fh2.WriteString(`
+job "job1" {
+	datacenters = [ "dc1" ]
+	group "group1" {
+		count = 1
+		task "task1" {
+			driver = "exec"
+			resources = {
+				cpu = 1000
+				mem = 512
+			}
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if code := cmd.Run([]string{"-address=nope", fh2.Name()}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error during plan") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestPlanCommand_FormatJobDiff(t *testing.T) {
+	diff := &api.JobDiff{
+		Type: "Edited",
+		ID:   "job1",
+		TaskGroups: []*api.TaskGroupDiff{
+			{
+				Type: "Edited",
+				Name: "group1",
+				Fields: []*api.FieldDiff{
+					{
+						Type:        "Edited",
+						Name:        "Count",
+						Old:         "1",
+						New:         "3",
+						Annotations: []string{"forces create"},
+					},
+				},
+				Updates: map[string]uint64{
+					"create": 2,
+					"ignore": 1,
+				},
+			},
+		},
+	}
+
+	out := formatJobDiff(diff, false)
+	expected := []string{
+		`+/- Job: "job1"`,
+		`+/- Task Group: "group1" (2 create, 1 ignore)`,
+		`  +/- Count: "1" => "3" (forces create)`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Fatalf("expected %q in output:\n%s", e, out)
+		}
+	}
+}
@@ -4,13 +4,23 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/jobspec"
+	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
+var (
+	// enforceIndexRegex is a regular expression which extracts the enforcement
+	// error from the error returned by the HTTP API.
+	enforceIndexRegex = regexp.MustCompile(`\((Enforcing job modify index.*)\)`)
+)
+
 type RunCommand struct {
 	Meta
 }
@@ -41,11 +51,22 @@ General Options:
 
 Run Options:
 
+  -check-index
+    If set, the job is only registered or updated if the passed
+    job modify index matches the server side version. If a check-index value of
+    zero is passed, the job is only registered if it does not yet exist. If a
+    non-zero value is passed, it ensures that the job is being updated from a
+    known state. The use of this flag is most common in conjunction with plan
+    command.
+
   -detach
     Return immediately instead of entering monitor mode. After job
     submission, the evaluation ID will be printed to the screen.
     You can use this ID to start a monitor using the eval-monitor
-    command later if needed.
+    command later if needed. Periodic jobs are never monitored since
+    they are launched by the leader at the times given by their
+    periodic specification, and parameterized jobs are not monitored
+    since they only run when dispatched.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -56,10 +77,12 @@ func (c *RunCommand) Synopsis() string {
 
 func (c *RunCommand) Run(args []string) int {
 	var detach bool
+	var checkIndexStr string
 
 	flags := c.Meta.FlagSet("run", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&detach, "detach", false, "")
+	flags.StringVar(&checkIndexStr, "check-index", "", "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -100,17 +123,51 @@ func (c *RunCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Parse the check-index
+	checkIndex, enforce, err := parseCheckIndex(checkIndexStr)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing check-index value %q: %v", checkIndexStr, err))
+		return 1
+	}
+
 	// Submit the job
-	evalID, _, err := client.Jobs().Register(apiJob, nil)
+	var evalID string
+	if enforce {
+		evalID, _, err = client.Jobs().EnforceRegister(apiJob, checkIndex, nil)
+	} else {
+		evalID, _, err = client.Jobs().Register(apiJob, nil)
+	}
 	if err != nil {
+		if strings.Contains(err.Error(), nomad.RegisterEnforceIndexErrPrefix) {
+			// Format the error specially if the error is due to index
+			// enforcement
+			matches := enforceIndexRegex.FindStringSubmatch(err.Error())
+			if len(matches) == 2 {
+				c.Ui.Error(matches[1]) // The matched group
+				c.Ui.Error("Job not updated")
+				return 1
+			}
+		}
+
 		c.Ui.Error(fmt.Sprintf("Error submitting job: %s", err))
 		return 1
 	}
 
+	// Periodic jobs are launched by the leader and parameterized jobs are
+	// only run when dispatched, so registering either does not create an
+	// evaluation to monitor.
+	periodic := job.IsPeriodic()
+	parameterized := job.IsParameterized()
+
 	// Check if we should enter monitor mode
-	if detach {
+	if detach || periodic || parameterized {
 		c.Ui.Output("Job registration successful")
-		c.Ui.Output("Evaluation ID: " + evalID)
+		if periodic {
+			next := job.Periodic.Next(time.Now().UTC())
+			c.Ui.Output(fmt.Sprintf("Approximate next launch time: %v", next))
+		} else if !parameterized {
+			c.Ui.Output("Evaluation ID: " + evalID)
+		}
 		return 0
 	}
 
@@ -120,6 +177,17 @@ func (c *RunCommand) Run(args []string) int {
 
 }
 
+// parseCheckIndex parses the check-index flag and returns the index, whether
+// it was set and potentially an error during parsing.
+func parseCheckIndex(input string) (uint64, bool, error) {
+	if input == "" {
+		return 0, false, nil
+	}
+
+	u, err := strconv.ParseUint(input, 10, 64)
+	return u, true, err
+}
+
 // convertJob is used to take a *structs.Job and convert it to an *api.Job.
 // This function is just a hammer and probably needs to be revisited.
 func convertJob(in *structs.Job) (*api.Job, error) {
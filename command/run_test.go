@@ -98,4 +98,25 @@ job "job1" {
 	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error submitting job") {
 		t.Fatalf("expected failed query error, got: %s", out)
 	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an invalid check-index
+	if code := cmd.Run([]string{"-check-index=bad", fh3.Name()}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error parsing check-index") {
+		t.Fatalf("expected check-index parse error, got: %s", out)
+	}
+}
+
+func TestRunCommand_EnforceIndexRegex(t *testing.T) {
+	err := "Unexpected response code: 500 (Enforcing job modify index 10: job exists with conflicting job modify index: 12)"
+	matches := enforceIndexRegex.FindStringSubmatch(err)
+	if len(matches) != 2 {
+		t.Fatalf("bad matches: %#v", matches)
+	}
+	expected := "Enforcing job modify index 10: job exists with conflicting job modify index: 12"
+	if matches[1] != expected {
+		t.Fatalf("got %q; want %q", matches[1], expected)
+	}
 }
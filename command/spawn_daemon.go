@@ -9,12 +9,15 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+
+	"github.com/hashicorp/nomad/client/driver/logging"
 )
 
 type SpawnDaemonCommand struct {
 	Meta
 	config   *DaemonConfig
 	exitFile io.WriteCloser
+	logs     []io.Closer
 }
 
 func (c *SpawnDaemonCommand) Help() string {
@@ -54,6 +57,9 @@ type SpawnStartStatus struct {
 type SpawnExitStatus struct {
 	// The exit code of the user's command.
 	ExitCode int
+
+	// The signal that terminated the user's command, if any.
+	Signal int
 }
 
 // Configuration for the command to start as a daemon.
@@ -69,6 +75,11 @@ type DaemonConfig struct {
 	StdinFile  string
 	StderrFile string
 
+	// If MaxLogFiles is set, StdoutFile and StderrFile are the base paths of
+	// log files that are rotated once they reach MaxLogFileSize bytes.
+	MaxLogFiles    int
+	MaxLogFileSize int64
+
 	// An optional path specifying the directory to chroot the process in.
 	Chroot string
 }
@@ -109,7 +120,7 @@ func (c *SpawnDaemonCommand) parseConfig(args []string) (*DaemonConfig, error) {
 // stdin/stderr/stdout to them. If unsuccessful, an error is returned.
 func (c *SpawnDaemonCommand) configureLogs() error {
 	if len(c.config.StdoutFile) != 0 {
-		stdo, err := os.OpenFile(c.config.StdoutFile, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+		stdo, err := c.openLog(c.config.StdoutFile)
 		if err != nil {
 			return fmt.Errorf("Error opening file to redirect stdout: %v", err)
 		}
@@ -118,7 +129,7 @@ func (c *SpawnDaemonCommand) configureLogs() error {
 	}
 
 	if len(c.config.StderrFile) != 0 {
-		stde, err := os.OpenFile(c.config.StderrFile, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+		stde, err := c.openLog(c.config.StderrFile)
 		if err != nil {
 			return fmt.Errorf("Error opening file to redirect stderr: %v", err)
 		}
@@ -136,6 +147,21 @@ func (c *SpawnDaemonCommand) configureLogs() error {
 	return nil
 }
 
+// openLog opens the log file at the path, which is rotated if the config sets
+// a maximum number of log files.
+func (c *SpawnDaemonCommand) openLog(path string) (io.WriteCloser, error) {
+	if c.config.MaxLogFiles == 0 {
+		return os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	}
+
+	rotator, err := logging.NewFileRotator(path, c.config.MaxLogFiles, c.config.MaxLogFileSize)
+	if err != nil {
+		return nil, err
+	}
+	c.logs = append(c.logs, rotator)
+	return rotator, nil
+}
+
 func (c *SpawnDaemonCommand) Run(args []string) int {
 	var err error
 	c.config, err = c.parseConfig(args)
@@ -184,8 +210,13 @@ func (c *SpawnDaemonCommand) Run(args []string) int {
 	// Indicate that the command was started successfully.
 	c.outputStartStatus(nil, 0)
 
-	// Wait and then output the exit status.
-	return c.writeExitStatus(c.config.Cmd.Wait())
+	// Wait and then output the exit status. Waiting copies the remaining
+	// output into the rotated logs, which can then be closed.
+	exit := c.config.Cmd.Wait()
+	for _, l := range c.logs {
+		l.Close()
+	}
+	return c.writeExitStatus(exit)
 }
 
 // outputStartStatus is a helper function that outputs a SpawnStartStatus to
@@ -220,6 +251,9 @@ func (c *SpawnDaemonCommand) writeExitStatus(exit error) int {
 		if exiterr, ok := exit.(*exec.ExitError); ok {
 			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
 				exitStatus.ExitCode = status.ExitStatus()
+				if status.Signaled() {
+					exitStatus.Signal = int(status.Signal())
+				}
 			}
 		}
 	}
@@ -3,6 +3,9 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
 )
 
 type StatusCommand struct {
@@ -99,8 +102,22 @@ func (c *StatusCommand) Run(args []string) int {
 		fmt.Sprintf("Name|%s", job.Name),
 		fmt.Sprintf("Type|%s", job.Type),
 		fmt.Sprintf("Priority|%d", job.Priority),
+		fmt.Sprintf("Version|%d", job.Version),
 		fmt.Sprintf("Datacenters|%s", strings.Join(job.Datacenters, ",")),
 		fmt.Sprintf("Status|%s", job.Status),
+		fmt.Sprintf("Periodic|%v", job.Periodic != nil && job.Periodic.Enabled),
+	}
+
+	// Show the next launch of periodic jobs
+	if job.Periodic != nil && job.Periodic.Enabled {
+		periodic := &structs.PeriodicConfig{
+			Enabled:  job.Periodic.Enabled,
+			Spec:     job.Periodic.Spec,
+			SpecType: job.Periodic.SpecType,
+			TimeZone: job.Periodic.TimeZone,
+		}
+		next := periodic.Next(time.Now().UTC())
+		basic = append(basic, fmt.Sprintf("Next Periodic Launch|%v", next))
 	}
 
 	var evals, allocs []string
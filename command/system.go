@@ -0,0 +1,35 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type SystemCommand struct {
+	Meta
+}
+
+func (c *SystemCommand) Help() string {
+	helpText := `
+Usage: nomad system <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with the system as a whole,
+  such as running the administrative tasks of the servers.
+
+  Force the garbage collection of the system:
+
+      $ nomad system gc
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SystemCommand) Synopsis() string {
+	return "Interact with the system API"
+}
+
+func (c *SystemCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SystemGCCommand struct {
+	Meta
+}
+
+func (c *SystemGCCommand) Help() string {
+	helpText := `
+Usage: nomad system gc [options]
+
+  Initializes a garbage collection of jobs, evaluations, allocations, and nodes.
+  Unlike the periodic garbage collection of the servers, all eligible objects
+  are collected regardless of how long ago they became eligible.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SystemGCCommand) Synopsis() string {
+	return "Run the system garbage collection process"
+}
+
+func (c *SystemGCCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("system gc", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if args = flags.Args(); len(args) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if err := client.System().GarbageCollect(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error running system garbage-collection: %s", err))
+		return 1
+	}
+	return 0
+}
@@ -24,6 +24,18 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 	}
 
 	return map[string]cli.CommandFactory{
+		"alloc": func() (cli.Command, error) {
+			return &command.AllocCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"alloc exec": func() (cli.Command, error) {
+			return &command.AllocExecCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"alloc-status": func() (cli.Command, error) {
 			return &command.AllocStatusCommand{
 				Meta: meta,
@@ -52,18 +64,96 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"deployment": func() (cli.Command, error) {
+			return &command.DeploymentCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"deployment fail": func() (cli.Command, error) {
+			return &command.DeploymentFailCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"deployment list": func() (cli.Command, error) {
+			return &command.DeploymentListCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"deployment promote": func() (cli.Command, error) {
+			return &command.DeploymentPromoteCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"deployment status": func() (cli.Command, error) {
+			return &command.DeploymentStatusCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"eval-monitor": func() (cli.Command, error) {
 			return &command.EvalMonitorCommand{
 				Meta: meta,
 			}, nil
 		},
 
+		"fs": func() (cli.Command, error) {
+			return &command.FSCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"init": func() (cli.Command, error) {
 			return &command.InitCommand{
 				Meta: meta,
 			}, nil
 		},
 
+		"job": func() (cli.Command, error) {
+			return &command.JobCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"job dispatch": func() (cli.Command, error) {
+			return &command.JobDispatchCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"job history": func() (cli.Command, error) {
+			return &command.JobHistoryCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"job revert": func() (cli.Command, error) {
+			return &command.JobRevertCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"logs": func() (cli.Command, error) {
+			return &command.LogsCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"node": func() (cli.Command, error) {
+			return &command.NodeCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"node eligibility": func() (cli.Command, error) {
+			return &command.NodeEligibilityCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"node-drain": func() (cli.Command, error) {
 			return &command.NodeDrainCommand{
 				Meta: meta,
@@ -76,6 +166,12 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"plan": func() (cli.Command, error) {
+			return &command.PlanCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"run": func() (cli.Command, error) {
 			return &command.RunCommand{
 				Meta: meta,
@@ -118,6 +214,18 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"system": func() (cli.Command, error) {
+			return &command.SystemCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"system gc": func() (cli.Command, error) {
+			return &command.SystemGCCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"validate": func() (cli.Command, error) {
 			return &command.ValidateCommand{
 				Meta: meta,
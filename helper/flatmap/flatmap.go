@@ -0,0 +1,111 @@
+package flatmap
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Flatten takes an object and returns a flat map of the object. The keys of
+// the map is the path of the field names until a primitive field is reached
+// and the value is a string representation of the terminal field. Struct
+// fields are joined with ".", map entries are suffixed with "[key]" and
+// slice elements with "[index]". Fields whose names are in the filter are
+// skipped. If primitiveOnly is set, only the top level primitive fields are
+// returned.
+func Flatten(obj interface{}, filter []string, primitiveOnly bool) map[string]string {
+	flat := make(map[string]string)
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() {
+		return nil
+	}
+
+	flatten("", v, primitiveOnly, false, filter, flat)
+	return flat
+}
+
+// flatten recursively calls itself to create a flatmap representation of the
+// passed value. The results are stored into the output map and the keys are
+// the fields prepended with the passed prefix.
+// XXX: A current restriction is that maps only support string keys.
+func flatten(prefix string, v reflect.Value, primitiveOnly, enteredStruct bool,
+	filter []string, output map[string]string) {
+	switch v.Kind() {
+	case reflect.Bool:
+		output[prefix] = fmt.Sprintf("%v", v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		// Use the interface so that types such as time.Duration are printed
+		// using their String method.
+		output[prefix] = fmt.Sprintf("%v", v.Interface())
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if primitiveOnly && enteredStruct {
+			return
+		}
+		flatten(prefix, v.Elem(), primitiveOnly, enteredStruct, filter, output)
+	case reflect.Map:
+		if primitiveOnly && enteredStruct {
+			return
+		}
+		for _, k := range v.MapKeys() {
+			if k.Kind() != reflect.String {
+				panic(fmt.Sprintf("%q: map key is not string: %s", prefix, k))
+			}
+			flatten(getSubKeyPrefix(prefix, k.String()), v.MapIndex(k),
+				primitiveOnly, enteredStruct, filter, output)
+		}
+	case reflect.Struct:
+		if primitiveOnly && enteredStruct {
+			return
+		}
+		enteredStruct = true
+
+		t := v.Type()
+	FIELDS:
+		for i := 0; i < v.NumField(); i++ {
+			name := t.Field(i).Name
+			for _, f := range filter {
+				if name == f {
+					continue FIELDS
+				}
+			}
+
+			// Skip unexported fields
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+
+			flatten(getSubPrefix(prefix, name), v.Field(i), primitiveOnly,
+				enteredStruct, filter, output)
+		}
+	case reflect.Slice, reflect.Array:
+		if primitiveOnly && enteredStruct {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			flatten(getSubKeyPrefix(prefix, fmt.Sprintf("%d", i)), v.Index(i),
+				primitiveOnly, enteredStruct, filter, output)
+		}
+	}
+}
+
+// getSubPrefix takes the current prefix and the next subfield and returns an
+// appropriate prefix.
+func getSubPrefix(curPrefix, subField string) string {
+	if curPrefix != "" {
+		return fmt.Sprintf("%s.%s", curPrefix, subField)
+	}
+	return subField
+}
+
+// getSubKeyPrefix takes the current prefix and the next subfield and returns
+// an appropriate prefix for a map or slice entry.
+func getSubKeyPrefix(curPrefix, subField string) string {
+	if curPrefix != "" {
+		return fmt.Sprintf("%s[%s]", curPrefix, subField)
+	}
+	return subField
+}
@@ -0,0 +1,115 @@
+package flatmap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type simpleTypes struct {
+	b   bool
+	B   bool
+	I   int
+	U   uint64
+	F   float64
+	S   string
+	D   time.Duration
+	Ptr *int
+}
+
+type containers struct {
+	Simple  simpleTypes
+	Pointer *simpleTypes
+	Map     map[string]string
+	Slice   []string
+}
+
+func TestFlatMap(t *testing.T) {
+	cases := []struct {
+		Input         interface{}
+		Filter        []string
+		PrimitiveOnly bool
+		Expected      map[string]string
+	}{
+		{
+			Input:    nil,
+			Expected: nil,
+		},
+		{
+			Input: &simpleTypes{
+				b: true,
+				B: true,
+				I: -10,
+				U: 10,
+				F: 1.5,
+				S: "foo",
+				D: 2 * time.Second,
+			},
+			Expected: map[string]string{
+				"B": "true",
+				"I": "-10",
+				"U": "10",
+				"F": "1.5",
+				"S": "foo",
+				"D": "2s",
+			},
+		},
+		{
+			Input: &simpleTypes{
+				I: 1,
+				S: "foo",
+			},
+			Filter: []string{"I", "D"},
+			Expected: map[string]string{
+				"B": "false",
+				"U": "0",
+				"F": "0",
+				"S": "foo",
+			},
+		},
+		{
+			Input: &containers{
+				Simple: simpleTypes{S: "foo"},
+				Map: map[string]string{
+					"a": "b",
+				},
+				Slice: []string{"x", "y"},
+			},
+			Filter: []string{"B", "I", "U", "F", "D"},
+			Expected: map[string]string{
+				"Simple.S": "foo",
+				"Map[a]":   "b",
+				"Slice[0]": "x",
+				"Slice[1]": "y",
+			},
+		},
+		{
+			Input: &containers{
+				Simple: simpleTypes{S: "foo"},
+				Map: map[string]string{
+					"a": "b",
+				},
+				Slice: []string{"x", "y"},
+			},
+			PrimitiveOnly: true,
+			Expected:      map[string]string{},
+		},
+		{
+			Input: &simpleTypes{
+				S: "foo",
+			},
+			Filter:        []string{"B", "I", "U", "F", "D"},
+			PrimitiveOnly: true,
+			Expected: map[string]string{
+				"S": "foo",
+			},
+		},
+	}
+
+	for i, c := range cases {
+		act := Flatten(c.Input, c.Filter, c.PrimitiveOnly)
+		if !reflect.DeepEqual(act, c.Expected) {
+			t.Fatalf("case %d: got %#v; want %#v", i+1, act, c.Expected)
+		}
+	}
+}
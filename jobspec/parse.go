@@ -88,8 +88,11 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		return err
 	}
 	delete(m, "constraint")
+	delete(m, "spread")
 	delete(m, "meta")
 	delete(m, "update")
+	delete(m, "periodic")
+	delete(m, "parameterized")
 
 	// Set the ID and name to the object key
 	result.ID = obj.Keys[0].Token.Value().(string)
@@ -120,6 +123,13 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse spreads
+	if o := listVal.Filter("spread"); len(o.Items) > 0 {
+		if err := parseSpreads(&result.Spreads, o); err != nil {
+			return err
+		}
+	}
+
 	// If we have an update strategy, then parse that
 	if o := listVal.Filter("update"); len(o.Items) > 0 {
 		if err := parseUpdate(&result.Update, o); err != nil {
@@ -127,6 +137,20 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		}
 	}
 
+	// If we have a periodic definition, then parse that
+	if o := listVal.Filter("periodic"); len(o.Items) > 0 {
+		if err := parsePeriodic(&result.Periodic, o); err != nil {
+			return err
+		}
+	}
+
+	// If we have a parameterized definition, then parse that
+	if o := listVal.Filter("parameterized"); len(o.Items) > 0 {
+		if err := parseParameterizedJob(&result.ParameterizedJob, o); err != nil {
+			return err
+		}
+	}
+
 	// Parse out meta fields. These are in HCL as a list so we need
 	// to iterate over them and merge them.
 	if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -144,7 +168,7 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 	// If we have tasks outside, create TaskGroups for them
 	if o := listVal.Filter("task"); len(o.Items) > 0 {
 		var tasks []*structs.Task
-		if err := parseTasks(&tasks, o); err != nil {
+		if err := parseTasks(result.Name, "", &tasks, o); err != nil {
 			return err
 		}
 
@@ -200,9 +224,13 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 			return err
 		}
 		delete(m, "constraint")
+		delete(m, "spread")
 		delete(m, "meta")
 		delete(m, "task")
 		delete(m, "restart")
+		delete(m, "ephemeral_disk")
+		delete(m, "migrate")
+		delete(m, "volume")
 
 		// Default count to 1 if not specified
 		if _, ok := m["count"]; !ok {
@@ -222,6 +250,13 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 				return err
 			}
 		}
+
+		// Parse spreads
+		if o := listVal.Filter("spread"); len(o.Items) > 0 {
+			if err := parseSpreads(&g.Spreads, o); err != nil {
+				return fmt.Errorf("group '%s': %v", n, err)
+			}
+		}
 		g.RestartPolicy = structs.NewRestartPolicy(result.Type)
 
 		// Parse restart policy
@@ -231,6 +266,27 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse the ephemeral disk
+		if o := listVal.Filter("ephemeral_disk"); len(o.Items) > 0 {
+			if err := parseEphemeralDisk(&g.EphemeralDisk, o); err != nil {
+				return fmt.Errorf("group '%s': %v", n, err)
+			}
+		}
+
+		// Parse the migrate strategy
+		if o := listVal.Filter("migrate"); len(o.Items) > 0 {
+			if err := parseMigrate(&g.Migrate, o); err != nil {
+				return fmt.Errorf("group '%s': %v", n, err)
+			}
+		}
+
+		// Parse the volumes
+		if o := listVal.Filter("volume"); len(o.Items) > 0 {
+			if err := parseVolumes(&g.Volumes, o); err != nil {
+				return fmt.Errorf("group '%s': %v", n, err)
+			}
+		}
+
 		// Parse out meta fields. These are in HCL as a list so we need
 		// to iterate over them and merge them.
 		if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -247,7 +303,7 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 
 		// Parse tasks
 		if o := listVal.Filter("task"); len(o.Items) > 0 {
-			if err := parseTasks(&g.Tasks, o); err != nil {
+			if err := parseTasks(result.Name, g.Name, &g.Tasks, o); err != nil {
 				return err
 			}
 		}
@@ -293,6 +349,101 @@ func parseRestartPolicy(final *structs.RestartPolicy, list *ast.ObjectList) erro
 	return nil
 }
 
+func parseEphemeralDisk(result **structs.EphemeralDisk, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'ephemeral_disk' block allowed")
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, list.Items[0].Val); err != nil {
+		return err
+	}
+
+	// Unset fields keep their defaults
+	disk := structs.DefaultEphemeralDisk()
+	if err := mapstructure.WeakDecode(m, disk); err != nil {
+		return err
+	}
+	*result = disk
+	return nil
+}
+
+func parseMigrate(result **structs.MigrateStrategy, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'migrate' block allowed")
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, list.Items[0].Val); err != nil {
+		return err
+	}
+
+	// Unset fields keep their defaults
+	migrate := structs.DefaultMigrateStrategy()
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           migrate,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+	*result = migrate
+	return nil
+}
+
+func parseVolumes(result *map[string]*structs.VolumeRequest, list *ast.ObjectList) error {
+	list = list.Children()
+	volumes := make(map[string]*structs.VolumeRequest, len(list.Items))
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+		if _, ok := volumes[n]; ok {
+			return fmt.Errorf("volume '%s' defined more than once", n)
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		// Volumes are host volumes by default
+		volume := &structs.VolumeRequest{
+			Name: n,
+			Type: structs.VolumeTypeHost,
+		}
+		if err := mapstructure.WeakDecode(m, volume); err != nil {
+			return err
+		}
+		volumes[n] = volume
+	}
+
+	*result = volumes
+	return nil
+}
+
+func parseVolumeMounts(result *[]*structs.VolumeMount, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		var mount structs.VolumeMount
+		if err := mapstructure.WeakDecode(m, &mount); err != nil {
+			return err
+		}
+
+		*result = append(*result, &mount)
+	}
+
+	return nil
+}
+
 func parseConstraints(result *[]*structs.Constraint, list *ast.ObjectList) error {
 	for _, o := range list.Elem().Items {
 		var m map[string]interface{}
@@ -346,7 +497,53 @@ func parseConstraints(result *[]*structs.Constraint, list *ast.ObjectList) error
 	return nil
 }
 
-func parseTasks(result *[]*structs.Task, list *ast.ObjectList) error {
+func parseSpreads(result *[]*structs.Spread, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+		delete(m, "target")
+
+		// Spreads are weighed equally by default
+		spread := &structs.Spread{Weight: 50}
+		if err := mapstructure.WeakDecode(m, spread); err != nil {
+			return err
+		}
+
+		// Parse the targets, which are keyed by their attribute value
+		var listVal *ast.ObjectList
+		if ot, ok := o.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("spread: should be an object")
+		}
+		seen := make(map[string]struct{})
+		for _, item := range listVal.Filter("target").Children().Items {
+			value := item.Keys[0].Token.Value().(string)
+			if _, ok := seen[value]; ok {
+				return fmt.Errorf("spread target '%s' defined more than once", value)
+			}
+			seen[value] = struct{}{}
+
+			var tm map[string]interface{}
+			if err := hcl.DecodeObject(&tm, item.Val); err != nil {
+				return err
+			}
+			target := &structs.SpreadTarget{Value: value}
+			if err := mapstructure.WeakDecode(tm, target); err != nil {
+				return err
+			}
+			spread.Targets = append(spread.Targets, target)
+		}
+
+		*result = append(*result, spread)
+	}
+
+	return nil
+}
+
+func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, list *ast.ObjectList) error {
 	list = list.Children()
 	if len(list.Items) == 0 {
 		return nil
@@ -380,11 +577,26 @@ func parseTasks(result *[]*structs.Task, list *ast.ObjectList) error {
 		delete(m, "constraint")
 		delete(m, "meta")
 		delete(m, "resources")
+		delete(m, "dispatch_payload")
+		delete(m, "service")
+		delete(m, "artifact")
+		delete(m, "template")
+		delete(m, "lifecycle")
+		delete(m, "logs")
+		delete(m, "volume_mount")
 
 		// Build the task
 		var t structs.Task
 		t.Name = n
-		if err := mapstructure.WeakDecode(m, &t); err != nil {
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			Result:           &t,
+		})
+		if err != nil {
+			return err
+		}
+		if err := dec.Decode(m); err != nil {
 			return err
 		}
 
@@ -435,6 +647,39 @@ func parseTasks(result *[]*structs.Task, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse the services
+		if o := listVal.Filter("service"); len(o.Items) > 0 {
+			// Tasks defined outside of a group run in a group of the same name
+			groupName := taskGroupName
+			if groupName == "" {
+				groupName = t.Name
+			}
+			if err := parseServices(jobName, groupName, &t, o); err != nil {
+				return fmt.Errorf("task '%s': %s", t.Name, err)
+			}
+		}
+
+		// Parse the artifacts
+		if o := listVal.Filter("artifact"); len(o.Items) > 0 {
+			if err := parseArtifacts(&t.Artifacts, o); err != nil {
+				return fmt.Errorf("task '%s': %s", t.Name, err)
+			}
+		}
+
+		// Parse the templates
+		if o := listVal.Filter("template"); len(o.Items) > 0 {
+			if err := parseTemplates(&t.Templates, o); err != nil {
+				return fmt.Errorf("task '%s': %s", t.Name, err)
+			}
+		}
+
+		// Parse the volume mounts
+		if o := listVal.Filter("volume_mount"); len(o.Items) > 0 {
+			if err := parseVolumeMounts(&t.VolumeMounts, o); err != nil {
+				return fmt.Errorf("task '%s': %s", t.Name, err)
+			}
+		}
+
 		// If we have resources, then parse that
 		if o := listVal.Filter("resources"); len(o.Items) > 0 {
 			var r structs.Resources
@@ -445,12 +690,200 @@ func parseTasks(result *[]*structs.Task, list *ast.ObjectList) error {
 			t.Resources = &r
 		}
 
+		// If we have a dispatch payload block parse that
+		if o := listVal.Filter("dispatch_payload"); len(o.Items) > 0 {
+			if len(o.Items) > 1 {
+				return fmt.Errorf("only one dispatch_payload block is allowed in a task. Number of dispatch_payload blocks found: %d", len(o.Items))
+			}
+			var m map[string]interface{}
+			dispatchBlock := o.Items[0]
+			if err := hcl.DecodeObject(&m, dispatchBlock.Val); err != nil {
+				return err
+			}
+
+			t.DispatchPayload = &structs.DispatchPayloadConfig{}
+			if err := mapstructure.WeakDecode(m, t.DispatchPayload); err != nil {
+				return err
+			}
+		}
+
+		// If we have a lifecycle block parse that
+		if o := listVal.Filter("lifecycle"); len(o.Items) > 0 {
+			if len(o.Items) > 1 {
+				return fmt.Errorf("task '%s': only one lifecycle block is allowed in a task", t.Name)
+			}
+			var m map[string]interface{}
+			if err := hcl.DecodeObject(&m, o.Items[0].Val); err != nil {
+				return err
+			}
+
+			t.Lifecycle = &structs.TaskLifecycleConfig{}
+			if err := mapstructure.WeakDecode(m, t.Lifecycle); err != nil {
+				return err
+			}
+		}
+
+		// If we have a logs block parse that
+		if o := listVal.Filter("logs"); len(o.Items) > 0 {
+			if len(o.Items) > 1 {
+				return fmt.Errorf("task '%s': only one logs block is allowed in a task", t.Name)
+			}
+			var m map[string]interface{}
+			if err := hcl.DecodeObject(&m, o.Items[0].Val); err != nil {
+				return err
+			}
+
+			t.LogConfig = &structs.LogConfig{}
+			if err := mapstructure.WeakDecode(m, t.LogConfig); err != nil {
+				return err
+			}
+		}
+
 		*result = append(*result, &t)
 	}
 
 	return nil
 }
 
+func parseArtifacts(result *[]*structs.TaskArtifact, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+		delete(m, "options")
+
+		var ta structs.TaskArtifact
+		if err := mapstructure.WeakDecode(m, &ta); err != nil {
+			return err
+		}
+
+		// The options are a block so they are decoded separately
+		if ot, ok := o.Val.(*ast.ObjectType); ok {
+			if oo := ot.List.Filter("options"); len(oo.Items) > 0 {
+				if len(oo.Items) > 1 {
+					return fmt.Errorf("only one 'options' block allowed per artifact")
+				}
+				var options map[string]interface{}
+				if err := hcl.DecodeObject(&options, oo.Items[0].Val); err != nil {
+					return err
+				}
+				if err := mapstructure.WeakDecode(options, &ta.GetterOptions); err != nil {
+					return err
+				}
+			}
+		}
+
+		*result = append(*result, &ta)
+	}
+
+	return nil
+}
+
+func parseTemplates(result *[]*structs.Template, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		// Templates restart the task when they change by default
+		templ := structs.Template{
+			ChangeMode: structs.TemplateChangeModeRestart,
+		}
+		if err := mapstructure.WeakDecode(m, &templ); err != nil {
+			return err
+		}
+
+		*result = append(*result, &templ)
+	}
+
+	return nil
+}
+
+func parseServices(jobName string, taskGroupName string, task *structs.Task, list *ast.ObjectList) error {
+	if len(list.Items) == 0 {
+		return nil
+	}
+
+	task.Services = make([]*structs.Service, len(list.Items))
+	for idx, o := range list.Items {
+		// We need this later
+		var listVal *ast.ObjectList
+		if ot, ok := o.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("service: should be an object")
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+		delete(m, "check")
+
+		var service structs.Service
+		if err := mapstructure.WeakDecode(m, &service); err != nil {
+			return err
+		}
+
+		// A single service defaults to a name derived from the job, group
+		// and task while multiple services must be named explicitly.
+		if service.Name == "" {
+			if len(list.Items) > 1 {
+				return fmt.Errorf("service %d: a name is required when defining multiple services", idx)
+			}
+			service.Name = fmt.Sprintf("%s-%s-%s", jobName, taskGroupName, task.Name)
+		}
+
+		// Parse the checks
+		if co := listVal.Filter("check"); len(co.Items) > 0 {
+			if err := parseChecks(&service, co); err != nil {
+				return fmt.Errorf("service '%s': %s", service.Name, err)
+			}
+		}
+
+		task.Services[idx] = &service
+	}
+
+	return nil
+}
+
+func parseChecks(service *structs.Service, list *ast.ObjectList) error {
+	service.Checks = make([]*structs.ServiceCheck, len(list.Items))
+	for idx, o := range list.Items {
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		var check structs.ServiceCheck
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			Result:           &check,
+		})
+		if err != nil {
+			return err
+		}
+		if err := dec.Decode(m); err != nil {
+			return err
+		}
+
+		// A single check defaults to a name derived from the service
+		if check.Name == "" {
+			if len(list.Items) > 1 {
+				return fmt.Errorf("check %d: a name is required when defining multiple checks", idx)
+			}
+			check.Name = fmt.Sprintf("service: %q check", service.Name)
+		}
+
+		service.Checks[idx] = &check
+	}
+
+	return nil
+}
+
 func parseResources(result *structs.Resources, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) == 0 {
@@ -543,3 +976,86 @@ func parseUpdate(result *structs.UpdateStrategy, list *ast.ObjectList) error {
 	}
 	return dec.Decode(m)
 }
+
+func parsePeriodic(result **structs.PeriodicConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'periodic' block allowed per job")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Enabled by default if the periodic block exists.
+	if value, ok := m["enabled"]; !ok {
+		m["Enabled"] = true
+	} else {
+		enabled, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("periodic.enabled should be set to true or false; %v", err)
+		}
+		m["Enabled"] = enabled
+	}
+	delete(m, "enabled")
+
+	// If "cron" is provided, set the type to "cron" and store the spec.
+	if cron, ok := m["cron"]; ok {
+		m["SpecType"] = structs.PeriodicSpecCron
+		m["Spec"] = cron
+	}
+	delete(m, "cron")
+
+	// Build the periodic config.
+	var p structs.PeriodicConfig
+	if err := mapstructure.WeakDecode(m, &p); err != nil {
+		return err
+	}
+	*result = &p
+	return nil
+}
+
+func parseParameterizedJob(result **structs.ParameterizedJobConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'parameterized' block allowed per job")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Build the parameterized job block
+	var d structs.ParameterizedJobConfig
+	if err := mapstructure.WeakDecode(m, &d); err != nil {
+		return err
+	}
+
+	*result = &d
+	return nil
+}
+
+// parseBool takes an interface value and tries to convert it to a boolean and
+// returns an error if the type can't be converted.
+func parseBool(value interface{}) (bool, error) {
+	var enabled bool
+	var err error
+	switch value.(type) {
+	case string:
+		enabled, err = strconv.ParseBool(value.(string))
+	case bool:
+		enabled = value.(bool)
+	default:
+		err = fmt.Errorf("%v couldn't be converted to boolean value", value)
+	}
+
+	return enabled, err
+}
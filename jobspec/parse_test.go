@@ -39,9 +39,20 @@ func TestParse(t *testing.T) {
 					},
 				},
 
+				Spreads: []*structs.Spread{
+					&structs.Spread{
+						Attribute: "$meta.rack",
+						Weight:    50,
+					},
+				},
+
 				Update: structs.UpdateStrategy{
-					Stagger:     60 * time.Second,
-					MaxParallel: 2,
+					Stagger:         60 * time.Second,
+					MaxParallel:     2,
+					Canary:          1,
+					MinHealthyTime:  10 * time.Second,
+					HealthyDeadline: 5 * time.Minute,
+					AutoRevert:      true,
 				},
 
 				TaskGroups: []*structs.TaskGroup{
@@ -87,6 +98,35 @@ func TestParse(t *testing.T) {
 							Attempts: 5,
 							Delay:    15 * time.Second,
 						},
+						EphemeralDisk: &structs.EphemeralDisk{
+							SizeMB:  structs.DefaultEphemeralDiskSizeMB,
+							Sticky:  true,
+							Migrate: true,
+						},
+						Migrate: &structs.MigrateStrategy{
+							MaxParallel:     2,
+							HealthCheck:     structs.MigrateHealthCheckTaskStates,
+							MinHealthyTime:  30 * time.Second,
+							HealthyDeadline: 5 * time.Minute,
+						},
+						Spreads: []*structs.Spread{
+							&structs.Spread{
+								Attribute: "$node.datacenter",
+								Weight:    80,
+								Targets: []*structs.SpreadTarget{
+									&structs.SpreadTarget{Value: "us2", Percent: 70},
+									&structs.SpreadTarget{Value: "eu1", Percent: 30},
+								},
+							},
+						},
+						Volumes: map[string]*structs.VolumeRequest{
+							"certs": &structs.VolumeRequest{
+								Name:     "certs",
+								Type:     structs.VolumeTypeHost,
+								Source:   "ca-certs",
+								ReadOnly: true,
+							},
+						},
 						Tasks: []*structs.Task{
 							&structs.Task{
 								Name:   "binstore",
@@ -94,10 +134,68 @@ func TestParse(t *testing.T) {
 								Config: map[string]string{
 									"image": "hashicorp/binstore",
 								},
+								KillTimeout: 22 * time.Second,
+								KillSignal:  "SIGTERM",
+								LogConfig: &structs.LogConfig{
+									MaxFiles:      5,
+									MaxFileSizeMB: 20,
+								},
+								VolumeMounts: []*structs.VolumeMount{
+									&structs.VolumeMount{
+										Volume:      "certs",
+										Destination: "/etc/ssl/certs",
+									},
+								},
 								Env: map[string]string{
 									"HELLO": "world",
 									"LOREM": "ipsum",
 								},
+								Artifacts: []*structs.TaskArtifact{
+									&structs.TaskArtifact{
+										GetterSource: "http://foo.com/artifact",
+										GetterOptions: map[string]string{
+											"checksum": "md5:b8a4f3f72ecab0510a6a31e997461c5f",
+										},
+									},
+									&structs.TaskArtifact{
+										GetterSource: "http://bar.com/artifact.tar.gz",
+										RelativeDest: "local/bar",
+									},
+								},
+								Templates: []*structs.Template{
+									&structs.Template{
+										SourcePath:   "local/file.yml.tpl",
+										DestPath:     "local/file.yml",
+										ChangeMode:   structs.TemplateChangeModeSignal,
+										ChangeSignal: "SIGHUP",
+									},
+									&structs.Template{
+										EmbeddedTmpl: "{{ env \"NOMAD_IP\" }}",
+										DestPath:     "local/ip.txt",
+										ChangeMode:   structs.TemplateChangeModeRestart,
+									},
+								},
+								Services: []*structs.Service{
+									&structs.Service{
+										Name:      "binstore-storagelocker-binsl-binstore",
+										Tags:      []string{"foo", "bar"},
+										PortLabel: "http",
+										Checks: []*structs.ServiceCheck{
+											&structs.ServiceCheck{
+												Name:     "check-name",
+												Type:     "http",
+												Path:     "/health",
+												Interval: 10 * time.Second,
+												Timeout:  2 * time.Second,
+											},
+											&structs.ServiceCheck{
+												Name:     "check-tcp",
+												Type:     "tcp",
+												Interval: 5 * time.Second,
+											},
+										},
+									},
+								},
 								Resources: &structs.Resources{
 									CPU:      500,
 									MemoryMB: 128,
@@ -127,6 +225,10 @@ func TestParse(t *testing.T) {
 										Operand: "=",
 									},
 								},
+								Lifecycle: &structs.TaskLifecycleConfig{
+									Hook:    structs.TaskLifecycleHookPrestart,
+									Sidecar: true,
+								},
 							},
 						},
 					},
@@ -147,6 +249,12 @@ func TestParse(t *testing.T) {
 			true,
 		},
 
+		{
+			"multi-service.hcl",
+			nil,
+			true,
+		},
+
 		{
 			"default-job.hcl",
 			&structs.Job{
@@ -225,6 +333,61 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+
+		{
+			"periodic-cron.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Priority: 50,
+				Region:   "global",
+				Type:     "batch",
+				Periodic: &structs.PeriodicConfig{
+					Enabled:         true,
+					SpecType:        structs.PeriodicSpecCron,
+					Spec:            "*/5 * * *",
+					ProhibitOverlap: true,
+					TimeZone:        "Europe/Minsk",
+				},
+			},
+			false,
+		},
+
+		{
+			"parameterized_job.hcl",
+			&structs.Job{
+				ID:       "parameterized_job",
+				Name:     "parameterized_job",
+				Priority: 50,
+				Region:   "global",
+				Type:     "batch",
+				ParameterizedJob: &structs.ParameterizedJobConfig{
+					Payload:      "required",
+					MetaRequired: []string{"foo", "bar"},
+					MetaOptional: []string{"baz", "bam"},
+				},
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:  "foo",
+						Count: 1,
+						RestartPolicy: &structs.RestartPolicy{
+							Attempts: 15,
+							Delay:    15 * time.Second,
+						},
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:   "bar",
+								Driver: "docker",
+								DispatchPayload: &structs.DispatchPayloadConfig{
+									File: "foo/bar",
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
 	}
 
 	for _, tc := range cases {
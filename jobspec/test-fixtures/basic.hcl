@@ -14,9 +14,17 @@ job "binstore-storagelocker" {
         value = "windows"
     }
 
+    spread {
+        attribute = "$meta.rack"
+    }
+
     update {
         stagger = "60s"
         max_parallel = 2
+        canary = 1
+        min_healthy_time = "10s"
+        healthy_deadline = "5m"
+        auto_revert = true
     }
 
     task "outside" {
@@ -36,15 +44,83 @@ job "binstore-storagelocker" {
             interval = "10m"
             delay = "15s"
         }
+        ephemeral_disk {
+            sticky = true
+            migrate = true
+        }
+        migrate {
+            max_parallel = 2
+            min_healthy_time = "30s"
+        }
+        spread {
+            attribute = "$node.datacenter"
+            weight = 80
+            target "us2" {
+                percent = 70
+            }
+            target "eu1" {
+                percent = 30
+            }
+        }
+        volume "certs" {
+            source = "ca-certs"
+            read_only = true
+        }
         task "binstore" {
             driver = "docker"
+            kill_timeout = "22s"
+            kill_signal = "SIGTERM"
+            volume_mount {
+                volume = "certs"
+                destination = "/etc/ssl/certs"
+            }
+            logs {
+                max_files = 5
+                max_file_size = 20
+            }
             config {
                 image = "hashicorp/binstore"
             }
+            artifact {
+                source = "http://foo.com/artifact"
+                options {
+                    checksum = "md5:b8a4f3f72ecab0510a6a31e997461c5f"
+                }
+            }
+            artifact {
+                source = "http://bar.com/artifact.tar.gz"
+                destination = "local/bar"
+            }
+            template {
+                source = "local/file.yml.tpl"
+                destination = "local/file.yml"
+                change_mode = "signal"
+                change_signal = "SIGHUP"
+            }
+            template {
+                data = "{{ env \"NOMAD_IP\" }}"
+                destination = "local/ip.txt"
+            }
             env {
               HELLO = "world"
               LOREM = "ipsum"
             }
+            service {
+                tags = ["foo", "bar"]
+                port = "http"
+                check {
+                    name = "check-name"
+                    type = "http"
+                    path = "/health"
+                    interval = "10s"
+                    timeout = "2s"
+                }
+                check {
+                    name = "check-tcp"
+                    type = "tcp"
+                    interval = "5s"
+                }
+            }
             resources {
                 cpu = 500
                 memory = 128
@@ -70,6 +146,10 @@ job "binstore-storagelocker" {
                 attribute = "kernel.arch"
                 value = "amd64"
             }
+            lifecycle {
+                hook = "prestart"
+                sidecar = true
+            }
         }
 
         constraint {
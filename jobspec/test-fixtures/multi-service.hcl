@@ -0,0 +1,15 @@
+job "binstore-storagelocker" {
+    group "binsl" {
+        task "binstore" {
+            driver = "docker"
+
+            service {
+                port = "http"
+            }
+
+            service {
+                port = "admin"
+            }
+        }
+    }
+}
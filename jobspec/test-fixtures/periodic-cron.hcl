@@ -0,0 +1,9 @@
+job "foo" {
+    type = "batch"
+
+    periodic {
+        cron = "*/5 * * *"
+        prohibit_overlap = true
+        time_zone = "Europe/Minsk"
+    }
+}
@@ -2,6 +2,7 @@ package nomad
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/hashicorp/nomad/nomad/state"
@@ -33,27 +34,156 @@ func (s *CoreScheduler) Process(eval *structs.Evaluation) error {
 		return s.evalGC(eval)
 	case structs.CoreJobNodeGC:
 		return s.nodeGC(eval)
+	case structs.CoreJobJobGC:
+		return s.jobGC(eval)
+	case structs.CoreJobForceGC:
+		return s.forceGC(eval)
 	default:
 		return fmt.Errorf("core scheduler cannot handle job '%s'", eval.JobID)
 	}
 }
 
-// evalGC is used to garbage collect old evaluations
-func (c *CoreScheduler) evalGC(eval *structs.Evaluation) error {
-	// Iterate over the evaluations
-	iter, err := c.snap.Evals()
-	if err != nil {
+// forceGC is used to garbage collect all eligible objects regardless of
+// their age
+func (c *CoreScheduler) forceGC(eval *structs.Evaluation) error {
+	if err := c.jobGC(eval); err != nil {
 		return err
 	}
+	if err := c.evalGC(eval); err != nil {
+		return err
+	}
+	return c.nodeGC(eval)
+}
+
+// getThreshold returns the Raft index before which objects are old enough to
+// be garbage collected. Everything is old enough if the GC is forced.
+func (c *CoreScheduler) getThreshold(eval *structs.Evaluation, objName string, threshold time.Duration) uint64 {
+	if eval.JobID == structs.CoreJobForceGC {
+		c.srv.logger.Printf("[DEBUG] sched.core: forced %s GC", objName)
+		return math.MaxUint64
+	}
 
 	// Compute the old threshold limit for GC using the FSM
 	// time table.  This is a rough mapping of a time to the
 	// Raft index it belongs to.
 	tt := c.srv.fsm.TimeTable()
-	cutoff := time.Now().UTC().Add(-1 * c.srv.config.EvalGCThreshold)
+	cutoff := time.Now().UTC().Add(-1 * threshold)
 	oldThreshold := tt.NearestIndex(cutoff)
-	c.srv.logger.Printf("[DEBUG] sched.core: eval GC: scanning before index %d (%v)",
-		oldThreshold, c.srv.config.EvalGCThreshold)
+	c.srv.logger.Printf("[DEBUG] sched.core: %s GC: scanning before index %d (%v)",
+		objName, oldThreshold, threshold)
+	return oldThreshold
+}
+
+// jobGC is used to garbage collect batch jobs whose evaluations and
+// allocations are all terminal and old
+func (c *CoreScheduler) jobGC(eval *structs.Evaluation) error {
+	// Only batch jobs complete, all other jobs run until deregistered
+	iter, err := c.snap.JobsByScheduler(structs.JobTypeBatch)
+	if err != nil {
+		return err
+	}
+	oldThreshold := c.getThreshold(eval, "job", c.srv.config.JobGCThreshold)
+
+	// Collect the jobs, evaluations and allocations to GC
+	var gcJob, gcAlloc, gcEval []string
+
+OUTER:
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		job := raw.(*structs.Job)
+
+		// Ignore new jobs and jobs launching children
+		if job.ModifyIndex > oldThreshold || job.IsPeriodic() || job.IsParameterized() {
+			continue
+		}
+
+		evals, err := c.snap.EvalsByJob(job.ID)
+		if err != nil {
+			c.srv.logger.Printf("[ERR] sched.core: failed to get evals for job %s: %v",
+				job.ID, err)
+			continue
+		}
+		for _, eval := range evals {
+			if !eval.TerminalStatus() || eval.ModifyIndex > oldThreshold {
+				continue OUTER
+			}
+		}
+
+		allocs, err := c.snap.AllocsByJob(job.ID)
+		if err != nil {
+			c.srv.logger.Printf("[ERR] sched.core: failed to get allocs for job %s: %v",
+				job.ID, err)
+			continue
+		}
+		// The allocations of a completed job are only terminal on the client
+		for _, alloc := range allocs {
+			terminal := alloc.TerminalStatus() || alloc.ClientTerminalStatus()
+			if !terminal || alloc.ModifyIndex > oldThreshold {
+				continue OUTER
+			}
+		}
+
+		// Job is eligible for garbage collection
+		gcJob = append(gcJob, job.ID)
+		for _, eval := range evals {
+			gcEval = append(gcEval, eval.ID)
+		}
+		for _, alloc := range allocs {
+			gcAlloc = append(gcAlloc, alloc.ID)
+		}
+	}
+
+	// Fast-path the nothing case
+	if len(gcJob) == 0 {
+		return nil
+	}
+	c.srv.logger.Printf("[DEBUG] sched.core: job GC: %d jobs, %d evaluations, %d allocs eligible",
+		len(gcJob), len(gcEval), len(gcAlloc))
+
+	// Reap the evaluations and allocations before the jobs
+	if len(gcEval) != 0 || len(gcAlloc) != 0 {
+		req := structs.EvalDeleteRequest{
+			Evals:  gcEval,
+			Allocs: gcAlloc,
+			WriteRequest: structs.WriteRequest{
+				Region: c.srv.config.Region,
+			},
+		}
+		var resp structs.GenericResponse
+		if err := c.srv.RPC("Eval.Reap", &req, &resp); err != nil {
+			c.srv.logger.Printf("[ERR] sched.core: eval reap failed: %v", err)
+			return err
+		}
+	}
+
+	// Call to the leader to deregister the jobs
+	for _, jobID := range gcJob {
+		req := structs.JobDeregisterRequest{
+			JobID: jobID,
+			WriteRequest: structs.WriteRequest{
+				Region: c.srv.config.Region,
+			},
+		}
+		var resp structs.JobDeregisterResponse
+		if err := c.srv.RPC("Job.Deregister", &req, &resp); err != nil {
+			c.srv.logger.Printf("[ERR] sched.core: job '%s' deregister failed: %v", jobID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// evalGC is used to garbage collect old evaluations
+func (c *CoreScheduler) evalGC(eval *structs.Evaluation) error {
+	// Iterate over the evaluations
+	iter, err := c.snap.Evals()
+	if err != nil {
+		return err
+	}
+	oldThreshold := c.getThreshold(eval, "eval", c.srv.config.EvalGCThreshold)
 
 	// Collect the allocations and evaluations to GC
 	var gcAlloc, gcEval []string
@@ -123,15 +253,7 @@ func (c *CoreScheduler) nodeGC(eval *structs.Evaluation) error {
 	if err != nil {
 		return err
 	}
-
-	// Compute the old threshold limit for GC using the FSM
-	// time table.  This is a rough mapping of a time to the
-	// Raft index it belongs to.
-	tt := c.srv.fsm.TimeTable()
-	cutoff := time.Now().UTC().Add(-1 * c.srv.config.NodeGCThreshold)
-	oldThreshold := tt.NearestIndex(cutoff)
-	c.srv.logger.Printf("[DEBUG] sched.core: node GC: scanning before index %d (%v)",
-		oldThreshold, c.srv.config.NodeGCThreshold)
+	oldThreshold := c.getThreshold(eval, "node", c.srv.config.NodeGCThreshold)
 
 	// Collect the nodes to GC
 	var gcNode []string
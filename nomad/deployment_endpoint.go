@@ -0,0 +1,250 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// Deployment endpoint is used for manipulating deployments
+type Deployment struct {
+	srv *Server
+}
+
+// GetDeployment is used to request information about a specific deployment
+func (d *Deployment) GetDeployment(args *structs.DeploymentSpecificRequest,
+	reply *structs.SingleDeploymentResponse) error {
+	if done, err := d.srv.forward("Deployment.GetDeployment", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "get_deployment"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Deployment: args.DeploymentID}),
+		run: func() error {
+			// Look for the deployment
+			snap, err := d.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.DeploymentByID(args.DeploymentID)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Deployment = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the deployment table
+				index, err := snap.Index("deployment")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// List returns the list of deployments in the cluster
+func (d *Deployment) List(args *structs.DeploymentListRequest,
+	reply *structs.DeploymentListResponse) error {
+	if done, err := d.srv.forward("Deployment.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "list"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "deployment"}),
+		run: func() error {
+			// Capture all the deployments
+			snap, err := d.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			iter, err := snap.Deployments()
+			if err != nil {
+				return err
+			}
+
+			var deploys []*structs.Deployment
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				deploys = append(deploys, raw.(*structs.Deployment))
+			}
+			reply.Deployments = deploys
+
+			// Use the last index that affected the deployment table
+			index, err := snap.Index("deployment")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// Promote is used to promote the canaries of a deployment. Promotion creates
+// an evaluation so the scheduler replaces the remaining allocations.
+func (d *Deployment) Promote(args *structs.DeploymentPromoteRequest,
+	reply *structs.DeploymentUpdateResponse) error {
+	if done, err := d.srv.forward("Deployment.Promote", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "promote"}, time.Now())
+
+	// Validate the arguments
+	if args.DeploymentID == "" {
+		return fmt.Errorf("missing deployment ID")
+	}
+	if !args.All && len(args.Groups) == 0 {
+		return fmt.Errorf("must promote all task groups or specify the groups to promote")
+	}
+
+	// Lookup the deployment and create an evaluation for its job
+	deploy, eval, err := d.lookupActive(args.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the canaries have been placed before promoting
+	for name, state := range deploy.TaskGroups {
+		if !args.All && !containsString(args.Groups, name) {
+			continue
+		}
+		if len(state.PlacedCanaries) < state.DesiredCanaries {
+			return fmt.Errorf("task group %q has %d/%d canaries placed",
+				name, len(state.PlacedCanaries), state.DesiredCanaries)
+		}
+	}
+
+	// Commit the promotion and evaluation via Raft
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: *args,
+		Eval:                     eval,
+	}
+
+	resp, index, err := d.srv.raftApply(structs.DeploymentPromoteRequestType, req)
+	if err == nil {
+		err, _ = resp.(error)
+	}
+	if err != nil {
+		d.srv.logger.Printf("[ERR] nomad.deployment: Promote failed: %v", err)
+		return err
+	}
+
+	// Setup the reply
+	reply.EvalID = eval.ID
+	reply.EvalCreateIndex = index
+	reply.DeploymentModifyIndex = index
+	reply.Index = index
+	return nil
+}
+
+// Fail is used to force fail a deployment. Failing the deployment creates an
+// evaluation so the scheduler stops the canaries that were not promoted.
+func (d *Deployment) Fail(args *structs.DeploymentFailRequest,
+	reply *structs.DeploymentUpdateResponse) error {
+	if done, err := d.srv.forward("Deployment.Fail", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "fail"}, time.Now())
+
+	// Validate the arguments
+	if args.DeploymentID == "" {
+		return fmt.Errorf("missing deployment ID")
+	}
+
+	// Lookup the deployment and create an evaluation for its job
+	deploy, eval, err := d.lookupActive(args.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	// Commit the status update and evaluation via Raft
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      deploy.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedByUser,
+		},
+		Eval: eval,
+	}
+	resp, index, err := d.srv.raftApply(structs.DeploymentStatusUpdateRequestType, req)
+	if err == nil {
+		err, _ = resp.(error)
+	}
+	if err != nil {
+		d.srv.logger.Printf("[ERR] nomad.deployment: Fail failed: %v", err)
+		return err
+	}
+
+	// Setup the reply
+	reply.EvalID = eval.ID
+	reply.EvalCreateIndex = index
+	reply.DeploymentModifyIndex = index
+	reply.Index = index
+	return nil
+}
+
+// lookupActive returns the deployment with the given ID along with an
+// evaluation for its job. An error is returned if the deployment doesn't
+// exist or is no longer active.
+func (d *Deployment) lookupActive(id string) (*structs.Deployment, *structs.Evaluation, error) {
+	snap, err := d.srv.fsm.State().Snapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+	deploy, err := snap.DeploymentByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if deploy == nil {
+		return nil, nil, fmt.Errorf("deployment not found")
+	}
+	if !deploy.Active() {
+		return nil, nil, fmt.Errorf("can't modify terminal deployment (status %q)", deploy.Status)
+	}
+
+	job, err := snap.JobByID(deploy.JobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job == nil {
+		return nil, nil, fmt.Errorf("job %q for deployment not found", deploy.JobID)
+	}
+
+	return deploy, newDeploymentEval(job), nil
+}
+
+// containsString returns whether the string is in the list
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
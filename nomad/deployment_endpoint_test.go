@@ -0,0 +1,200 @@
+package nomad
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestDeploymentEndpoint_GetDeployment(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the deployment
+	d := mock.Deployment()
+	if err := s1.fsm.State().UpsertDeployment(1000, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the deployment
+	get := &structs.DeploymentSpecificRequest{
+		DeploymentID: d.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SingleDeploymentResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.GetDeployment", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if !reflect.DeepEqual(d, resp.Deployment) {
+		t.Fatalf("bad: %#v %#v", d, resp.Deployment)
+	}
+
+	// Lookup non-existing deployment
+	get.DeploymentID = structs.GenerateUUID()
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.GetDeployment", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Deployment != nil {
+		t.Fatalf("unexpected deployment")
+	}
+}
+
+func TestDeploymentEndpoint_List(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the deployment
+	d := mock.Deployment()
+	if err := s1.fsm.State().UpsertDeployment(1000, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the deployments
+	get := &structs.DeploymentListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.DeploymentListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if len(resp.Deployments) != 1 || resp.Deployments[0].ID != d.ID {
+		t.Fatalf("bad: %#v", resp.Deployments)
+	}
+}
+
+func TestDeploymentEndpoint_Promote(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the job and a deployment with its canaries placed
+	state := s1.fsm.State()
+	job := mock.Job()
+	job.Update.Canary = 1
+	if err := state.UpsertJob(999, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	d := structs.NewDeployment(job)
+	d.TaskGroups["web"] = &structs.DeploymentState{
+		DesiredCanaries: 1,
+		DesiredTotal:    10,
+	}
+	if err := state.UpsertDeployment(1000, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Promoting before the canaries are placed fails
+	req := &structs.DeploymentPromoteRequest{
+		DeploymentID: d.ID,
+		All:          true,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.DeploymentUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Place the canary
+	alloc := mock.Alloc()
+	alloc.JobID = job.ID
+	alloc.Job = job
+	alloc.DeploymentID = d.ID
+	alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Promote the deployment
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Check the deployment was promoted
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.TaskGroups["web"].Promoted {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Check the evaluation was created
+	eval, err := state.EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil {
+		t.Fatalf("expected eval")
+	}
+	if eval.TriggeredBy != structs.EvalTriggerDeployment || eval.JobID != job.ID {
+		t.Fatalf("bad: %#v", eval)
+	}
+}
+
+func TestDeploymentEndpoint_Fail(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the job and its deployment
+	state := s1.fsm.State()
+	job := mock.Job()
+	if err := state.UpsertJob(999, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := structs.NewDeployment(job)
+	if err := state.UpsertDeployment(1000, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Fail the deployment
+	req := &structs.DeploymentFailRequest{
+		DeploymentID: d.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.DeploymentUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Fail", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Check the deployment failed
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusFailed {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Failing a terminal deployment errors
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Fail", req, &resp); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,259 @@
+package nomad
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// watchDeployments is used to drive the active deployments while we are the
+// leader. The deployments are checked each time a deployment or allocation
+// changes. A deployment fails once one of its allocations is unhealthy,
+// newly healthy allocations trigger an evaluation to continue the rollout and
+// the deployment succeeds once all of its allocations are healthy.
+func (s *Server) watchDeployments(stopCh chan struct{}) {
+	items := watch.NewItems(
+		watch.Item{Table: "deployment"},
+		watch.Item{Table: "allocs"})
+	notifyCh := make(chan struct{}, 1)
+
+	// healthy tracks the number of healthy allocations last seen for each
+	// deployment so that only an increase triggers an evaluation.
+	healthy := make(map[string]int)
+
+	for {
+		// The state store is replaced on restore so it is looked up each time
+		state := s.fsm.State()
+		state.Watch(items, notifyCh)
+
+		if err := s.checkDeployments(healthy); err != nil {
+			s.logger.Printf("[ERR] nomad.deployment: failed to check deployments: %v", err)
+		}
+
+		select {
+		case <-notifyCh:
+			state.StopWatch(items, notifyCh)
+		case <-stopCh:
+			state.StopWatch(items, notifyCh)
+			return
+		}
+	}
+}
+
+// checkDeployments checks each of the active deployments
+func (s *Server) checkDeployments(healthy map[string]int) error {
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	iter, err := snap.Deployments()
+	if err != nil {
+		return err
+	}
+
+	active := make(map[string]struct{})
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		d := raw.(*structs.Deployment)
+		if !d.Active() {
+			continue
+		}
+
+		active[d.ID] = struct{}{}
+		if err := s.checkDeployment(snap, d, healthy); err != nil {
+			s.logger.Printf("[ERR] nomad.deployment: failed to check deployment %q: %v", d.ID, err)
+		}
+	}
+
+	// Stop tracking the deployments that are no longer active
+	for id := range healthy {
+		if _, ok := active[id]; !ok {
+			delete(healthy, id)
+		}
+	}
+	return nil
+}
+
+// checkDeployment fails, continues or completes a single deployment based on
+// the health of its allocations.
+func (s *Server) checkDeployment(snap *state.StateSnapshot, d *structs.Deployment, healthy map[string]int) error {
+	// Deployments that no longer match the job are cancelled by the scheduler
+	job, err := snap.JobByID(d.JobID)
+	if err != nil {
+		return err
+	}
+	if job == nil || job.CreateIndex != d.JobCreateIndex || job.ModifyIndex != d.JobModifyIndex {
+		return nil
+	}
+
+	// Fail the deployment if any of its allocations are unhealthy
+	var numHealthy, numUnhealthy int
+	autoRevert := false
+	for _, state := range d.TaskGroups {
+		numHealthy += state.HealthyAllocs
+		numUnhealthy += state.UnhealthyAllocs
+		autoRevert = autoRevert || state.AutoRevert
+	}
+	if numUnhealthy > 0 {
+		return s.failDeployment(snap, d, job, autoRevert)
+	}
+
+	// Check if the deployment has completed
+	allocs, err := snap.AllocsByJob(d.JobID)
+	if err != nil {
+		return err
+	}
+	if deploymentComplete(d, job, allocs) {
+		return s.updateDeploymentStatus(&structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusSuccessful,
+			StatusDescription: structs.DeploymentStatusDescriptionSuccessful,
+		}, nil)
+	}
+
+	// Continue the rollout when more allocations have become healthy
+	last, ok := healthy[d.ID]
+	healthy[d.ID] = numHealthy
+	if (ok && numHealthy <= last) || (!ok && numHealthy == 0) {
+		return nil
+	}
+
+	eval := newDeploymentEval(job)
+	req := structs.EvalUpdateRequest{
+		Evals: []*structs.Evaluation{eval},
+	}
+	if _, _, err := s.raftApply(structs.EvalUpdateRequestType, &req); err != nil {
+		delete(healthy, d.ID)
+		return fmt.Errorf("failed to create evaluation: %v", err)
+	}
+	return nil
+}
+
+// failDeployment marks the deployment as failed and, if requested, reverts
+// the job to its latest stable version.
+func (s *Server) failDeployment(snap *state.StateSnapshot, d *structs.Deployment, job *structs.Job, autoRevert bool) error {
+	// Find the version to revert to
+	var revert *structs.Job
+	if autoRevert {
+		versions, err := snap.JobVersionsByID(job.ID)
+		if err != nil {
+			return err
+		}
+		for _, v := range versions {
+			if v.Stable && v.Version != job.Version {
+				revert = v
+				break
+			}
+		}
+	}
+
+	desc := structs.DeploymentStatusDescriptionFailedAllocations
+	if revert != nil {
+		desc = fmt.Sprintf("%s - rolling back to job version %d", desc, revert.Version)
+	}
+
+	// Fail the deployment along with an evaluation so that the scheduler
+	// stops the canaries that weren't promoted.
+	u := &structs.DeploymentStatusUpdate{
+		DeploymentID:      d.ID,
+		Status:            structs.DeploymentStatusFailed,
+		StatusDescription: desc,
+	}
+	if err := s.updateDeploymentStatus(u, newDeploymentEval(job)); err != nil {
+		return err
+	}
+	if revert == nil {
+		return nil
+	}
+
+	// Revert the job to its latest stable version
+	s.logger.Printf("[INFO] nomad.deployment: deployment %q failed, reverting job %q to version %d",
+		d.ID, job.ID, revert.Version)
+	args := &structs.JobRevertRequest{
+		JobID:        job.ID,
+		JobVersion:   revert.Version,
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+	var resp structs.JobRegisterResponse
+	if err := s.endpoints.Job.Revert(args, &resp); err != nil {
+		return fmt.Errorf("failed to revert job %q: %v", job.ID, err)
+	}
+	return nil
+}
+
+// updateDeploymentStatus commits a deployment status update and an optional
+// evaluation via Raft.
+func (s *Server) updateDeploymentStatus(u *structs.DeploymentStatusUpdate, eval *structs.Evaluation) error {
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: u,
+		Eval:             eval,
+	}
+	resp, _, err := s.raftApply(structs.DeploymentStatusUpdateRequestType, req)
+	if err == nil {
+		err, _ = resp.(error)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update deployment status: %v", err)
+	}
+	return nil
+}
+
+// deploymentComplete returns whether the deployment has completed. The
+// deployment is complete once it no longer requires promotion, each of its
+// task groups is running at the job's version and all of the allocations
+// placed by the deployment are healthy.
+func deploymentComplete(d *structs.Deployment, job *structs.Job, allocs []*structs.Allocation) bool {
+	if len(d.TaskGroups) == 0 || d.RequiresPromotion() {
+		return false
+	}
+
+	running := make(map[string]int)
+	for _, alloc := range allocs {
+		if alloc.TerminalStatus() {
+			continue
+		}
+		if _, ok := d.TaskGroups[alloc.TaskGroup]; !ok {
+			continue
+		}
+
+		// Allocations of an older version still have to be replaced
+		if alloc.Job == nil || alloc.Job.ModifyIndex != job.ModifyIndex {
+			return false
+		}
+
+		// Allocations placed by the deployment must be healthy
+		if alloc.DeploymentID == d.ID && !alloc.DeploymentStatus.IsHealthy() {
+			return false
+		}
+		running[alloc.TaskGroup]++
+	}
+
+	for _, tg := range job.TaskGroups {
+		if _, ok := d.TaskGroups[tg.Name]; !ok {
+			continue
+		}
+		if running[tg.Name] < tg.Count {
+			return false
+		}
+	}
+	return true
+}
+
+// newDeploymentEval returns an evaluation of the job triggered by a change to
+// its deployment.
+func newDeploymentEval(job *structs.Job) *structs.Evaluation {
+	return &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       job.Priority,
+		Type:           job.Type,
+		TriggeredBy:    structs.EvalTriggerDeployment,
+		JobID:          job.ID,
+		JobModifyIndex: job.ModifyIndex,
+		Status:         structs.EvalStatusPending,
+	}
+}
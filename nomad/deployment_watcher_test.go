@@ -0,0 +1,202 @@
+package nomad
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestDeploymentWatcher_Healthy(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the job and its deployment
+	state := s1.fsm.State()
+	job := mock.Job()
+	job.TaskGroups[0].Count = 2
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := structs.NewDeployment(job)
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2}
+	if err := state.UpsertDeployment(1001, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Place the allocations of the deployment
+	var allocs []*structs.Allocation
+	for i := 0; i < 2; i++ {
+		alloc := mock.Alloc()
+		alloc.JobID = job.ID
+		alloc.Job = job
+		alloc.DeploymentID = d.ID
+		allocs = append(allocs, alloc)
+	}
+	if err := state.UpsertAllocs(1002, allocs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Report the first allocation healthy
+	healthy := true
+	update := new(structs.Allocation)
+	*update = *allocs[0]
+	update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+	if err := state.UpdateAllocFromClient(1003, update); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An evaluation is created to continue the deployment
+	testutil.WaitForResult(func() (bool, error) {
+		evals, err := state.EvalsByJob(job.ID)
+		if err != nil {
+			return false, err
+		}
+		if len(evals) != 1 || evals[0].TriggeredBy != structs.EvalTriggerDeployment {
+			return false, fmt.Errorf("bad: %#v", evals)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Report the second allocation healthy
+	update = new(structs.Allocation)
+	*update = *allocs[1]
+	update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+	if err := state.UpdateAllocFromClient(1004, update); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The deployment completes and the job is marked stable
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusSuccessful {
+			return false, fmt.Errorf("bad: %#v", out)
+		}
+		jobOut, err := state.JobByID(job.ID)
+		if err != nil {
+			return false, err
+		}
+		if !jobOut.Stable {
+			return false, fmt.Errorf("job not stable")
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestDeploymentWatcher_Unhealthy_AutoRevert(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a stable version of the job and update it
+	state := s1.fsm.State()
+	job := mock.Job()
+	job.Stable = true
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	job2 := job.Copy()
+	job2.Stable = false
+	job2.Meta["version"] = "2"
+	if err := state.UpsertJob(1001, job2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	d := structs.NewDeployment(job2)
+	d.TaskGroups["web"] = &structs.DeploymentState{
+		DesiredTotal: 10,
+		AutoRevert:   true,
+	}
+	if err := state.UpsertDeployment(1002, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	alloc := mock.Alloc()
+	alloc.JobID = job2.ID
+	alloc.Job = job2
+	alloc.DeploymentID = d.ID
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Report the allocation unhealthy
+	unhealthy := false
+	update := new(structs.Allocation)
+	*update = *alloc
+	update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &unhealthy}
+	if err := state.UpdateAllocFromClient(1004, update); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The deployment fails and the job is reverted to the stable version
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusFailed {
+			return false, fmt.Errorf("bad: %#v", out)
+		}
+		jobOut, err := state.JobByID(job.ID)
+		if err != nil {
+			return false, err
+		}
+		if jobOut.Version != 2 || jobOut.Meta["version"] != "" || jobOut.Stable {
+			return false, fmt.Errorf("bad: %#v", jobOut)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestDeploymentComplete(t *testing.T) {
+	job := mock.Job()
+	job.ModifyIndex = 10
+	job.TaskGroups[0].Count = 1
+	d := structs.NewDeployment(job)
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 1}
+
+	healthy := true
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.DeploymentID = d.ID
+	alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+	if !deploymentComplete(d, job, []*structs.Allocation{alloc}) {
+		t.Fatalf("expected deployment to be complete")
+	}
+
+	// An allocation of an older version blocks completion
+	old := mock.Alloc()
+	old.Job = job.Copy()
+	old.Job.ModifyIndex = 5
+	if deploymentComplete(d, job, []*structs.Allocation{alloc, old}) {
+		t.Fatalf("expected deployment to be incomplete")
+	}
+
+	// Stopped allocations are ignored
+	old.DesiredStatus = structs.AllocDesiredStatusStop
+	if !deploymentComplete(d, job, []*structs.Allocation{alloc, old}) {
+		t.Fatalf("expected deployment to be complete")
+	}
+
+	// Allocations without health block completion
+	alloc.DeploymentStatus = nil
+	if deploymentComplete(d, job, []*structs.Allocation{alloc}) {
+		t.Fatalf("expected deployment to be incomplete")
+	}
+}
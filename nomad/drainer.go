@@ -0,0 +1,274 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// drainNodes is used to drive the drains of nodes while we are the leader.
+// The drains are checked each time a node or allocation changes and when the
+// deadline of a drain is reached. The allocations of service jobs are
+// migrated in batches that respect the migrate strategy of their task group,
+// the allocations of batch jobs are left to complete and the allocations of
+// system jobs are stopped last. Once the deadline is reached the remaining
+// allocations are stopped.
+func (s *Server) drainNodes(stopCh chan struct{}) {
+	items := watch.NewItems(
+		watch.Item{Table: "nodes"},
+		watch.Item{Table: "allocs"})
+	notifyCh := make(chan struct{}, 1)
+
+	for {
+		// The state store is replaced on restore so it is looked up each time
+		state := s.fsm.State()
+		state.Watch(items, notifyCh)
+
+		next, err := s.checkDrains()
+		if err != nil {
+			s.logger.Printf("[ERR] nomad.drain: failed to check drains: %v", err)
+		}
+
+		// Wake up at the next deadline of a drain
+		var deadlineCh <-chan time.Time
+		if !next.IsZero() {
+			deadlineCh = time.After(next.Sub(time.Now()))
+		}
+
+		select {
+		case <-notifyCh:
+		case <-deadlineCh:
+		case <-stopCh:
+			state.StopWatch(items, notifyCh)
+			return
+		}
+		state.StopWatch(items, notifyCh)
+	}
+}
+
+// checkDrains checks each of the draining nodes and returns the earliest
+// deadline of the drains that is yet to be reached
+func (s *Server) checkDrains() (time.Time, error) {
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		return time.Time{}, err
+	}
+	iter, err := snap.Nodes()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	var next time.Time
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		node := raw.(*structs.Node)
+		if node.DrainStrategy == nil {
+			continue
+		}
+
+		if has, deadline := node.DrainStrategy.DeadlineTime(); has && deadline.After(now) {
+			if next.IsZero() || deadline.Before(next) {
+				next = deadline
+			}
+		}
+		if err := s.drainNode(snap, node, now); err != nil {
+			s.logger.Printf("[ERR] nomad.drain: failed to drain node %q: %v", node.ID, err)
+		}
+	}
+	return next, nil
+}
+
+// drainNode marks the allocations of the node that may be migrated now and
+// completes the drain once no allocations remain.
+func (s *Server) drainNode(snap *state.StateSnapshot, node *structs.Node, now time.Time) error {
+	allocs, err := snap.AllocsByNode(node.ID)
+	if err != nil {
+		return err
+	}
+	strategy := node.DrainStrategy
+	has, deadline := strategy.DeadlineTime()
+	force := has && !now.Before(deadline)
+
+	// Sort the allocations that still run on the node. Service allocations
+	// are grouped by their job and task group to be migrated in batches.
+	var remaining, systemRemaining int
+	var migrate, system []*structs.Allocation
+	groups := make(map[drainGroup][]*structs.Allocation)
+	for _, alloc := range allocs {
+		if alloc.TerminalStatus() || alloc.ClientTerminalStatus() {
+			continue
+		}
+		if alloc.Job.Type == structs.JobTypeSystem {
+			if strategy.IgnoreSystemJobs {
+				continue
+			}
+			systemRemaining++
+			if !alloc.DesiredTransition.Migrate {
+				system = append(system, alloc)
+			}
+			continue
+		}
+
+		remaining++
+		if alloc.DesiredTransition.Migrate {
+			continue
+		}
+		switch {
+		case force:
+			migrate = append(migrate, alloc)
+		case alloc.Job.Type == structs.JobTypeService:
+			group := drainGroup{jobID: alloc.JobID, taskGroup: alloc.TaskGroup}
+			groups[group] = append(groups[group], alloc)
+		}
+	}
+
+	// The drain is complete once no allocations remain
+	if remaining == 0 && systemRemaining == 0 {
+		return s.completeDrain(node)
+	}
+
+	for group, allocs := range groups {
+		available, err := drainAvailable(snap, group)
+		if err != nil {
+			return err
+		}
+		if available > len(allocs) {
+			available = len(allocs)
+		}
+		if available > 0 {
+			migrate = append(migrate, allocs[:available]...)
+		}
+	}
+
+	// The allocations of system jobs are stopped once the other allocations
+	// have migrated
+	if force || remaining == 0 {
+		migrate = append(migrate, system...)
+	}
+
+	if len(migrate) == 0 {
+		return nil
+	}
+	s.logger.Printf("[DEBUG] nomad.drain: migrating %d allocations off node %q", len(migrate), node.ID)
+	return s.markMigrate(migrate)
+}
+
+// drainGroup identifies the allocations of a task group of a job
+type drainGroup struct {
+	jobID     string
+	taskGroup string
+}
+
+// drainAvailable returns the number of allocations of the task group that may
+// start migrating. The allocations marked for migration that are still
+// running and their replacements that are not yet healthy count against the
+// max parallel of the migrate strategy.
+func drainAvailable(snap *state.StateSnapshot, group drainGroup) (int, error) {
+	// The allocations of deregistered jobs and removed task groups are
+	// stopped by the scheduler
+	job, err := snap.JobByID(group.jobID)
+	if err != nil {
+		return 0, err
+	}
+	if job == nil {
+		return 0, nil
+	}
+	tg := job.LookupTaskGroup(group.taskGroup)
+	if tg == nil {
+		return 0, nil
+	}
+	strategy := tg.Migrate
+	if strategy == nil {
+		strategy = structs.DefaultMigrateStrategy()
+	}
+
+	allocs, err := snap.AllocsByJob(group.jobID)
+	if err != nil {
+		return 0, err
+	}
+	migrated := make(map[string]struct{})
+	inflight := 0
+	for _, alloc := range allocs {
+		if alloc.TaskGroup != group.taskGroup || !alloc.DesiredTransition.Migrate {
+			continue
+		}
+		migrated[alloc.ID] = struct{}{}
+		if !alloc.TerminalStatus() {
+			inflight++
+		}
+	}
+
+	if strategy.HealthCheck != structs.MigrateHealthCheckNone {
+		for _, alloc := range allocs {
+			if alloc.TaskGroup != group.taskGroup || alloc.TerminalStatus() || alloc.ClientTerminalStatus() {
+				continue
+			}
+			if _, ok := migrated[alloc.PreviousAllocation]; ok && !alloc.DeploymentStatus.HasHealth() {
+				inflight++
+			}
+		}
+	}
+	return strategy.MaxParallel - inflight, nil
+}
+
+// markMigrate marks the allocations for migration along with an evaluation of
+// each of their jobs via Raft
+func (s *Server) markMigrate(allocs []*structs.Allocation) error {
+	req := &structs.AllocUpdateDesiredTransitionRequest{
+		Allocs:       make(map[string]*structs.DesiredTransition, len(allocs)),
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+	jobs := make(map[string]struct{})
+	for _, alloc := range allocs {
+		req.Allocs[alloc.ID] = &structs.DesiredTransition{Migrate: true}
+
+		// Deduplicate on JobID
+		if _, ok := jobs[alloc.JobID]; ok {
+			continue
+		}
+		jobs[alloc.JobID] = struct{}{}
+		req.Evals = append(req.Evals, &structs.Evaluation{
+			ID:          structs.GenerateUUID(),
+			Priority:    alloc.Job.Priority,
+			Type:        alloc.Job.Type,
+			TriggeredBy: structs.EvalTriggerNodeDrain,
+			JobID:       alloc.JobID,
+			Status:      structs.EvalStatusPending,
+		})
+	}
+
+	resp, _, err := s.raftApply(structs.AllocUpdateDesiredTransitionRequestType, req)
+	if err == nil {
+		err, _ = resp.(error)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark allocations for migration: %v", err)
+	}
+	return nil
+}
+
+// completeDrain clears the drain strategy of the node via Raft. The node
+// remains ineligible for placements until the drain is disabled.
+func (s *Server) completeDrain(node *structs.Node) error {
+	req := &structs.NodeUpdateDrainRequest{
+		NodeID:       node.ID,
+		Drain:        true,
+		WriteRequest: structs.WriteRequest{Region: s.config.Region},
+	}
+	resp, _, err := s.raftApply(structs.NodeUpdateDrainRequestType, req)
+	if err == nil {
+		err, _ = resp.(error)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to complete drain: %v", err)
+	}
+	s.logger.Printf("[INFO] nomad.drain: drain of node %q complete", node.ID)
+	return nil
+}
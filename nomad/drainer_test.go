@@ -0,0 +1,256 @@
+package nomad
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// waitMarked waits for the number of allocations marked for migration
+func waitMarked(t *testing.T, state *state.StateStore, allocs []*structs.Allocation, expected int) {
+	testutil.WaitForResult(func() (bool, error) {
+		marked := 0
+		for _, alloc := range allocs {
+			out, err := state.AllocByID(alloc.ID)
+			if err != nil {
+				return false, err
+			}
+			if out.DesiredTransition.Migrate {
+				marked++
+			}
+		}
+		if marked != expected {
+			return false, fmt.Errorf("got %d marked allocs; want %d", marked, expected)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+// waitDrainComplete waits for the drain of the node to complete
+func waitDrainComplete(t *testing.T, state *state.StateStore, nodeID string) {
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.NodeByID(nodeID)
+		if err != nil {
+			return false, err
+		}
+		if !out.Drain || out.DrainStrategy != nil {
+			return false, fmt.Errorf("drain not complete: %#v", out)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+// stopAllocs marks the allocations as stopped like the scheduler does
+func stopAllocs(t *testing.T, state *state.StateStore, index uint64, allocs []*structs.Allocation) {
+	var stopped []*structs.Allocation
+	for _, alloc := range allocs {
+		out, err := state.AllocByID(alloc.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		update := new(structs.Allocation)
+		*update = *out
+		update.DesiredStatus = structs.AllocDesiredStatusStop
+		stopped = append(stopped, update)
+	}
+	if err := state.UpsertAllocs(index, stopped); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestDrainer_MaxParallel(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	state := s1.fsm.State()
+	node := mock.Node()
+	if err := state.UpsertNode(1000, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Run four allocations of a job migrating two at a time on the node
+	job := mock.Job()
+	job.TaskGroups[0].Count = 4
+	job.TaskGroups[0].Migrate = &structs.MigrateStrategy{
+		MaxParallel:    2,
+		HealthCheck:    structs.MigrateHealthCheckTaskStates,
+		MinHealthyTime: time.Second,
+	}
+	if err := state.UpsertJob(1001, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var allocs []*structs.Allocation
+	for i := 0; i < 4; i++ {
+		alloc := mock.Alloc()
+		alloc.JobID = job.ID
+		alloc.Job = job
+		alloc.NodeID = node.ID
+		allocs = append(allocs, alloc)
+	}
+	if err := state.UpsertAllocs(1002, allocs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Drain the node
+	strategy := structs.NewDrainStrategy(structs.DrainSpec{}, time.Now())
+	if err := state.UpdateNodeDrain(1003, node.ID, true, strategy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The first two allocations are marked along with an evaluation
+	waitMarked(t, state, allocs, 2)
+	evals, err := state.EvalsByJob(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(evals) != 1 || evals[0].TriggeredBy != structs.EvalTriggerNodeDrain {
+		t.Fatalf("bad: %#v", evals)
+	}
+
+	// Stop the marked allocations and place their replacements at once
+	var updates, unmarked, replacements []*structs.Allocation
+	for _, alloc := range allocs {
+		out, err := state.AllocByID(alloc.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !out.DesiredTransition.Migrate {
+			unmarked = append(unmarked, out)
+			continue
+		}
+		stopped := new(structs.Allocation)
+		*stopped = *out
+		stopped.DesiredStatus = structs.AllocDesiredStatusStop
+		replacement := mock.Alloc()
+		replacement.JobID = job.ID
+		replacement.Job = job
+		replacement.PreviousAllocation = alloc.ID
+		updates = append(updates, stopped, replacement)
+		replacements = append(replacements, replacement)
+	}
+	if err := state.UpsertAllocs(1004, updates); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The unhealthy replacements hold back the migration
+	time.Sleep(100 * time.Millisecond)
+	waitMarked(t, state, unmarked, 0)
+
+	// Report the replacements healthy
+	healthy := true
+	for i, replacement := range replacements {
+		update := new(structs.Allocation)
+		*update = *replacement
+		update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+		if err := state.UpdateAllocFromClient(uint64(1006+i), update); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// The remaining allocations are migrated and the drain completes once
+	// they are stopped
+	waitMarked(t, state, unmarked, 2)
+	stopAllocs(t, state, 1010, unmarked)
+	waitDrainComplete(t, state, node.ID)
+}
+
+func TestDrainer_Deadline(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	state := s1.fsm.State()
+	node := mock.Node()
+	if err := state.UpsertNode(1000, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Run an allocation of a service, batch and system job on the node
+	service := mock.Job()
+	batch := mock.Job()
+	batch.Type = structs.JobTypeBatch
+	system := mock.SystemJob()
+	var allocs []*structs.Allocation
+	for i, job := range []*structs.Job{service, batch, system} {
+		if err := state.UpsertJob(uint64(1001+i), job); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		alloc := mock.Alloc()
+		alloc.JobID = job.ID
+		alloc.Job = job
+		alloc.NodeID = node.ID
+		allocs = append(allocs, alloc)
+	}
+	if err := state.UpsertAllocs(1004, allocs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Drain the node with a deadline that is reached shortly
+	spec := structs.DrainSpec{Deadline: 500 * time.Millisecond}
+	strategy := structs.NewDrainStrategy(spec, time.Now())
+	if err := state.UpdateNodeDrain(1005, node.ID, true, strategy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Only the service allocation is migrated before the deadline
+	waitMarked(t, state, allocs[:1], 1)
+	waitMarked(t, state, allocs[1:], 0)
+
+	// All allocations are marked once the deadline is reached and the drain
+	// completes once they are stopped
+	waitMarked(t, state, allocs, 3)
+	stopAllocs(t, state, 1006, allocs)
+	waitDrainComplete(t, state, node.ID)
+}
+
+func TestDrainer_IgnoreSystemJobs(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	state := s1.fsm.State()
+	node := mock.Node()
+	if err := state.UpsertNode(1000, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Run an allocation of a system job on the node
+	job := mock.SystemJob()
+	if err := state.UpsertJob(1001, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	alloc := mock.Alloc()
+	alloc.JobID = job.ID
+	alloc.Job = job
+	alloc.NodeID = node.ID
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Drain the node immediately while ignoring system jobs
+	spec := structs.DrainSpec{Deadline: -1, IgnoreSystemJobs: true}
+	strategy := structs.NewDrainStrategy(spec, time.Now())
+	if err := state.UpdateNodeDrain(1003, node.ID, true, strategy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The drain completes without stopping the system allocation
+	waitDrainComplete(t, state, node.ID)
+	waitMarked(t, state, []*structs.Allocation{alloc}, 0)
+}
@@ -39,17 +39,21 @@ const (
 	EvalSnapshot
 	AllocSnapshot
 	TimeTableSnapshot
+	PeriodicLaunchSnapshot
+	JobVersionSnapshot
+	DeploymentSnapshot
 )
 
 // nomadFSM implements a finite state machine that is used
 // along with Raft to provide strong consistency. We implement
 // this outside the Server to avoid exposing this outside the package.
 type nomadFSM struct {
-	evalBroker *EvalBroker
-	logOutput  io.Writer
-	logger     *log.Logger
-	state      *state.StateStore
-	timetable  *TimeTable
+	evalBroker         *EvalBroker
+	periodicDispatcher *PeriodicDispatch
+	logOutput          io.Writer
+	logger             *log.Logger
+	state              *state.StateStore
+	timetable          *TimeTable
 }
 
 // nomadSnapshot is used to provide a snapshot of the current
@@ -65,7 +69,7 @@ type snapshotHeader struct {
 }
 
 // NewFSMPath is used to construct a new FSM with a blank state
-func NewFSM(evalBroker *EvalBroker, logOutput io.Writer) (*nomadFSM, error) {
+func NewFSM(evalBroker *EvalBroker, periodic *PeriodicDispatch, logOutput io.Writer) (*nomadFSM, error) {
 	// Create a state store
 	state, err := state.NewStateStore(logOutput)
 	if err != nil {
@@ -73,11 +77,12 @@ func NewFSM(evalBroker *EvalBroker, logOutput io.Writer) (*nomadFSM, error) {
 	}
 
 	fsm := &nomadFSM{
-		evalBroker: evalBroker,
-		logOutput:  logOutput,
-		logger:     log.New(logOutput, "", log.LstdFlags),
-		state:      state,
-		timetable:  NewTimeTable(timeTableGranularity, timeTableLimit),
+		evalBroker:         evalBroker,
+		periodicDispatcher: periodic,
+		logOutput:          logOutput,
+		logger:             log.New(logOutput, "", log.LstdFlags),
+		state:              state,
+		timetable:          NewTimeTable(timeTableGranularity, timeTableLimit),
 	}
 	return fsm, nil
 }
@@ -134,6 +139,16 @@ func (n *nomadFSM) Apply(log *raft.Log) interface{} {
 		return n.applyAllocUpdate(buf[1:], log.Index)
 	case structs.AllocClientUpdateRequestType:
 		return n.applyAllocClientUpdate(buf[1:], log.Index)
+	case structs.ApplyPlanResultsRequestType:
+		return n.applyPlanResults(buf[1:], log.Index)
+	case structs.DeploymentStatusUpdateRequestType:
+		return n.applyDeploymentStatusUpdate(buf[1:], log.Index)
+	case structs.DeploymentPromoteRequestType:
+		return n.applyDeploymentPromotion(buf[1:], log.Index)
+	case structs.NodeUpdateEligibilityRequestType:
+		return n.applyEligibilityUpdate(buf[1:], log.Index)
+	case structs.AllocUpdateDesiredTransitionRequestType:
+		return n.applyAllocUpdateDesiredTransition(buf[1:], log.Index)
 	default:
 		if ignoreUnknown {
 			n.logger.Printf("[WARN] nomad.fsm: ignoring unknown message type (%d), upgrade to newer version", msgType)
@@ -193,13 +208,27 @@ func (n *nomadFSM) applyDrainUpdate(buf []byte, index uint64) interface{} {
 		panic(fmt.Errorf("failed to decode request: %v", err))
 	}
 
-	if err := n.state.UpdateNodeDrain(index, req.NodeID, req.Drain); err != nil {
+	if err := n.state.UpdateNodeDrain(index, req.NodeID, req.Drain, req.DrainStrategy); err != nil {
 		n.logger.Printf("[ERR] nomad.fsm: UpdateNodeDrain failed: %v", err)
 		return err
 	}
 	return nil
 }
 
+func (n *nomadFSM) applyEligibilityUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "node_eligibility_update"}, time.Now())
+	var req structs.NodeUpdateEligibilityRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateNodeEligibility(index, req.NodeID, req.Eligibility); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateNodeEligibility failed: %v", err)
+		return err
+	}
+	return nil
+}
+
 func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 	defer metrics.MeasureSince([]string{"nomad", "fsm", "register_job"}, time.Now())
 	var req structs.JobRegisterRequest
@@ -211,6 +240,65 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 		n.logger.Printf("[ERR] nomad.fsm: UpsertJob failed: %v", err)
 		return err
 	}
+
+	// We always add the job to the periodic dispatcher because there is the
+	// possibility that the periodic spec was removed and then we should stop
+	// tracking it.
+	if err := n.periodicDispatcher.Add(req.Job); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: periodicDispatcher.Add failed: %v", err)
+		return err
+	}
+
+	// If it is periodic, record the time it was inserted. This is necessary for
+	// recovering during leader election. It is possible that from the time it
+	// is added to when it was suppose to launch, leader election occurs and the
+	// job was not launched. In this case, we use the insertion time to
+	// determine if a launch was missed.
+	if req.Job.IsPeriodic() {
+		prevLaunch, err := n.state.PeriodicLaunchByID(req.Job.ID)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: PeriodicLaunchByID failed: %v", err)
+			return err
+		}
+
+		// Record the insertion time as a launch. We overload the launch table
+		// such that the first entry is the insertion time.
+		if prevLaunch == nil {
+			launch := &structs.PeriodicLaunch{ID: req.Job.ID, Launch: time.Now()}
+			if err := n.state.UpsertPeriodicLaunch(index, launch); err != nil {
+				n.logger.Printf("[ERR] nomad.fsm: UpsertPeriodicLaunch failed: %v", err)
+				return err
+			}
+		}
+	}
+
+	// Check if the parent job is periodic and mark the launch time.
+	parentID := req.Job.ParentID
+	if parentID != "" {
+		parent, err := n.state.JobByID(parentID)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: JobByID(%v) lookup for parent failed: %v", parentID, err)
+			return err
+		} else if parent == nil {
+			// The parent has been deregistered.
+			return nil
+		}
+
+		if parent.IsPeriodic() {
+			t, err := n.periodicDispatcher.LaunchTime(req.Job.ID)
+			if err != nil {
+				n.logger.Printf("[ERR] nomad.fsm: LaunchTime(%v) failed: %v", req.Job.ID, err)
+				return err
+			}
+
+			launch := &structs.PeriodicLaunch{ID: parentID, Launch: t}
+			if err := n.state.UpsertPeriodicLaunch(index, launch); err != nil {
+				n.logger.Printf("[ERR] nomad.fsm: UpsertPeriodicLaunch failed: %v", err)
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -225,6 +313,11 @@ func (n *nomadFSM) applyDeregisterJob(buf []byte, index uint64) interface{} {
 		n.logger.Printf("[ERR] nomad.fsm: DeleteJob failed: %v", err)
 		return err
 	}
+
+	if err := n.periodicDispatcher.Remove(req.JobID); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: periodicDispatcher.Remove failed: %v", err)
+		return err
+	}
 	return nil
 }
 
@@ -296,6 +389,91 @@ func (n *nomadFSM) applyAllocClientUpdate(buf []byte, index uint64) interface{}
 	return nil
 }
 
+// applyPlanResults applies the results of a plan application
+func (n *nomadFSM) applyPlanResults(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_plan_results"}, time.Now())
+	var req structs.ApplyPlanResultsRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertPlanResults(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: ApplyPlan failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyDeploymentStatusUpdate is used to update the status of an existing
+// deployment
+func (n *nomadFSM) applyDeploymentStatusUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_deployment_status_update"}, time.Now())
+	var req structs.DeploymentStatusUpdateRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateDeploymentStatus(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateDeploymentStatus failed: %v", err)
+		return err
+	}
+
+	if req.Eval != nil && req.Eval.ShouldEnqueue() {
+		if err := n.evalBroker.Enqueue(req.Eval); err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: failed to enqueue evaluation %s: %v", req.Eval.ID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// applyDeploymentPromotion is used to promote canaries in a deployment
+func (n *nomadFSM) applyDeploymentPromotion(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_deployment_promotion"}, time.Now())
+	var req structs.ApplyDeploymentPromoteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateDeploymentPromotion(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateDeploymentPromotion failed: %v", err)
+		return err
+	}
+
+	if req.Eval != nil && req.Eval.ShouldEnqueue() {
+		if err := n.evalBroker.Enqueue(req.Eval); err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: failed to enqueue evaluation %s: %v", req.Eval.ID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// applyAllocUpdateDesiredTransition is used to update the desired
+// transitions of allocations and enqueue the evaluations acting on them
+func (n *nomadFSM) applyAllocUpdateDesiredTransition(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "alloc_update_desired_transition"}, time.Now())
+	var req structs.AllocUpdateDesiredTransitionRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateAllocsDesiredTransitions(index, req.Allocs, req.Evals); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateAllocsDesiredTransitions failed: %v", err)
+		return err
+	}
+
+	for _, eval := range req.Evals {
+		if eval.ShouldEnqueue() {
+			if err := n.evalBroker.Enqueue(eval); err != nil {
+				n.logger.Printf("[ERR] nomad.fsm: failed to enqueue evaluation %s: %v", eval.ID, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (n *nomadFSM) Snapshot() (raft.FSMSnapshot, error) {
 	// Create a new snapshot
 	snap, err := n.state.Snapshot()
@@ -390,6 +568,33 @@ func (n *nomadFSM) Restore(old io.ReadCloser) error {
 				return err
 			}
 
+		case PeriodicLaunchSnapshot:
+			launch := new(structs.PeriodicLaunch)
+			if err := dec.Decode(launch); err != nil {
+				return err
+			}
+			if err := restore.PeriodicLaunchRestore(launch); err != nil {
+				return err
+			}
+
+		case JobVersionSnapshot:
+			version := new(structs.Job)
+			if err := dec.Decode(version); err != nil {
+				return err
+			}
+			if err := restore.JobVersionRestore(version); err != nil {
+				return err
+			}
+
+		case DeploymentSnapshot:
+			deployment := new(structs.Deployment)
+			if err := dec.Decode(deployment); err != nil {
+				return err
+			}
+			if err := restore.DeploymentRestore(deployment); err != nil {
+				return err
+			}
+
 		case IndexSnapshot:
 			idx := new(state.IndexEntry)
 			if err := dec.Decode(idx); err != nil {
@@ -449,6 +654,18 @@ func (s *nomadSnapshot) Persist(sink raft.SnapshotSink) error {
 		sink.Cancel()
 		return err
 	}
+	if err := s.persistPeriodicLaunches(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistJobVersions(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistDeployments(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
 	return nil
 }
 
@@ -587,6 +804,87 @@ func (s *nomadSnapshot) persistAllocs(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *nomadSnapshot) persistPeriodicLaunches(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the periodic launches
+	launches, err := s.snap.PeriodicLaunches()
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := launches.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		launch := raw.(*structs.PeriodicLaunch)
+
+		// Write out a periodic launch
+		sink.Write([]byte{byte(PeriodicLaunchSnapshot)})
+		if err := encoder.Encode(launch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistJobVersions(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the job versions
+	versions, err := s.snap.JobVersions()
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := versions.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		version := raw.(*structs.Job)
+
+		// Write out a job version
+		sink.Write([]byte{byte(JobVersionSnapshot)})
+		if err := encoder.Encode(version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistDeployments(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the deployments
+	deployments, err := s.snap.Deployments()
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := deployments.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		deployment := raw.(*structs.Deployment)
+
+		// Write out a deployment
+		sink.Write([]byte{byte(DeploymentSnapshot)})
+		if err := encoder.Encode(deployment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Release is a no-op, as we just need to GC the pointer
 // to the state store snapshot. There is nothing to explicitly
 // cleanup.
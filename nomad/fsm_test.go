@@ -43,7 +43,8 @@ func testStateStore(t *testing.T) *state.StateStore {
 }
 
 func testFSM(t *testing.T) *nomadFSM {
-	fsm, err := NewFSM(testBroker(t, 0), os.Stderr)
+	dispatcher, _ := testPeriodicDispatcher()
+	fsm, err := NewFSM(testBroker(t, 0), dispatcher, os.Stderr)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -195,9 +196,11 @@ func TestFSM_UpdateNodeDrain(t *testing.T) {
 		t.Fatalf("resp: %v", resp)
 	}
 
+	strategy := structs.NewDrainStrategy(structs.DrainSpec{Deadline: time.Hour}, time.Now())
 	req2 := structs.NodeUpdateDrainRequest{
-		NodeID: node.ID,
-		Drain:  true,
+		NodeID:        node.ID,
+		Drain:         true,
+		DrainStrategy: strategy,
 	}
 	buf, err = structs.Encode(structs.NodeUpdateDrainRequestType, req2)
 	if err != nil {
@@ -214,7 +217,48 @@ func TestFSM_UpdateNodeDrain(t *testing.T) {
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if !node.Drain {
+	if !node.Drain || !reflect.DeepEqual(node.DrainStrategy, strategy) {
+		t.Fatalf("bad node: %#v", node)
+	}
+}
+
+func TestFSM_UpdateNodeEligibility(t *testing.T) {
+	fsm := testFSM(t)
+
+	node := mock.Node()
+	req := structs.NodeRegisterRequest{
+		Node: node,
+	}
+	buf, err := structs.Encode(structs.NodeRegisterRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	req2 := structs.NodeUpdateEligibilityRequest{
+		NodeID:      node.ID,
+		Eligibility: structs.NodeSchedulingIneligible,
+	}
+	buf, err = structs.Encode(structs.NodeUpdateEligibilityRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the node is ineligible
+	node, err = fsm.State().NodeByID(req.Node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if node.SchedulingEligibility != structs.NodeSchedulingIneligible {
 		t.Fatalf("bad node: %#v", node)
 	}
 }
@@ -248,6 +292,66 @@ func TestFSM_RegisterJob(t *testing.T) {
 	}
 }
 
+func TestFSM_RegisterJob_Periodic(t *testing.T) {
+	fsm := testFSM(t)
+
+	job := mock.PeriodicJob()
+	req := structs.JobRegisterRequest{
+		Job: job,
+	}
+	buf, err := structs.Encode(structs.JobRegisterRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify it was added to the periodic dispatcher.
+	tracked := fsm.periodicDispatcher.Tracked()
+	if len(tracked) != 1 || tracked[0].ID != job.ID {
+		t.Fatalf("job not added to periodic dispatcher: %v", tracked)
+	}
+
+	// Verify the launch time was tracked.
+	launchOut, err := fsm.State().PeriodicLaunchByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if launchOut == nil {
+		t.Fatalf("not found!")
+	}
+	if launchOut.Launch.IsZero() {
+		t.Fatalf("bad launch time: %v", launchOut.Launch)
+	}
+
+	// Register a job derived from the periodic job and verify the launch time
+	// of the parent is updated.
+	child := fsm.periodicDispatcher.deriveJob(job, time.Unix(1445000000, 0))
+	req = structs.JobRegisterRequest{
+		Job: child,
+	}
+	buf, err = structs.Encode(structs.JobRegisterRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	launchOut, err = fsm.State().PeriodicLaunchByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if launchOut == nil || launchOut.Launch.Unix() != 1445000000 {
+		t.Fatalf("bad launch: %#v", launchOut)
+	}
+}
+
 func TestFSM_DeregisterJob(t *testing.T) {
 	fsm := testFSM(t)
 
@@ -288,6 +392,51 @@ func TestFSM_DeregisterJob(t *testing.T) {
 	}
 }
 
+func TestFSM_DeregisterJob_Periodic(t *testing.T) {
+	fsm := testFSM(t)
+
+	job := mock.PeriodicJob()
+	req := structs.JobRegisterRequest{
+		Job: job,
+	}
+	buf, err := structs.Encode(structs.JobRegisterRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	req2 := structs.JobDeregisterRequest{
+		JobID: job.ID,
+	}
+	buf, err = structs.Encode(structs.JobDeregisterRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify it was removed from the periodic dispatcher.
+	if tracked := fsm.periodicDispatcher.Tracked(); len(tracked) != 0 {
+		t.Fatalf("job not removed from periodic dispatcher: %v", tracked)
+	}
+
+	// Verify the launch time was removed.
+	launchOut, err := fsm.State().PeriodicLaunchByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if launchOut != nil {
+		t.Fatalf("launch found!")
+	}
+}
+
 func TestFSM_UpdateEval(t *testing.T) {
 	fsm := testFSM(t)
 	fsm.evalBroker.SetEnabled(true)
@@ -454,6 +603,167 @@ func TestFSM_UpdateAllocFromClient(t *testing.T) {
 	}
 }
 
+func TestFSM_ApplyPlanResults(t *testing.T) {
+	fsm := testFSM(t)
+
+	d := mock.Deployment()
+	alloc := mock.Alloc()
+	alloc.DeploymentID = d.ID
+	req := structs.ApplyPlanResultsRequest{
+		AllocUpdateRequest: structs.AllocUpdateRequest{
+			Alloc: []*structs.Allocation{alloc},
+		},
+		Deployment: d,
+	}
+	buf, err := structs.Encode(structs.ApplyPlanResultsRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the allocation and deployment were committed
+	out, err := fsm.State().AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.DeploymentID != d.ID {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	dout, err := fsm.State().DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if dout == nil || dout.TaskGroups["web"].PlacedAllocs != 1 {
+		t.Fatalf("bad: %#v", dout)
+	}
+}
+
+func TestFSM_DeploymentStatusUpdate(t *testing.T) {
+	fsm := testFSM(t)
+	state := fsm.State()
+
+	d := mock.Deployment()
+	if err := state.UpsertDeployment(1, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedByUser,
+		},
+		Eval: eval,
+	}
+	buf, err := structs.Encode(structs.DeploymentStatusUpdateRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	dout, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if dout.Status != structs.DeploymentStatusFailed {
+		t.Fatalf("bad: %#v", dout)
+	}
+
+	eout, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eout == nil {
+		t.Fatalf("expected eval")
+	}
+}
+
+func TestFSM_DeploymentPromotion(t *testing.T) {
+	fsm := testFSM(t)
+	state := fsm.State()
+
+	d := mock.Deployment()
+	d.TaskGroups["web"].DesiredCanaries = 1
+	if err := state.UpsertDeployment(1, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
+		Eval: mock.Eval(),
+	}
+	buf, err := structs.Encode(structs.DeploymentPromoteRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	dout, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !dout.TaskGroups["web"].Promoted {
+		t.Fatalf("bad: %#v", dout)
+	}
+}
+
+func TestFSM_AllocUpdateDesiredTransition(t *testing.T) {
+	fsm := testFSM(t)
+	state := fsm.State()
+
+	alloc := mock.Alloc()
+	if err := state.UpsertAllocs(1, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	req := &structs.AllocUpdateDesiredTransitionRequest{
+		Allocs: map[string]*structs.DesiredTransition{
+			alloc.ID: &structs.DesiredTransition{Migrate: true},
+		},
+		Evals: []*structs.Evaluation{eval},
+	}
+	buf, err := structs.Encode(structs.AllocUpdateDesiredTransitionRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.DesiredTransition.Migrate {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	eout, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eout == nil {
+		t.Fatalf("expected eval")
+	}
+}
+
 func testSnapshotRestore(t *testing.T, fsm *nomadFSM) *nomadFSM {
 	// Snapshot
 	snap, err := fsm.Snapshot()
@@ -567,6 +877,78 @@ func TestFSM_SnapshotRestore_Allocs(t *testing.T) {
 	}
 }
 
+func TestFSM_SnapshotRestore_PeriodicLaunches(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	launch1 := &structs.PeriodicLaunch{ID: "foo", Launch: time.Now()}
+	state.UpsertPeriodicLaunch(1000, launch1)
+	launch2 := &structs.PeriodicLaunch{ID: "bar", Launch: time.Now()}
+	state.UpsertPeriodicLaunch(1001, launch2)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out1, _ := state2.PeriodicLaunchByID(launch1.ID)
+	out2, _ := state2.PeriodicLaunchByID(launch2.ID)
+	if !out1.Launch.Equal(launch1.Launch) || out1.ModifyIndex != 1000 {
+		t.Fatalf("bad: \n%#v\n%#v", out1, launch1)
+	}
+	if !out2.Launch.Equal(launch2.Launch) || out2.ModifyIndex != 1001 {
+		t.Fatalf("bad: \n%#v\n%#v", out2, launch2)
+	}
+}
+
+func TestFSM_SnapshotRestore_JobVersions(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	job := mock.Job()
+	state.UpsertJob(1000, job)
+	job2 := job.Copy()
+	job2.Priority = 99
+	state.UpsertJob(1001, job2)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out, err := state2.JobVersionsByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out[0].Version != 1 || out[0].Priority != 99 {
+		t.Fatalf("bad: %#v", out[0])
+	}
+	if out[1].Version != 0 || out[1].Priority != job.Priority {
+		t.Fatalf("bad: %#v", out[1])
+	}
+}
+
+func TestFSM_SnapshotRestore_Deployments(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	d1 := mock.Deployment()
+	d2 := mock.Deployment()
+	state.UpsertDeployment(1000, d1)
+	state.UpsertDeployment(1001, d2)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out1, _ := state2.DeploymentByID(d1.ID)
+	out2, _ := state2.DeploymentByID(d2.ID)
+	if !reflect.DeepEqual(d1, out1) {
+		t.Fatalf("bad: \n%#v\n%#v", out1, d1)
+	}
+	if !reflect.DeepEqual(d2, out2) {
+		t.Fatalf("bad: \n%#v\n%#v", out2, d2)
+	}
+}
+
 func TestFSM_SnapshotRestore_Indexes(t *testing.T) {
 	// Add some state
 	fsm := testFSM(t)
@@ -2,11 +2,19 @@ package nomad
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/watch"
+	"github.com/hashicorp/nomad/scheduler"
+)
+
+const (
+	// RegisterEnforceIndexErrPrefix is the prefix to use in errors caused by
+	// enforcing the job modify index during registers.
+	RegisterEnforceIndexErrPrefix = "Enforcing job modify index"
 )
 
 // Job endpoint is used for job interactions
@@ -32,6 +40,38 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		return fmt.Errorf("job type cannot be core")
 	}
 
+	// If the job modify index is being enforced, check it against the
+	// current job before committing.
+	if args.EnforceIndex {
+		snap, err := j.srv.fsm.State().Snapshot()
+		if err != nil {
+			return err
+		}
+		existingJob, err := snap.JobByID(args.Job.ID)
+		if err != nil {
+			return err
+		}
+
+		jmi := args.JobModifyIndex
+		if existingJob != nil {
+			if jmi == 0 {
+				return fmt.Errorf("%s 0: job already exists", RegisterEnforceIndexErrPrefix)
+			} else if jmi != existingJob.ModifyIndex {
+				return fmt.Errorf("%s %d: job exists with conflicting job modify index: %d",
+					RegisterEnforceIndexErrPrefix, jmi, existingJob.ModifyIndex)
+			}
+		} else if jmi != 0 {
+			return fmt.Errorf("%s %d: job does not exist", RegisterEnforceIndexErrPrefix, jmi)
+		}
+	}
+
+	// Record the submission time. This is done before committing to Raft so
+	// that every server applies the same value.
+	args.Job.SubmitTime = time.Now().UTC().UnixNano()
+
+	// A newly registered version is not stable until it has been deployed
+	args.Job.Stable = false
+
 	// Commit this update via Raft
 	_, index, err := j.srv.raftApply(structs.JobRegisterRequestType, args)
 	if err != nil {
@@ -39,6 +79,17 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		return err
 	}
 
+	// Populate the reply with job information
+	reply.JobModifyIndex = index
+
+	// If the job is periodic or parameterized, we don't create an eval. The
+	// periodic dispatcher on the leader creates one each time a periodic job
+	// is launched and parameterized jobs are only run when dispatched.
+	if args.Job.IsPeriodic() || args.Job.IsParameterized() {
+		reply.Index = index
+		return nil
+	}
+
 	// Create a new evaluation
 	eval := &structs.Evaluation{
 		ID:             structs.GenerateUUID(),
@@ -66,11 +117,57 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 	// Setup the reply
 	reply.EvalID = eval.ID
 	reply.EvalCreateIndex = evalIndex
-	reply.JobModifyIndex = index
 	reply.Index = evalIndex
 	return nil
 }
 
+// Revert is used to revert the job to a prior version
+func (j *Job) Revert(args *structs.JobRevertRequest, reply *structs.JobRegisterResponse) error {
+	if done, err := j.srv.forward("Job.Revert", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "revert"}, time.Now())
+
+	// Validate the arguments
+	if args.JobID == "" {
+		return fmt.Errorf("missing job ID for revert")
+	}
+
+	// Lookup the current job and the version to revert to
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	cur, err := snap.JobByID(args.JobID)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		return fmt.Errorf("job %q not found", args.JobID)
+	}
+	if args.JobVersion == cur.Version {
+		return fmt.Errorf("can't revert to current version")
+	}
+
+	jobV, err := snap.JobByIDAndVersion(args.JobID, args.JobVersion)
+	if err != nil {
+		return err
+	}
+	if jobV == nil {
+		return fmt.Errorf("job %q at version %d not found", args.JobID, args.JobVersion)
+	}
+
+	// Build the register request. The job is copied so that the stored
+	// version isn't mutated when it is registered.
+	reg := &structs.JobRegisterRequest{
+		Job:          jobV.Copy(),
+		WriteRequest: args.WriteRequest,
+	}
+
+	// Register the version.
+	return j.Register(reg, reply)
+}
+
 // Evaluate is used to force a job for re-evaluation
 func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegisterResponse) error {
 	if done, err := j.srv.forward("Job.Evaluate", args, args, reply); done {
@@ -96,6 +193,12 @@ func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegis
 		return fmt.Errorf("job not found")
 	}
 
+	if job.IsPeriodic() {
+		return fmt.Errorf("can't evaluate periodic job")
+	} else if job.IsParameterized() {
+		return fmt.Errorf("can't evaluate parameterized job")
+	}
+
 	// Create a new evaluation
 	eval := &structs.Evaluation{
 		ID:             structs.GenerateUUID(),
@@ -168,7 +271,6 @@ func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobD
 	// Setup the reply
 	reply.EvalID = eval.ID
 	reply.EvalCreateIndex = evalIndex
-	reply.JobModifyIndex = index
 	reply.Index = evalIndex
 	return nil
 }
@@ -218,6 +320,51 @@ func (j *Job) GetJob(args *structs.JobSpecificRequest,
 	return j.srv.blockingRPC(&opts)
 }
 
+// GetJobVersions is used to retrieve the tracked versions of a job
+func (j *Job) GetJobVersions(args *structs.JobSpecificRequest,
+	reply *structs.JobVersionsResponse) error {
+	if done, err := j.srv.forward("Job.GetJobVersions", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "get_job_versions"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Job: args.JobID}),
+		run: func() error {
+
+			// Look for the job versions
+			snap, err := j.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.JobVersionsByID(args.JobID)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Versions = out
+			if len(out) != 0 {
+				reply.Index = out[0].ModifyIndex
+			} else {
+				// Use the last index that affected the job versions table
+				index, err := snap.Index("job_versions")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			j.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return j.srv.blockingRPC(&opts)
+}
+
 // List is used to list the jobs registered in the system
 func (j *Job) List(args *structs.JobListRequest,
 	reply *structs.JobListResponse) error {
@@ -343,3 +490,300 @@ func (j *Job) Evaluations(args *structs.JobSpecificRequest,
 	j.srv.setQueryMeta(&reply.QueryMeta)
 	return nil
 }
+
+// Dispatch a parameterized job.
+func (j *Job) Dispatch(args *structs.JobDispatchRequest, reply *structs.JobDispatchResponse) error {
+	if done, err := j.srv.forward("Job.Dispatch", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "dispatch"}, time.Now())
+
+	// Validate the arguments
+	if args.JobID == "" {
+		return fmt.Errorf("missing parameterized job ID")
+	}
+
+	// Lookup the parameterized job
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	parameterizedJob, err := snap.JobByID(args.JobID)
+	if err != nil {
+		return err
+	}
+	if parameterizedJob == nil {
+		return fmt.Errorf("parameterized job not found")
+	}
+
+	if !parameterizedJob.IsParameterized() {
+		return fmt.Errorf("Specified job %q is not a parameterized job", args.JobID)
+	}
+
+	// Validate the arguments
+	if err := validateDispatchRequest(args, parameterizedJob); err != nil {
+		return err
+	}
+
+	// Derive the child job and commit it via Raft
+	dispatchJob := parameterizedJob.Copy()
+	dispatchJob.ParameterizedJob = nil
+	dispatchJob.ID = structs.DispatchedID(parameterizedJob.ID, time.Now())
+	dispatchJob.ParentID = parameterizedJob.ID
+	dispatchJob.Name = dispatchJob.ID
+	dispatchJob.Status = ""
+	dispatchJob.StatusDescription = ""
+
+	// Merge in the meta data
+	for k, v := range args.Meta {
+		if dispatchJob.Meta == nil {
+			dispatchJob.Meta = make(map[string]string, len(args.Meta))
+		}
+		dispatchJob.Meta[k] = v
+	}
+
+	// Store the payload
+	dispatchJob.Payload = args.Payload
+
+	// Commit this update via Raft
+	regReq := &structs.JobRegisterRequest{
+		Job:          dispatchJob,
+		WriteRequest: args.WriteRequest,
+	}
+	_, jobCreateIndex, err := j.srv.raftApply(structs.JobRegisterRequestType, regReq)
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Dispatched job register failed: %v", err)
+		return err
+	}
+
+	// Create a new evaluation
+	eval := &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       dispatchJob.Priority,
+		Type:           dispatchJob.Type,
+		TriggeredBy:    structs.EvalTriggerJobRegister,
+		JobID:          dispatchJob.ID,
+		JobModifyIndex: jobCreateIndex,
+		Status:         structs.EvalStatusPending,
+	}
+	update := &structs.EvalUpdateRequest{
+		Evals:        []*structs.Evaluation{eval},
+		WriteRequest: structs.WriteRequest{Region: args.Region},
+	}
+
+	// Commit this evaluation via Raft
+	_, evalIndex, err := j.srv.raftApply(structs.EvalUpdateRequestType, update)
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Eval create failed: %v", err)
+		return err
+	}
+
+	// Setup the reply
+	reply.DispatchedJobID = dispatchJob.ID
+	reply.JobCreateIndex = jobCreateIndex
+	reply.EvalID = eval.ID
+	reply.EvalCreateIndex = evalIndex
+	reply.Index = evalIndex
+	return nil
+}
+
+// Plan is used to cause a dry-run evaluation of the Job and return the results
+// with a potential diff containing annotations.
+func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse) error {
+	if done, err := j.srv.forward("Job.Plan", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "plan"}, time.Now())
+
+	// Validate the arguments
+	if args.Job == nil {
+		return fmt.Errorf("Job required for plan")
+	}
+	if err := args.Job.Validate(); err != nil {
+		return err
+	}
+	if args.Job.Type == structs.JobTypeCore {
+		return fmt.Errorf("job type cannot be core")
+	}
+
+	// Snapshot the state
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	// Get the original job
+	oldJob, err := snap.JobByID(args.Job.ID)
+	if err != nil {
+		return err
+	}
+
+	var index uint64
+	if oldJob != nil {
+		index = oldJob.ModifyIndex
+	}
+
+	// Use an index past any existing job so that the scheduler treats the
+	// allocations of the existing job as requiring an update.
+	updatedIndex, err := snap.Index("jobs")
+	if err != nil {
+		return err
+	}
+	updatedIndex++
+
+	// Insert the updated Job into the snapshot so the scheduler sees the
+	// job as it would be after registration. The job is copied since the
+	// state store sets the indexes on the inserted job.
+	updatedJob := args.Job.Copy()
+	if err := snap.UpsertJob(updatedIndex, updatedJob); err != nil {
+		return err
+	}
+
+	// Create an eval and mark it as requiring annotations
+	eval := &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       args.Job.Priority,
+		Type:           args.Job.Type,
+		TriggeredBy:    structs.EvalTriggerJobRegister,
+		JobID:          args.Job.ID,
+		JobModifyIndex: updatedIndex,
+		Status:         structs.EvalStatusPending,
+		AnnotatePlan:   true,
+	}
+
+	// Create an in-memory Planner that returns no errors and stores the
+	// submitted plan and created evals.
+	planner := &dryRunPlanner{}
+
+	// Create the scheduler and run it
+	sched, err := scheduler.NewScheduler(eval.Type, j.srv.logger, snap, planner)
+	if err != nil {
+		return err
+	}
+
+	if err := sched.Process(eval); err != nil {
+		return err
+	}
+
+	// Annotate and store the diff
+	if plans := len(planner.Plans); plans != 1 {
+		return fmt.Errorf("scheduler resulted in an unexpected number of plans: %d", plans)
+	}
+	annotations := planner.Plans[0].Annotations
+	if args.Diff {
+		jobDiff, err := oldJob.Diff(args.Job, true)
+		if err != nil {
+			return fmt.Errorf("failed to create job diff: %v", err)
+		}
+
+		if err := scheduler.Annotate(jobDiff, annotations); err != nil {
+			return fmt.Errorf("failed to annotate job diff: %v", err)
+		}
+		reply.Diff = jobDiff
+	}
+
+	// Grab the failures
+	reply.FailedAllocs = planner.Plans[0].FailedAllocs
+
+	// If it is a periodic job calculate the next launch
+	if args.Job.IsPeriodic() && args.Job.Periodic.Enabled {
+		reply.NextPeriodicLaunch = args.Job.Periodic.Next(time.Now().UTC())
+	}
+
+	reply.Annotations = annotations
+	reply.CreatedEvals = planner.CreateEvals
+	reply.JobModifyIndex = index
+	reply.Index = index
+	return nil
+}
+
+// validateDispatchRequest returns whether the request is valid given the
+// parameterized job.
+func validateDispatchRequest(req *structs.JobDispatchRequest, job *structs.Job) error {
+	// Check the payload constraint is met
+	hasInput := len(req.Payload) != 0
+	if job.ParameterizedJob.Payload == structs.DispatchPayloadRequired && !hasInput {
+		return fmt.Errorf("Payload is not provided but required by parameterized job")
+	} else if job.ParameterizedJob.Payload == structs.DispatchPayloadForbidden && hasInput {
+		return fmt.Errorf("Payload provided but forbidden by parameterized job")
+	}
+
+	// Check the payload doesn't exceed the size limit
+	if l := len(req.Payload); l > structs.DispatchPayloadSizeLimit {
+		return fmt.Errorf("Payload exceeds maximum size; %d > %d", l, structs.DispatchPayloadSizeLimit)
+	}
+
+	// Check if the metadata is a set
+	keys := make(map[string]struct{}, len(req.Meta))
+	for k := range req.Meta {
+		keys[k] = struct{}{}
+	}
+
+	required := make(map[string]struct{}, len(job.ParameterizedJob.MetaRequired))
+	for _, k := range job.ParameterizedJob.MetaRequired {
+		required[k] = struct{}{}
+	}
+	optional := make(map[string]struct{}, len(job.ParameterizedJob.MetaOptional))
+	for _, k := range job.ParameterizedJob.MetaOptional {
+		optional[k] = struct{}{}
+	}
+
+	// Check the metadata key constraints are met
+	var unpermitted []string
+	for k := range keys {
+		_, isRequired := required[k]
+		_, isOptional := optional[k]
+		if !isRequired && !isOptional {
+			unpermitted = append(unpermitted, k)
+		}
+	}
+	if len(unpermitted) != 0 {
+		sort.Strings(unpermitted)
+		return fmt.Errorf("Dispatch request included unpermitted metadata keys: %v", unpermitted)
+	}
+
+	var missing []string
+	for k := range required {
+		if _, ok := keys[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("Dispatch did not provide required meta keys: %v", missing)
+	}
+
+	return nil
+}
+
+// dryRunPlanner is a scheduler.Planner that doesn't commit anything. Each
+// submitted plan is recorded and treated as fully committed so that the
+// scheduler reports what it would have done.
+type dryRunPlanner struct {
+	Plans       []*structs.Plan
+	CreateEvals []*structs.Evaluation
+}
+
+// SubmitPlan records the plan and returns a result committing all of it.
+func (p *dryRunPlanner) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, scheduler.State, error) {
+	p.Plans = append(p.Plans, plan)
+	result := &structs.PlanResult{
+		NodeUpdate:        plan.NodeUpdate,
+		NodeAllocation:    plan.NodeAllocation,
+		FailedAllocs:      plan.FailedAllocs,
+		Deployment:        plan.Deployment,
+		DeploymentUpdates: plan.DeploymentUpdates,
+	}
+	return result, nil, nil
+}
+
+// UpdateEval is a no-op since the evaluation is never persisted.
+func (p *dryRunPlanner) UpdateEval(eval *structs.Evaluation) error {
+	return nil
+}
+
+// CreateEval records the evaluation the scheduler would have created.
+func (p *dryRunPlanner) CreateEval(eval *structs.Evaluation) error {
+	p.CreateEvals = append(p.CreateEvals, eval)
+	return nil
+}
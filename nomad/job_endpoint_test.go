@@ -2,6 +2,7 @@ package nomad
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -166,6 +167,257 @@ func TestJobEndpoint_Register_Existing(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Register_Periodic(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request for a periodic job.
+	job := mock.PeriodicJob()
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.JobModifyIndex == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	// Check for the job in the FSM
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected job")
+	}
+	if out.CreateIndex != resp.JobModifyIndex {
+		t.Fatalf("index mis-match")
+	}
+
+	if resp.EvalID != "" {
+		t.Fatalf("Register created an eval for a periodic job")
+	}
+
+	// The leader should be tracking the job
+	if tracked := s1.periodicDispatcher.Tracked(); len(tracked) != 1 {
+		t.Fatalf("periodic job not tracked: %v", tracked)
+	}
+}
+
+func TestJobEndpoint_Register_EnforceIndex(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request and enforcing an incorrect index
+	job := mock.Job()
+	req := &structs.JobRegisterRequest{
+		Job:            job,
+		EnforceIndex:   true,
+		JobModifyIndex: 100, // Not registered yet so not possible
+		WriteRequest:   structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), RegisterEnforceIndexErrPrefix) {
+		t.Fatalf("expected enforcement error")
+	}
+
+	// Create the register request and enforcing it is new
+	req = &structs.JobRegisterRequest{
+		Job:            job,
+		EnforceIndex:   true,
+		JobModifyIndex: 0,
+		WriteRequest:   structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	curIndex := resp.JobModifyIndex
+
+	// Check for the job in the FSM
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected job")
+	}
+	if out.CreateIndex != resp.JobModifyIndex {
+		t.Fatalf("index mis-match")
+	}
+
+	// Reregister request and enforcing it be a new job
+	req = &structs.JobRegisterRequest{
+		Job:            job,
+		EnforceIndex:   true,
+		JobModifyIndex: 0,
+		WriteRequest:   structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	err = msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), RegisterEnforceIndexErrPrefix) {
+		t.Fatalf("expected enforcement error")
+	}
+
+	// Reregister request and enforcing it be at an incorrect index
+	req = &structs.JobRegisterRequest{
+		Job:            job,
+		EnforceIndex:   true,
+		JobModifyIndex: curIndex - 1,
+		WriteRequest:   structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	err = msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), RegisterEnforceIndexErrPrefix) {
+		t.Fatalf("expected enforcement error")
+	}
+
+	// Reregister request and enforcing it be at the correct index
+	job.Priority = job.Priority + 1
+	req = &structs.JobRegisterRequest{
+		Job:            job,
+		EnforceIndex:   true,
+		JobModifyIndex: curIndex,
+		WriteRequest:   structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	out, err = state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected job")
+	}
+	if out.Priority != job.Priority {
+		t.Fatalf("priority mis-match")
+	}
+}
+
+func TestJobEndpoint_Revert(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the initial register request
+	job := mock.Job()
+	job.Priority = 100
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reregister again to get another version
+	job2 := job.Copy()
+	job2.Priority = 1
+	req = &structs.JobRegisterRequest{
+		Job:          job2,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create a revert request targeting the current version
+	revertReq := &structs.JobRevertRequest{
+		JobID:        job.ID,
+		JobVersion:   1,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Reverting to the current version should fail
+	err := msgpackrpc.CallWithCodec(codec, "Job.Revert", revertReq, &resp)
+	if err == nil || !strings.Contains(err.Error(), "current version") {
+		t.Fatalf("expected current version err: %v", err)
+	}
+
+	// Reverting to an unknown version should fail
+	revertReq.JobVersion = 10
+	err = msgpackrpc.CallWithCodec(codec, "Job.Revert", revertReq, &resp)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found err: %v", err)
+	}
+
+	// Revert to the first version
+	revertReq.JobVersion = 0
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Revert", revertReq, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+	if resp.EvalID == "" || resp.EvalCreateIndex == 0 {
+		t.Fatalf("bad created eval: %+v", resp)
+	}
+
+	// Check that the job is at the correct version and has the original
+	// specification
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected job")
+	}
+	if out.Priority != job.Priority {
+		t.Fatalf("priority mis-match")
+	}
+	if out.Version != 2 {
+		t.Fatalf("got version %d; want %d", out.Version, 2)
+	}
+
+	// The reverted version should be unchanged
+	old, err := state.JobByIDAndVersion(job.ID, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if old == nil || old.Version != 0 {
+		t.Fatalf("bad: %#v", old)
+	}
+}
+
 func TestJobEndpoint_Evaluate(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -237,6 +489,42 @@ func TestJobEndpoint_Evaluate(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Evaluate_Periodic(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	job := mock.PeriodicJob()
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.JobModifyIndex == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	// Force a re-evaluation
+	reEval := &structs.JobEvaluateRequest{
+		JobID:        job.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Evaluate", reEval, &resp); err == nil {
+		t.Fatal("expect an err")
+	}
+}
+
 func TestJobEndpoint_Deregister(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -347,6 +635,12 @@ func TestJobEndpoint_GetJob(t *testing.T) {
 		t.Fatalf("Bad index: %d %d", resp2.Index, resp.Index)
 	}
 
+	// The submit time is set by the server
+	if resp2.Job == nil || resp2.Job.SubmitTime == 0 {
+		t.Fatalf("bad: %#v", resp2.Job)
+	}
+	job.SubmitTime = resp2.Job.SubmitTime
+
 	if !reflect.DeepEqual(job, resp2.Job) {
 		t.Fatalf("bad: %#v %#v", job, resp2.Job)
 	}
@@ -364,6 +658,70 @@ func TestJobEndpoint_GetJob(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_GetJobVersions(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	job := mock.Job()
+	job.Priority = 88
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Register the job again to create another version
+	job.Priority = 100
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the job
+	get := &structs.JobSpecificRequest{
+		JobID:        job.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var versionsResp structs.JobVersionsResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJobVersions", get, &versionsResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if versionsResp.Index != resp.JobModifyIndex {
+		t.Fatalf("Bad index: %d %d", versionsResp.Index, resp.Index)
+	}
+
+	// Make sure there are two job versions, newest first
+	versions := versionsResp.Versions
+	if l := len(versions); l != 2 {
+		t.Fatalf("Got %d versions; want 2", l)
+	}
+	if v := versions[0]; v.Priority != 100 || v.ID != job.ID || v.Version != 1 {
+		t.Fatalf("bad: %+v", v)
+	}
+	if v := versions[1]; v.Priority != 88 || v.ID != job.ID || v.Version != 0 {
+		t.Fatalf("bad: %+v", v)
+	}
+
+	// Lookup non-existing job
+	get.JobID = "foobarbaz"
+	if err := msgpackrpc.CallWithCodec(codec, "Job.GetJobVersions", get, &versionsResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if versionsResp.Index != resp.JobModifyIndex {
+		t.Fatalf("Bad index: %d %d", versionsResp.Index, resp.Index)
+	}
+	if l := len(versionsResp.Versions); l != 0 {
+		t.Fatalf("unexpected versions: %d", l)
+	}
+}
+
 func TestJobEndpoint_GetJob_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -658,3 +1016,363 @@ func TestJobEndpoint_Evaluations(t *testing.T) {
 		t.Fatalf("bad: %#v", resp2.Evaluations)
 	}
 }
+
+func TestJobEndpoint_Dispatch(t *testing.T) {
+	// No requirements
+	d1 := mock.ParameterizedJob()
+
+	// Meta requirements
+	d2 := mock.ParameterizedJob()
+	d2.ParameterizedJob.MetaRequired = []string{"foo", "bar"}
+	d2.ParameterizedJob.MetaOptional = []string{"biff", "baz"}
+
+	// Payload requirements
+	d3 := mock.ParameterizedJob()
+	d3.ParameterizedJob.Payload = structs.DispatchPayloadRequired
+
+	// Disallow payload
+	d4 := mock.ParameterizedJob()
+	d4.ParameterizedJob.Payload = structs.DispatchPayloadForbidden
+
+	reqNoInputNoMeta := &structs.JobDispatchRequest{}
+	reqInputDataNoMeta := &structs.JobDispatchRequest{
+		Payload: []byte("hello world"),
+	}
+	reqNoInputValidMeta := &structs.JobDispatchRequest{
+		Meta: map[string]string{
+			"foo": "bar",
+			"bar": "foo",
+		},
+	}
+	reqInputDataValidMeta := &structs.JobDispatchRequest{
+		Meta: map[string]string{
+			"foo": "bar",
+			"bar": "foo",
+		},
+		Payload: []byte("hello world"),
+	}
+	reqNoInputInvalidMeta := &structs.JobDispatchRequest{
+		Meta: map[string]string{
+			"foo":  "bar",
+			"bar":  "foo",
+			"foom": "nohello",
+		},
+	}
+	reqInputDataTooLarge := &structs.JobDispatchRequest{
+		Payload: make([]byte, structs.DispatchPayloadSizeLimit+100),
+	}
+
+	type testCase struct {
+		name             string
+		parameterizedJob *structs.Job
+		dispatchReq      *structs.JobDispatchRequest
+		err              bool
+		errStr           string
+	}
+	cases := []testCase{
+		{
+			name:             "optional input data w/ data",
+			parameterizedJob: d1,
+			dispatchReq:      reqInputDataNoMeta,
+			err:              false,
+		},
+		{
+			name:             "optional input data w/o data",
+			parameterizedJob: d1,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              false,
+		},
+		{
+			name:             "require input data w/ data",
+			parameterizedJob: d3,
+			dispatchReq:      reqInputDataNoMeta,
+			err:              false,
+		},
+		{
+			name:             "require input data w/o data",
+			parameterizedJob: d3,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              true,
+			errStr:           "not provided but required",
+		},
+		{
+			name:             "disallow input data w/o data",
+			parameterizedJob: d4,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              false,
+		},
+		{
+			name:             "disallow input data w/ data",
+			parameterizedJob: d4,
+			dispatchReq:      reqInputDataNoMeta,
+			err:              true,
+			errStr:           "provided but forbidden",
+		},
+		{
+			name:             "input data too large",
+			parameterizedJob: d1,
+			dispatchReq:      reqInputDataTooLarge,
+			err:              true,
+			errStr:           "Payload exceeds maximum size",
+		},
+		{
+			name:             "require meta w/ meta",
+			parameterizedJob: d2,
+			dispatchReq:      reqInputDataValidMeta,
+			err:              false,
+		},
+		{
+			name:             "require meta w/o meta",
+			parameterizedJob: d2,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              true,
+			errStr:           "did not provide required meta keys",
+		},
+		{
+			name:             "disallowed meta",
+			parameterizedJob: d2,
+			dispatchReq:      reqNoInputInvalidMeta,
+			err:              true,
+			errStr:           "unpermitted metadata keys",
+		},
+		{
+			name:             "require meta w/ meta and no payload",
+			parameterizedJob: d2,
+			dispatchReq:      reqNoInputValidMeta,
+			err:              false,
+		},
+	}
+
+	for _, tc := range cases {
+		s1 := testServer(t, func(c *Config) {
+			c.NumSchedulers = 0 // Prevent automatic dequeue
+		})
+		codec := rpcClient(t, s1)
+		testutil.WaitForLeader(t, s1.RPC)
+
+		// Create the register request
+		regReq := &structs.JobRegisterRequest{
+			Job:          tc.parameterizedJob,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+
+		// Fetch the response
+		var regResp structs.JobRegisterResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Job.Register", regReq, &regResp); err != nil {
+			t.Fatalf("%s: err: %v", tc.name, err)
+		}
+		if regResp.EvalID != "" {
+			t.Fatalf("%s: registering a parameterized job created an eval", tc.name)
+		}
+
+		// Now try to dispatch
+		tc.dispatchReq.JobID = tc.parameterizedJob.ID
+		tc.dispatchReq.WriteRequest = structs.WriteRequest{Region: "global"}
+
+		var dispatchResp structs.JobDispatchResponse
+		dispatchErr := msgpackrpc.CallWithCodec(codec, "Job.Dispatch", tc.dispatchReq, &dispatchResp)
+
+		if dispatchErr == nil {
+			if tc.err {
+				t.Fatalf("%s: Expected error: %v", tc.name, dispatchErr)
+			}
+
+			// Check that we got an eval and job id back
+			if dispatchResp.EvalID == "" || dispatchResp.DispatchedJobID == "" {
+				t.Fatalf("%s: Bad response: %#v", tc.name, dispatchResp)
+			}
+
+			state := s1.fsm.State()
+			out, err := state.JobByID(dispatchResp.DispatchedJobID)
+			if err != nil {
+				t.Fatalf("%s: err: %v", tc.name, err)
+			}
+			if out == nil {
+				t.Fatalf("%s: expected job", tc.name)
+			}
+			if out.CreateIndex != dispatchResp.JobCreateIndex {
+				t.Fatalf("%s: index mis-match", tc.name)
+			}
+			if out.ParentID != tc.parameterizedJob.ID {
+				t.Fatalf("%s: bad parent ID", tc.name)
+			}
+			if out.IsParameterized() {
+				t.Fatalf("%s: dispatched job should not be parameterized", tc.name)
+			}
+			if string(out.Payload) != string(tc.dispatchReq.Payload) {
+				t.Fatalf("%s: bad payload: %q", tc.name, out.Payload)
+			}
+			for k, v := range tc.dispatchReq.Meta {
+				if out.Meta[k] != v {
+					t.Fatalf("%s: meta %q not merged: %#v", tc.name, k, out.Meta)
+				}
+			}
+
+			// Lookup the evaluation
+			eval, err := state.EvalByID(dispatchResp.EvalID)
+			if err != nil {
+				t.Fatalf("%s: err: %v", tc.name, err)
+			}
+			if eval == nil {
+				t.Fatalf("%s: expected eval", tc.name)
+			}
+			if eval.CreateIndex != dispatchResp.EvalCreateIndex {
+				t.Fatalf("%s: index mis-match", tc.name)
+			}
+		} else {
+			if !tc.err {
+				t.Fatalf("%s: Got unexpected error: %v", tc.name, dispatchErr)
+			} else if !strings.Contains(dispatchErr.Error(), tc.errStr) {
+				t.Fatalf("%s: Expected err to include %q; got %v", tc.name, tc.errStr, dispatchErr)
+			}
+		}
+
+		s1.Shutdown()
+	}
+}
+
+func TestJobEndpoint_Dispatch_NonParameterized(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register a regular job
+	job := mock.Job()
+	regReq := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var regResp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", regReq, &regResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := &structs.JobDispatchRequest{
+		JobID:        job.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.JobDispatchResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Dispatch", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "not a parameterized job") {
+		t.Fatalf("expected error dispatching a non-parameterized job: %v", err)
+	}
+}
+
+func TestJobEndpoint_Plan_WithDiff(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	job := mock.Job()
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	// Create a plan request
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.TaskGroups[0].Count = 20
+	planReq := &structs.JobPlanRequest{
+		Job:          job2,
+		Diff:         true,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var planResp structs.JobPlanResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Plan", planReq, &planResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check the response
+	if planResp.JobModifyIndex == 0 {
+		t.Fatalf("bad cas: %d", planResp.JobModifyIndex)
+	}
+	if planResp.Annotations == nil {
+		t.Fatalf("no annotations")
+	}
+	if updates, ok := planResp.Annotations.DesiredTGUpdates["web"]; !ok || updates.Place != 20 {
+		t.Fatalf("bad updates: %#v", planResp.Annotations.DesiredTGUpdates)
+	}
+	if len(planResp.FailedAllocs) != 1 {
+		t.Fatalf("bad failed allocs: %#v", planResp.FailedAllocs)
+	}
+	if planResp.Diff == nil {
+		t.Fatalf("no diff")
+	}
+	if planResp.Diff.Type != structs.DiffTypeEdited {
+		t.Fatalf("bad diff: %#v", planResp.Diff)
+	}
+
+	// The job should not have been modified
+	out, err := s1.fsm.State().JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.TaskGroups[0].Count != job.TaskGroups[0].Count || out.ModifyIndex != planResp.JobModifyIndex {
+		t.Fatalf("job modified by plan: %#v", out)
+	}
+}
+
+func TestJobEndpoint_Plan_NoDiff(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a plan request for a new job
+	job := mock.Job()
+	planReq := &structs.JobPlanRequest{
+		Job:          job,
+		Diff:         false,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var planResp structs.JobPlanResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Plan", planReq, &planResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check the response
+	if planResp.JobModifyIndex != 0 {
+		t.Fatalf("bad cas: %d", planResp.JobModifyIndex)
+	}
+	if planResp.Annotations == nil {
+		t.Fatalf("no annotations")
+	}
+	if len(planResp.FailedAllocs) != 1 {
+		t.Fatalf("bad failed allocs: %#v", planResp.FailedAllocs)
+	}
+	if planResp.Diff != nil {
+		t.Fatalf("got diff")
+	}
+
+	// The job should not have been registered
+	out, err := s1.fsm.State().JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("job registered by plan: %#v", out)
+	}
+}
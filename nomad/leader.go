@@ -117,12 +117,26 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 		return err
 	}
 
+	// Enable the periodic dispatcher, since we are now the leader.
+	s.periodicDispatcher.SetEnabled(true)
+
+	// Restore the periodic dispatcher state
+	if err := s.restorePeriodicDispatcher(); err != nil {
+		return err
+	}
+
 	// Scheduler periodic jobs
 	go s.schedulePeriodic(stopCh)
 
 	// Reap any failed evaluations
 	go s.reapFailedEvaluations(stopCh)
 
+	// Drive the active deployments
+	go s.watchDeployments(stopCh)
+
+	// Migrate the allocations off draining nodes
+	go s.drainNodes(stopCh)
+
 	// Setup the heartbeat timers. This is done both when starting up or when
 	// a leader fail over happens. Since the timers are maintained by the leader
 	// node, effectively this means all the timers are renewed at the time of failover.
@@ -167,12 +181,69 @@ func (s *Server) restoreEvalBroker() error {
 	return nil
 }
 
+// restorePeriodicDispatcher is used to restore all periodic jobs into the
+// periodic dispatcher. It also determines if a periodic job should have been
+// created during the leadership transition and force runs them. The periodic
+// dispatcher is maintained only by the leader, so it must be restored anytime a
+// leadership transition takes place.
+func (s *Server) restorePeriodicDispatcher() error {
+	iter, err := s.fsm.State().Jobs()
+	if err != nil {
+		return fmt.Errorf("failed to get jobs: %v", err)
+	}
+
+	now := time.Now()
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		job := raw.(*structs.Job)
+
+		if !job.IsPeriodic() {
+			continue
+		}
+
+		if err := s.periodicDispatcher.Add(job); err != nil {
+			return fmt.Errorf("failed to add periodic job %q: %v", job.ID, err)
+		}
+
+		// If the periodic job has never been launched before, launch will hold
+		// the time the periodic job was added. Otherwise it has the last launch
+		// time of the periodic job.
+		launch, err := s.fsm.State().PeriodicLaunchByID(job.ID)
+		if err != nil || launch == nil {
+			return fmt.Errorf("failed to get periodic launch time: %v", err)
+		}
+
+		// nextLaunch is the next launch that should occur.
+		nextLaunch := job.Periodic.Next(launch.Launch)
+
+		// We skip force launching the job if there should be no next launch
+		// (the zero case) or if the next launch time is in the future. If it is
+		// in the future, it will be handled by the periodic dispatcher.
+		if nextLaunch.IsZero() || !nextLaunch.Before(now) {
+			continue
+		}
+
+		if _, err := s.periodicDispatcher.ForceRun(job.ID); err != nil {
+			return fmt.Errorf("force run of periodic job %q failed: %v", job.ID, err)
+		}
+		s.logger.Printf("[DEBUG] nomad.periodic: periodic job %q force"+
+			" run during leadership establishment", job.ID)
+	}
+
+	return nil
+}
+
 // schedulePeriodic is used to do periodic job dispatch while we are leader
 func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 	evalGC := time.NewTicker(s.config.EvalGCInterval)
 	defer evalGC.Stop()
 	nodeGC := time.NewTicker(s.config.NodeGCInterval)
 	defer nodeGC.Stop()
+	jobGC := time.NewTicker(s.config.JobGCInterval)
+	defer jobGC.Stop()
 
 	for {
 		select {
@@ -180,6 +251,8 @@ func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 			s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobEvalGC))
 		case <-nodeGC.C:
 			s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobNodeGC))
+		case <-jobGC.C:
+			s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobJobGC))
 		case <-stopCh:
 			return
 		}
@@ -246,6 +319,9 @@ func (s *Server) revokeLeadership() error {
 	// Disable the eval broker, since it is only useful as a leader
 	s.evalBroker.SetEnabled(false)
 
+	// Disable the periodic dispatcher, since it is only useful as a leader
+	s.periodicDispatcher.SetEnabled(false)
+
 	// Clear the heartbeat timers on either shutdown or step down,
 	// since we are no longer responsible for TTL expirations.
 	if err := s.clearAllHeartbeatTimers(); err != nil {
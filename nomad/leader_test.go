@@ -306,6 +306,60 @@ func TestLeader_PeriodicDispatch(t *testing.T) {
 	})
 }
 
+func TestLeader_PeriodicDispatcher_Restore(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Insert a periodic job whose last launch was long enough ago that a
+	// launch was missed.
+	job := mock.PeriodicJob()
+	state := s1.fsm.State()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	past := time.Now().Add(-1 * time.Hour)
+	launch := &structs.PeriodicLaunch{ID: job.ID, Launch: past}
+	if err := state.UpsertPeriodicLaunch(1001, launch); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Simulate a leadership transition.
+	s1.periodicDispatcher.SetEnabled(false)
+	if tracked := s1.periodicDispatcher.Tracked(); len(tracked) != 0 {
+		t.Fatalf("disabling should flush the dispatcher: %v", tracked)
+	}
+	s1.periodicDispatcher.SetEnabled(true)
+	if err := s1.restorePeriodicDispatcher(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check that the job is tracked again.
+	tracked := s1.periodicDispatcher.Tracked()
+	if len(tracked) != 1 || tracked[0].ID != job.ID {
+		t.Fatalf("periodic job not restored: %v", tracked)
+	}
+
+	// Check that the missed launch was forced and recorded.
+	out, err := state.PeriodicLaunchByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || !out.Launch.After(past) {
+		t.Fatalf("missed launch should have been forced: %#v", out)
+	}
+
+	iter, err := state.JobsByParent(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if iter.Next() == nil {
+		t.Fatalf("expected a derived job")
+	}
+}
+
 func TestLeader_ReapFailedEval(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0
@@ -48,8 +48,9 @@ func Node() *structs.Node {
 		Meta: map[string]string{
 			"pci-dss": "true",
 		},
-		NodeClass: "linux-medium-pci",
-		Status:    structs.NodeStatusReady,
+		NodeClass:             "linux-medium-pci",
+		SchedulingEligibility: structs.NodeSchedulingEligible,
+		Status:                structs.NodeStatusReady,
 	}
 	return node
 }
@@ -119,6 +120,26 @@ func Job() *structs.Job {
 	return job
 }
 
+func PeriodicJob() *structs.Job {
+	job := Job()
+	job.Type = structs.JobTypeBatch
+	job.Periodic = &structs.PeriodicConfig{
+		Enabled:  true,
+		SpecType: structs.PeriodicSpecCron,
+		Spec:     "*/30 * * * *",
+	}
+	return job
+}
+
+func ParameterizedJob() *structs.Job {
+	job := Job()
+	job.Type = structs.JobTypeBatch
+	job.ParameterizedJob = &structs.ParameterizedJobConfig{
+		Payload: structs.DispatchPayloadOptional,
+	}
+	return job
+}
+
 func SystemJob() *structs.Job {
 	job := &structs.Job{
 		Region:      "global",
@@ -229,6 +250,25 @@ func Alloc() *structs.Allocation {
 	return alloc
 }
 
+func Deployment() *structs.Deployment {
+	return &structs.Deployment{
+		ID:             structs.GenerateUUID(),
+		JobID:          structs.GenerateUUID(),
+		JobVersion:     2,
+		JobModifyIndex: 20,
+		JobCreateIndex: 18,
+		TaskGroups: map[string]*structs.DeploymentState{
+			"web": &structs.DeploymentState{
+				DesiredTotal: 10,
+			},
+		},
+		Status:            structs.DeploymentStatusRunning,
+		StatusDescription: structs.DeploymentStatusDescriptionRunning,
+		ModifyIndex:       23,
+		CreateIndex:       21,
+	}
+}
+
 func Plan() *structs.Plan {
 	return &structs.Plan{
 		Priority: 50,
@@ -209,9 +209,22 @@ func (n *Node) UpdateDrain(args *structs.NodeUpdateDrainRequest,
 		return fmt.Errorf("node not found")
 	}
 
-	// Commit this update via Raft
+	// Start the drain at the deadline of the requested spec. The allocations
+	// are migrated by the drainer of the leader.
+	if args.Drain {
+		var spec structs.DrainSpec
+		if args.DrainStrategy != nil {
+			spec = args.DrainStrategy.DrainSpec
+		}
+		args.DrainStrategy = structs.NewDrainStrategy(spec, time.Now())
+	} else {
+		args.DrainStrategy = nil
+	}
+
+	// Commit this update via Raft. Enabling the drain again updates the
+	// strategy of the drain in progress.
 	var index uint64
-	if node.Drain != args.Drain {
+	if node.Drain != args.Drain || args.Drain {
 		_, index, err = n.srv.raftApply(structs.NodeUpdateDrainRequestType, args)
 		if err != nil {
 			n.srv.logger.Printf("[ERR] nomad.client: drain update failed: %v", err)
@@ -220,8 +233,73 @@ func (n *Node) UpdateDrain(args *structs.NodeUpdateDrainRequest,
 		reply.NodeModifyIndex = index
 	}
 
-	// Check if we should trigger evaluations
-	if args.Drain {
+	// Place the system jobs on the node again once the drain is disabled
+	if node.Drain && !args.Drain {
+		evalIDs, evalIndex, err := n.createNodeEvals(args.NodeID, index)
+		if err != nil {
+			n.srv.logger.Printf("[ERR] nomad.client: eval creation failed: %v", err)
+			return err
+		}
+		reply.EvalIDs = evalIDs
+		reply.EvalCreateIndex = evalIndex
+	}
+
+	// Set the reply index
+	reply.Index = index
+	return nil
+}
+
+// UpdateEligibility is used to update the scheduling eligibility of a client
+// node
+func (n *Node) UpdateEligibility(args *structs.NodeUpdateEligibilityRequest,
+	reply *structs.NodeEligibilityUpdateResponse) error {
+	if done, err := n.srv.forward("Node.UpdateEligibility", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "client", "update_eligibility"}, time.Now())
+
+	// Verify the arguments
+	if args.NodeID == "" {
+		return fmt.Errorf("missing node ID for eligibility update")
+	}
+	switch args.Eligibility {
+	case structs.NodeSchedulingEligible, structs.NodeSchedulingIneligible:
+	default:
+		return fmt.Errorf("invalid scheduling eligibility %q", args.Eligibility)
+	}
+
+	// Look for the node
+	snap, err := n.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	node, err := snap.NodeByID(args.NodeID)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return fmt.Errorf("node not found")
+	}
+
+	// A draining node stays ineligible until the drain is disabled
+	if node.Drain && args.Eligibility == structs.NodeSchedulingEligible {
+		return fmt.Errorf("can not mark a draining node eligible; disable the drain instead")
+	}
+
+	// Commit this update via Raft
+	var index uint64
+	if node.SchedulingEligibility != args.Eligibility {
+		_, index, err = n.srv.raftApply(structs.NodeUpdateEligibilityRequestType, args)
+		if err != nil {
+			n.srv.logger.Printf("[ERR] nomad.client: eligibility update failed: %v", err)
+			return err
+		}
+		reply.NodeModifyIndex = index
+	}
+
+	// Place the system jobs on the node once it becomes eligible again
+	if node.SchedulingEligibility == structs.NodeSchedulingIneligible &&
+		args.Eligibility == structs.NodeSchedulingEligible {
 		evalIDs, evalIndex, err := n.createNodeEvals(args.NodeID, index)
 		if err != nil {
 			n.srv.logger.Printf("[ERR] nomad.client: eval creation failed: %v", err)
@@ -2,6 +2,7 @@ package nomad
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -296,9 +297,13 @@ func TestClientEndpoint_UpdateDrain(t *testing.T) {
 	}
 
 	// Update the status
+	start := time.Now()
 	dereg := &structs.NodeUpdateDrainRequest{
-		NodeID:       node.ID,
-		Drain:        true,
+		NodeID: node.ID,
+		Drain:  true,
+		DrainStrategy: &structs.DrainStrategy{
+			DrainSpec: structs.DrainSpec{Deadline: time.Hour},
+		},
 		WriteRequest: structs.WriteRequest{Region: "global"},
 	}
 	var resp2 structs.NodeDrainUpdateResponse
@@ -318,6 +323,96 @@ func TestClientEndpoint_UpdateDrain(t *testing.T) {
 	if !out.Drain {
 		t.Fatalf("bad: %#v", out)
 	}
+	has, deadline := out.DrainStrategy.DeadlineTime()
+	if !has || deadline.Before(start.Add(time.Hour)) || deadline.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("bad: %#v", out.DrainStrategy)
+	}
+
+	// Disabling the drain clears the strategy
+	dereg.Drain = false
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateDrain", dereg, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Drain || out.DrainStrategy != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestClientEndpoint_UpdateEligibility(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	node := mock.Node()
+	reg := &structs.NodeRegisterRequest{
+		Node:         node,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.NodeUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Mark the node ineligible
+	elig := &structs.NodeUpdateEligibilityRequest{
+		NodeID:       node.ID,
+		Eligibility:  structs.NodeSchedulingIneligible,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp2 structs.NodeEligibilityUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateEligibility", elig, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp2.Index == 0 {
+		t.Fatalf("bad index: %d", resp2.Index)
+	}
+
+	// Check for the node in the FSM
+	state := s1.fsm.State()
+	out, err := state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.SchedulingEligibility != structs.NodeSchedulingIneligible {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// An invalid eligibility is rejected
+	elig.Eligibility = "foo"
+	err = msgpackrpc.CallWithCodec(codec, "Node.UpdateEligibility", elig, &resp2)
+	if err == nil || !strings.Contains(err.Error(), "invalid scheduling eligibility") {
+		t.Fatalf("expected invalid eligibility error, got: %v", err)
+	}
+
+	// A draining node can not be marked eligible
+	if err := state.UpdateNodeDrain(resp2.Index+1, node.ID, true, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	elig.Eligibility = structs.NodeSchedulingEligible
+	err = msgpackrpc.CallWithCodec(codec, "Node.UpdateEligibility", elig, &resp2)
+	if err == nil || !strings.Contains(err.Error(), "draining") {
+		t.Fatalf("expected draining error, got: %v", err)
+	}
+
+	// Disabling the drain marks the node eligible again
+	if err := state.UpdateNodeDrain(resp2.Index+2, node.ID, false, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.SchedulingEligibility != structs.NodeSchedulingEligible {
+		t.Fatalf("bad: %#v", out)
+	}
 }
 
 func TestClientEndpoint_GetNode(t *testing.T) {
@@ -922,7 +1017,7 @@ func TestClientEndpoint_ListNodes_Blocking(t *testing.T) {
 
 	// Node drain updates trigger watches.
 	time.AfterFunc(100*time.Millisecond, func() {
-		if err := state.UpdateNodeDrain(3, node.ID, true); err != nil {
+		if err := state.UpdateNodeDrain(3, node.ID, true, nil); err != nil {
 			t.Fatalf("err: %v", err)
 		}
 	})
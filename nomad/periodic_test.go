@@ -0,0 +1,383 @@
+package nomad
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// MockJobEvalDispatcher is a JobEvalDispatcher that records the jobs it was
+// asked to dispatch.
+type MockJobEvalDispatcher struct {
+	Jobs map[string]*structs.Job
+	lock sync.Mutex
+}
+
+func NewMockJobEvalDispatcher() *MockJobEvalDispatcher {
+	return &MockJobEvalDispatcher{Jobs: make(map[string]*structs.Job)}
+}
+
+func (m *MockJobEvalDispatcher) DispatchJob(job *structs.Job) (*structs.Evaluation, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Jobs[job.ID] = job
+	return nil, nil
+}
+
+func (m *MockJobEvalDispatcher) RunningChildren(parent *structs.Job) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, job := range m.Jobs {
+		if job.ParentID == parent.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LaunchTimes returns the launch times of child jobs in sorted order.
+func (m *MockJobEvalDispatcher) LaunchTimes(p *PeriodicDispatch, parentID string) ([]time.Time, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var launches []time.Time
+	for _, job := range m.Jobs {
+		if job.ParentID != parentID {
+			continue
+		}
+
+		t, err := p.LaunchTime(job.ID)
+		if err != nil {
+			return nil, err
+		}
+		launches = append(launches, t)
+	}
+	return launches, nil
+}
+
+func testPeriodicDispatcher() (*PeriodicDispatch, *MockJobEvalDispatcher) {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	m := NewMockJobEvalDispatcher()
+	d := NewPeriodicDispatch(logger, m)
+	d.SetEnabled(true)
+	return d, m
+}
+
+// testPeriodicJob is a helper that creates a periodic job that launches at the
+// passed time.
+func testPeriodicJob(launch time.Time) *structs.Job {
+	job := mock.PeriodicJob()
+	launch = launch.UTC()
+	job.Periodic.Spec = fmt.Sprintf("%d %d %d %d %d * %d",
+		launch.Second(), launch.Minute(), launch.Hour(), launch.Day(),
+		int(launch.Month()), launch.Year())
+	return job
+}
+
+func TestPeriodicDispatch_Add_NonPeriodic(t *testing.T) {
+	p, _ := testPeriodicDispatcher()
+	job := mock.Job()
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add of non-periodic job failed: %v; expect no-op", err)
+	}
+
+	tracked := p.Tracked()
+	if len(tracked) != 0 {
+		t.Fatalf("Add of non-periodic job should be no-op: %v", tracked)
+	}
+}
+
+func TestPeriodicDispatch_Add_UpdateJob(t *testing.T) {
+	p, _ := testPeriodicDispatcher()
+	job := mock.PeriodicJob()
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	tracked := p.Tracked()
+	if len(tracked) != 1 {
+		t.Fatalf("Add didn't track the job: %v", tracked)
+	}
+
+	// Update the job and add it again.
+	job.Periodic.Spec = "foo"
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	tracked = p.Tracked()
+	if len(tracked) != 1 {
+		t.Fatalf("Add didn't update: %v", tracked)
+	}
+
+	if !reflect.DeepEqual(job, tracked[0]) {
+		t.Fatalf("Add didn't properly update: got %v; want %v", tracked[0], job)
+	}
+}
+
+func TestPeriodicDispatch_Add_RemoveJob(t *testing.T) {
+	p, _ := testPeriodicDispatcher()
+	job := mock.PeriodicJob()
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	tracked := p.Tracked()
+	if len(tracked) != 1 {
+		t.Fatalf("Add didn't track the job: %v", tracked)
+	}
+
+	// Update the job to be non-periodic and add it again.
+	job.Periodic = nil
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	tracked = p.Tracked()
+	if len(tracked) != 0 {
+		t.Fatalf("Add didn't remove: %v", tracked)
+	}
+}
+
+func TestPeriodicDispatch_Remove_Untracked(t *testing.T) {
+	p, _ := testPeriodicDispatcher()
+	if err := p.Remove("foo"); err != nil {
+		t.Fatalf("Remove failed %v; expected a no-op", err)
+	}
+}
+
+func TestPeriodicDispatch_Remove_Tracked(t *testing.T) {
+	p, _ := testPeriodicDispatcher()
+
+	job := mock.PeriodicJob()
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	if err := p.Remove(job.ID); err != nil {
+		t.Fatalf("Remove failed %v", err)
+	}
+
+	tracked := p.Tracked()
+	if len(tracked) != 0 {
+		t.Fatalf("Remove didn't untrack the job: %v", tracked)
+	}
+}
+
+func TestPeriodicDispatch_ForceRun_Untracked(t *testing.T) {
+	p, _ := testPeriodicDispatcher()
+
+	if _, err := p.ForceRun("foo"); err == nil {
+		t.Fatal("ForceRun of untracked job should fail")
+	}
+}
+
+func TestPeriodicDispatch_ForceRun_Tracked(t *testing.T) {
+	p, m := testPeriodicDispatcher()
+
+	// Create a job that won't be evaluated for a while.
+	job := testPeriodicJob(time.Now().Add(10 * time.Second))
+
+	// Add it.
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	// ForceRun the job
+	if _, err := p.ForceRun(job.ID); err != nil {
+		t.Fatalf("ForceRun failed %v", err)
+	}
+
+	// Check that ForceRun created a child job.
+	times, err := m.LaunchTimes(p, job.ID)
+	if err != nil {
+		t.Fatalf("failed to get launch times for job %q", job.ID)
+	}
+	if len(times) != 1 {
+		t.Fatalf("incorrect number of launch times for job %q; got %d; want 1", job.ID, len(times))
+	}
+}
+
+func TestPeriodicDispatch_Run_Launch(t *testing.T) {
+	p, m := testPeriodicDispatcher()
+
+	// Create a job that will be launched shortly.
+	launch := time.Now().Add(1 * time.Second)
+	job := testPeriodicJob(launch)
+
+	// Add it.
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	time.Sleep(2 * time.Second)
+
+	times, err := m.LaunchTimes(p, job.ID)
+	if err != nil {
+		t.Fatalf("failed to get launch times for job %q", job.ID)
+	}
+	if len(times) != 1 {
+		t.Fatalf("incorrect number of launch times for job %q; got %d; want 1", job.ID, len(times))
+	}
+	if times[0].Unix() != launch.Unix() {
+		t.Fatalf("periodic dispatcher created eval at the wrong time; got %v; want %v", times[0], launch)
+	}
+}
+
+func TestPeriodicDispatch_Run_ProhibitOverlap(t *testing.T) {
+	p, m := testPeriodicDispatcher()
+
+	// Create a job that will be launched shortly and prohibits overlap.
+	launch := time.Now().Add(1 * time.Second)
+	job := testPeriodicJob(launch)
+	job.Periodic.ProhibitOverlap = true
+
+	// Seed a running child of the job.
+	child := p.deriveJob(job, time.Now())
+	m.Jobs[child.ID] = child
+
+	// Add it.
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	time.Sleep(2 * time.Second)
+
+	times, err := m.LaunchTimes(p, job.ID)
+	if err != nil {
+		t.Fatalf("failed to get launch times for job %q", job.ID)
+	}
+	if len(times) != 1 {
+		t.Fatalf("overlapping job should not have launched; got %d launches", len(times))
+	}
+}
+
+func TestPeriodicDispatch_SetEnabled_Flush(t *testing.T) {
+	p, _ := testPeriodicDispatcher()
+	if err := p.Add(mock.PeriodicJob()); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	p.SetEnabled(false)
+	if tracked := p.Tracked(); len(tracked) != 0 {
+		t.Fatalf("disabling should flush tracked jobs: %v", tracked)
+	}
+
+	// Adding while disabled is a no-op.
+	if err := p.Add(mock.PeriodicJob()); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+	if tracked := p.Tracked(); len(tracked) != 0 {
+		t.Fatalf("Add should be a no-op when disabled: %v", tracked)
+	}
+}
+
+func TestPeriodicDispatch_LaunchTime(t *testing.T) {
+	p, _ := testPeriodicDispatcher()
+	job := mock.PeriodicJob()
+	launch := time.Unix(1445000000, 0)
+
+	child := p.deriveJob(job, launch)
+	if child.ParentID != job.ID {
+		t.Fatalf("bad parent: %q", child.ParentID)
+	}
+	if child.Periodic != nil {
+		t.Fatalf("derived job should not be periodic")
+	}
+	if !strings.HasPrefix(child.ID, job.ID+JobLaunchSuffix) {
+		t.Fatalf("bad derived ID: %q", child.ID)
+	}
+
+	out, err := p.LaunchTime(child.ID)
+	if err != nil {
+		t.Fatalf("LaunchTime failed: %v", err)
+	}
+	if !out.Equal(launch) {
+		t.Fatalf("LaunchTime got %v; want %v", out, launch)
+	}
+
+	if _, err := p.LaunchTime(job.ID); err == nil {
+		t.Fatalf("LaunchTime of non-derived job should fail")
+	}
+}
+
+func TestPeriodicHeap_Order(t *testing.T) {
+	h := newPeriodicHeap()
+	j1 := mock.PeriodicJob()
+	j2 := mock.PeriodicJob()
+	j3 := mock.PeriodicJob()
+
+	lookup := map[*structs.Job]string{
+		j1: "j1",
+		j2: "j2",
+		j3: "j3",
+	}
+
+	h.Push(j1, time.Time{})
+	h.Push(j2, time.Unix(10, 0))
+	h.Push(j3, time.Unix(11, 0))
+
+	exp := []string{"j2", "j3", "j1"}
+	var act []string
+	for i := 0; i < 3; i++ {
+		pJob := h.Pop()
+		act = append(act, lookup[pJob.job])
+	}
+
+	if !reflect.DeepEqual(act, exp) {
+		t.Fatalf("Wrong ordering; got %v; want %v", act, exp)
+	}
+}
+
+func TestPeriodicDispatch_Server_ForceRun(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Insert a periodic job directly into the state store and track it.
+	job := mock.PeriodicJob()
+	if err := s1.fsm.State().UpsertJob(1000, job); err != nil {
+		t.Fatalf("UpsertJob failed: %v", err)
+	}
+	if err := s1.periodicDispatcher.Add(job); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	eval, err := s1.periodicDispatcher.ForceRun(job.ID)
+	if err != nil {
+		t.Fatalf("ForceRun failed: %v", err)
+	}
+
+	// The derived job and its evaluation should be committed.
+	state := s1.fsm.State()
+	out, err := state.EvalByID(eval.ID)
+	if err != nil || out == nil {
+		t.Fatalf("expected eval: %v", err)
+	}
+	if out.TriggeredBy != structs.EvalTriggerPeriodicJob {
+		t.Fatalf("bad: %#v", out)
+	}
+	child, err := state.JobByID(out.JobID)
+	if err != nil || child == nil {
+		t.Fatalf("expected derived job: %v", err)
+	}
+	if child.ParentID != job.ID {
+		t.Fatalf("bad: %#v", child)
+	}
+
+	// The launch should be recorded against the parent.
+	launch, err := state.PeriodicLaunchByID(job.ID)
+	if err != nil || launch == nil {
+		t.Fatalf("expected periodic launch: %v", err)
+	}
+}
@@ -118,7 +118,10 @@ func (s *Server) planApply() {
 
 // applyPlan is used to apply the plan result and to return the alloc index
 func (s *Server) applyPlan(result *structs.PlanResult, snap *state.StateSnapshot) (raft.ApplyFuture, error) {
-	req := structs.AllocUpdateRequest{}
+	req := structs.ApplyPlanResultsRequest{
+		Deployment:        result.Deployment,
+		DeploymentUpdates: result.DeploymentUpdates,
+	}
 	for _, updateList := range result.NodeUpdate {
 		req.Alloc = append(req.Alloc, updateList...)
 	}
@@ -128,7 +131,7 @@ func (s *Server) applyPlan(result *structs.PlanResult, snap *state.StateSnapshot
 	req.Alloc = append(req.Alloc, result.FailedAllocs...)
 
 	// Dispatch the Raft transaction
-	future, err := s.raftApplyFuture(structs.AllocUpdateRequestType, &req)
+	future, err := s.raftApplyFuture(structs.ApplyPlanResultsRequestType, &req)
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +139,7 @@ func (s *Server) applyPlan(result *structs.PlanResult, snap *state.StateSnapshot
 	// Optimistically apply to our state view
 	if snap != nil {
 		nextIdx := s.raft.AppliedIndex() + 1
-		if err := snap.UpsertAllocs(nextIdx, req.Alloc); err != nil {
+		if err := snap.UpsertPlanResults(nextIdx, &req); err != nil {
 			return future, err
 		}
 	}
@@ -169,9 +172,11 @@ func evaluatePlan(snap *state.StateSnapshot, plan *structs.Plan) (*structs.PlanR
 
 	// Create a result holder for the plan
 	result := &structs.PlanResult{
-		NodeUpdate:     make(map[string][]*structs.Allocation),
-		NodeAllocation: make(map[string][]*structs.Allocation),
-		FailedAllocs:   plan.FailedAllocs,
+		NodeUpdate:        make(map[string][]*structs.Allocation),
+		NodeAllocation:    make(map[string][]*structs.Allocation),
+		FailedAllocs:      plan.FailedAllocs,
+		Deployment:        plan.Deployment.Copy(),
+		DeploymentUpdates: plan.DeploymentUpdates,
 	}
 
 	// Collect all the nodeIDs
@@ -208,6 +213,8 @@ func evaluatePlan(snap *state.StateSnapshot, plan *structs.Plan) (*structs.PlanR
 			if plan.AllAtOnce {
 				result.NodeUpdate = nil
 				result.NodeAllocation = nil
+				result.Deployment = nil
+				result.DeploymentUpdates = nil
 				return result, nil
 			}
 
@@ -157,6 +157,62 @@ func TestPlanApply_applyPlan(t *testing.T) {
 	}
 }
 
+func TestPlanApply_applyPlan_Deployment(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register node
+	node := mock.Node()
+	testRegisterNode(t, s1, node)
+
+	// Register a canary that is part of a new deployment
+	d := mock.Deployment()
+	alloc := mock.Alloc()
+	alloc.DeploymentID = d.ID
+	alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+	plan := &structs.PlanResult{
+		NodeAllocation: map[string][]*structs.Allocation{
+			node.ID: []*structs.Allocation{alloc},
+		},
+		Deployment: d,
+	}
+
+	// Snapshot the state
+	snap, err := s1.State().Snapshot()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Apply the plan
+	future, err := s1.applyPlan(plan, snap)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Verify our optimistic snapshot is updated
+	if out, err := snap.DeploymentByID(d.ID); err != nil || out == nil {
+		t.Fatalf("bad: %v %v", out, err)
+	}
+
+	// Check plan does apply cleanly
+	if _, err := planWaitFuture(future); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the deployment
+	out, err := s1.fsm.State().DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("missing deployment")
+	}
+	if canaries := out.TaskGroups["web"].PlacedCanaries; len(canaries) != 1 || canaries[0] != alloc.ID {
+		t.Fatalf("bad: %#v", out.TaskGroups["web"])
+	}
+}
+
 func TestPlanApply_EvalPlan_Simple(t *testing.T) {
 	state := testStateStore(t)
 	node := mock.Node()
@@ -112,6 +112,9 @@ type Server struct {
 	// plans that are waiting to be assessed by the leader
 	planQueue *PlanQueue
 
+	// periodicDispatcher is used to track and create evaluations for periodic jobs.
+	periodicDispatcher *PeriodicDispatch
+
 	// heartbeatTimers track the expiration time of each heartbeat that has
 	// a TTL. On expiration, the node status is updated to be 'down'.
 	heartbeatTimers     map[string]*time.Timer
@@ -128,12 +131,15 @@ type Server struct {
 
 // Holds the RPC endpoints
 type endpoints struct {
-	Status *Status
-	Node   *Node
-	Job    *Job
-	Eval   *Eval
-	Plan   *Plan
-	Alloc  *Alloc
+	Status     *Status
+	Node       *Node
+	Job        *Job
+	Eval       *Eval
+	Plan       *Plan
+	Alloc      *Alloc
+	Periodic   *Periodic
+	Deployment *Deployment
+	System     *System
 }
 
 // NewServer is used to construct a new Nomad server from the
@@ -179,6 +185,9 @@ func NewServer(config *Config) (*Server, error) {
 		shutdownCh:  make(chan struct{}),
 	}
 
+	// Create the periodic dispatcher for launching periodic jobs.
+	s.periodicDispatcher = NewPeriodicDispatch(s.logger, s)
+
 	// Initialize the RPC layer
 	// TODO: TLS...
 	if err := s.setupRPC(nil); err != nil {
@@ -353,6 +362,9 @@ func (s *Server) setupRPC(tlsWrap tlsutil.DCWrapper) error {
 	s.endpoints.Eval = &Eval{s}
 	s.endpoints.Plan = &Plan{s}
 	s.endpoints.Alloc = &Alloc{s}
+	s.endpoints.Periodic = &Periodic{s}
+	s.endpoints.Deployment = &Deployment{s}
+	s.endpoints.System = &System{s}
 
 	// Register the handlers
 	s.rpcServer.Register(s.endpoints.Status)
@@ -361,6 +373,9 @@ func (s *Server) setupRPC(tlsWrap tlsutil.DCWrapper) error {
 	s.rpcServer.Register(s.endpoints.Eval)
 	s.rpcServer.Register(s.endpoints.Plan)
 	s.rpcServer.Register(s.endpoints.Alloc)
+	s.rpcServer.Register(s.endpoints.Periodic)
+	s.rpcServer.Register(s.endpoints.Deployment)
+	s.rpcServer.Register(s.endpoints.System)
 
 	list, err := net.ListenTCP("tcp", s.config.RPCAddr)
 	if err != nil {
@@ -402,7 +417,7 @@ func (s *Server) setupRaft() error {
 
 	// Create the FSM
 	var err error
-	s.fsm, err = NewFSM(s.evalBroker, s.config.LogOutput)
+	s.fsm, err = NewFSM(s.evalBroker, s.periodicDispatcher, s.config.LogOutput)
 	if err != nil {
 		return err
 	}
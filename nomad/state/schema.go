@@ -18,8 +18,11 @@ func stateStoreSchema() *memdb.DBSchema {
 		indexTableSchema,
 		nodeTableSchema,
 		jobTableSchema,
+		jobVersionTableSchema,
+		periodicLaunchTableSchema,
 		evalTableSchema,
 		allocTableSchema,
+		deploymentSchema,
 	}
 
 	// Add each of the tables
@@ -100,6 +103,69 @@ func jobTableSchema() *memdb.TableSchema {
 					Lowercase: false,
 				},
 			},
+
+			// Parent index is used to lookup the jobs spawned by another
+			// job, such as the children of a periodic job.
+			"parent": &memdb.IndexSchema{
+				Name:         "parent",
+				AllowMissing: true, // Missing is allowed for jobs without a parent
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "ParentID",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+// jobVersionTableSchema returns the MemDB schema for the job versions
+// table. This table is used to store the recent versions of each job so
+// that a job can be reverted to a previous version.
+func jobVersionTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "job_versions",
+		Indexes: map[string]*memdb.IndexSchema{
+			// Primary index is the compound of the job ID and the
+			// version, which is unique.
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field:     "ID",
+							Lowercase: true,
+						},
+						&memdb.UintFieldIndex{
+							Field: "Version",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// periodicLaunchTableSchema returns the MemDB schema tracking the most recent
+// launch time for a periodic job.
+func periodicLaunchTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "periodic_launch",
+		Indexes: map[string]*memdb.IndexSchema{
+			// Primary index is used for job management
+			// and simple direct lookup. ID is required to be
+			// unique.
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "ID",
+					Lowercase: true,
+				},
+			},
 		},
 	}
 }
@@ -186,3 +252,31 @@ func allocTableSchema() *memdb.TableSchema {
 		},
 	}
 }
+
+// deploymentSchema returns the MemDB schema tracking a job's deployments
+func deploymentSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "deployment",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "ID",
+				},
+			},
+
+			// Job index is used to lookup deployments by job
+			"job": &memdb.IndexSchema{
+				Name:         "job",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "JobID",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sort"
 	"sync"
 
 	"github.com/hashicorp/go-memdb"
@@ -108,9 +109,14 @@ func (s *StateStore) UpsertNode(index uint64, node *structs.Node) error {
 		node.CreateIndex = exist.CreateIndex
 		node.ModifyIndex = index
 		node.Drain = exist.Drain // Retain the drain mode
+		node.DrainStrategy = exist.DrainStrategy
+		node.SchedulingEligibility = exist.SchedulingEligibility
 	} else {
 		node.CreateIndex = index
 		node.ModifyIndex = index
+		if node.SchedulingEligibility == "" {
+			node.SchedulingEligibility = structs.NodeSchedulingEligible
+		}
 	}
 
 	// Insert the node
@@ -198,7 +204,7 @@ func (s *StateStore) UpdateNodeStatus(index uint64, nodeID, status string) error
 }
 
 // UpdateNodeDrain is used to update the drain of a node
-func (s *StateStore) UpdateNodeDrain(index uint64, nodeID string, drain bool) error {
+func (s *StateStore) UpdateNodeDrain(index uint64, nodeID string, drain bool, strategy *structs.DrainStrategy) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
@@ -220,8 +226,55 @@ func (s *StateStore) UpdateNodeDrain(index uint64, nodeID string, drain bool) er
 	copyNode := new(structs.Node)
 	*copyNode = *existingNode
 
-	// Update the drain in the copy
+	// Update the drain in the copy. A draining node is ineligible for new
+	// allocations and becomes eligible again once the drain is disabled.
 	copyNode.Drain = drain
+	copyNode.DrainStrategy = strategy
+	if drain {
+		copyNode.SchedulingEligibility = structs.NodeSchedulingIneligible
+	} else {
+		copyNode.SchedulingEligibility = structs.NodeSchedulingEligible
+	}
+	copyNode.ModifyIndex = index
+
+	// Insert the node
+	if err := txn.Insert("nodes", copyNode); err != nil {
+		return fmt.Errorf("node update failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"nodes", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// UpdateNodeEligibility is used to update the scheduling eligibility of a node
+func (s *StateStore) UpdateNodeEligibility(index uint64, nodeID string, eligibility string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "nodes"})
+	watcher.Add(watch.Item{Node: nodeID})
+
+	// Lookup the node
+	existing, err := txn.First("nodes", "id", nodeID)
+	if err != nil {
+		return fmt.Errorf("node lookup failed: %v", err)
+	}
+	if existing == nil {
+		return fmt.Errorf("node not found")
+	}
+
+	// Copy the existing node
+	existingNode := existing.(*structs.Node)
+	copyNode := new(structs.Node)
+	*copyNode = *existingNode
+
+	// Update the eligibility in the copy
+	copyNode.SchedulingEligibility = eligibility
 	copyNode.ModifyIndex = index
 
 	// Insert the node
@@ -279,13 +332,15 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 		return fmt.Errorf("job lookup failed: %v", err)
 	}
 
-	// Setup the indexes correctly
+	// Setup the indexes and version correctly
 	if existing != nil {
 		job.CreateIndex = existing.(*structs.Job).CreateIndex
 		job.ModifyIndex = index
+		job.Version = existing.(*structs.Job).Version + 1
 	} else {
 		job.CreateIndex = index
 		job.ModifyIndex = index
+		job.Version = 0
 	}
 
 	// Insert the job
@@ -296,11 +351,48 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
+	// Track the version of the job
+	if err := s.upsertJobVersion(index, job, txn, watcher); err != nil {
+		return err
+	}
+
 	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
 	return nil
 }
 
+// upsertJobVersion inserts a job into its historic version table and limits
+// the number of tracked versions to JobTrackedVersions.
+func (s *StateStore) upsertJobVersion(index uint64, job *structs.Job, txn *memdb.Txn, watcher watch.Items) error {
+	watcher.Add(watch.Item{Table: "job_versions"})
+
+	// Insert the job
+	if err := txn.Insert("job_versions", job); err != nil {
+		return fmt.Errorf("job version insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_versions", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// Get all the historic jobs for this ID
+	all, err := s.jobVersionsByID(txn, job.ID)
+	if err != nil {
+		return fmt.Errorf("job version lookup failed: %v", err)
+	}
+
+	// Remove the versions beyond the number we track. The versions are
+	// sorted newest first so the oldest are at the end.
+	if len(all) <= structs.JobTrackedVersions {
+		return nil
+	}
+	for _, old := range all[structs.JobTrackedVersions:] {
+		if err := txn.Delete("job_versions", old); err != nil {
+			return fmt.Errorf("job version delete failed: %v", err)
+		}
+	}
+	return nil
+}
+
 // DeleteJob is used to deregister a job
 func (s *StateStore) DeleteJob(index uint64, jobID string) error {
 	txn := s.db.Txn(true)
@@ -327,6 +419,38 @@ func (s *StateStore) DeleteJob(index uint64, jobID string) error {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
+	// Delete all the tracked versions of the job
+	versions, err := s.jobVersionsByID(txn, jobID)
+	if err != nil {
+		return fmt.Errorf("job version lookup failed: %v", err)
+	}
+	if len(versions) != 0 {
+		watcher.Add(watch.Item{Table: "job_versions"})
+		for _, version := range versions {
+			if err := txn.Delete("job_versions", version); err != nil {
+				return fmt.Errorf("job version delete failed: %v", err)
+			}
+		}
+		if err := txn.Insert("index", &IndexEntry{"job_versions", index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+
+	// Delete the periodic launch tracking of the job, if any
+	launch, err := txn.First("periodic_launch", "id", jobID)
+	if err != nil {
+		return fmt.Errorf("periodic launch lookup failed: %v", err)
+	}
+	if launch != nil {
+		watcher.Add(watch.Item{Table: "periodic_launch"})
+		if err := txn.Delete("periodic_launch", launch); err != nil {
+			return fmt.Errorf("periodic launch delete failed: %v", err)
+		}
+		if err := txn.Insert("index", &IndexEntry{"periodic_launch", index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+
 	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
 	return nil
@@ -359,6 +483,76 @@ func (s *StateStore) Jobs() (memdb.ResultIterator, error) {
 	return iter, nil
 }
 
+// JobVersionsByID returns all the tracked versions of a job, sorted from
+// newest to oldest.
+func (s *StateStore) JobVersionsByID(id string) ([]*structs.Job, error) {
+	txn := s.db.Txn(false)
+	return s.jobVersionsByID(txn, id)
+}
+
+// jobVersionsByID is the implementation of JobVersionsByID that can be used
+// within an existing transaction.
+func (s *StateStore) jobVersionsByID(txn *memdb.Txn, id string) ([]*structs.Job, error) {
+	// Get all the historic jobs for this ID
+	iter, err := txn.Get("job_versions", "id_prefix", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []*structs.Job
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+
+		// Ensure the ID is an exact match and not just a prefix
+		job := raw.(*structs.Job)
+		if job.ID != id {
+			continue
+		}
+		all = append(all, job)
+	}
+
+	// Sort with the newest version first
+	sort.Sort(jobVersionSorter(all))
+	return all, nil
+}
+
+// jobVersionSorter sorts jobs from the newest version to the oldest.
+type jobVersionSorter []*structs.Job
+
+func (j jobVersionSorter) Len() int           { return len(j) }
+func (j jobVersionSorter) Swap(a, b int)      { j[a], j[b] = j[b], j[a] }
+func (j jobVersionSorter) Less(a, b int) bool { return j[a].Version > j[b].Version }
+
+// JobByIDAndVersion returns the job identified by its ID and version
+func (s *StateStore) JobByIDAndVersion(id string, version uint64) (*structs.Job, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("job_versions", "id", id, version)
+	if err != nil {
+		return nil, fmt.Errorf("job version lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Job), nil
+	}
+	return nil, nil
+}
+
+// JobVersions returns an iterator over all the tracked job versions
+func (s *StateStore) JobVersions() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire job versions table
+	iter, err := txn.Get("job_versions", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
 // JobsByScheduler returns an iterator over all the jobs with the specific
 // scheduler type.
 func (s *StateStore) JobsByScheduler(schedulerType string) (memdb.ResultIterator, error) {
@@ -372,6 +566,84 @@ func (s *StateStore) JobsByScheduler(schedulerType string) (memdb.ResultIterator
 	return iter, nil
 }
 
+// JobsByParent returns an iterator over all the jobs that were spawned
+// by the given parent job.
+func (s *StateStore) JobsByParent(parentID string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Return an iterator for jobs with the specific parent.
+	iter, err := txn.Get("jobs", "parent", parentID)
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// UpsertPeriodicLaunch is used to register a launch or update it.
+func (s *StateStore) UpsertPeriodicLaunch(index uint64, launch *structs.PeriodicLaunch) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "periodic_launch"})
+	watcher.Add(watch.Item{Job: launch.ID})
+
+	// Check if the launch already exists
+	existing, err := txn.First("periodic_launch", "id", launch.ID)
+	if err != nil {
+		return fmt.Errorf("periodic launch lookup failed: %v", err)
+	}
+
+	// Setup the indexes correctly
+	if existing != nil {
+		launch.CreateIndex = existing.(*structs.PeriodicLaunch).CreateIndex
+		launch.ModifyIndex = index
+	} else {
+		launch.CreateIndex = index
+		launch.ModifyIndex = index
+	}
+
+	// Insert the launch
+	if err := txn.Insert("periodic_launch", launch); err != nil {
+		return fmt.Errorf("launch insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"periodic_launch", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// PeriodicLaunchByID is used to lookup the launch of a periodic job by
+// the job's ID
+func (s *StateStore) PeriodicLaunchByID(id string) (*structs.PeriodicLaunch, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("periodic_launch", "id", id)
+	if err != nil {
+		return nil, fmt.Errorf("periodic launch lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.PeriodicLaunch), nil
+	}
+	return nil, nil
+}
+
+// PeriodicLaunches returns an iterator over all the periodic launches
+func (s *StateStore) PeriodicLaunches() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("periodic_launch", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
 // UpsertEvaluation is used to upsert an evaluation
 func (s *StateStore) UpsertEvals(index uint64, evals []*structs.Evaluation) error {
 	txn := s.db.Txn(true)
@@ -556,10 +828,27 @@ func (s *StateStore) UpdateAllocFromClient(index uint64, alloc *structs.Allocati
 	// Pull in anything the client is the authority on
 	copyAlloc.ClientStatus = alloc.ClientStatus
 	copyAlloc.ClientDescription = alloc.ClientDescription
+	copyAlloc.TaskStates = alloc.TaskStates
+
+	// The client is the authority on the health of the allocation. The
+	// health is only recorded once.
+	if alloc.DeploymentStatus.HasHealth() && !exist.DeploymentStatus.HasHealth() {
+		copyAlloc.DeploymentStatus = exist.DeploymentStatus.Copy()
+		if copyAlloc.DeploymentStatus == nil {
+			copyAlloc.DeploymentStatus = &structs.AllocDeploymentStatus{}
+		}
+		healthy := *alloc.DeploymentStatus.Healthy
+		copyAlloc.DeploymentStatus.Healthy = &healthy
+	}
 
 	// Update the modify index
 	copyAlloc.ModifyIndex = index
 
+	// Track the health against the allocation's deployment
+	if err := s.updateDeploymentWithAlloc(index, copyAlloc, exist, txn, watcher); err != nil {
+		return fmt.Errorf("error updating deployment: %v", err)
+	}
+
 	// Update the allocation
 	if err := txn.Insert("allocs", copyAlloc); err != nil {
 		return fmt.Errorf("alloc insert failed: %v", err)
@@ -582,6 +871,19 @@ func (s *StateStore) UpsertAllocs(index uint64, allocs []*structs.Allocation) er
 	defer txn.Abort()
 
 	watcher := watch.NewItems()
+	if err := s.upsertAllocsImpl(index, allocs, txn, watcher); err != nil {
+		return err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// upsertAllocsImpl is the actual implementation of the allocation upsert
+// which can be nested within a larger transaction.
+func (s *StateStore) upsertAllocsImpl(index uint64, allocs []*structs.Allocation,
+	txn *memdb.Txn, watcher watch.Items) error {
 	watcher.Add(watch.Item{Table: "allocs"})
 
 	// Handle the allocations
@@ -594,12 +896,32 @@ func (s *StateStore) UpsertAllocs(index uint64, allocs []*structs.Allocation) er
 		if existing == nil {
 			alloc.CreateIndex = index
 			alloc.ModifyIndex = index
+
+			// Track the placement against the allocation's deployment
+			if err := s.updateDeploymentWithAlloc(index, alloc, nil, txn, watcher); err != nil {
+				return fmt.Errorf("error updating deployment: %v", err)
+			}
 		} else {
 			exist := existing.(*structs.Allocation)
 			alloc.CreateIndex = exist.CreateIndex
 			alloc.ModifyIndex = index
 			alloc.ClientStatus = exist.ClientStatus
 			alloc.ClientDescription = exist.ClientDescription
+			alloc.TaskStates = exist.TaskStates
+
+			// Keep the health reported by the client for the same deployment
+			if exist.DeploymentStatus.HasHealth() && alloc.DeploymentID == exist.DeploymentID {
+				ds := alloc.DeploymentStatus.Copy()
+				if ds == nil {
+					ds = new(structs.AllocDeploymentStatus)
+				}
+				ds.Healthy = exist.DeploymentStatus.Healthy
+				alloc.DeploymentStatus = ds
+			}
+
+			// The transition may have been requested after the scheduler
+			// snapshotted the allocation
+			alloc.DesiredTransition = exist.DesiredTransition
 		}
 		if err := txn.Insert("allocs", alloc); err != nil {
 			return fmt.Errorf("alloc insert failed: %v", err)
@@ -615,6 +937,58 @@ func (s *StateStore) UpsertAllocs(index uint64, allocs []*structs.Allocation) er
 	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
 		return fmt.Errorf("index update failed: %v", err)
 	}
+	return nil
+}
+
+// UpdateAllocsDesiredTransitions is used to update the desired transitions of
+// a set of allocations and to create the evaluations that carry them out
+func (s *StateStore) UpdateAllocsDesiredTransitions(index uint64, allocs map[string]*structs.DesiredTransition,
+	evals []*structs.Evaluation) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "allocs"})
+
+	for allocID, transition := range allocs {
+		existing, err := txn.First("allocs", "id", allocID)
+		if err != nil {
+			return fmt.Errorf("alloc lookup failed: %v", err)
+		}
+
+		// The allocation may have been garbage collected
+		if existing == nil {
+			continue
+		}
+		exist := existing.(*structs.Allocation)
+
+		// Copy the existing allocation and update the transition
+		copyAlloc := new(structs.Allocation)
+		*copyAlloc = *exist
+		copyAlloc.DesiredTransition = *transition
+		copyAlloc.ModifyIndex = index
+
+		if err := txn.Insert("allocs", copyAlloc); err != nil {
+			return fmt.Errorf("alloc insert failed: %v", err)
+		}
+		watcher.Add(watch.Item{Alloc: exist.ID})
+		watcher.Add(watch.Item{AllocEval: exist.EvalID})
+		watcher.Add(watch.Item{AllocJob: exist.JobID})
+		watcher.Add(watch.Item{AllocNode: exist.NodeID})
+	}
+	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	if len(evals) != 0 {
+		watcher.Add(watch.Item{Table: "evals"})
+	}
+	for _, eval := range evals {
+		watcher.Add(watch.Item{Eval: eval.ID})
+		if err := s.nestedUpsertEval(txn, index, eval); err != nil {
+			return err
+		}
+	}
 
 	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
@@ -711,6 +1085,397 @@ func (s *StateStore) Allocs() (memdb.ResultIterator, error) {
 	return iter, nil
 }
 
+// UpsertPlanResults is used to upsert the results of a plan. The allocations,
+// the deployment and the deployment status updates are committed in a single
+// transaction.
+func (s *StateStore) UpsertPlanResults(index uint64, results *structs.ApplyPlanResultsRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+
+	// Upsert the newly created deployment
+	if results.Deployment != nil {
+		if err := s.upsertDeploymentImpl(index, results.Deployment, txn, watcher); err != nil {
+			return err
+		}
+	}
+
+	// Update the status of deployments effected by the plan.
+	for _, u := range results.DeploymentUpdates {
+		if err := s.updateDeploymentStatusImpl(index, u, txn, watcher); err != nil {
+			return err
+		}
+	}
+
+	// Upsert the allocations
+	if err := s.upsertAllocsImpl(index, results.Alloc, txn, watcher); err != nil {
+		return err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// UpsertDeployment is used to insert a new deployment.
+func (s *StateStore) UpsertDeployment(index uint64, deployment *structs.Deployment) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	if err := s.upsertDeploymentImpl(index, deployment, txn, watcher); err != nil {
+		return err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// upsertDeploymentImpl is used to insert a deployment within a transaction.
+func (s *StateStore) upsertDeploymentImpl(index uint64, deployment *structs.Deployment,
+	txn *memdb.Txn, watcher watch.Items) error {
+	// Check if the deployment already exists
+	existing, err := txn.First("deployment", "id", deployment.ID)
+	if err != nil {
+		return fmt.Errorf("deployment lookup failed: %v", err)
+	}
+
+	// Setup the indexes correctly
+	if existing != nil {
+		deployment.CreateIndex = existing.(*structs.Deployment).CreateIndex
+		deployment.ModifyIndex = index
+	} else {
+		deployment.CreateIndex = index
+		deployment.ModifyIndex = index
+	}
+
+	// Insert the deployment
+	if err := txn.Insert("deployment", deployment); err != nil {
+		return fmt.Errorf("deployment insert failed: %v", err)
+	}
+
+	// Update the indexes table for deployment
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	watcher.Add(watch.Item{Table: "deployment"})
+	watcher.Add(watch.Item{Deployment: deployment.ID})
+	return nil
+}
+
+// UpdateDeploymentStatus is used to make deployment status updates and
+// potentially make a evaluation
+func (s *StateStore) UpdateDeploymentStatus(index uint64, req *structs.DeploymentStatusUpdateRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	if err := s.updateDeploymentStatusImpl(index, req.DeploymentUpdate, txn, watcher); err != nil {
+		return err
+	}
+
+	// Upsert the optional eval
+	if req.Eval != nil {
+		watcher.Add(watch.Item{Table: "evals"})
+		watcher.Add(watch.Item{Eval: req.Eval.ID})
+		if err := s.nestedUpsertEval(txn, index, req.Eval); err != nil {
+			return err
+		}
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// updateDeploymentStatusImpl is used to make deployment status updates
+func (s *StateStore) updateDeploymentStatusImpl(index uint64, u *structs.DeploymentStatusUpdate,
+	txn *memdb.Txn, watcher watch.Items) error {
+	// Retrieve deployment
+	ws, err := txn.First("deployment", "id", u.DeploymentID)
+	if err != nil {
+		return err
+	} else if ws == nil {
+		return fmt.Errorf("Deployment ID %q couldn't be updated as it does not exist", u.DeploymentID)
+	}
+
+	// Copy the existing deployment so it isn't modified in place
+	copy := ws.(*structs.Deployment).Copy()
+	copy.Status = u.Status
+	copy.StatusDescription = u.StatusDescription
+	copy.ModifyIndex = index
+
+	// Insert the deployment
+	if err := txn.Insert("deployment", copy); err != nil {
+		return err
+	}
+
+	// Update the index
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	watcher.Add(watch.Item{Table: "deployment"})
+	watcher.Add(watch.Item{Deployment: copy.ID})
+
+	// A successful deployment marks the job version as stable
+	if copy.Status == structs.DeploymentStatusSuccessful {
+		if err := s.markJobStable(index, copy.JobID, copy.JobVersion, txn, watcher); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markJobStable marks the given version of the job as stable. The job's
+// modify index is left unchanged so that its allocations aren't updated.
+func (s *StateStore) markJobStable(index uint64, jobID string, version uint64,
+	txn *memdb.Txn, watcher watch.Items) error {
+	// Mark the tracked version as stable
+	raw, err := txn.First("job_versions", "id", jobID, version)
+	if err != nil {
+		return fmt.Errorf("job version lookup failed: %v", err)
+	}
+	if raw == nil {
+		return nil
+	}
+	jobV := raw.(*structs.Job).Copy()
+	jobV.Stable = true
+	if err := txn.Insert("job_versions", jobV); err != nil {
+		return fmt.Errorf("job version insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_versions", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	watcher.Add(watch.Item{Table: "job_versions"})
+
+	// Mark the current job if it is still at the version
+	raw, err = txn.First("jobs", "id", jobID)
+	if err != nil {
+		return fmt.Errorf("job lookup failed: %v", err)
+	}
+	if raw == nil || raw.(*structs.Job).Version != version {
+		return nil
+	}
+	job := raw.(*structs.Job).Copy()
+	job.Stable = true
+	if err := txn.Insert("jobs", job); err != nil {
+		return fmt.Errorf("job insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"jobs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	watcher.Add(watch.Item{Table: "jobs"})
+	watcher.Add(watch.Item{Job: jobID})
+	return nil
+}
+
+// UpdateDeploymentPromotion is used to promote the canaries of a deployment
+// and potentially make a evaluation
+func (s *StateStore) UpdateDeploymentPromotion(index uint64, req *structs.ApplyDeploymentPromoteRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	// Retrieve deployment and ensure it is not terminal and is active
+	ws, err := txn.First("deployment", "id", req.DeploymentID)
+	if err != nil {
+		return err
+	} else if ws == nil {
+		return fmt.Errorf("Deployment ID %q does not exist", req.DeploymentID)
+	}
+	deployment := ws.(*structs.Deployment)
+	if !deployment.Active() {
+		return fmt.Errorf("Deployment %q has terminal status %q", deployment.ID, deployment.Status)
+	}
+
+	// Build the set of groups to promote
+	groups := make(map[string]struct{}, len(req.Groups))
+	for _, tg := range req.Groups {
+		if _, ok := deployment.TaskGroups[tg]; !ok {
+			return fmt.Errorf("Deployment %q does not have task group %q", deployment.ID, tg)
+		}
+		groups[tg] = struct{}{}
+	}
+
+	// Update deployment
+	copy := deployment.Copy()
+	copy.ModifyIndex = index
+	for tg, status := range copy.TaskGroups {
+		if _, ok := groups[tg]; !req.All && !ok {
+			continue
+		}
+
+		status.Promoted = true
+	}
+
+	// If the deployment no longer needs promotion, update its status
+	if !copy.RequiresPromotion() && copy.Status == structs.DeploymentStatusRunning {
+		copy.StatusDescription = structs.DeploymentStatusDescriptionRunning
+	}
+
+	// Insert the deployment
+	if err := txn.Insert("deployment", copy); err != nil {
+		return err
+	}
+
+	// Update the index
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "deployment"})
+	watcher.Add(watch.Item{Deployment: copy.ID})
+
+	// Upsert the optional eval
+	if req.Eval != nil {
+		watcher.Add(watch.Item{Table: "evals"})
+		watcher.Add(watch.Item{Eval: req.Eval.ID})
+		if err := s.nestedUpsertEval(txn, index, req.Eval); err != nil {
+			return err
+		}
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// updateDeploymentWithAlloc is used to update the deployment state of an
+// allocation that is part of a deployment. New allocations count as placed and
+// the first health reported by the client is counted as healthy or unhealthy.
+// The existing allocation is nil for new allocations.
+func (s *StateStore) updateDeploymentWithAlloc(index uint64, alloc, existing *structs.Allocation,
+	txn *memdb.Txn, watcher watch.Items) error {
+	// Nothing to do if the allocation is not part of a deployment
+	if alloc.DeploymentID == "" {
+		return nil
+	}
+
+	// Determine the changes to the deployment's counts
+	var placed, healthy, unhealthy int
+	var existingHealth bool
+	if existing == nil {
+		placed = 1
+	} else {
+		existingHealth = existing.DeploymentStatus.HasHealth()
+	}
+	if !existingHealth && alloc.DeploymentStatus.HasHealth() {
+		if alloc.DeploymentStatus.IsHealthy() {
+			healthy = 1
+		} else {
+			unhealthy = 1
+		}
+	}
+	if placed == 0 && healthy == 0 && unhealthy == 0 {
+		return nil
+	}
+
+	raw, err := txn.First("deployment", "id", alloc.DeploymentID)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return nil
+	}
+
+	deployment := raw.(*structs.Deployment)
+	state, ok := deployment.TaskGroups[alloc.TaskGroup]
+	if !ok {
+		return nil
+	}
+
+	// Copy the deployment so it isn't modified in place
+	copy := deployment.Copy()
+	copy.ModifyIndex = index
+	state = copy.TaskGroups[alloc.TaskGroup]
+	state.PlacedAllocs += placed
+	state.HealthyAllocs += healthy
+	state.UnhealthyAllocs += unhealthy
+	if placed != 0 && alloc.DeploymentStatus.IsCanary() {
+		state.PlacedCanaries = append(state.PlacedCanaries, alloc.ID)
+	}
+
+	if err := txn.Insert("deployment", copy); err != nil {
+		return err
+	}
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	watcher.Add(watch.Item{Table: "deployment"})
+	watcher.Add(watch.Item{Deployment: copy.ID})
+	return nil
+}
+
+// DeploymentByID is used to lookup a deployment by its ID
+func (s *StateStore) DeploymentByID(id string) (*structs.Deployment, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("deployment", "id", id)
+	if err != nil {
+		return nil, fmt.Errorf("deployment lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Deployment), nil
+	}
+	return nil, nil
+}
+
+// Deployments returns an iterator over all the deployments
+func (s *StateStore) Deployments() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire deployments table
+	iter, err := txn.Get("deployment", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// DeploymentsByJobID returns all the deployments of the given job
+func (s *StateStore) DeploymentsByJobID(jobID string) ([]*structs.Deployment, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the deployments
+	iter, err := txn.Get("deployment", "job", jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Deployment
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Deployment))
+	}
+	return out, nil
+}
+
+// LatestDeploymentByJobID returns the most recently created deployment of the
+// given job
+func (s *StateStore) LatestDeploymentByJobID(jobID string) (*structs.Deployment, error) {
+	deployments, err := s.DeploymentsByJobID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out *structs.Deployment
+	for _, d := range deployments {
+		if out == nil || out.CreateIndex < d.CreateIndex {
+			out = d
+		}
+	}
+	return out, nil
+}
+
 // Index finds the matching index value
 func (s *StateStore) Index(name string) (uint64, error) {
 	txn := s.db.Txn(false)
@@ -783,6 +1548,25 @@ func (r *StateRestore) JobRestore(job *structs.Job) error {
 	return nil
 }
 
+// JobVersionRestore is used to restore a job version
+func (r *StateRestore) JobVersionRestore(job *structs.Job) error {
+	r.items.Add(watch.Item{Table: "job_versions"})
+	if err := r.txn.Insert("job_versions", job); err != nil {
+		return fmt.Errorf("job version insert failed: %v", err)
+	}
+	return nil
+}
+
+// PeriodicLaunchRestore is used to restore a periodic launch.
+func (r *StateRestore) PeriodicLaunchRestore(launch *structs.PeriodicLaunch) error {
+	r.items.Add(watch.Item{Table: "periodic_launch"})
+	r.items.Add(watch.Item{Job: launch.ID})
+	if err := r.txn.Insert("periodic_launch", launch); err != nil {
+		return fmt.Errorf("periodic launch insert failed: %v", err)
+	}
+	return nil
+}
+
 // EvalRestore is used to restore an evaluation
 func (r *StateRestore) EvalRestore(eval *structs.Evaluation) error {
 	r.items.Add(watch.Item{Table: "evals"})
@@ -806,6 +1590,16 @@ func (r *StateRestore) AllocRestore(alloc *structs.Allocation) error {
 	return nil
 }
 
+// DeploymentRestore is used to restore a deployment
+func (r *StateRestore) DeploymentRestore(deployment *structs.Deployment) error {
+	r.items.Add(watch.Item{Table: "deployment"})
+	r.items.Add(watch.Item{Deployment: deployment.ID})
+	if err := r.txn.Insert("deployment", deployment); err != nil {
+		return fmt.Errorf("deployment insert failed: %v", err)
+	}
+	return nil
+}
+
 // IndexRestore is used to restore an index
 func (r *StateRestore) IndexRestore(idx *IndexEntry) error {
 	if err := r.txn.Insert("index", idx); err != nil {
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -151,7 +152,8 @@ func TestStateStore_UpdateNodeDrain_Node(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	err = state.UpdateNodeDrain(1001, node.ID, true)
+	strategy := structs.NewDrainStrategy(structs.DrainSpec{Deadline: time.Hour}, time.Now())
+	err = state.UpdateNodeDrain(1001, node.ID, true, strategy)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -161,7 +163,69 @@ func TestStateStore_UpdateNodeDrain_Node(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	if !out.Drain {
+	if !out.Drain || !reflect.DeepEqual(out.DrainStrategy, strategy) {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.SchedulingEligibility != structs.NodeSchedulingIneligible {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	index, err := state.Index("nodes")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+
+	// Disabling the drain marks the node eligible again
+	err = state.UpdateNodeDrain(1002, node.ID, false, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err = state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Drain || out.DrainStrategy != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.SchedulingEligibility != structs.NodeSchedulingEligible {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestStateStore_UpdateNodeEligibility(t *testing.T) {
+	state := testStateStore(t)
+	node := mock.Node()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "nodes"},
+		watch.Item{Node: node.ID})
+
+	err := state.UpsertNode(1000, node)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err = state.UpdateNodeEligibility(1001, node.ID, structs.NodeSchedulingIneligible)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if out.SchedulingEligibility != structs.NodeSchedulingIneligible {
 		t.Fatalf("bad: %#v", out)
 	}
 	if out.ModifyIndex != 1001 {
@@ -177,6 +241,21 @@ func TestStateStore_UpdateNodeDrain_Node(t *testing.T) {
 	}
 
 	notify.verify(t)
+
+	// The eligibility is retained when the node registers again
+	node2 := new(structs.Node)
+	*node2 = *out
+	node2.SchedulingEligibility = ""
+	if err := state.UpsertNode(1002, node2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.SchedulingEligibility != structs.NodeSchedulingIneligible {
+		t.Fatalf("bad: %#v", out)
+	}
 }
 
 func TestStateStore_Nodes(t *testing.T) {
@@ -317,6 +396,9 @@ func TestStateStore_UpdateUpsertJob_Job(t *testing.T) {
 	if out.ModifyIndex != 1001 {
 		t.Fatalf("bad: %#v", out)
 	}
+	if out.Version != 1 {
+		t.Fatalf("bad: %#v", out)
+	}
 
 	index, err := state.Index("jobs")
 	if err != nil {
@@ -329,6 +411,65 @@ func TestStateStore_UpdateUpsertJob_Job(t *testing.T) {
 	notify.verify(t)
 }
 
+func TestStateStore_UpsertJob_JobVersions(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "job_versions"})
+
+	// Register the job more times than the number of tracked versions
+	var index uint64 = 1000
+	for i := 0; i < structs.JobTrackedVersions+2; i++ {
+		next := job.Copy()
+		next.Priority = i + 1
+		if err := state.UpsertJob(index, next); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		index++
+	}
+
+	out, err := state.JobVersionsByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out) != structs.JobTrackedVersions {
+		t.Fatalf("got %d versions; want %d", len(out), structs.JobTrackedVersions)
+	}
+
+	// Versions should be newest first and the oldest should be dropped
+	for i, version := range out {
+		expected := uint64(structs.JobTrackedVersions + 1 - i)
+		if version.Version != expected {
+			t.Fatalf("version %d: got %d; want %d", i, version.Version, expected)
+		}
+		if version.Priority != int(expected)+1 {
+			t.Fatalf("bad: %#v", version)
+		}
+	}
+
+	// Lookup a specific version
+	v, err := state.JobByIDAndVersion(job.ID, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v == nil || v.Version != 3 || v.Priority != 4 {
+		t.Fatalf("bad: %#v", v)
+	}
+
+	// The oldest version should have been removed
+	v, err = state.JobByIDAndVersion(job.ID, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v != nil {
+		t.Fatalf("bad: %#v", v)
+	}
+
+	notify.verify(t)
+}
+
 func TestStateStore_DeleteJob_Job(t *testing.T) {
 	state := testStateStore(t)
 	job := mock.Job()
@@ -357,6 +498,14 @@ func TestStateStore_DeleteJob_Job(t *testing.T) {
 		t.Fatalf("bad: %#v %#v", job, out)
 	}
 
+	versions, err := state.JobVersionsByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(versions) != 0 {
+		t.Fatalf("bad: %#v", versions)
+	}
+
 	index, err := state.Index("jobs")
 	if err != nil {
 		t.Fatalf("err: %v", err)
@@ -471,6 +620,170 @@ func TestStateStore_JobsByScheduler(t *testing.T) {
 	}
 }
 
+func TestStateStore_JobsByParent(t *testing.T) {
+	state := testStateStore(t)
+	parent := mock.PeriodicJob()
+	if err := state.UpsertJob(1000, parent); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var children []*structs.Job
+	for i := 0; i < 3; i++ {
+		child := mock.Job()
+		child.ParentID = parent.ID
+		children = append(children, child)
+
+		if err := state.UpsertJob(1001+uint64(i), child); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// An unrelated job should not be returned
+	if err := state.UpsertJob(2000, mock.Job()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	iter, err := state.JobsByParent(parent.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var out []*structs.Job
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Job))
+	}
+
+	sort.Sort(JobIDSort(children))
+	sort.Sort(JobIDSort(out))
+
+	if !reflect.DeepEqual(children, out) {
+		t.Fatalf("bad: %#v %#v", children, out)
+	}
+}
+
+func TestStateStore_UpsertPeriodicLaunch(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+	launch := &structs.PeriodicLaunch{ID: job.ID, Launch: time.Now()}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "periodic_launch"},
+		watch.Item{Job: job.ID})
+
+	err := state.UpsertPeriodicLaunch(1000, launch)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.PeriodicLaunchByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.CreateIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.ModifyIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Update the launch time
+	launch2 := &structs.PeriodicLaunch{ID: job.ID, Launch: launch.Launch.Add(time.Hour)}
+	if err := state.UpsertPeriodicLaunch(1001, launch2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err = state.PeriodicLaunchByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.CreateIndex != 1000 || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if !out.Launch.Equal(launch2.Launch) {
+		t.Fatalf("bad: %#v %#v", out, launch2)
+	}
+
+	index, err := state.Index("periodic_launch")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_DeleteJob_PeriodicLaunch(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.PeriodicJob()
+
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	launch := &structs.PeriodicLaunch{ID: job.ID, Launch: time.Now()}
+	if err := state.UpsertPeriodicLaunch(1001, launch); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := state.DeleteJob(1002, job.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.PeriodicLaunchByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	index, err := state.Index("periodic_launch")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1002 {
+		t.Fatalf("bad: %d", index)
+	}
+}
+
+func TestStateStore_RestorePeriodicLaunch(t *testing.T) {
+	state := testStateStore(t)
+	launch := &structs.PeriodicLaunch{ID: "foo", Launch: time.Now()}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "periodic_launch"},
+		watch.Item{Job: launch.ID})
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err = restore.PeriodicLaunchRestore(launch)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore.Commit()
+
+	out, err := state.PeriodicLaunchByID(launch.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, launch) {
+		t.Fatalf("Bad: %#v %#v", out, launch)
+	}
+
+	notify.verify(t)
+}
+
 func TestStateStore_RestoreJob(t *testing.T) {
 	state := testStateStore(t)
 	job := mock.Job()
@@ -854,6 +1167,15 @@ func TestStateStore_UpdateAllocFromClient(t *testing.T) {
 	update := new(structs.Allocation)
 	*update = *alloc
 	update.ClientStatus = structs.AllocClientStatusFailed
+	update.TaskStates = map[string]*structs.TaskState{
+		"web": &structs.TaskState{
+			State:  structs.TaskStateDead,
+			Failed: true,
+			Events: []*structs.TaskEvent{
+				structs.NewTaskEvent(structs.TaskTerminated).SetExitCode(1),
+			},
+		},
+	}
 
 	err = state.UpdateAllocFromClient(1001, update)
 	if err != nil {
@@ -881,20 +1203,30 @@ func TestStateStore_UpdateAllocFromClient(t *testing.T) {
 	notify.verify(t)
 }
 
-func TestStateStore_UpsertAlloc_Alloc(t *testing.T) {
+func TestStateStore_UpdateAllocFromClient_DeploymentHealth(t *testing.T) {
 	state := testStateStore(t)
+	d := mock.Deployment()
+	if err := state.UpsertDeployment(999, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
 	alloc := mock.Alloc()
+	alloc.DeploymentID = d.ID
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
 	notify := setupNotifyTest(
 		state,
-		watch.Item{Table: "allocs"},
-		watch.Item{Alloc: alloc.ID},
-		watch.Item{AllocEval: alloc.EvalID},
-		watch.Item{AllocJob: alloc.JobID},
-		watch.Item{AllocNode: alloc.NodeID})
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: d.ID})
 
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
-	if err != nil {
+	// Report the allocation as healthy
+	healthy := true
+	update := new(structs.Allocation)
+	*update = *alloc
+	update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+	if err := state.UpdateAllocFromClient(1001, update); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
@@ -902,38 +1234,162 @@ func TestStateStore_UpsertAlloc_Alloc(t *testing.T) {
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	if !reflect.DeepEqual(alloc, out) {
-		t.Fatalf("bad: %#v %#v", alloc, out)
+	if !out.DeploymentStatus.IsHealthy() {
+		t.Fatalf("bad: %#v", out.DeploymentStatus)
 	}
 
-	index, err := state.Index("allocs")
+	dout, err := state.DeploymentByID(d.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if index != 1000 {
-		t.Fatalf("bad: %d", index)
+	state1 := dout.TaskGroups["web"]
+	if state1.PlacedAllocs != 1 || state1.HealthyAllocs != 1 || state1.UnhealthyAllocs != 0 {
+		t.Fatalf("bad: %#v", state1)
+	}
+	if dout.ModifyIndex != 1001 {
+		t.Fatalf("bad: %d", dout.ModifyIndex)
 	}
 
 	notify.verify(t)
-}
 
-func TestStateStore_UpdateAlloc_Alloc(t *testing.T) {
-	state := testStateStore(t)
-	alloc := mock.Alloc()
+	// The health is only recorded once
+	unhealthy := false
+	update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &unhealthy}
+	if err := state.UpdateAllocFromClient(1002, update); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	out, err = state.AllocByID(alloc.ID)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	if !out.DeploymentStatus.IsHealthy() {
+		t.Fatalf("bad: %#v", out.DeploymentStatus)
+	}
 
-	alloc2 := mock.Alloc()
-	alloc2.ID = alloc.ID
-	alloc2.NodeID = alloc.NodeID + ".new"
-
-	notify := setupNotifyTest(
-		state,
-		watch.Item{Table: "allocs"},
+	dout, err = state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if state2 := dout.TaskGroups["web"]; state2.HealthyAllocs != 1 || state2.UnhealthyAllocs != 0 {
+		t.Fatalf("bad: %#v", state2)
+	}
+}
+
+func TestStateStore_UpdateAllocsDesiredTransitions(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "allocs"},
+		watch.Item{Alloc: alloc.ID},
+		watch.Item{AllocEval: alloc.EvalID},
+		watch.Item{AllocJob: alloc.JobID},
+		watch.Item{AllocNode: alloc.NodeID},
+		watch.Item{Table: "evals"})
+
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	transitions := map[string]*structs.DesiredTransition{
+		alloc.ID:               &structs.DesiredTransition{Migrate: true},
+		structs.GenerateUUID(): &structs.DesiredTransition{Migrate: true},
+	}
+	err := state.UpdateAllocsDesiredTransitions(1001, transitions, []*structs.Evaluation{eval})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.DesiredTransition.Migrate || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The transition is retained by updates of the scheduler
+	update := new(structs.Allocation)
+	*update = *alloc
+	update.DesiredStatus = structs.AllocDesiredStatusStop
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{update}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.DesiredTransition.Migrate {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	eout, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eout == nil || eout.CreateIndex != 1001 {
+		t.Fatalf("bad: %#v", eout)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_UpsertAlloc_Alloc(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "allocs"},
+		watch.Item{Alloc: alloc.ID},
+		watch.Item{AllocEval: alloc.EvalID},
+		watch.Item{AllocJob: alloc.JobID},
+		watch.Item{AllocNode: alloc.NodeID})
+
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !reflect.DeepEqual(alloc, out) {
+		t.Fatalf("bad: %#v %#v", alloc, out)
+	}
+
+	index, err := state.Index("allocs")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_UpdateAlloc_Alloc(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
+
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	alloc2 := mock.Alloc()
+	alloc2.ID = alloc.ID
+	alloc2.NodeID = alloc.NodeID + ".new"
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "allocs"},
 		watch.Item{Alloc: alloc2.ID},
 		watch.Item{AllocEval: alloc2.EvalID},
 		watch.Item{AllocJob: alloc2.JobID},
@@ -971,6 +1427,55 @@ func TestStateStore_UpdateAlloc_Alloc(t *testing.T) {
 	notify.verify(t)
 }
 
+func TestStateStore_UpdateAlloc_KeepsClientState(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
+	alloc.DeploymentID = structs.GenerateUUID()
+
+	err := state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The client reports the task states and the health
+	healthy := true
+	update := new(structs.Allocation)
+	*update = *alloc
+	update.ClientStatus = structs.AllocClientStatusRunning
+	update.TaskStates = map[string]*structs.TaskState{
+		"web": &structs.TaskState{State: structs.TaskStateRunning},
+	}
+	update.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+	if err := state.UpdateAllocFromClient(1001, update); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The scheduler upserts the copy it snapshotted before the client update
+	alloc2 := new(structs.Allocation)
+	*alloc2 = *alloc
+	alloc2.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{alloc2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.ClientStatus != structs.AllocClientStatusRunning {
+		t.Fatalf("bad: %#v", out)
+	}
+	if !reflect.DeepEqual(out.TaskStates, update.TaskStates) {
+		t.Fatalf("bad: %#v", out.TaskStates)
+	}
+	if !out.DeploymentStatus.IsHealthy() || !out.DeploymentStatus.IsCanary() {
+		t.Fatalf("bad: %#v", out.DeploymentStatus)
+	}
+	if out.ModifyIndex != 1002 {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestStateStore_EvictAlloc_Alloc(t *testing.T) {
 	state := testStateStore(t)
 	alloc := mock.Alloc()
@@ -1134,6 +1639,314 @@ func TestStateStore_RestoreAlloc(t *testing.T) {
 	notify.verify(t)
 }
 
+func TestStateStore_UpsertDeployment(t *testing.T) {
+	state := testStateStore(t)
+	deployment := mock.Deployment()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: deployment.ID})
+
+	err := state.UpsertDeployment(1000, deployment)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(deployment.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(deployment, out) {
+		t.Fatalf("bad: %#v %#v", deployment, out)
+	}
+
+	index, err := state.Index("deployment")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_LatestDeploymentByJobID(t *testing.T) {
+	state := testStateStore(t)
+	d1 := mock.Deployment()
+	d2 := mock.Deployment()
+	d2.JobID = d1.JobID
+
+	if err := state.UpsertDeployment(1000, d1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1001, d2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	all, err := state.DeploymentsByJobID(d1.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("bad: %#v", all)
+	}
+
+	out, err := state.LatestDeploymentByJobID(d1.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.ID != d2.ID {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestStateStore_UpdateDeploymentStatus(t *testing.T) {
+	state := testStateStore(t)
+	d := mock.Deployment()
+	if err := state.UpsertDeployment(1000, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedByUser,
+		},
+		Eval: eval,
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: d.ID},
+		watch.Item{Table: "evals"},
+		watch.Item{Eval: eval.ID})
+
+	if err := state.UpdateDeploymentStatus(1001, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusFailed || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	evalOut, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if evalOut == nil {
+		t.Fatalf("expected eval")
+	}
+
+	notify.verify(t)
+
+	// Updating a non-existent deployment fails
+	req.DeploymentUpdate.DeploymentID = structs.GenerateUUID()
+	if err := state.UpdateDeploymentStatus(1002, req); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestStateStore_UpdateDeploymentStatus_Successful(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	d := structs.NewDeployment(job)
+	if err := state.UpsertDeployment(1001, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "jobs"},
+		watch.Item{Job: job.ID},
+		watch.Item{Table: "job_versions"})
+
+	req := &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusSuccessful,
+			StatusDescription: structs.DeploymentStatusDescriptionSuccessful,
+		},
+	}
+	if err := state.UpdateDeploymentStatus(1002, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The job and its version are marked stable without being modified
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.Stable || out.ModifyIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	outV, err := state.JobByIDAndVersion(job.ID, job.Version)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !outV.Stable {
+		t.Fatalf("bad: %#v", outV)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_UpdateDeploymentPromotion(t *testing.T) {
+	state := testStateStore(t)
+	d := mock.Deployment()
+	d.StatusDescription = structs.DeploymentStatusDescriptionRunningNeedsPromotion
+	d.TaskGroups["web"].DesiredCanaries = 1
+	d.TaskGroups["api"] = &structs.DeploymentState{DesiredCanaries: 1}
+	if err := state.UpsertDeployment(1000, d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Promote a single group
+	eval := mock.Eval()
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			Groups:       []string{"web"},
+		},
+		Eval: eval,
+	}
+	if err := state.UpdateDeploymentPromotion(1001, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.TaskGroups["web"].Promoted || out.TaskGroups["api"].Promoted {
+		t.Fatalf("bad: %#v", out)
+	}
+	if !out.RequiresPromotion() {
+		t.Fatalf("deployment should still require promotion")
+	}
+
+	// Promote everything
+	req.Groups = nil
+	req.All = true
+	req.Eval = mock.Eval()
+	if err := state.UpdateDeploymentPromotion(1002, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err = state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.RequiresPromotion() || out.StatusDescription != structs.DeploymentStatusDescriptionRunning {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Unknown groups are rejected
+	req.All = false
+	req.Groups = []string{"foo"}
+	if err := state.UpdateDeploymentPromotion(1003, req); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestStateStore_UpsertPlanResults_Deployment(t *testing.T) {
+	state := testStateStore(t)
+	d := mock.Deployment()
+	d.TaskGroups["web"].DesiredCanaries = 1
+
+	alloc := mock.Alloc()
+	alloc.DeploymentID = d.ID
+	alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+	alloc2 := mock.Alloc()
+	alloc2.DeploymentID = d.ID
+
+	req := &structs.ApplyPlanResultsRequest{
+		AllocUpdateRequest: structs.AllocUpdateRequest{
+			Alloc: []*structs.Allocation{alloc, alloc2},
+		},
+		Deployment: d,
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: d.ID},
+		watch.Item{Table: "allocs"},
+		watch.Item{Alloc: alloc.ID})
+
+	if err := state.UpsertPlanResults(1000, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	tg := out.TaskGroups["web"]
+	if tg.PlacedAllocs != 2 {
+		t.Fatalf("bad: %#v", tg)
+	}
+	if len(tg.PlacedCanaries) != 1 || tg.PlacedCanaries[0] != alloc.ID {
+		t.Fatalf("bad: %#v", tg)
+	}
+
+	allocOut, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if allocOut == nil || !allocOut.DeploymentStatus.IsCanary() {
+		t.Fatalf("bad: %#v", allocOut)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_RestoreDeployment(t *testing.T) {
+	state := testStateStore(t)
+	d := mock.Deployment()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: d.ID})
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err = restore.DeploymentRestore(d)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	restore.Commit()
+
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, d) {
+		t.Fatalf("Bad: %#v %#v", out, d)
+	}
+
+	notify.verify(t)
+}
+
 func TestStateWatch_watch(t *testing.T) {
 	sw := newStateWatch()
 	notify1 := make(chan struct{}, 1)
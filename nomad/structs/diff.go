@@ -0,0 +1,1199 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/helper/flatmap"
+)
+
+// DiffType denotes the type of a diff object.
+type DiffType string
+
+var (
+	DiffTypeNone    DiffType = "None"
+	DiffTypeAdded   DiffType = "Added"
+	DiffTypeDeleted DiffType = "Deleted"
+	DiffTypeEdited  DiffType = "Edited"
+)
+
+// JobDiff contains the diff of two jobs.
+type JobDiff struct {
+	Type       DiffType
+	ID         string
+	Fields     []*FieldDiff
+	Objects    []*ObjectDiff
+	TaskGroups []*TaskGroupDiff
+}
+
+// Diff returns a diff of two jobs and a potential error if the Jobs are not
+// diffable. If contextual diff is enabled, objects within the job will contain
+// field information even if unchanged.
+func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
+	diff := &JobDiff{Type: DiffTypeNone}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+	filter := []string{"ID", "Status", "StatusDescription", "Version",
+		"Stable", "SubmitTime", "CreateIndex", "ModifyIndex"}
+
+	if j == nil && other == nil {
+		return diff, nil
+	} else if j == nil {
+		j = &Job{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+		diff.ID = other.ID
+	} else if other == nil {
+		other = &Job{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(j, filter, true)
+		diff.ID = j.ID
+	} else {
+		if j.ID != other.ID {
+			return nil, fmt.Errorf("can not diff jobs with different IDs: %q and %q", j.ID, other.ID)
+		}
+
+		oldPrimitiveFlat = flatmap.Flatten(j, filter, true)
+		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+		diff.ID = other.ID
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Datacenters diff
+	if setDiff := stringSetDiff(j.Datacenters, other.Datacenters, "Datacenters", contextual); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+
+	// Constraints diff
+	diff.Objects = append(diff.Objects, constraintsDiff(j.Constraints, other.Constraints, contextual)...)
+
+	// Spreads diff
+	diff.Objects = append(diff.Objects, spreadsDiff(j.Spreads, other.Spreads, contextual)...)
+
+	// Meta diff
+	if mDiff := mapDiff(j.Meta, other.Meta, "Meta", contextual); mDiff != nil {
+		diff.Objects = append(diff.Objects, mDiff)
+	}
+
+	// Update diff
+	if uDiff := primitiveObjectDiff(&j.Update, &other.Update, nil, "Update", contextual); uDiff != nil {
+		diff.Objects = append(diff.Objects, uDiff)
+	}
+
+	// Periodic diff
+	if pDiff := primitiveObjectDiff(j.Periodic, other.Periodic, nil, "Periodic", contextual); pDiff != nil {
+		diff.Objects = append(diff.Objects, pDiff)
+	}
+
+	// Parameterized job diff
+	if pDiff := parameterizedJobDiff(j.ParameterizedJob, other.ParameterizedJob, contextual); pDiff != nil {
+		diff.Objects = append(diff.Objects, pDiff)
+	}
+
+	// Task groups diff
+	tgs, err := taskGroupDiffs(j.TaskGroups, other.TaskGroups, contextual)
+	if err != nil {
+		return nil, err
+	}
+	diff.TaskGroups = tgs
+
+	// Determine the type of an update to an existing job
+	if diff.Type == DiffTypeNone &&
+		(fieldsChanged(diff.Fields) || objectsChanged(diff.Objects)) {
+		diff.Type = DiffTypeEdited
+	}
+	for _, tg := range diff.TaskGroups {
+		if diff.Type == DiffTypeNone && tg.Type != DiffTypeNone {
+			diff.Type = DiffTypeEdited
+		}
+	}
+
+	sort.Sort(FieldDiffs(diff.Fields))
+	sort.Sort(ObjectDiffs(diff.Objects))
+	return diff, nil
+}
+
+func (j *JobDiff) GoString() string {
+	out := fmt.Sprintf("Job %q (%s):\n", j.ID, j.Type)
+
+	for _, f := range j.Fields {
+		out += fmt.Sprintf("%#v\n", f)
+	}
+
+	for _, o := range j.Objects {
+		out += fmt.Sprintf("%#v\n", o)
+	}
+
+	for _, tg := range j.TaskGroups {
+		out += fmt.Sprintf("%#v\n", tg)
+	}
+
+	return out
+}
+
+// TaskGroupDiff contains the diff of two task groups.
+type TaskGroupDiff struct {
+	Type    DiffType
+	Name    string
+	Fields  []*FieldDiff
+	Objects []*ObjectDiff
+	Tasks   []*TaskDiff
+	Updates map[string]uint64
+}
+
+// Diff returns a diff of two task groups. If contextual diff is enabled,
+// objects' fields will be stored even if no diff occurred as long as one field
+// changed.
+func (tg *TaskGroup) Diff(other *TaskGroup, contextual bool) (*TaskGroupDiff, error) {
+	diff := &TaskGroupDiff{Type: DiffTypeNone}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+	filter := []string{"Name"}
+
+	if tg == nil && other == nil {
+		return diff, nil
+	} else if tg == nil {
+		tg = &TaskGroup{}
+		diff.Type = DiffTypeAdded
+		diff.Name = other.Name
+		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+	} else if other == nil {
+		other = &TaskGroup{}
+		diff.Type = DiffTypeDeleted
+		diff.Name = tg.Name
+		oldPrimitiveFlat = flatmap.Flatten(tg, filter, true)
+	} else {
+		if tg.Name != other.Name {
+			return nil, fmt.Errorf("can not diff task groups with different names: %q and %q", tg.Name, other.Name)
+		}
+
+		diff.Name = other.Name
+		oldPrimitiveFlat = flatmap.Flatten(tg, filter, true)
+		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Constraints diff
+	diff.Objects = append(diff.Objects, constraintsDiff(tg.Constraints, other.Constraints, contextual)...)
+
+	// Spreads diff
+	diff.Objects = append(diff.Objects, spreadsDiff(tg.Spreads, other.Spreads, contextual)...)
+
+	// Meta diff
+	if mDiff := mapDiff(tg.Meta, other.Meta, "Meta", contextual); mDiff != nil {
+		diff.Objects = append(diff.Objects, mDiff)
+	}
+
+	// Restart policy diff
+	if rDiff := primitiveObjectDiff(tg.RestartPolicy, other.RestartPolicy, nil, "RestartPolicy", contextual); rDiff != nil {
+		diff.Objects = append(diff.Objects, rDiff)
+	}
+
+	// Ephemeral disk diff
+	if eDiff := primitiveObjectDiff(tg.EphemeralDisk, other.EphemeralDisk, nil, "EphemeralDisk", contextual); eDiff != nil {
+		diff.Objects = append(diff.Objects, eDiff)
+	}
+
+	// Migrate strategy diff
+	if mDiff := primitiveObjectDiff(tg.Migrate, other.Migrate, nil, "Migrate", contextual); mDiff != nil {
+		diff.Objects = append(diff.Objects, mDiff)
+	}
+
+	// Volumes diff
+	diff.Objects = append(diff.Objects, volumeDiffs(tg.Volumes, other.Volumes, contextual)...)
+
+	// Tasks diff
+	tasks, err := taskDiffs(tg.Tasks, other.Tasks, contextual)
+	if err != nil {
+		return nil, err
+	}
+	diff.Tasks = tasks
+
+	// Determine the type of an update to an existing task group
+	if diff.Type == DiffTypeNone &&
+		(fieldsChanged(diff.Fields) || objectsChanged(diff.Objects)) {
+		diff.Type = DiffTypeEdited
+	}
+	for _, task := range diff.Tasks {
+		if diff.Type == DiffTypeNone && task.Type != DiffTypeNone {
+			diff.Type = DiffTypeEdited
+		}
+	}
+
+	sort.Sort(FieldDiffs(diff.Fields))
+	sort.Sort(ObjectDiffs(diff.Objects))
+	return diff, nil
+}
+
+func (tg *TaskGroupDiff) GoString() string {
+	out := fmt.Sprintf("Group %q (%s):\n", tg.Name, tg.Type)
+
+	if len(tg.Updates) != 0 {
+		out += "Updates {\n"
+		for update, count := range tg.Updates {
+			out += fmt.Sprintf("%d %s\n", count, update)
+		}
+		out += "}\n"
+	}
+
+	for _, f := range tg.Fields {
+		out += fmt.Sprintf("%#v\n", f)
+	}
+
+	for _, o := range tg.Objects {
+		out += fmt.Sprintf("%#v\n", o)
+	}
+
+	for _, t := range tg.Tasks {
+		out += fmt.Sprintf("%#v\n", t)
+	}
+
+	return out
+}
+
+// taskGroupDiffs diffs two sets of task groups. If contextual diff is enabled,
+// objects' fields will be stored even if no diff occurred as long as one field
+// changed.
+func taskGroupDiffs(old, new []*TaskGroup, contextual bool) ([]*TaskGroupDiff, error) {
+	oldMap := make(map[string]*TaskGroup, len(old))
+	newMap := make(map[string]*TaskGroup, len(new))
+	for _, o := range old {
+		oldMap[o.Name] = o
+	}
+	for _, n := range new {
+		newMap[n.Name] = n
+	}
+
+	var diffs []*TaskGroupDiff
+	for name, oldGroup := range oldMap {
+		// Diff the same, deleted and edited
+		diff, err := oldGroup.Diff(newMap[name], contextual)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+	}
+
+	for name, newGroup := range newMap {
+		// Diff the added
+		if old, ok := oldMap[name]; !ok {
+			diff, err := old.Diff(newGroup, contextual)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, diff)
+		}
+	}
+
+	sort.Sort(TaskGroupDiffs(diffs))
+	return diffs, nil
+}
+
+// For sorting TaskGroupDiffs
+type TaskGroupDiffs []*TaskGroupDiff
+
+func (tg TaskGroupDiffs) Len() int           { return len(tg) }
+func (tg TaskGroupDiffs) Swap(i, j int)      { tg[i], tg[j] = tg[j], tg[i] }
+func (tg TaskGroupDiffs) Less(i, j int) bool { return tg[i].Name < tg[j].Name }
+
+// TaskDiff contains the diff of two Tasks
+type TaskDiff struct {
+	Type        DiffType
+	Name        string
+	Fields      []*FieldDiff
+	Objects     []*ObjectDiff
+	Annotations []string
+}
+
+// Diff returns a diff of two tasks. If contextual diff is enabled, objects
+// within the task will contain field information even if unchanged.
+func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
+	diff := &TaskDiff{Type: DiffTypeNone}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+	filter := []string{"Name"}
+
+	if t == nil && other == nil {
+		return diff, nil
+	} else if t == nil {
+		t = &Task{}
+		diff.Type = DiffTypeAdded
+		diff.Name = other.Name
+		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+	} else if other == nil {
+		other = &Task{}
+		diff.Type = DiffTypeDeleted
+		diff.Name = t.Name
+		oldPrimitiveFlat = flatmap.Flatten(t, filter, true)
+	} else {
+		if t.Name != other.Name {
+			return nil, fmt.Errorf("can not diff tasks with different names: %q and %q", t.Name, other.Name)
+		}
+
+		diff.Name = other.Name
+		oldPrimitiveFlat = flatmap.Flatten(t, filter, true)
+		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Config diff
+	if cDiff := mapDiff(t.Config, other.Config, "Config", contextual); cDiff != nil {
+		diff.Objects = append(diff.Objects, cDiff)
+	}
+
+	// Env diff
+	if eDiff := mapDiff(t.Env, other.Env, "Env", contextual); eDiff != nil {
+		diff.Objects = append(diff.Objects, eDiff)
+	}
+
+	// Meta diff
+	if mDiff := mapDiff(t.Meta, other.Meta, "Meta", contextual); mDiff != nil {
+		diff.Objects = append(diff.Objects, mDiff)
+	}
+
+	// Constraints diff
+	diff.Objects = append(diff.Objects, constraintsDiff(t.Constraints, other.Constraints, contextual)...)
+
+	// Resources diff
+	if rDiff := t.Resources.Diff(other.Resources, contextual); rDiff != nil {
+		diff.Objects = append(diff.Objects, rDiff)
+	}
+
+	// Dispatch payload diff
+	if dDiff := primitiveObjectDiff(t.DispatchPayload, other.DispatchPayload, nil, "DispatchPayload", contextual); dDiff != nil {
+		diff.Objects = append(diff.Objects, dDiff)
+	}
+
+	// Lifecycle diff
+	if lDiff := primitiveObjectDiff(t.Lifecycle, other.Lifecycle, nil, "Lifecycle", contextual); lDiff != nil {
+		diff.Objects = append(diff.Objects, lDiff)
+	}
+
+	// Log config diff
+	if lDiff := primitiveObjectDiff(t.LogConfig, other.LogConfig, nil, "LogConfig", contextual); lDiff != nil {
+		diff.Objects = append(diff.Objects, lDiff)
+	}
+
+	// Services diff
+	diff.Objects = append(diff.Objects, serviceDiffs(t.Services, other.Services, contextual)...)
+
+	// Artifacts diff
+	diff.Objects = append(diff.Objects, artifactDiffs(t.Artifacts, other.Artifacts, contextual)...)
+
+	// Templates diff
+	diff.Objects = append(diff.Objects, templateDiffs(t.Templates, other.Templates, contextual)...)
+
+	// Volume mounts diff
+	diff.Objects = append(diff.Objects, volumeMountDiffs(t.VolumeMounts, other.VolumeMounts, contextual)...)
+
+	// Determine the type of an update to an existing task
+	if diff.Type == DiffTypeNone &&
+		(fieldsChanged(diff.Fields) || objectsChanged(diff.Objects)) {
+		diff.Type = DiffTypeEdited
+	}
+
+	sort.Sort(FieldDiffs(diff.Fields))
+	sort.Sort(ObjectDiffs(diff.Objects))
+	return diff, nil
+}
+
+func (t *TaskDiff) GoString() string {
+	var out string
+	if len(t.Annotations) == 0 {
+		out = fmt.Sprintf("Task %q (%s):\n", t.Name, t.Type)
+	} else {
+		out = fmt.Sprintf("Task %q (%s) (%s):\n", t.Name, t.Type, strings.Join(t.Annotations, ","))
+	}
+
+	for _, f := range t.Fields {
+		out += fmt.Sprintf("%#v\n", f)
+	}
+
+	for _, o := range t.Objects {
+		out += fmt.Sprintf("%#v\n", o)
+	}
+
+	return out
+}
+
+// taskDiffs diffs a set of tasks. If contextual diff is enabled, unchanged
+// fields within objects nested in the tasks will be returned.
+func taskDiffs(old, new []*Task, contextual bool) ([]*TaskDiff, error) {
+	oldMap := make(map[string]*Task, len(old))
+	newMap := make(map[string]*Task, len(new))
+	for _, o := range old {
+		oldMap[o.Name] = o
+	}
+	for _, n := range new {
+		newMap[n.Name] = n
+	}
+
+	var diffs []*TaskDiff
+	for name, oldTask := range oldMap {
+		// Diff the same, deleted and edited
+		diff, err := oldTask.Diff(newMap[name], contextual)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+	}
+
+	for name, newTask := range newMap {
+		// Diff the added
+		if old, ok := oldMap[name]; !ok {
+			diff, err := old.Diff(newTask, contextual)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, diff)
+		}
+	}
+
+	sort.Sort(TaskDiffs(diffs))
+	return diffs, nil
+}
+
+// For sorting TaskDiffs
+type TaskDiffs []*TaskDiff
+
+func (t TaskDiffs) Len() int           { return len(t) }
+func (t TaskDiffs) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t TaskDiffs) Less(i, j int) bool { return t[i].Name < t[j].Name }
+
+// Diff returns a diff of two resource objects. If contextual diff is enabled,
+// non-changed fields will still be returned.
+func (r *Resources) Diff(other *Resources, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "Resources"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+
+	if reflect.DeepEqual(r, other) {
+		return nil
+	} else if r == nil {
+		r = &Resources{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(other, nil, true)
+	} else if other == nil {
+		other = &Resources{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(r, nil, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(r, nil, true)
+		newPrimitiveFlat = flatmap.Flatten(other, nil, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Network Resources diff
+	diff.Objects = append(diff.Objects, networkResourceDiffs(r.Networks, other.Networks, contextual)...)
+
+	// The resources may be deeply different without a visible change, such
+	// as an empty rather than a nil set of networks.
+	if diff.Type == DiffTypeEdited && !fieldsChanged(diff.Fields) && !objectsChanged(diff.Objects) {
+		return nil
+	}
+
+	sort.Sort(FieldDiffs(diff.Fields))
+	sort.Sort(ObjectDiffs(diff.Objects))
+	return diff
+}
+
+// networkResourceDiffs diffs the networks of two resources by position. If
+// contextual diff is enabled, non-changed fields will still be returned.
+func networkResourceDiffs(old, new []*NetworkResource, contextual bool) []*ObjectDiff {
+	var diffs []*ObjectDiff
+	for i := 0; i < len(old) || i < len(new); i++ {
+		var o, n *NetworkResource
+		if i < len(old) {
+			o = old[i]
+		}
+		if i < len(new) {
+			n = new[i]
+		}
+
+		if diff := o.Diff(n, contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+// Diff returns a diff of two network resources. If contextual diff is
+// enabled, non-changed fields will still be returned.
+func (n *NetworkResource) Diff(other *NetworkResource, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "Network"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+	filter := []string{"Device", "CIDR", "IP"}
+
+	if reflect.DeepEqual(n, other) {
+		return nil
+	} else if n == nil {
+		n = &NetworkResource{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+	} else if other == nil {
+		other = &NetworkResource{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(n, filter, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(n, filter, true)
+		newPrimitiveFlat = flatmap.Flatten(other, filter, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Port diffs
+	oldReserved := make([]string, len(n.ReservedPorts))
+	for i, port := range n.ReservedPorts {
+		oldReserved[i] = strconv.Itoa(port)
+	}
+	newReserved := make([]string, len(other.ReservedPorts))
+	for i, port := range other.ReservedPorts {
+		newReserved[i] = strconv.Itoa(port)
+	}
+	if setDiff := stringSetDiff(oldReserved, newReserved, "ReservedPorts", contextual); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+	if setDiff := stringSetDiff(n.DynamicPorts, other.DynamicPorts, "DynamicPorts", contextual); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+
+	// Ignore differences in fields that aren't diffed, such as the
+	// IP assigned to the network.
+	if diff.Type == DiffTypeEdited && !fieldsChanged(diff.Fields) && !objectsChanged(diff.Objects) {
+		return nil
+	}
+
+	sort.Sort(FieldDiffs(diff.Fields))
+	sort.Sort(ObjectDiffs(diff.Objects))
+	return diff
+}
+
+// artifactDiffs diffs a set of artifacts. Artifacts have no identity so an
+// artifact is either unchanged, added or deleted.
+func artifactDiffs(old, new []*TaskArtifact, contextual bool) []*ObjectDiff {
+	var diffs []*ObjectDiff
+	for _, o := range old {
+		if !containsArtifact(new, o) {
+			diffs = append(diffs, &ObjectDiff{
+				Type:   DiffTypeDeleted,
+				Name:   "Artifact",
+				Fields: fieldDiffs(flatmap.Flatten(o, nil, false), nil, contextual),
+			})
+		}
+	}
+	for _, n := range new {
+		if !containsArtifact(old, n) {
+			diffs = append(diffs, &ObjectDiff{
+				Type:   DiffTypeAdded,
+				Name:   "Artifact",
+				Fields: fieldDiffs(nil, flatmap.Flatten(n, nil, false), contextual),
+			})
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
+// containsArtifact returns whether the set of artifacts contains the artifact
+func containsArtifact(artifacts []*TaskArtifact, artifact *TaskArtifact) bool {
+	for _, a := range artifacts {
+		if reflect.DeepEqual(a, artifact) {
+			return true
+		}
+	}
+	return false
+}
+
+// templateDiffs diffs a set of templates. The templates are matched by their
+// destination.
+func templateDiffs(old, new []*Template, contextual bool) []*ObjectDiff {
+	oldMap := make(map[string]*Template, len(old))
+	newMap := make(map[string]*Template, len(new))
+	for _, o := range old {
+		oldMap[o.DestPath] = o
+	}
+	for _, n := range new {
+		newMap[n.DestPath] = n
+	}
+
+	var diffs []*ObjectDiff
+	for dest, oldTmpl := range oldMap {
+		// Diff the same, deleted and edited
+		if diff := primitiveObjectDiff(oldTmpl, newMap[dest], nil, "Template", contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+	for dest, newTmpl := range newMap {
+		// Diff the added
+		if _, ok := oldMap[dest]; !ok {
+			if diff := primitiveObjectDiff(nil, newTmpl, nil, "Template", contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
+// volumeDiffs diffs the volumes of a task group. The volumes are matched by
+// name.
+func volumeDiffs(old, new map[string]*VolumeRequest, contextual bool) []*ObjectDiff {
+	var diffs []*ObjectDiff
+	for name, oldVolume := range old {
+		// Diff the same, deleted and edited
+		if diff := primitiveObjectDiff(oldVolume, new[name], nil, "Volume", contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+	for name, newVolume := range new {
+		// Diff the added
+		if _, ok := old[name]; !ok {
+			if diff := primitiveObjectDiff(nil, newVolume, nil, "Volume", contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
+// volumeMountDiffs diffs a set of volume mounts. The mounts are matched by
+// their destination.
+func volumeMountDiffs(old, new []*VolumeMount, contextual bool) []*ObjectDiff {
+	oldMap := make(map[string]*VolumeMount, len(old))
+	newMap := make(map[string]*VolumeMount, len(new))
+	for _, o := range old {
+		oldMap[o.Destination] = o
+	}
+	for _, n := range new {
+		newMap[n.Destination] = n
+	}
+
+	var diffs []*ObjectDiff
+	for dest, oldMount := range oldMap {
+		// Diff the same, deleted and edited
+		if diff := primitiveObjectDiff(oldMount, newMap[dest], nil, "VolumeMount", contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+	for dest, newMount := range newMap {
+		// Diff the added
+		if _, ok := oldMap[dest]; !ok {
+			if diff := primitiveObjectDiff(nil, newMount, nil, "VolumeMount", contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
+// serviceDiffs diffs a set of services. The services are matched by name.
+func serviceDiffs(old, new []*Service, contextual bool) []*ObjectDiff {
+	oldMap := make(map[string]*Service, len(old))
+	newMap := make(map[string]*Service, len(new))
+	for _, o := range old {
+		oldMap[o.Name] = o
+	}
+	for _, n := range new {
+		newMap[n.Name] = n
+	}
+
+	var diffs []*ObjectDiff
+	for name, oldService := range oldMap {
+		// Diff the same, deleted and edited
+		if diff := oldService.Diff(newMap[name], contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+	for name, newService := range newMap {
+		// Diff the added
+		if _, ok := oldMap[name]; !ok {
+			if diff := (*Service)(nil).Diff(newService, contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
+// Diff returns a diff of two services. If contextual diff is enabled,
+// non-changed fields will still be returned.
+func (s *Service) Diff(other *Service, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "Service"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+
+	if reflect.DeepEqual(s, other) {
+		return nil
+	} else if s == nil {
+		s = &Service{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(other, nil, true)
+	} else if other == nil {
+		other = &Service{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(s, nil, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(s, nil, true)
+		newPrimitiveFlat = flatmap.Flatten(other, nil, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Tags diff
+	if setDiff := stringSetDiff(s.Tags, other.Tags, "Tags", contextual); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+
+	// Checks diff
+	diff.Objects = append(diff.Objects, serviceCheckDiffs(s.Checks, other.Checks, contextual)...)
+
+	sort.Sort(FieldDiffs(diff.Fields))
+	sort.Sort(ObjectDiffs(diff.Objects))
+	return diff
+}
+
+// serviceCheckDiffs diffs a set of service checks. The checks are matched by
+// name.
+func serviceCheckDiffs(old, new []*ServiceCheck, contextual bool) []*ObjectDiff {
+	oldMap := make(map[string]*ServiceCheck, len(old))
+	newMap := make(map[string]*ServiceCheck, len(new))
+	for _, o := range old {
+		oldMap[o.Name] = o
+	}
+	for _, n := range new {
+		newMap[n.Name] = n
+	}
+
+	var diffs []*ObjectDiff
+	for name, oldCheck := range oldMap {
+		// Diff the same, deleted and edited
+		if diff := primitiveObjectDiff(oldCheck, newMap[name], nil, "Check", contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+	for name, newCheck := range newMap {
+		// Diff the added
+		if _, ok := oldMap[name]; !ok {
+			if diff := primitiveObjectDiff(nil, newCheck, nil, "Check", contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
+// parameterizedJobDiff returns the diff of two parameterized job configs. If
+// contextual diff is enabled, non-changed fields will still be returned.
+func parameterizedJobDiff(old, new *ParameterizedJobConfig, contextual bool) *ObjectDiff {
+	diff := primitiveObjectDiff(old, new, nil, "ParameterizedJob", contextual)
+
+	var oldConfig, newConfig ParameterizedJobConfig
+	if old != nil {
+		oldConfig = *old
+	}
+	if new != nil {
+		newConfig = *new
+	}
+
+	// Diff the meta keys
+	var objects []*ObjectDiff
+	if setDiff := stringSetDiff(oldConfig.MetaRequired, newConfig.MetaRequired, "MetaRequired", contextual); setDiff != nil {
+		objects = append(objects, setDiff)
+	}
+	if setDiff := stringSetDiff(oldConfig.MetaOptional, newConfig.MetaOptional, "MetaOptional", contextual); setDiff != nil {
+		objects = append(objects, setDiff)
+	}
+	if len(objects) == 0 {
+		return diff
+	}
+
+	if diff == nil {
+		diff = &ObjectDiff{Type: DiffTypeEdited, Name: "ParameterizedJob"}
+		if old == nil {
+			diff.Type = DiffTypeAdded
+		} else if new == nil {
+			diff.Type = DiffTypeDeleted
+		}
+	}
+	diff.Objects = append(diff.Objects, objects...)
+	sort.Sort(ObjectDiffs(diff.Objects))
+	return diff
+}
+
+// ObjectDiff contains the diff of two generic objects.
+type ObjectDiff struct {
+	Type    DiffType
+	Name    string
+	Fields  []*FieldDiff
+	Objects []*ObjectDiff
+}
+
+func (o *ObjectDiff) GoString() string {
+	out := fmt.Sprintf("\n%q (%s) {\n", o.Name, o.Type)
+	for _, f := range o.Fields {
+		out += fmt.Sprintf("%#v\n", f)
+	}
+	for _, o := range o.Objects {
+		out += fmt.Sprintf("%#v\n", o)
+	}
+	out += "}"
+	return out
+}
+
+func (o *ObjectDiff) Less(other *ObjectDiff) bool {
+	if reflect.DeepEqual(o, other) {
+		return false
+	} else if other == nil {
+		return false
+	} else if o == nil {
+		return true
+	}
+
+	if o.Name != other.Name {
+		return o.Name < other.Name
+	}
+
+	if o.Type != other.Type {
+		return o.Type < other.Type
+	}
+
+	if lO, lOther := len(o.Fields), len(other.Fields); lO != lOther {
+		return lO < lOther
+	}
+
+	if lO, lOther := len(o.Objects), len(other.Objects); lO != lOther {
+		return lO < lOther
+	}
+
+	// Check each field
+	sort.Sort(FieldDiffs(o.Fields))
+	sort.Sort(FieldDiffs(other.Fields))
+
+	for i, oV := range o.Fields {
+		if oV.Less(other.Fields[i]) {
+			return true
+		}
+	}
+
+	// Check each object
+	sort.Sort(ObjectDiffs(o.Objects))
+	sort.Sort(ObjectDiffs(other.Objects))
+	for i, oV := range o.Objects {
+		if oV.Less(other.Objects[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// For sorting ObjectDiffs
+type ObjectDiffs []*ObjectDiff
+
+func (o ObjectDiffs) Len() int           { return len(o) }
+func (o ObjectDiffs) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+func (o ObjectDiffs) Less(i, j int) bool { return o[i].Less(o[j]) }
+
+// FieldDiff contains the diff of a single field.
+type FieldDiff struct {
+	Type        DiffType
+	Name        string
+	Old, New    string
+	Annotations []string
+}
+
+// fieldDiff returns a FieldDiff if old and new are different otherwise, it
+// returns nil. If contextual diff is enabled, even non-changed fields will be
+// returned.
+func fieldDiff(old, new, name string, contextual bool) *FieldDiff {
+	diff := &FieldDiff{Name: name, Type: DiffTypeNone}
+	if old == new {
+		if !contextual {
+			return nil
+		}
+		diff.Old, diff.New = old, new
+		return diff
+	}
+
+	if old == "" {
+		diff.Type = DiffTypeAdded
+		diff.New = new
+	} else if new == "" {
+		diff.Type = DiffTypeDeleted
+		diff.Old = old
+	} else {
+		diff.Type = DiffTypeEdited
+		diff.Old = old
+		diff.New = new
+	}
+	return diff
+}
+
+func (f *FieldDiff) GoString() string {
+	out := fmt.Sprintf("%q (%s): %q => %q", f.Name, f.Type, f.Old, f.New)
+	if len(f.Annotations) != 0 {
+		out += fmt.Sprintf(" (%s)", strings.Join(f.Annotations, ", "))
+	}
+
+	return out
+}
+
+func (f *FieldDiff) Less(other *FieldDiff) bool {
+	if f.Name != other.Name {
+		return f.Name < other.Name
+	}
+	if f.Old != other.Old {
+		return f.Old < other.Old
+	}
+	return f.New < other.New
+}
+
+// For sorting FieldDiffs
+type FieldDiffs []*FieldDiff
+
+func (f FieldDiffs) Len() int           { return len(f) }
+func (f FieldDiffs) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f FieldDiffs) Less(i, j int) bool { return f[i].Less(f[j]) }
+
+// fieldDiffs takes a map of field names to their values and returns a set of
+// field diffs. If contextual diff is enabled, even non-changed fields will be
+// returned.
+func fieldDiffs(old, new map[string]string, contextual bool) []*FieldDiff {
+	var diffs []*FieldDiff
+	visited := make(map[string]struct{})
+	for k, oldV := range old {
+		visited[k] = struct{}{}
+		newV := new[k]
+		if diff := fieldDiff(oldV, newV, k, contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	for k, newV := range new {
+		if _, ok := visited[k]; !ok {
+			if diff := fieldDiff("", newV, k, contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(FieldDiffs(diffs))
+	return diffs
+}
+
+// primitiveObjectDiff returns a diff of the passed objects' primitive fields.
+// The filter field can be used to exclude fields from the diff. The name is
+// the name of the objects. If contextual is set, non-changed fields will also
+// be stored in the object diff.
+func primitiveObjectDiff(old, new interface{}, filter []string,
+	name string, contextual bool) *ObjectDiff {
+	oldPrimitiveFlat := flatmap.Flatten(old, filter, true)
+	newPrimitiveFlat := flatmap.Flatten(new, filter, true)
+	delete(oldPrimitiveFlat, "")
+	delete(newPrimitiveFlat, "")
+
+	diff := &ObjectDiff{Name: name}
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	var added, deleted, edited bool
+	for _, f := range diff.Fields {
+		switch f.Type {
+		case DiffTypeEdited:
+			edited = true
+		case DiffTypeDeleted:
+			deleted = true
+		case DiffTypeAdded:
+			added = true
+		}
+	}
+
+	if edited || added && deleted {
+		diff.Type = DiffTypeEdited
+	} else if added {
+		diff.Type = DiffTypeAdded
+	} else if deleted {
+		diff.Type = DiffTypeDeleted
+	} else {
+		return nil
+	}
+
+	return diff
+}
+
+// mapDiff returns the diff of two maps. If contextual is set, non-changed
+// keys will also be stored in the object diff.
+func mapDiff(old, new map[string]string, name string, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: name}
+	diff.Fields = fieldDiffs(old, new, contextual)
+	if !fieldsChanged(diff.Fields) {
+		return nil
+	}
+
+	if len(old) == 0 {
+		diff.Type = DiffTypeAdded
+	} else if len(new) == 0 {
+		diff.Type = DiffTypeDeleted
+	} else {
+		diff.Type = DiffTypeEdited
+	}
+	return diff
+}
+
+// stringSetDiff diffs two sets of strings with the given name. If contextual
+// is set, non-changed values will also be stored in the object diff.
+func stringSetDiff(old, new []string, name string, contextual bool) *ObjectDiff {
+	oldMap := make(map[string]struct{}, len(old))
+	newMap := make(map[string]struct{}, len(new))
+	for _, o := range old {
+		oldMap[o] = struct{}{}
+	}
+	for _, n := range new {
+		newMap[n] = struct{}{}
+	}
+	if reflect.DeepEqual(oldMap, newMap) && !contextual {
+		return nil
+	}
+
+	diff := &ObjectDiff{Name: name}
+	var added, removed bool
+	for k := range oldMap {
+		if _, ok := newMap[k]; !ok {
+			diff.Fields = append(diff.Fields, fieldDiff(k, "", name, contextual))
+			removed = true
+		} else if contextual {
+			diff.Fields = append(diff.Fields, fieldDiff(k, k, name, contextual))
+		}
+	}
+
+	for k := range newMap {
+		if _, ok := oldMap[k]; !ok {
+			diff.Fields = append(diff.Fields, fieldDiff("", k, name, contextual))
+			added = true
+		}
+	}
+
+	sort.Sort(FieldDiffs(diff.Fields))
+
+	// Determine the type
+	if added && removed {
+		diff.Type = DiffTypeEdited
+	} else if added {
+		diff.Type = DiffTypeAdded
+	} else if removed {
+		diff.Type = DiffTypeDeleted
+	} else {
+		// Diff of an empty set
+		if len(diff.Fields) == 0 {
+			return nil
+		}
+
+		diff.Type = DiffTypeNone
+	}
+
+	return diff
+}
+
+// constraintsDiff returns the diff of two sets of constraints. Constraints
+// are treated as a set, so a constraint is either added or deleted.
+func constraintsDiff(old, new []*Constraint, contextual bool) []*ObjectDiff {
+	oldMap := make(map[string]*Constraint, len(old))
+	newMap := make(map[string]*Constraint, len(new))
+	for _, o := range old {
+		oldMap[o.String()] = o
+	}
+	for _, n := range new {
+		newMap[n.String()] = n
+	}
+
+	var diffs []*ObjectDiff
+	for k, oldC := range oldMap {
+		if _, ok := newMap[k]; !ok {
+			diffs = append(diffs, primitiveObjectDiff(oldC, nil, nil, "Constraint", contextual))
+		}
+	}
+	for k, newC := range newMap {
+		if _, ok := oldMap[k]; !ok {
+			diffs = append(diffs, primitiveObjectDiff(nil, newC, nil, "Constraint", contextual))
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
+// spreadsDiff returns the diff of two sets of spreads. Spreads are treated as
+// a set, so a spread is either added or deleted along with its targets.
+func spreadsDiff(old, new []*Spread, contextual bool) []*ObjectDiff {
+	oldMap := make(map[string]*Spread, len(old))
+	newMap := make(map[string]*Spread, len(new))
+	for _, o := range old {
+		oldMap[o.String()] = o
+	}
+	for _, n := range new {
+		newMap[n.String()] = n
+	}
+
+	var diffs []*ObjectDiff
+	for k, oldS := range oldMap {
+		if _, ok := newMap[k]; !ok {
+			diff := primitiveObjectDiff(oldS, nil, nil, "Spread", contextual)
+			for _, t := range oldS.Targets {
+				diff.Objects = append(diff.Objects, primitiveObjectDiff(t, nil, nil, "SpreadTarget", contextual))
+			}
+			diffs = append(diffs, diff)
+		}
+	}
+	for k, newS := range newMap {
+		if _, ok := oldMap[k]; !ok {
+			diff := primitiveObjectDiff(nil, newS, nil, "Spread", contextual)
+			for _, t := range newS.Targets {
+				diff.Objects = append(diff.Objects, primitiveObjectDiff(nil, t, nil, "SpreadTarget", contextual))
+			}
+			diffs = append(diffs, diff)
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
+// fieldsChanged returns whether any of the field diffs is a change.
+func fieldsChanged(diffs []*FieldDiff) bool {
+	for _, d := range diffs {
+		if d.Type != DiffTypeNone {
+			return true
+		}
+	}
+	return false
+}
+
+// objectsChanged returns whether any of the object diffs is a change.
+func objectsChanged(diffs []*ObjectDiff) bool {
+	for _, d := range diffs {
+		if d.Type != DiffTypeNone {
+			return true
+		}
+	}
+	return false
+}
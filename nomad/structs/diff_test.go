@@ -0,0 +1,698 @@
+package structs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJobDiff(t *testing.T) {
+	cases := []struct {
+		Old, New   *Job
+		Expected   *JobDiff
+		Error      bool
+		Contextual bool
+	}{
+		{
+			Old: nil,
+			New: nil,
+			Expected: &JobDiff{
+				Type: DiffTypeNone,
+			},
+		},
+		{
+			// Different IDs
+			Old: &Job{
+				ID: "foo",
+			},
+			New: &Job{
+				ID: "bar",
+			},
+			Error: true,
+		},
+		{
+			// Primitive only that is the same
+			Old: &Job{
+				Region:    "foo",
+				ID:        "foo",
+				Name:      "foo",
+				Type:      "batch",
+				Priority:  10,
+				AllAtOnce: true,
+			},
+			New: &Job{
+				Region:    "foo",
+				ID:        "foo",
+				Name:      "foo",
+				Type:      "batch",
+				Priority:  10,
+				AllAtOnce: true,
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeNone,
+				ID:   "foo",
+			},
+		},
+		{
+			// Primitive only that has diffs
+			Old: &Job{
+				Region:   "foo",
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "batch",
+				Priority: 10,
+			},
+			New: &Job{
+				Region:   "bar",
+				ID:       "foo",
+				Name:     "bar",
+				Type:     "batch",
+				Priority: 20,
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				ID:   "foo",
+				Fields: []*FieldDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Name",
+						Old:  "foo",
+						New:  "bar",
+					},
+					{
+						Type: DiffTypeEdited,
+						Name: "Priority",
+						Old:  "10",
+						New:  "20",
+					},
+					{
+						Type: DiffTypeEdited,
+						Name: "Region",
+						Old:  "foo",
+						New:  "bar",
+					},
+				},
+			},
+		},
+		{
+			// Filtered fields are ignored
+			Old: &Job{
+				ID:          "foo",
+				Status:      JobStatusPending,
+				Version:     1,
+				CreateIndex: 10,
+				ModifyIndex: 11,
+			},
+			New: &Job{
+				ID:          "foo",
+				Status:      JobStatusRunning,
+				Version:     2,
+				Stable:      true,
+				CreateIndex: 20,
+				ModifyIndex: 21,
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeNone,
+				ID:   "foo",
+			},
+		},
+		{
+			// Deleted job
+			Old: &Job{
+				ID:       "foo",
+				Name:     "foo",
+				Priority: 10,
+			},
+			New: nil,
+			Expected: &JobDiff{
+				Type: DiffTypeDeleted,
+				ID:   "foo",
+				Fields: []*FieldDiff{
+					{
+						Type: DiffTypeDeleted,
+						Name: "AllAtOnce",
+						Old:  "false",
+					},
+					{
+						Type: DiffTypeDeleted,
+						Name: "Name",
+						Old:  "foo",
+					},
+					{
+						Type: DiffTypeDeleted,
+						Name: "Priority",
+						Old:  "10",
+					},
+				},
+			},
+		},
+		{
+			// Datacenters and meta diff
+			Old: &Job{
+				ID:          "foo",
+				Datacenters: []string{"dc1", "dc2"},
+				Meta: map[string]string{
+					"foo": "bar",
+				},
+			},
+			New: &Job{
+				ID:          "foo",
+				Datacenters: []string{"dc2", "dc3"},
+				Meta: map[string]string{
+					"foo": "baz",
+				},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				ID:   "foo",
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Datacenters",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Datacenters",
+								New:  "dc3",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Datacenters",
+								Old:  "dc1",
+							},
+						},
+					},
+					{
+						Type: DiffTypeEdited,
+						Name: "Meta",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "foo",
+								Old:  "bar",
+								New:  "baz",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			// Update strategy edited
+			Old: &Job{
+				ID: "foo",
+				Update: UpdateStrategy{
+					Stagger:     10 * time.Second,
+					MaxParallel: 1,
+				},
+			},
+			New: &Job{
+				ID: "foo",
+				Update: UpdateStrategy{
+					Stagger:     30 * time.Second,
+					MaxParallel: 1,
+				},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				ID:   "foo",
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Update",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Stagger",
+								Old:  "10s",
+								New:  "30s",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			// Constraints are diffed as a set
+			Old: &Job{
+				ID: "foo",
+				Constraints: []*Constraint{
+					{
+						LTarget: "foo",
+						RTarget: "foo",
+						Operand: "=",
+					},
+				},
+			},
+			New: &Job{
+				ID: "foo",
+				Constraints: []*Constraint{
+					{
+						LTarget: "bar",
+						RTarget: "bar",
+						Operand: "=",
+					},
+				},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				ID:   "foo",
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeAdded,
+						Name: "Constraint",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "LTarget",
+								New:  "bar",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Operand",
+								New:  "=",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "RTarget",
+								New:  "bar",
+							},
+						},
+					},
+					{
+						Type: DiffTypeDeleted,
+						Name: "Constraint",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "LTarget",
+								Old:  "foo",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Operand",
+								Old:  "=",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "RTarget",
+								Old:  "foo",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		actual, err := c.Old.Diff(c.New, c.Contextual)
+		if c.Error && err == nil {
+			t.Fatalf("case %d: expected errored", i+1)
+		} else if err != nil {
+			if !c.Error {
+				t.Fatalf("case %d: errored %#v", i+1, err)
+			} else {
+				continue
+			}
+		}
+
+		if !reflect.DeepEqual(actual, c.Expected) {
+			t.Fatalf("case %d: got:\n%#v\n want:\n%#v\n",
+				i+1, actual, c.Expected)
+		}
+	}
+}
+
+func TestTaskGroupDiff(t *testing.T) {
+	cases := []struct {
+		Old, New   *TaskGroup
+		Expected   *TaskGroupDiff
+		Error      bool
+		Contextual bool
+	}{
+		{
+			// Different names
+			Old: &TaskGroup{
+				Name: "foo",
+			},
+			New: &TaskGroup{
+				Name: "bar",
+			},
+			Error: true,
+		},
+		{
+			// Count edited, tasks added, deleted and edited
+			Old: &TaskGroup{
+				Name:  "foo",
+				Count: 1,
+				Tasks: []*Task{
+					{
+						Name:   "foo",
+						Driver: "docker",
+					},
+					{
+						Name:   "bar",
+						Driver: "docker",
+					},
+				},
+			},
+			New: &TaskGroup{
+				Name:  "foo",
+				Count: 2,
+				Tasks: []*Task{
+					{
+						Name:   "bar",
+						Driver: "exec",
+					},
+					{
+						Name:   "baz",
+						Driver: "docker",
+					},
+				},
+			},
+			Expected: &TaskGroupDiff{
+				Type: DiffTypeEdited,
+				Name: "foo",
+				Fields: []*FieldDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Count",
+						Old:  "1",
+						New:  "2",
+					},
+				},
+				Tasks: []*TaskDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "bar",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Driver",
+								Old:  "docker",
+								New:  "exec",
+							},
+						},
+					},
+					{
+						Type: DiffTypeAdded,
+						Name: "baz",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Driver",
+								New:  "docker",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "KillTimeout",
+								New:  "0s",
+							},
+						},
+					},
+					{
+						Type: DiffTypeDeleted,
+						Name: "foo",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "Driver",
+								Old:  "docker",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "KillTimeout",
+								Old:  "0s",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		actual, err := c.Old.Diff(c.New, c.Contextual)
+		if c.Error && err == nil {
+			t.Fatalf("case %d: expected errored", i+1)
+		} else if err != nil {
+			if !c.Error {
+				t.Fatalf("case %d: errored %#v", i+1, err)
+			} else {
+				continue
+			}
+		}
+
+		if !reflect.DeepEqual(actual, c.Expected) {
+			t.Fatalf("case %d: got:\n%#v\n want:\n%#v\n",
+				i+1, actual, c.Expected)
+		}
+	}
+}
+
+func TestTaskDiff_Resources(t *testing.T) {
+	old := &Task{
+		Name: "foo",
+		Resources: &Resources{
+			CPU:      100,
+			MemoryMB: 100,
+			Networks: []*NetworkResource{
+				{
+					MBits:        10,
+					DynamicPorts: []string{"http"},
+				},
+			},
+		},
+	}
+	new := &Task{
+		Name: "foo",
+		Resources: &Resources{
+			CPU:      200,
+			MemoryMB: 100,
+			Networks: []*NetworkResource{
+				{
+					MBits:        10,
+					DynamicPorts: []string{"http", "admin"},
+				},
+			},
+		},
+	}
+
+	expected := &TaskDiff{
+		Type: DiffTypeEdited,
+		Name: "foo",
+		Objects: []*ObjectDiff{
+			{
+				Type: DiffTypeEdited,
+				Name: "Resources",
+				Fields: []*FieldDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "CPU",
+						Old:  "100",
+						New:  "200",
+					},
+				},
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Network",
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "DynamicPorts",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "DynamicPorts",
+										New:  "admin",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	actual, err := old.Diff(new, false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("got:\n%#v\n want:\n%#v\n", actual, expected)
+	}
+}
+
+func TestTaskDiff_Services(t *testing.T) {
+	old := &Task{
+		Name: "foo",
+		Services: []*Service{
+			{
+				Name:      "web",
+				Tags:      []string{"a"},
+				PortLabel: "http",
+				Checks: []*ServiceCheck{
+					{
+						Name:     "alive",
+						Type:     ServiceCheckTCP,
+						Interval: 10 * time.Second,
+					},
+				},
+			},
+		},
+	}
+	new := &Task{
+		Name: "foo",
+		Services: []*Service{
+			{
+				Name:      "web",
+				Tags:      []string{"a", "b"},
+				PortLabel: "http",
+				Checks: []*ServiceCheck{
+					{
+						Name:     "alive",
+						Type:     ServiceCheckTCP,
+						Interval: 5 * time.Second,
+					},
+				},
+			},
+			{
+				Name: "admin",
+			},
+		},
+	}
+
+	expected := &TaskDiff{
+		Type: DiffTypeEdited,
+		Name: "foo",
+		Objects: []*ObjectDiff{
+			{
+				Type: DiffTypeAdded,
+				Name: "Service",
+				Fields: []*FieldDiff{
+					{
+						Type: DiffTypeAdded,
+						Name: "Name",
+						New:  "admin",
+					},
+				},
+			},
+			{
+				Type: DiffTypeEdited,
+				Name: "Service",
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Check",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Interval",
+								Old:  "10s",
+								New:  "5s",
+							},
+						},
+					},
+					{
+						Type: DiffTypeAdded,
+						Name: "Tags",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Tags",
+								New:  "b",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	actual, err := old.Diff(new, false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("got:\n%#v\n want:\n%#v\n", actual, expected)
+	}
+}
+
+func TestTaskDiff_Templates(t *testing.T) {
+	old := &Task{
+		Name: "foo",
+		Templates: []*Template{
+			{
+				EmbeddedTmpl: "foo",
+				DestPath:     "local/foo",
+				ChangeMode:   TemplateChangeModeRestart,
+			},
+		},
+	}
+	new := &Task{
+		Name: "foo",
+		Templates: []*Template{
+			{
+				EmbeddedTmpl: "bar",
+				DestPath:     "local/foo",
+				ChangeMode:   TemplateChangeModeRestart,
+			},
+			{
+				SourcePath: "local/bar.tpl",
+				DestPath:   "local/bar",
+				ChangeMode: TemplateChangeModeNoop,
+			},
+		},
+	}
+
+	expected := &TaskDiff{
+		Type: DiffTypeEdited,
+		Name: "foo",
+		Objects: []*ObjectDiff{
+			{
+				Type: DiffTypeAdded,
+				Name: "Template",
+				Fields: []*FieldDiff{
+					{
+						Type: DiffTypeAdded,
+						Name: "ChangeMode",
+						New:  TemplateChangeModeNoop,
+					},
+					{
+						Type: DiffTypeAdded,
+						Name: "DestPath",
+						New:  "local/bar",
+					},
+					{
+						Type: DiffTypeAdded,
+						Name: "SourcePath",
+						New:  "local/bar.tpl",
+					},
+				},
+			},
+			{
+				Type: DiffTypeEdited,
+				Name: "Template",
+				Fields: []*FieldDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "EmbeddedTmpl",
+						Old:  "foo",
+						New:  "bar",
+					},
+				},
+			},
+		},
+	}
+
+	actual, err := old.Diff(new, false)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("got:\n%#v\n want:\n%#v\n", actual, expected)
+	}
+}
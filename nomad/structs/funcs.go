@@ -137,3 +137,53 @@ func GenerateUUID() string {
 		buf[8:10],
 		buf[10:16])
 }
+
+// copyStringMap returns a copy of the given map
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// copyStringSlice returns a copy of the given slice
+func copyStringSlice(s []string) []string {
+	if s == nil {
+		return nil
+	}
+
+	c := make([]string, len(s))
+	copy(c, s)
+	return c
+}
+
+// copySpreads returns a deep copy of the given spreads
+func copySpreads(spreads []*Spread) []*Spread {
+	if spreads == nil {
+		return nil
+	}
+
+	c := make([]*Spread, len(spreads))
+	for i, spread := range spreads {
+		c[i] = spread.Copy()
+	}
+	return c
+}
+
+// copyConstraints returns a deep copy of the given constraints
+func copyConstraints(constraints []*Constraint) []*Constraint {
+	if constraints == nil {
+		return nil
+	}
+
+	c := make([]*Constraint, len(constraints))
+	for i, constraint := range constraints {
+		c[i] = constraint.Copy()
+	}
+	return c
+}
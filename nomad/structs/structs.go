@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/gorhill/cronexpr"
 	"github.com/hashicorp/go-msgpack/codec"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-version"
@@ -40,6 +42,11 @@ const (
 	EvalDeleteRequestType
 	AllocUpdateRequestType
 	AllocClientUpdateRequestType
+	ApplyPlanResultsRequestType
+	DeploymentStatusUpdateRequestType
+	DeploymentPromoteRequestType
+	AllocUpdateDesiredTransitionRequestType
+	NodeUpdateEligibilityRequestType
 )
 
 const (
@@ -155,6 +162,19 @@ type NodeUpdateStatusRequest struct {
 type NodeUpdateDrainRequest struct {
 	NodeID string
 	Drain  bool
+
+	// DrainStrategy is how the node is drained. Only its spec is set by
+	// the caller and the server starts the deadline. It is cleared once the
+	// drain completes.
+	DrainStrategy *DrainStrategy
+	WriteRequest
+}
+
+// NodeUpdateEligibilityRequest is used for updating the scheduling
+// eligibility of a node
+type NodeUpdateEligibilityRequest struct {
+	NodeID      string
+	Eligibility string
 	WriteRequest
 }
 
@@ -174,6 +194,13 @@ type NodeSpecificRequest struct {
 // to register a job as being a schedulable entity.
 type JobRegisterRequest struct {
 	Job *Job
+
+	// If EnforceIndex is set then the job will only be registered if the passed
+	// JobModifyIndex matches the current Jobs index. If the index is zero, the
+	// register only occurs if the job is new.
+	EnforceIndex   bool
+	JobModifyIndex uint64
+
 	WriteRequest
 }
 
@@ -190,6 +217,25 @@ type JobEvaluateRequest struct {
 	WriteRequest
 }
 
+// JobPlanRequest is used for the Job.Plan endpoint to trigger a dry-run
+// evaluation of the Job.
+type JobPlanRequest struct {
+	Job  *Job
+	Diff bool // Toggles an annotated diff
+	WriteRequest
+}
+
+// JobRevertRequest is used to revert a job to a prior version.
+type JobRevertRequest struct {
+	// JobID is the ID of the job being reverted
+	JobID string
+
+	// JobVersion the version to revert to.
+	JobVersion uint64
+
+	WriteRequest
+}
+
 // JobSpecificRequest is used when we just need to specify a target job
 type JobSpecificRequest struct {
 	JobID string
@@ -260,6 +306,34 @@ type AllocUpdateRequest struct {
 	WriteRequest
 }
 
+// AllocUpdateDesiredTransitionRequest is used to update the transitions
+// desired of a set of allocations along with the evaluations that act on them
+type AllocUpdateDesiredTransitionRequest struct {
+	// Allocs maps the IDs of the allocations to their desired transition
+	Allocs map[string]*DesiredTransition
+
+	// Evals are the evaluations to create
+	Evals []*Evaluation
+	WriteRequest
+}
+
+// ApplyPlanResultsRequest is used by the planner to apply a Raft transaction
+// committing the result of a plan.
+type ApplyPlanResultsRequest struct {
+	// AllocUpdateRequest holds the allocation updates to be made by the
+	// scheduler.
+	AllocUpdateRequest
+
+	// Deployment is the deployment created or updated as a result of a
+	// scheduling event.
+	Deployment *Deployment
+
+	// DeploymentUpdates is a set of status updates to apply to the given
+	// deployments. This allows the scheduler to cancel any unneeded deployment
+	// because the job is stopped or the update block is removed.
+	DeploymentUpdates []*DeploymentStatusUpdate
+}
+
 // AllocListRequest is used to request a list of allocations
 type AllocListRequest struct {
 	QueryOptions
@@ -271,6 +345,20 @@ type AllocSpecificRequest struct {
 	QueryOptions
 }
 
+// PeriodicForceRequest is used to force a specific periodic job.
+type PeriodicForceRequest struct {
+	JobID string
+	WriteRequest
+}
+
+// JobDispatchRequest is used to dispatch a job based on a parameterized job
+type JobDispatchRequest struct {
+	JobID   string
+	Payload []byte
+	Meta    map[string]string
+	WriteRequest
+}
+
 // GenericRequest is used to request where no
 // specific information is needed.
 type GenericRequest struct {
@@ -312,6 +400,74 @@ type JobDeregisterResponse struct {
 	QueryMeta
 }
 
+// PeriodicForceResponse is used to respond to a periodic job force launch
+type PeriodicForceResponse struct {
+	EvalID          string
+	EvalCreateIndex uint64
+	WriteMeta
+}
+
+// DeploymentListRequest is used to list the deployments
+type DeploymentListRequest struct {
+	QueryOptions
+}
+
+// DeploymentSpecificRequest is used to make a request specific to a particular
+// deployment
+type DeploymentSpecificRequest struct {
+	DeploymentID string
+	QueryOptions
+}
+
+// DeploymentPromoteRequest is used to promote the canaries of a deployment.
+type DeploymentPromoteRequest struct {
+	DeploymentID string
+
+	// All is to promote all task groups
+	All bool
+
+	// Groups is used to set the promotion status per task group
+	Groups []string
+
+	WriteRequest
+}
+
+// ApplyDeploymentPromoteRequest is used to promote the canaries of a
+// deployment and create an evaluation to continue the rollout, atomically.
+type ApplyDeploymentPromoteRequest struct {
+	DeploymentPromoteRequest
+
+	// An optional evaluation to create after promoting the canaries
+	Eval *Evaluation
+}
+
+// DeploymentFailRequest is used to fail a particular deployment
+type DeploymentFailRequest struct {
+	DeploymentID string
+	WriteRequest
+}
+
+// DeploymentStatusUpdateRequest is used to update the status of a deployment
+// and create an evaluation, atomically.
+type DeploymentStatusUpdateRequest struct {
+	// Eval, if set, is used to create an evaluation at the same time as
+	// updating the status of a deployment.
+	Eval *Evaluation
+
+	// DeploymentUpdate is a status update to apply to the given
+	// deployment.
+	DeploymentUpdate *DeploymentStatusUpdate
+}
+
+// JobDispatchResponse is used to respond to a job dispatch
+type JobDispatchResponse struct {
+	DispatchedJobID string
+	EvalID          string
+	EvalCreateIndex uint64
+	JobCreateIndex  uint64
+	WriteMeta
+}
+
 // NodeUpdateResponse is used to respond to a node update
 type NodeUpdateResponse struct {
 	HeartbeatTTL    time.Duration
@@ -329,6 +485,15 @@ type NodeDrainUpdateResponse struct {
 	QueryMeta
 }
 
+// NodeEligibilityUpdateResponse is used to respond to a node eligibility
+// update
+type NodeEligibilityUpdateResponse struct {
+	EvalIDs         []string
+	EvalCreateIndex uint64
+	NodeModifyIndex uint64
+	QueryMeta
+}
+
 // NodeAllocsResponse is used to return allocs for a single node
 type NodeAllocsResponse struct {
 	Allocs []*Allocation
@@ -353,6 +518,64 @@ type SingleJobResponse struct {
 	QueryMeta
 }
 
+// JobPlanResponse is used to respond to a job plan request
+type JobPlanResponse struct {
+	// Annotations stores annotations explaining decisions the scheduler made.
+	Annotations *PlanAnnotations
+
+	// FailedAllocs is the set of allocations that could not be placed,
+	// coalesced per task group. The metrics of each explain why the
+	// placement failed.
+	FailedAllocs []*Allocation
+
+	// JobModifyIndex is the modification index of the job. The value can be
+	// used when running `nomad run` to ensure that the Job wasn't modified
+	// since the last plan. If the job is being created, the value is zero.
+	JobModifyIndex uint64
+
+	// CreatedEvals is the set of evaluations created by the scheduler. The
+	// reasons for this can be rolling-updates or blocked evals.
+	CreatedEvals []*Evaluation
+
+	// Diff contains the diff of the job and annotations on whether the change
+	// causes an in-place update or create/destroy
+	Diff *JobDiff
+
+	// NextPeriodicLaunch is the time duration till the job would be launched if
+	// submitted.
+	NextPeriodicLaunch time.Time
+
+	WriteMeta
+}
+
+// SingleDeploymentResponse is used to respond with a single deployment
+type SingleDeploymentResponse struct {
+	Deployment *Deployment
+	QueryMeta
+}
+
+// DeploymentListResponse is used for a list request
+type DeploymentListResponse struct {
+	Deployments []*Deployment
+	QueryMeta
+}
+
+// DeploymentUpdateResponse is used to respond to a deployment change. The
+// response will include the modify index of the deployment as well as details
+// of any triggered evaluation.
+type DeploymentUpdateResponse struct {
+	EvalID                string
+	EvalCreateIndex       uint64
+	DeploymentModifyIndex uint64
+	WriteMeta
+}
+
+// JobVersionsResponse is used for a job get versions request
+type JobVersionsResponse struct {
+	Versions []*Job
+	QueryMeta
+}
+
 // JobListResponse is used for a list request
 type JobListResponse struct {
 	Jobs []*JobListStub
@@ -433,6 +656,13 @@ func ShouldDrainNode(status string) bool {
 	}
 }
 
+const (
+	// NodeSchedulingEligible and NodeSchedulingIneligible mark whether new
+	// allocations may be placed on a node
+	NodeSchedulingEligible   = "eligible"
+	NodeSchedulingIneligible = "ineligible"
+)
+
 // ValidNodeStatus is used to check if a node status is valid
 func ValidNodeStatus(status string) bool {
 	switch status {
@@ -456,6 +686,11 @@ type Node struct {
 	// Node name
 	Name string
 
+	// HTTPAddr is the address the client's HTTP API is reachable at. It is
+	// used to forward requests that are served by the client, such as reading
+	// the logs of a task.
+	HTTPAddr string
+
 	// Attributes is an arbitrary set of key/value
 	// data that can be used for constraints. Examples
 	// include "kernel.name=linux", "arch=386", "driver.docker=1",
@@ -486,11 +721,24 @@ type Node struct {
 	// together for the purpose of determining scheduling pressure.
 	NodeClass string
 
+	// HostVolumes are the host paths the client offers to be mounted into
+	// tasks, keyed by their name
+	HostVolumes map[string]*ClientHostVolumeConfig
+
 	// Drain is controlled by the servers, and not the client.
 	// If true, no jobs will be scheduled to this node, and existing
 	// allocations will be drained.
 	Drain bool
 
+	// DrainStrategy is how the allocations of the node are drained. It is
+	// only set while the drain is in progress.
+	DrainStrategy *DrainStrategy
+
+	// SchedulingEligibility is controlled by the servers, and not the
+	// client. Ineligible nodes receive no new allocations but keep running
+	// their existing ones.
+	SchedulingEligibility string
+
 	// Status of this node
 	Status string
 
@@ -516,30 +764,103 @@ func (n *Node) TerminalStatus() bool {
 // Stub returns a summarized version of the node
 func (n *Node) Stub() *NodeListStub {
 	return &NodeListStub{
-		ID:                n.ID,
-		Datacenter:        n.Datacenter,
-		Name:              n.Name,
-		NodeClass:         n.NodeClass,
-		Drain:             n.Drain,
-		Status:            n.Status,
-		StatusDescription: n.StatusDescription,
-		CreateIndex:       n.CreateIndex,
-		ModifyIndex:       n.ModifyIndex,
+		ID:                    n.ID,
+		Datacenter:            n.Datacenter,
+		Name:                  n.Name,
+		NodeClass:             n.NodeClass,
+		Drain:                 n.Drain,
+		SchedulingEligibility: n.SchedulingEligibility,
+		Status:                n.Status,
+		StatusDescription:     n.StatusDescription,
+		CreateIndex:           n.CreateIndex,
+		ModifyIndex:           n.ModifyIndex,
+	}
+}
+
+// DrainSpec is the drain requested by an operator
+type DrainSpec struct {
+	// Deadline is the time after which the remaining allocations of the node
+	// are stopped regardless of their migrate strategy. A zero deadline
+	// waits for the allocations to migrate and a negative deadline stops
+	// them immediately.
+	Deadline time.Duration
+
+	// IgnoreSystemJobs leaves the allocations of system jobs running on the
+	// node
+	IgnoreSystemJobs bool
+}
+
+// DrainStrategy is the drain of a node that is in progress
+type DrainStrategy struct {
+	DrainSpec
+
+	// ForceDeadline is the time the remaining allocations are stopped. It is
+	// zero if the drain has no deadline.
+	ForceDeadline time.Time
+}
+
+// NewDrainStrategy returns the strategy of a drain of the spec that starts
+// at the passed time
+func NewDrainStrategy(spec DrainSpec, now time.Time) *DrainStrategy {
+	d := &DrainStrategy{DrainSpec: spec}
+	switch {
+	case spec.Deadline < 0:
+		d.ForceDeadline = now
+	case spec.Deadline > 0:
+		d.ForceDeadline = now.Add(spec.Deadline)
+	}
+	return d
+}
+
+// DeadlineTime returns whether the drain has a deadline and the time of it
+func (d *DrainStrategy) DeadlineTime() (bool, time.Time) {
+	if d == nil || d.ForceDeadline.IsZero() {
+		return false, time.Time{}
 	}
+	return true, d.ForceDeadline
+}
+
+// Copy returns a copy of the drain strategy
+func (d *DrainStrategy) Copy() *DrainStrategy {
+	if d == nil {
+		return nil
+	}
+	nd := new(DrainStrategy)
+	*nd = *d
+	return nd
+}
+
+// ClientHostVolumeConfig is a host path a client offers to be mounted into
+// tasks that request a volume with its name
+type ClientHostVolumeConfig struct {
+	Name     string
+	Path     string
+	ReadOnly bool
+}
+
+// Copy returns a copy of the host volume
+func (v *ClientHostVolumeConfig) Copy() *ClientHostVolumeConfig {
+	if v == nil {
+		return nil
+	}
+	nv := new(ClientHostVolumeConfig)
+	*nv = *v
+	return nv
 }
 
 // NodeListStub is used to return a subset of job information
 // for the job list
 type NodeListStub struct {
-	ID                string
-	Datacenter        string
-	Name              string
-	NodeClass         string
-	Drain             bool
-	Status            string
-	StatusDescription string
-	CreateIndex       uint64
-	ModifyIndex       uint64
+	ID                    string
+	Datacenter            string
+	Name                  string
+	NodeClass             string
+	Drain                 bool
+	SchedulingEligibility string
+	Status                string
+	StatusDescription     string
+	CreateIndex           uint64
+	ModifyIndex           uint64
 }
 
 // Resources is used to define the resources available
@@ -729,6 +1050,10 @@ const (
 	// JobMaxPriority is the maximum allowed priority
 	JobMaxPriority = 100
 
+	// JobTrackedVersions is the number of historic job versions that are
+	// kept.
+	JobTrackedVersions = 6
+
 	// Ensure CoreJobPriority is higher than any user
 	// specified job so that it gets priority. This is important
 	// for the system to remain healthy.
@@ -747,6 +1072,9 @@ type Job struct {
 	// specified hierarchically like LineOfBiz/OrgName/Team/Project
 	ID string
 
+	// ParentID is the unique identifier of the job that spawned this job.
+	ParentID string
+
 	// Name is the logical name of the job used to refer to it. This is unique
 	// per region, but not unique globally.
 	Name string
@@ -773,6 +1101,10 @@ type Job struct {
 	// all the task groups and tasks.
 	Constraints []*Constraint
 
+	// Spreads can be specified at a job level and apply to all the task
+	// groups.
+	Spreads []*Spread
+
 	// TaskGroups are the collections of task groups that this job needs
 	// to run. Each task group is an atomic unit of scheduling and placement.
 	TaskGroups []*TaskGroup
@@ -780,6 +1112,16 @@ type Job struct {
 	// Update is used to control the update strategy
 	Update UpdateStrategy
 
+	// Periodic is used to define the interval the job is run at.
+	Periodic *PeriodicConfig
+
+	// ParameterizedJob is used to specify the job as a parameterized job
+	// for dispatching.
+	ParameterizedJob *ParameterizedJobConfig `mapstructure:"parameterized"`
+
+	// Payload is the payload supplied when the job was dispatched.
+	Payload []byte
+
 	// Meta is used to associate arbitrary metadata with this
 	// job. This is opaque to Nomad.
 	Meta map[string]string
@@ -790,6 +1132,18 @@ type Job struct {
 	// StatusDescription is meant to provide more human useful information
 	StatusDescription string
 
+	// Version is a monotonically increasing version number that is
+	// incremented on each job register.
+	Version uint64
+
+	// Stable marks a job version as stable. A version is stable once a
+	// deployment of it has completed successfully.
+	Stable bool
+
+	// SubmitTime is the time at which the job was submitted as a UnixNano in
+	// UTC
+	SubmitTime int64
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -827,6 +1181,15 @@ func (j *Job) Validate() error {
 			mErr.Errors = append(mErr.Errors, outer)
 		}
 	}
+	if j.Type == JobTypeSystem && len(j.Spreads) != 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("System jobs may not have a spread stanza"))
+	}
+	for idx, spread := range j.Spreads {
+		if err := spread.Validate(); err != nil {
+			outer := fmt.Errorf("Spread %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
 
 	// Check for duplicate task groups
 	taskGroups := make(map[string]int)
@@ -844,6 +1207,10 @@ func (j *Job) Validate() error {
 				fmt.Errorf("Job task group %d has count %d. Only count of 1 is supported with system scheduler",
 					idx+1, tg.Count))
 		}
+		if j.Type == JobTypeSystem && len(tg.Spreads) != 0 {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Job task group %d has a spread stanza. Spreads are not supported with system scheduler", idx+1))
+		}
 	}
 
 	// Validate the task group
@@ -853,9 +1220,99 @@ func (j *Job) Validate() error {
 			mErr.Errors = append(mErr.Errors, outer)
 		}
 	}
+
+	// Validate periodic is only used with batch jobs.
+	if j.IsPeriodic() {
+		if j.Type != JobTypeBatch {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Periodic can only be used with %q scheduler", JobTypeBatch))
+		}
+
+		if err := j.Periodic.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	// Validate parameterized jobs are batch jobs that aren't also periodic.
+	if j.IsParameterized() {
+		if j.Type != JobTypeBatch {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Parameterized job can only be used with %q scheduler", JobTypeBatch))
+		}
+
+		if j.IsPeriodic() {
+			mErr.Errors = append(mErr.Errors, errors.New("Parameterized job can't be periodic"))
+		}
+
+		if err := j.ParameterizedJob.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	// Validate the canary count
+	if j.Update.Canary < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Canary count can not be less than zero: %d < 0", j.Update.Canary))
+	} else if j.Update.Canary > 0 && j.Type != JobTypeService {
+		mErr.Errors = append(mErr.Errors,
+			fmt.Errorf("Canaries can only be used with %q scheduler", JobTypeService))
+	}
+
+	// Validate the health settings of the update strategy
+	if j.Update.MinHealthyTime < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Minimum healthy time can not be negative: %v", j.Update.MinHealthyTime))
+	}
+	if j.Update.HealthyDeadline < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Healthy deadline can not be negative: %v", j.Update.HealthyDeadline))
+	} else if j.Update.HealthyDeadline > 0 && j.Update.HealthyDeadline <= j.Update.MinHealthyTime {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Healthy deadline must be greater than the minimum healthy time: %v <= %v",
+			j.Update.HealthyDeadline, j.Update.MinHealthyTime))
+	}
+	if j.Update.AutoRevert && j.Type != JobTypeService {
+		mErr.Errors = append(mErr.Errors,
+			fmt.Errorf("Auto revert can only be used with %q scheduler", JobTypeService))
+	}
 	return mErr.ErrorOrNil()
 }
 
+// Copy returns a deep copy of the Job
+func (j *Job) Copy() *Job {
+	if j == nil {
+		return nil
+	}
+	nj := new(Job)
+	*nj = *j
+	nj.Datacenters = copyStringSlice(nj.Datacenters)
+	nj.Constraints = copyConstraints(nj.Constraints)
+	nj.Spreads = copySpreads(nj.Spreads)
+	nj.Meta = copyStringMap(nj.Meta)
+
+	if j.TaskGroups != nil {
+		tgs := make([]*TaskGroup, len(nj.TaskGroups))
+		for i, tg := range nj.TaskGroups {
+			tgs[i] = tg.Copy()
+		}
+		nj.TaskGroups = tgs
+	}
+
+	nj.Periodic = nj.Periodic.Copy()
+	nj.ParameterizedJob = nj.ParameterizedJob.Copy()
+	if j.Payload != nil {
+		nj.Payload = make([]byte, len(j.Payload))
+		copy(nj.Payload, j.Payload)
+	}
+	return nj
+}
+
+// IsPeriodic returns whether a job is periodic.
+func (j *Job) IsPeriodic() bool {
+	return j.Periodic != nil && j.Periodic.Enabled
+}
+
+// IsParameterized returns whether a job is a parameterized job.
+func (j *Job) IsParameterized() bool {
+	return j.ParameterizedJob != nil
+}
+
 // LookupTaskGroup finds a task group by name
 func (j *Job) LookupTaskGroup(name string) *TaskGroup {
 	for _, tg := range j.TaskGroups {
@@ -870,6 +1327,7 @@ func (j *Job) LookupTaskGroup(name string) *TaskGroup {
 func (j *Job) Stub() *JobListStub {
 	return &JobListStub{
 		ID:                j.ID,
+		ParentID:          j.ParentID,
 		Name:              j.Name,
 		Type:              j.Type,
 		Priority:          j.Priority,
@@ -884,6 +1342,7 @@ func (j *Job) Stub() *JobListStub {
 // for the job list
 type JobListStub struct {
 	ID                string
+	ParentID          string
 	Name              string
 	Type              string
 	Priority          int
@@ -900,94 +1359,682 @@ type UpdateStrategy struct {
 
 	// MaxParallel is how many updates can be done in parallel
 	MaxParallel int `mapstructure:"max_parallel"`
-}
 
-// Rolling returns if a rolling strategy should be used
-func (u *UpdateStrategy) Rolling() bool {
-	return u.Stagger > 0 && u.MaxParallel > 0
-}
+	// Canary is the number of canaries to deploy when a change to a task
+	// group is detected. The rollout is held until the canaries are promoted.
+	Canary int
 
-// RestartPolicy influences how Nomad restarts Tasks when they
-// crash or fail.
-type RestartPolicy struct {
-	Attempts int
-	Interval time.Duration
-	Delay    time.Duration
-}
+	// MinHealthyTime is the minimum time an allocation must be running
+	// without restarting before it is considered healthy.
+	MinHealthyTime time.Duration `mapstructure:"min_healthy_time"`
 
-func (r *RestartPolicy) Validate() error {
-	if time.Duration(r.Attempts)*r.Delay > r.Interval {
-		return fmt.Errorf("Nomad can't restart the TaskGroup %v times in an interval of %v with a delay of %v", r.Attempts, r.Interval, r.Delay)
-	}
-	return nil
+	// HealthyDeadline is the time in which an allocation must become healthy
+	// before it is marked as unhealthy. A zero value disables the deadline.
+	HealthyDeadline time.Duration `mapstructure:"healthy_deadline"`
+
+	// AutoRevert reverts the job to its last stable version if a deployment
+	// fails.
+	AutoRevert bool `mapstructure:"auto_revert"`
 }
 
-func NewRestartPolicy(jobType string) *RestartPolicy {
-	switch jobType {
-	case JobTypeService, JobTypeSystem:
-		rp := defaultServiceJobRestartPolicy
-		return &rp
-	case JobTypeBatch:
-		rp := defaultBatchJobRestartPolicy
-		return &rp
-	}
-	return nil
+// Rolling returns if a rolling strategy should be used
+func (u *UpdateStrategy) Rolling() bool {
+	return u.Stagger > 0 && u.MaxParallel > 0
 }
 
-// TaskGroup is an atomic unit of placement. Each task group belongs to
-// a job and may contain any number of tasks. A task group support running
-// in many replicas using the same configuration..
-type TaskGroup struct {
-	// Name of the task group
-	Name string
+const (
+	// PeriodicSpecCron is used for a cron spec.
+	PeriodicSpecCron = "cron"
+)
 
-	// Count is the number of replicas of this task group that should
-	// be scheduled.
-	Count int
+// PeriodicConfig defines the interval a job should be run at.
+type PeriodicConfig struct {
+	// Enabled determines if the job should be run periodically.
+	Enabled bool
 
-	// Constraints can be specified at a task group level and apply to
-	// all the tasks contained.
-	Constraints []*Constraint
+	// Spec specifies the interval the job should be run as. It is parsed based
+	// on the SpecType.
+	Spec string
 
-	//RestartPolicy of a TaskGroup
-	RestartPolicy *RestartPolicy
+	// SpecType defines the format of the spec.
+	SpecType string
 
-	// Tasks are the collection of tasks that this task group needs to run
-	Tasks []*Task
+	// ProhibitOverlap enforces that spawned jobs do not run in parallel.
+	ProhibitOverlap bool `mapstructure:"prohibit_overlap"`
 
-	// Meta is used to associate arbitrary metadata with this
-	// task group. This is opaque to Nomad.
-	Meta map[string]string
+	// TimeZone is the user specified string that determines the time zone to
+	// launch against. The time zones must be specified from IANA Time Zone
+	// database, such as "America/New_York". If unset, UTC is used.
+	TimeZone string `mapstructure:"time_zone"`
 }
 
-// Validate is used to sanity check a task group
-func (tg *TaskGroup) Validate() error {
-	var mErr multierror.Error
-	if tg.Name == "" {
-		mErr.Errors = append(mErr.Errors, errors.New("Missing task group name"))
-	}
-	if tg.Count <= 0 {
-		mErr.Errors = append(mErr.Errors, errors.New("Task group count must be positive"))
+// Validate is used to sanity check a periodic config
+func (p *PeriodicConfig) Validate() error {
+	if !p.Enabled {
+		return nil
 	}
-	if len(tg.Tasks) == 0 {
-		mErr.Errors = append(mErr.Errors, errors.New("Missing tasks for task group"))
+
+	var mErr multierror.Error
+	if p.Spec == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Must specify a spec"))
 	}
-	for idx, constr := range tg.Constraints {
-		if err := constr.Validate(); err != nil {
-			outer := fmt.Errorf("Constraint %d validation failed: %s", idx+1, err)
-			mErr.Errors = append(mErr.Errors, outer)
+
+	// Check if we got a valid time zone
+	if p.TimeZone != "" {
+		if _, err := time.LoadLocation(p.TimeZone); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid time zone %q: %v", p.TimeZone, err))
 		}
 	}
 
-	if tg.RestartPolicy != nil {
-		if err := tg.RestartPolicy.Validate(); err != nil {
-			mErr.Errors = append(mErr.Errors, err)
+	switch p.SpecType {
+	case PeriodicSpecCron:
+		// Validate the cron spec
+		if _, err := cronexpr.Parse(p.Spec); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid cron spec %q: %v", p.Spec, err))
 		}
-	} else {
-		mErr.Errors = append(mErr.Errors, fmt.Errorf("Task Group %v should have a restart policy", tg.Name))
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Unknown periodic specification type %q", p.SpecType))
 	}
+	return mErr.ErrorOrNil()
+}
 
-	// Check for duplicate tasks
+// Copy returns a copy of the periodic config
+func (p *PeriodicConfig) Copy() *PeriodicConfig {
+	if p == nil {
+		return nil
+	}
+	np := new(PeriodicConfig)
+	*np = *p
+	return np
+}
+
+// GetLocation returns the location to launch the periodic job against. An
+// unset time zone is treated as UTC.
+func (p *PeriodicConfig) GetLocation() (*time.Location, error) {
+	if p.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(p.TimeZone)
+}
+
+// Next returns the closest time instant matching the spec that is after the
+// passed time. If no matching instance exists, the zero value of time.Time is
+// returned. The fromTime is interpreted in the configured time zone.
+func (p *PeriodicConfig) Next(fromTime time.Time) time.Time {
+	switch p.SpecType {
+	case PeriodicSpecCron:
+		e, err := cronexpr.Parse(p.Spec)
+		if err != nil {
+			return time.Time{}
+		}
+
+		loc, err := p.GetLocation()
+		if err != nil {
+			return time.Time{}
+		}
+		return e.Next(fromTime.In(loc))
+	}
+
+	return time.Time{}
+}
+
+// PeriodicLaunch tracks the last launch time of a periodic job.
+type PeriodicLaunch struct {
+	ID     string    // ID of the periodic job.
+	Launch time.Time // The last launch time.
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+const (
+	// DispatchPayloadForbidden denotes that a payload can not be provided
+	// when dispatching a parameterized job.
+	DispatchPayloadForbidden = "forbidden"
+
+	// DispatchPayloadOptional denotes that a payload may be provided when
+	// dispatching a parameterized job.
+	DispatchPayloadOptional = "optional"
+
+	// DispatchPayloadRequired denotes that a payload must be provided when
+	// dispatching a parameterized job.
+	DispatchPayloadRequired = "required"
+
+	// DispatchPayloadSizeLimit is the maximum size of the payload that can be
+	// provided when dispatching a job.
+	DispatchPayloadSizeLimit = 16 * 1024
+
+	// DispatchLaunchSuffix is the string appended to a parameterized job's ID
+	// when deriving the ID of a dispatched child job.
+	DispatchLaunchSuffix = "/dispatch-"
+)
+
+// ParameterizedJobConfig is used to configure the parameterized job. A
+// parameterized job is not run directly but is dispatched with a payload and
+// meta data, creating a child job.
+type ParameterizedJobConfig struct {
+	// Payload configures the payload requirements. An empty value is
+	// treated as optional.
+	Payload string
+
+	// MetaRequired is metadata keys that must be specified by the dispatcher
+	MetaRequired []string `mapstructure:"meta_required"`
+
+	// MetaOptional is metadata keys that may be specified by the dispatcher
+	MetaOptional []string `mapstructure:"meta_optional"`
+}
+
+func (d *ParameterizedJobConfig) Validate() error {
+	var mErr multierror.Error
+	switch d.Payload {
+	case "", DispatchPayloadOptional, DispatchPayloadRequired, DispatchPayloadForbidden:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Unknown payload requirement: %q", d.Payload))
+	}
+
+	// Check that the meta configurations are disjoint sets
+	for _, key := range d.MetaRequired {
+		for _, optional := range d.MetaOptional {
+			if key == optional {
+				mErr.Errors = append(mErr.Errors,
+					fmt.Errorf("Meta key %q can't be both required and optional", key))
+			}
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the parameterized job config
+func (d *ParameterizedJobConfig) Copy() *ParameterizedJobConfig {
+	if d == nil {
+		return nil
+	}
+	nd := new(ParameterizedJobConfig)
+	*nd = *d
+	nd.MetaOptional = copyStringSlice(nd.MetaOptional)
+	nd.MetaRequired = copyStringSlice(nd.MetaRequired)
+	return nd
+}
+
+// DispatchedID returns an ID appropriate for a job dispatched against a
+// particular parameterized job
+func DispatchedID(templateID string, t time.Time) string {
+	u := GenerateUUID()[:8]
+	return fmt.Sprintf("%s%s%d-%s", templateID, DispatchLaunchSuffix, t.Unix(), u)
+}
+
+// DispatchPayloadConfig configures how a task gets its input from a job
+// dispatch
+type DispatchPayloadConfig struct {
+	// File specifies a relative path to where the input data should be
+	// written
+	File string
+}
+
+// Copy returns a copy of the dispatch payload config
+func (d *DispatchPayloadConfig) Copy() *DispatchPayloadConfig {
+	if d == nil {
+		return nil
+	}
+	nd := new(DispatchPayloadConfig)
+	*nd = *d
+	return nd
+}
+
+// Validate checks that the payload file stays within the task directory
+func (d *DispatchPayloadConfig) Validate() error {
+	if d.File == "" {
+		return errors.New("Missing dispatch payload file")
+	}
+	if filepath.IsAbs(d.File) {
+		return fmt.Errorf("Dispatch payload file %q must be a relative path", d.File)
+	}
+	if cleaned := filepath.Clean(d.File); cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("Dispatch payload file %q escapes the task's local directory", d.File)
+	}
+	return nil
+}
+
+const (
+	// DefaultLogMaxFiles is the number of log files of each type kept for a
+	// task that doesn't configure its logs
+	DefaultLogMaxFiles = 10
+
+	// DefaultLogMaxFileSizeMB is the size a log file of a task that doesn't
+	// configure its logs is rotated at
+	DefaultLogMaxFileSizeMB = 10
+)
+
+// LogConfig configures the rotation of the stdout and stderr log files of a
+// task
+type LogConfig struct {
+	// MaxFiles is the number of log files of each type that are kept
+	MaxFiles int `mapstructure:"max_files"`
+
+	// MaxFileSizeMB is the size in MB a log file is rotated at
+	MaxFileSizeMB int `mapstructure:"max_file_size"`
+}
+
+// DefaultLogConfig returns the default log config of a task
+func DefaultLogConfig() *LogConfig {
+	return &LogConfig{
+		MaxFiles:      DefaultLogMaxFiles,
+		MaxFileSizeMB: DefaultLogMaxFileSizeMB,
+	}
+}
+
+// Copy returns a copy of the log config
+func (l *LogConfig) Copy() *LogConfig {
+	if l == nil {
+		return nil
+	}
+	nl := new(LogConfig)
+	*nl = *l
+	return nl
+}
+
+// Validate checks that the log config keeps at least one file of a positive
+// size
+func (l *LogConfig) Validate() error {
+	var mErr multierror.Error
+	if l.MaxFiles < 1 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Maximum number of log files must be at least 1: %d", l.MaxFiles))
+	}
+	if l.MaxFileSizeMB < 1 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Maximum log file size must be at least 1 MB: %d", l.MaxFileSizeMB))
+	}
+	return mErr.ErrorOrNil()
+}
+
+const (
+	// TaskLifecycleHookPrestart runs the task before the main tasks start
+	TaskLifecycleHookPrestart = "prestart"
+
+	// TaskLifecycleHookPoststart runs the task once the main tasks are
+	// running
+	TaskLifecycleHookPoststart = "poststart"
+
+	// TaskLifecycleHookPoststop runs the task once the main tasks have
+	// exited
+	TaskLifecycleHookPoststop = "poststop"
+)
+
+// TaskLifecycleConfig configures when a task runs relative to the main tasks
+// of its task group
+type TaskLifecycleConfig struct {
+	// Hook is the point in the lifecycle of the main tasks the task is
+	// started at
+	Hook string
+
+	// Sidecar keeps the task running until the main tasks exit. Prestart
+	// tasks that aren't sidecars must complete successfully before the main
+	// tasks are started.
+	Sidecar bool
+}
+
+// Copy returns a copy of the lifecycle config
+func (l *TaskLifecycleConfig) Copy() *TaskLifecycleConfig {
+	if l == nil {
+		return nil
+	}
+	nl := new(TaskLifecycleConfig)
+	*nl = *l
+	return nl
+}
+
+// Validate checks that the lifecycle hook is valid
+func (l *TaskLifecycleConfig) Validate() error {
+	switch l.Hook {
+	case TaskLifecycleHookPrestart, TaskLifecycleHookPoststart:
+	case TaskLifecycleHookPoststop:
+		if l.Sidecar {
+			return errors.New("Poststop tasks can't be sidecars")
+		}
+	case "":
+		return errors.New("Missing lifecycle hook")
+	default:
+		return fmt.Errorf("Invalid lifecycle hook %q", l.Hook)
+	}
+	return nil
+}
+
+// RestartPolicy influences how Nomad restarts Tasks when they
+// crash or fail.
+type RestartPolicy struct {
+	Attempts int
+	Interval time.Duration
+	Delay    time.Duration
+}
+
+func (r *RestartPolicy) Validate() error {
+	if time.Duration(r.Attempts)*r.Delay > r.Interval {
+		return fmt.Errorf("Nomad can't restart the TaskGroup %v times in an interval of %v with a delay of %v", r.Attempts, r.Interval, r.Delay)
+	}
+	return nil
+}
+
+// Copy returns a copy of the restart policy
+func (r *RestartPolicy) Copy() *RestartPolicy {
+	if r == nil {
+		return nil
+	}
+	nrp := new(RestartPolicy)
+	*nrp = *r
+	return nrp
+}
+
+func NewRestartPolicy(jobType string) *RestartPolicy {
+	switch jobType {
+	case JobTypeService, JobTypeSystem:
+		rp := defaultServiceJobRestartPolicy
+		return &rp
+	case JobTypeBatch:
+		rp := defaultBatchJobRestartPolicy
+		return &rp
+	}
+	return nil
+}
+
+const (
+	// DefaultEphemeralDiskSizeMB is the size of an ephemeral disk that
+	// doesn't set one
+	DefaultEphemeralDiskSizeMB = 300
+
+	// minEphemeralDiskSizeMB is the smallest size of an ephemeral disk
+	minEphemeralDiskSizeMB = 10
+)
+
+// EphemeralDisk is the disk shared by the tasks of an allocation in the
+// alloc/data directory. Its data is lost when the allocation is replaced
+// unless it is sticky or migrated.
+type EphemeralDisk struct {
+	// SizeMB is the size of the disk reserved on the node
+	SizeMB int `mapstructure:"size_mb"`
+
+	// Sticky places the replacement of an allocation on the node of the
+	// allocation when possible and moves the data to it
+	Sticky bool
+
+	// Migrate streams the data to the replacement of an allocation that is
+	// placed on a different node
+	Migrate bool
+}
+
+// DefaultEphemeralDisk returns the ephemeral disk of a task group that
+// doesn't configure one
+func DefaultEphemeralDisk() *EphemeralDisk {
+	return &EphemeralDisk{
+		SizeMB: DefaultEphemeralDiskSizeMB,
+	}
+}
+
+// Validate is used to sanity check an ephemeral disk
+func (d *EphemeralDisk) Validate() error {
+	if d.SizeMB < minEphemeralDiskSizeMB {
+		return fmt.Errorf("Ephemeral disk size must be at least %d MB: %d", minEphemeralDiskSizeMB, d.SizeMB)
+	}
+	return nil
+}
+
+// Copy returns a copy of the ephemeral disk
+func (d *EphemeralDisk) Copy() *EphemeralDisk {
+	if d == nil {
+		return nil
+	}
+	nd := new(EphemeralDisk)
+	*nd = *d
+	return nd
+}
+
+const (
+	// MigrateHealthCheckTaskStates considers a migrated allocation healthy
+	// once its tasks have been running for the minimum healthy time
+	MigrateHealthCheckTaskStates = "task_states"
+
+	// MigrateHealthCheckNone considers a migrated allocation healthy as soon
+	// as it is placed
+	MigrateHealthCheckNone = "none"
+)
+
+// MigrateStrategy is how the allocations of a task group are migrated off a
+// draining node
+type MigrateStrategy struct {
+	// MaxParallel is the number of allocations of the task group that are
+	// migrated at a time
+	MaxParallel int `mapstructure:"max_parallel"`
+
+	// HealthCheck is how the health of the replacement of a migrated
+	// allocation is determined
+	HealthCheck string `mapstructure:"health_check"`
+
+	// MinHealthyTime is the time the replacement must be running before it
+	// is considered healthy
+	MinHealthyTime time.Duration `mapstructure:"min_healthy_time"`
+
+	// HealthyDeadline is the time in which the replacement must become
+	// healthy before it is marked as unhealthy
+	HealthyDeadline time.Duration `mapstructure:"healthy_deadline"`
+}
+
+// DefaultMigrateStrategy returns the migrate strategy of a task group that
+// doesn't configure one
+func DefaultMigrateStrategy() *MigrateStrategy {
+	return &MigrateStrategy{
+		MaxParallel:     1,
+		HealthCheck:     MigrateHealthCheckTaskStates,
+		MinHealthyTime:  10 * time.Second,
+		HealthyDeadline: 5 * time.Minute,
+	}
+}
+
+// Validate is used to sanity check a migrate strategy
+func (m *MigrateStrategy) Validate() error {
+	var mErr multierror.Error
+	if m.MaxParallel < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Migrate max parallel must not be negative: %d", m.MaxParallel))
+	}
+	switch m.HealthCheck {
+	case MigrateHealthCheckTaskStates, MigrateHealthCheckNone:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid migrate health check: %q", m.HealthCheck))
+	}
+	if m.MinHealthyTime < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Migrate minimum healthy time must not be negative: %v", m.MinHealthyTime))
+	}
+	if m.HealthyDeadline < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Migrate healthy deadline must not be negative: %v", m.HealthyDeadline))
+	} else if m.HealthyDeadline > 0 && m.HealthyDeadline <= m.MinHealthyTime {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Migrate healthy deadline must be greater than the minimum healthy time: %v <= %v",
+			m.HealthyDeadline, m.MinHealthyTime))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the migrate strategy
+func (m *MigrateStrategy) Copy() *MigrateStrategy {
+	if m == nil {
+		return nil
+	}
+	nm := new(MigrateStrategy)
+	*nm = *m
+	return nm
+}
+
+const (
+	// VolumeTypeHost is the type of a volume that is a host volume offered by
+	// the node
+	VolumeTypeHost = "host"
+)
+
+// VolumeRequest is a volume requested by a task group. The task group is only
+// placed on nodes that offer the volume.
+type VolumeRequest struct {
+	// Name is the name the tasks of the group mount the volume by
+	Name string
+
+	// Type is the type of the volume. Only host volumes are supported.
+	Type string
+
+	// Source is the name of the host volume on the node
+	Source string
+
+	// ReadOnly mounts the volume read-only into all tasks
+	ReadOnly bool `mapstructure:"read_only"`
+}
+
+// Validate is used to sanity check a volume request
+func (v *VolumeRequest) Validate() error {
+	var mErr multierror.Error
+	if v.Name == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing volume name"))
+	}
+	if v.Type != VolumeTypeHost {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Unsupported volume type %q", v.Type))
+	}
+	if v.Source == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing volume source"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the volume request
+func (v *VolumeRequest) Copy() *VolumeRequest {
+	if v == nil {
+		return nil
+	}
+	nv := new(VolumeRequest)
+	*nv = *v
+	return nv
+}
+
+// VolumeMount mounts a volume of the task group into a task
+type VolumeMount struct {
+	// Volume is the name of the volume of the task group
+	Volume string
+
+	// Destination is the path the volume is mounted at inside the task
+	Destination string
+
+	// ReadOnly mounts the volume read-only
+	ReadOnly bool `mapstructure:"read_only"`
+}
+
+// Validate is used to sanity check a volume mount
+func (m *VolumeMount) Validate() error {
+	var mErr multierror.Error
+	if m.Volume == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing volume to mount"))
+	}
+	if m.Destination == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing mount destination"))
+	} else if strings.Contains(m.Destination, "..") {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Mount destination %q must not contain \"..\"", m.Destination))
+	} else if filepath.Clean("/"+m.Destination) == "/" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Mount destination %q must not be the task root", m.Destination))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the volume mount
+func (m *VolumeMount) Copy() *VolumeMount {
+	if m == nil {
+		return nil
+	}
+	nm := new(VolumeMount)
+	*nm = *m
+	return nm
+}
+
+// TaskGroup is an atomic unit of placement. Each task group belongs to
+// a job and may contain any number of tasks. A task group support running
+// in many replicas using the same configuration..
+type TaskGroup struct {
+	// Name of the task group
+	Name string
+
+	// Count is the number of replicas of this task group that should
+	// be scheduled.
+	Count int
+
+	// Constraints can be specified at a task group level and apply to
+	// all the tasks contained.
+	Constraints []*Constraint
+
+	// Spreads distribute the allocations of the task group across the
+	// values of node attributes. They are combined with the spreads of the
+	// job.
+	Spreads []*Spread
+
+	//RestartPolicy of a TaskGroup
+	RestartPolicy *RestartPolicy
+
+	// EphemeralDisk is the disk shared by the tasks of each allocation. It
+	// is optional and only reserves disk space when set.
+	EphemeralDisk *EphemeralDisk
+
+	// Migrate is how the allocations of the task group are migrated off
+	// draining nodes. The default strategy is used if it is not set.
+	Migrate *MigrateStrategy
+
+	// Volumes are the volumes the tasks of the group may mount, keyed by
+	// their name
+	Volumes map[string]*VolumeRequest
+
+	// Tasks are the collection of tasks that this task group needs to run
+	Tasks []*Task
+
+	// Meta is used to associate arbitrary metadata with this
+	// task group. This is opaque to Nomad.
+	Meta map[string]string
+}
+
+// Validate is used to sanity check a task group
+func (tg *TaskGroup) Validate() error {
+	var mErr multierror.Error
+	if tg.Name == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing task group name"))
+	}
+	if tg.Count <= 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Task group count must be positive"))
+	}
+	if len(tg.Tasks) == 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing tasks for task group"))
+	}
+	for idx, constr := range tg.Constraints {
+		if err := constr.Validate(); err != nil {
+			outer := fmt.Errorf("Constraint %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	for idx, spread := range tg.Spreads {
+		if err := spread.Validate(); err != nil {
+			outer := fmt.Errorf("Spread %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
+	if tg.RestartPolicy != nil {
+		if err := tg.RestartPolicy.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	} else {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Task Group %v should have a restart policy", tg.Name))
+	}
+
+	if tg.EphemeralDisk != nil {
+		if err := tg.EphemeralDisk.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	if tg.Migrate != nil {
+		if err := tg.Migrate.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	// Check for duplicate tasks
 	tasks := make(map[string]int)
 	for idx, task := range tg.Tasks {
 		if task.Name == "" {
@@ -999,6 +2046,36 @@ func (tg *TaskGroup) Validate() error {
 		}
 	}
 
+	// Validate the volumes and ensure the tasks only mount those
+	for name, volume := range tg.Volumes {
+		if err := volume.Validate(); err != nil {
+			outer := fmt.Errorf("Volume %q validation failed: %s", name, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		} else if volume.Name != name {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Volume %q has mismatched name %q", name, volume.Name))
+		}
+	}
+	for _, task := range tg.Tasks {
+		for _, mount := range task.VolumeMounts {
+			if _, ok := tg.Volumes[mount.Volume]; mount.Volume != "" && !ok {
+				mErr.Errors = append(mErr.Errors,
+					fmt.Errorf("Task %q mounts unknown volume %q", task.Name, mount.Volume))
+			}
+		}
+	}
+
+	// Ensure the lifecycle tasks have a main task to run alongside
+	main := false
+	for _, task := range tg.Tasks {
+		if task.IsMain() {
+			main = true
+			break
+		}
+	}
+	if len(tg.Tasks) > 0 && !main {
+		mErr.Errors = append(mErr.Errors, errors.New("Task group must have a task without a lifecycle"))
+	}
+
 	// Validate the tasks
 	for idx, task := range tg.Tasks {
 		if err := task.Validate(); err != nil {
@@ -1019,6 +2096,37 @@ func (tg *TaskGroup) LookupTask(name string) *Task {
 	return nil
 }
 
+// Copy returns a deep copy of the task group
+func (tg *TaskGroup) Copy() *TaskGroup {
+	if tg == nil {
+		return nil
+	}
+	ntg := new(TaskGroup)
+	*ntg = *tg
+	ntg.Constraints = copyConstraints(ntg.Constraints)
+	ntg.Spreads = copySpreads(ntg.Spreads)
+	ntg.RestartPolicy = ntg.RestartPolicy.Copy()
+	ntg.EphemeralDisk = ntg.EphemeralDisk.Copy()
+	ntg.Migrate = ntg.Migrate.Copy()
+	ntg.Meta = copyStringMap(ntg.Meta)
+	if tg.Volumes != nil {
+		volumes := make(map[string]*VolumeRequest, len(tg.Volumes))
+		for name, v := range tg.Volumes {
+			volumes[name] = v.Copy()
+		}
+		ntg.Volumes = volumes
+	}
+
+	if tg.Tasks != nil {
+		tasks := make([]*Task, len(ntg.Tasks))
+		for i, t := range ntg.Tasks {
+			tasks[i] = t.Copy()
+		}
+		ntg.Tasks = tasks
+	}
+	return ntg
+}
+
 func (tg *TaskGroup) GoString() string {
 	return fmt.Sprintf("*%#v", *tg)
 }
@@ -1034,41 +2142,450 @@ type Task struct {
 	// Config is provided to the driver to initialize
 	Config map[string]string
 
-	// Map of environment variables to be used by the driver
-	Env map[string]string
+	// Map of environment variables to be used by the driver
+	Env map[string]string
+
+	// Constraints can be specified at a task level and apply only to
+	// the particular task.
+	Constraints []*Constraint
+
+	// Resources is the resources needed by this task
+	Resources *Resources
+
+	// Meta is used to associate arbitrary metadata with this
+	// task. This is opaque to Nomad.
+	Meta map[string]string
+
+	// DispatchPayload configures how the task retrieves its input from a
+	// dispatch
+	DispatchPayload *DispatchPayloadConfig `mapstructure:"dispatch_payload"`
+
+	// Services are the services the task registers with Consul
+	Services []*Service
+
+	// Artifacts are downloaded into the task directory before the task starts
+	Artifacts []*TaskArtifact
+
+	// Templates are rendered into the task directory before the task starts
+	Templates []*Template
+
+	// VolumeMounts mount volumes of the task group into the task
+	VolumeMounts []*VolumeMount
+
+	// Lifecycle determines when the task is started relative to the other
+	// tasks of the task group. Tasks without a lifecycle are the main tasks.
+	Lifecycle *TaskLifecycleConfig
+
+	// KillSignal is the signal sent to the task to ask it to exit. It
+	// defaults to DefaultKillSignal.
+	KillSignal string `mapstructure:"kill_signal"`
+
+	// KillTimeout is the time the task is given to exit after receiving its
+	// kill signal before it is forcefully killed. It defaults to
+	// DefaultKillTimeout.
+	KillTimeout time.Duration `mapstructure:"kill_timeout"`
+
+	// LogConfig configures the rotation of the task's stdout and stderr log
+	// files. It defaults to DefaultLogConfig.
+	LogConfig *LogConfig
+}
+
+const (
+	// DefaultKillSignal is the signal sent to a task to stop it if the task
+	// doesn't configure a kill signal
+	DefaultKillSignal = "SIGINT"
+
+	// DefaultKillTimeout is the time a task is given to exit if the task
+	// doesn't configure a kill timeout
+	DefaultKillTimeout = 5 * time.Second
+)
+
+// Copy returns a deep copy of the task
+func (t *Task) Copy() *Task {
+	if t == nil {
+		return nil
+	}
+	nt := new(Task)
+	*nt = *t
+	nt.Config = copyStringMap(nt.Config)
+	nt.Env = copyStringMap(nt.Env)
+	nt.Constraints = copyConstraints(nt.Constraints)
+	nt.Meta = copyStringMap(nt.Meta)
+	if t.Resources != nil {
+		nt.Resources = t.Resources.Copy()
+	}
+	nt.DispatchPayload = nt.DispatchPayload.Copy()
+	if t.Services != nil {
+		services := make([]*Service, len(t.Services))
+		for i, s := range t.Services {
+			services[i] = s.Copy()
+		}
+		nt.Services = services
+	}
+	if t.Artifacts != nil {
+		artifacts := make([]*TaskArtifact, len(t.Artifacts))
+		for i, a := range t.Artifacts {
+			artifacts[i] = a.Copy()
+		}
+		nt.Artifacts = artifacts
+	}
+	if t.Templates != nil {
+		templates := make([]*Template, len(t.Templates))
+		for i, tmpl := range t.Templates {
+			templates[i] = tmpl.Copy()
+		}
+		nt.Templates = templates
+	}
+	if t.VolumeMounts != nil {
+		mounts := make([]*VolumeMount, len(t.VolumeMounts))
+		for i, m := range t.VolumeMounts {
+			mounts[i] = m.Copy()
+		}
+		nt.VolumeMounts = mounts
+	}
+	nt.Lifecycle = nt.Lifecycle.Copy()
+	nt.LogConfig = nt.LogConfig.Copy()
+	return nt
+}
+
+// IsMain returns whether the task is a main task of its task group
+func (t *Task) IsMain() bool {
+	return t.Lifecycle == nil
+}
+
+// IsSidecar returns whether the task keeps running alongside the main tasks
+// until they exit
+func (t *Task) IsSidecar() bool {
+	return t.Lifecycle != nil && t.Lifecycle.Sidecar
+}
+
+func (t *Task) GoString() string {
+	return fmt.Sprintf("*%#v", *t)
+}
+
+// Validate is used to sanity check a task group
+func (t *Task) Validate() error {
+	var mErr multierror.Error
+	if t.Name == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing task name"))
+	}
+	if t.Driver == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing task driver"))
+	}
+	if t.Resources == nil {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing task resources"))
+	}
+	for idx, constr := range t.Constraints {
+		if err := constr.Validate(); err != nil {
+			outer := fmt.Errorf("Constraint %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	if t.DispatchPayload != nil {
+		if err := t.DispatchPayload.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+	if t.Lifecycle != nil {
+		if err := t.Lifecycle.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+	if t.KillTimeout < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Kill timeout must not be negative"))
+	}
+	if t.LogConfig != nil {
+		if err := t.LogConfig.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	// Validate the services and ensure their names are unique
+	services := make(map[string]int)
+	for idx, service := range t.Services {
+		if err := service.Validate(); err != nil {
+			outer := fmt.Errorf("Service %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+		if existing, ok := services[service.Name]; ok {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Service %d redefines '%s' from service %d", idx+1, service.Name, existing+1))
+		} else {
+			services[service.Name] = idx
+		}
+	}
+
+	// Validate the artifacts
+	for idx, artifact := range t.Artifacts {
+		if err := artifact.Validate(); err != nil {
+			outer := fmt.Errorf("Artifact %d validation failed: %v", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
+	// Validate the templates and ensure they render to distinct files
+	destinations := make(map[string]int)
+	for idx, tmpl := range t.Templates {
+		if err := tmpl.Validate(); err != nil {
+			outer := fmt.Errorf("Template %d validation failed: %v", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+			continue
+		}
+		dest := filepath.Clean(tmpl.DestPath)
+		if existing, ok := destinations[dest]; ok {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Template %d renders to %q like template %d", idx+1, tmpl.DestPath, existing+1))
+		} else {
+			destinations[dest] = idx
+		}
+	}
+
+	// Validate the volume mounts
+	for idx, mount := range t.VolumeMounts {
+		if err := mount.Validate(); err != nil {
+			outer := fmt.Errorf("Volume mount %d validation failed: %v", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+const (
+	// TemplateChangeModeNoop leaves the task running when its template
+	// changes
+	TemplateChangeModeNoop = "noop"
+
+	// TemplateChangeModeRestart restarts the task when its template changes
+	TemplateChangeModeRestart = "restart"
+
+	// TemplateChangeModeSignal signals the task when its template changes
+	TemplateChangeModeSignal = "signal"
+)
+
+// Template is a template rendered into the task directory. Templates have
+// access to the task's environment, such as NOMAD_IP, NOMAD_PORT_<label> and
+// NOMAD_META_<key>, using the env function.
+type Template struct {
+	// SourcePath is the path of the template relative to the task directory
+	SourcePath string `mapstructure:"source"`
+
+	// DestPath is the path relative to the task directory the template is
+	// rendered to
+	DestPath string `mapstructure:"destination"`
+
+	// EmbeddedTmpl is the template itself when it is inlined in the job
+	EmbeddedTmpl string `mapstructure:"data"`
+
+	// ChangeMode is the action taken when the rendered template changes
+	ChangeMode string `mapstructure:"change_mode"`
+
+	// ChangeSignal is the signal sent to the task when ChangeMode is signal
+	ChangeSignal string `mapstructure:"change_signal"`
+}
+
+// Copy returns a copy of the template
+func (t *Template) Copy() *Template {
+	if t == nil {
+		return nil
+	}
+	nt := new(Template)
+	*nt = *t
+	return nt
+}
+
+// Validate is used to sanity check a template
+func (t *Template) Validate() error {
+	var mErr multierror.Error
+
+	// Exactly one source of the template must be given
+	if t.SourcePath == "" && t.EmbeddedTmpl == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Must specify a source path or have an embedded template"))
+	} else if t.SourcePath != "" && t.EmbeddedTmpl != "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Can not specify both a source path and an embedded template"))
+	}
+	if t.SourcePath != "" {
+		if err := validateTaskPath(t.SourcePath); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Source path: %v", err))
+		}
+	}
+
+	if t.DestPath == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Must specify a destination path"))
+	} else if err := validateTaskPath(t.DestPath); err != nil {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Destination path: %v", err))
+	}
+
+	switch t.ChangeMode {
+	case TemplateChangeModeNoop, TemplateChangeModeRestart:
+	case TemplateChangeModeSignal:
+		if t.ChangeSignal == "" {
+			mErr.Errors = append(mErr.Errors, errors.New("Must specify a signal with the signal change mode"))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Change mode must be one of %q, %q or %q: %q",
+			TemplateChangeModeNoop, TemplateChangeModeRestart, TemplateChangeModeSignal, t.ChangeMode))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// validateTaskPath checks that a path is relative and stays within the task
+// directory
+func validateTaskPath(path string) error {
+	if filepath.IsAbs(path) {
+		return fmt.Errorf("%q must be a relative path", path)
+	}
+	if cleaned := filepath.Clean(path); cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%q escapes the task directory", path)
+	}
+	return nil
+}
+
+// TaskArtifact is an artifact to download and extract before running the task
+type TaskArtifact struct {
+	// GetterSource is the source to download the artifact from using
+	// go-getter
+	GetterSource string `mapstructure:"source"`
+
+	// GetterOptions are options passed to go-getter, such as the checksum of
+	// the artifact
+	GetterOptions map[string]string `mapstructure:"options"`
+
+	// RelativeDest is the directory relative to the task directory the
+	// artifact is downloaded into. It defaults to the task's local directory.
+	// Archives are unpacked into the destination.
+	RelativeDest string `mapstructure:"destination"`
+}
+
+// Copy returns a deep copy of the artifact
+func (ta *TaskArtifact) Copy() *TaskArtifact {
+	if ta == nil {
+		return nil
+	}
+	nta := new(TaskArtifact)
+	*nta = *ta
+	nta.GetterOptions = copyStringMap(ta.GetterOptions)
+	return nta
+}
+
+// Validate checks that the artifact has a source and that its destination
+// stays within the task directory
+func (ta *TaskArtifact) Validate() error {
+	var mErr multierror.Error
+	if ta.GetterSource == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing artifact source"))
+	}
+	if filepath.IsAbs(ta.RelativeDest) {
+		mErr.Errors = append(mErr.Errors,
+			fmt.Errorf("Artifact destination %q must be a relative path", ta.RelativeDest))
+	} else if cleaned := filepath.Clean(ta.RelativeDest); cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		mErr.Errors = append(mErr.Errors,
+			fmt.Errorf("Artifact destination %q escapes the task directory", ta.RelativeDest))
+	}
+	return mErr.ErrorOrNil()
+}
+
+const (
+	ServiceCheckHTTP   = "http"
+	ServiceCheckTCP    = "tcp"
+	ServiceCheckScript = "script"
+)
 
-	// Constraints can be specified at a task level and apply only to
-	// the particular task.
-	Constraints []*Constraint
+// ServiceCheck is a health check registered with Consul for a service
+type ServiceCheck struct {
+	Name     string        // Name of the check, unique within the service
+	Type     string        // Type of the check: http, tcp or script
+	Script   string        // Script to invoke for a script check
+	Path     string        // Path of the health check URL for an http check
+	Protocol string        // Protocol of an http check, defaults to http
+	Interval time.Duration // Interval of the check
+	Timeout  time.Duration // Timeout of the check's response
+}
 
-	// Resources is the resources needed by this task
-	Resources *Resources
+// Copy returns a copy of the service check
+func (sc *ServiceCheck) Copy() *ServiceCheck {
+	if sc == nil {
+		return nil
+	}
+	nsc := new(ServiceCheck)
+	*nsc = *sc
+	return nsc
+}
 
-	// Meta is used to associate arbitrary metadata with this
-	// task. This is opaque to Nomad.
-	Meta map[string]string
+// Validate is used to sanity check a service check
+func (sc *ServiceCheck) Validate() error {
+	var mErr multierror.Error
+	switch sc.Type {
+	case ServiceCheckHTTP:
+		if sc.Path == "" {
+			mErr.Errors = append(mErr.Errors, errors.New("http check must have a path"))
+		}
+	case ServiceCheckTCP:
+	case ServiceCheckScript:
+		if sc.Script == "" {
+			mErr.Errors = append(mErr.Errors, errors.New("script check must have a script"))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("check type must be one of %q, %q or %q: %q",
+			ServiceCheckHTTP, ServiceCheckTCP, ServiceCheckScript, sc.Type))
+	}
+	if sc.Interval <= 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("check interval must be positive: %v", sc.Interval))
+	}
+	if sc.Timeout < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("check timeout can not be negative: %v", sc.Timeout))
+	}
+	return mErr.ErrorOrNil()
 }
 
-func (t *Task) GoString() string {
-	return fmt.Sprintf("*%#v", *t)
+// Service is a service the task registers with Consul
+type Service struct {
+	Name      string          // Name of the service
+	Tags      []string        // Tags of the service
+	PortLabel string          `mapstructure:"port"` // Label of the port the service listens on
+	Checks    []*ServiceCheck // Health checks of the service
 }
 
-// Validate is used to sanity check a task group
-func (t *Task) Validate() error {
-	var mErr multierror.Error
-	if t.Name == "" {
-		mErr.Errors = append(mErr.Errors, errors.New("Missing task name"))
+// Copy returns a deep copy of the service
+func (s *Service) Copy() *Service {
+	if s == nil {
+		return nil
 	}
-	if t.Driver == "" {
-		mErr.Errors = append(mErr.Errors, errors.New("Missing task driver"))
+	ns := new(Service)
+	*ns = *s
+	ns.Tags = copyStringSlice(s.Tags)
+	if s.Checks != nil {
+		checks := make([]*ServiceCheck, len(s.Checks))
+		for i, c := range s.Checks {
+			checks[i] = c.Copy()
+		}
+		ns.Checks = checks
 	}
-	if t.Resources == nil {
-		mErr.Errors = append(mErr.Errors, errors.New("Missing task resources"))
+	return ns
+}
+
+// Validate is used to sanity check a service
+func (s *Service) Validate() error {
+	var mErr multierror.Error
+	if s.Name == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing service name"))
 	}
-	for idx, constr := range t.Constraints {
-		if err := constr.Validate(); err != nil {
-			outer := fmt.Errorf("Constraint %d validation failed: %s", idx+1, err)
+	checks := make(map[string]int)
+	for idx, check := range s.Checks {
+		if check.Name == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Check %d missing name", idx+1))
+		} else if existing, ok := checks[check.Name]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Check %d redefines '%s' from check %d", idx+1, check.Name, existing))
+		} else {
+			checks[check.Name] = idx + 1
+		}
+		if err := check.Validate(); err != nil {
+			outer := fmt.Errorf("Check %d validation failed: %s", idx+1, err)
 			mErr.Errors = append(mErr.Errors, outer)
+			continue
+		}
+		if check.Type != ServiceCheckScript && s.PortLabel == "" {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Check %d of type %q requires the service to have a port", idx+1, check.Type))
 		}
 	}
 	return mErr.ErrorOrNil()
@@ -1087,6 +2604,16 @@ type Constraint struct {
 	Operand string // Constraint operand (<=, <, =, !=, >, >=), contains, near
 }
 
+// Copy returns a copy of the constraint
+func (c *Constraint) Copy() *Constraint {
+	if c == nil {
+		return nil
+	}
+	nc := new(Constraint)
+	*nc = *c
+	return nc
+}
+
 func (c *Constraint) String() string {
 	return fmt.Sprintf("%s %s %s", c.LTarget, c.Operand, c.RTarget)
 }
@@ -1111,6 +2638,272 @@ func (c *Constraint) Validate() error {
 	return mErr.ErrorOrNil()
 }
 
+// Spread is used to distribute the allocations of a task group evenly, or by
+// the percentages of its targets, across the values of a node attribute.
+type Spread struct {
+	// Attribute is the node attribute to spread across, such as
+	// "$node.datacenter" or "$meta.rack"
+	Attribute string
+
+	// Weight is the importance of the spread relative to the other spreads
+	// of the task group, between 1 and 100
+	Weight int
+
+	// Targets are the desired percentages of the allocations for values of
+	// the attribute. The allocations are spread evenly if none are set.
+	Targets []*SpreadTarget
+}
+
+// SpreadTarget is the desired percentage of the allocations of a task group
+// placed on the nodes with the value of the attribute of a spread
+type SpreadTarget struct {
+	Value   string
+	Percent int
+}
+
+// Copy returns a deep copy of the spread
+func (s *Spread) Copy() *Spread {
+	if s == nil {
+		return nil
+	}
+	ns := new(Spread)
+	*ns = *s
+	if s.Targets != nil {
+		ns.Targets = make([]*SpreadTarget, len(s.Targets))
+		for i, t := range s.Targets {
+			nt := new(SpreadTarget)
+			*nt = *t
+			ns.Targets[i] = nt
+		}
+	}
+	return ns
+}
+
+func (s *Spread) String() string {
+	targets := make([]string, len(s.Targets))
+	for i, t := range s.Targets {
+		targets[i] = fmt.Sprintf("%s:%d%%", t.Value, t.Percent)
+	}
+	return fmt.Sprintf("%s %d [%s]", s.Attribute, s.Weight, strings.Join(targets, ", "))
+}
+
+// Validate is used to sanity check a spread
+func (s *Spread) Validate() error {
+	var mErr multierror.Error
+	if s.Attribute == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing spread attribute"))
+	}
+	if s.Weight <= 0 || s.Weight > 100 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread weight must be between 1 and 100, got %d", s.Weight))
+	}
+
+	seen := make(map[string]struct{}, len(s.Targets))
+	sum := 0
+	for idx, t := range s.Targets {
+		if t.Value == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread target %d is missing a value", idx+1))
+		} else if _, ok := seen[t.Value]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread target %q is defined more than once", t.Value))
+		}
+		seen[t.Value] = struct{}{}
+		if t.Percent < 0 || t.Percent > 100 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread target %q percent must be between 0 and 100, got %d", t.Value, t.Percent))
+		}
+		sum += t.Percent
+	}
+	if sum > 100 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Sum of spread target percentages must not exceed 100, got %d", sum))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Set of possible states for a task.
+const (
+	TaskStatePending = "pending" // The task is waiting to be run.
+	TaskStateRunning = "running" // The task is currently running.
+	TaskStateDead    = "dead"    // Terminal state of task.
+)
+
+// maxTaskEvents is the maximum number of events kept for a task
+const maxTaskEvents = 10
+
+// TaskState tracks the current state of a task and events that caused state
+// transistions.
+type TaskState struct {
+	// The current state of the task.
+	State string
+
+	// Failed marks a task as having failed
+	Failed bool
+
+	// Series of task events that transistion the state of the task.
+	Events []*TaskEvent
+}
+
+// Copy returns a copy of the task state
+func (ts *TaskState) Copy() *TaskState {
+	if ts == nil {
+		return nil
+	}
+	copy := new(TaskState)
+	*copy = *ts
+
+	if ts.Events != nil {
+		copy.Events = make([]*TaskEvent, len(ts.Events))
+		for i, e := range ts.Events {
+			copy.Events[i] = e.Copy()
+		}
+	}
+	return copy
+}
+
+// AppendEvent appends an event to the task state, dropping the oldest events
+// so that only the most recent ones are kept.
+func (ts *TaskState) AppendEvent(event *TaskEvent) {
+	if len(ts.Events) >= maxTaskEvents {
+		ts.Events = ts.Events[len(ts.Events)-maxTaskEvents+1:]
+	}
+	ts.Events = append(ts.Events, event)
+}
+
+const (
+	// A Driver failure indicates that the task could not be started due to a
+	// failure in the driver.
+	TaskDriverFailure = "Driver Failure"
+
+	// Task Started signals that the task was started and its timestamp can be
+	// used to determine the running length of the task.
+	TaskStarted = "Started"
+
+	// Task terminated indicates that the task was started and exited.
+	TaskTerminated = "Terminated"
+
+	// Task Killed indicates a user has killed the task.
+	TaskKilled = "Killed"
+
+	// TaskRestarting indicates that task terminated and is being restarted.
+	TaskRestarting = "Restarting"
+
+	// TaskNotRestarting indicates that the task has failed and is not being
+	// restarted because it has exceeded its restart policy.
+	TaskNotRestarting = "Not Restarting"
+
+	// TaskDownloadingArtifacts means the task is downloading the artifacts
+	// specified in the task.
+	TaskDownloadingArtifacts = "Downloading Artifacts"
+
+	// TaskArtifactDownloadFailed indicates that downloading the artifacts
+	// failed.
+	TaskArtifactDownloadFailed = "Failed Artifact Download"
+
+	// TaskSetupFailure indicates that the task could not be started due to a
+	// a setup failure, such as rendering its templates.
+	TaskSetupFailure = "Setup Failure"
+)
+
+// TaskEvent is an event that effects the state of a task and contains meta-data
+// appropriate to the events type.
+type TaskEvent struct {
+	Type string
+	Time int64 // Unix Nanosecond timestamp
+
+	// FailsTask marks whether this event fails the task
+	FailsTask bool
+
+	// Driver Failure fields.
+	DriverError string // A driver error occured while starting the task.
+
+	// Task Terminated Fields.
+	ExitCode int    // The exit code of the task.
+	Signal   int    // The signal that terminated the task.
+	Message  string // A possible message explaining the termination of the task.
+
+	// Task Killed Fields.
+	KillError string // Error killing the task.
+
+	// TaskRestarting fields.
+	StartDelay int64 // The sleep period before restarting the task in unix nanoseconds.
+
+	// Artifact Download fields
+	DownloadError string // Error downloading artifacts
+
+	// Setup Failure fields.
+	SetupError string // Error setting up the task
+}
+
+// Copy returns a copy of the task event
+func (te *TaskEvent) Copy() *TaskEvent {
+	if te == nil {
+		return nil
+	}
+	copy := new(TaskEvent)
+	*copy = *te
+	return copy
+}
+
+// NewTaskEvent returns a task event of the given type with the current time
+func NewTaskEvent(event string) *TaskEvent {
+	return &TaskEvent{
+		Type: event,
+		Time: time.Now().UnixNano(),
+	}
+}
+
+func (e *TaskEvent) SetFailsTask() *TaskEvent {
+	e.FailsTask = true
+	return e
+}
+
+func (e *TaskEvent) SetDriverError(err error) *TaskEvent {
+	if err != nil {
+		e.DriverError = err.Error()
+	}
+	return e
+}
+
+func (e *TaskEvent) SetExitCode(c int) *TaskEvent {
+	e.ExitCode = c
+	return e
+}
+
+func (e *TaskEvent) SetSignal(s int) *TaskEvent {
+	e.Signal = s
+	return e
+}
+
+func (e *TaskEvent) SetExitMessage(err error) *TaskEvent {
+	if err != nil {
+		e.Message = err.Error()
+	}
+	return e
+}
+
+func (e *TaskEvent) SetKillError(err error) *TaskEvent {
+	if err != nil {
+		e.KillError = err.Error()
+	}
+	return e
+}
+
+func (e *TaskEvent) SetRestartDelay(delay time.Duration) *TaskEvent {
+	e.StartDelay = int64(delay)
+	return e
+}
+
+func (e *TaskEvent) SetDownloadError(err error) *TaskEvent {
+	if err != nil {
+		e.DownloadError = err.Error()
+	}
+	return e
+}
+
+func (e *TaskEvent) SetSetupError(err error) *TaskEvent {
+	if err != nil {
+		e.SetupError = err.Error()
+	}
+	return e
+}
+
 const (
 	AllocDesiredStatusRun    = "run"    // Allocation should run
 	AllocDesiredStatusStop   = "stop"   // Allocation should stop
@@ -1171,6 +2964,26 @@ type Allocation struct {
 	// ClientStatusDescription is meant to provide more human useful information
 	ClientDescription string
 
+	// TaskStates stores the state of each task
+	TaskStates map[string]*TaskState
+
+	// PreviousAllocation is the allocation this allocation replaces. The
+	// data of its ephemeral disk is moved to this allocation if sticky or
+	// migrated.
+	PreviousAllocation string
+
+	// DeploymentID identifies an allocation as being created from a
+	// particular deployment
+	DeploymentID string
+
+	// DeploymentStatus captures the status of the allocation as part of the
+	// given deployment
+	DeploymentStatus *AllocDeploymentStatus
+
+	// DesiredTransition is the transition the servers request of the
+	// allocation, such as a migration off its draining node
+	DesiredTransition DesiredTransition
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -1187,6 +3000,17 @@ func (a *Allocation) TerminalStatus() bool {
 	}
 }
 
+// ClientTerminalStatus returns if the client status is terminal and will no
+// longer transition
+func (a *Allocation) ClientTerminalStatus() bool {
+	switch a.ClientStatus {
+	case AllocClientStatusDead, AllocClientStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
 // Stub returns a list stub for the allocation
 func (a *Allocation) Stub() *AllocListStub {
 	return &AllocListStub{
@@ -1200,6 +3024,7 @@ func (a *Allocation) Stub() *AllocListStub {
 		DesiredDescription: a.DesiredDescription,
 		ClientStatus:       a.ClientStatus,
 		ClientDescription:  a.ClientDescription,
+		TaskStates:         a.TaskStates,
 		CreateIndex:        a.CreateIndex,
 		ModifyIndex:        a.ModifyIndex,
 	}
@@ -1217,6 +3042,7 @@ type AllocListStub struct {
 	DesiredDescription string
 	ClientStatus       string
 	ClientDescription  string
+	TaskStates         map[string]*TaskState
 	CreateIndex        uint64
 	ModifyIndex        uint64
 }
@@ -1306,6 +3132,241 @@ func (a *AllocMetric) ScoreNode(node *Node, name string, score float64) {
 	a.Scores[key] = score
 }
 
+const (
+	DeploymentStatusRunning    = "running"
+	DeploymentStatusFailed     = "failed"
+	DeploymentStatusSuccessful = "successful"
+	DeploymentStatusCancelled  = "cancelled"
+
+	// DeploymentStatusDescriptions are the various descriptions of the states a
+	// deployment can be in.
+	DeploymentStatusDescriptionRunning               = "Deployment is running"
+	DeploymentStatusDescriptionRunningNeedsPromotion = "Deployment is running but requires promotion"
+	DeploymentStatusDescriptionStoppedJob            = "Cancelled because job is stopped"
+	DeploymentStatusDescriptionNewerJob              = "Cancelled due to newer version of job"
+	DeploymentStatusDescriptionFailedByUser          = "Deployment marked as failed"
+	DeploymentStatusDescriptionFailedAllocations     = "Failed due to unhealthy allocations"
+	DeploymentStatusDescriptionSuccessful            = "Deployment completed successfully"
+)
+
+// Deployment is the object that represents a job deployment which is used to
+// transition a job between versions.
+type Deployment struct {
+	// ID is a generated UUID for the deployment
+	ID string
+
+	// JobID is the job the deployment is created for
+	JobID string
+
+	// JobVersion is the version of the job at which the deployment is tracking
+	JobVersion uint64
+
+	// JobModifyIndex is the modify index of the job at which the deployment
+	// is tracking
+	JobModifyIndex uint64
+
+	// JobCreateIndex is the create index of the job which the deployment is
+	// tracking. It is needed so that if the job gets stopped and reran we can
+	// present the correct list of deployments for the job and not old ones.
+	JobCreateIndex uint64
+
+	// TaskGroups is the set of task groups effected by the deployment and their
+	// current deployment status.
+	TaskGroups map[string]*DeploymentState
+
+	// The status of the deployment
+	Status string
+
+	// StatusDescription allows a human readable description of the deployment
+	// status.
+	StatusDescription string
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// NewDeployment creates a new deployment given the job.
+func NewDeployment(job *Job) *Deployment {
+	return &Deployment{
+		ID:                GenerateUUID(),
+		JobID:             job.ID,
+		JobVersion:        job.Version,
+		JobModifyIndex:    job.ModifyIndex,
+		JobCreateIndex:    job.CreateIndex,
+		Status:            DeploymentStatusRunning,
+		StatusDescription: DeploymentStatusDescriptionRunning,
+		TaskGroups:        make(map[string]*DeploymentState, len(job.TaskGroups)),
+	}
+}
+
+// Copy returns a deep copy of the deployment
+func (d *Deployment) Copy() *Deployment {
+	if d == nil {
+		return nil
+	}
+
+	c := &Deployment{}
+	*c = *d
+
+	c.TaskGroups = nil
+	if l := len(d.TaskGroups); d.TaskGroups != nil {
+		c.TaskGroups = make(map[string]*DeploymentState, l)
+		for tg, s := range d.TaskGroups {
+			c.TaskGroups[tg] = s.Copy()
+		}
+	}
+
+	return c
+}
+
+// Active returns whether the deployment is active or terminal.
+func (d *Deployment) Active() bool {
+	switch d.Status {
+	case DeploymentStatusRunning:
+		return true
+	default:
+		return false
+	}
+}
+
+// RequiresPromotion returns whether the deployment requires promotion to
+// continue
+func (d *Deployment) RequiresPromotion() bool {
+	if d == nil || len(d.TaskGroups) == 0 || d.Status != DeploymentStatusRunning {
+		return false
+	}
+	for _, group := range d.TaskGroups {
+		if group.DesiredCanaries > 0 && !group.Promoted {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Deployment) GoString() string {
+	base := fmt.Sprintf("Deployment ID %q for job %q has status %q (%v):", d.ID, d.JobID, d.Status, d.StatusDescription)
+	for group, state := range d.TaskGroups {
+		base += fmt.Sprintf("\nTask Group %q has state:\n%#v", group, state)
+	}
+	return base
+}
+
+// DeploymentState tracks the state of a deployment for a given task group.
+type DeploymentState struct {
+	// Promoted marks whether the canaries have been promoted
+	Promoted bool
+
+	// DesiredCanaries is the number of canaries that should be created.
+	DesiredCanaries int
+
+	// DesiredTotal is the total number of allocations that should be created as
+	// part of the deployment.
+	DesiredTotal int
+
+	// PlacedCanaries is the set of placed canary allocations
+	PlacedCanaries []string
+
+	// PlacedAllocs is the number of allocations that have been placed
+	PlacedAllocs int
+
+	// HealthyAllocs is the number of allocations that have been reported
+	// healthy by their clients
+	HealthyAllocs int
+
+	// UnhealthyAllocs is the number of allocations that have been reported
+	// unhealthy by their clients
+	UnhealthyAllocs int
+
+	// AutoRevert marks whether the job should be reverted to its last
+	// stable version if the deployment fails.
+	AutoRevert bool
+}
+
+func (d *DeploymentState) GoString() string {
+	base := fmt.Sprintf("\tDesired Total: %d", d.DesiredTotal)
+	base += fmt.Sprintf("\n\tDesired Canaries: %d", d.DesiredCanaries)
+	base += fmt.Sprintf("\n\tPlaced Canaries: %#v", d.PlacedCanaries)
+	base += fmt.Sprintf("\n\tPromoted: %v", d.Promoted)
+	base += fmt.Sprintf("\n\tPlaced: %d", d.PlacedAllocs)
+	base += fmt.Sprintf("\n\tHealthy: %d", d.HealthyAllocs)
+	base += fmt.Sprintf("\n\tUnhealthy: %d", d.UnhealthyAllocs)
+	base += fmt.Sprintf("\n\tAutoRevert: %v", d.AutoRevert)
+	return base
+}
+
+// Copy returns a deep copy of the deployment state
+func (d *DeploymentState) Copy() *DeploymentState {
+	c := &DeploymentState{}
+	*c = *d
+	c.PlacedCanaries = copyStringSlice(d.PlacedCanaries)
+	return c
+}
+
+// DeploymentStatusUpdate is used to update the status of a given deployment
+type DeploymentStatusUpdate struct {
+	// DeploymentID is the ID of the deployment to update
+	DeploymentID string
+
+	// Status is the new status of the deployment.
+	Status string
+
+	// StatusDescription is the new status description of the deployment.
+	StatusDescription string
+}
+
+// DesiredTransition is the transition the servers request of an allocation
+// that the schedulers carry out
+type DesiredTransition struct {
+	// Migrate marks the allocation to be migrated off its node
+	Migrate bool
+}
+
+// AllocDeploymentStatus captures the status of the allocation as part of the
+// deployment.
+type AllocDeploymentStatus struct {
+	// Canary marks whether the allocation was placed as a canary of the
+	// deployment.
+	Canary bool
+
+	// Healthy marks whether the allocation has been reported healthy by the
+	// client. It is nil until the client has determined the health.
+	Healthy *bool
+}
+
+// IsCanary returns whether the allocation was placed as a canary
+func (a *AllocDeploymentStatus) IsCanary() bool {
+	return a != nil && a.Canary
+}
+
+// HasHealth returns whether the client has reported the allocation's health
+func (a *AllocDeploymentStatus) HasHealth() bool {
+	return a != nil && a.Healthy != nil
+}
+
+// IsHealthy returns whether the allocation has been reported healthy
+func (a *AllocDeploymentStatus) IsHealthy() bool {
+	return a.HasHealth() && *a.Healthy
+}
+
+// IsUnhealthy returns whether the allocation has been reported unhealthy
+func (a *AllocDeploymentStatus) IsUnhealthy() bool {
+	return a.HasHealth() && !*a.Healthy
+}
+
+// Copy returns a copy of the deployment status
+func (a *AllocDeploymentStatus) Copy() *AllocDeploymentStatus {
+	if a == nil {
+		return nil
+	}
+	c := new(AllocDeploymentStatus)
+	*c = *a
+	if a.Healthy != nil {
+		healthy := *a.Healthy
+		c.Healthy = &healthy
+	}
+	return c
+}
+
 const (
 	EvalStatusPending  = "pending"
 	EvalStatusComplete = "complete"
@@ -1318,6 +3379,9 @@ const (
 	EvalTriggerNodeUpdate    = "node-update"
 	EvalTriggerScheduled     = "scheduled"
 	EvalTriggerRollingUpdate = "rolling-update"
+	EvalTriggerPeriodicJob   = "periodic-job"
+	EvalTriggerDeployment    = "deployment-update"
+	EvalTriggerNodeDrain     = "node-drain"
 )
 
 const (
@@ -1331,6 +3395,15 @@ const (
 	// We periodically scan nodes in a terminal state, and if they have no
 	// corresponding allocations we delete these out of the system.
 	CoreJobNodeGC = "node-gc"
+
+	// CoreJobJobGC is used for the garbage collection of eligible jobs. We
+	// periodically scan garbage collectible jobs and check if both their
+	// evaluations and allocations are terminal. If so, we delete these out of
+	// the system.
+	CoreJobJobGC = "job-gc"
+
+	// CoreJobForceGC is used to force garbage collection of all GCable objects.
+	CoreJobForceGC = "force-gc"
 )
 
 // Evaluation is used anytime we need to apply business logic as a result
@@ -1388,6 +3461,10 @@ type Evaluation struct {
 	// This is used to support rolling upgrades, where we need a chain of evaluations.
 	PreviousEval string
 
+	// AnnotatePlan triggers the scheduler to provide additional annotations
+	// during the evaluation. This should not be set during normal operations.
+	AnnotatePlan bool
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -1491,6 +3568,19 @@ type Plan struct {
 	// but are persisted so that the user can use the feedback
 	// to determine the cause.
 	FailedAllocs []*Allocation
+
+	// Annotations contains annotations by the scheduler to be used by operators
+	// to understand the decisions made by the scheduler.
+	Annotations *PlanAnnotations
+
+	// Deployment is the deployment created or updated by the scheduler that
+	// should be applied by the planner.
+	Deployment *Deployment
+
+	// DeploymentUpdates is a set of status updates to apply to the given
+	// deployments. This allows the scheduler to cancel any unneeded deployment
+	// because the job is stopped or the update block is removed.
+	DeploymentUpdates []*DeploymentStatusUpdate
 }
 
 func (p *Plan) AppendUpdate(alloc *Allocation, status, desc string) {
@@ -1528,7 +3618,27 @@ func (p *Plan) AppendFailed(alloc *Allocation) {
 
 // IsNoOp checks if this plan would do nothing
 func (p *Plan) IsNoOp() bool {
-	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 && len(p.FailedAllocs) == 0
+	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 && len(p.FailedAllocs) == 0 &&
+		p.Deployment == nil && len(p.DeploymentUpdates) == 0
+}
+
+// PlanAnnotations holds annotations made by the scheduler to give further debug
+// information to operators.
+type PlanAnnotations struct {
+	// DesiredTGUpdates is the set of desired updates per task group.
+	DesiredTGUpdates map[string]*DesiredUpdates
+}
+
+// DesiredUpdates is the set of changes the scheduler would like to make given
+// sufficient resources and cluster capacity.
+type DesiredUpdates struct {
+	Ignore            uint64
+	Place             uint64
+	Migrate           uint64
+	Stop              uint64
+	InPlaceUpdate     uint64
+	DestructiveUpdate uint64
+	Canary            uint64
 }
 
 // PlanResult is the result of a plan submitted to the leader.
@@ -1553,11 +3663,18 @@ type PlanResult struct {
 	// AllocIndex is the Raft index in which the evictions and
 	// allocations took place. This is used for the write index.
 	AllocIndex uint64
+
+	// Deployment is the deployment that was committed.
+	Deployment *Deployment
+
+	// DeploymentUpdates is the set of deployment updates that were committed.
+	DeploymentUpdates []*DeploymentStatusUpdate
 }
 
 // IsNoOp checks if this plan result would do nothing
 func (p *PlanResult) IsNoOp() bool {
-	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 && len(p.FailedAllocs) == 0
+	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 && len(p.FailedAllocs) == 0 &&
+		len(p.DeploymentUpdates) == 0 && p.Deployment == nil
 }
 
 // FullCommit is used to check if all the allocations in a plan
@@ -80,6 +80,221 @@ func TestJob_Validate(t *testing.T) {
 	}
 }
 
+func TestJob_Copy(t *testing.T) {
+	j := &Job{
+		Region:      "global",
+		ID:          GenerateUUID(),
+		Name:        "my-job",
+		Type:        JobTypeBatch,
+		Priority:    50,
+		Datacenters: []string{"dc1"},
+		Constraints: []*Constraint{
+			&Constraint{
+				LTarget: "$attr.kernel.name",
+				RTarget: "linux",
+				Operand: "=",
+			},
+		},
+		TaskGroups: []*TaskGroup{
+			&TaskGroup{
+				Name:  "web",
+				Count: 10,
+				RestartPolicy: &RestartPolicy{
+					Interval: 5 * time.Minute,
+					Delay:    10 * time.Second,
+					Attempts: 10,
+				},
+				Tasks: []*Task{
+					&Task{
+						Name:   "web",
+						Driver: "exec",
+						Config: map[string]string{
+							"command": "/bin/date",
+						},
+						Env: map[string]string{
+							"FOO": "bar",
+						},
+						Resources: &Resources{
+							CPU:      500,
+							MemoryMB: 256,
+							Networks: []*NetworkResource{
+								&NetworkResource{
+									MBits:        50,
+									DynamicPorts: []string{"http"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Periodic: &PeriodicConfig{
+			Enabled:  true,
+			SpecType: PeriodicSpecCron,
+			Spec:     "*/15 * * * *",
+		},
+		Meta: map[string]string{
+			"owner": "armon",
+		},
+	}
+
+	c := j.Copy()
+	if !reflect.DeepEqual(j, c) {
+		t.Fatalf("Copy() returned an unequal Job; got %#v; want %#v", c, j)
+	}
+
+	// Mutating the copy must not affect the original
+	c.Datacenters[0] = "dc2"
+	c.Meta["owner"] = "foo"
+	c.TaskGroups[0].Tasks[0].Env["FOO"] = "baz"
+	c.TaskGroups[0].Tasks[0].Resources.CPU = 100
+	c.Periodic.Spec = "* * * * *"
+	if j.Datacenters[0] != "dc1" || j.Meta["owner"] != "armon" {
+		t.Fatalf("copy shares job fields with the original: %#v", j)
+	}
+	if j.TaskGroups[0].Tasks[0].Env["FOO"] != "bar" || j.TaskGroups[0].Tasks[0].Resources.CPU != 500 {
+		t.Fatalf("copy shares task fields with the original: %#v", j.TaskGroups[0].Tasks[0])
+	}
+	if j.Periodic.Spec != "*/15 * * * *" {
+		t.Fatalf("copy shares periodic config with the original: %#v", j.Periodic)
+	}
+}
+
+func TestJob_Validate_Periodic(t *testing.T) {
+	j := &Job{
+		Type: JobTypeService,
+		Periodic: &PeriodicConfig{
+			Enabled:  true,
+			SpecType: PeriodicSpecCron,
+			Spec:     "*/15 * * * *",
+		},
+	}
+	err := j.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Periodic") {
+		t.Fatalf("expected periodic service job to fail validation: %v", err)
+	}
+}
+
+func TestJob_Validate_Canary(t *testing.T) {
+	j := &Job{
+		Type:   JobTypeBatch,
+		Update: UpdateStrategy{Canary: 1},
+	}
+	err := j.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Canaries") {
+		t.Fatalf("expected batch job with canaries to fail validation: %v", err)
+	}
+
+	j.Type = JobTypeService
+	j.Update.Canary = -1
+	err = j.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Canary count") {
+		t.Fatalf("expected negative canary count to fail validation: %v", err)
+	}
+}
+
+func TestJob_Validate_UpdateHealth(t *testing.T) {
+	j := &Job{
+		Type: JobTypeBatch,
+		Update: UpdateStrategy{
+			MinHealthyTime:  10 * time.Second,
+			HealthyDeadline: 5 * time.Second,
+			AutoRevert:      true,
+		},
+	}
+	err := j.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Auto revert") {
+		t.Fatalf("expected batch job with auto revert to fail validation: %v", err)
+	}
+	if !strings.Contains(err.Error(), "Healthy deadline") {
+		t.Fatalf("expected healthy deadline below min healthy time to fail validation: %v", err)
+	}
+
+	j.Type = JobTypeService
+	j.Update.MinHealthyTime = -1
+	j.Update.HealthyDeadline = 0
+	err = j.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Minimum healthy time") {
+		t.Fatalf("expected negative min healthy time to fail validation: %v", err)
+	}
+}
+
+func TestPeriodicConfig_EnabledInvalid(t *testing.T) {
+	// Create a config that is enabled but with no interval specified.
+	p := &PeriodicConfig{Enabled: true}
+	if err := p.Validate(); err == nil {
+		t.Fatal("Enabled PeriodicConfig with no spec or type shouldn't be valid")
+	}
+
+	// Create a config that is enabled, with a spec but no type specified.
+	p = &PeriodicConfig{Enabled: true, Spec: "foo"}
+	if err := p.Validate(); err == nil {
+		t.Fatal("Enabled PeriodicConfig with no spec type shouldn't be valid")
+	}
+
+	// Create a config that is enabled, with a spec type but no spec specified.
+	p = &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron}
+	if err := p.Validate(); err == nil {
+		t.Fatal("Enabled PeriodicConfig with no spec shouldn't be valid")
+	}
+
+	// Create a config that is enabled, with a bad time zone.
+	p = &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Spec: "* * * * *", TimeZone: "FOO"}
+	if err := p.Validate(); err == nil {
+		t.Fatal("Enabled PeriodicConfig with bad time zone shouldn't be valid")
+	}
+}
+
+func TestPeriodicConfig_InvalidCron(t *testing.T) {
+	specs := []string{"foo", "* *", "@foo"}
+	for _, spec := range specs {
+		p := &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Spec: spec}
+		if err := p.Validate(); err == nil {
+			t.Fatalf("Invalid cron spec %q should fail validation", spec)
+		}
+	}
+}
+
+func TestPeriodicConfig_ValidCron(t *testing.T) {
+	specs := []string{"0 0 29 2 *", "@hourly", "0 0-15 * * *"}
+	for _, spec := range specs {
+		p := &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Spec: spec}
+		if err := p.Validate(); err != nil {
+			t.Fatalf("Passed valid cron %q: %v", spec, err)
+		}
+	}
+}
+
+func TestPeriodicConfig_NextCron(t *testing.T) {
+	from := time.Date(2009, time.November, 10, 23, 22, 30, 0, time.UTC)
+	specs := []string{"0 0 29 2 * 1980", "*/5 * * * *"}
+	expected := []time.Time{time.Time{}, time.Date(2009, time.November, 10, 23, 25, 0, 0, time.UTC)}
+	for i, spec := range specs {
+		p := &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Spec: spec}
+		n := p.Next(from)
+		if !expected[i].Equal(n) {
+			t.Fatalf("Next(%v) returned %v; want %v", from, n, expected[i])
+		}
+	}
+}
+
+func TestPeriodicConfig_NextCron_TimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	from := time.Date(2009, time.November, 10, 23, 22, 30, 0, time.UTC)
+	p := &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Spec: "0 12 * * *", TimeZone: "America/New_York"}
+	n := p.Next(from)
+
+	// 23:22 UTC is 18:22 in New York so the next noon there is the next day.
+	expected := time.Date(2009, time.November, 11, 12, 0, 0, 0, loc)
+	if !expected.Equal(n) {
+		t.Fatalf("Next(%v) returned %v; want %v", from, n, expected)
+	}
+}
+
 func TestTaskGroup_Validate(t *testing.T) {
 	tg := &TaskGroup{
 		RestartPolicy: &RestartPolicy{
@@ -125,6 +340,158 @@ func TestTaskGroup_Validate(t *testing.T) {
 	if !strings.Contains(mErr.Errors[2].Error(), "Task 1 validation failed") {
 		t.Fatalf("err: %s", err)
 	}
+
+	// A task group needs a main task
+	tg.Tasks = []*Task{
+		&Task{
+			Name:      "init",
+			Lifecycle: &TaskLifecycleConfig{Hook: TaskLifecycleHookPrestart},
+		},
+	}
+	err = tg.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "task without a lifecycle") {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The ephemeral disk must have a minimum size
+	tg.EphemeralDisk = &EphemeralDisk{SizeMB: 1}
+	err = tg.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "Ephemeral disk size") {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Volumes must be valid and tasks may only mount those of the group
+	tg.EphemeralDisk = nil
+	tg.Volumes = map[string]*VolumeRequest{
+		"data": &VolumeRequest{Name: "data", Type: "csi", Source: "data"},
+	}
+	tg.Tasks[0].VolumeMounts = []*VolumeMount{
+		&VolumeMount{Volume: "cache", Destination: "/cache"},
+	}
+	err = tg.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "Unsupported volume type") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "mounts unknown volume \"cache\"") {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestVolumeMount_Validate(t *testing.T) {
+	valid := []*VolumeMount{
+		&VolumeMount{Volume: "data", Destination: "/srv/data"},
+		&VolumeMount{Volume: "data", Destination: "local/data", ReadOnly: true},
+	}
+	for i, m := range valid {
+		if err := m.Validate(); err != nil {
+			t.Fatalf("case %d: err: %v", i, err)
+		}
+	}
+
+	invalid := []*VolumeMount{
+		&VolumeMount{Destination: "/srv/data"},
+		&VolumeMount{Volume: "data"},
+		&VolumeMount{Volume: "data", Destination: "/"},
+		&VolumeMount{Volume: "data", Destination: "../../etc"},
+	}
+	for i, m := range invalid {
+		if err := m.Validate(); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestMigrateStrategy_Validate(t *testing.T) {
+	valid := []*MigrateStrategy{
+		DefaultMigrateStrategy(),
+		&MigrateStrategy{HealthCheck: MigrateHealthCheckNone},
+		&MigrateStrategy{MaxParallel: 3, HealthCheck: MigrateHealthCheckTaskStates, MinHealthyTime: time.Minute},
+	}
+	for i, m := range valid {
+		if err := m.Validate(); err != nil {
+			t.Fatalf("case %d: err: %v", i, err)
+		}
+	}
+
+	invalid := []*MigrateStrategy{
+		&MigrateStrategy{},
+		&MigrateStrategy{MaxParallel: -1, HealthCheck: MigrateHealthCheckNone},
+		&MigrateStrategy{HealthCheck: "checks"},
+		&MigrateStrategy{HealthCheck: MigrateHealthCheckNone, MinHealthyTime: -time.Second},
+		&MigrateStrategy{HealthCheck: MigrateHealthCheckNone, MinHealthyTime: time.Minute, HealthyDeadline: time.Second},
+	}
+	for i, m := range invalid {
+		if err := m.Validate(); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestNewDrainStrategy(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		Deadline time.Duration
+		Has      bool
+		Force    time.Time
+	}{
+		{0, false, time.Time{}},
+		{-1, true, now},
+		{time.Hour, true, now.Add(time.Hour)},
+	}
+	for i, c := range cases {
+		d := NewDrainStrategy(DrainSpec{Deadline: c.Deadline}, now)
+		has, force := d.DeadlineTime()
+		if has != c.Has || !force.Equal(c.Force) {
+			t.Fatalf("case %d: got %v %v; want %v %v", i, has, force, c.Has, c.Force)
+		}
+	}
+}
+
+func TestTaskLifecycleConfig_Validate(t *testing.T) {
+	valid := []*TaskLifecycleConfig{
+		&TaskLifecycleConfig{Hook: TaskLifecycleHookPrestart},
+		&TaskLifecycleConfig{Hook: TaskLifecycleHookPrestart, Sidecar: true},
+		&TaskLifecycleConfig{Hook: TaskLifecycleHookPoststart, Sidecar: true},
+		&TaskLifecycleConfig{Hook: TaskLifecycleHookPoststop},
+	}
+	for i, l := range valid {
+		if err := l.Validate(); err != nil {
+			t.Fatalf("case %d: err: %v", i, err)
+		}
+	}
+
+	invalid := []*TaskLifecycleConfig{
+		&TaskLifecycleConfig{},
+		&TaskLifecycleConfig{Hook: "prerun"},
+		&TaskLifecycleConfig{Hook: TaskLifecycleHookPoststop, Sidecar: true},
+	}
+	for i, l := range invalid {
+		if err := l.Validate(); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestLogConfig_Validate(t *testing.T) {
+	if err := DefaultLogConfig().Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l := &LogConfig{MaxFiles: 0, MaxFileSizeMB: -1}
+	err := l.Validate()
+	mErr := err.(*multierror.Error)
+	if len(mErr.Errors) != 2 {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[0].Error(), "number of log files") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "log file size") {
+		t.Fatalf("err: %s", err)
+	}
 }
 
 func TestTask_Validate(t *testing.T) {
@@ -152,6 +519,66 @@ func TestTask_Validate(t *testing.T) {
 	}
 }
 
+func TestTask_Validate_Services(t *testing.T) {
+	task := &Task{
+		Name:      "web",
+		Driver:    "docker",
+		Resources: &Resources{},
+		Services: []*Service{
+			&Service{
+				Name:      "frontend",
+				PortLabel: "http",
+				Checks: []*ServiceCheck{
+					&ServiceCheck{
+						Name:     "alive",
+						Type:     ServiceCheckHTTP,
+						Path:     "/health",
+						Interval: 10 * time.Second,
+					},
+				},
+			},
+		},
+	}
+	if err := task.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Services must be named uniquely
+	task.Services = append(task.Services, &Service{Name: "frontend"})
+	err := task.Validate()
+	if err == nil || !strings.Contains(err.Error(), "redefines 'frontend'") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Checks must be valid and have a port to check
+	task.Services = []*Service{
+		&Service{
+			Name: "frontend",
+			Checks: []*ServiceCheck{
+				&ServiceCheck{
+					Name:     "alive",
+					Type:     ServiceCheckTCP,
+					Interval: 10 * time.Second,
+				},
+				&ServiceCheck{
+					Name: "alive",
+					Type: "grpc",
+				},
+			},
+		},
+	}
+	err = task.Validate()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, msg := range []string{"requires the service to have a port", "redefines 'alive'",
+		"check type must be one of", "check interval must be positive"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("expected %q: %v", msg, err)
+		}
+	}
+}
+
 func TestConstraint_Validate(t *testing.T) {
 	c := &Constraint{}
 	err := c.Validate()
@@ -189,6 +616,68 @@ func TestConstraint_Validate(t *testing.T) {
 	}
 }
 
+func TestSpread_Validate(t *testing.T) {
+	valid := []*Spread{
+		&Spread{Attribute: "$node.datacenter", Weight: 50},
+		&Spread{
+			Attribute: "$meta.rack",
+			Weight:    100,
+			Targets: []*SpreadTarget{
+				&SpreadTarget{Value: "r1", Percent: 60},
+				&SpreadTarget{Value: "r2", Percent: 40},
+			},
+		},
+	}
+	for i, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Fatalf("case %d: err: %v", i, err)
+		}
+	}
+
+	invalid := []*Spread{
+		&Spread{Weight: 50},
+		&Spread{Attribute: "$node.datacenter"},
+		&Spread{Attribute: "$node.datacenter", Weight: 101},
+		&Spread{
+			Attribute: "$meta.rack",
+			Weight:    50,
+			Targets:   []*SpreadTarget{&SpreadTarget{Percent: 50}},
+		},
+		&Spread{
+			Attribute: "$meta.rack",
+			Weight:    50,
+			Targets: []*SpreadTarget{
+				&SpreadTarget{Value: "r1", Percent: 20},
+				&SpreadTarget{Value: "r1", Percent: 20},
+			},
+		},
+		&Spread{
+			Attribute: "$meta.rack",
+			Weight:    50,
+			Targets: []*SpreadTarget{
+				&SpreadTarget{Value: "r1", Percent: 70},
+				&SpreadTarget{Value: "r2", Percent: 40},
+			},
+		},
+	}
+	for i, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestJob_Validate_SystemSpread(t *testing.T) {
+	j := &Job{
+		Type:    JobTypeSystem,
+		Spreads: []*Spread{&Spread{Attribute: "$node.datacenter", Weight: 50}},
+	}
+	err := j.Validate()
+	if err == nil || !strings.Contains(err.Error(), "spread stanza") {
+		t.Fatalf("expected spread error, got: %v", err)
+	}
+}
+
 func TestResource_NetIndex(t *testing.T) {
 	r := &Resources{
 		Networks: []*NetworkResource{
@@ -474,3 +963,183 @@ func TestEncodeDecode(t *testing.T) {
 		t.Fatalf("bad: %#v %#v", arg, out)
 	}
 }
+
+func TestJob_Validate_Parameterized(t *testing.T) {
+	j := &Job{
+		Type: JobTypeService,
+		ParameterizedJob: &ParameterizedJobConfig{
+			Payload: DispatchPayloadOptional,
+		},
+		Periodic: &PeriodicConfig{
+			Enabled:  true,
+			SpecType: PeriodicSpecCron,
+			Spec:     "*/15 * * * *",
+		},
+	}
+	err := j.Validate()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(err.Error(), "Parameterized job can only be used") {
+		t.Fatalf("missing scheduler error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "can't be periodic") {
+		t.Fatalf("missing periodic error: %v", err)
+	}
+}
+
+func TestParameterizedJobConfig_Validate(t *testing.T) {
+	d := &ParameterizedJobConfig{
+		Payload: "foo",
+	}
+
+	if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "payload") {
+		t.Fatalf("Expected unknown payload requirement: %v", err)
+	}
+
+	d.Payload = DispatchPayloadOptional
+	d.MetaOptional = []string{"foo", "bar"}
+	d.MetaRequired = []string{"bar", "baz"}
+
+	if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "both required and optional") {
+		t.Fatalf("Expected meta not being disjoint error: %v", err)
+	}
+
+	d.MetaRequired = []string{"baz"}
+	if err := d.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestDispatchPayloadConfig_Validate(t *testing.T) {
+	d := &DispatchPayloadConfig{
+		File: "foo",
+	}
+
+	// task/local/haha
+	if err := d.Validate(); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// task/haha
+	d.File = "../haha"
+	if err := d.Validate(); err == nil {
+		t.Fatalf("bad: %v", d.File)
+	}
+
+	// ../haha
+	d.File = "../../../haha"
+	if err := d.Validate(); err == nil {
+		t.Fatalf("bad: %v", d.File)
+	}
+
+	// /etc/passwd
+	d.File = "/etc/passwd"
+	if err := d.Validate(); err == nil {
+		t.Fatalf("bad: %v", d.File)
+	}
+}
+
+func TestTaskArtifact_Validate(t *testing.T) {
+	a := &TaskArtifact{}
+	if err := a.Validate(); err == nil || !strings.Contains(err.Error(), "Missing artifact source") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// task/local
+	a.GetterSource = "http://foo.com/bar"
+	if err := a.Validate(); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// task/local/foo
+	a.RelativeDest = "local/foo"
+	if err := a.Validate(); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// ../foo
+	a.RelativeDest = "../foo"
+	if err := a.Validate(); err == nil {
+		t.Fatalf("bad: %v", a.RelativeDest)
+	}
+
+	// /etc
+	a.RelativeDest = "/etc"
+	if err := a.Validate(); err == nil {
+		t.Fatalf("bad: %v", a.RelativeDest)
+	}
+}
+
+func TestTemplate_Validate(t *testing.T) {
+	tmpl := &Template{}
+	err := tmpl.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "source path or have an embedded template") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "destination path") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[2].Error(), "Change mode") {
+		t.Fatalf("err: %s", err)
+	}
+
+	tmpl = &Template{
+		EmbeddedTmpl: "{{ env \"NOMAD_IP\" }}",
+		DestPath:     "local/ip",
+		ChangeMode:   TemplateChangeModeRestart,
+	}
+	if err := tmpl.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Both sources
+	tmpl.SourcePath = "local/ip.tpl"
+	if err := tmpl.Validate(); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Destination escapes the task directory
+	tmpl.SourcePath = ""
+	tmpl.DestPath = "../../ip"
+	if err := tmpl.Validate(); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Signal without a signal
+	tmpl.DestPath = "local/ip"
+	tmpl.ChangeMode = TemplateChangeModeSignal
+	if err := tmpl.Validate(); err == nil || !strings.Contains(err.Error(), "signal") {
+		t.Fatalf("err: %v", err)
+	}
+	tmpl.ChangeSignal = "SIGHUP"
+	if err := tmpl.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestTaskState_AppendEvent(t *testing.T) {
+	state := &TaskState{State: TaskStatePending}
+	for i := 0; i < maxTaskEvents+5; i++ {
+		state.AppendEvent(NewTaskEvent(TaskRestarting).SetExitCode(i))
+	}
+
+	if len(state.Events) != maxTaskEvents {
+		t.Fatalf("bad: %d", len(state.Events))
+	}
+
+	// Only the most recent events are kept
+	if state.Events[0].ExitCode != 5 || state.Events[maxTaskEvents-1].ExitCode != maxTaskEvents+4 {
+		t.Fatalf("bad: %#v", state.Events)
+	}
+
+	copy := state.Copy()
+	if !reflect.DeepEqual(state, copy) {
+		t.Fatalf("bad: %#v %#v", state, copy)
+	}
+	copy.Events[0].ExitCode = 100
+	if state.Events[0].ExitCode == 100 {
+		t.Fatalf("copy shares events")
+	}
+}
@@ -0,0 +1,26 @@
+package nomad
+
+import (
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// System endpoint is used to invoke system tasks
+type System struct {
+	srv *Server
+}
+
+// GarbageCollect is used to trigger the system to immediately garbage collect
+// all eligible jobs, evaluations, allocations and nodes regardless of their
+// age
+func (s *System) GarbageCollect(args *structs.GenericRequest, reply *structs.GenericResponse) error {
+	if done, err := s.srv.forward("System.GarbageCollect", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "system", "garbage_collect"}, time.Now())
+
+	s.srv.evalBroker.Enqueue(s.srv.coreJobEval(structs.CoreJobForceGC))
+	return nil
+}
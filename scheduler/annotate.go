@@ -0,0 +1,241 @@
+package scheduler
+
+import (
+	"strconv"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	AnnotationForcesCreate            = "forces create"
+	AnnotationForcesDestroy           = "forces destroy"
+	AnnotationForcesInplaceUpdate     = "forces in-place update"
+	AnnotationForcesDestructiveUpdate = "forces create/destroy update"
+)
+
+// UpdateTypes denote the type of update to occur against the task group.
+const (
+	UpdateTypeIgnore            = "ignore"
+	UpdateTypeCreate            = "create"
+	UpdateTypeDestroy           = "destroy"
+	UpdateTypeMigrate           = "migrate"
+	UpdateTypeInplaceUpdate     = "in-place update"
+	UpdateTypeDestructiveUpdate = "create/destroy update"
+	UpdateTypeCanary            = "canary"
+)
+
+// Annotate takes the diff between the old and new version of a Job, the
+// scheduler's plan annotations and will add annotations to the diff to aid
+// human understanding of the plan. Task groups are annotated with the count
+// changes and the number of each type of update, and tasks are annotated with
+// whether their changes force an in-place or a create/destroy update.
+func Annotate(diff *structs.JobDiff, annotations *structs.PlanAnnotations) error {
+	tgDiffs := diff.TaskGroups
+	if len(tgDiffs) == 0 {
+		return nil
+	}
+
+	for _, tgDiff := range tgDiffs {
+		if err := annotateTaskGroup(tgDiff, annotations); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// annotateTaskGroup takes a task group diff and annotates it.
+func annotateTaskGroup(diff *structs.TaskGroupDiff, annotations *structs.PlanAnnotations) error {
+	// Annotate with the updates
+	if annotations != nil {
+		tg, ok := annotations.DesiredTGUpdates[diff.Name]
+		if ok {
+			if diff.Updates == nil {
+				diff.Updates = make(map[string]uint64, 7)
+			}
+
+			if tg.Ignore != 0 {
+				diff.Updates[UpdateTypeIgnore] = tg.Ignore
+			}
+			if tg.Place != 0 {
+				diff.Updates[UpdateTypeCreate] = tg.Place
+			}
+			if tg.Migrate != 0 {
+				diff.Updates[UpdateTypeMigrate] = tg.Migrate
+			}
+			if tg.Stop != 0 {
+				diff.Updates[UpdateTypeDestroy] = tg.Stop
+			}
+			if tg.InPlaceUpdate != 0 {
+				diff.Updates[UpdateTypeInplaceUpdate] = tg.InPlaceUpdate
+			}
+			if tg.DestructiveUpdate != 0 {
+				diff.Updates[UpdateTypeDestructiveUpdate] = tg.DestructiveUpdate
+			}
+			if tg.Canary != 0 {
+				diff.Updates[UpdateTypeCanary] = tg.Canary
+			}
+		}
+	}
+
+	// Annotate the count
+	if err := annotateCountChange(diff); err != nil {
+		return err
+	}
+
+	// Annotate the tasks.
+	taskDiffs := diff.Tasks
+	if len(taskDiffs) == 0 {
+		return nil
+	}
+
+	for _, taskDiff := range taskDiffs {
+		annotateTask(taskDiff, diff)
+	}
+
+	return nil
+}
+
+// annotateCountChange takes a task group diff and annotates the count
+// parameter.
+func annotateCountChange(diff *structs.TaskGroupDiff) error {
+	var countDiff *structs.FieldDiff
+	for _, diff := range diff.Fields {
+		if diff.Name == "Count" {
+			countDiff = diff
+			break
+		}
+	}
+
+	// Didn't find
+	if countDiff == nil {
+		return nil
+	}
+	var oldV, newV int
+	var err error
+	if countDiff.Old == "" {
+		oldV = 0
+	} else {
+		oldV, err = strconv.Atoi(countDiff.Old)
+		if err != nil {
+			return err
+		}
+	}
+
+	if countDiff.New == "" {
+		newV = 0
+	} else {
+		newV, err = strconv.Atoi(countDiff.New)
+		if err != nil {
+			return err
+		}
+	}
+
+	if oldV < newV {
+		countDiff.Annotations = append(countDiff.Annotations, AnnotationForcesCreate)
+	} else if newV < oldV {
+		countDiff.Annotations = append(countDiff.Annotations, AnnotationForcesDestroy)
+	}
+
+	return nil
+}
+
+// annotateTask takes a task diff and annotates it. The changes that force a
+// create/destroy update mirror those checked by tasksUpdated.
+func annotateTask(diff *structs.TaskDiff, parent *structs.TaskGroupDiff) {
+	if diff.Type == structs.DiffTypeNone {
+		return
+	}
+
+	// The whole task group is changing
+	if parent.Type == structs.DiffTypeAdded || parent.Type == structs.DiffTypeDeleted {
+		if diff.Type == structs.DiffTypeAdded {
+			diff.Annotations = append(diff.Annotations, AnnotationForcesCreate)
+			return
+		} else if diff.Type == structs.DiffTypeDeleted {
+			diff.Annotations = append(diff.Annotations, AnnotationForcesDestroy)
+			return
+		}
+	}
+
+	// All changes to primitive fields result in a destructive update except
+	// those to fields that are not inspected by tasksUpdated.
+	destructive := false
+	for _, fDiff := range diff.Fields {
+		if fDiff.Type == structs.DiffTypeNone {
+			continue
+		}
+		if fDiff.Name == "Driver" {
+			destructive = true
+			break
+		}
+	}
+
+	// Object changes that can be done in-place are meta, constraints and
+	// resource changes other than the networks.
+	if !destructive {
+		for _, oDiff := range diff.Objects {
+			if oDiff.Type == structs.DiffTypeNone {
+				continue
+			}
+
+			switch oDiff.Name {
+			case "Config", "Env":
+				destructive = true
+			case "Resources":
+				for _, nDiff := range oDiff.Objects {
+					if nDiff.Name == "Network" && networkUpdated(nDiff) {
+						destructive = true
+					}
+				}
+			}
+			if destructive {
+				break
+			}
+		}
+	}
+
+	// Tasks being added or removed from an existing group always force a
+	// destructive update of the group.
+	if diff.Type == structs.DiffTypeAdded || diff.Type == structs.DiffTypeDeleted {
+		destructive = true
+	}
+
+	if destructive {
+		diff.Annotations = append(diff.Annotations, AnnotationForcesDestructiveUpdate)
+	} else {
+		diff.Annotations = append(diff.Annotations, AnnotationForcesInplaceUpdate)
+	}
+}
+
+// networkUpdated returns whether the network diff changes the number of
+// networks or dynamic ports, which can not be updated in-place.
+func networkUpdated(diff *structs.ObjectDiff) bool {
+	switch diff.Type {
+	case structs.DiffTypeAdded, structs.DiffTypeDeleted:
+		return true
+	case structs.DiffTypeNone:
+		return false
+	}
+
+	for _, pDiff := range diff.Objects {
+		if pDiff.Name != "DynamicPorts" {
+			continue
+		}
+
+		added, deleted := 0, 0
+		for _, fDiff := range pDiff.Fields {
+			switch fDiff.Type {
+			case structs.DiffTypeAdded:
+				added++
+			case structs.DiffTypeDeleted:
+				deleted++
+			}
+		}
+		if added != deleted {
+			return true
+		}
+	}
+
+	return false
+}
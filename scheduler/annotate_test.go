@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestAnnotateTaskGroup_Updates(t *testing.T) {
+	annotations := &structs.PlanAnnotations{
+		DesiredTGUpdates: map[string]*structs.DesiredUpdates{
+			"foo": &structs.DesiredUpdates{
+				Ignore:            1,
+				Place:             2,
+				Migrate:           3,
+				Stop:              4,
+				InPlaceUpdate:     5,
+				DestructiveUpdate: 6,
+				Canary:            7,
+			},
+		},
+	}
+
+	tgDiff := &structs.TaskGroupDiff{
+		Type: structs.DiffTypeEdited,
+		Name: "foo",
+	}
+	expected := &structs.TaskGroupDiff{
+		Type: structs.DiffTypeEdited,
+		Name: "foo",
+		Updates: map[string]uint64{
+			UpdateTypeIgnore:            1,
+			UpdateTypeCreate:            2,
+			UpdateTypeMigrate:           3,
+			UpdateTypeDestroy:           4,
+			UpdateTypeInplaceUpdate:     5,
+			UpdateTypeDestructiveUpdate: 6,
+			UpdateTypeCanary:            7,
+		},
+	}
+
+	if err := annotateTaskGroup(tgDiff, annotations); err != nil {
+		t.Fatalf("annotateTaskGroup(%#v, %#v) failed: %#v", tgDiff, annotations, err)
+	}
+
+	if !reflect.DeepEqual(tgDiff, expected) {
+		t.Fatalf("got %#v, want %#v", tgDiff, expected)
+	}
+}
+
+func TestAnnotateCountChange(t *testing.T) {
+	up := &structs.FieldDiff{
+		Type: structs.DiffTypeEdited,
+		Name: "Count",
+		Old:  "1",
+		New:  "3",
+	}
+	down := &structs.FieldDiff{
+		Type: structs.DiffTypeEdited,
+		Name: "Count",
+		Old:  "3",
+		New:  "1",
+	}
+	tgUp := &structs.TaskGroupDiff{
+		Type:   structs.DiffTypeEdited,
+		Fields: []*structs.FieldDiff{up},
+	}
+	tgDown := &structs.TaskGroupDiff{
+		Type:   structs.DiffTypeEdited,
+		Fields: []*structs.FieldDiff{down},
+	}
+
+	// Test the up case
+	if err := annotateCountChange(tgUp); err != nil {
+		t.Fatalf("annotateCountChange(%#v) failed: %v", tgUp, err)
+	}
+	countDiff := tgUp.Fields[0]
+	if len(countDiff.Annotations) != 1 || countDiff.Annotations[0] != AnnotationForcesCreate {
+		t.Fatalf("incorrect annotation: %#v", tgUp)
+	}
+
+	// Test the down case
+	if err := annotateCountChange(tgDown); err != nil {
+		t.Fatalf("annotateCountChange(%#v) failed: %v", tgDown, err)
+	}
+	countDiff = tgDown.Fields[0]
+	if len(countDiff.Annotations) != 1 || countDiff.Annotations[0] != AnnotationForcesDestroy {
+		t.Fatalf("incorrect annotation: %#v", tgDown)
+	}
+}
+
+func TestAnnotateTask_NewTask(t *testing.T) {
+	tgDiff := &structs.TaskGroupDiff{Type: structs.DiffTypeAdded}
+	td := &structs.TaskDiff{Type: structs.DiffTypeAdded}
+
+	annotateTask(td, tgDiff)
+	if len(td.Annotations) != 1 || td.Annotations[0] != AnnotationForcesCreate {
+		t.Fatalf("incorrect annotation: %#v", td)
+	}
+}
+
+func TestAnnotateTask(t *testing.T) {
+	cases := []struct {
+		Diff    *structs.TaskDiff
+		Desired string
+	}{
+		{
+			Diff: &structs.TaskDiff{
+				Type: structs.DiffTypeEdited,
+				Fields: []*structs.FieldDiff{
+					{
+						Type: structs.DiffTypeEdited,
+						Name: "Driver",
+						Old:  "docker",
+						New:  "exec",
+					},
+				},
+			},
+			Desired: AnnotationForcesDestructiveUpdate,
+		},
+		{
+			Diff: &structs.TaskDiff{
+				Type: structs.DiffTypeEdited,
+				Objects: []*structs.ObjectDiff{
+					{
+						Type: structs.DiffTypeAdded,
+						Name: "Env",
+						Fields: []*structs.FieldDiff{
+							{
+								Type: structs.DiffTypeAdded,
+								Name: "foo",
+								New:  "bar",
+							},
+						},
+					},
+				},
+			},
+			Desired: AnnotationForcesDestructiveUpdate,
+		},
+		{
+			Diff: &structs.TaskDiff{
+				Type: structs.DiffTypeEdited,
+				Objects: []*structs.ObjectDiff{
+					{
+						Type: structs.DiffTypeAdded,
+						Name: "Meta",
+						Fields: []*structs.FieldDiff{
+							{
+								Type: structs.DiffTypeAdded,
+								Name: "foo",
+								New:  "bar",
+							},
+						},
+					},
+				},
+			},
+			Desired: AnnotationForcesInplaceUpdate,
+		},
+		{
+			Diff: &structs.TaskDiff{
+				Type: structs.DiffTypeEdited,
+				Objects: []*structs.ObjectDiff{
+					{
+						Type: structs.DiffTypeEdited,
+						Name: "Resources",
+						Fields: []*structs.FieldDiff{
+							{
+								Type: structs.DiffTypeEdited,
+								Name: "CPU",
+								Old:  "100",
+								New:  "200",
+							},
+						},
+					},
+				},
+			},
+			Desired: AnnotationForcesInplaceUpdate,
+		},
+		{
+			Diff: &structs.TaskDiff{
+				Type: structs.DiffTypeEdited,
+				Objects: []*structs.ObjectDiff{
+					{
+						Type: structs.DiffTypeEdited,
+						Name: "Resources",
+						Objects: []*structs.ObjectDiff{
+							{
+								Type: structs.DiffTypeEdited,
+								Name: "Network",
+								Objects: []*structs.ObjectDiff{
+									{
+										Type: structs.DiffTypeAdded,
+										Name: "DynamicPorts",
+										Fields: []*structs.FieldDiff{
+											{
+												Type: structs.DiffTypeAdded,
+												Name: "DynamicPorts",
+												New:  "http",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Desired: AnnotationForcesDestructiveUpdate,
+		},
+		{
+			Diff: &structs.TaskDiff{
+				Type: structs.DiffTypeDeleted,
+			},
+			Desired: AnnotationForcesDestructiveUpdate,
+		},
+	}
+
+	for i, c := range cases {
+		tgDiff := &structs.TaskGroupDiff{Type: structs.DiffTypeEdited}
+		annotateTask(c.Diff, tgDiff)
+		if len(c.Diff.Annotations) != 1 || c.Diff.Annotations[0] != c.Desired {
+			t.Fatalf("case %d: incorrect annotation %#v", i+1, c.Diff.Annotations)
+		}
+	}
+}
@@ -150,6 +150,68 @@ func (iter *DriverIterator) hasDrivers(option *structs.Node) bool {
 	return true
 }
 
+// HostVolumeIterator is a FeasibleIterator which returns nodes that offer the
+// host volumes requested by a task group.
+type HostVolumeIterator struct {
+	ctx     Context
+	source  FeasibleIterator
+	volumes map[string]*structs.VolumeRequest
+}
+
+// NewHostVolumeIterator creates a HostVolumeIterator from a source and set of
+// requested volumes
+func NewHostVolumeIterator(ctx Context, source FeasibleIterator, volumes map[string]*structs.VolumeRequest) *HostVolumeIterator {
+	iter := &HostVolumeIterator{
+		ctx:     ctx,
+		source:  source,
+		volumes: volumes,
+	}
+	return iter
+}
+
+func (iter *HostVolumeIterator) SetVolumes(volumes map[string]*structs.VolumeRequest) {
+	iter.volumes = volumes
+}
+
+func (iter *HostVolumeIterator) Next() *structs.Node {
+	for {
+		// Get the next option from the source
+		option := iter.source.Next()
+		if option == nil {
+			return nil
+		}
+
+		// Use this node if possible
+		if iter.hasVolumes(option) {
+			return option
+		}
+		iter.ctx.Metrics().FilterNode(option, "missing host volumes")
+	}
+}
+
+func (iter *HostVolumeIterator) Reset() {
+	iter.source.Reset()
+}
+
+// hasVolumes is used to check if the node offers all the host volumes
+// requested by the task group. A read-only host volume only satisfies
+// read-only requests.
+func (iter *HostVolumeIterator) hasVolumes(option *structs.Node) bool {
+	for _, req := range iter.volumes {
+		if req.Type != structs.VolumeTypeHost {
+			continue
+		}
+		volume, ok := option.HostVolumes[req.Source]
+		if !ok {
+			return false
+		}
+		if volume.ReadOnly && !req.ReadOnly {
+			return false
+		}
+	}
+	return true
+}
+
 // ProposedAllocConstraintIterator is a FeasibleIterator which returns nodes that
 // match constraints that are not static such as Node attributes but are
 // effected by proposed alloc placements. Examples are distinct_hosts and
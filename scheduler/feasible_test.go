@@ -106,6 +106,47 @@ func TestDriverIterator(t *testing.T) {
 	}
 }
 
+func TestHostVolumeIterator(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+	}
+	static := NewStaticIterator(ctx, nodes)
+
+	nodes[0].HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"mysql": &structs.ClientHostVolumeConfig{Name: "mysql", Path: "/srv/mysql"},
+		"certs": &structs.ClientHostVolumeConfig{Name: "certs", Path: "/etc/ssl", ReadOnly: true},
+	}
+	nodes[1].HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"mysql": &structs.ClientHostVolumeConfig{Name: "mysql", Path: "/srv/mysql"},
+	}
+	nodes[2].HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"mysql": &structs.ClientHostVolumeConfig{Name: "mysql", Path: "/srv/mysql", ReadOnly: true},
+		"certs": &structs.ClientHostVolumeConfig{Name: "certs", Path: "/etc/ssl", ReadOnly: true},
+	}
+
+	volumes := map[string]*structs.VolumeRequest{
+		"data": &structs.VolumeRequest{Name: "data", Type: structs.VolumeTypeHost, Source: "mysql"},
+		"ssl":  &structs.VolumeRequest{Name: "ssl", Type: structs.VolumeTypeHost, Source: "certs", ReadOnly: true},
+	}
+	iter := NewHostVolumeIterator(ctx, static, volumes)
+
+	out := collectFeasible(iter)
+	if len(out) != 1 || out[0] != nodes[0] {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// All nodes are feasible without volumes
+	static.Reset()
+	iter.SetVolumes(nil)
+	if out := collectFeasible(iter); len(out) != len(nodes) {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestConstraintIterator(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*structs.Node{
@@ -27,6 +27,14 @@ const (
 
 	// allocInPlace is the status used when speculating on an in-place update
 	allocInPlace = "alloc updating in-place"
+
+	// allocCanaryReplaced is the status used when an allocation has been
+	// replaced by a promoted canary
+	allocCanaryReplaced = "alloc replaced by promoted canary"
+
+	// allocCanaryFailed is the status used when stopping the canaries of a
+	// failed deployment
+	allocCanaryFailed = "alloc is a canary of a failed deployment"
 )
 
 // SetStatusError is used to set the status of the evaluation to the given error
@@ -50,11 +58,12 @@ type GenericScheduler struct {
 	planner Planner
 	batch   bool
 
-	eval  *structs.Evaluation
-	job   *structs.Job
-	plan  *structs.Plan
-	ctx   *EvalContext
-	stack *GenericStack
+	eval       *structs.Evaluation
+	job        *structs.Job
+	deployment *structs.Deployment
+	plan       *structs.Plan
+	ctx        *EvalContext
+	stack      *GenericStack
 
 	limitReached bool
 	nextEval     *structs.Evaluation
@@ -90,7 +99,8 @@ func (s *GenericScheduler) Process(eval *structs.Evaluation) error {
 	// Verify the evaluation trigger reason is understood
 	switch eval.TriggeredBy {
 	case structs.EvalTriggerJobRegister, structs.EvalTriggerNodeUpdate,
-		structs.EvalTriggerJobDeregister, structs.EvalTriggerRollingUpdate:
+		structs.EvalTriggerJobDeregister, structs.EvalTriggerRollingUpdate,
+		structs.EvalTriggerDeployment, structs.EvalTriggerNodeDrain:
 	default:
 		desc := fmt.Sprintf("scheduler cannot handle '%s' evaluation reason",
 			eval.TriggeredBy)
@@ -124,6 +134,16 @@ func (s *GenericScheduler) process() (bool, error) {
 			s.eval.JobID, err)
 	}
 
+	// Lookup the latest deployment of the job. Only service jobs are deployed.
+	s.deployment = nil
+	if !s.batch {
+		s.deployment, err = s.state.LatestDeploymentByJobID(s.eval.JobID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get deployment for job '%s': %v",
+				s.eval.JobID, err)
+		}
+	}
+
 	// Create a plan
 	s.plan = s.eval.MakePlan(s.job)
 
@@ -142,14 +162,16 @@ func (s *GenericScheduler) process() (bool, error) {
 		return false, err
 	}
 
-	// If the plan is a no-op, we can bail
-	if s.plan.IsNoOp() {
+	// If the plan is a no-op, we can bail. If AnnotatePlan is set submit the
+	// plan anyways to get the annotations.
+	if s.plan.IsNoOp() && !s.eval.AnnotatePlan {
 		return true, nil
 	}
 
 	// If the limit of placements was reached we need to create an evaluation
-	// to pickup from here after the stagger period.
-	if s.limitReached && s.nextEval == nil {
+	// to pickup from here after the stagger period. Active deployments are
+	// instead continued as their allocations become healthy.
+	if s.limitReached && s.nextEval == nil && !s.deploymentActive() {
 		s.nextEval = s.eval.NextRollingEval(s.job.Update.Stagger)
 		if err := s.planner.CreateEval(s.nextEval); err != nil {
 			s.logger.Printf("[ERR] sched: %#v failed to make next eval for rolling update: %v", s.eval, err)
@@ -186,6 +208,9 @@ func (s *GenericScheduler) process() (bool, error) {
 // computeJobAllocs is used to reconcile differences between the job,
 // existing allocations and node status to update the allocations.
 func (s *GenericScheduler) computeJobAllocs() error {
+	// Cancel the deployment if it no longer matches the job
+	s.cancelStaleDeployment()
+
 	// Materialize all the task groups, job could be missing if deregistered
 	var groups map[string]*structs.TaskGroup
 	if s.job != nil {
@@ -219,12 +244,31 @@ func (s *GenericScheduler) computeJobAllocs() error {
 	}
 
 	// Attempt to do the upgrades in place
-	diff.update = inplaceUpdate(s.ctx, s.eval, s.job, s.stack, diff.update)
+	destructiveUpdates, inplaceUpdates := inplaceUpdate(s.ctx, s.eval, s.job, s.stack, diff.update)
+	diff.update = destructiveUpdates
+
+	// Track the updates with a deployment, placing canaries and holding back
+	// the updates gated on them
+	s.handleDeployment(diff)
+	destructiveUpdates = diff.update
+
+	if s.eval.AnnotatePlan {
+		s.plan.Annotations = &structs.PlanAnnotations{
+			DesiredTGUpdates: desiredUpdates(diff, inplaceUpdates, destructiveUpdates),
+		}
+	}
 
-	// Check if a rolling upgrade strategy is being used
+	// Check if a rolling upgrade strategy is being used. The allocations of
+	// an active deployment that are not yet healthy count against the limit.
 	limit := len(diff.update) + len(diff.migrate)
 	if s.job != nil && s.job.Update.Rolling() {
 		limit = s.job.Update.MaxParallel
+		if s.deploymentActive() {
+			limit -= s.unhealthyDeploymentAllocs(diff)
+			if limit < 0 {
+				limit = 0
+			}
+		}
 	}
 
 	// Treat migrations as an eviction and a new placement.
@@ -242,6 +286,208 @@ func (s *GenericScheduler) computeJobAllocs() error {
 	return s.computePlacements(diff.place)
 }
 
+// cancelStaleDeployment cancels the job's latest deployment if the job has
+// since been stopped or updated. The scheduler ignores deployments that do
+// not match the job.
+func (s *GenericScheduler) cancelStaleDeployment() {
+	d := s.deployment
+	if d == nil {
+		return
+	}
+
+	var desc string
+	switch {
+	case s.job == nil:
+		desc = structs.DeploymentStatusDescriptionStoppedJob
+	case d.JobCreateIndex != s.job.CreateIndex || d.JobModifyIndex != s.job.ModifyIndex:
+		desc = structs.DeploymentStatusDescriptionNewerJob
+	default:
+		return
+	}
+
+	if d.Active() {
+		s.plan.DeploymentUpdates = append(s.plan.DeploymentUpdates, &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusCancelled,
+			StatusDescription: desc,
+		})
+	}
+	s.deployment = nil
+}
+
+// deploymentActive returns whether the job's deployment is active
+func (s *GenericScheduler) deploymentActive() bool {
+	return s.deployment != nil && s.deployment.Active()
+}
+
+// unhealthyDeploymentAllocs returns the number of allocations placed by the
+// deployment that have not been reported healthy.
+func (s *GenericScheduler) unhealthyDeploymentAllocs(diff *diffResult) int {
+	count := 0
+	for _, t := range diff.ignore {
+		if t.Alloc.DeploymentID == s.deployment.ID && !t.Alloc.DeploymentStatus.IsHealthy() {
+			count++
+		}
+	}
+	return count
+}
+
+// handleDeployment reconciles the destructive updates with the job's
+// deployment. Service jobs using a rolling update or canaries create a
+// deployment when their allocations are placed or updated so the rollout is
+// driven by the health of the new allocations. Until a task group is promoted
+// its destructive updates are held and canaries are placed alongside the
+// existing allocations. Once promoted, the allocations replaced by canaries are
+// stopped and the rest are updated as usual. If the deployment failed the
+// canaries are stopped and the remaining updates are held.
+func (s *GenericScheduler) handleDeployment(diff *diffResult) {
+	// Count the allocations sharing each name, canaries run alongside the
+	// allocation they are replacing.
+	names := make(map[string]int)
+	for _, set := range [][]allocTuple{diff.update, diff.migrate, diff.ignore} {
+		for _, t := range set {
+			names[t.Name]++
+		}
+	}
+
+	// Canaries of an earlier deployment that were never promoted are
+	// stopped rather than updated.
+	var updates []allocTuple
+	for _, t := range diff.update {
+		if t.Alloc.DeploymentStatus.IsCanary() && names[t.Name] > 1 {
+			s.plan.AppendUpdate(t.Alloc, structs.AllocDesiredStatusStop, allocNotNeeded)
+			names[t.Name]--
+			continue
+		}
+		updates = append(updates, t)
+	}
+	diff.update = updates
+
+	if s.batch || s.job == nil || (s.job.Update.Canary == 0 && !s.job.Update.Rolling()) {
+		return
+	}
+
+	// Group the destructive updates and placements by task group
+	groupUpdates := make(map[string][]allocTuple)
+	for _, t := range diff.update {
+		groupUpdates[t.TaskGroup.Name] = append(groupUpdates[t.TaskGroup.Name], t)
+	}
+	groupPlaces := make(map[string]struct{})
+	for _, t := range diff.place {
+		groupPlaces[t.TaskGroup.Name] = struct{}{}
+	}
+
+	// Create a deployment if this is a new version of the job
+	if s.deployment == nil {
+		if len(groupUpdates) == 0 && len(groupPlaces) == 0 {
+			return
+		}
+
+		s.deployment = structs.NewDeployment(s.job)
+		for _, tg := range s.job.TaskGroups {
+			_, updated := groupUpdates[tg.Name]
+			_, placed := groupPlaces[tg.Name]
+			if !updated && !placed {
+				continue
+			}
+
+			state := &structs.DeploymentState{
+				DesiredTotal: tg.Count,
+				AutoRevert:   s.job.Update.AutoRevert,
+			}
+
+			// Canaries are only needed when existing allocations are updated
+			if updated {
+				state.DesiredCanaries = s.job.Update.Canary
+			}
+			s.deployment.TaskGroups[tg.Name] = state
+		}
+		if s.deployment.RequiresPromotion() {
+			s.deployment.StatusDescription = structs.DeploymentStatusDescriptionRunningNeedsPromotion
+		}
+		s.plan.Deployment = s.deployment
+	}
+
+	// Index the canaries placed for the deployment and stop the unpromoted
+	// canaries if the deployment has failed.
+	failed := s.deployment.Status == structs.DeploymentStatusFailed
+	canaries := make(map[string]map[string]struct{})
+	var ignore []allocTuple
+	for _, t := range diff.ignore {
+		if t.Alloc.DeploymentID != s.deployment.ID || !t.Alloc.DeploymentStatus.IsCanary() {
+			ignore = append(ignore, t)
+			continue
+		}
+
+		state, ok := s.deployment.TaskGroups[t.TaskGroup.Name]
+		if failed && ok && !state.Promoted {
+			s.plan.AppendUpdate(t.Alloc, structs.AllocDesiredStatusStop, allocCanaryFailed)
+			continue
+		}
+
+		ignore = append(ignore, t)
+		if _, ok := canaries[t.TaskGroup.Name]; !ok {
+			canaries[t.TaskGroup.Name] = make(map[string]struct{})
+		}
+		canaries[t.TaskGroup.Name][t.Name] = struct{}{}
+	}
+	diff.ignore = ignore
+
+	// Decide which updates can be made
+	updates = nil
+	for _, tg := range s.job.TaskGroups {
+		tuples := groupUpdates[tg.Name]
+		state, ok := s.deployment.TaskGroups[tg.Name]
+		if !ok || state.DesiredCanaries == 0 {
+			updates = append(updates, tuples...)
+			continue
+		}
+
+		placed := canaries[tg.Name]
+		if state.Promoted {
+			for _, t := range tuples {
+				// The promoted canary takes the place of this allocation
+				if _, ok := placed[t.Name]; ok {
+					s.plan.AppendUpdate(t.Alloc, structs.AllocDesiredStatusStop, allocCanaryReplaced)
+					continue
+				}
+				updates = append(updates, t)
+			}
+			continue
+		}
+
+		// Hold the updates until the canaries are promoted
+		diff.ignore = append(diff.ignore, tuples...)
+		if !s.deployment.Active() {
+			continue
+		}
+
+		// Place any missing canaries alongside the allocations being updated
+		need := state.DesiredCanaries - len(placed)
+		for _, t := range tuples {
+			if need <= 0 {
+				break
+			}
+			if _, ok := placed[t.Name]; ok {
+				continue
+			}
+			diff.place = append(diff.place, allocTuple{
+				Name:      t.Name,
+				TaskGroup: t.TaskGroup,
+				Canary:    true,
+			})
+			need--
+		}
+	}
+
+	// Hold the remaining updates of a failed deployment
+	if failed {
+		diff.ignore = append(diff.ignore, updates...)
+		updates = nil
+	}
+	diff.update = updates
+}
+
 // computePlacements computes placements for allocations
 func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 	// Get the base nodes
@@ -264,8 +510,18 @@ func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 			continue
 		}
 
-		// Attempt to match the task group
-		option, size := s.stack.Select(missing.TaskGroup)
+		// Attempt to match the task group, preferring the node of the
+		// replaced allocation if its ephemeral disk is sticky
+		var option *RankedNode
+		var size *structs.Resources
+		if node := stickyNode(missing, nodes); node != nil {
+			s.stack.SetNodes([]*structs.Node{node})
+			option, size = s.stack.Select(missing.TaskGroup)
+			s.stack.SetNodes(nodes)
+		}
+		if option == nil {
+			option, size = s.stack.Select(missing.TaskGroup)
+		}
 
 		// Create an allocation for this
 		alloc := &structs.Allocation{
@@ -279,12 +535,29 @@ func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 			Metrics:   s.ctx.Metrics(),
 		}
 
+		// Record the replaced allocation so the client can move its data
+		if missing.Alloc != nil {
+			alloc.PreviousAllocation = missing.Alloc.ID
+		}
+
 		// Set fields based on if we found an allocation option
 		if option != nil {
 			alloc.NodeID = option.Node.ID
 			alloc.TaskResources = option.TaskResources
 			alloc.DesiredStatus = structs.AllocDesiredStatusRun
 			alloc.ClientStatus = structs.AllocClientStatusPending
+
+			// Track the placement against the deployment
+			if d := s.deployment; d != nil && d.Active() {
+				if _, ok := d.TaskGroups[missing.TaskGroup.Name]; ok {
+					alloc.DeploymentID = d.ID
+				}
+			}
+			if missing.Canary {
+				alloc.DeploymentStatus = &structs.AllocDeploymentStatus{
+					Canary: true,
+				}
+			}
 			s.plan.AppendAlloc(alloc)
 		} else {
 			alloc.DesiredStatus = structs.AllocDesiredStatusFailed
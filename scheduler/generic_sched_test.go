@@ -63,6 +63,64 @@ func TestServiceSched_JobRegister(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestServiceSched_JobRegister_Spread(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes, most of them in dc1
+	nodeDCs := make(map[string]string)
+	for i := 0; i < 12; i++ {
+		node := mock.Node()
+		if i%3 == 0 {
+			node.Datacenter = "dc2"
+		}
+		nodeDCs[node.ID] = node.Datacenter
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Create a job spread evenly across the datacenters
+	job := mock.Job()
+	job.Datacenters = []string{"dc1", "dc2"}
+	job.TaskGroups[0].Count = 6
+	job.TaskGroups[0].Spreads = []*structs.Spread{
+		&structs.Spread{
+			Attribute: "$node.datacenter",
+			Weight:    100,
+		},
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan spread the allocations evenly
+	dcCounts := make(map[string]int)
+	for nodeID, allocList := range plan.NodeAllocation {
+		dcCounts[nodeDCs[nodeID]] += len(allocList)
+	}
+	if dcCounts["dc1"] != 3 || dcCounts["dc2"] != 3 {
+		t.Fatalf("bad: %#v", dcCounts)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestServiceSched_JobRegister_AllocFail(t *testing.T) {
 	h := NewHarness(t)
 
@@ -113,6 +171,88 @@ func TestServiceSched_JobRegister_AllocFail(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestServiceSched_JobModify_StickyDisk(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with a sticky ephemeral disk and allocations
+	job := mock.Job()
+	job.TaskGroups[0].EphemeralDisk = &structs.EphemeralDisk{
+		SizeMB: 500,
+		Sticky: true,
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	allocNodes := make(map[string]string)
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+		allocNodes[alloc.ID] = alloc.NodeID
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the task, such that it cannot be done in-place
+	job2 := job.Copy()
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create a mock evaluation to deal with the update
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure each replacement is placed on the node of the allocation it
+	// replaces and reserves the ephemeral disk
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 10 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	for _, alloc := range planned {
+		nodeID, ok := allocNodes[alloc.PreviousAllocation]
+		if !ok {
+			t.Fatalf("alloc %q has unknown previous alloc %q", alloc.ID, alloc.PreviousAllocation)
+		}
+		if alloc.NodeID != nodeID {
+			t.Fatalf("alloc %q placed on %q; want %q", alloc.ID, alloc.NodeID, nodeID)
+		}
+		if alloc.Resources.DiskMB < 500 {
+			t.Fatalf("alloc %q doesn't reserve the ephemeral disk: %#v", alloc.ID, alloc.Resources)
+		}
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestServiceSched_JobModify(t *testing.T) {
 	h := NewHarness(t)
 
@@ -287,29 +427,517 @@ func TestServiceSched_JobModify_Rolling(t *testing.T) {
 		t.Fatalf("bad: %#v", plan)
 	}
 
+	// Ensure a deployment was created to track the update
+	d := plan.Deployment
+	if d == nil {
+		t.Fatalf("missing deployment")
+	}
+	state, ok := d.TaskGroups["web"]
+	if !ok || state.DesiredTotal != 10 || state.DesiredCanaries != 0 {
+		t.Fatalf("bad: %#v", d)
+	}
+	for _, alloc := range planned {
+		if alloc.DeploymentID != d.ID {
+			t.Fatalf("bad: %#v", alloc)
+		}
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+
+	// The deployment continues the update once the allocations are healthy,
+	// so no follow up eval is created.
+	if h.Evals[0].NextEval != "" || len(h.CreateEvals) != 0 {
+		t.Fatalf("unexpected follow up eval: %#v", h.CreateEvals)
+	}
+}
+
+func TestServiceSched_JobModify_Rolling_Health(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Update the job such that it cannot be done in-place
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update = structs.UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 3,
+	}
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create the deployment of the update
+	d := structs.NewDeployment(job2)
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 10}
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d))
+
+	// Create the allocations, three of which were placed by the deployment
+	// and only one of those is healthy.
+	healthy := true
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		if i < 3 {
+			alloc.Job = job2
+			alloc.DeploymentID = d.ID
+		}
+		if i == 0 {
+			alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &healthy}
+		}
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Create a mock evaluation triggered by the deployment
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerDeployment,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan only updated as many allocations as are healthy
+	var update []*structs.Allocation
+	for _, updateList := range plan.NodeUpdate {
+		update = append(update, updateList...)
+	}
+	if len(update) != 1 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 1 || planned[0].DeploymentID != d.ID {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobModify_Rolling_Failed(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job such that it cannot be done in-place
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update = structs.UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 3,
+	}
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create the failed deployment of the update
+	d := structs.NewDeployment(job2)
+	d.Status = structs.DeploymentStatusFailed
+	d.StatusDescription = structs.DeploymentStatusDescriptionFailedAllocations
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 10}
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d))
+
+	// Create a mock evaluation triggered by the deployment
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerDeployment,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure no plan as the updates are held
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobModify_Canaries(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job with canaries
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update = structs.UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 5,
+		Canary:      2,
+	}
+
+	// Update the task, such that it cannot be done in-place
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create a mock evaluation to deal with the update
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan evicted nothing
+	if len(plan.NodeUpdate) != 0 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the plan placed the canaries
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 2 {
+		t.Fatalf("bad: %#v", plan)
+	}
+	for _, alloc := range planned {
+		if !alloc.DeploymentStatus.IsCanary() {
+			t.Fatalf("expected canary: %#v", alloc)
+		}
+	}
+
+	// Ensure the plan created a deployment requiring promotion
+	d := plan.Deployment
+	if d == nil {
+		t.Fatalf("bad: %#v", plan)
+	}
+	if !d.RequiresPromotion() {
+		t.Fatalf("deployment should require promotion: %#v", d)
+	}
+	state, ok := d.TaskGroups["web"]
+	if !ok || state.DesiredCanaries != 2 || state.DesiredTotal != 10 {
+		t.Fatalf("bad: %#v", d)
+	}
+
+	// No rolling update evaluation is created while waiting on promotion
+	if len(h.CreateEvals) != 0 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+
+	// Lookup the deployment and ensure the canaries were recorded
+	out, err := h.State.DeploymentByID(d.ID)
+	noErr(t, err)
+	if len(out.TaskGroups["web"].PlacedCanaries) != 2 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Processing another evaluation should not place more canaries
+	h.Plans = nil
+	h.Evals = nil
+	eval2 := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+	noErr(t, h.Process(NewServiceScheduler, eval2))
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans[0])
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobModify_Canaries_Promoted(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job with canaries
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update.Canary = 2
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create a promoted deployment with placed canaries
+	d := structs.NewDeployment(job2)
+	d.TaskGroups["web"] = &structs.DeploymentState{
+		Promoted:        true,
+		DesiredCanaries: 2,
+		DesiredTotal:    10,
+	}
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d))
+
+	var canaries []*structs.Allocation
+	for i := 0; i < 2; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job2
+		alloc.JobID = job2.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		alloc.DeploymentID = d.ID
+		alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+		canaries = append(canaries, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), canaries))
+
+	// Create a mock evaluation to deal with the promotion
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerDeployment,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan stopped all the old allocations
+	var update []*structs.Allocation
+	for _, updateList := range plan.NodeUpdate {
+		update = append(update, updateList...)
+	}
+	if len(update) != len(allocs) {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the canaries were not replaced
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 8 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the remaining allocations were placed as part of the deployment.
+	// The deployment completes once they are healthy.
+	for _, alloc := range planned {
+		if alloc.DeploymentID != d.ID {
+			t.Fatalf("bad: %#v", alloc)
+		}
+	}
+	if len(plan.DeploymentUpdates) != 0 {
+		t.Fatalf("bad: %#v", plan.DeploymentUpdates)
+	}
+
+	// Ensure all allocations placed
+	out, err := h.State.AllocsByJob(job.ID)
+	noErr(t, err)
+	out = structs.FilterTerminalAllocs(out)
+	if len(out) != 10 {
+		t.Fatalf("bad: %#v", out)
+	}
+
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobModify_Canaries_Failed(t *testing.T) {
+	h := NewHarness(t)
 
-	// Ensure a follow up eval was created
-	eval = h.Evals[0]
-	if eval.NextEval == "" {
-		t.Fatalf("missing next eval")
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job with canaries
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update.Canary = 2
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create a failed deployment with placed canaries
+	d := structs.NewDeployment(job2)
+	d.Status = structs.DeploymentStatusFailed
+	d.StatusDescription = structs.DeploymentStatusDescriptionFailedByUser
+	d.TaskGroups["web"] = &structs.DeploymentState{
+		DesiredCanaries: 2,
+		DesiredTotal:    10,
+	}
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d))
+
+	var canaries []*structs.Allocation
+	for i := 0; i < 2; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job2
+		alloc.JobID = job2.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		alloc.DeploymentID = d.ID
+		alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Canary: true}
+		canaries = append(canaries, alloc)
 	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), canaries))
 
-	// Check for create
-	if len(h.CreateEvals) == 0 {
-		t.Fatalf("missing created eval")
+	// Create a mock evaluation to deal with the failure
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerDeployment,
+		JobID:       job.ID,
 	}
-	create := h.CreateEvals[0]
-	if eval.NextEval != create.ID {
-		t.Fatalf("ID mismatch")
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-	if create.PreviousEval != eval.ID {
-		t.Fatalf("missing previous eval")
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
 	}
+	plan := h.Plans[0]
 
-	if create.TriggeredBy != structs.EvalTriggerRollingUpdate {
-		t.Fatalf("bad: %#v", create)
+	// Ensure only the canaries were stopped
+	var update []*structs.Allocation
+	for _, updateList := range plan.NodeUpdate {
+		update = append(update, updateList...)
+	}
+	if len(update) != len(canaries) {
+		t.Fatalf("bad: %#v", plan)
+	}
+	for _, alloc := range update {
+		if !alloc.DeploymentStatus.IsCanary() {
+			t.Fatalf("bad: %#v", alloc)
+		}
+	}
+
+	// Ensure nothing was placed
+	if len(plan.NodeAllocation) != 0 {
+		t.Fatalf("bad: %#v", plan)
 	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
 func TestServiceSched_JobModify_InPlace(t *testing.T) {
@@ -473,7 +1101,8 @@ func TestServiceSched_NodeDrain(t *testing.T) {
 		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
 	}
 
-	// Generate a fake job with allocations
+	// Generate a fake job with allocations. Only the allocations marked by
+	// the drainer are migrated.
 	job := mock.Job()
 	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
 
@@ -484,6 +1113,7 @@ func TestServiceSched_NodeDrain(t *testing.T) {
 		alloc.JobID = job.ID
 		alloc.NodeID = node.ID
 		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		alloc.DesiredTransition.Migrate = i < 7
 		allocs = append(allocs, alloc)
 	}
 	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
@@ -492,9 +1122,8 @@ func TestServiceSched_NodeDrain(t *testing.T) {
 	eval := &structs.Evaluation{
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
-		TriggeredBy: structs.EvalTriggerNodeUpdate,
+		TriggeredBy: structs.EvalTriggerNodeDrain,
 		JobID:       job.ID,
-		NodeID:      node.ID,
 	}
 
 	// Process the evaluation
@@ -509,8 +1138,8 @@ func TestServiceSched_NodeDrain(t *testing.T) {
 	}
 	plan := h.Plans[0]
 
-	// Ensure the plan evicted all allocs
-	if len(plan.NodeUpdate[node.ID]) != len(allocs) {
+	// Ensure the plan evicted the marked allocs
+	if len(plan.NodeUpdate[node.ID]) != 7 {
 		t.Fatalf("bad: %#v", plan)
 	}
 
@@ -519,7 +1148,7 @@ func TestServiceSched_NodeDrain(t *testing.T) {
 	for _, allocList := range plan.NodeAllocation {
 		planned = append(planned, allocList...)
 	}
-	if len(planned) != 10 {
+	if len(planned) != 7 {
 		t.Fatalf("bad: %#v", plan)
 	}
 
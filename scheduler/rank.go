@@ -131,11 +131,11 @@ func (iter *StaticRankIterator) Reset() {
 // BinPackIterator is a RankIterator that scores potential options
 // based on a bin-packing algorithm.
 type BinPackIterator struct {
-	ctx      Context
-	source   RankIterator
-	evict    bool
-	priority int
-	tasks    []*structs.Task
+	ctx       Context
+	source    RankIterator
+	evict     bool
+	priority  int
+	taskGroup *structs.TaskGroup
 }
 
 // NewBinPackIterator returns a BinPackIterator which tries to fit tasks
@@ -154,8 +154,8 @@ func (iter *BinPackIterator) SetPriority(p int) {
 	iter.priority = p
 }
 
-func (iter *BinPackIterator) SetTasks(tasks []*structs.Task) {
-	iter.tasks = tasks
+func (iter *BinPackIterator) SetTaskGroup(taskGroup *structs.TaskGroup) {
+	iter.taskGroup = taskGroup
 }
 
 func (iter *BinPackIterator) Next() *RankedNode {
@@ -183,7 +183,7 @@ OUTER:
 
 		// Assign the resources for each task
 		total := new(structs.Resources)
-		for _, task := range iter.tasks {
+		for _, task := range iter.taskGroup.Tasks {
 			taskResources := task.Resources.Copy()
 
 			// Check if we need a network resource
@@ -210,6 +210,9 @@ OUTER:
 			total.Add(taskResources)
 		}
 
+		// The ephemeral disk is shared by the tasks of the group
+		total.DiskMB += ephemeralDiskSize(iter.taskGroup)
+
 		// Add the resources we are trying to fit
 		proposed = append(proposed, &structs.Allocation{Resources: total})
 
@@ -300,3 +303,238 @@ func (iter *JobAntiAffinityIterator) Next() *RankedNode {
 func (iter *JobAntiAffinityIterator) Reset() {
 	iter.source.Reset()
 }
+
+// SpreadIterator is used to spread the allocations of a task group across the
+// values of node attributes. Nodes are boosted by how much placing on them
+// improves the distribution of the existing and planned allocations of the
+// task group, either evenly or towards the percentages of the spread targets.
+// Nodes that lack the attribute receive the maximum penalty.
+type SpreadIterator struct {
+	ctx    Context
+	source RankIterator
+	boost  float64
+	job    *structs.Job
+	tg     *structs.TaskGroup
+
+	// spreads are the spreads of the job and the task group
+	spreads    []*structs.Spread
+	sumWeights int
+
+	// counts are the allocations of the task group per value of the
+	// attribute of each spread. They are computed once per placement as the
+	// plan changes between placements.
+	counts []map[string]int
+}
+
+// NewSpreadIterator is used to create a SpreadIterator that applies at most
+// the given boost for placements that improve the spread of the task group.
+func NewSpreadIterator(ctx Context, source RankIterator, boost float64) *SpreadIterator {
+	iter := &SpreadIterator{
+		ctx:    ctx,
+		source: source,
+		boost:  boost,
+	}
+	return iter
+}
+
+func (iter *SpreadIterator) SetJob(job *structs.Job) {
+	iter.job = job
+}
+
+func (iter *SpreadIterator) SetTaskGroup(tg *structs.TaskGroup) {
+	iter.tg = tg
+	iter.spreads = nil
+	iter.sumWeights = 0
+	iter.counts = nil
+	if iter.job != nil {
+		iter.spreads = append(iter.spreads, iter.job.Spreads...)
+	}
+	iter.spreads = append(iter.spreads, tg.Spreads...)
+	for _, spread := range iter.spreads {
+		iter.sumWeights += spread.Weight
+	}
+}
+
+// HasSpreads returns whether the task group is spread
+func (iter *SpreadIterator) HasSpreads() bool {
+	return len(iter.spreads) != 0 && iter.sumWeights > 0
+}
+
+func (iter *SpreadIterator) Next() *RankedNode {
+	for {
+		option := iter.source.Next()
+		if option == nil || !iter.HasSpreads() {
+			return option
+		}
+
+		// Count the allocations of the task group per attribute value
+		if iter.counts == nil {
+			if err := iter.computeCounts(); err != nil {
+				iter.ctx.Logger().Printf(
+					"[ERR] sched.spread: failed to count allocations: %v", err)
+				return option
+			}
+		}
+
+		// Weigh the boost of each spread
+		var total float64
+		for i, spread := range iter.spreads {
+			boost := -1.0
+			if value, ok := spreadValue(spread.Attribute, option.Node); ok {
+				if len(spread.Targets) == 0 {
+					boost = evenSpreadBoost(iter.counts[i], value)
+				} else {
+					boost = targetSpreadBoost(spread, iter.counts[i], value, iter.tg.Count)
+				}
+			}
+			total += boost * float64(spread.Weight) / float64(iter.sumWeights)
+		}
+
+		scoreBoost := total * iter.boost
+		option.Score += scoreBoost
+		iter.ctx.Metrics().ScoreNode(option.Node, "allocation-spread", scoreBoost)
+		return option
+	}
+}
+
+func (iter *SpreadIterator) Reset() {
+	iter.source.Reset()
+	iter.counts = nil
+}
+
+// computeCounts counts the allocations of the task group per value of the
+// attribute of each spread. The allocations stopped by the plan are excluded
+// and the planned placements included.
+func (iter *SpreadIterator) computeCounts() error {
+	allocs, err := iter.ctx.State().AllocsByJob(iter.job.ID)
+	if err != nil {
+		return err
+	}
+	plan := iter.ctx.Plan()
+	stopped := make(map[string]struct{})
+	for _, updates := range plan.NodeUpdate {
+		for _, alloc := range updates {
+			stopped[alloc.ID] = struct{}{}
+		}
+	}
+
+	// Track the node of each allocation. In-place updates are planned with
+	// the ID of the existing allocation so they are only counted once.
+	placed := make(map[string]string)
+	for _, alloc := range allocs {
+		if alloc.TaskGroup != iter.tg.Name || alloc.TerminalStatus() {
+			continue
+		}
+		if _, ok := stopped[alloc.ID]; ok {
+			continue
+		}
+		placed[alloc.ID] = alloc.NodeID
+	}
+	for nodeID, planned := range plan.NodeAllocation {
+		for _, alloc := range planned {
+			if alloc.JobID == iter.job.ID && alloc.TaskGroup == iter.tg.Name {
+				placed[alloc.ID] = nodeID
+			}
+		}
+	}
+
+	counts := make([]map[string]int, len(iter.spreads))
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+	nodes := make(map[string]*structs.Node)
+	for _, nodeID := range placed {
+		node, ok := nodes[nodeID]
+		if !ok {
+			if node, err = iter.ctx.State().NodeByID(nodeID); err != nil {
+				return err
+			}
+			nodes[nodeID] = node
+		}
+		if node == nil {
+			continue
+		}
+		for i, spread := range iter.spreads {
+			if value, ok := spreadValue(spread.Attribute, node); ok {
+				counts[i][value]++
+			}
+		}
+	}
+	iter.counts = counts
+	return nil
+}
+
+// spreadValue returns the value of the spread attribute for the node
+func spreadValue(attribute string, node *structs.Node) (string, bool) {
+	raw, ok := resolveConstraintTarget(attribute, node)
+	if !ok {
+		return "", false
+	}
+	value, ok := raw.(string)
+	return value, ok
+}
+
+// evenSpreadBoost returns the boost, between -1 and 1, of placing on a node
+// with the attribute value when spreading evenly. Values without allocations
+// receive the maximum boost and placements that worsen an even distribution
+// the maximum penalty.
+func evenSpreadBoost(counts map[string]int, value string) float64 {
+	if len(counts) == 0 {
+		return 0
+	}
+	min, max := -1, 0
+	for _, count := range counts {
+		if min == -1 || count < min {
+			min = count
+		}
+		if count > max {
+			max = count
+		}
+	}
+
+	used := counts[value]
+	switch {
+	case used < min:
+		return 1
+	case min == max:
+		return -1
+	case used == min:
+		return float64(max-min) / float64(max)
+	default:
+		return -float64(used-min) / float64(max)
+	}
+}
+
+// targetSpreadBoost returns the boost, between -1 and 1, of placing on a node
+// with the attribute value by how far the value is below its desired share of
+// the task group. The values without a target share the remaining percentage.
+func targetSpreadBoost(spread *structs.Spread, counts map[string]int, value string, count int) float64 {
+	targeted := make(map[string]int, len(spread.Targets))
+	remaining := 100
+	for _, t := range spread.Targets {
+		targeted[t.Value] = t.Percent
+		remaining -= t.Percent
+	}
+
+	percent, ok := targeted[value]
+	used := counts[value]
+	if !ok {
+		percent = remaining
+		used = 0
+		for v, c := range counts {
+			if _, ok := targeted[v]; !ok {
+				used += c
+			}
+		}
+	}
+
+	desired := float64(percent) / 100 * float64(count)
+	if desired == 0 {
+		return -1
+	}
+	boost := (desired - float64(used)) / desired
+	if boost < -1 {
+		boost = -1
+	}
+	return boost
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"math"
 	"testing"
 
 	"github.com/hashicorp/nomad/nomad/mock"
@@ -77,7 +78,7 @@ func TestBinPackIterator_NoExistingAlloc(t *testing.T) {
 	}
 
 	binp := NewBinPackIterator(ctx, static, false, 0)
-	binp.SetTasks([]*structs.Task{task})
+	binp.SetTaskGroup(&structs.TaskGroup{Tasks: []*structs.Task{task}})
 
 	out := collectRanked(binp)
 	if len(out) != 2 {
@@ -151,7 +152,7 @@ func TestBinPackIterator_PlannedAlloc(t *testing.T) {
 	}
 
 	binp := NewBinPackIterator(ctx, static, false, 0)
-	binp.SetTasks([]*structs.Task{task})
+	binp.SetTaskGroup(&structs.TaskGroup{Tasks: []*structs.Task{task}})
 
 	out := collectRanked(binp)
 	if len(out) != 1 {
@@ -226,7 +227,7 @@ func TestBinPackIterator_ExistingAlloc(t *testing.T) {
 	}
 
 	binp := NewBinPackIterator(ctx, static, false, 0)
-	binp.SetTasks([]*structs.Task{task})
+	binp.SetTaskGroup(&structs.TaskGroup{Tasks: []*structs.Task{task}})
 
 	out := collectRanked(binp)
 	if len(out) != 1 {
@@ -304,7 +305,7 @@ func TestBinPackIterator_ExistingAlloc_PlannedEvict(t *testing.T) {
 	}
 
 	binp := NewBinPackIterator(ctx, static, false, 0)
-	binp.SetTasks([]*structs.Task{task})
+	binp.SetTaskGroup(&structs.TaskGroup{Tasks: []*structs.Task{task}})
 
 	out := collectRanked(binp)
 	if len(out) != 2 {
@@ -321,6 +322,67 @@ func TestBinPackIterator_ExistingAlloc_PlannedEvict(t *testing.T) {
 	}
 }
 
+func TestBinPackIterator_EphemeralDisk(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		&RankedNode{
+			Node: &structs.Node{
+				// Not enough free disk
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+					DiskMB:   10000,
+				},
+				Reserved: &structs.Resources{
+					DiskMB: 4096,
+				},
+			},
+		},
+		&RankedNode{
+			Node: &structs.Node{
+				// Enough free disk
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+					DiskMB:   20000,
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	tg := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{
+			SizeMB: 8000,
+		},
+		Tasks: []*structs.Task{
+			&structs.Task{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(tg)
+
+	out := collectRanked(binp)
+	if len(out) != 1 {
+		t.Fatalf("Bad: %v", out)
+	}
+	if out[0] != nodes[1] {
+		t.Fatalf("Bad: %v", out)
+	}
+	if ctx.Metrics().DimensionExhausted["disk exhausted"] != 1 {
+		t.Fatalf("Bad: %#v", ctx.Metrics())
+	}
+}
+
 func TestJobAntiAffinity_PlannedAlloc(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*RankedNode{
@@ -376,6 +438,137 @@ func TestJobAntiAffinity_PlannedAlloc(t *testing.T) {
 	}
 }
 
+func TestSpreadIterator_EvenSpread(t *testing.T) {
+	state, ctx := testContext(t)
+	var nodes []*RankedNode
+	for i, dc := range []string{"dc1", "dc1", "dc2"} {
+		node := mock.Node()
+		node.Datacenter = dc
+		noErr(t, state.UpsertNode(uint64(1000+i), node))
+		nodes = append(nodes, &RankedNode{Node: node})
+	}
+	noSpread := mock.Node()
+	noSpread.Datacenter = "dc3"
+	noSpread.Meta = nil
+	noErr(t, state.UpsertNode(1003, noSpread))
+	nodes = append(nodes, &RankedNode{Node: noSpread})
+	static := NewStaticRankIterator(ctx, nodes)
+
+	job := mock.Job()
+	job.Spreads = []*structs.Spread{
+		&structs.Spread{
+			Attribute: "$meta.pci-dss",
+			Weight:    50,
+		},
+	}
+	tg := job.TaskGroups[0]
+	tg.Spreads = []*structs.Spread{
+		&structs.Spread{
+			Attribute: "$node.datacenter",
+			Weight:    50,
+		},
+	}
+
+	// Run an alloc on the first node and plan another on the second one
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = nodes[0].Node.ID
+	noErr(t, state.UpsertAllocs(1004, []*structs.Allocation{alloc}))
+
+	planned := mock.Alloc()
+	planned.Job = job
+	planned.JobID = job.ID
+	ctx.Plan().NodeAllocation[nodes[1].Node.ID] = []*structs.Allocation{planned}
+
+	spread := NewSpreadIterator(ctx, static, 10.0)
+	spread.SetJob(job)
+	spread.SetTaskGroup(tg)
+
+	out := collectRanked(spread)
+	if len(out) != 4 {
+		t.Fatalf("Bad: %#v", out)
+	}
+
+	// Both spreads are worsened by the nodes in dc1
+	if out[0].Score != -10.0 || out[1].Score != -10.0 {
+		t.Fatalf("Bad: %v %v", out[0], out[1])
+	}
+
+	// The node in dc2 improves the datacenter spread but not the meta one
+	if out[2].Score != 0.0 {
+		t.Fatalf("Bad: %v", out[2])
+	}
+
+	// The node without the meta value is penalized for it but improves the
+	// datacenter spread
+	if out[3].Score != 0.0 {
+		t.Fatalf("Bad: %v", out[3])
+	}
+}
+
+func TestSpreadIterator_Targets(t *testing.T) {
+	state, ctx := testContext(t)
+	var nodes []*RankedNode
+	for i, dc := range []string{"dc1", "dc2", "dc3"} {
+		node := mock.Node()
+		node.Datacenter = dc
+		noErr(t, state.UpsertNode(uint64(1000+i), node))
+		nodes = append(nodes, &RankedNode{Node: node})
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	job := mock.Job()
+	tg := job.TaskGroups[0]
+	tg.Count = 4
+	tg.Spreads = []*structs.Spread{
+		&structs.Spread{
+			Attribute: "$node.datacenter",
+			Weight:    100,
+			Targets: []*structs.SpreadTarget{
+				&structs.SpreadTarget{Value: "dc1", Percent: 75},
+				&structs.SpreadTarget{Value: "dc2", Percent: 25},
+			},
+		},
+	}
+
+	// Run an alloc in dc1 and dc2, and one in dc2 that is being stopped
+	var allocs []*structs.Allocation
+	for _, i := range []int{0, 1, 1} {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].Node.ID
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, state.UpsertAllocs(1003, allocs))
+	ctx.Plan().AppendUpdate(allocs[2], structs.AllocDesiredStatusStop, "")
+
+	spread := NewSpreadIterator(ctx, static, 10.0)
+	spread.SetJob(job)
+	spread.SetTaskGroup(tg)
+
+	out := collectRanked(spread)
+	if len(out) != 3 {
+		t.Fatalf("Bad: %#v", out)
+	}
+
+	// dc1 is desired three allocs and has one
+	if math.Abs(out[0].Score-2.0/3.0*10.0) > 1e-9 {
+		t.Fatalf("Bad: %v", out[0])
+	}
+
+	// dc2 has the one alloc it is desired
+	if out[1].Score != 0.0 {
+		t.Fatalf("Bad: %v", out[1])
+	}
+
+	// dc3 has no share of the allocs
+	if out[2].Score != -10.0 {
+		t.Fatalf("Bad: %v", out[2])
+	}
+}
+
 func collectRanked(iter RankIterator) (out []*RankedNode) {
 	for {
 		next := iter.Next()
@@ -68,6 +68,10 @@ type State interface {
 
 	// GetJobByID is used to lookup a job by ID
 	JobByID(id string) (*structs.Job, error)
+
+	// LatestDeploymentByJobID returns the latest deployment matching the given
+	// job ID
+	LatestDeploymentByJobID(jobID string) (*structs.Deployment, error)
 }
 
 // Planner interface is used to submit a task allocation plan.
@@ -81,6 +81,8 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 	result := new(structs.PlanResult)
 	result.NodeUpdate = plan.NodeUpdate
 	result.NodeAllocation = plan.NodeAllocation
+	result.Deployment = plan.Deployment
+	result.DeploymentUpdates = plan.DeploymentUpdates
 	result.AllocIndex = index
 
 	// Flatten evicts and allocs
@@ -94,7 +96,14 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 	allocs = append(allocs, plan.FailedAllocs...)
 
 	// Apply the full plan
-	err := h.State.UpsertAllocs(index, allocs)
+	req := structs.ApplyPlanResultsRequest{
+		AllocUpdateRequest: structs.AllocUpdateRequest{
+			Alloc: allocs,
+		},
+		Deployment:        plan.Deployment,
+		DeploymentUpdates: plan.DeploymentUpdates,
+	}
+	err := h.State.UpsertPlanResults(index, &req)
 	return result, nil, err
 }
 
@@ -16,6 +16,11 @@ const (
 	// batchJobAntiAffinityPenalty is the same as the
 	// serviceJobAntiAffinityPenalty but for batch type jobs.
 	batchJobAntiAffinityPenalty = 5.0
+
+	// spreadScoreBoost is the maximum boost applied to the score for placing
+	// an alloc on a node that improves the spread of its task group. Nodes
+	// that worsen the spread are penalized by as much.
+	spreadScoreBoost = 10.0
 )
 
 // Stack is a chained collection of iterators. The stack is used to
@@ -40,12 +45,18 @@ type GenericStack struct {
 	source                  *StaticIterator
 	jobConstraint           *ConstraintIterator
 	taskGroupDrivers        *DriverIterator
+	taskGroupHostVolumes    *HostVolumeIterator
 	taskGroupConstraint     *ConstraintIterator
 	proposedAllocConstraint *ProposedAllocConstraintIterator
 	binPack                 *BinPackIterator
 	jobAntiAff              *JobAntiAffinityIterator
+	spread                  *SpreadIterator
 	limit                   *LimitIterator
 	maxScore                *MaxScoreIterator
+
+	// nodeLimit is the number of options visited for task groups that are
+	// not spread
+	nodeLimit int
 }
 
 // NewGenericStack constructs a stack used for selecting service placements
@@ -67,8 +78,11 @@ func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	// Filter on task group drivers first as they are faster
 	s.taskGroupDrivers = NewDriverIterator(ctx, s.jobConstraint, nil)
 
+	// Filter on the host volumes requested by the task group
+	s.taskGroupHostVolumes = NewHostVolumeIterator(ctx, s.taskGroupDrivers, nil)
+
 	// Filter on task group constraints second
-	s.taskGroupConstraint = NewConstraintIterator(ctx, s.taskGroupDrivers, nil)
+	s.taskGroupConstraint = NewConstraintIterator(ctx, s.taskGroupHostVolumes, nil)
 
 	// Filter on constraints that are affected by propsed allocations.
 	s.proposedAllocConstraint = NewProposedAllocConstraintIterator(ctx, s.taskGroupConstraint)
@@ -91,8 +105,13 @@ func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	}
 	s.jobAntiAff = NewJobAntiAffinityIterator(ctx, s.binPack, penalty, "")
 
+	// Apply the spread iterator. This distributes the allocations of the task
+	// group across the values of the attributes of its spreads.
+	s.spread = NewSpreadIterator(ctx, s.jobAntiAff, spreadScoreBoost)
+
 	// Apply a limit function. This is to avoid scanning *every* possible node.
-	s.limit = NewLimitIterator(ctx, s.jobAntiAff, 2)
+	s.nodeLimit = 2
+	s.limit = NewLimitIterator(ctx, s.spread, s.nodeLimit)
 
 	// Select the node with the maximum score for placement
 	s.maxScore = NewMaxScoreIterator(ctx, s.limit)
@@ -118,6 +137,7 @@ func (s *GenericStack) SetNodes(baseNodes []*structs.Node) {
 			limit = logLimit
 		}
 	}
+	s.nodeLimit = limit
 	s.limit.SetLimit(limit)
 }
 
@@ -126,6 +146,7 @@ func (s *GenericStack) SetJob(job *structs.Job) {
 	s.proposedAllocConstraint.SetJob(job)
 	s.binPack.SetPriority(job.Priority)
 	s.jobAntiAff.SetJob(job.ID)
+	s.spread.SetJob(job)
 }
 
 func (s *GenericStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Resources) {
@@ -139,9 +160,19 @@ func (s *GenericStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Reso
 
 	// Update the parameters of iterators
 	s.taskGroupDrivers.SetDrivers(tgConstr.drivers)
+	s.taskGroupHostVolumes.SetVolumes(tg.Volumes)
 	s.taskGroupConstraint.SetConstraints(tgConstr.constraints)
 	s.proposedAllocConstraint.SetTaskGroup(tg)
-	s.binPack.SetTasks(tg.Tasks)
+	s.binPack.SetTaskGroup(tg)
+	s.spread.SetTaskGroup(tg)
+
+	// Visit every node when spreading as the best improvement of the spread
+	// may be on any of them
+	if s.spread.HasSpreads() {
+		s.limit.SetLimit(math.MaxInt32)
+	} else {
+		s.limit.SetLimit(s.nodeLimit)
+	}
 
 	// Find the node with the max score
 	option := s.maxScore.Next()
@@ -161,12 +192,13 @@ func (s *GenericStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Reso
 // SystemStack is the Stack used for the System scheduler. It is designed to
 // attempt to make placements on all nodes.
 type SystemStack struct {
-	ctx                 Context
-	source              *StaticIterator
-	jobConstraint       *ConstraintIterator
-	taskGroupDrivers    *DriverIterator
-	taskGroupConstraint *ConstraintIterator
-	binPack             *BinPackIterator
+	ctx                  Context
+	source               *StaticIterator
+	jobConstraint        *ConstraintIterator
+	taskGroupDrivers     *DriverIterator
+	taskGroupHostVolumes *HostVolumeIterator
+	taskGroupConstraint  *ConstraintIterator
+	binPack              *BinPackIterator
 }
 
 // NewSystemStack constructs a stack used for selecting service placements
@@ -184,8 +216,11 @@ func NewSystemStack(ctx Context) *SystemStack {
 	// Filter on task group drivers first as they are faster
 	s.taskGroupDrivers = NewDriverIterator(ctx, s.jobConstraint, nil)
 
+	// Filter on the host volumes requested by the task group
+	s.taskGroupHostVolumes = NewHostVolumeIterator(ctx, s.taskGroupDrivers, nil)
+
 	// Filter on task group constraints second
-	s.taskGroupConstraint = NewConstraintIterator(ctx, s.taskGroupDrivers, nil)
+	s.taskGroupConstraint = NewConstraintIterator(ctx, s.taskGroupHostVolumes, nil)
 
 	// Upgrade from feasible to rank iterator
 	rankSource := NewFeasibleRankIterator(ctx, s.taskGroupConstraint)
@@ -218,8 +253,9 @@ func (s *SystemStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Resou
 
 	// Update the parameters of iterators
 	s.taskGroupDrivers.SetDrivers(tgConstr.drivers)
+	s.taskGroupHostVolumes.SetVolumes(tg.Volumes)
 	s.taskGroupConstraint.SetConstraints(tgConstr.constraints)
-	s.binPack.SetTasks(tg.Tasks)
+	s.binPack.SetTaskGroup(tg)
 
 	// Get the next option that satisfies the constraints.
 	option := s.binPack.Next()
@@ -54,7 +54,8 @@ func (s *SystemScheduler) Process(eval *structs.Evaluation) error {
 	// Verify the evaluation trigger reason is understood
 	switch eval.TriggeredBy {
 	case structs.EvalTriggerJobRegister, structs.EvalTriggerNodeUpdate,
-		structs.EvalTriggerJobDeregister, structs.EvalTriggerRollingUpdate:
+		structs.EvalTriggerJobDeregister, structs.EvalTriggerRollingUpdate,
+		structs.EvalTriggerNodeDrain:
 	default:
 		desc := fmt.Sprintf("scheduler cannot handle '%s' evaluation reason",
 			eval.TriggeredBy)
@@ -110,8 +111,9 @@ func (s *SystemScheduler) process() (bool, error) {
 		return false, err
 	}
 
-	// If the plan is a no-op, we can bail
-	if s.plan.IsNoOp() {
+	// If the plan is a no-op, we can bail. If AnnotatePlan is set submit the
+	// plan anyways to get the annotations.
+	if s.plan.IsNoOp() && !s.eval.AnnotatePlan {
 		return true, nil
 	}
 
@@ -181,7 +183,29 @@ func (s *SystemScheduler) computeJobAllocs() error {
 	}
 
 	// Attempt to do the upgrades in place
-	diff.update = inplaceUpdate(s.ctx, s.eval, s.job, s.stack, diff.update)
+	destructiveUpdates, inplaceUpdates := inplaceUpdate(s.ctx, s.eval, s.job, s.stack, diff.update)
+
+	// The allocations on nodes that are not ready, such as ineligible or
+	// draining nodes, can not be replaced and are left untouched
+	ready := make(map[string]struct{}, len(s.nodes))
+	for _, node := range s.nodes {
+		ready[node.ID] = struct{}{}
+	}
+	diff.update = nil
+	for _, tuple := range destructiveUpdates {
+		if _, ok := ready[tuple.Alloc.NodeID]; !ok {
+			diff.ignore = append(diff.ignore, tuple)
+			continue
+		}
+		diff.update = append(diff.update, tuple)
+	}
+	destructiveUpdates = diff.update
+
+	if s.eval.AnnotatePlan {
+		s.plan.Annotations = &structs.PlanAnnotations{
+			DesiredTGUpdates: desiredUpdates(diff, inplaceUpdates, destructiveUpdates),
+		}
+	}
 
 	// Check if a rolling upgrade strategy is being used
 	limit := len(diff.update)
@@ -9,11 +9,13 @@ import (
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
-// allocTuple is a tuple of the allocation name and potential alloc ID
+// allocTuple is a tuple of the allocation name and potential alloc ID. Canary
+// is set for placements that are canaries of a deployment.
 type allocTuple struct {
 	Name      string
 	TaskGroup *structs.TaskGroup
 	Alloc     *structs.Allocation
+	Canary    bool
 }
 
 // materializeTaskGroups is used to materialize all the task groups
@@ -81,8 +83,9 @@ func diffAllocs(job *structs.Job, taintedNodes map[string]bool,
 			continue
 		}
 
-		// If we are on a tainted node, we must migrate
-		if taintedNodes[exist.NodeID] {
+		// If we are on a tainted node or the drainer marked the alloc, we
+		// must migrate
+		if taintedNodes[exist.NodeID] || exist.DesiredTransition.Migrate {
 			result.migrate = append(result.migrate, allocTuple{
 				Name:      name,
 				TaskGroup: tg,
@@ -172,7 +175,9 @@ func diffSystemAllocs(job *structs.Job, nodes []*structs.Node, taintedNodes map[
 	return result
 }
 
-// readyNodesInDCs returns all the ready nodes in the given datacenters
+// readyNodesInDCs returns all the ready nodes in the given datacenters that
+// are eligible for new placements. The existing allocations on ineligible nodes
+// are left untouched.
 func readyNodesInDCs(state State, dcs []string) ([]*structs.Node, error) {
 	// Index the DCs
 	dcMap := make(map[string]struct{}, len(dcs))
@@ -200,6 +205,9 @@ func readyNodesInDCs(state State, dcs []string) ([]*structs.Node, error) {
 		if node.Drain {
 			continue
 		}
+		if node.SchedulingEligibility == structs.NodeSchedulingIneligible {
+			continue
+		}
 		if _, ok := dcMap[node.Datacenter]; !ok {
 			continue
 		}
@@ -230,6 +238,8 @@ func retryMax(max int, cb func() (bool, error)) error {
 
 // taintedNodes is used to scan the allocations and then check if the
 // underlying nodes are tainted, and should force a migration of the allocation.
+// Draining nodes are not tainted as the drainer marks their allocations to be
+// migrated at the pace of the migrate strategy.
 func taintedNodes(state State, allocs []*structs.Allocation) (map[string]bool, error) {
 	out := make(map[string]bool)
 	for _, alloc := range allocs {
@@ -248,7 +258,7 @@ func taintedNodes(state State, allocs []*structs.Allocation) (map[string]bool, e
 			continue
 		}
 
-		out[alloc.NodeID] = structs.ShouldDrainNode(node.Status) || node.Drain
+		out[alloc.NodeID] = structs.ShouldDrainNode(node.Status)
 	}
 	return out, nil
 }
@@ -270,6 +280,16 @@ func tasksUpdated(a, b *structs.TaskGroup) bool {
 		return true
 	}
 
+	// The size of the ephemeral disk is reserved when placing the allocation
+	if ephemeralDiskSize(a) != ephemeralDiskSize(b) {
+		return true
+	}
+
+	// The volumes may require a different node
+	if !reflect.DeepEqual(a.Volumes, b.Volumes) {
+		return true
+	}
+
 	// Check each task
 	for _, at := range a.Tasks {
 		bt := b.LookupTask(at.Name)
@@ -285,6 +305,21 @@ func tasksUpdated(a, b *structs.TaskGroup) bool {
 		if !reflect.DeepEqual(at.Env, bt.Env) {
 			return true
 		}
+		if !reflect.DeepEqual(at.Artifacts, bt.Artifacts) {
+			return true
+		}
+		if !reflect.DeepEqual(at.Templates, bt.Templates) {
+			return true
+		}
+		if !reflect.DeepEqual(at.Lifecycle, bt.Lifecycle) {
+			return true
+		}
+		if !reflect.DeepEqual(at.LogConfig, bt.LogConfig) {
+			return true
+		}
+		if !reflect.DeepEqual(at.VolumeMounts, bt.VolumeMounts) {
+			return true
+		}
 
 		// Inspect the network to see if the dynamic ports are different
 		if len(at.Resources.Networks) != len(bt.Resources.Networks) {
@@ -301,6 +336,30 @@ func tasksUpdated(a, b *structs.TaskGroup) bool {
 	return false
 }
 
+// ephemeralDiskSize returns the size of the ephemeral disk of the task group or
+// zero if it has none
+func ephemeralDiskSize(tg *structs.TaskGroup) int {
+	if tg.EphemeralDisk == nil {
+		return 0
+	}
+	return tg.EphemeralDisk.SizeMB
+}
+
+// stickyNode returns the node of the allocation replaced by the placement if
+// the ephemeral disk of the task group is sticky and the node is among the
+// given ready nodes
+func stickyNode(place allocTuple, nodes []*structs.Node) *structs.Node {
+	if place.Alloc == nil || place.TaskGroup.EphemeralDisk == nil || !place.TaskGroup.EphemeralDisk.Sticky {
+		return nil
+	}
+	for _, node := range nodes {
+		if node.ID == place.Alloc.NodeID {
+			return node
+		}
+	}
+	return nil
+}
+
 // setStatus is used to update the status of the evaluation
 func setStatus(logger *log.Logger, planner Planner, eval, nextEval *structs.Evaluation, status, desc string) error {
 	logger.Printf("[DEBUG] sched: %#v: setting status to %s", eval, status)
@@ -313,12 +372,13 @@ func setStatus(logger *log.Logger, planner Planner, eval, nextEval *structs.Eval
 	return planner.UpdateEval(newEval)
 }
 
-// inplaceUpdate attempts to update allocations in-place where possible.
+// inplaceUpdate attempts to update allocations in-place where possible. It
+// returns the allocs that couldn't be done inplace and then those that could.
 func inplaceUpdate(ctx Context, eval *structs.Evaluation, job *structs.Job,
-	stack Stack, updates []allocTuple) []allocTuple {
+	stack Stack, updates []allocTuple) (destructive, inplace []allocTuple) {
 
 	n := len(updates)
-	inplace := 0
+	inplaceCount := 0
 	for i := 0; i < n; i++ {
 		// Get the update
 		update := updates[i]
@@ -386,15 +446,67 @@ func inplaceUpdate(ctx Context, eval *structs.Evaluation, job *structs.Job,
 		ctx.Plan().AppendAlloc(newAlloc)
 
 		// Remove this allocation from the slice
+		inplace = append(inplace, update)
 		updates[i] = updates[n-1]
 		i--
 		n--
-		inplace++
+		inplaceCount++
 	}
 	if len(updates) > 0 {
-		ctx.Logger().Printf("[DEBUG] sched: %#v: %d in-place updates of %d", eval, inplace, len(updates))
+		ctx.Logger().Printf("[DEBUG] sched: %#v: %d in-place updates of %d", eval, inplaceCount, len(updates))
+	}
+	return updates[:n], inplace
+}
+
+// desiredUpdates takes the diffResult as well as the set of inplace and
+// destructive updates and returns a map of task groups to their set of
+// desired updates.
+func desiredUpdates(diff *diffResult, inplaceUpdates,
+	destructiveUpdates []allocTuple) map[string]*structs.DesiredUpdates {
+	desiredTgs := make(map[string]*structs.DesiredUpdates)
+
+	// desired returns the desired updates of the named task group, creating
+	// them if necessary.
+	desired := func(name string) *structs.DesiredUpdates {
+		des, ok := desiredTgs[name]
+		if !ok {
+			des = &structs.DesiredUpdates{}
+			desiredTgs[name] = des
+		}
+		return des
+	}
+
+	for _, tuple := range diff.place {
+		if tuple.Canary {
+			desired(tuple.TaskGroup.Name).Canary++
+			continue
+		}
+		desired(tuple.TaskGroup.Name).Place++
 	}
-	return updates[:n]
+
+	// Allocations being stopped may belong to a task group that no longer
+	// exists, so use the name stored on the allocation.
+	for _, tuple := range diff.stop {
+		desired(tuple.Alloc.TaskGroup).Stop++
+	}
+
+	for _, tuple := range diff.ignore {
+		desired(tuple.TaskGroup.Name).Ignore++
+	}
+
+	for _, tuple := range diff.migrate {
+		desired(tuple.TaskGroup.Name).Migrate++
+	}
+
+	for _, tuple := range inplaceUpdates {
+		desired(tuple.TaskGroup.Name).InPlaceUpdate++
+	}
+
+	for _, tuple := range destructiveUpdates {
+		desired(tuple.TaskGroup.Name).DestructiveUpdate++
+	}
+
+	return desiredTgs
 }
 
 // evictAndPlace is used to mark allocations for evicts and add them to the
@@ -442,6 +554,7 @@ func taskGroupConstraints(tg *structs.TaskGroup) tgConstrainTuple {
 		c.constraints = append(c.constraints, task.Constraints...)
 		c.size.Add(task.Resources)
 	}
+	c.size.DiskMB += ephemeralDiskSize(tg)
 
 	return c
 }
@@ -75,6 +75,17 @@ func TestDiffAllocs(t *testing.T) {
 			NodeID: "dead",
 			Name:   "my-job.web[2]",
 		},
+
+		// Migrate the 4th marked by the drainer
+		&structs.Allocation{
+			ID:     structs.GenerateUUID(),
+			NodeID: "zip",
+			Name:   "my-job.web[3]",
+			Job:    job,
+			DesiredTransition: structs.DesiredTransition{
+				Migrate: true,
+			},
+		},
 	}
 
 	diff := diffAllocs(job, tainted, required, allocs)
@@ -99,13 +110,13 @@ func TestDiffAllocs(t *testing.T) {
 		t.Fatalf("bad: %#v", stop)
 	}
 
-	// We should migrate the 4rd alloc
-	if len(migrate) != 1 || migrate[0].Alloc != allocs[3] {
+	// We should migrate the 4rd and 5th alloc
+	if len(migrate) != 2 || migrate[0].Alloc != allocs[3] || migrate[1].Alloc != allocs[4] {
 		t.Fatalf("bad: %#v", migrate)
 	}
 
-	// We should place 7
-	if len(place) != 7 {
+	// We should place 6
+	if len(place) != 6 {
 		t.Fatalf("bad: %#v", place)
 	}
 }
@@ -198,11 +209,14 @@ func TestReadyNodesInDCs(t *testing.T) {
 	node3.Status = structs.NodeStatusDown
 	node4 := mock.Node()
 	node4.Drain = true
+	node5 := mock.Node()
+	node5.SchedulingEligibility = structs.NodeSchedulingIneligible
 
 	noErr(t, state.UpsertNode(1000, node1))
 	noErr(t, state.UpsertNode(1001, node2))
 	noErr(t, state.UpsertNode(1002, node3))
 	noErr(t, state.UpsertNode(1003, node4))
+	noErr(t, state.UpsertNode(1004, node5))
 
 	nodes, err := readyNodesInDCs(state, []string{"dc1", "dc2"})
 	if err != nil {
@@ -212,8 +226,10 @@ func TestReadyNodesInDCs(t *testing.T) {
 	if len(nodes) != 2 {
 		t.Fatalf("bad: %v", nodes)
 	}
-	if nodes[0].ID == node3.ID || nodes[1].ID == node3.ID {
-		t.Fatalf("Bad: %#v", nodes)
+	for _, node := range nodes {
+		if node.ID != node1.ID && node.ID != node2.ID {
+			t.Fatalf("Bad: %#v", nodes)
+		}
 	}
 }
 
@@ -279,10 +295,10 @@ func TestTaintedNodes(t *testing.T) {
 	if len(tainted) != 5 {
 		t.Fatalf("bad: %v", tainted)
 	}
-	if tainted[node1.ID] || tainted[node2.ID] {
+	if tainted[node1.ID] || tainted[node2.ID] || tainted[node4.ID] {
 		t.Fatalf("Bad: %v", tainted)
 	}
-	if !tainted[node3.ID] || !tainted[node4.ID] || !tainted["blah"] {
+	if !tainted[node3.ID] || !tainted["blah"] {
 		t.Fatalf("Bad: %v", tainted)
 	}
 }
@@ -353,6 +369,54 @@ func TestTasksUpdated(t *testing.T) {
 	if !tasksUpdated(j1.TaskGroups[0], j7.TaskGroups[0]) {
 		t.Fatalf("bad")
 	}
+
+	j8 := mock.Job()
+	j8.TaskGroups[0].Tasks[0].Artifacts = []*structs.TaskArtifact{
+		&structs.TaskArtifact{GetterSource: "http://foo.com/bar.tar.gz"},
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j8.TaskGroups[0]) {
+		t.Fatalf("bad")
+	}
+
+	j9 := mock.Job()
+	j9.TaskGroups[0].Tasks[0].Templates = []*structs.Template{
+		&structs.Template{
+			EmbeddedTmpl: "{{ env \"NOMAD_IP\" }}",
+			DestPath:     "local/ip",
+			ChangeMode:   structs.TemplateChangeModeRestart,
+		},
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j9.TaskGroups[0]) {
+		t.Fatalf("bad")
+	}
+
+	j10 := mock.Job()
+	j10.TaskGroups[0].Tasks[0].Lifecycle = &structs.TaskLifecycleConfig{
+		Hook: structs.TaskLifecycleHookPrestart,
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j10.TaskGroups[0]) {
+		t.Fatalf("bad")
+	}
+
+	j11 := mock.Job()
+	j11.TaskGroups[0].Tasks[0].LogConfig = &structs.LogConfig{
+		MaxFiles:      3,
+		MaxFileSizeMB: 5,
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j11.TaskGroups[0]) {
+		t.Fatalf("bad")
+	}
+
+	j12 := mock.Job()
+	j12.TaskGroups[0].Volumes = map[string]*structs.VolumeRequest{
+		"data": &structs.VolumeRequest{Name: "data", Type: structs.VolumeTypeHost, Source: "mysql"},
+	}
+	j12.TaskGroups[0].Tasks[0].VolumeMounts = []*structs.VolumeMount{
+		&structs.VolumeMount{Volume: "data", Destination: "/srv/data"},
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j12.TaskGroups[0]) {
+		t.Fatalf("bad")
+	}
 }
 
 func TestEvictAndPlace_LimitLessThanAllocs(t *testing.T) {
@@ -473,9 +537,9 @@ func TestInplaceUpdate_ChangedTaskGroup(t *testing.T) {
 	stack := NewGenericStack(false, ctx)
 
 	// Do the inplace update.
-	unplaced := inplaceUpdate(ctx, eval, job, stack, updates)
+	unplaced, inplace := inplaceUpdate(ctx, eval, job, stack, updates)
 
-	if len(unplaced) != 1 {
+	if len(unplaced) != 1 || len(inplace) != 0 {
 		t.Fatal("inplaceUpdate incorrectly did an inplace update")
 	}
 
@@ -518,9 +582,9 @@ func TestInplaceUpdate_NoMatch(t *testing.T) {
 	stack := NewGenericStack(false, ctx)
 
 	// Do the inplace update.
-	unplaced := inplaceUpdate(ctx, eval, job, stack, updates)
+	unplaced, inplace := inplaceUpdate(ctx, eval, job, stack, updates)
 
-	if len(unplaced) != 1 {
+	if len(unplaced) != 1 || len(inplace) != 0 {
 		t.Fatal("inplaceUpdate incorrectly did an inplace update")
 	}
 
@@ -564,9 +628,9 @@ func TestInplaceUpdate_Success(t *testing.T) {
 	stack.SetJob(job)
 
 	// Do the inplace update.
-	unplaced := inplaceUpdate(ctx, eval, job, stack, updates)
+	unplaced, inplace := inplaceUpdate(ctx, eval, job, stack, updates)
 
-	if len(unplaced) != 0 {
+	if len(unplaced) != 0 || len(inplace) != 1 {
 		t.Fatal("inplaceUpdate did not do an inplace update")
 	}
 